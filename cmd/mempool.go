@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/stackup-wallet/stackup-bundler/internal/start"
+	"github.com/stackup-wallet/stackup-bundler/pkg/snapshot"
+)
+
+var mempoolCmd = &cobra.Command{
+	Use:   "mempool",
+	Short: "Exports or imports a snapshot of the mempool",
+	Long: `The mempool command moves pending UserOperations between bundler instances or storage backends.
+	
+	Snapshots are written as a single JSON document (json) or a stream of length delimited protobuf messages
+	(proto). With the badger backend, the bundler must not be running against the same data directory.`,
+}
+
+var mempoolExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Writes all pending UserOperations to a snapshot file",
+	Run: func(cmd *cobra.Command, args []string) {
+		start.ExportMempool(snapshotFile, snapshotFormat)
+	},
+}
+
+var mempoolImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Loads pending UserOperations from a snapshot file",
+	Run: func(cmd *cobra.Command, args []string) {
+		start.ImportMempool(snapshotFile, snapshotFormat)
+	},
+}
+
+var (
+	snapshotFile   string
+	snapshotFormat string
+)
+
+func init() {
+	rootCmd.AddCommand(mempoolCmd)
+	for _, c := range []*cobra.Command{mempoolExportCmd, mempoolImportCmd} {
+		mempoolCmd.AddCommand(c)
+		c.Flags().StringVarP(&snapshotFile, "file", "f", "", "Required. Path of the snapshot file.")
+		c.Flags().StringVar(&snapshotFormat, "format", snapshot.FormatJSON, "Snapshot format, json or proto.")
+		if err := c.MarkFlagRequired("file"); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/stackup-wallet/stackup-bundler/internal/start"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backup"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restores the data directory from a backup",
+	Long: `The restore command loads a backup written by a running bundler into the data directory.
+	
+	Backups are read from the location set by erc4337_bundler_backup_url. The bundler must not be running
+	against the same data directory while a restore is in progress.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		start.RestoreBackup(backupName)
+	},
+}
+
+var backupName string
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+	restoreCmd.Flags().StringVarP(&backupName, "name", "n", backup.LatestName, "Name of the backup to restore.")
+}
@@ -14,12 +14,15 @@ var startCmd = &cobra.Command{
 	Long: `The start command has the following modes:
 	
 	1. private: A bundler backed by a private mempool and compatible with all EVM networks.
-	2. searcher: A bundler backed by the P2P mempool and integrated with a Block Builder API.`,
+	2. searcher: A bundler backed by the P2P mempool and integrated with a Block Builder API.
+	3. readonly: An RPC node that only serves read methods and redirects writes to a primary bundler.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if viper.GetString("mode") == "private" {
 			start.PrivateMode()
 		} else if viper.GetString("mode") == "searcher" {
 			start.SearcherMode()
+		} else if viper.GetString("mode") == "readonly" {
+			start.ReadOnlyMode()
 		} else {
 			panic(fmt.Sprintf("Fatal flag error: \"%s\" mode not supported", viper.GetString("mode")))
 		}
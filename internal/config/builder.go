@@ -0,0 +1,24 @@
+package config
+
+import "strings"
+
+const (
+	// BuilderFormatFlashbots sends signed eth_sendBundle requests. This is the default for builders without a
+	// format.
+	BuilderFormatFlashbots = "flashbots"
+
+	// BuilderFormatBloxroute sends blxr_submit_bundle requests.
+	BuilderFormatBloxroute = "bloxroute"
+
+	// BuilderFormatTitan sends unsigned eth_sendBundle requests.
+	BuilderFormatTitan = "titan"
+
+	// BuilderFormatRawTx sends each transaction with eth_sendRawTransaction.
+	BuilderFormatRawTx = "rawtx"
+)
+
+// SplitBuilderFormat returns the format and optional auth value from an entry in the form "format:auth".
+func SplitBuilderFormat(val string) (string, string) {
+	format, auth, _ := strings.Cut(strings.TrimSpace(val), ":")
+	return format, auth
+}
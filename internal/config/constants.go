@@ -6,6 +6,10 @@ import (
 	mapset "github.com/deckarep/golang-set/v2"
 )
 
+// MaxReliableEntityGasDiscountPercent is the upper bound on the minimum gas price discount given to reliable
+// entities. This keeps the discount small enough that underpriced ops cannot crowd out a batch.
+const MaxReliableEntityGasDiscountPercent = 25
+
 var (
 	EthereumChainID        = big.NewInt(1)
 	GoerliChainID          = big.NewInt(5)
@@ -0,0 +1,62 @@
+package config
+
+const (
+	// ChainMismatchFail exits on startup if the network is not compatible with the selected mode.
+	ChainMismatchFail = "fail"
+
+	// ChainMismatchDegrade continues in the selected mode but disables incompatible features. In searcher mode,
+	// batches are sent as regular transactions instead of through the Block Builder API.
+	ChainMismatchDegrade = "degrade"
+
+	// ChainMismatchAuto switches to a mode that is compatible with the network.
+	ChainMismatchAuto = "auto"
+)
+
+const (
+	// TxTypeAuto uses EIP-1559 transactions if the network supports them and legacy transactions otherwise.
+	TxTypeAuto = "auto"
+
+	// TxTypeLegacy always uses legacy gas pricing for bundle transactions.
+	TxTypeLegacy = "legacy"
+
+	// TxTypeDynamic always uses EIP-1559 gas pricing for bundle transactions.
+	TxTypeDynamic = "dynamic"
+)
+
+const (
+	// ProfitModelAuto uses the basefee rebate model on networks with an effectively zero priority fee and the
+	// priority fee model otherwise.
+	ProfitModelAuto = "auto"
+
+	// ProfitModelPriorityFee requires UserOperations to cover both the basefee and the priority fee.
+	ProfitModelPriorityFee = "priority_fee"
+
+	// ProfitModelBaseFeeRebate only requires UserOperations to cover the basefee.
+	ProfitModelBaseFeeRebate = "base_fee_rebate"
+)
+
+const (
+	// ValidationProfileTracer validates UserOperations with the JS BundlerCollectorTracer or a native tracer if
+	// one is set.
+	ValidationProfileTracer = "tracer"
+
+	// ValidationProfileStructLog validates UserOperations by parsing output from the built-in structLogger in
+	// Go. It is slower but works on nodes without custom tracer support.
+	ValidationProfileStructLog = "structlog"
+)
+
+const (
+	// SearcherRoleAll runs both the client and the bundler in a single searcher process.
+	SearcherRoleAll = "all"
+
+	// SearcherRoleIngest runs only the client. Validated UserOperations are handed off to a bundle back-end.
+	SearcherRoleIngest = "ingest"
+
+	// SearcherRoleBundle runs the bundler and accepts UserOperations handed off from ingest front-ends.
+	SearcherRoleBundle = "bundle"
+)
+
+const (
+	// MinAdminRpcTokenLength is the minimum length of the bearer token required for the admin namespace.
+	MinAdminRpcTokenLength = 32
+)
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"net/url"
+
+	"github.com/stackup-wallet/stackup-bundler/pkg/apikey"
+)
+
+const redacted = "[redacted]"
+
+// redactUrl strips everything but the scheme and host from a URL since node providers commonly embed API keys
+// in the path or query.
+func redactUrl(s string) string {
+	if s == "" {
+		return s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return redacted
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func redactUrls(s []string) []string {
+	out := []string{}
+	for _, v := range s {
+		out = append(out, redactUrl(v))
+	}
+	return out
+}
+
+// Redacted returns a copy of the Values that is safe to expose to operators. Secrets are removed and URLs are
+// reduced to their host.
+func (v *Values) Redacted() *Values {
+	r := *v
+	if r.PrivateKey != "" {
+		r.PrivateKey = redacted
+	}
+	if r.AdminRpcToken != "" {
+		r.AdminRpcToken = redacted
+	}
+	if r.BackupS3SecretKey != "" {
+		r.BackupS3SecretKey = redacted
+	}
+	apiKeys := map[string]*apikey.Key{}
+	for _, k := range r.ApiKeys {
+		apiKeys[redacted+":"+k.Name] = k
+	}
+	r.ApiKeys = apiKeys
+	headers := map[string]string{}
+	for k := range r.OTELCollectorHeaders {
+		headers[k] = redacted
+	}
+	r.OTELCollectorHeaders = headers
+	r.EthClientUrl = redactUrl(r.EthClientUrl)
+	r.EthBundleSimulationUrl = redactUrl(r.EthBundleSimulationUrl)
+	r.SigningApprovalUrl = redactUrl(r.SigningApprovalUrl)
+	r.PaymasterServiceUrl = redactUrl(r.PaymasterServiceUrl)
+	r.RiskServiceUrl = redactUrl(r.RiskServiceUrl)
+	r.ChainHeadWsUrl = redactUrl(r.ChainHeadWsUrl)
+	r.EthBuilderUrls = redactUrls(r.EthBuilderUrls)
+	formats := []string{}
+	for _, val := range r.EthBuilderFormats {
+		if format, auth := SplitBuilderFormat(val); auth != "" {
+			val = format + ":" + redacted
+		}
+		formats = append(formats, val)
+	}
+	r.EthBuilderFormats = formats
+	r.WarmUpPeerUrls = redactUrls(r.WarmUpPeerUrls)
+	return &r
+}
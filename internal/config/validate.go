@@ -0,0 +1,98 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/utils"
+)
+
+// problems collects every config error found during validation so that they can all be reported at once
+// rather than requiring a restart to discover each one.
+type problems []string
+
+func (p *problems) add(key string, format string, a ...any) {
+	*p = append(*p, fmt.Sprintf("%s: %s", key, fmt.Sprintf(format, a...)))
+}
+
+func (p problems) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "found %d problem(s)", len(p))
+	for _, msg := range p {
+		fmt.Fprintf(&sb, "\n  - %s", msg)
+	}
+	return sb.String()
+}
+
+// panicIfAny stops the bundler from starting with a single error listing all problems.
+func (p problems) panicIfAny() {
+	if len(p) > 0 {
+		panic(fmt.Sprintf("Fatal config error: %s", p))
+	}
+}
+
+func probeTracer(rpc *rpc.Client, tracer string) error {
+	var res json.RawMessage
+	req := utils.TraceCallReq{
+		From: common.HexToAddress("0x"),
+		To:   common.HexToAddress("0x"),
+	}
+	opts := utils.TraceCallOpts{Tracer: tracer}
+	return rpc.CallContext(context.Background(), &res, "debug_traceCall", &req, "latest", &opts)
+}
+
+func probeStructLogger(rpc *rpc.Client) error {
+	var res json.RawMessage
+	req := utils.TraceCallReq{
+		From: common.HexToAddress("0x"),
+		To:   common.HexToAddress("0x"),
+	}
+	opts := utils.StructLogOpts{EnableMemory: true}
+	return rpc.CallContext(context.Background(), &res, "debug_traceCall", &req, "latest", &opts)
+}
+
+// CheckNodeCapabilities cross-checks the config against the features supported by the connected Ethereum
+// node. All problems are reported in a single error.
+func CheckNodeCapabilities(rpc *rpc.Client, conf *Values) error {
+	var p problems
+	if conf.ValidationProfile == ValidationProfileStructLog {
+		if err := probeStructLogger(rpc); err != nil {
+			p.add(
+				"erc4337_bundler_eth_client_url",
+				"node does not support debug_traceCall with the structLogger: %s",
+				err,
+			)
+			return fmt.Errorf("fatal node error: %s", p)
+		}
+		return nil
+	}
+
+	if err := probeTracer(rpc, "callTracer"); err != nil {
+		p.add(
+			"erc4337_bundler_eth_client_url",
+			"node does not support debug_traceCall which is required for validation: %s",
+			err,
+		)
+		return fmt.Errorf("fatal node error: %s", p)
+	}
+
+	if conf.NativeBundlerCollectorTracer != "" {
+		if err := probeTracer(rpc, conf.NativeBundlerCollectorTracer); err != nil {
+			p.add("erc4337_bundler_native_bundler_collector_tracer", "tracer not available on node: %s", err)
+		}
+	}
+	if conf.NativeBundlerExecutorTracer != "" {
+		if err := probeTracer(rpc, conf.NativeBundlerExecutorTracer); err != nil {
+			p.add("erc4337_bundler_native_bundler_executor_tracer", "tracer not available on node: %s", err)
+		}
+	}
+
+	if len(p) > 0 {
+		return fmt.Errorf("fatal node error: %s", p)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+)
+
+func dialMock(t *testing.T, mocks testutils.MethodMocks) *rpc.Client {
+	srv := testutils.RpcMock(mocks)
+	t.Cleanup(srv.Close)
+	c, err := rpc.Dial(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+// TestCheckNodeCapabilities verifies that startup fails with the config key to fix if the node does not
+// support debug_traceCall.
+func TestCheckNodeCapabilities(t *testing.T) {
+	conf := &Values{NativeBundlerCollectorTracer: "bundlerCollectorTracer"}
+	ok := dialMock(t, testutils.MethodMocks{"debug_traceCall": map[string]any{}})
+	if err := CheckNodeCapabilities(ok, conf); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	err := CheckNodeCapabilities(dialMock(t, testutils.MethodMocks{}), conf)
+	if err == nil || !strings.Contains(err.Error(), "erc4337_bundler_eth_client_url") {
+		t.Fatalf("got %v, want error for erc4337_bundler_eth_client_url", err)
+	}
+
+	conf.ValidationProfile = ValidationProfileStructLog
+	err = CheckNodeCapabilities(dialMock(t, testutils.MethodMocks{}), conf)
+	if err == nil || !strings.Contains(err.Error(), "structLogger") {
+		t.Fatalf("got %v, want structLogger error", err)
+	}
+}
@@ -3,13 +3,23 @@ package config
 import (
 	"fmt"
 	"math/big"
+	"net"
+	"net/url"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
+	"github.com/stackup-wallet/stackup-bundler/pkg/apikey"
+	"github.com/stackup-wallet/stackup-bundler/pkg/delegate"
+	"github.com/stackup-wallet/stackup-bundler/pkg/fingerprint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/ipguard"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
+	"github.com/stackup-wallet/stackup-bundler/pkg/passkey"
+	"github.com/stackup-wallet/stackup-bundler/pkg/ratelimit"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
 )
 
@@ -22,16 +32,91 @@ type Values struct {
 	SupportedEntryPoints         []common.Address
 	MaxVerificationGas           *big.Int
 	MaxBatchGasLimit             *big.Int
+	MaxCallGasLimit              *big.Int
+	MaxOpGas                     *big.Int
+	MinPriorityFeePaymaster      *big.Int
+	MinPriorityFeeInitCode       *big.Int
+	MinPriorityFeeSender         *big.Int
+	ReplacementFeeBump           int64
 	MaxOpTTL                     time.Duration
 	OpLookupLimit                uint64
+	OpStatusRetention            time.Duration
 	Beneficiary                  string
 	NativeBundlerCollectorTracer string
 	NativeBundlerExecutorTracer  string
+	ValidationProfile            string
 	ReputationConstants          *entities.ReputationConstants
+	DeterministicMode            bool
+	DeterministicSeed            []byte
+	BanReviewCooldown            time.Duration
+	ReputationBufferSize         int
+	ReputationFlushInterval      time.Duration
+	PaymasterStakeGracePeriod    time.Duration
+	DataDirSoftLimit             int64
+	DataDirHardLimit             int64
+	DataDirCheckInterval         time.Duration
+	GasFeedbackEnabled           bool
+	GasFeedbackTarget            int
+	GasFeedbackMaxPadding        int
+	MaxRequestBodySize           int64
+	MaxCallDataSize              int
+	MaxInitCodeSize              int
+	WarmUpPeerUrls               []string
+	StuckTxTimeout               time.Duration
+	SigBanThreshold              int
+	SigBanWindow                 time.Duration
+	SigBanDuration               time.Duration
+	TrustedProxies               []string
+	EPFailureThreshold           int
+	EPRecoveryInterval           time.Duration
+	VGLSafetyMargin              int64
+	PasskeyVerifiers             []*passkey.Verifier
+	DelegatedRelayers            []*delegate.Relayer
+	ApiKeyAuthEnabled            bool
+	ApiKeys                      map[string]*apikey.Key
+	RateLimits                   ratelimit.Limits
+	ApiKeyRateLimits             ratelimit.Limits
+	RateLimitStore               string
+	HoldOpsDuringSync            bool
+	MaxHeldOps                   int
+	TxType                       string
+	ProfitModel                  string
+	SigningApprovalUrl           string
+	SigningApprovalTimeout       time.Duration
+	SafeModeRevertThreshold      int
+	SafeModeRecoveryBundles      int
+	ReliableEntityGasDiscount    *entities.GasPriceDiscount
+	AdminAddr                    string
+	GrpcAddr                     string
+	RestApiEnabled               bool
+	CorsAllowedOrigins           []string
+	CorsAllowedHeaders           []string
+	CorsAllowCredentials         bool
+	LogTailSize                  int
+	AdminRpcToken                string
+	BackupUrl                    string
+	BackupInterval               time.Duration
+	BackupS3Endpoint             string
+	BackupS3Region               string
+	BackupS3AccessKey            string
+	BackupS3SecretKey            string
+	DashboardAddr                string
+	DashboardInterval            time.Duration
+	AccountFingerprintEnabled    bool
+	AccountFingerprints          *fingerprint.Known
 
 	// Searcher mode variables.
-	EthBuilderUrls    []string
-	BlocksInTheFuture int
+	EthBuilderUrls            []string
+	EthBuilderFormats         []string
+	BlocksInTheFuture         int
+	ChainMismatchPolicy       string
+	BeneficiaryPayoutCallData []byte
+	BeneficiaryPayoutGasLimit uint64
+	PresignedTemplates        int
+	EthBundleSimulationUrl    string
+	SearcherRole              string
+	BundleBackendUrl          string
+	HandoffDictionary         [][]byte
 
 	// Observability variables.
 	OTELServiceName      string
@@ -43,10 +128,96 @@ type Values struct {
 	AltMempoolIPFSGateway string
 	AltMempoolIds         []string
 
+	// Read replica variables.
+	ReplicaExportEnabled bool
+	ReplicaPrimaryUrl    string
+	ReplicaSyncInterval  time.Duration
+
+	// Federation variables.
+	FederationRegistryUrl string
+	FederationPublicUrl   string
+	FederationInterval    time.Duration
+
+	// Policy script variables.
+	PolicyScript  []string
+	PolicyTimeout time.Duration
+
+	// Prometheus variables.
+	PrometheusEnabled bool
+
+	// Risk service variables.
+	RiskServiceUrl      string
+	RiskServiceTimeout  time.Duration
+	RiskServiceFailOpen bool
+	RiskServiceMaxScore float64
+
+	// Orderflow auction variables.
+	AuctionUrl           string
+	AuctionTimeout       time.Duration
+	AuctionRefundPercent int
+
+	// Mempool storage variables.
+	MempoolBackend      string
+	MempoolRedisUrl     string
+	MempoolRedisPrefix  string
+	MempoolPostgresUrl  string
+	MempoolSqlitePath   string
+	MempoolSyncInterval time.Duration
+	MempoolLeaseTTL     time.Duration
+
+	// Known factory variables.
+	KnownFactories              []common.Address
+	KnownFactoriesFeedUrl       string
+	KnownFactoriesInterval      time.Duration
+	UnknownFactoryMaxPendingOps int
+	UnknownFactoryMaxVGL        *big.Int
+	UnknownFactoryMaxPerBatch   int
+
+	// Pending op limit variables.
+	MaxPendingOpsPerSender    int
+	MaxPendingOpsPerPaymaster int
+
+	// IP guard variables.
+	IpReputationThreshold float64
+	IpReputationHalfLife  time.Duration
+	IpAsnDatabase         string
+	IpCountryHeader       string
+	IpPolicies            []*ipguard.Policy
+
+	// Chain head variables.
+	ChainHeadWsUrl        string
+	ChainHeadPollInterval time.Duration
+
+	// Health check variables.
+	HealthCheckTimeout time.Duration
+	HealthMinBalance   *big.Int
+
+	// Paymaster service variables.
+	PaymasterServiceUrl     string
+	PaymasterServiceTimeout time.Duration
+
+	// HTTP server variables.
+	HTTPReadTimeout           time.Duration
+	HTTPWriteTimeout          time.Duration
+	HTTPIdleTimeout           time.Duration
+	HTTPMaxHeaderBytes        int
+	HTTP2Enabled              bool
+	HTTP2MaxConcurrentStreams uint32
+
+	// Adaptive batch size variables.
+	AdaptiveMaxOps           int
+	AdaptiveMinOps           int
+	AdaptiveTargetSimLatency time.Duration
+	AdaptiveDeadline         time.Duration
+
+	// Shadow mode variables.
+	ShadowAltMempoolIds []string
+
 	// Rollup related variables.
 	IsOpStackNetwork   bool
 	IsRIP7212Supported bool
 	IsArbStackNetwork  bool
+	PvgBoundsBlocks    uint64
 
 	// Undocumented variables.
 	DebugMode     bool
@@ -83,6 +254,12 @@ func envArrayToStringSlice(s string) []string {
 	return strings.Split(s, ",")
 }
 
+// isSharedMempoolBackend returns true if the mempool backend can be shared by multiple bundler processes and
+// needs to be synced and leased.
+func isSharedMempoolBackend(backend string) bool {
+	return backend == "redis" || backend == "postgres"
+}
+
 func variableNotSetOrIsNil(env string) bool {
 	return !viper.IsSet(env) || viper.GetString(env) == ""
 }
@@ -96,13 +273,105 @@ func GetValues() *Values {
 	viper.SetDefault("erc4337_bundler_supported_entry_points", "0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789")
 	viper.SetDefault("erc4337_bundler_max_verification_gas", 6000000)
 	viper.SetDefault("erc4337_bundler_max_batch_gas_limit", 18000000)
+	viper.SetDefault("erc4337_bundler_max_call_gas_limit", 0)
+	viper.SetDefault("erc4337_bundler_max_op_gas", 0)
+	viper.SetDefault("erc4337_bundler_min_priority_fee_paymaster", 0)
+	viper.SetDefault("erc4337_bundler_min_priority_fee_init_code", 0)
+	viper.SetDefault("erc4337_bundler_min_priority_fee_sender", 0)
+	viper.SetDefault("erc4337_bundler_replacement_fee_bump_percent", 10)
 	viper.SetDefault("erc4337_bundler_max_op_ttl_seconds", 180)
 	viper.SetDefault("erc4337_bundler_op_lookup_limit", 2000)
+	viper.SetDefault("erc4337_bundler_op_status_retention_seconds", 604800)
+	viper.SetDefault("erc4337_bundler_deterministic_mode", false)
+	viper.SetDefault("erc4337_bundler_validation_profile", ValidationProfileTracer)
+	viper.SetDefault("erc4337_bundler_api_key_auth_enabled", false)
+	viper.SetDefault("erc4337_bundler_rate_limit_store", "memory")
+	viper.SetDefault("erc4337_bundler_deterministic_seed", "0x")
+	viper.SetDefault("erc4337_bundler_ban_review_cooldown_seconds", 0)
+	viper.SetDefault("erc4337_bundler_reputation_buffer_size", 0)
+	viper.SetDefault("erc4337_bundler_reputation_flush_interval_seconds", 5)
+	viper.SetDefault("erc4337_bundler_paymaster_stake_grace_period_seconds", 600)
+	viper.SetDefault("erc4337_bundler_data_dir_soft_limit_mb", 0)
+	viper.SetDefault("erc4337_bundler_data_dir_hard_limit_mb", 0)
+	viper.SetDefault("erc4337_bundler_data_dir_check_interval_seconds", 60)
+	viper.SetDefault("erc4337_bundler_gas_feedback_enabled", false)
+	viper.SetDefault("erc4337_bundler_gas_feedback_target_percent", 90)
+	viper.SetDefault("erc4337_bundler_gas_feedback_max_padding_percent", 50)
+	viper.SetDefault("erc4337_bundler_max_request_body_bytes", 0)
+	viper.SetDefault("erc4337_bundler_max_calldata_bytes", 0)
+	viper.SetDefault("erc4337_bundler_max_initcode_bytes", 0)
+	viper.SetDefault("erc4337_bundler_stuck_tx_timeout_seconds", 120)
+	viper.SetDefault("erc4337_bundler_sig_ban_threshold", 0)
+	viper.SetDefault("erc4337_bundler_sig_ban_window_seconds", 600)
+	viper.SetDefault("erc4337_bundler_sig_ban_duration_seconds", 3600)
+	viper.SetDefault("erc4337_bundler_ep_failure_threshold", 3)
+	viper.SetDefault("erc4337_bundler_ep_recovery_interval_seconds", 60)
+	viper.SetDefault("erc4337_bundler_vgl_safety_margin", 25)
+	viper.SetDefault("erc4337_bundler_hold_ops_during_sync", false)
+	viper.SetDefault("erc4337_bundler_max_held_ops", 1000)
+	viper.SetDefault("erc4337_bundler_tx_type", TxTypeAuto)
+	viper.SetDefault("erc4337_bundler_profit_model", ProfitModelAuto)
+	viper.SetDefault("erc4337_bundler_dashboard_interval_seconds", 10)
+	viper.SetDefault("erc4337_bundler_account_fingerprint_enabled", false)
+	viper.SetDefault("erc4337_bundler_signing_approval_timeout_seconds", 30)
+	viper.SetDefault("erc4337_bundler_safe_mode_revert_threshold", 3)
+	viper.SetDefault("erc4337_bundler_safe_mode_recovery_bundles", 5)
+	viper.SetDefault("erc4337_bundler_reliable_entity_gas_discount_percent", 0)
+	viper.SetDefault("erc4337_bundler_reliable_entity_min_ops_included", 100)
+	viper.SetDefault("erc4337_bundler_reliable_entity_min_inclusion_percent", 95)
 	viper.SetDefault("erc4337_bundler_blocks_in_the_future", 6)
+	viper.SetDefault("erc4337_bundler_beneficiary_payout_gas_limit", 100000)
+	viper.SetDefault("erc4337_bundler_chain_mismatch_policy", ChainMismatchFail)
+	viper.SetDefault("erc4337_bundler_presigned_templates", 0)
+	viper.SetDefault("erc4337_bundler_searcher_role", SearcherRoleAll)
 	viper.SetDefault("erc4337_bundler_otel_insecure_mode", false)
+	viper.SetDefault("erc4337_bundler_replica_export_enabled", false)
+	viper.SetDefault("erc4337_bundler_replica_sync_interval_seconds", 5)
+	viper.SetDefault("erc4337_bundler_policy_timeout_ms", 500)
+	viper.SetDefault("erc4337_bundler_federation_interval_seconds", 30)
+	viper.SetDefault("erc4337_bundler_paymaster_service_timeout_ms", 10000)
+	viper.SetDefault("erc4337_bundler_risk_service_timeout_ms", 1000)
+	viper.SetDefault("erc4337_bundler_prometheus_enabled", false)
+	viper.SetDefault("erc4337_bundler_risk_service_fail_open", false)
+	viper.SetDefault("erc4337_bundler_risk_service_max_score", 0)
+	viper.SetDefault("erc4337_bundler_auction_timeout_ms", 200)
+	viper.SetDefault("erc4337_bundler_auction_refund_percent", 90)
+	viper.SetDefault("erc4337_bundler_mempool_backend", "badger")
+	viper.SetDefault("erc4337_bundler_mempool_redis_prefix", "mempool")
+	viper.SetDefault("erc4337_bundler_mempool_sync_interval_ms", 1000)
+	viper.SetDefault("erc4337_bundler_mempool_lease_ms", 10000)
+	viper.SetDefault("erc4337_bundler_known_factories_refresh_interval_seconds", 600)
+	viper.SetDefault("erc4337_bundler_unknown_factory_max_pending_ops", 0)
+	viper.SetDefault("erc4337_bundler_unknown_factory_max_verification_gas", 0)
+	viper.SetDefault("erc4337_bundler_unknown_factory_max_ops_per_batch", 0)
+	viper.SetDefault("erc4337_bundler_max_pending_ops_per_sender", 0)
+	viper.SetDefault("erc4337_bundler_max_pending_ops_per_paymaster", 0)
+	viper.SetDefault("erc4337_bundler_ip_reputation_threshold", 0)
+	viper.SetDefault("erc4337_bundler_ip_reputation_half_life_seconds", 600)
+	viper.SetDefault("erc4337_bundler_chain_head_poll_interval_ms", 1000)
+	viper.SetDefault("erc4337_bundler_health_check_timeout_ms", 2000)
+	viper.SetDefault("erc4337_bundler_health_min_balance", "0")
+	viper.SetDefault("erc4337_bundler_log_tail_size", 1000)
+	viper.SetDefault("erc4337_bundler_http_read_timeout_seconds", 0)
+	viper.SetDefault("erc4337_bundler_http_write_timeout_seconds", 0)
+	viper.SetDefault("erc4337_bundler_http_idle_timeout_seconds", 0)
+	viper.SetDefault("erc4337_bundler_http_max_header_bytes", 1<<20)
+	viper.SetDefault("erc4337_bundler_http2_enabled", false)
+	viper.SetDefault("erc4337_bundler_http2_max_concurrent_streams", 250)
+	viper.SetDefault("erc4337_bundler_adaptive_max_ops", 0)
+	viper.SetDefault("erc4337_bundler_adaptive_min_ops", 1)
+	viper.SetDefault("erc4337_bundler_adaptive_target_sim_latency_ms", 1000)
+	viper.SetDefault("erc4337_bundler_adaptive_deadline_ms", 12000)
+	viper.SetDefault("erc4337_bundler_backup_interval_seconds", 3600)
+	viper.SetDefault("erc4337_bundler_backup_s3_endpoint", "https://s3.amazonaws.com")
+	viper.SetDefault("erc4337_bundler_backup_s3_region", "us-east-1")
 	viper.SetDefault("erc4337_bundler_is_op_stack_network", false)
 	viper.SetDefault("erc4337_bundler_is_arb_stack_network", false)
 	viper.SetDefault("erc4337_bundler_is_rip7212_supported", false)
+	viper.SetDefault("erc4337_bundler_pvg_bounds_blocks", 3)
+	viper.SetDefault("erc4337_bundler_cors_allowed_origins", "*")
+	viper.SetDefault("erc4337_bundler_cors_allowed_headers", "Origin,Content-Length,Content-Type")
+	viper.SetDefault("erc4337_bundler_cors_allow_credentials", false)
 	viper.SetDefault("erc4337_bundler_debug_mode", false)
 	viper.SetDefault("erc4337_bundler_gin_mode", gin.ReleaseMode)
 
@@ -128,64 +397,786 @@ func GetValues() *Values {
 	_ = viper.BindEnv("erc4337_bundler_beneficiary")
 	_ = viper.BindEnv("erc4337_bundler_native_bundler_collector_tracer")
 	_ = viper.BindEnv("erc4337_bundler_native_bundler_executor_tracer")
+	_ = viper.BindEnv("erc4337_bundler_validation_profile")
 	_ = viper.BindEnv("erc4337_bundler_max_verification_gas")
 	_ = viper.BindEnv("erc4337_bundler_max_batch_gas_limit")
+	_ = viper.BindEnv("erc4337_bundler_max_call_gas_limit")
+	_ = viper.BindEnv("erc4337_bundler_max_op_gas")
+	_ = viper.BindEnv("erc4337_bundler_min_priority_fee_paymaster")
+	_ = viper.BindEnv("erc4337_bundler_min_priority_fee_init_code")
+	_ = viper.BindEnv("erc4337_bundler_min_priority_fee_sender")
+	_ = viper.BindEnv("erc4337_bundler_replacement_fee_bump_percent")
 	_ = viper.BindEnv("erc4337_bundler_max_op_ttl_seconds")
 	_ = viper.BindEnv("erc4337_bundler_op_lookup_limit")
+	_ = viper.BindEnv("erc4337_bundler_op_status_retention_seconds")
+	_ = viper.BindEnv("erc4337_bundler_deterministic_mode")
+	_ = viper.BindEnv("erc4337_bundler_deterministic_seed")
+	_ = viper.BindEnv("erc4337_bundler_ban_review_cooldown_seconds")
+	_ = viper.BindEnv("erc4337_bundler_reputation_buffer_size")
+	_ = viper.BindEnv("erc4337_bundler_reputation_flush_interval_seconds")
+	_ = viper.BindEnv("erc4337_bundler_paymaster_stake_grace_period_seconds")
+	_ = viper.BindEnv("erc4337_bundler_data_dir_soft_limit_mb")
+	_ = viper.BindEnv("erc4337_bundler_data_dir_hard_limit_mb")
+	_ = viper.BindEnv("erc4337_bundler_data_dir_check_interval_seconds")
+	_ = viper.BindEnv("erc4337_bundler_gas_feedback_enabled")
+	_ = viper.BindEnv("erc4337_bundler_gas_feedback_target_percent")
+	_ = viper.BindEnv("erc4337_bundler_gas_feedback_max_padding_percent")
+	_ = viper.BindEnv("erc4337_bundler_max_request_body_bytes")
+	_ = viper.BindEnv("erc4337_bundler_max_calldata_bytes")
+	_ = viper.BindEnv("erc4337_bundler_max_initcode_bytes")
+	_ = viper.BindEnv("erc4337_bundler_warm_up_peer_urls")
+	_ = viper.BindEnv("erc4337_bundler_stuck_tx_timeout_seconds")
+	_ = viper.BindEnv("erc4337_bundler_sig_ban_threshold")
+	_ = viper.BindEnv("erc4337_bundler_sig_ban_window_seconds")
+	_ = viper.BindEnv("erc4337_bundler_sig_ban_duration_seconds")
+	_ = viper.BindEnv("erc4337_bundler_trusted_proxies")
+	_ = viper.BindEnv("erc4337_bundler_ep_failure_threshold")
+	_ = viper.BindEnv("erc4337_bundler_ep_recovery_interval_seconds")
+	_ = viper.BindEnv("erc4337_bundler_vgl_safety_margin")
+	_ = viper.BindEnv("erc4337_bundler_passkey_verifiers")
+	_ = viper.BindEnv("erc4337_bundler_delegated_relayers")
+	_ = viper.BindEnv("erc4337_bundler_api_key_auth_enabled")
+	_ = viper.BindEnv("erc4337_bundler_api_keys")
+	_ = viper.BindEnv("erc4337_bundler_rate_limits")
+	_ = viper.BindEnv("erc4337_bundler_api_key_rate_limits")
+	_ = viper.BindEnv("erc4337_bundler_rate_limit_store")
+	_ = viper.BindEnv("erc4337_bundler_hold_ops_during_sync")
+	_ = viper.BindEnv("erc4337_bundler_max_held_ops")
+	_ = viper.BindEnv("erc4337_bundler_tx_type")
+	_ = viper.BindEnv("erc4337_bundler_profit_model")
+	_ = viper.BindEnv("erc4337_bundler_signing_approval_url")
+	_ = viper.BindEnv("erc4337_bundler_signing_approval_timeout_seconds")
+	_ = viper.BindEnv("erc4337_bundler_safe_mode_revert_threshold")
+	_ = viper.BindEnv("erc4337_bundler_safe_mode_recovery_bundles")
+	_ = viper.BindEnv("erc4337_bundler_reliable_entity_gas_discount_percent")
+	_ = viper.BindEnv("erc4337_bundler_reliable_entity_min_ops_included")
+	_ = viper.BindEnv("erc4337_bundler_reliable_entity_min_inclusion_percent")
+	_ = viper.BindEnv("erc4337_bundler_admin_addr")
+	_ = viper.BindEnv("erc4337_bundler_grpc_addr")
+	_ = viper.BindEnv("erc4337_bundler_rest_api_enabled")
+	_ = viper.BindEnv("erc4337_bundler_cors_allowed_origins")
+	_ = viper.BindEnv("erc4337_bundler_cors_allowed_headers")
+	_ = viper.BindEnv("erc4337_bundler_cors_allow_credentials")
+	_ = viper.BindEnv("erc4337_bundler_log_tail_size")
+	_ = viper.BindEnv("erc4337_bundler_admin_rpc_token")
+	_ = viper.BindEnv("erc4337_bundler_backup_url")
+	_ = viper.BindEnv("erc4337_bundler_backup_interval_seconds")
+	_ = viper.BindEnv("erc4337_bundler_backup_s3_endpoint")
+	_ = viper.BindEnv("erc4337_bundler_backup_s3_region")
+	_ = viper.BindEnv("erc4337_bundler_backup_s3_access_key")
+	_ = viper.BindEnv("erc4337_bundler_backup_s3_secret_key")
+	_ = viper.BindEnv("erc4337_bundler_dashboard_addr")
+	_ = viper.BindEnv("erc4337_bundler_account_fingerprint_enabled")
+	_ = viper.BindEnv("erc4337_bundler_account_fingerprints")
+	_ = viper.BindEnv("erc4337_bundler_dashboard_interval_seconds")
 	_ = viper.BindEnv("erc4337_bundler_eth_builder_urls")
+	_ = viper.BindEnv("erc4337_bundler_eth_builder_formats")
 	_ = viper.BindEnv("erc4337_bundler_blocks_in_the_future")
+	_ = viper.BindEnv("erc4337_bundler_chain_mismatch_policy")
+	_ = viper.BindEnv("erc4337_bundler_beneficiary_payout_calldata")
+	_ = viper.BindEnv("erc4337_bundler_beneficiary_payout_gas_limit")
+	_ = viper.BindEnv("erc4337_bundler_presigned_templates")
+	_ = viper.BindEnv("erc4337_bundler_eth_bundle_simulation_url")
+	_ = viper.BindEnv("erc4337_bundler_searcher_role")
+	_ = viper.BindEnv("erc4337_bundler_bundle_backend_url")
+	_ = viper.BindEnv("erc4337_bundler_handoff_dictionary")
 	_ = viper.BindEnv("erc4337_bundler_otel_service_name")
 	_ = viper.BindEnv("erc4337_bundler_otel_collector_headers")
 	_ = viper.BindEnv("erc4337_bundler_otel_collector_url")
 	_ = viper.BindEnv("erc4337_bundler_otel_insecure_mode")
 	_ = viper.BindEnv("erc4337_bundler_alt_mempool_ipfs_gateway")
 	_ = viper.BindEnv("erc4337_bundler_alt_mempool_ids")
+	_ = viper.BindEnv("erc4337_bundler_replica_export_enabled")
+	_ = viper.BindEnv("erc4337_bundler_replica_primary_url")
+	_ = viper.BindEnv("erc4337_bundler_replica_sync_interval_seconds")
+	_ = viper.BindEnv("erc4337_bundler_federation_registry_url")
+	_ = viper.BindEnv("erc4337_bundler_federation_public_url")
+	_ = viper.BindEnv("erc4337_bundler_federation_interval_seconds")
+	_ = viper.BindEnv("erc4337_bundler_paymaster_service_url")
+	_ = viper.BindEnv("erc4337_bundler_paymaster_service_timeout_ms")
+	_ = viper.BindEnv("erc4337_bundler_prometheus_enabled")
+	_ = viper.BindEnv("erc4337_bundler_risk_service_url")
+	_ = viper.BindEnv("erc4337_bundler_risk_service_timeout_ms")
+	_ = viper.BindEnv("erc4337_bundler_risk_service_fail_open")
+	_ = viper.BindEnv("erc4337_bundler_risk_service_max_score")
+	_ = viper.BindEnv("erc4337_bundler_auction_url")
+	_ = viper.BindEnv("erc4337_bundler_auction_timeout_ms")
+	_ = viper.BindEnv("erc4337_bundler_auction_refund_percent")
+	_ = viper.BindEnv("erc4337_bundler_mempool_backend")
+	_ = viper.BindEnv("erc4337_bundler_mempool_redis_url")
+	_ = viper.BindEnv("erc4337_bundler_mempool_redis_prefix")
+	_ = viper.BindEnv("erc4337_bundler_mempool_postgres_url")
+	_ = viper.BindEnv("erc4337_bundler_mempool_sqlite_path")
+	_ = viper.BindEnv("erc4337_bundler_mempool_sync_interval_ms")
+	_ = viper.BindEnv("erc4337_bundler_mempool_lease_ms")
+	_ = viper.BindEnv("erc4337_bundler_known_factories")
+	_ = viper.BindEnv("erc4337_bundler_known_factories_feed_url")
+	_ = viper.BindEnv("erc4337_bundler_known_factories_refresh_interval_seconds")
+	_ = viper.BindEnv("erc4337_bundler_unknown_factory_max_pending_ops")
+	_ = viper.BindEnv("erc4337_bundler_unknown_factory_max_verification_gas")
+	_ = viper.BindEnv("erc4337_bundler_unknown_factory_max_ops_per_batch")
+	_ = viper.BindEnv("erc4337_bundler_max_pending_ops_per_sender")
+	_ = viper.BindEnv("erc4337_bundler_max_pending_ops_per_paymaster")
+	_ = viper.BindEnv("erc4337_bundler_ip_reputation_threshold")
+	_ = viper.BindEnv("erc4337_bundler_ip_reputation_half_life_seconds")
+	_ = viper.BindEnv("erc4337_bundler_ip_asn_database")
+	_ = viper.BindEnv("erc4337_bundler_ip_country_header")
+	_ = viper.BindEnv("erc4337_bundler_ip_policies")
+	_ = viper.BindEnv("erc4337_bundler_chain_head_ws_url")
+	_ = viper.BindEnv("erc4337_bundler_chain_head_poll_interval_ms")
+	_ = viper.BindEnv("erc4337_bundler_health_check_timeout_ms")
+	_ = viper.BindEnv("erc4337_bundler_health_min_balance")
+	_ = viper.BindEnv("erc4337_bundler_http_read_timeout_seconds")
+	_ = viper.BindEnv("erc4337_bundler_http_write_timeout_seconds")
+	_ = viper.BindEnv("erc4337_bundler_http_idle_timeout_seconds")
+	_ = viper.BindEnv("erc4337_bundler_http_max_header_bytes")
+	_ = viper.BindEnv("erc4337_bundler_http2_enabled")
+	_ = viper.BindEnv("erc4337_bundler_http2_max_concurrent_streams")
+	_ = viper.BindEnv("erc4337_bundler_adaptive_max_ops")
+	_ = viper.BindEnv("erc4337_bundler_adaptive_min_ops")
+	_ = viper.BindEnv("erc4337_bundler_adaptive_target_sim_latency_ms")
+	_ = viper.BindEnv("erc4337_bundler_adaptive_deadline_ms")
+	_ = viper.BindEnv("erc4337_bundler_policy_script")
+	_ = viper.BindEnv("erc4337_bundler_policy_timeout_ms")
+	_ = viper.BindEnv("erc4337_bundler_shadow_alt_mempool_ids")
 	_ = viper.BindEnv("erc4337_bundler_is_op_stack_network")
 	_ = viper.BindEnv("erc4337_bundler_is_arb_stack_network")
 	_ = viper.BindEnv("erc4337_bundler_is_rip7212_supported")
+	_ = viper.BindEnv("erc4337_bundler_pvg_bounds_blocks")
 	_ = viper.BindEnv("erc4337_bundler_debug_mode")
 	_ = viper.BindEnv("erc4337_bundler_gin_mode")
 	_ = viper.BindEnv("qng_meerchange_cross_contract")
 
-	// Validate required variables
+	// Validate variables. All problems are collected and reported together.
+	var p problems
 	if variableNotSetOrIsNil("erc4337_bundler_eth_client_url") {
-		panic("Fatal config error: erc4337_bundler_eth_client_url not set")
+		p.add("erc4337_bundler_eth_client_url", "not set")
 	}
 
-	if variableNotSetOrIsNil("erc4337_bundler_private_key") {
-		panic("Fatal config error: erc4337_bundler_private_key not set")
-	}
-
-	if !viper.IsSet("erc4337_bundler_beneficiary") {
-		s, err := signer.New(viper.GetString("erc4337_bundler_private_key"))
-		if err != nil {
-			panic(err)
+	// Read-only instances never sign transactions so a private key is not required.
+	if viper.GetString("mode") != "readonly" {
+		if variableNotSetOrIsNil("erc4337_bundler_private_key") {
+			p.add("erc4337_bundler_private_key", "not set")
+		} else if !viper.IsSet("erc4337_bundler_beneficiary") {
+			s, err := signer.New(viper.GetString("erc4337_bundler_private_key"))
+			if err != nil {
+				p.add("erc4337_bundler_private_key", "%s", err)
+			} else {
+				viper.SetDefault("erc4337_bundler_beneficiary", s.Address.String())
+			}
 		}
-		viper.SetDefault("erc4337_bundler_beneficiary", s.Address.String())
 	}
 	if variableNotSetOrIsNil("qng_meerchange_cross_contract") {
-		panic("Fatal config error: qng_meerchange_cross_contract not set")
+		p.add("qng_meerchange_cross_contract", "not set")
 	}
 
+	// Validate mode specific variables
 	switch viper.GetString("mode") {
 	case "searcher":
 		if variableNotSetOrIsNil("erc4337_bundler_eth_builder_urls") {
-			panic("Fatal config error: erc4337_bundler_eth_builder_urls not set")
+			p.add("erc4337_bundler_eth_builder_urls", "not set but required in searcher mode")
+		}
+		urls := envArrayToStringSlice(viper.GetString("erc4337_bundler_eth_builder_urls"))
+		formats := envArrayToStringSlice(viper.GetString("erc4337_bundler_eth_builder_formats"))
+		if len(formats) > len(urls) {
+			p.add("erc4337_bundler_eth_builder_formats", "has more entries than erc4337_bundler_eth_builder_urls")
+		}
+		for _, val := range formats {
+			switch format, _ := SplitBuilderFormat(val); format {
+			case BuilderFormatFlashbots, BuilderFormatBloxroute, BuilderFormatTitan, BuilderFormatRawTx:
+			default:
+				p.add("erc4337_bundler_eth_builder_formats", "unknown format %s", format)
+			}
+		}
+	case "private":
+		for _, key := range []string{
+			"erc4337_bundler_eth_builder_urls",
+			"erc4337_bundler_eth_builder_formats",
+			"erc4337_bundler_beneficiary_payout_calldata",
+			"erc4337_bundler_eth_bundle_simulation_url",
+		} {
+			if !variableNotSetOrIsNil(key) {
+				p.add(key, "only used in searcher mode but is set in private mode")
+			}
+		}
+		if viper.GetInt("erc4337_bundler_presigned_templates") > 0 {
+			p.add("erc4337_bundler_presigned_templates", "only used in searcher mode but is set in private mode")
+		}
+		if viper.GetString("erc4337_bundler_searcher_role") != SearcherRoleAll {
+			p.add("erc4337_bundler_searcher_role", "only used in searcher mode but is set in private mode")
+		}
+	case "readonly":
+		if variableNotSetOrIsNil("erc4337_bundler_replica_primary_url") {
+			p.add("erc4337_bundler_replica_primary_url", "not set but required in readonly mode")
+		}
+	}
+
+	switch viper.GetString("erc4337_bundler_chain_mismatch_policy") {
+	case ChainMismatchFail, ChainMismatchDegrade, ChainMismatchAuto:
+	default:
+		p.add(
+			"erc4337_bundler_chain_mismatch_policy",
+			"must be one of %s, %s, or %s",
+			ChainMismatchFail,
+			ChainMismatchDegrade,
+			ChainMismatchAuto,
+		)
+	}
+
+	switch viper.GetString("erc4337_bundler_searcher_role") {
+	case SearcherRoleAll, SearcherRoleBundle:
+		if !variableNotSetOrIsNil("erc4337_bundler_bundle_backend_url") {
+			p.add(
+				"erc4337_bundler_bundle_backend_url",
+				"only used when erc4337_bundler_searcher_role is %s",
+				SearcherRoleIngest,
+			)
+		}
+	case SearcherRoleIngest:
+		if variableNotSetOrIsNil("erc4337_bundler_bundle_backend_url") {
+			p.add(
+				"erc4337_bundler_bundle_backend_url",
+				"not set but required when erc4337_bundler_searcher_role is %s",
+				SearcherRoleIngest,
+			)
+		} else if _, err := url.ParseRequestURI(viper.GetString("erc4337_bundler_bundle_backend_url")); err != nil {
+			p.add("erc4337_bundler_bundle_backend_url", "%s", err)
+		}
+		if !variableNotSetOrIsNil("erc4337_bundler_replica_primary_url") {
+			p.add(
+				"erc4337_bundler_replica_primary_url",
+				"cannot be set when erc4337_bundler_searcher_role is %s",
+				SearcherRoleIngest,
+			)
+		}
+	default:
+		p.add(
+			"erc4337_bundler_searcher_role",
+			"must be one of %s, %s, or %s",
+			SearcherRoleAll,
+			SearcherRoleIngest,
+			SearcherRoleBundle,
+		)
+	}
+
+	switch viper.GetString("erc4337_bundler_tx_type") {
+	case TxTypeAuto, TxTypeLegacy, TxTypeDynamic:
+	default:
+		p.add(
+			"erc4337_bundler_tx_type",
+			"must be one of %s, %s, or %s",
+			TxTypeAuto,
+			TxTypeLegacy,
+			TxTypeDynamic,
+		)
+	}
+
+	switch viper.GetString("erc4337_bundler_profit_model") {
+	case ProfitModelAuto, ProfitModelPriorityFee, ProfitModelBaseFeeRebate:
+	default:
+		p.add(
+			"erc4337_bundler_profit_model",
+			"must be one of %s, %s, or %s",
+			ProfitModelAuto,
+			ProfitModelPriorityFee,
+			ProfitModelBaseFeeRebate,
+		)
+	}
+
+	// Validate validation profile variables
+	switch viper.GetString("erc4337_bundler_validation_profile") {
+	case ValidationProfileTracer:
+	case ValidationProfileStructLog:
+		if viper.GetString("erc4337_bundler_native_bundler_collector_tracer") != "" {
+			p.add(
+				"erc4337_bundler_validation_profile",
+				"%s cannot be used with erc4337_bundler_native_bundler_collector_tracer",
+				ValidationProfileStructLog,
+			)
+		}
+	default:
+		p.add(
+			"erc4337_bundler_validation_profile",
+			"must be %s or %s",
+			ValidationProfileTracer,
+			ValidationProfileStructLog,
+		)
+	}
+
+	// Validate deterministic mode variables
+	if _, err := hexutil.Decode(viper.GetString("erc4337_bundler_deterministic_seed")); err != nil {
+		p.add("erc4337_bundler_deterministic_seed", "%s", err)
+	}
+
+	// Validate handoff variables
+	handoffDictionary := [][]byte{}
+	if !variableNotSetOrIsNil("erc4337_bundler_handoff_dictionary") {
+		for _, prefix := range envArrayToStringSlice(viper.GetString("erc4337_bundler_handoff_dictionary")) {
+			b, err := hexutil.Decode(prefix)
+			if err != nil {
+				p.add("erc4337_bundler_handoff_dictionary", "%s: %s", prefix, err)
+				continue
+			}
+			handoffDictionary = append(handoffDictionary, b)
+		}
+		if viper.GetString("erc4337_bundler_searcher_role") == SearcherRoleAll {
+			p.add("erc4337_bundler_handoff_dictionary", "only used when erc4337_bundler_searcher_role is set")
+		}
+	}
+
+	// Validate passkey verifier variables
+	passkeyVerifiers, err := passkey.ParseVerifiers(
+		envArrayToStringSlice(viper.GetString("erc4337_bundler_passkey_verifiers")),
+	)
+	if err != nil {
+		p.add("erc4337_bundler_passkey_verifiers", "%s", err)
+	}
+
+	// Validate min priority fee variables
+	for _, key := range []string{
+		"erc4337_bundler_min_priority_fee_paymaster",
+		"erc4337_bundler_min_priority_fee_init_code",
+		"erc4337_bundler_min_priority_fee_sender",
+	} {
+		if viper.GetInt64(key) < 0 {
+			p.add(key, "cannot be negative")
+		}
+	}
+	if viper.GetInt64("erc4337_bundler_replacement_fee_bump_percent") < 0 {
+		p.add("erc4337_bundler_replacement_fee_bump_percent", "cannot be negative")
+	}
+
+	// Validate account fingerprint variables
+	accountFingerprints, err := fingerprint.ParseKnown(
+		envArrayToStringSlice(viper.GetString("erc4337_bundler_account_fingerprints")),
+	)
+	if err != nil {
+		p.add("erc4337_bundler_account_fingerprints", "%s", err)
+	}
+	if !variableNotSetOrIsNil("erc4337_bundler_account_fingerprints") &&
+		!viper.GetBool("erc4337_bundler_account_fingerprint_enabled") {
+		p.add("erc4337_bundler_account_fingerprints", "set without enabling account fingerprinting")
+	}
+
+	// Validate delegated relayer variables
+	delegatedRelayers, err := delegate.ParseRelayers(
+		envArrayToStringSlice(viper.GetString("erc4337_bundler_delegated_relayers")),
+	)
+	if err != nil {
+		p.add("erc4337_bundler_delegated_relayers", "%s", err)
+	}
+
+	// Validate op status variables
+	if viper.GetInt("erc4337_bundler_op_status_retention_seconds") <= 0 {
+		p.add("erc4337_bundler_op_status_retention_seconds", "must be greater than 0")
+	}
+
+	// Validate API key variables
+	apiKeys, err := apikey.ParseKeys(envArrayToStringSlice(viper.GetString("erc4337_bundler_api_keys")))
+	if err != nil {
+		p.add("erc4337_bundler_api_keys", "%s", err)
+	}
+	if len(apiKeys) > 0 && !viper.GetBool("erc4337_bundler_api_key_auth_enabled") {
+		p.add("erc4337_bundler_api_keys", "set without enabling api key auth")
+	}
+
+	// Validate rate limit variables
+	rateLimits, err := ratelimit.ParseLimits(envArrayToStringSlice(viper.GetString("erc4337_bundler_rate_limits")))
+	if err != nil {
+		p.add("erc4337_bundler_rate_limits", "%s", err)
+	}
+	apiKeyRateLimits, err := ratelimit.ParseLimits(
+		envArrayToStringSlice(viper.GetString("erc4337_bundler_api_key_rate_limits")),
+	)
+	if err != nil {
+		p.add("erc4337_bundler_api_key_rate_limits", "%s", err)
+	}
+	rateLimitStore := viper.GetString("erc4337_bundler_rate_limit_store")
+	if rateLimitStore != "memory" && rateLimitStore != "badger" {
+		p.add("erc4337_bundler_rate_limit_store", "must be memory or badger")
+	}
+
+	// Validate reputation buffer variables
+	if viper.GetInt("erc4337_bundler_reputation_buffer_size") < 0 {
+		p.add("erc4337_bundler_reputation_buffer_size", "cannot be negative")
+	}
+	if viper.GetInt("erc4337_bundler_reputation_buffer_size") > 0 &&
+		viper.GetInt("erc4337_bundler_reputation_flush_interval_seconds") <= 0 {
+		p.add("erc4337_bundler_reputation_flush_interval_seconds", "must be positive when buffering is enabled")
+	}
+	if viper.GetInt("erc4337_bundler_paymaster_stake_grace_period_seconds") < 0 {
+		p.add("erc4337_bundler_paymaster_stake_grace_period_seconds", "cannot be negative")
+	}
+	if viper.GetInt64("erc4337_bundler_data_dir_soft_limit_mb") < 0 {
+		p.add("erc4337_bundler_data_dir_soft_limit_mb", "cannot be negative")
+	}
+	if viper.GetInt64("erc4337_bundler_data_dir_hard_limit_mb") < 0 {
+		p.add("erc4337_bundler_data_dir_hard_limit_mb", "cannot be negative")
+	}
+	if soft, hard := viper.GetInt64("erc4337_bundler_data_dir_soft_limit_mb"),
+		viper.GetInt64("erc4337_bundler_data_dir_hard_limit_mb"); soft > 0 && hard > 0 && soft > hard {
+		p.add("erc4337_bundler_data_dir_soft_limit_mb", "cannot be greater than the hard limit")
+	}
+	if viper.GetInt("erc4337_bundler_data_dir_check_interval_seconds") <= 0 {
+		p.add("erc4337_bundler_data_dir_check_interval_seconds", "must be positive")
+	}
+	if t := viper.GetInt("erc4337_bundler_gas_feedback_target_percent"); t <= 0 || t > 100 {
+		p.add("erc4337_bundler_gas_feedback_target_percent", "must be between 1 and 100")
+	}
+	if viper.GetInt("erc4337_bundler_gas_feedback_max_padding_percent") < 0 {
+		p.add("erc4337_bundler_gas_feedback_max_padding_percent", "cannot be negative")
+	}
+	for _, key := range []string{
+		"erc4337_bundler_max_request_body_bytes",
+		"erc4337_bundler_max_calldata_bytes",
+		"erc4337_bundler_max_initcode_bytes",
+	} {
+		if viper.GetInt64(key) < 0 {
+			p.add(key, "cannot be negative")
+		}
+	}
+
+	if viper.GetInt("erc4337_bundler_presigned_templates") < 0 {
+		p.add("erc4337_bundler_presigned_templates", "cannot be negative")
+	}
+
+	if !variableNotSetOrIsNil("erc4337_bundler_eth_bundle_simulation_url") {
+		if _, err := url.ParseRequestURI(viper.GetString("erc4337_bundler_eth_bundle_simulation_url")); err != nil {
+			p.add("erc4337_bundler_eth_bundle_simulation_url", "%s", err)
+		}
+	}
+
+	// Validate beneficiary payout variables
+	if !variableNotSetOrIsNil("erc4337_bundler_beneficiary_payout_calldata") {
+		if _, err := hexutil.Decode(viper.GetString("erc4337_bundler_beneficiary_payout_calldata")); err != nil {
+			p.add("erc4337_bundler_beneficiary_payout_calldata", "%s", err)
+		}
+		if viper.GetInt64("erc4337_bundler_beneficiary_payout_gas_limit") <= 0 {
+			p.add("erc4337_bundler_beneficiary_payout_gas_limit", "must be greater than 0")
+		}
+	}
+
+	// Validate gas and sync variables
+	if viper.GetInt64("erc4337_bundler_vgl_safety_margin") < 0 {
+		p.add("erc4337_bundler_vgl_safety_margin", "cannot be negative")
+	}
+	if viper.GetBool("erc4337_bundler_hold_ops_during_sync") && viper.GetInt("erc4337_bundler_max_held_ops") < 0 {
+		p.add("erc4337_bundler_max_held_ops", "cannot be negative")
+	}
+
+	// Validate signing approval variables
+	if !variableNotSetOrIsNil("erc4337_bundler_signing_approval_url") {
+		if _, err := url.ParseRequestURI(viper.GetString("erc4337_bundler_signing_approval_url")); err != nil {
+			p.add("erc4337_bundler_signing_approval_url", "%s", err)
+		}
+		if viper.GetInt("erc4337_bundler_signing_approval_timeout_seconds") <= 0 {
+			p.add("erc4337_bundler_signing_approval_timeout_seconds", "must be greater than 0")
 		}
 	}
 
+	// Validate safe mode variables
+	if viper.GetInt("erc4337_bundler_safe_mode_revert_threshold") < 0 {
+		p.add("erc4337_bundler_safe_mode_revert_threshold", "cannot be negative")
+	} else if viper.GetInt("erc4337_bundler_safe_mode_revert_threshold") > 0 &&
+		viper.GetInt("erc4337_bundler_safe_mode_recovery_bundles") <= 0 {
+		p.add("erc4337_bundler_safe_mode_recovery_bundles", "must be greater than 0 when safe mode is enabled")
+	}
+
+	// Validate reliable entity variables
+	if pct := viper.GetInt64("erc4337_bundler_reliable_entity_gas_discount_percent"); pct < 0 ||
+		pct > MaxReliableEntityGasDiscountPercent {
+		p.add(
+			"erc4337_bundler_reliable_entity_gas_discount_percent",
+			"must be between 0 and %d",
+			MaxReliableEntityGasDiscountPercent,
+		)
+	}
+	if pct := viper.GetInt("erc4337_bundler_reliable_entity_min_inclusion_percent"); pct < 0 || pct > 100 {
+		p.add("erc4337_bundler_reliable_entity_min_inclusion_percent", "must be between 0 and 100")
+	}
+
 	// Validate O11Y variables
+	if !variableNotSetOrIsNil("erc4337_bundler_dashboard_addr") &&
+		viper.GetInt("erc4337_bundler_dashboard_interval_seconds") <= 0 {
+		p.add("erc4337_bundler_dashboard_interval_seconds", "must be greater than 0")
+	}
 	if viper.IsSet("erc4337_bundler_otel_service_name") &&
 		variableNotSetOrIsNil("erc4337_bundler_otel_collector_url") {
-		panic("Fatal config error: erc4337_bundler_otel_service_name is set without a collector URL")
+		p.add("erc4337_bundler_otel_service_name", "set without a collector URL")
 	}
 
 	// Validate Alternative mempool variables
 	if viper.IsSet("erc4337_bundler_alt_mempool_ids") &&
 		variableNotSetOrIsNil("erc4337_bundler_alt_mempool_ipfs_gateway") {
-		panic("Fatal config error: erc4337_bundler_alt_mempool_ids is set without specifying an IPFS gateway")
+		p.add("erc4337_bundler_alt_mempool_ids", "set without specifying an IPFS gateway")
+	}
+	if viper.IsSet("erc4337_bundler_shadow_alt_mempool_ids") &&
+		variableNotSetOrIsNil("erc4337_bundler_alt_mempool_ipfs_gateway") {
+		p.add("erc4337_bundler_shadow_alt_mempool_ids", "set without specifying an IPFS gateway")
+	}
+
+	// Validate read replica variables
+	if viper.GetBool("erc4337_bundler_replica_export_enabled") &&
+		!variableNotSetOrIsNil("erc4337_bundler_replica_primary_url") {
+		p.add("erc4337_bundler_replica_export_enabled", "cannot be set on a read replica")
+	}
+	if !variableNotSetOrIsNil("erc4337_bundler_replica_primary_url") {
+		if _, err := url.ParseRequestURI(viper.GetString("erc4337_bundler_replica_primary_url")); err != nil {
+			p.add("erc4337_bundler_replica_primary_url", "%s", err)
+		}
+	}
+
+	// Validate federation variables
+	if !variableNotSetOrIsNil("erc4337_bundler_federation_registry_url") {
+		for _, key := range []string{
+			"erc4337_bundler_federation_registry_url",
+			"erc4337_bundler_federation_public_url",
+		} {
+			if variableNotSetOrIsNil(key) {
+				p.add(key, "not set but required when federation is enabled")
+			} else if _, err := url.ParseRequestURI(viper.GetString(key)); err != nil {
+				p.add(key, "%s", err)
+			}
+		}
+		if viper.GetInt("erc4337_bundler_federation_interval_seconds") <= 0 {
+			p.add("erc4337_bundler_federation_interval_seconds", "must be greater than 0")
+		}
+	}
+
+	// Validate paymaster service variables
+	if !variableNotSetOrIsNil("erc4337_bundler_paymaster_service_url") {
+		if _, err := url.ParseRequestURI(viper.GetString("erc4337_bundler_paymaster_service_url")); err != nil {
+			p.add("erc4337_bundler_paymaster_service_url", "%s", err)
+		}
+		if viper.GetInt("erc4337_bundler_paymaster_service_timeout_ms") <= 0 {
+			p.add("erc4337_bundler_paymaster_service_timeout_ms", "must be greater than 0")
+		}
+	}
+
+	// Validate risk service variables
+	if !variableNotSetOrIsNil("erc4337_bundler_risk_service_url") {
+		if _, err := url.ParseRequestURI(viper.GetString("erc4337_bundler_risk_service_url")); err != nil {
+			p.add("erc4337_bundler_risk_service_url", "%s", err)
+		}
+		if viper.GetInt("erc4337_bundler_risk_service_timeout_ms") <= 0 {
+			p.add("erc4337_bundler_risk_service_timeout_ms", "must be greater than 0")
+		}
+	}
+	if viper.GetFloat64("erc4337_bundler_risk_service_max_score") < 0 {
+		p.add("erc4337_bundler_risk_service_max_score", "cannot be negative")
+	}
+
+	// Validate orderflow auction variables
+	if !variableNotSetOrIsNil("erc4337_bundler_auction_url") {
+		if _, err := url.ParseRequestURI(viper.GetString("erc4337_bundler_auction_url")); err != nil {
+			p.add("erc4337_bundler_auction_url", "%s", err)
+		}
+		if viper.GetInt("erc4337_bundler_auction_timeout_ms") <= 0 {
+			p.add("erc4337_bundler_auction_timeout_ms", "must be greater than 0")
+		}
+	}
+	if pct := viper.GetInt("erc4337_bundler_auction_refund_percent"); pct < 0 || pct > 100 {
+		p.add("erc4337_bundler_auction_refund_percent", "must be between 0 and 100")
+	}
+
+	// Validate mempool storage variables
+	switch viper.GetString("erc4337_bundler_mempool_backend") {
+	case "badger":
+	case "redis":
+		if variableNotSetOrIsNil("erc4337_bundler_mempool_redis_url") {
+			p.add("erc4337_bundler_mempool_redis_url", "must be set when the mempool backend is redis")
+		}
+		if viper.GetString("erc4337_bundler_mempool_redis_prefix") == "" {
+			p.add("erc4337_bundler_mempool_redis_prefix", "cannot be empty")
+		}
+	case "postgres":
+		if variableNotSetOrIsNil("erc4337_bundler_mempool_postgres_url") {
+			p.add("erc4337_bundler_mempool_postgres_url", "must be set when the mempool backend is postgres")
+		}
+	case "sqlite":
+	default:
+		p.add("erc4337_bundler_mempool_backend", "must be one of badger, sqlite, redis, or postgres")
+	}
+	if isSharedMempoolBackend(viper.GetString("erc4337_bundler_mempool_backend")) &&
+		viper.GetInt("erc4337_bundler_mempool_sync_interval_ms") <= 0 {
+		p.add("erc4337_bundler_mempool_sync_interval_ms", "must be greater than 0")
+	}
+	if isSharedMempoolBackend(viper.GetString("erc4337_bundler_mempool_backend")) &&
+		viper.GetInt("erc4337_bundler_mempool_lease_ms") <= 0 {
+		p.add("erc4337_bundler_mempool_lease_ms", "must be greater than 0")
+	}
+
+	// Validate known factory variables
+	if !variableNotSetOrIsNil("erc4337_bundler_known_factories") {
+		for _, addr := range envArrayToStringSlice(viper.GetString("erc4337_bundler_known_factories")) {
+			if !common.IsHexAddress(addr) {
+				p.add("erc4337_bundler_known_factories", "must be a comma separated list of addresses")
+				break
+			}
+		}
+	}
+	if !variableNotSetOrIsNil("erc4337_bundler_known_factories_feed_url") {
+		if _, err := url.ParseRequestURI(viper.GetString("erc4337_bundler_known_factories_feed_url")); err != nil {
+			p.add("erc4337_bundler_known_factories_feed_url", "%s", err)
+		}
+		if viper.GetInt("erc4337_bundler_known_factories_refresh_interval_seconds") <= 0 {
+			p.add("erc4337_bundler_known_factories_refresh_interval_seconds", "must be greater than 0")
+		}
+	}
+	for _, key := range []string{
+		"erc4337_bundler_unknown_factory_max_pending_ops",
+		"erc4337_bundler_unknown_factory_max_verification_gas",
+		"erc4337_bundler_unknown_factory_max_ops_per_batch",
+	} {
+		if viper.GetInt(key) < 0 {
+			p.add(key, "cannot be negative")
+		}
+	}
+
+	// Validate pending op limit variables
+	for _, key := range []string{
+		"erc4337_bundler_max_pending_ops_per_sender",
+		"erc4337_bundler_max_pending_ops_per_paymaster",
+	} {
+		if viper.GetInt(key) < 0 {
+			p.add(key, "cannot be negative")
+		}
+	}
+
+	// Validate IP guard variables
+	if viper.GetFloat64("erc4337_bundler_ip_reputation_threshold") < 0 {
+		p.add("erc4337_bundler_ip_reputation_threshold", "cannot be negative")
+	}
+	if viper.GetInt("erc4337_bundler_ip_reputation_half_life_seconds") <= 0 {
+		p.add("erc4337_bundler_ip_reputation_half_life_seconds", "must be greater than 0")
+	}
+	ipPolicies, err := ipguard.ParsePolicies(envArrayToStringSlice(viper.GetString("erc4337_bundler_ip_policies")))
+	if err != nil {
+		p.add("erc4337_bundler_ip_policies", "%s", err)
+	}
+
+	// Validate chain head variables
+	if !variableNotSetOrIsNil("erc4337_bundler_chain_head_ws_url") {
+		if _, err := url.ParseRequestURI(viper.GetString("erc4337_bundler_chain_head_ws_url")); err != nil {
+			p.add("erc4337_bundler_chain_head_ws_url", "%s", err)
+		}
+	}
+	if viper.GetInt("erc4337_bundler_chain_head_poll_interval_ms") <= 0 {
+		p.add("erc4337_bundler_chain_head_poll_interval_ms", "must be greater than 0")
+	}
+
+	// Validate log tail variables
+	if viper.GetInt("erc4337_bundler_log_tail_size") < 0 {
+		p.add("erc4337_bundler_log_tail_size", "cannot be negative")
+	}
+
+	// Validate health check variables
+	if viper.GetInt("erc4337_bundler_health_check_timeout_ms") <= 0 {
+		p.add("erc4337_bundler_health_check_timeout_ms", "must be greater than 0")
+	}
+	if bal, ok := big.NewInt(0).SetString(viper.GetString("erc4337_bundler_health_min_balance"), 10); !ok ||
+		bal.Sign() < 0 {
+		p.add("erc4337_bundler_health_min_balance", "must be a non-negative integer in wei")
+	}
+
+	// Validate admin variables
+	if !variableNotSetOrIsNil("erc4337_bundler_admin_rpc_token") &&
+		len(viper.GetString("erc4337_bundler_admin_rpc_token")) < MinAdminRpcTokenLength {
+		p.add("erc4337_bundler_admin_rpc_token", "must be at least %d characters", MinAdminRpcTokenLength)
+	}
+
+	// Validate CORS variables
+	corsOrigins := envArrayToStringSlice(viper.GetString("erc4337_bundler_cors_allowed_origins"))
+	if len(corsOrigins) == 0 {
+		p.add("erc4337_bundler_cors_allowed_origins", "not set")
+	}
+	for _, origin := range corsOrigins {
+		if origin == "*" {
+			if len(corsOrigins) > 1 {
+				p.add("erc4337_bundler_cors_allowed_origins", "* cannot be combined with other origins")
+			}
+			if viper.GetBool("erc4337_bundler_cors_allow_credentials") {
+				p.add("erc4337_bundler_cors_allow_credentials", "cannot be used when all origins are allowed")
+			}
+		} else if !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			p.add("erc4337_bundler_cors_allowed_origins", "%s must start with http:// or https://", origin)
+		}
+	}
+
+	// Validate trusted proxy variables
+	for _, proxy := range envArrayToStringSlice(viper.GetString("erc4337_bundler_trusted_proxies")) {
+		proxy = strings.TrimSpace(proxy)
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			p.add("erc4337_bundler_trusted_proxies", "%s must be an IP or CIDR", proxy)
+		}
 	}
 
+	// Validate backup variables
+	if !variableNotSetOrIsNil("erc4337_bundler_backup_url") {
+		if viper.GetInt("erc4337_bundler_backup_interval_seconds") <= 0 {
+			p.add("erc4337_bundler_backup_interval_seconds", "must be greater than 0")
+		}
+		if strings.HasPrefix(viper.GetString("erc4337_bundler_backup_url"), "s3://") {
+			if _, err := url.ParseRequestURI(viper.GetString("erc4337_bundler_backup_s3_endpoint")); err != nil {
+				p.add("erc4337_bundler_backup_s3_endpoint", "%s", err)
+			}
+			for _, key := range []string{
+				"erc4337_bundler_backup_s3_access_key",
+				"erc4337_bundler_backup_s3_secret_key",
+			} {
+				if variableNotSetOrIsNil(key) {
+					p.add(key, "not set but required for s3 backups")
+				}
+			}
+		}
+	}
+
+	// Validate HTTP server variables
+	for _, key := range []string{
+		"erc4337_bundler_http_read_timeout_seconds",
+		"erc4337_bundler_http_write_timeout_seconds",
+		"erc4337_bundler_http_idle_timeout_seconds",
+	} {
+		if viper.GetInt(key) < 0 {
+			p.add(key, "cannot be negative")
+		}
+	}
+	if viper.GetInt("erc4337_bundler_http_max_header_bytes") <= 0 {
+		p.add("erc4337_bundler_http_max_header_bytes", "must be greater than 0")
+	}
+	if viper.GetInt("erc4337_bundler_http2_max_concurrent_streams") <= 0 {
+		p.add("erc4337_bundler_http2_max_concurrent_streams", "must be greater than 0")
+	}
+
+	// Validate adaptive batch size variables
+	if viper.GetInt("erc4337_bundler_adaptive_max_ops") < 0 {
+		p.add("erc4337_bundler_adaptive_max_ops", "cannot be negative")
+	}
+	if viper.GetInt("erc4337_bundler_adaptive_max_ops") > 0 {
+		if viper.GetInt("erc4337_bundler_adaptive_min_ops") <= 0 {
+			p.add("erc4337_bundler_adaptive_min_ops", "must be greater than 0")
+		} else if viper.GetInt("erc4337_bundler_adaptive_min_ops") > viper.GetInt("erc4337_bundler_adaptive_max_ops") {
+			p.add("erc4337_bundler_adaptive_min_ops", "cannot be greater than erc4337_bundler_adaptive_max_ops")
+		}
+		if viper.GetInt("erc4337_bundler_adaptive_target_sim_latency_ms") <= 0 {
+			p.add("erc4337_bundler_adaptive_target_sim_latency_ms", "must be greater than 0")
+		}
+		if viper.GetInt("erc4337_bundler_adaptive_deadline_ms") < 0 {
+			p.add("erc4337_bundler_adaptive_deadline_ms", "cannot be negative")
+		}
+	}
+
+	// Validate rollup variables
+	if viper.GetBool("erc4337_bundler_is_op_stack_network") &&
+		viper.GetBool("erc4337_bundler_is_arb_stack_network") {
+		p.add("erc4337_bundler_is_op_stack_network", "cannot be set together with erc4337_bundler_is_arb_stack_network")
+	}
+	if viper.GetInt("erc4337_bundler_pvg_bounds_blocks") < 0 {
+		p.add("erc4337_bundler_pvg_bounds_blocks", "cannot be negative")
+	}
+
+	p.panicIfAny()
+
 	// Return Values
 	privateKey := viper.GetString("erc4337_bundler_private_key")
 	ethClientUrl := viper.GetString("erc4337_bundler_eth_client_url")
@@ -195,21 +1186,159 @@ func GetValues() *Values {
 	beneficiary := viper.GetString("erc4337_bundler_beneficiary")
 	nativeBundlerCollectorTracer := viper.GetString("erc4337_bundler_native_bundler_collector_tracer")
 	nativeBundlerExecutorTracer := viper.GetString("erc4337_bundler_native_bundler_executor_tracer")
+	validationProfile := viper.GetString("erc4337_bundler_validation_profile")
 	maxVerificationGas := big.NewInt(int64(viper.GetInt("erc4337_bundler_max_verification_gas")))
 	maxBatchGasLimit := big.NewInt(int64(viper.GetInt("erc4337_bundler_max_batch_gas_limit")))
+	maxCallGasLimit := big.NewInt(int64(viper.GetInt("erc4337_bundler_max_call_gas_limit")))
+	maxOpGas := big.NewInt(int64(viper.GetInt("erc4337_bundler_max_op_gas")))
+	minPriorityFeePaymaster := big.NewInt(viper.GetInt64("erc4337_bundler_min_priority_fee_paymaster"))
+	minPriorityFeeInitCode := big.NewInt(viper.GetInt64("erc4337_bundler_min_priority_fee_init_code"))
+	minPriorityFeeSender := big.NewInt(viper.GetInt64("erc4337_bundler_min_priority_fee_sender"))
+	replacementFeeBump := viper.GetInt64("erc4337_bundler_replacement_fee_bump_percent")
 	maxOpTTL := time.Second * viper.GetDuration("erc4337_bundler_max_op_ttl_seconds")
 	opLookupLimit := viper.GetUint64("erc4337_bundler_op_lookup_limit")
+	opStatusRetention := time.Second * viper.GetDuration("erc4337_bundler_op_status_retention_seconds")
+	deterministicMode := viper.GetBool("erc4337_bundler_deterministic_mode")
+	deterministicSeed := hexutil.MustDecode(viper.GetString("erc4337_bundler_deterministic_seed"))
+	banReviewCooldown := time.Second * viper.GetDuration("erc4337_bundler_ban_review_cooldown_seconds")
+	reputationBufferSize := viper.GetInt("erc4337_bundler_reputation_buffer_size")
+	reputationFlushInterval := time.Second * viper.GetDuration("erc4337_bundler_reputation_flush_interval_seconds")
+	paymasterStakeGracePeriod := time.Second * viper.GetDuration(
+		"erc4337_bundler_paymaster_stake_grace_period_seconds",
+	)
+	dataDirSoftLimit := viper.GetInt64("erc4337_bundler_data_dir_soft_limit_mb") * 1024 * 1024
+	dataDirHardLimit := viper.GetInt64("erc4337_bundler_data_dir_hard_limit_mb") * 1024 * 1024
+	dataDirCheckInterval := time.Second * viper.GetDuration("erc4337_bundler_data_dir_check_interval_seconds")
+	gasFeedbackEnabled := viper.GetBool("erc4337_bundler_gas_feedback_enabled")
+	gasFeedbackTarget := viper.GetInt("erc4337_bundler_gas_feedback_target_percent")
+	gasFeedbackMaxPadding := viper.GetInt("erc4337_bundler_gas_feedback_max_padding_percent")
+	maxRequestBodySize := viper.GetInt64("erc4337_bundler_max_request_body_bytes")
+	maxCallDataSize := viper.GetInt("erc4337_bundler_max_calldata_bytes")
+	maxInitCodeSize := viper.GetInt("erc4337_bundler_max_initcode_bytes")
+	warmUpPeerUrls := envArrayToStringSlice(viper.GetString("erc4337_bundler_warm_up_peer_urls"))
+	stuckTxTimeout := time.Second * viper.GetDuration("erc4337_bundler_stuck_tx_timeout_seconds")
+	sigBanThreshold := viper.GetInt("erc4337_bundler_sig_ban_threshold")
+	sigBanWindow := time.Second * viper.GetDuration("erc4337_bundler_sig_ban_window_seconds")
+	sigBanDuration := time.Second * viper.GetDuration("erc4337_bundler_sig_ban_duration_seconds")
+	trustedProxies := []string{}
+	for _, proxy := range envArrayToStringSlice(viper.GetString("erc4337_bundler_trusted_proxies")) {
+		trustedProxies = append(trustedProxies, strings.TrimSpace(proxy))
+	}
+	epFailureThreshold := viper.GetInt("erc4337_bundler_ep_failure_threshold")
+	epRecoveryInterval := time.Second * viper.GetDuration("erc4337_bundler_ep_recovery_interval_seconds")
+	vglSafetyMargin := viper.GetInt64("erc4337_bundler_vgl_safety_margin")
+	holdOpsDuringSync := viper.GetBool("erc4337_bundler_hold_ops_during_sync")
+	maxHeldOps := viper.GetInt("erc4337_bundler_max_held_ops")
+	txType := viper.GetString("erc4337_bundler_tx_type")
+	profitModel := viper.GetString("erc4337_bundler_profit_model")
+	signingApprovalUrl := viper.GetString("erc4337_bundler_signing_approval_url")
+	signingApprovalTimeout := time.Second * viper.GetDuration("erc4337_bundler_signing_approval_timeout_seconds")
+	safeModeRevertThreshold := viper.GetInt("erc4337_bundler_safe_mode_revert_threshold")
+	safeModeRecoveryBundles := viper.GetInt("erc4337_bundler_safe_mode_recovery_bundles")
+	reliableEntityGasDiscount := &entities.GasPriceDiscount{
+		Percent:             viper.GetInt64("erc4337_bundler_reliable_entity_gas_discount_percent"),
+		MinOpsIncluded:      viper.GetInt("erc4337_bundler_reliable_entity_min_ops_included"),
+		MinInclusionPercent: viper.GetInt("erc4337_bundler_reliable_entity_min_inclusion_percent"),
+	}
+	adminAddr := viper.GetString("erc4337_bundler_admin_addr")
+	grpcAddr := viper.GetString("erc4337_bundler_grpc_addr")
+	restApiEnabled := viper.GetBool("erc4337_bundler_rest_api_enabled")
+	corsAllowedOrigins := envArrayToStringSlice(viper.GetString("erc4337_bundler_cors_allowed_origins"))
+	corsAllowedHeaders := envArrayToStringSlice(viper.GetString("erc4337_bundler_cors_allowed_headers"))
+	corsAllowCredentials := viper.GetBool("erc4337_bundler_cors_allow_credentials")
+	logTailSize := viper.GetInt("erc4337_bundler_log_tail_size")
+	adminRpcToken := viper.GetString("erc4337_bundler_admin_rpc_token")
+	backupUrl := viper.GetString("erc4337_bundler_backup_url")
+	backupInterval := time.Second * viper.GetDuration("erc4337_bundler_backup_interval_seconds")
+	backupS3Endpoint := viper.GetString("erc4337_bundler_backup_s3_endpoint")
+	backupS3Region := viper.GetString("erc4337_bundler_backup_s3_region")
+	backupS3AccessKey := viper.GetString("erc4337_bundler_backup_s3_access_key")
+	backupS3SecretKey := viper.GetString("erc4337_bundler_backup_s3_secret_key")
+	dashboardAddr := viper.GetString("erc4337_bundler_dashboard_addr")
+	accountFingerprintEnabled := viper.GetBool("erc4337_bundler_account_fingerprint_enabled")
+	apiKeyAuthEnabled := viper.GetBool("erc4337_bundler_api_key_auth_enabled")
+	dashboardInterval := time.Second * viper.GetDuration("erc4337_bundler_dashboard_interval_seconds")
 	ethBuilderUrls := envArrayToStringSlice(viper.GetString("erc4337_bundler_eth_builder_urls"))
+	ethBuilderFormats := envArrayToStringSlice(viper.GetString("erc4337_bundler_eth_builder_formats"))
 	blocksInTheFuture := viper.GetInt("erc4337_bundler_blocks_in_the_future")
+	chainMismatchPolicy := viper.GetString("erc4337_bundler_chain_mismatch_policy")
+	presignedTemplates := viper.GetInt("erc4337_bundler_presigned_templates")
+	ethBundleSimulationUrl := viper.GetString("erc4337_bundler_eth_bundle_simulation_url")
+	searcherRole := viper.GetString("erc4337_bundler_searcher_role")
+	bundleBackendUrl := viper.GetString("erc4337_bundler_bundle_backend_url")
+	beneficiaryPayoutCallData := []byte{}
+	if !variableNotSetOrIsNil("erc4337_bundler_beneficiary_payout_calldata") {
+		beneficiaryPayoutCallData = hexutil.MustDecode(viper.GetString("erc4337_bundler_beneficiary_payout_calldata"))
+	}
+	beneficiaryPayoutGasLimit := viper.GetUint64("erc4337_bundler_beneficiary_payout_gas_limit")
 	otelServiceName := viper.GetString("erc4337_bundler_otel_service_name")
 	otelCollectorHeader := envKeyValStringToMap(viper.GetString("erc4337_bundler_otel_collector_headers"))
 	otelCollectorUrl := viper.GetString("erc4337_bundler_otel_collector_url")
 	otelInsecureMode := viper.GetBool("erc4337_bundler_otel_insecure_mode")
 	altMempoolIPFSGateway := viper.GetString("erc4337_bundler_alt_mempool_ipfs_gateway")
 	altMempoolIds := envArrayToStringSlice(viper.GetString("erc4337_bundler_alt_mempool_ids"))
+	replicaExportEnabled := viper.GetBool("erc4337_bundler_replica_export_enabled")
+	replicaPrimaryUrl := viper.GetString("erc4337_bundler_replica_primary_url")
+	replicaSyncInterval := time.Second * viper.GetDuration("erc4337_bundler_replica_sync_interval_seconds")
+	federationRegistryUrl := viper.GetString("erc4337_bundler_federation_registry_url")
+	federationPublicUrl := viper.GetString("erc4337_bundler_federation_public_url")
+	federationInterval := time.Second * viper.GetDuration("erc4337_bundler_federation_interval_seconds")
+	paymasterServiceUrl := viper.GetString("erc4337_bundler_paymaster_service_url")
+	paymasterServiceTimeout := time.Millisecond * viper.GetDuration("erc4337_bundler_paymaster_service_timeout_ms")
+	httpReadTimeout := time.Second * viper.GetDuration("erc4337_bundler_http_read_timeout_seconds")
+	httpWriteTimeout := time.Second * viper.GetDuration("erc4337_bundler_http_write_timeout_seconds")
+	httpIdleTimeout := time.Second * viper.GetDuration("erc4337_bundler_http_idle_timeout_seconds")
+	httpMaxHeaderBytes := viper.GetInt("erc4337_bundler_http_max_header_bytes")
+	http2Enabled := viper.GetBool("erc4337_bundler_http2_enabled")
+	http2MaxConcurrentStreams := viper.GetUint32("erc4337_bundler_http2_max_concurrent_streams")
+	adaptiveMaxOps := viper.GetInt("erc4337_bundler_adaptive_max_ops")
+	adaptiveMinOps := viper.GetInt("erc4337_bundler_adaptive_min_ops")
+	adaptiveTargetSimLatency := time.Millisecond * viper.GetDuration("erc4337_bundler_adaptive_target_sim_latency_ms")
+	adaptiveDeadline := time.Millisecond * viper.GetDuration("erc4337_bundler_adaptive_deadline_ms")
+	policyScript := strings.Fields(viper.GetString("erc4337_bundler_policy_script"))
+	policyTimeout := time.Millisecond * viper.GetDuration("erc4337_bundler_policy_timeout_ms")
+	prometheusEnabled := viper.GetBool("erc4337_bundler_prometheus_enabled")
+	riskServiceUrl := viper.GetString("erc4337_bundler_risk_service_url")
+	riskServiceTimeout := time.Millisecond * viper.GetDuration("erc4337_bundler_risk_service_timeout_ms")
+	riskServiceFailOpen := viper.GetBool("erc4337_bundler_risk_service_fail_open")
+	riskServiceMaxScore := viper.GetFloat64("erc4337_bundler_risk_service_max_score")
+	auctionUrl := viper.GetString("erc4337_bundler_auction_url")
+	auctionTimeout := time.Millisecond * viper.GetDuration("erc4337_bundler_auction_timeout_ms")
+	auctionRefundPercent := viper.GetInt("erc4337_bundler_auction_refund_percent")
+	mempoolBackend := viper.GetString("erc4337_bundler_mempool_backend")
+	mempoolRedisUrl := viper.GetString("erc4337_bundler_mempool_redis_url")
+	mempoolRedisPrefix := viper.GetString("erc4337_bundler_mempool_redis_prefix")
+	mempoolPostgresUrl := viper.GetString("erc4337_bundler_mempool_postgres_url")
+	mempoolSqlitePath := filepath.Join(dataDirectory, "mempool.sqlite")
+	if !variableNotSetOrIsNil("erc4337_bundler_mempool_sqlite_path") {
+		mempoolSqlitePath = viper.GetString("erc4337_bundler_mempool_sqlite_path")
+	}
+	mempoolSyncInterval := time.Millisecond * viper.GetDuration("erc4337_bundler_mempool_sync_interval_ms")
+	mempoolLeaseTTL := time.Millisecond * viper.GetDuration("erc4337_bundler_mempool_lease_ms")
+	knownFactories := []common.Address{}
+	if !variableNotSetOrIsNil("erc4337_bundler_known_factories") {
+		knownFactories = envArrayToAddressSlice(viper.GetString("erc4337_bundler_known_factories"))
+	}
+	knownFactoriesFeedUrl := viper.GetString("erc4337_bundler_known_factories_feed_url")
+	knownFactoriesInterval := time.Second * viper.GetDuration("erc4337_bundler_known_factories_refresh_interval_seconds")
+	unknownFactoryMaxPendingOps := viper.GetInt("erc4337_bundler_unknown_factory_max_pending_ops")
+	unknownFactoryMaxVGL := big.NewInt(int64(viper.GetInt("erc4337_bundler_unknown_factory_max_verification_gas")))
+	unknownFactoryMaxPerBatch := viper.GetInt("erc4337_bundler_unknown_factory_max_ops_per_batch")
+	maxPendingOpsPerSender := viper.GetInt("erc4337_bundler_max_pending_ops_per_sender")
+	maxPendingOpsPerPaymaster := viper.GetInt("erc4337_bundler_max_pending_ops_per_paymaster")
+	ipReputationThreshold := viper.GetFloat64("erc4337_bundler_ip_reputation_threshold")
+	ipReputationHalfLife := time.Second * viper.GetDuration("erc4337_bundler_ip_reputation_half_life_seconds")
+	ipAsnDatabase := viper.GetString("erc4337_bundler_ip_asn_database")
+	ipCountryHeader := viper.GetString("erc4337_bundler_ip_country_header")
+	chainHeadWsUrl := viper.GetString("erc4337_bundler_chain_head_ws_url")
+	healthCheckTimeout := time.Millisecond * viper.GetDuration("erc4337_bundler_health_check_timeout_ms")
+	healthMinBalance, _ := big.NewInt(0).SetString(viper.GetString("erc4337_bundler_health_min_balance"), 10)
+	chainHeadPollInterval := time.Millisecond * viper.GetDuration("erc4337_bundler_chain_head_poll_interval_ms")
+	shadowAltMempoolIds := envArrayToStringSlice(viper.GetString("erc4337_bundler_shadow_alt_mempool_ids"))
 	isOpStackNetwork := viper.GetBool("erc4337_bundler_is_op_stack_network")
 	isArbStackNetwork := viper.GetBool("erc4337_bundler_is_arb_stack_network")
 	isRIP7212Supported := viper.GetBool("erc4337_bundler_is_rip7212_supported")
+	pvgBoundsBlocks := viper.GetUint64("erc4337_bundler_pvg_bounds_blocks")
 	debugMode := viper.GetBool("erc4337_bundler_debug_mode")
 	ginMode := viper.GetString("erc4337_bundler_gin_mode")
 	crossContract := viper.GetString("qng_meerchange_cross_contract")
@@ -222,22 +1351,151 @@ func GetValues() *Values {
 		Beneficiary:                  beneficiary,
 		NativeBundlerCollectorTracer: nativeBundlerCollectorTracer,
 		NativeBundlerExecutorTracer:  nativeBundlerExecutorTracer,
+		ValidationProfile:            validationProfile,
 		MaxVerificationGas:           maxVerificationGas,
 		MaxBatchGasLimit:             maxBatchGasLimit,
+		MaxCallGasLimit:              maxCallGasLimit,
+		MaxOpGas:                     maxOpGas,
+		MinPriorityFeePaymaster:      minPriorityFeePaymaster,
+		MinPriorityFeeInitCode:       minPriorityFeeInitCode,
+		MinPriorityFeeSender:         minPriorityFeeSender,
+		ReplacementFeeBump:           replacementFeeBump,
 		MaxOpTTL:                     maxOpTTL,
 		OpLookupLimit:                opLookupLimit,
+		OpStatusRetention:            opStatusRetention,
 		ReputationConstants:          NewReputationConstantsFromEnv(),
+		DeterministicMode:            deterministicMode,
+		DeterministicSeed:            deterministicSeed,
+		BanReviewCooldown:            banReviewCooldown,
+		ReputationBufferSize:         reputationBufferSize,
+		ReputationFlushInterval:      reputationFlushInterval,
+		PaymasterStakeGracePeriod:    paymasterStakeGracePeriod,
+		DataDirSoftLimit:             dataDirSoftLimit,
+		DataDirHardLimit:             dataDirHardLimit,
+		DataDirCheckInterval:         dataDirCheckInterval,
+		GasFeedbackEnabled:           gasFeedbackEnabled,
+		GasFeedbackTarget:            gasFeedbackTarget,
+		GasFeedbackMaxPadding:        gasFeedbackMaxPadding,
+		MaxRequestBodySize:           maxRequestBodySize,
+		MaxCallDataSize:              maxCallDataSize,
+		MaxInitCodeSize:              maxInitCodeSize,
+		WarmUpPeerUrls:               warmUpPeerUrls,
+		StuckTxTimeout:               stuckTxTimeout,
+		SigBanThreshold:              sigBanThreshold,
+		SigBanWindow:                 sigBanWindow,
+		SigBanDuration:               sigBanDuration,
+		TrustedProxies:               trustedProxies,
+		EPFailureThreshold:           epFailureThreshold,
+		EPRecoveryInterval:           epRecoveryInterval,
+		VGLSafetyMargin:              vglSafetyMargin,
+		PasskeyVerifiers:             passkeyVerifiers,
+		DelegatedRelayers:            delegatedRelayers,
+		ApiKeyAuthEnabled:            apiKeyAuthEnabled,
+		ApiKeys:                      apiKeys,
+		RateLimits:                   rateLimits,
+		ApiKeyRateLimits:             apiKeyRateLimits,
+		RateLimitStore:               rateLimitStore,
+		HoldOpsDuringSync:            holdOpsDuringSync,
+		MaxHeldOps:                   maxHeldOps,
+		TxType:                       txType,
+		ProfitModel:                  profitModel,
+		SigningApprovalUrl:           signingApprovalUrl,
+		SigningApprovalTimeout:       signingApprovalTimeout,
+		SafeModeRevertThreshold:      safeModeRevertThreshold,
+		SafeModeRecoveryBundles:      safeModeRecoveryBundles,
+		ReliableEntityGasDiscount:    reliableEntityGasDiscount,
+		AdminAddr:                    adminAddr,
+		GrpcAddr:                     grpcAddr,
+		RestApiEnabled:               restApiEnabled,
+		CorsAllowedOrigins:           corsAllowedOrigins,
+		CorsAllowedHeaders:           corsAllowedHeaders,
+		CorsAllowCredentials:         corsAllowCredentials,
+		LogTailSize:                  logTailSize,
+		AdminRpcToken:                adminRpcToken,
+		BackupUrl:                    backupUrl,
+		BackupInterval:               backupInterval,
+		BackupS3Endpoint:             backupS3Endpoint,
+		BackupS3Region:               backupS3Region,
+		BackupS3AccessKey:            backupS3AccessKey,
+		BackupS3SecretKey:            backupS3SecretKey,
+		DashboardAddr:                dashboardAddr,
+		DashboardInterval:            dashboardInterval,
+		AccountFingerprintEnabled:    accountFingerprintEnabled,
+		AccountFingerprints:          accountFingerprints,
 		EthBuilderUrls:               ethBuilderUrls,
+		EthBuilderFormats:            ethBuilderFormats,
 		BlocksInTheFuture:            blocksInTheFuture,
+		ChainMismatchPolicy:          chainMismatchPolicy,
+		BeneficiaryPayoutCallData:    beneficiaryPayoutCallData,
+		BeneficiaryPayoutGasLimit:    beneficiaryPayoutGasLimit,
+		PresignedTemplates:           presignedTemplates,
+		EthBundleSimulationUrl:       ethBundleSimulationUrl,
+		SearcherRole:                 searcherRole,
+		BundleBackendUrl:             bundleBackendUrl,
+		HandoffDictionary:            handoffDictionary,
 		OTELServiceName:              otelServiceName,
 		OTELCollectorHeaders:         otelCollectorHeader,
 		OTELCollectorUrl:             otelCollectorUrl,
 		OTELInsecureMode:             otelInsecureMode,
 		AltMempoolIPFSGateway:        altMempoolIPFSGateway,
 		AltMempoolIds:                altMempoolIds,
+		ReplicaExportEnabled:         replicaExportEnabled,
+		ReplicaPrimaryUrl:            replicaPrimaryUrl,
+		ReplicaSyncInterval:          replicaSyncInterval,
+		FederationRegistryUrl:        federationRegistryUrl,
+		FederationPublicUrl:          federationPublicUrl,
+		FederationInterval:           federationInterval,
+		PaymasterServiceUrl:          paymasterServiceUrl,
+		PaymasterServiceTimeout:      paymasterServiceTimeout,
+		HTTPReadTimeout:              httpReadTimeout,
+		HTTPWriteTimeout:             httpWriteTimeout,
+		HTTPIdleTimeout:              httpIdleTimeout,
+		HTTPMaxHeaderBytes:           httpMaxHeaderBytes,
+		HTTP2Enabled:                 http2Enabled,
+		HTTP2MaxConcurrentStreams:    http2MaxConcurrentStreams,
+		AdaptiveMaxOps:               adaptiveMaxOps,
+		AdaptiveMinOps:               adaptiveMinOps,
+		AdaptiveTargetSimLatency:     adaptiveTargetSimLatency,
+		AdaptiveDeadline:             adaptiveDeadline,
+		PolicyScript:                 policyScript,
+		PolicyTimeout:                policyTimeout,
+		PrometheusEnabled:            prometheusEnabled,
+		RiskServiceUrl:               riskServiceUrl,
+		RiskServiceTimeout:           riskServiceTimeout,
+		RiskServiceFailOpen:          riskServiceFailOpen,
+		RiskServiceMaxScore:          riskServiceMaxScore,
+		AuctionUrl:                   auctionUrl,
+		AuctionTimeout:               auctionTimeout,
+		AuctionRefundPercent:         auctionRefundPercent,
+		MempoolBackend:               mempoolBackend,
+		MempoolRedisUrl:              mempoolRedisUrl,
+		MempoolRedisPrefix:           mempoolRedisPrefix,
+		MempoolPostgresUrl:           mempoolPostgresUrl,
+		MempoolSqlitePath:            mempoolSqlitePath,
+		MempoolSyncInterval:          mempoolSyncInterval,
+		MempoolLeaseTTL:              mempoolLeaseTTL,
+		KnownFactories:               knownFactories,
+		KnownFactoriesFeedUrl:        knownFactoriesFeedUrl,
+		KnownFactoriesInterval:       knownFactoriesInterval,
+		UnknownFactoryMaxPendingOps:  unknownFactoryMaxPendingOps,
+		UnknownFactoryMaxVGL:         unknownFactoryMaxVGL,
+		UnknownFactoryMaxPerBatch:    unknownFactoryMaxPerBatch,
+		MaxPendingOpsPerSender:       maxPendingOpsPerSender,
+		MaxPendingOpsPerPaymaster:    maxPendingOpsPerPaymaster,
+		IpReputationThreshold:        ipReputationThreshold,
+		IpReputationHalfLife:         ipReputationHalfLife,
+		IpAsnDatabase:                ipAsnDatabase,
+		IpCountryHeader:              ipCountryHeader,
+		IpPolicies:                   ipPolicies,
+		ChainHeadWsUrl:               chainHeadWsUrl,
+		ChainHeadPollInterval:        chainHeadPollInterval,
+		HealthCheckTimeout:           healthCheckTimeout,
+		HealthMinBalance:             healthMinBalance,
+		ShadowAltMempoolIds:          shadowAltMempoolIds,
 		IsOpStackNetwork:             isOpStackNetwork,
 		IsArbStackNetwork:            isArbStackNetwork,
 		IsRIP7212Supported:           isRIP7212Supported,
+		PvgBoundsBlocks:              pvgBoundsBlocks,
 		DebugMode:                    debugMode,
 		GinMode:                      ginMode,
 		CrossContract:                crossContract,
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/viper"
+)
+
+// getValues runs GetValues in the given mode with only the required variables and the given overrides set. It
+// returns the values or the panic message if the config is invalid.
+func getValues(t *testing.T, mode string, env map[string]string) (vals *Values, msg string) {
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	required := map[string]string{
+		"erc4337_bundler_eth_client_url": "http://localhost:8545",
+		"erc4337_bundler_private_key":    hexutil.Encode(crypto.FromECDSA(pk))[2:],
+		"qng_meerchange_cross_contract":  "0x0000000000000000000000000000000000000001",
+	}
+	if mode == "searcher" {
+		required["erc4337_bundler_eth_builder_urls"] = "http://localhost:8546"
+	}
+	for k, v := range required {
+		if _, ok := env[k]; !ok {
+			t.Setenv(strings.ToUpper(k), v)
+		}
+	}
+	for k, v := range env {
+		t.Setenv(strings.ToUpper(k), v)
+	}
+
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("mode", mode)
+
+	defer func() {
+		if r := recover(); r != nil {
+			msg = fmt.Sprint(r)
+		}
+	}()
+	return GetValues(), ""
+}
+
+// TestChainMismatchPolicy verifies that the chain mismatch policy defaults to fail and that only known
+// policies are accepted.
+func TestChainMismatchPolicy(t *testing.T) {
+	vals, msg := getValues(t, "searcher", nil)
+	if msg != "" {
+		t.Fatalf("got %s, want nil", msg)
+	}
+	if vals.ChainMismatchPolicy != ChainMismatchFail {
+		t.Fatalf("got %s, want %s", vals.ChainMismatchPolicy, ChainMismatchFail)
+	}
+
+	vals, msg = getValues(t, "searcher", map[string]string{"erc4337_bundler_chain_mismatch_policy": "auto"})
+	if msg != "" {
+		t.Fatalf("got %s, want nil", msg)
+	}
+	if vals.ChainMismatchPolicy != ChainMismatchAuto {
+		t.Fatalf("got %s, want %s", vals.ChainMismatchPolicy, ChainMismatchAuto)
+	}
+
+	_, msg = getValues(t, "searcher", map[string]string{"erc4337_bundler_chain_mismatch_policy": "ignore"})
+	if !strings.Contains(msg, "erc4337_bundler_chain_mismatch_policy") {
+		t.Fatalf("got %q, want chain mismatch policy error", msg)
+	}
+}
+
+// TestVGLSafetyMargin verifies that the verificationGasLimit margin defaults to 25 percent and cannot be
+// negative.
+func TestVGLSafetyMargin(t *testing.T) {
+	vals, msg := getValues(t, "searcher", nil)
+	if msg != "" {
+		t.Fatalf("got %s, want nil", msg)
+	}
+	if vals.VGLSafetyMargin != 25 {
+		t.Fatalf("got %d, want 25", vals.VGLSafetyMargin)
+	}
+
+	_, msg = getValues(t, "searcher", map[string]string{"erc4337_bundler_vgl_safety_margin": "-1"})
+	if !strings.Contains(msg, "erc4337_bundler_vgl_safety_margin") {
+		t.Fatalf("got %q, want vgl safety margin error", msg)
+	}
+}
+
+// TestReportsAllProblems verifies that every invalid variable is reported in a single error instead of
+// stopping at the first one.
+func TestReportsAllProblems(t *testing.T) {
+	_, msg := getValues(t, "private", map[string]string{
+		"erc4337_bundler_eth_client_url":        "",
+		"erc4337_bundler_eth_builder_urls":      "http://localhost:8546",
+		"erc4337_bundler_chain_mismatch_policy": "ignore",
+	})
+	if !strings.Contains(msg, "found 3 problem(s)") {
+		t.Fatalf("got %q, want 3 problems", msg)
+	}
+	for _, key := range []string{
+		"erc4337_bundler_eth_client_url: not set",
+		"erc4337_bundler_eth_builder_urls: only used in searcher mode",
+		"erc4337_bundler_chain_mismatch_policy: must be one of",
+	} {
+		if !strings.Contains(msg, key) {
+			t.Fatalf("got %q, want %q", msg, key)
+		}
+	}
+}
+
+// TestHTTPServerValues verifies the HTTP server defaults and that invalid limits are rejected.
+func TestHTTPServerValues(t *testing.T) {
+	vals, msg := getValues(t, "searcher", map[string]string{"erc4337_bundler_http_read_timeout_seconds": "5"})
+	if msg != "" {
+		t.Fatalf("got %s, want nil", msg)
+	}
+	if vals.HTTPReadTimeout != 5*time.Second || vals.HTTPWriteTimeout != 0 {
+		t.Fatalf("got %s/%s, want 5s/0s", vals.HTTPReadTimeout, vals.HTTPWriteTimeout)
+	}
+	if vals.HTTPMaxHeaderBytes != 1<<20 || vals.HTTP2Enabled || vals.HTTP2MaxConcurrentStreams != 250 {
+		t.Fatalf(
+			"got %d/%v/%d, want %d/false/250",
+			vals.HTTPMaxHeaderBytes,
+			vals.HTTP2Enabled,
+			vals.HTTP2MaxConcurrentStreams,
+			1<<20,
+		)
+	}
+
+	_, msg = getValues(t, "searcher", map[string]string{
+		"erc4337_bundler_http_idle_timeout_seconds":    "-1",
+		"erc4337_bundler_http_max_header_bytes":        "0",
+		"erc4337_bundler_http2_max_concurrent_streams": "0",
+	})
+	for _, key := range []string{
+		"erc4337_bundler_http_idle_timeout_seconds",
+		"erc4337_bundler_http_max_header_bytes",
+		"erc4337_bundler_http2_max_concurrent_streams",
+	} {
+		if !strings.Contains(msg, key) {
+			t.Fatalf("got %q, want %s error", msg, key)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package config
+
+import "runtime/debug"
+
+// Version is the release version of the bundler. This is set at build time with:
+//
+//	go build -ldflags "-X github.com/stackup-wallet/stackup-bundler/internal/config.Version=<version>"
+var Version = "dev"
+
+// GetCommit returns the VCS revision that the binary was built from if available.
+func GetCommit() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" {
+			return s.Value
+		}
+	}
+	return "unknown"
+}
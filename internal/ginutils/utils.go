@@ -18,3 +18,30 @@ func GetClientIPFromXFF(c *gin.Context) string {
 
 	return c.ClientIP()
 }
+
+// RelayerContextKey is the gin context key set to the address of an authenticated relayer submitting requests
+// on behalf of its users.
+const RelayerContextKey = "relayer"
+
+// GetRelayer returns the address of the authenticated relayer for the request if one is set.
+func GetRelayer(c *gin.Context) (string, bool) {
+	v, ok := c.Get(RelayerContextKey)
+	if !ok {
+		return "", false
+	}
+	s, ok := v.(string)
+	return s, ok
+}
+
+// ApiKeyContextKey is the gin context key set to the name of the API key used to authenticate a request.
+const ApiKeyContextKey = "api_key"
+
+// GetApiKey returns the name of the API key used to authenticate the request if one is set.
+func GetApiKey(c *gin.Context) (string, bool) {
+	v, ok := c.Get(ApiKeyContextKey)
+	if !ok {
+		return "", false
+	}
+	s, ok := v.(string)
+	return s, ok
+}
@@ -0,0 +1,151 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/zerologr"
+	"github.com/rs/zerolog"
+)
+
+// Tail keeps the most recent log events in memory and fans out new events to subscribers. It implements
+// io.Writer so that it can be added as an output of the zerolog logger.
+type Tail struct {
+	mu     sync.Mutex
+	events [][]byte
+	next   int
+	full   bool
+	subs   map[int]chan []byte
+	nextID int
+}
+
+// NewTail returns a Tail that keeps up to size recent events.
+func NewTail(size int) *Tail {
+	return &Tail{
+		events: make([][]byte, size),
+		subs:   make(map[int]chan []byte),
+	}
+}
+
+// Write implements the io.Writer interface. Each call is expected to contain a single JSON log event.
+// Subscribers that are not keeping up will miss events rather than block the logger.
+func (t *Tail) Write(p []byte) (int, error) {
+	ev := bytes.TrimSpace(append([]byte{}, p...))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events[t.next] = ev
+	t.next = (t.next + 1) % len(t.events)
+	if t.next == 0 {
+		t.full = true
+	}
+	for _, ch := range t.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Recent returns the buffered events from oldest to newest.
+func (t *Tail) Recent() [][]byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.full {
+		return append([][]byte{}, t.events[:t.next]...)
+	}
+	return append(append([][]byte{}, t.events[t.next:]...), t.events[:t.next]...)
+}
+
+// Subscribe returns a channel of new events and a function to unsubscribe.
+func (t *Tail) Subscribe() (<-chan []byte, func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	id := t.nextID
+	t.nextID++
+	ch := make(chan []byte, 256)
+	t.subs[id] = ch
+	return ch, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.subs, id)
+	}
+}
+
+// TailFilter selects log events by minimum level, logger name, and userOpHash. Empty fields match all events.
+type TailFilter struct {
+	Level  string
+	Module string
+	OpHash string
+}
+
+// Matches returns true if the event passes the filter. The module matches any part of the logger name and the
+// op hash matches anywhere in the event so that batch logs with a list of hashes are included.
+func (f *TailFilter) Matches(ev []byte) bool {
+	var fields map[string]any
+	if err := json.Unmarshal(ev, &fields); err != nil {
+		return false
+	}
+
+	if f.Level != "" {
+		min, err := zerolog.ParseLevel(f.Level)
+		if err != nil {
+			return false
+		}
+		lvl, _ := fields[zerolog.LevelFieldName].(string)
+		if got, err := zerolog.ParseLevel(lvl); err != nil || got < min {
+			return false
+		}
+	}
+	if f.Module != "" {
+		name, _ := fields[zerologr.NameFieldName].(string)
+		if !strings.Contains(name, f.Module) {
+			return false
+		}
+	}
+	if f.OpHash != "" && !bytes.Contains(bytes.ToLower(ev), []byte(strings.ToLower(f.OpHash))) {
+		return false
+	}
+	return true
+}
+
+// TailHandler returns a gin handler that streams log events as server-sent events. Buffered events are sent
+// first followed by new events until the client disconnects. Events are filtered by the level, module, and
+// op_hash query parameters.
+func TailHandler(t *Tail) gin.HandlerFunc {
+	return func(g *gin.Context) {
+		f := &TailFilter{
+			Level:  g.Query("level"),
+			Module: g.Query("module"),
+			OpHash: g.Query("op_hash"),
+		}
+		ch, unsubscribe := t.Subscribe()
+		defer unsubscribe()
+
+		for _, ev := range t.Recent() {
+			if f.Matches(ev) {
+				g.SSEvent("log", string(ev))
+			}
+		}
+		g.Writer.Flush()
+
+		ctx := g.Request.Context()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev := <-ch:
+				if f.Matches(ev) {
+					g.SSEvent("log", string(ev))
+					g.Writer.Flush()
+				}
+			}
+		}
+	}
+}
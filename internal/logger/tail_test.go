@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	infoEvent  = `{"level":"info","logger":"stackup_bundler/client","userop_hash":"0xABC","message":"ok"}`
+	errorEvent = `{"level":"error","logger":"stackup_bundler/bundler","batch_userop_hashes":["0xabc","0xdef"],"message":"fail"}`
+	debugEvent = `{"level":"debug","logger":"stackup_bundler/client","message":"noise"}`
+)
+
+// TestTailKeepsRecentEvents verifies that only the most recent events are kept in order.
+func TestTailKeepsRecentEvents(t *testing.T) {
+	tail := NewTail(2)
+	for _, ev := range []string{infoEvent, errorEvent, debugEvent} {
+		_, _ = tail.Write([]byte(ev + "\n"))
+	}
+
+	got := tail.Recent()
+	if len(got) != 2 || string(got[0]) != errorEvent || string(got[1]) != debugEvent {
+		t.Fatalf("got %s, want last 2 events", got)
+	}
+}
+
+// TestTailFilter verifies that events are filtered by minimum level, module, and op hash.
+func TestTailFilter(t *testing.T) {
+	cases := []struct {
+		filter TailFilter
+		want   []bool
+	}{
+		{TailFilter{}, []bool{true, true, true}},
+		{TailFilter{Level: "info"}, []bool{true, true, false}},
+		{TailFilter{Level: "error"}, []bool{false, true, false}},
+		{TailFilter{Module: "client"}, []bool{true, false, true}},
+		{TailFilter{OpHash: "0xabc"}, []bool{true, true, false}},
+		{TailFilter{Module: "bundler", OpHash: "0xdef"}, []bool{false, true, false}},
+	}
+	for _, c := range cases {
+		for i, ev := range []string{infoEvent, errorEvent, debugEvent} {
+			if got := c.filter.Matches([]byte(ev)); got != c.want[i] {
+				t.Fatalf("got %v for filter %+v and event %d, want %v", got, c.filter, i, c.want[i])
+			}
+		}
+	}
+}
+
+// TestTailHandler verifies that buffered and new events are streamed as server-sent events.
+func TestTailHandler(t *testing.T) {
+	tail := NewTail(10)
+	_, _ = tail.Write([]byte(infoEvent))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/logs/tail", TailHandler(tail))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/logs/tail?level=info", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		r.ServeHTTP(w, req)
+		close(done)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	_, _ = tail.Write([]byte(debugEvent))
+	_, _ = tail.Write([]byte(errorEvent))
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+
+	body := w.Body.String()
+	if strings.Count(body, "event:log") != 2 || !strings.Contains(body, `"message":"fail"`) {
+		t.Fatalf("got %s, want info and error events", body)
+	}
+	if strings.Contains(body, "noise") {
+		t.Fatalf("got %s, want no debug events", body)
+	}
+}
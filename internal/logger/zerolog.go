@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"io"
 	"os"
 
 	"github.com/go-logr/logr"
@@ -10,6 +11,15 @@ import (
 
 // NewZeroLogr returns a Zerolog logger wrapped in a go-logr/logr interface.
 func NewZeroLogr() logr.Logger {
-	zl := zerolog.New(os.Stderr).With().Caller().Timestamp().Logger()
+	return NewZeroLogrWithTail(nil)
+}
+
+// NewZeroLogrWithTail is like NewZeroLogr but also writes each log event to the Tail, if it is not nil.
+func NewZeroLogrWithTail(t *Tail) logr.Logger {
+	var w io.Writer = os.Stderr
+	if t != nil {
+		w = io.MultiWriter(os.Stderr, t)
+	}
+	zl := zerolog.New(w).With().Caller().Timestamp().Logger()
 	return zerologr.New(&zl)
 }
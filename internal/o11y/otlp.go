@@ -0,0 +1,87 @@
+//go:build !noo11y
+
+package o11y
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc/credentials"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func IsEnabled(serviceName string) bool {
+	return len(serviceName) > 0
+}
+
+func InitTracer(opts *Opts) func() {
+	secureOption := otlptracegrpc.WithTLSCredentials(credentials.NewClientTLSFromCert(nil, ""))
+	if opts.InsecureMode {
+		secureOption = otlptracegrpc.WithInsecure()
+	}
+
+	exporter, err := otlptrace.New(
+		context.Background(),
+		otlptracegrpc.NewClient(
+			secureOption,
+			otlptracegrpc.WithHeaders(opts.CollectorHeader),
+			otlptracegrpc.WithEndpoint(opts.CollectorUrl),
+		),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	otel.SetTracerProvider(
+		sdktrace.NewTracerProvider(
+			sdktrace.WithSampler(sdktrace.AlwaysSample()),
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(initResources(opts)),
+		),
+	)
+	propagator := propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	otel.SetTextMapPropagator(propagator)
+	return func() {
+		_ = exporter.Shutdown(context.Background())
+	}
+}
+
+// InitMetrics sets a MeterProvider that exports to the OTEL collector. Any extra readers, such as from a
+// PrometheusExporter, also receive all metrics.
+func InitMetrics(opts *Opts, readers ...sdkmetric.Reader) func() {
+	secureOption := otlpmetricgrpc.WithTLSCredentials(credentials.NewClientTLSFromCert(nil, ""))
+	if opts.InsecureMode {
+		secureOption = otlpmetricgrpc.WithInsecure()
+	}
+
+	exporter, err := otlpmetricgrpc.New(
+		context.Background(),
+		secureOption,
+		otlpmetricgrpc.WithHeaders(opts.CollectorHeader),
+		otlpmetricgrpc.WithEndpoint(opts.CollectorUrl),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	readers = append(readers, sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(30*time.Second)))
+	InitLocalMetrics(opts, readers...)
+	return func() {
+		_ = exporter.Shutdown(context.Background())
+	}
+}
+
+// GinMiddleware returns a gin middleware that starts a trace span for each request.
+func GinMiddleware(serviceName string) gin.HandlerFunc {
+	return otelgin.Middleware(serviceName)
+}
@@ -0,0 +1,38 @@
+//go:build noo11y
+
+package o11y
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// IsEnabled always returns false for builds without the OTEL exporters. The process exits if a service name is
+// set so that a misconfigured deployment doesn't silently lose traces.
+func IsEnabled(serviceName string) bool {
+	if len(serviceName) > 0 {
+		log.Fatal("error: OTEL is not available in a binary built with the noo11y tag.")
+	}
+	return false
+}
+
+// InitTracer is a no-op for builds without the OTEL exporters.
+func InitTracer(opts *Opts) func() {
+	return func() {}
+}
+
+// InitMetrics only sets a MeterProvider for the given readers for builds without the OTEL exporters.
+func InitMetrics(opts *Opts, readers ...sdkmetric.Reader) func() {
+	InitLocalMetrics(opts, readers...)
+	return func() {}
+}
+
+// GinMiddleware returns a passthrough middleware for builds without the OTEL exporters.
+func GinMiddleware(serviceName string) gin.HandlerFunc {
+	return func(g *gin.Context) {
+		g.Next()
+	}
+}
@@ -0,0 +1,29 @@
+//go:build noo11y
+
+package o11y
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestDisabledWithoutServiceName verifies that OTEL is reported as disabled and that the tracer and middleware
+// are no-ops in builds with the noo11y tag.
+func TestDisabledWithoutServiceName(t *testing.T) {
+	if IsEnabled("") {
+		t.Fatal("got true, want false")
+	}
+	InitTracer(&Opts{})()
+
+	r := gin.New()
+	r.Use(GinMiddleware("bundler"))
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", w.Code, http.StatusNoContent)
+	}
+}
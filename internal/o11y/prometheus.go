@@ -0,0 +1,164 @@
+package o11y
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var invalidPromChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// PrometheusExporter serves all metrics from the global MeterProvider in the Prometheus text format. This
+// allows operators to scrape the bundler directly without running an OTEL collector.
+type PrometheusExporter struct {
+	reader sdkmetric.Reader
+}
+
+// NewPrometheusExporter returns a PrometheusExporter. Its Reader must be registered with the MeterProvider.
+func NewPrometheusExporter() *PrometheusExporter {
+	return &PrometheusExporter{reader: sdkmetric.NewManualReader()}
+}
+
+// Reader returns the metric reader used to collect metrics on each scrape.
+func (e *PrometheusExporter) Reader() sdkmetric.Reader {
+	return e.reader
+}
+
+func sanitizeName(name string) string {
+	name = invalidPromChars.ReplaceAllString(name, "_")
+	if len(name) > 0 && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+func escapeLabel(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	return strings.ReplaceAll(v, `"`, `\"`)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// formatLabels returns the attributes as sorted Prometheus labels along with any extra label.
+func formatLabels(attrs attribute.Set, extra ...string) string {
+	labels := []string{}
+	iter := attrs.Iter()
+	for iter.Next() {
+		kv := iter.Attribute()
+		labels = append(labels, fmt.Sprintf(`%s="%s"`, sanitizeName(string(kv.Key)), escapeLabel(kv.Value.Emit())))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		labels = append(labels, fmt.Sprintf(`%s="%s"`, extra[i], escapeLabel(extra[i+1])))
+	}
+	if len(labels) == 0 {
+		return ""
+	}
+	sort.Strings(labels)
+	return "{" + strings.Join(labels, ",") + "}"
+}
+
+func writeHeader(buf *bytes.Buffer, name, desc, typ string) {
+	if desc != "" {
+		fmt.Fprintf(buf, "# HELP %s %s\n", name, strings.ReplaceAll(desc, "\n", " "))
+	}
+	fmt.Fprintf(buf, "# TYPE %s %s\n", name, typ)
+}
+
+func writeGauge[N int64 | float64](buf *bytes.Buffer, name, desc string, dps []metricdata.DataPoint[N]) {
+	writeHeader(buf, name, desc, "gauge")
+	for _, dp := range dps {
+		fmt.Fprintf(buf, "%s%s %s\n", name, formatLabels(dp.Attributes), formatFloat(float64(dp.Value)))
+	}
+}
+
+func writeSum[N int64 | float64](buf *bytes.Buffer, name, desc string, sum metricdata.Sum[N]) {
+	typ := "gauge"
+	if sum.IsMonotonic {
+		typ = "counter"
+		if !strings.HasSuffix(name, "_total") {
+			name += "_total"
+		}
+	}
+	writeHeader(buf, name, desc, typ)
+	for _, dp := range sum.DataPoints {
+		fmt.Fprintf(buf, "%s%s %s\n", name, formatLabels(dp.Attributes), formatFloat(float64(dp.Value)))
+	}
+}
+
+func writeHistogram[N int64 | float64](buf *bytes.Buffer, name, desc string, dps []metricdata.HistogramDataPoint[N]) {
+	writeHeader(buf, name, desc, "histogram")
+	for _, dp := range dps {
+		var count uint64
+		for i, bound := range dp.Bounds {
+			count += dp.BucketCounts[i]
+			labels := formatLabels(dp.Attributes, "le", formatFloat(bound))
+			fmt.Fprintf(buf, "%s_bucket%s %d\n", name, labels, count)
+		}
+		fmt.Fprintf(buf, "%s_bucket%s %d\n", name, formatLabels(dp.Attributes, "le", "+Inf"), dp.Count)
+		fmt.Fprintf(buf, "%s_sum%s %s\n", name, formatLabels(dp.Attributes), formatFloat(float64(dp.Sum)))
+		fmt.Fprintf(buf, "%s_count%s %d\n", name, formatLabels(dp.Attributes), dp.Count)
+	}
+}
+
+// Write collects all metrics and writes them to buf in the Prometheus text format.
+func (e *PrometheusExporter) Write(ctx context.Context, buf *bytes.Buffer) error {
+	var rm metricdata.ResourceMetrics
+	if err := e.reader.Collect(ctx, &rm); err != nil {
+		return err
+	}
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			name := sanitizeName(m.Name)
+			switch data := m.Data.(type) {
+			case metricdata.Gauge[int64]:
+				writeGauge(buf, name, m.Description, data.DataPoints)
+			case metricdata.Gauge[float64]:
+				writeGauge(buf, name, m.Description, data.DataPoints)
+			case metricdata.Sum[int64]:
+				writeSum(buf, name, m.Description, data)
+			case metricdata.Sum[float64]:
+				writeSum(buf, name, m.Description, data)
+			case metricdata.Histogram[int64]:
+				writeHistogram(buf, name, m.Description, data.DataPoints)
+			case metricdata.Histogram[float64]:
+				writeHistogram(buf, name, m.Description, data.DataPoints)
+			}
+		}
+	}
+	return nil
+}
+
+// Handler returns a gin handler for serving metrics to a Prometheus scraper.
+func (e *PrometheusExporter) Handler() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		var buf bytes.Buffer
+		if err := e.Write(g.Request.Context(), &buf); err != nil {
+			_ = g.Error(err)
+			g.Status(http.StatusInternalServerError)
+			return
+		}
+		g.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", buf.Bytes())
+	}
+}
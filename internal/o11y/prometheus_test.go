@@ -0,0 +1,59 @@
+package o11y
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// TestPrometheusExporterWrite verifies that counters, gauges, and histograms are written in the Prometheus text
+// format with their attributes as labels.
+func TestPrometheusExporterWrite(t *testing.T) {
+	e := NewPrometheusExporter()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(e.Reader())).Meter("test")
+
+	counter, err := meter.Int64Counter("bundler_bundles_sent")
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	hist, err := meter.Int64Histogram("bundler_op_inclusion_latency")
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	_, err = meter.Int64ObservableGauge(
+		"bundler_mempool_size",
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(3)
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	attrs := metric.WithAttributes(attribute.String("entrypoint", "0xabc"))
+	counter.Add(context.Background(), 2, attrs)
+	hist.Record(context.Background(), 7, attrs)
+
+	var buf bytes.Buffer
+	if err := e.Write(context.Background(), &buf); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"# TYPE bundler_bundles_sent_total counter\n",
+		`bundler_bundles_sent_total{entrypoint="0xabc"} 2` + "\n",
+		"# TYPE bundler_mempool_size gauge\nbundler_mempool_size 3\n",
+		`bundler_op_inclusion_latency_bucket{entrypoint="0xabc",le="10"} 1` + "\n",
+		`bundler_op_inclusion_latency_bucket{entrypoint="0xabc",le="+Inf"} 1` + "\n",
+		`bundler_op_inclusion_latency_count{entrypoint="0xabc"} 1` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("got %s, want output containing %s", out, want)
+		}
+	}
+}
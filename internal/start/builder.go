@@ -0,0 +1,113 @@
+//go:build !nobuilder
+
+package start
+
+import (
+	"context"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/go-logr/logr"
+	"github.com/metachris/flashbotsrpc"
+	"github.com/stackup-wallet/stackup-bundler/internal/config"
+	"github.com/stackup-wallet/stackup-bundler/pkg/bundler"
+	"github.com/stackup-wallet/stackup-bundler/pkg/chainhead"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/builder"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+	"go.opentelemetry.io/otel"
+)
+
+// builderClient adapts a *builder.BuilderClient to the blockBuilder interface.
+type builderClient struct {
+	bc *builder.BuilderClient
+}
+
+func (c *builderClient) SendUserOperation() modules.BatchHandlerFunc {
+	return c.bc.SendUserOperation()
+}
+
+func (c *builderClient) RunTemplates(
+	b *bundler.Bundler,
+	eps []common.Address,
+	n int,
+	head *chainhead.Watcher,
+	l logr.Logger,
+	ordering ...modules.BatchHandlerFunc,
+) {
+	getHead := func() (uint64, error) {
+		h, err := head.Latest()
+		if err != nil {
+			return 0, err
+		}
+		return h.Number.Uint64(), nil
+	}
+	c.bc.RunTemplates(b.NewContext, eps, n, head.NewHeads(), getHead, l, ordering...)
+}
+
+// isBuilderCompatible returns true if the chain supports the Block Builder API.
+func isBuilderCompatible(chain *big.Int) bool {
+	return builder.CompatibleChainIDs.Contains(chain.Uint64())
+}
+
+// newBlockBuilder returns a client for sending bundles to the configured block builders.
+func newBlockBuilder(
+	eoa *signer.EOA,
+	eth *ethclient.Client,
+	beneficiary common.Address,
+	conf *config.Values,
+) blockBuilder {
+	// TODO: Create separate go-routine for tracking transactions sent to the block builder.
+	fb := flashbotsrpc.NewBuilderBroadcastRPC(conf.EthBuilderUrls)
+	bc := builder.New(eoa, eth, fb, beneficiary, conf.BlocksInTheFuture)
+	bc.SetAdapters(getBuilderAdapters(conf))
+	bc.SetApproveFunc(getApproveFunc(conf))
+	if len(conf.BeneficiaryPayoutCallData) > 0 {
+		code, err := eth.CodeAt(context.Background(), beneficiary, nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(code) == 0 {
+			log.Fatalf("error: beneficiary %s must be a contract to receive a payout transaction.", beneficiary)
+		}
+		bc.SetPayoutCallData(conf.BeneficiaryPayoutCallData)
+		bc.SetPayoutGasLimit(conf.BeneficiaryPayoutGasLimit)
+	}
+	if conf.EthBundleSimulationUrl != "" {
+		bc.SetSimulator(flashbotsrpc.New(conf.EthBundleSimulationUrl))
+	}
+	if err := bc.UseMeter(otel.GetMeterProvider().Meter("builder")); err != nil {
+		log.Fatal(err)
+	}
+	return &builderClient{bc}
+}
+
+// getBuilderAdapters returns an Adapter for each builder URL using the format at the same index. Builders
+// without a format use the Flashbots format.
+func getBuilderAdapters(conf *config.Values) []builder.Adapter {
+	endpoints := []*builder.Endpoint{}
+	for i, url := range conf.EthBuilderUrls {
+		e := &builder.Endpoint{Url: url, Format: builder.FormatFlashbots}
+		if i < len(conf.EthBuilderFormats) {
+			format, auth := config.SplitBuilderFormat(conf.EthBuilderFormats[i])
+			switch format {
+			case config.BuilderFormatBloxroute:
+				e.Format = builder.FormatBloxroute
+			case config.BuilderFormatTitan:
+				e.Format = builder.FormatTitan
+			case config.BuilderFormatRawTx:
+				e.Format = builder.FormatRawTx
+			}
+			e.Auth = auth
+		}
+		endpoints = append(endpoints, e)
+	}
+
+	adapters, err := builder.NewAdapters(endpoints)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return adapters
+}
@@ -0,0 +1,30 @@
+//go:build nobuilder
+
+package start
+
+import (
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/internal/config"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+)
+
+// isBuilderCompatible always returns false for builds without the block builder client.
+func isBuilderCompatible(chain *big.Int) bool {
+	return false
+}
+
+// newBlockBuilder is never called for builds without the block builder client since every chain is treated as
+// incompatible.
+func newBlockBuilder(
+	eoa *signer.EOA,
+	eth *ethclient.Client,
+	beneficiary common.Address,
+	conf *config.Values,
+) blockBuilder {
+	log.Fatal("error: block builder is not available in a binary built with the nobuilder tag.")
+	return nil
+}
@@ -0,0 +1,124 @@
+package start
+
+import (
+	"log"
+	"os"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/config"
+	"github.com/stackup-wallet/stackup-bundler/internal/logger"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backup"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/snapshot"
+)
+
+// RestoreBackup loads a backup from the configured backup URL into the data directory. This should be run
+// before starting the bundler on a new disk.
+func RestoreBackup(name string) {
+	conf := config.GetValues()
+
+	logr := logger.NewZeroLogr().
+		WithName("stackup_bundler").
+		WithValues("command", "restore")
+
+	if conf.BackupUrl == "" {
+		log.Fatal("error: erc4337_bundler_backup_url is not set")
+	}
+	store, err := getBackupStore(conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := badger.Open(badger.DefaultOptions(conf.DataDirectory))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	restored, err := backup.Restore(db, store, name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	logr.WithValues("name", restored).
+		WithValues("data_directory", conf.DataDirectory).
+		Info("restore ok")
+}
+
+// openSnapshotMempool opens the mempool of the configured storage backend for a snapshot command. The caller
+// must close the returned DB.
+func openSnapshotMempool(conf *config.Values, logr logr.Logger) (*badger.DB, *mempool.Mempool) {
+	db, err := badger.Open(badger.DefaultOptions(conf.DataDirectory))
+	if err != nil {
+		log.Fatal(err)
+	}
+	mem, err := getMempool(db, conf, logr)
+	if err != nil {
+		db.Close()
+		log.Fatal(err)
+	}
+	return db, mem
+}
+
+// ExportMempool writes a snapshot of all pending UserOperations for the supported EntryPoints to a file. With
+// the badger backend, the bundler must not be running against the same data directory.
+func ExportMempool(file string, format string) {
+	conf := config.GetValues()
+
+	logr := logger.NewZeroLogr().
+		WithName("stackup_bundler").
+		WithValues("command", "mempool_export")
+
+	db, mem := openSnapshotMempool(conf, logr)
+	defer db.Close()
+
+	s, err := snapshot.Export(mem, conf.SupportedEntryPoints)
+	if err != nil {
+		log.Fatal(err)
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	if err := snapshot.Encode(f, s, format); err != nil {
+		log.Fatal(err)
+	}
+	logr.WithValues("file", file).
+		WithValues("format", format).
+		WithValues("ops", s.Len()).
+		Info("mempool export ok")
+}
+
+// ImportMempool loads a snapshot written by ExportMempool or admin_exportMempool into the mempool of the
+// configured storage backend. With the badger backend, the bundler must not be running against the same data
+// directory.
+func ImportMempool(file string, format string) {
+	conf := config.GetValues()
+
+	logr := logger.NewZeroLogr().
+		WithName("stackup_bundler").
+		WithValues("command", "mempool_import")
+
+	f, err := os.Open(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	s, err := snapshot.Decode(f, format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, mem := openSnapshotMempool(conf, logr)
+	defer db.Close()
+
+	n, err := snapshot.Import(mem, conf.SupportedEntryPoints, s)
+	if err != nil {
+		log.Fatal(err)
+	}
+	logr.WithValues("file", file).
+		WithValues("format", format).
+		WithValues("ops", n).
+		Info("mempool import ok")
+}
@@ -0,0 +1,122 @@
+package start
+
+import (
+	"log"
+	"strings"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/config"
+	"github.com/stackup-wallet/stackup-bundler/pkg/apikey"
+	"github.com/stackup-wallet/stackup-bundler/pkg/delegate"
+	"github.com/stackup-wallet/stackup-bundler/pkg/ipguard"
+	"github.com/stackup-wallet/stackup-bundler/pkg/ratelimit"
+	"github.com/stackup-wallet/stackup-bundler/pkg/sigban"
+	"github.com/stackup-wallet/stackup-bundler/pkg/sizelimit"
+)
+
+// getCorsHandler returns a CORS middleware for the configured origins. A single * origin allows all origins.
+// Origins may contain a wildcard, e.g. https://*.example.com, to allow all subdomains.
+func getCorsHandler(conf *config.Values) gin.HandlerFunc {
+	c := cors.DefaultConfig()
+	c.AllowHeaders = conf.CorsAllowedHeaders
+	c.AllowCredentials = conf.CorsAllowCredentials
+	if len(conf.CorsAllowedOrigins) == 1 && conf.CorsAllowedOrigins[0] == "*" {
+		c.AllowAllOrigins = true
+	} else {
+		c.AllowOrigins = conf.CorsAllowedOrigins
+		for _, origin := range conf.CorsAllowedOrigins {
+			if strings.Contains(origin, "*") {
+				c.AllowWildcard = true
+			}
+		}
+	}
+	return cors.New(c)
+}
+
+// getApiKeyStore returns a Store for API keys from config and the DB or nil if API key auth is not enabled.
+func getApiKeyStore(db *badger.DB, conf *config.Values) *apikey.Store {
+	if !conf.ApiKeyAuthEnabled {
+		return nil
+	}
+
+	return apikey.New(db, conf.ApiKeys)
+}
+
+func getApiKeyHandlers(keys *apikey.Store, logr logr.Logger) []gin.HandlerFunc {
+	if keys == nil {
+		return []gin.HandlerFunc{}
+	}
+
+	return []gin.HandlerFunc{apikey.Middleware(keys, logr)}
+}
+
+func getSizeLimitHandlers(conf *config.Values, logr logr.Logger) []gin.HandlerFunc {
+	if conf.MaxRequestBodySize <= 0 && conf.MaxCallDataSize <= 0 && conf.MaxInitCodeSize <= 0 {
+		return []gin.HandlerFunc{}
+	}
+
+	return []gin.HandlerFunc{sizelimit.Middleware(sizelimit.Limits{
+		MaxBodySize:     conf.MaxRequestBodySize,
+		MaxCallDataSize: conf.MaxCallDataSize,
+		MaxInitCodeSize: conf.MaxInitCodeSize,
+	}, logr)}
+}
+
+// getIpGuardHandlers returns middleware for per-IP reputation and geo/ASN based policies. It is skipped if
+// neither a reputation threshold nor any policies are configured.
+func getIpGuardHandlers(conf *config.Values, logr logr.Logger) []gin.HandlerFunc {
+	if conf.IpReputationThreshold == 0 && len(conf.IpPolicies) == 0 {
+		return []gin.HandlerFunc{}
+	}
+
+	var rep *ipguard.Reputation
+	if conf.IpReputationThreshold > 0 {
+		rep = ipguard.NewReputation(conf.IpReputationThreshold)
+		rep.SetHalfLife(conf.IpReputationHalfLife)
+	}
+	g := ipguard.New(rep, conf.IpPolicies)
+	g.SetCountryHeader(conf.IpCountryHeader)
+	if conf.IpAsnDatabase != "" {
+		t, err := ipguard.LoadASNTable(conf.IpAsnDatabase)
+		if err != nil {
+			log.Fatal(err)
+		}
+		g.SetASNTable(t)
+	}
+	return []gin.HandlerFunc{g.Middleware(logr)}
+}
+
+func getRateLimitHandlers(db *badger.DB, conf *config.Values, logr logr.Logger) []gin.HandlerFunc {
+	if len(conf.RateLimits) == 0 && len(conf.ApiKeyRateLimits) == 0 {
+		return []gin.HandlerFunc{}
+	}
+
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if conf.RateLimitStore == "badger" {
+		store = ratelimit.NewBadgerStore(db)
+	}
+	return []gin.HandlerFunc{ratelimit.New(store, conf.RateLimits, conf.ApiKeyRateLimits).Middleware(logr)}
+}
+
+func getSigBanHandlers(db *badger.DB, conf *config.Values, logr logr.Logger) []gin.HandlerFunc {
+	if conf.SigBanThreshold <= 0 {
+		return []gin.HandlerFunc{}
+	}
+
+	t := sigban.New(db)
+	t.SetThreshold(conf.SigBanThreshold)
+	t.SetWindow(conf.SigBanWindow)
+	t.SetBanDuration(conf.SigBanDuration)
+	return []gin.HandlerFunc{t.Middleware(logr)}
+}
+
+func getDelegateHandlers(conf *config.Values, logr logr.Logger) []gin.HandlerFunc {
+	if len(conf.DelegatedRelayers) == 0 {
+		return []gin.HandlerFunc{}
+	}
+
+	return []gin.HandlerFunc{delegate.Middleware(logr, conf.DelegatedRelayers...)}
+}
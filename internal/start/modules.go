@@ -0,0 +1,402 @@
+package start
+
+import (
+	"context"
+	"log"
+	"math/big"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/config"
+	"github.com/stackup-wallet/stackup-bundler/pkg/altmempools"
+	"github.com/stackup-wallet/stackup-bundler/pkg/approval"
+	"github.com/stackup-wallet/stackup-bundler/pkg/chainhead"
+	"github.com/stackup-wallet/stackup-bundler/pkg/client"
+	"github.com/stackup-wallet/stackup-bundler/pkg/diskquota"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
+	"github.com/stackup-wallet/stackup-bundler/pkg/fingerprint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gasfeedback"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/auction"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/batch"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/checks"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/epstatus"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/factories"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/gasprice"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/noop"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/pendinglimit"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/policy"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/risk"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/shadow"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/stakegrace"
+	"github.com/stackup-wallet/stackup-bundler/pkg/paymaster"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+	"github.com/stackup-wallet/stackup-bundler/pkg/subscription"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// getCollectorTracer returns the tracer used to validate UserOperations. An empty string uses the default JS
+// tracer.
+func getCollectorTracer(conf *config.Values) string {
+	if conf.ValidationProfile == config.ValidationProfileStructLog {
+		return tracer.StructLogProfile
+	}
+	return conf.NativeBundlerCollectorTracer
+}
+
+// getBaseFeeFunc returns the GetBaseFeeFunc for the Bundler based on the configured transaction type. If
+// legacy transactions are used, no basefee is returned so that batches are sent with a legacy gas price.
+func getBaseFeeFunc(
+	conf *config.Values,
+	eth *ethclient.Client,
+	head *chainhead.Watcher,
+	eoa *signer.EOA,
+	logr logr.Logger,
+) gasprice.GetBaseFeeFunc {
+	dynamic := conf.TxType == config.TxTypeDynamic
+	if conf.TxType == config.TxTypeAuto {
+		ok, err := gasprice.IsDynamicFeeSupportedWithEthClient(eth, eoa.Address)
+		if err != nil {
+			log.Fatal(err)
+		}
+		dynamic = ok
+	}
+
+	if !dynamic {
+		logr.Info("using legacy gas pricing for bundle transactions", "tx_type", conf.TxType)
+		return gasprice.NoopGetBaseFeeFunc()
+	}
+	return head.GetBaseFeeFunc()
+}
+
+// getProfitModel returns the gasprice profit model based on the configured value. In auto mode, networks with
+// an effectively zero priority fee use the basefee rebate model.
+func getProfitModel(conf *config.Values, chain *big.Int) string {
+	switch conf.ProfitModel {
+	case config.ProfitModelPriorityFee:
+		return gasprice.ProfitModelPriorityFee
+	case config.ProfitModelBaseFeeRebate:
+		return gasprice.ProfitModelBaseFeeRebate
+	}
+
+	if conf.IsArbStackNetwork || config.ArbStackChains.Contains(chain.Uint64()) {
+		return gasprice.ProfitModelBaseFeeRebate
+	}
+	return gasprice.ProfitModelPriorityFee
+}
+
+// getApproveFunc returns a hook for approving each handleOps transaction before it is signed, if an external
+// approval system is configured.
+func getApproveFunc(conf *config.Values) transaction.ApproveFunc {
+	if conf.SigningApprovalUrl == "" {
+		return nil
+	}
+
+	return approval.NewWebhook(conf.SigningApprovalUrl, conf.SigningApprovalTimeout).Approve
+}
+
+// getPaymasterDataFunc returns a function for proxying ERC-7677 paymaster methods to the configured paymaster
+// service. The pm namespace returns an error for every call if a service is not configured.
+func getPaymasterDataFunc(conf *config.Values) paymaster.GetPaymasterDataFunc {
+	if conf.PaymasterServiceUrl == "" {
+		return paymaster.GetPaymasterDataFuncNoop()
+	}
+
+	pm, err := rpc.Dial(conf.PaymasterServiceUrl)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return paymaster.GetPaymasterDataWithRpcClient(pm, conf.PaymasterServiceTimeout)
+}
+
+// getPendingLimitUserOpHandlers returns a Client module for capping pending ops per sender and paymaster, if
+// either limit is set.
+func getPendingLimitUserOpHandlers(conf *config.Values) []modules.UserOpHandlerFunc {
+	handlers := []modules.UserOpHandlerFunc{}
+	if conf.MaxPendingOpsPerSender == 0 && conf.MaxPendingOpsPerPaymaster == 0 {
+		return handlers
+	}
+
+	return append(handlers, pendinglimit.ValidateLimits(pendinglimit.Limits{
+		MaxPerSender:    conf.MaxPendingOpsPerSender,
+		MaxPerPaymaster: conf.MaxPendingOpsPerPaymaster,
+	}))
+}
+
+// getPolicyUserOpHandlers returns a Client module for evaluating each UserOperation against an operator
+// supplied policy script, if one is configured.
+func getPolicyUserOpHandlers(
+	conf *config.Values,
+	gr policy.GetReputationFunc,
+	gp policy.GetGasPricesFunc,
+	logr logr.Logger,
+) []modules.UserOpHandlerFunc {
+	handlers := []modules.UserOpHandlerFunc{}
+	if len(conf.PolicyScript) == 0 {
+		return handlers
+	}
+
+	s, err := policy.New(conf.PolicyScript, logr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.SetTimeout(conf.PolicyTimeout)
+
+	return append(handlers, s.UserOpHandler(gr, gp))
+}
+
+// getRiskUserOpHandlers returns a Client module for screening each UserOperation with an external risk
+// service, if one is configured.
+func getRiskUserOpHandlers(conf *config.Values, logr logr.Logger) []modules.UserOpHandlerFunc {
+	handlers := []modules.UserOpHandlerFunc{}
+	if conf.RiskServiceUrl == "" {
+		return handlers
+	}
+
+	s := risk.New(conf.RiskServiceUrl, conf.RiskServiceTimeout, logr)
+	s.SetFailOpen(conf.RiskServiceFailOpen)
+	s.SetMaxScore(conf.RiskServiceMaxScore)
+	return append(handlers, s.UserOpHandler())
+}
+
+// getShadowUserOpHandlers returns Client modules for any rule sets configured to run in shadow mode. These
+// modules only log what would have been rejected and never reject a UserOperation.
+func getShadowUserOpHandlers(
+	conf *config.Values,
+	chain *big.Int,
+	check *checks.Standalone,
+	logr logr.Logger,
+) []modules.UserOpHandlerFunc {
+	handlers := []modules.UserOpHandlerFunc{}
+	if len(conf.ShadowAltMempoolIds) == 0 {
+		return handlers
+	}
+
+	shd, err := shadow.New(logr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	alt, err := altmempools.NewFromIPFS(chain, conf.AltMempoolIPFSGateway, conf.ShadowAltMempoolIds)
+	if err != nil {
+		log.Fatal(err)
+	}
+	handlers = append(handlers, shd.UserOpHandler("alt_mempools", check.WithAltMempools(alt).SimulateOp()))
+
+	return handlers
+}
+
+// getFactoryRegistry returns a Registry of known factories or nil if neither a static list nor a remote feed
+// is configured. If a feed is set, it is refreshed in the background at the configured interval.
+func getFactoryRegistry(chain *big.Int, conf *config.Values, logr logr.Logger) *factories.Registry {
+	if len(conf.KnownFactories) == 0 && conf.KnownFactoriesFeedUrl == "" {
+		return nil
+	}
+
+	r := factories.New(chain, conf.KnownFactories, logr)
+	r.SetFeedUrl(conf.KnownFactoriesFeedUrl)
+	r.SetRefreshInterval(conf.KnownFactoriesInterval)
+	r.Run()
+	return r
+}
+
+func getFactoryLimits(conf *config.Values) factories.Limits {
+	return factories.Limits{
+		MaxPendingOps:      conf.UnknownFactoryMaxPendingOps,
+		MaxVerificationGas: conf.UnknownFactoryMaxVGL,
+		MaxOpsPerBatch:     conf.UnknownFactoryMaxPerBatch,
+	}
+}
+
+func getFactoryUserOpHandlers(r *factories.Registry, conf *config.Values) []modules.UserOpHandlerFunc {
+	if r == nil {
+		return []modules.UserOpHandlerFunc{}
+	}
+	return []modules.UserOpHandlerFunc{r.CheckUnknown(getFactoryLimits(conf))}
+}
+
+func getFactoryBatchHandler(r *factories.Registry, conf *config.Values) modules.BatchHandlerFunc {
+	if r == nil {
+		return noop.BatchHandler
+	}
+	return r.Deprioritize(getFactoryLimits(conf))
+}
+
+// getStakeGraceMonitor returns a Monitor for paymaster stake drops or nil if the grace period is disabled.
+// Paymasters are notified through the subscription Manager when a grace period starts.
+func getStakeGraceMonitor(
+	eth *ethclient.Client,
+	rep *entities.Reputation,
+	subs *subscription.Manager,
+	conf *config.Values,
+	logr logr.Logger,
+) *stakegrace.Monitor {
+	if conf.PaymasterStakeGracePeriod <= 0 {
+		return nil
+	}
+
+	m := stakegrace.New(stake.GetStakeWithEthClient(eth), rep.IsStaked, logr)
+	m.SetGracePeriod(conf.PaymasterStakeGracePeriod)
+	m.SetNotifyFunc(subs.PublishPaymasterStakeLow)
+	return m
+}
+
+func getStakeGraceUserOpHandlers(m *stakegrace.Monitor) []modules.UserOpHandlerFunc {
+	if m == nil {
+		return []modules.UserOpHandlerFunc{}
+	}
+	return []modules.UserOpHandlerFunc{m.CheckStake()}
+}
+
+func getStakeGraceBatchHandler(m *stakegrace.Monitor) modules.BatchHandlerFunc {
+	if m == nil {
+		return noop.BatchHandler
+	}
+	return m.DropAfterGrace()
+}
+
+// getFingerprintTracker returns a Tracker for sender account implementations or nil if fingerprinting is not
+// enabled. If a Tracker is returned, the Client is also set to attach fingerprints to op lookups.
+func getFingerprintTracker(
+	db *badger.DB,
+	eth *ethclient.Client,
+	c *client.Client,
+	conf *config.Values,
+	logr logr.Logger,
+) *fingerprint.Tracker {
+	if !conf.AccountFingerprintEnabled {
+		return nil
+	}
+
+	fp, err := fingerprint.New(db, epstatus.GetCodeWithEthClient(eth), conf.AccountFingerprints, logr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	c.SetGetFingerprintFunc(fp.Get)
+	return fp
+}
+
+func getFingerprintUserOpHandlers(fp *fingerprint.Tracker) []modules.UserOpHandlerFunc {
+	if fp == nil {
+		return []modules.UserOpHandlerFunc{}
+	}
+	return []modules.UserOpHandlerFunc{fp.Record()}
+}
+
+func getFingerprintBatchHandler(fp *fingerprint.Tracker) modules.BatchHandlerFunc {
+	if fp == nil {
+		return noop.BatchHandler
+	}
+	return fp.Track()
+}
+
+// getGasFeedbackTracker returns a Tracker for the gas usage of included ops or nil if gas feedback is not
+// enabled. If a Tracker is returned, the Client is also set to record and pad estimates and serve stats.
+func getGasFeedbackTracker(
+	db *badger.DB,
+	eth *ethclient.Client,
+	c *client.Client,
+	conf *config.Values,
+	logr logr.Logger,
+) *gasfeedback.Tracker {
+	if !conf.GasFeedbackEnabled {
+		return nil
+	}
+
+	getCode := epstatus.GetCodeWithEthClient(eth)
+	gf := gasfeedback.New(
+		db,
+		func(txHash common.Hash) (*types.Receipt, error) {
+			return eth.TransactionReceipt(context.Background(), txHash)
+		},
+		func(op *userop.UserOperation) (string, error) {
+			return conf.AccountFingerprints.Identify(getCode, op)
+		},
+		logr,
+	)
+	gf.SetTargetUtilization(conf.GasFeedbackTarget)
+	gf.SetMaxPadding(conf.GasFeedbackMaxPadding)
+	c.SetRecordEstimateFunc(gf.RecordEstimate)
+	c.SetGetEstimatePaddingFunc(gf.Padding)
+	c.SetGetGasUsageStatsFunc(gf.Stats)
+	return gf
+}
+
+func getGasFeedbackBatchHandler(gf *gasfeedback.Tracker) modules.BatchHandlerFunc {
+	if gf == nil {
+		return noop.BatchHandler
+	}
+	return gf.Track()
+}
+
+// getAdaptiveLimiter returns an AdaptiveLimiter for the per batch op count or nil if it is not enabled.
+func getAdaptiveLimiter(conf *config.Values) *batch.AdaptiveLimiter {
+	if conf.AdaptiveMaxOps == 0 {
+		return nil
+	}
+	return batch.NewAdaptiveLimiter(
+		conf.AdaptiveMinOps,
+		conf.AdaptiveMaxOps,
+		conf.AdaptiveTargetSimLatency,
+		conf.AdaptiveDeadline,
+	)
+}
+
+func getAdaptiveLimitBatchHandler(al *batch.AdaptiveLimiter) modules.BatchHandlerFunc {
+	if al == nil {
+		return noop.BatchHandler
+	}
+	return al.LimitBatch()
+}
+
+func getAdaptiveCapBatchHandler(al *batch.AdaptiveLimiter) modules.BatchHandlerFunc {
+	if al == nil {
+		return noop.BatchHandler
+	}
+	return al.CapBatch()
+}
+
+func trackSimulationLatency(al *batch.AdaptiveLimiter, h modules.BatchHandlerFunc) modules.BatchHandlerFunc {
+	if al == nil {
+		return h
+	}
+	return al.TrackSimulation(h)
+}
+
+func trackBatchDeadline(al *batch.AdaptiveLimiter, h modules.BatchHandlerFunc) modules.BatchHandlerFunc {
+	if al == nil {
+		return h
+	}
+	return al.TrackDeadline(h)
+}
+
+// getAuctionBatchHandler returns a Bundler module for offering each batch to an orderflow auction, if one is
+// configured. Backruns can only be included atomically by a block builder so the module is skipped when
+// bundles are sent with the relayer.
+func getAuctionBatchHandler(
+	bb blockBuilder,
+	conf *config.Values,
+	logr logr.Logger,
+) modules.BatchHandlerFunc {
+	if conf.AuctionUrl == "" || bb == nil {
+		return noop.BatchHandler
+	}
+
+	a := auction.New(conf.AuctionUrl, conf.AuctionTimeout, logr)
+	a.SetRefundPercent(conf.AuctionRefundPercent)
+	return a.BatchHandler()
+}
+
+func getDiskQuotaUserOpHandlers(q *diskquota.Quota) []modules.UserOpHandlerFunc {
+	if q == nil {
+		return []modules.UserOpHandlerFunc{}
+	}
+	return []modules.UserOpHandlerFunc{q.CheckQuota()}
+}
@@ -0,0 +1,475 @@
+package start
+
+import (
+	"context"
+	"log"
+	"math/big"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/config"
+	"github.com/stackup-wallet/stackup-bundler/internal/logger"
+	"github.com/stackup-wallet/stackup-bundler/internal/o11y"
+	"github.com/stackup-wallet/stackup-bundler/pkg/altmempools"
+	"github.com/stackup-wallet/stackup-bundler/pkg/bundler"
+	"github.com/stackup-wallet/stackup-bundler/pkg/chainhead"
+	"github.com/stackup-wallet/stackup-bundler/pkg/client"
+	"github.com/stackup-wallet/stackup-bundler/pkg/diskquota"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/nonce"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
+	"github.com/stackup-wallet/stackup-bundler/pkg/federation"
+	"github.com/stackup-wallet/stackup-bundler/pkg/fingerprint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gasfeedback"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/batch"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/checks"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/epstatus"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/expire"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/factories"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/gasprice"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/relay"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/stakegrace"
+	"github.com/stackup-wallet/stackup-bundler/pkg/opstatus"
+	"github.com/stackup-wallet/stackup-bundler/pkg/origin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/passkey"
+	"github.com/stackup-wallet/stackup-bundler/pkg/paymaster"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+	"github.com/stackup-wallet/stackup-bundler/pkg/subscription"
+	"go.opentelemetry.io/otel"
+)
+
+// node holds the components shared by the private and searcher modes. Both modes build the same Client and
+// Bundler modules from it and only differ in how batches are sent and which routes are served.
+type node struct {
+	mode        string
+	conf        *config.Values
+	tail        *logger.Tail
+	logr        logr.Logger
+	eoa         *signer.EOA
+	beneficiary common.Address
+	rpc         *rpc.Client
+	eth         *ethclient.Client
+	chain       *big.Int
+	ov          *gas.Overhead
+
+	db          *badger.DB
+	prom        *o11y.PrometheusExporter
+	head        *chainhead.Watcher
+	mem         *mempool.Mempool
+	check       *checks.Standalone
+	profitModel string
+	exp         *expire.ExpireHandler
+	rep         *entities.Reputation
+	eps         *epstatus.Monitor
+	org         *origin.Tracker
+	sts         *opstatus.Tracker
+	subs        *subscription.Manager
+
+	// Set by newClient for the Bundler modules that track the same ops.
+	dq *diskquota.Quota
+	fp *fingerprint.Tracker
+	gf *gasfeedback.Tracker
+	sg *stakegrace.Monitor
+	fr *factories.Registry
+}
+
+// dialNode connects to the eth node for the given mode. Nothing is opened or started so that a mode can
+// still hand off to another one after checking the chain.
+func dialNode(mode string) *node {
+	conf := config.GetValues()
+
+	tail := getLogTail(conf)
+	logr := logger.NewZeroLogrWithTail(tail).
+		WithName("stackup_bundler").
+		WithValues("bundler_mode", mode)
+
+	eoa, err := signer.New(conf.PrivateKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rpc, err := rpc.Dial(conf.EthClientUrl)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := config.CheckNodeCapabilities(rpc, conf); err != nil {
+		log.Fatal(err)
+	}
+
+	eth := ethclient.NewClient(rpc)
+
+	chain, err := eth.ChainID(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ov := gas.NewDefaultOverhead()
+	ov.SetPreVerificationGasBoundsBlocks(conf.PvgBoundsBlocks)
+
+	return &node{
+		mode:        mode,
+		conf:        conf,
+		tail:        tail,
+		logr:        logr,
+		eoa:         eoa,
+		beneficiary: common.HexToAddress(conf.Beneficiary),
+		rpc:         rpc,
+		eth:         eth,
+		chain:       chain,
+		ov:          ov,
+	}
+}
+
+// useL2Overhead sets the PVG calculation for Arbitrum and OP stack networks, if the chain is one of them.
+func useL2Overhead(ov *gas.Overhead, rpc *rpc.Client, chain *big.Int, conf *config.Values) {
+	if conf.IsArbStackNetwork || config.ArbStackChains.Contains(chain.Uint64()) {
+		ov.SetCalcPreVerificationGasFunc(gas.CalcArbitrumPVGWithEthClient(rpc, conf.SupportedEntryPoints[0]))
+		ov.SetPreVerificationGasBufferFactor(16)
+	}
+
+	if conf.IsOpStackNetwork || config.OpStackChains.Contains(chain.Uint64()) {
+		ov.SetCalcPreVerificationGasFunc(
+			gas.CalcOptimismPVGWithEthClient(rpc, chain, conf.SupportedEntryPoints[0]),
+		)
+		ov.SetPreVerificationGasBufferFactor(1)
+	}
+}
+
+// initO11y sets the global tracer and meter providers. The returned func flushes any exporters and should be
+// deferred by the caller.
+func initO11y(
+	conf *config.Values,
+	chain *big.Int,
+	addr common.Address,
+	prom *o11y.PrometheusExporter,
+) func() {
+	if o11y.IsEnabled(conf.OTELServiceName) {
+		o11yOpts := &o11y.Opts{
+			ServiceName:     conf.OTELServiceName,
+			CollectorHeader: conf.OTELCollectorHeaders,
+			CollectorUrl:    conf.OTELCollectorUrl,
+			InsecureMode:    conf.OTELInsecureMode,
+
+			ChainID: chain,
+			Address: addr,
+		}
+
+		tracerCleanup := o11y.InitTracer(o11yOpts)
+		metricsCleanup := o11y.InitMetrics(o11yOpts, getPrometheusReaders(prom)...)
+		return func() {
+			metricsCleanup()
+			tracerCleanup()
+		}
+	} else if prom != nil {
+		o11y.InitLocalMetrics(&o11y.Opts{ChainID: chain, Address: addr}, prom.Reader())
+	}
+	return func() {}
+}
+
+// start opens the DB and mempool and runs the background services shared by every bundling mode. The
+// returned func releases them and should be deferred by the caller.
+func (n *node) start() func() {
+	conf, logr := n.conf, n.logr
+
+	db, err := badger.Open(badger.DefaultOptions(conf.DataDirectory))
+	if err != nil {
+		log.Fatal(err)
+	}
+	n.db = db
+	runDBGarbageCollection(db)
+	runBackups(db, conf, logr)
+
+	n.prom = getPrometheusExporter(conf)
+	o11yCleanup := initO11y(conf, n.chain, n.eoa.Address, n.prom)
+
+	n.head = runChainHeadWatcher(n.eth, conf, logr)
+
+	n.mem, err = getMempool(db, conf, logr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	alt, err := altmempools.NewFromIPFS(n.chain, conf.AltMempoolIPFSGateway, conf.AltMempoolIds)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	n.check = checks.New(
+		db,
+		n.rpc,
+		n.ov,
+		alt,
+		conf.MaxVerificationGas,
+		conf.MaxBatchGasLimit,
+		conf.IsRIP7212Supported,
+		getCollectorTracer(conf),
+		conf.ReputationConstants,
+	)
+	n.check.SetGasCeilings(conf.MaxCallGasLimit, conf.MaxOpGas)
+	n.check.SetMinPriorityFees(&checks.MinPriorityFees{
+		Paymaster: conf.MinPriorityFeePaymaster,
+		InitCode:  conf.MinPriorityFeeInitCode,
+		Sender:    conf.MinPriorityFeeSender,
+	})
+	n.check.SetReplacementFeeBump(conf.ReplacementFeeBump)
+	n.profitModel = getProfitModel(conf, n.chain)
+	n.check.SetProfitModel(n.profitModel)
+	if len(conf.PasskeyVerifiers) > 0 {
+		verifiers, err := passkey.NewAllowlist(passkey.GetCodeWithEthClient(n.eth), conf.PasskeyVerifiers...)
+		if err != nil {
+			log.Fatal(err)
+		}
+		n.check.SetPasskeyVerifiers(verifiers)
+	}
+
+	n.exp = expire.New(conf.MaxOpTTL)
+
+	n.rep = entities.New(db, n.eth, conf.ReputationConstants)
+	if isReadReplica(conf) {
+		runReplicaImporter(db, conf, logr)
+	} else if isIngestFrontend(conf) {
+		runIngestSync(db, n.mem, n.chain, conf, logr)
+	} else {
+		runBanReview(n.rep, conf.SupportedEntryPoints[0], conf.BanReviewCooldown, logr)
+		runReputationFlush(n.rep, n.mem, conf.ReputationBufferSize, conf.ReputationFlushInterval, logr)
+	}
+
+	n.eps = epstatus.New(epstatus.GetCodeWithEthClient(n.eth), logr)
+	n.eps.SetFailureThreshold(conf.EPFailureThreshold)
+	n.eps.SetRecoveryInterval(conf.EPRecoveryInterval)
+
+	n.org, err = origin.New(db, logr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	n.sts = opstatus.New(db)
+	n.sts.SetRecordTTL(conf.OpStatusRetention)
+	n.subs = subscription.New()
+
+	return func() {
+		n.head.Stop()
+		o11yCleanup()
+		db.Close()
+	}
+}
+
+// newRelayer returns a Relayer for sending batches as regular transactions from the node's EOA.
+func (n *node) newRelayer() *relay.Relayer {
+	relayer := relay.New(n.eoa, n.eth, n.chain, n.beneficiary, n.logr)
+	relayer.SetStuckTxTimeout(n.conf.StuckTxTimeout)
+	relayer.SetApproveFunc(getApproveFunc(n.conf))
+	if err := relayer.UseMeter(otel.GetMeterProvider().Meter("relayer")); err != nil {
+		log.Fatal(err)
+	}
+	return relayer
+}
+
+// newClient returns a Client with the validation modules for every mode. Modules for optional features are
+// only added if the feature is enabled.
+func (n *node) newClient() *client.Client {
+	conf, logr, rpc, eth := n.conf, n.logr, n.rpc, n.eth
+
+	c := client.New(n.mem, n.ov, n.chain, conf.SupportedEntryPoints, conf.OpLookupLimit)
+	c.SetGetUserOpReceiptFunc(client.GetUserOpReceiptWithEthClient(rpc, eth))
+	c.SetGetGasPricesFunc(client.GetGasPricesWithEthClient(eth))
+	c.SetGetGasEstimateFunc(
+		client.GetGasEstimateWithEthClient(
+			rpc,
+			n.ov,
+			n.chain,
+			conf.MaxBatchGasLimit,
+			conf.NativeBundlerExecutorTracer,
+			conf.VGLSafetyMargin,
+		),
+	)
+	c.SetGetUserOpByHashFunc(client.GetUserOpByHashWithEthClient(rpc, eth))
+	c.SetGetUserOpsBySenderFunc(client.GetUserOpsBySenderWithEthClient(rpc, eth))
+	c.SetGetStakeFunc(stake.GetStakeWithEthClient(eth))
+	c.SetGetNonceFunc(nonce.GetNonceWithEthClient(eth))
+	c.SetGetTokenValueOfEthFunc(paymaster.GetTokenValueOfEthWithEthClient(eth))
+	c.SetGetPaymasterDataFunc(getPaymasterDataFunc(conf))
+	c.SetGetAltMempoolExceptionsFunc(n.check.GetAltMempoolExceptions)
+	c.SetRecordOriginFunc(n.org.Record)
+	c.SetGetOriginFunc(n.org.Get)
+	c.SetGetUserOpStatusFunc(n.sts.Get)
+	c.SetPutUserOpStatusFunc(n.sts.Set)
+	c.SetSimulateAtBlockFunc(n.check.SimulateOpAtBlock)
+	c.UseLogger(logr)
+
+	n.dq = runDiskQuota(n.db, n.sts, conf, logr)
+	n.fp = getFingerprintTracker(n.db, eth, c, conf, logr)
+	n.gf = getGasFeedbackTracker(n.db, eth, c, conf, logr)
+	n.sg = getStakeGraceMonitor(eth, n.rep, n.subs, conf, logr)
+	n.fr = getFactoryRegistry(n.chain, conf, logr)
+
+	clientModules := getDiskQuotaUserOpHandlers(n.dq)
+	clientModules = append(clientModules, getFactoryUserOpHandlers(n.fr, conf)...)
+	clientModules = append(clientModules, getPendingLimitUserOpHandlers(conf)...)
+	clientModules = append(
+		clientModules,
+		n.eps.CheckAvailable(),
+		n.rep.CheckStatus(),
+		n.rep.ValidateOpLimit(),
+		n.check.ValidateOpValues(),
+		n.check.SimulateOp(),
+		// TODO: add p2p propagation module
+	)
+	clientModules = append(clientModules, getStakeGraceUserOpHandlers(n.sg)...)
+	clientModules = append(
+		clientModules,
+		getPolicyUserOpHandlers(conf, n.rep.GetStatus, client.GetGasPricesWithEthClient(eth), logr)...,
+	)
+	clientModules = append(clientModules, getRiskUserOpHandlers(conf, logr)...)
+	clientModules = append(clientModules, getShadowUserOpHandlers(conf, n.chain, n.check, logr)...)
+	clientModules = append(clientModules, getFingerprintUserOpHandlers(n.fp)...)
+	clientModules = append(clientModules, getIngestUserOpHandlers(conf, n.rep)...)
+	clientModules = append(clientModules, n.sts.RecordPending(), n.subs.PublishPending())
+	c.UseModules(clientModules...)
+	if err := c.UseMeter(otel.GetMeterProvider().Meter("client")); err != nil {
+		log.Fatal(err)
+	}
+
+	if len(conf.WarmUpPeerUrls) > 0 && runsBundler(conf) {
+		if _, err := c.WarmUp(conf.WarmUpPeerUrls); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if conf.HoldOpsDuringSync && !isReadReplica(conf) {
+		c.SetHoldDuringSync(client.IsSyncingWithEthClient(eth), conf.MaxHeldOps)
+		go c.ReleaseHeldOps()
+	}
+	if conf.FederationRegistryUrl != "" {
+		fed := federation.New(
+			conf.FederationPublicUrl,
+			conf.FederationRegistryUrl,
+			federation.GetStatusWithEthClient(eth, n.mem, n.chain, conf.SupportedEntryPoints),
+			logr,
+		)
+		fed.SetInterval(conf.FederationInterval)
+		fed.Run()
+		c.SetGetFederationPeersFunc(fed.Peers)
+	}
+	return c
+}
+
+// newBundler returns a Bundler with the batch modules for every mode and starts it if this process runs the
+// bundling loop. Batches are sent with send. If bb is set, batches are also offered to the configured auction
+// and pre-signed templates are built. The Client's info is set once all modules are known.
+func (n *node) newBundler(c *client.Client, send modules.BatchHandlerFunc, bb blockBuilder) *bundler.Bundler {
+	conf, logr, eth, head := n.conf, n.logr, n.eth, n.head
+
+	b := bundler.New(n.mem, n.chain, conf.SupportedEntryPoints)
+	b.SetGetBaseFeeFunc(getBaseFeeFunc(conf, eth, head, n.eoa, logr))
+	b.SetNewHeads(head.NewHeads())
+	b.SetGetGasTipFunc(gasprice.GetGasTipWithEthClient(eth))
+	b.SetGetLegacyGasPriceFunc(gasprice.GetLegacyGasPriceWithEthClient(eth))
+	b.SetGetTxReceiptFunc(func(hash common.Hash) (*types.Receipt, error) {
+		return eth.TransactionReceipt(context.Background(), hash)
+	})
+	claim, err := getBundlerClaimFunc(n.mem, conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b.SetClaimFunc(claim)
+	b.UseLogger(logr)
+	if err := b.UserMeter(otel.GetMeterProvider().Meter("bundler")); err != nil {
+		log.Fatal(err)
+	}
+	gasLimiter := batch.NewGasLimiter(conf.MaxBatchGasLimit)
+	gasLimiter.SetGetBlockGasLimitFunc(head.GetBlockGasLimit)
+	if err := gasLimiter.UseMeter(otel.GetMeterProvider().Meter("bundler")); err != nil {
+		log.Fatal(err)
+	}
+	al := getAdaptiveLimiter(conf)
+	sortByGasPrice := gasprice.SortByGasPrice()
+	if conf.DeterministicMode {
+		sortByGasPrice = batch.SortDeterministic(conf.DeterministicSeed)
+	}
+	filterUnderpriced := gasprice.FilterUnderpricedWithProfitModel(
+		n.profitModel,
+		n.rep.GetGasPriceDiscountFunc(conf.ReliableEntityGasDiscount),
+	)
+	dash := runDashboardServer(n.mem, conf, logr)
+	// Pre-signed templates are built with the same ordering as the Bundler so that their candidates match the
+	// batches it sends. The limits are applied without side effects and modules that drop ops are skipped.
+	ordering := []modules.BatchHandlerFunc{
+		sortByGasPrice,
+		filterUnderpriced,
+		getFactoryBatchHandler(n.fr, conf),
+		gasLimiter.PrioritizeDelayed(),
+		batch.SortBySenderSequence(),
+	}
+	batchHandlers := []modules.BatchHandlerFunc{n.exp.DropExpired(), getStakeGraceBatchHandler(n.sg)}
+	batchHandlers = append(batchHandlers, ordering...)
+	batchHandlers = append(
+		batchHandlers,
+		gasLimiter.MaintainGasLimit(),
+		getAdaptiveLimitBatchHandler(al),
+		n.check.CodeHashes(),
+		n.check.PaymasterDeposit(),
+		batch.SortBySenderSequence(),
+		trackSimulationLatency(al, n.check.SimulateBatch(n.beneficiary)),
+		n.sts.RecordBundling(),
+		getAuctionBatchHandler(bb, conf, logr),
+		trackBatchDeadline(al, n.eps.TrackHandleOps(dash.TrackBundles(send))),
+		n.sts.RecordSubmitted(),
+		n.rep.IncOpsIncluded(),
+		n.org.IncOpsIncluded(),
+		getFingerprintBatchHandler(n.fp),
+		getGasFeedbackBatchHandler(n.gf),
+		n.subs.PublishBatch(),
+		n.check.Clean(),
+	)
+	b.UseModules(batchHandlers...)
+	if bb != nil && conf.PresignedTemplates > 0 && runsBundler(conf) {
+		templateOrdering := append(ordering, gasLimiter.CapGasLimit(), getAdaptiveCapBatchHandler(al))
+		bb.RunTemplates(b, conf.SupportedEntryPoints, conf.PresignedTemplates, head, logr, templateOrdering...)
+	}
+	if runsBundler(conf) {
+		if err := b.Run(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	c.SetBundlerInfo(&client.BundlerInfo{
+		Version:        config.Version,
+		Commit:         config.GetCommit(),
+		Mode:           n.mode,
+		Executor:       n.eoa.Address,
+		ClientModules:  c.ModuleNames(),
+		BundlerModules: b.ModuleNames(),
+	})
+	return b
+}
+
+// newDebug returns the debug RPC methods or nil if debug mode is not enabled. In debug mode, every batch
+// only includes a single op.
+func (n *node) newDebug(b *bundler.Bundler) *client.Debug {
+	if !n.conf.DebugMode {
+		return nil
+	}
+	if !client.DebugSupported {
+		log.Fatal("error: debug mode is not available in a binary built with the nodebug tag.")
+	}
+
+	d := client.NewDebug(
+		n.eoa,
+		n.eth,
+		n.mem,
+		n.rep,
+		b,
+		n.chain,
+		n.conf.SupportedEntryPoints[0],
+		n.beneficiary,
+	)
+	b.SetMaxBatch(1)
+	return d
+}
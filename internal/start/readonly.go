@@ -0,0 +1,116 @@
+package start
+
+import (
+	"context"
+	"log"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/internal/config"
+	"github.com/stackup-wallet/stackup-bundler/internal/logger"
+	"github.com/stackup-wallet/stackup-bundler/pkg/client"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/nonce"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/paymaster"
+	"github.com/stackup-wallet/stackup-bundler/pkg/replica"
+)
+
+// ReadOnlyMode serves read methods from the upstream node without any bundler state so that query traffic
+// can be scaled separately from the stateful bundler. All other methods are rejected with a hint to send them
+// to the primary bundler.
+func ReadOnlyMode() {
+	conf := config.GetValues()
+
+	logr := logger.NewZeroLogr().
+		WithName("stackup_bundler").
+		WithValues("bundler_mode", "readonly")
+
+	// The mempool is required by the Client but is never written to.
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	rpc, err := rpc.Dial(conf.EthClientUrl)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := config.CheckNodeCapabilities(rpc, conf); err != nil {
+		log.Fatal(err)
+	}
+
+	eth := ethclient.NewClient(rpc)
+
+	chain, err := eth.ChainID(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	prom := getPrometheusExporter(conf)
+	o11yCleanup := initO11y(conf, chain, common.Address{}, prom)
+	defer o11yCleanup()
+
+	ov := gas.NewDefaultOverhead()
+	ov.SetPreVerificationGasBoundsBlocks(conf.PvgBoundsBlocks)
+	useL2Overhead(ov, rpc, chain, conf)
+
+	mem, err := getMempool(db, conf, logr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Init Client
+	c := client.New(mem, ov, chain, conf.SupportedEntryPoints, conf.OpLookupLimit)
+	c.SetGetUserOpReceiptFunc(client.GetUserOpReceiptWithEthClient(rpc, eth))
+	c.SetGetGasPricesFunc(client.GetGasPricesWithEthClient(eth))
+	c.SetGetGasEstimateFunc(
+		client.GetGasEstimateWithEthClient(
+			rpc,
+			ov,
+			chain,
+			conf.MaxBatchGasLimit,
+			conf.NativeBundlerExecutorTracer,
+			conf.VGLSafetyMargin,
+		),
+	)
+	c.SetGetUserOpByHashFunc(client.GetUserOpByHashWithEthClient(rpc, eth))
+	c.SetGetStakeFunc(stake.GetStakeWithEthClient(eth))
+	c.SetGetNonceFunc(nonce.GetNonceWithEthClient(eth))
+	c.SetGetTokenValueOfEthFunc(paymaster.GetTokenValueOfEthWithEthClient(eth))
+	c.UseLogger(logr)
+	c.SetBundlerInfo(&client.BundlerInfo{
+		Version:        config.Version,
+		Commit:         config.GetCommit(),
+		Mode:           "readonly",
+		ClientModules:  c.ModuleNames(),
+		BundlerModules: []string{},
+	})
+
+	// Init HTTP server
+	r := newRouter(conf, logr)
+	useHealth(r, getHealthChecker(eth, chain, nil, nil, []string{}, conf))
+	usePrometheus(r, prom)
+	handlers := append(
+		getSizeLimitHandlers(conf, logr),
+		getApiKeyHandlers(getApiKeyStore(db, conf), logr)...,
+	)
+	handlers = append(handlers, getIpGuardHandlers(conf, logr)...)
+	handlers = append(handlers, getRateLimitHandlers(db, conf, logr)...)
+	handlers = append(
+		handlers,
+		replica.ReadOnly(conf.ReplicaPrimaryUrl),
+		jsonrpc.Controller(client.NewRpcAdapter(c, nil)),
+		jsonrpc.WithOTELTracerAttributes(),
+	)
+	r.POST("/", handlers...)
+	r.POST("/rpc", handlers...)
+
+	if err := runRPCServer(r, conf); err != nil {
+		log.Fatal(err)
+	}
+}
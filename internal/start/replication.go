@@ -0,0 +1,129 @@
+package start
+
+import (
+	"log"
+	"math/big"
+	"net/url"
+	"strings"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/config"
+	"github.com/stackup-wallet/stackup-bundler/pkg/handoff"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
+	"github.com/stackup-wallet/stackup-bundler/pkg/replica"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+const replicaExportPath = "/replica/export"
+
+// isReadReplica returns true if the bundler is configured to replicate data from a primary. A read replica
+// does not run the bundling loop and routes all writes to the primary.
+func isReadReplica(conf *config.Values) bool {
+	return conf.ReplicaPrimaryUrl != ""
+}
+
+func runReplicaImporter(db *badger.DB, conf *config.Values, logr logr.Logger) {
+	if !isReadReplica(conf) {
+		return
+	}
+
+	imp := replica.NewImporter(db, strings.TrimSuffix(conf.ReplicaPrimaryUrl, "/")+replicaExportPath, logr)
+	imp.SetSyncInterval(conf.ReplicaSyncInterval)
+	imp.Run()
+}
+
+func getReplicaHandlers(conf *config.Values) []gin.HandlerFunc {
+	if !isReadReplica(conf) {
+		return []gin.HandlerFunc{}
+	}
+
+	primary, err := url.Parse(conf.ReplicaPrimaryUrl)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return []gin.HandlerFunc{replica.ForwardWrites(primary)}
+}
+
+func useReplicaExport(r *gin.Engine, db *badger.DB, conf *config.Values) {
+	if !conf.ReplicaExportEnabled {
+		return
+	}
+
+	r.GET(replicaExportPath, replica.Export(db, entities.KeyPrefix))
+}
+
+// isIngestFrontend returns true if the searcher only runs the client and hands off validated UserOperations
+// to a bundle back-end.
+func isIngestFrontend(conf *config.Values) bool {
+	return conf.SearcherRole == config.SearcherRoleIngest
+}
+
+// isBundleBackend returns true if the searcher accepts UserOperations handed off from ingest front-ends.
+func isBundleBackend(conf *config.Values) bool {
+	return conf.SearcherRole == config.SearcherRoleBundle
+}
+
+// runsBundler returns true if the bundling loop should run in this process.
+func runsBundler(conf *config.Values) bool {
+	return !isReadReplica(conf) && !isIngestFrontend(conf)
+}
+
+// getHandoffDictionary returns the shared dictionary used to compress UserOperations handed off between
+// processes or nil if compression is not enabled.
+func getHandoffDictionary(conf *config.Values) *userop.Dictionary {
+	if len(conf.HandoffDictionary) == 0 {
+		return nil
+	}
+	return userop.NewDictionary(conf.HandoffDictionary...)
+}
+
+// getIngestUserOpHandlers returns the client modules that record and hand off a validated UserOperation. On
+// an ingest front-end, opsSeen is counted by the back-end since reputation is synced from there.
+func getIngestUserOpHandlers(conf *config.Values, rep *entities.Reputation) []modules.UserOpHandlerFunc {
+	if !isIngestFrontend(conf) {
+		return []modules.UserOpHandlerFunc{rep.IncOpsSeen()}
+	}
+
+	url := strings.TrimSuffix(conf.BundleBackendUrl, "/")
+	return []modules.UserOpHandlerFunc{handoff.Push(url, handoff.DefaultTimeout, getHandoffDictionary(conf))}
+}
+
+// runIngestSync keeps an ingest front-end's mempool and reputation data consistent with the bundle back-end.
+func runIngestSync(db *badger.DB, mem *mempool.Mempool, chain *big.Int, conf *config.Values, logr logr.Logger) {
+	if !isIngestFrontend(conf) {
+		return
+	}
+
+	url := strings.TrimSuffix(conf.BundleBackendUrl, "/")
+	rec := handoff.NewReconciler(mem, url, chain, conf.SupportedEntryPoints, logr)
+	rec.SetSyncInterval(conf.ReplicaSyncInterval)
+	rec.Run()
+
+	imp := replica.NewImporter(db, url+replicaExportPath, logr)
+	imp.SetSyncInterval(conf.ReplicaSyncInterval)
+	imp.Run()
+}
+
+// useHandoffRoutes registers the endpoints used by ingest front-ends on a bundle back-end. This includes the
+// replica export so that front-ends can validate against the back-end's reputation data.
+func useHandoffRoutes(
+	r *gin.Engine,
+	db *badger.DB,
+	mem *mempool.Mempool,
+	rep *entities.Reputation,
+	chain *big.Int,
+	conf *config.Values,
+) {
+	if !isBundleBackend(conf) {
+		return
+	}
+
+	handoff.Routes(r, mem, chain, conf.SupportedEntryPoints, getHandoffDictionary(conf), rep.IncOpsSeen())
+	if !conf.ReplicaExportEnabled {
+		r.GET(replicaExportPath, replica.Export(db, entities.KeyPrefix))
+	}
+}
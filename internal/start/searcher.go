@@ -1,211 +1,92 @@
 package start
 
 import (
-	"context"
 	"fmt"
 	"log"
-	"net/http"
+	"math/big"
 
-	badger "github.com/dgraph-io/badger/v3"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/ethereum/go-ethereum/rpc"
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
-	"github.com/metachris/flashbotsrpc"
+	"github.com/go-logr/logr"
 	"github.com/stackup-wallet/stackup-bundler/internal/config"
-	"github.com/stackup-wallet/stackup-bundler/internal/logger"
-	"github.com/stackup-wallet/stackup-bundler/internal/o11y"
-	"github.com/stackup-wallet/stackup-bundler/pkg/altmempools"
 	"github.com/stackup-wallet/stackup-bundler/pkg/bundler"
-	"github.com/stackup-wallet/stackup-bundler/pkg/client"
-	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
-	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
-	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
-	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
-	"github.com/stackup-wallet/stackup-bundler/pkg/modules/batch"
-	"github.com/stackup-wallet/stackup-bundler/pkg/modules/builder"
-	"github.com/stackup-wallet/stackup-bundler/pkg/modules/checks"
-	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
-	"github.com/stackup-wallet/stackup-bundler/pkg/modules/expire"
-	"github.com/stackup-wallet/stackup-bundler/pkg/modules/gasprice"
-	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
-	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
-	"go.opentelemetry.io/otel"
+	"github.com/stackup-wallet/stackup-bundler/pkg/chainhead"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
 )
 
-func SearcherMode() {
-	conf := config.GetValues()
-
-	logr := logger.NewZeroLogr().
-		WithName("stackup_bundler").
-		WithValues("bundler_mode", "searcher")
-
-	eoa, err := signer.New(conf.PrivateKey)
-	if err != nil {
-		log.Fatal(err)
-	}
-	beneficiary := common.HexToAddress(conf.Beneficiary)
+// blockBuilder sends bundles to block builders in searcher mode. The builder client can be compiled out with
+// the nobuilder tag, in which case searcher mode falls back to the configured chain mismatch policy.
+type blockBuilder interface {
+	SendUserOperation() modules.BatchHandlerFunc
+	RunTemplates(
+		b *bundler.Bundler,
+		eps []common.Address,
+		n int,
+		head *chainhead.Watcher,
+		l logr.Logger,
+		ordering ...modules.BatchHandlerFunc,
+	)
+}
 
-	db, err := badger.Open(badger.DefaultOptions(conf.DataDirectory))
-	if err != nil {
-		log.Fatal(err)
+// checkChainMismatch applies the chain mismatch policy to a network that may not be compatible with the Block
+// Builder API. It returns whether searcher mode should send batches as regular transactions or switch to
+// private mode, or an error if it should not start at all.
+func checkChainMismatch(
+	policy string,
+	chain *big.Int,
+	compatible bool,
+) (degraded bool, switchToPrivate bool, err error) {
+	if compatible {
+		return false, false, nil
 	}
-	defer db.Close()
-	runDBGarbageCollection(db)
 
-	rpc, err := rpc.Dial(conf.EthClientUrl)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	eth := ethclient.NewClient(rpc)
-
-	fb := flashbotsrpc.NewBuilderBroadcastRPC(conf.EthBuilderUrls)
-
-	chain, err := eth.ChainID(context.Background())
-	if err != nil {
-		log.Fatal(err)
-	}
-	if !builder.CompatibleChainIDs.Contains(chain.Uint64()) {
-		log.Fatalf(
+	switch policy {
+	case config.ChainMismatchDegrade:
+		return true, false, nil
+	case config.ChainMismatchAuto:
+		return false, true, nil
+	default:
+		return false, false, fmt.Errorf(
 			"error: network with chainID %d is not compatible with the Block Builder API.",
 			chain.Uint64(),
 		)
 	}
+}
 
-	if o11y.IsEnabled(conf.OTELServiceName) {
-		o11yOpts := &o11y.Opts{
-			ServiceName:     conf.OTELServiceName,
-			CollectorHeader: conf.OTELCollectorHeaders,
-			CollectorUrl:    conf.OTELCollectorUrl,
-			InsecureMode:    conf.OTELInsecureMode,
-
-			ChainID: chain,
-			Address: eoa.Address,
-		}
-
-		tracerCleanup := o11y.InitTracer(o11yOpts)
-		defer tracerCleanup()
-
-		metricsCleanup := o11y.InitMetrics(o11yOpts)
-		defer metricsCleanup()
-	}
-
-	ov := gas.NewDefaultOverhead()
-
-	mem, err := mempool.New(db)
-	if err != nil {
-		log.Fatal(err)
-	}
+func SearcherMode() {
+	n := dialNode("searcher")
+	conf := n.conf
 
-	alt, err := altmempools.NewFromIPFS(chain, conf.AltMempoolIPFSGateway, conf.AltMempoolIds)
+	compatible := isBuilderCompatible(n.chain)
+	degraded, switchToPrivate, err := checkChainMismatch(conf.ChainMismatchPolicy, n.chain, compatible)
 	if err != nil {
 		log.Fatal(err)
 	}
-
-	check := checks.New(
-		db,
-		rpc,
-		ov,
-		alt,
-		conf.MaxVerificationGas,
-		conf.MaxBatchGasLimit,
-		conf.IsRIP7212Supported,
-		conf.NativeBundlerCollectorTracer,
-		conf.ReputationConstants,
-	)
-
-	exp := expire.New(conf.MaxOpTTL)
-
-	// TODO: Create separate go-routine for tracking transactions sent to the block builder.
-	builder := builder.New(eoa, eth, fb, beneficiary, conf.BlocksInTheFuture)
-
-	rep := entities.New(db, eth, conf.ReputationConstants)
-
-	// Init Client
-	c := client.New(mem, ov, chain, conf.SupportedEntryPoints, conf.OpLookupLimit)
-	c.SetGetUserOpReceiptFunc(client.GetUserOpReceiptWithEthClient(eth))
-	c.SetGetGasPricesFunc(client.GetGasPricesWithEthClient(eth))
-	c.SetGetGasEstimateFunc(
-		client.GetGasEstimateWithEthClient(
-			rpc,
-			ov,
-			chain,
-			conf.MaxBatchGasLimit,
-			conf.NativeBundlerExecutorTracer,
-		),
-	)
-	c.SetGetUserOpByHashFunc(client.GetUserOpByHashWithEthClient(eth))
-	c.SetGetStakeFunc(stake.GetStakeWithEthClient(eth))
-	c.UseLogger(logr)
-	c.UseModules(
-		rep.CheckStatus(),
-		rep.ValidateOpLimit(),
-		check.ValidateOpValues(),
-		check.SimulateOp(),
-		// TODO: add p2p propagation module
-		rep.IncOpsSeen(),
-	)
-
-	// Init Bundler
-	b := bundler.New(mem, chain, conf.SupportedEntryPoints)
-	b.SetGetBaseFeeFunc(gasprice.GetBaseFeeWithEthClient(eth))
-	b.SetGetGasTipFunc(gasprice.GetGasTipWithEthClient(eth))
-	b.SetGetLegacyGasPriceFunc(gasprice.GetLegacyGasPriceWithEthClient(eth))
-	b.UseLogger(logr)
-	if err := b.UserMeter(otel.GetMeterProvider().Meter("bundler")); err != nil {
-		log.Fatal(err)
-	}
-	b.UseModules(
-		exp.DropExpired(),
-		gasprice.SortByGasPrice(),
-		gasprice.FilterUnderpriced(),
-		batch.SortByNonce(),
-		batch.MaintainGasLimit(conf.MaxBatchGasLimit),
-		check.CodeHashes(),
-		check.PaymasterDeposit(),
-		check.SimulateBatch(),
-		builder.SendUserOperation(),
-		rep.IncOpsIncluded(),
-		check.Clean(),
-	)
-	if err := b.Run(); err != nil {
-		log.Fatal(err)
+	l := n.logr.WithValues("chain_id", n.chain.Uint64(), "policy", conf.ChainMismatchPolicy)
+	if switchToPrivate {
+		l.Info("chain not compatible with the Block Builder API, switching to private mode")
+		PrivateMode()
+		return
+	} else if degraded {
+		l.Info("chain not compatible with the Block Builder API, sending batches as regular transactions")
 	}
 
-	// init Debug
-	var d *client.Debug
-	if conf.DebugMode {
-		d = client.NewDebug(eoa, eth, mem, rep, b, chain, conf.SupportedEntryPoints[0], beneficiary)
-		b.SetMaxBatch(1)
+	stop := n.start()
+	defer stop()
+
+	var send modules.BatchHandlerFunc
+	var bb blockBuilder
+	builderUrls := conf.EthBuilderUrls
+	if degraded {
+		send = n.newRelayer().SendUserOperation()
+		builderUrls = []string{}
+	} else {
+		bb = newBlockBuilder(n.eoa, n.eth, n.beneficiary, conf)
+		send = bb.SendUserOperation()
 	}
 
-	// Init HTTP server
-	gin.SetMode(conf.GinMode)
-	r := gin.New()
-	if err := r.SetTrustedProxies(nil); err != nil {
-		log.Fatal(err)
-	}
-	if o11y.IsEnabled(conf.OTELServiceName) {
-		r.Use(otelgin.Middleware(conf.OTELServiceName))
-	}
-	r.Use(
-		cors.Default(),
-		logger.WithLogr(logr),
-		gin.Recovery(),
-	)
-	r.GET("/ping", func(g *gin.Context) {
-		g.Status(http.StatusOK)
-	})
-	handlers := []gin.HandlerFunc{
-		jsonrpc.Controller(client.NewRpcAdapter(c, d)),
-		jsonrpc.WithOTELTracerAttributes(),
-	}
-	r.POST("/", handlers...)
-	r.POST("/rpc", handlers...)
+	c := n.newClient()
+	b := n.newBundler(c, send, bb)
+	d := n.newDebug(b)
 
-	if err := r.Run(fmt.Sprintf(":%d", conf.Port)); err != nil {
-		log.Fatal(err)
-	}
+	n.serve(c, b, d, builderUrls, "/", "/rpc")
 }
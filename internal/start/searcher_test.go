@@ -0,0 +1,42 @@
+package start
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/config"
+)
+
+// TestCheckChainMismatch verifies that each chain mismatch policy is only applied to networks that are not
+// compatible with the Block Builder API.
+func TestCheckChainMismatch(t *testing.T) {
+	chain := big.NewInt(1)
+	for _, tc := range []struct {
+		policy          string
+		compatible      bool
+		degraded        bool
+		switchToPrivate bool
+		err             bool
+	}{
+		{policy: config.ChainMismatchFail, compatible: true},
+		{policy: config.ChainMismatchDegrade, compatible: true},
+		{policy: config.ChainMismatchAuto, compatible: true},
+		{policy: config.ChainMismatchFail, err: true},
+		{policy: config.ChainMismatchDegrade, degraded: true},
+		{policy: config.ChainMismatchAuto, switchToPrivate: true},
+	} {
+		degraded, switchToPrivate, err := checkChainMismatch(tc.policy, chain, tc.compatible)
+		if degraded != tc.degraded || switchToPrivate != tc.switchToPrivate || (err != nil) != tc.err {
+			t.Fatalf(
+				"got %v, %v, %v for %+v, want %v, %v, and error %v",
+				degraded,
+				switchToPrivate,
+				err,
+				tc,
+				tc.degraded,
+				tc.switchToPrivate,
+				tc.err,
+			)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package start
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/config"
+	"github.com/stackup-wallet/stackup-bundler/internal/logger"
+	"github.com/stackup-wallet/stackup-bundler/internal/o11y"
+	"github.com/stackup-wallet/stackup-bundler/pkg/admin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/apikey"
+	"github.com/stackup-wallet/stackup-bundler/pkg/bundler"
+	"github.com/stackup-wallet/stackup-bundler/pkg/client"
+	"github.com/stackup-wallet/stackup-bundler/pkg/dashboard"
+	"github.com/stackup-wallet/stackup-bundler/pkg/grpcapi"
+	"github.com/stackup-wallet/stackup-bundler/pkg/health"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
+	"github.com/stackup-wallet/stackup-bundler/pkg/origin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/restapi"
+	"github.com/stackup-wallet/stackup-bundler/pkg/subscription"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+)
+
+const subscriptionPath = "/ws"
+
+// newRouter returns the router for the public RPC port with the middleware shared by every mode.
+func newRouter(conf *config.Values, logr logr.Logger) *gin.Engine {
+	gin.SetMode(conf.GinMode)
+	r := gin.New()
+	if err := r.SetTrustedProxies(conf.TrustedProxies); err != nil {
+		log.Fatal(err)
+	}
+	if o11y.IsEnabled(conf.OTELServiceName) {
+		r.Use(o11y.GinMiddleware(conf.OTELServiceName))
+	}
+	r.Use(
+		getCorsHandler(conf),
+		logger.WithLogr(logr),
+		gin.Recovery(),
+	)
+	return r
+}
+
+// serve registers every API for the Client on the public RPC port and blocks until the server exits. The
+// JSON-RPC endpoint is served at each of the given paths. Health checks are added for each builder URL.
+func (n *node) serve(
+	c *client.Client,
+	b *bundler.Bundler,
+	d *client.Debug,
+	builderUrls []string,
+	paths ...string,
+) {
+	conf, logr, db := n.conf, n.logr, n.db
+
+	runAdminServer(n.rep, n.mem, conf, logr)
+	r := newRouter(conf, logr)
+	useHealth(r, getHealthChecker(n.eth, n.chain, db, &n.eoa.Address, builderUrls, conf))
+	usePrometheus(r, n.prom)
+	useReplicaExport(r, db, conf)
+	keys := getApiKeyStore(db, conf)
+	useSubscriptions(r, n.subs, getApiKeyHandlers(keys, logr), conf)
+	useAdminRpc(r, n.rep, n.mem, b, keys, conf)
+	useLogTail(r, n.tail, conf)
+	useHandoffRoutes(r, db, n.mem, n.rep, n.chain, conf)
+	auth := append(getSizeLimitHandlers(conf, logr), getApiKeyHandlers(keys, logr)...)
+	limits := append(getIpGuardHandlers(conf, logr), getRateLimitHandlers(db, conf, logr)...)
+	limits = append(limits, getSigBanHandlers(db, conf, logr)...)
+	middleware := append(append([]gin.HandlerFunc{}, auth...), limits...)
+	useRestApi(r, c, middleware, conf)
+	runGrpcServer(c, middleware, conf, logr)
+	handlers := append([]gin.HandlerFunc{}, auth...)
+	handlers = append(handlers, origin.WithHeader())
+	handlers = append(handlers, getDelegateHandlers(conf, logr)...)
+	handlers = append(handlers, limits...)
+	handlers = append(handlers, getReplicaHandlers(conf)...)
+	handlers = append(
+		handlers,
+		jsonrpc.Controller(client.NewRpcAdapter(c, d)),
+		jsonrpc.WithOTELTracerAttributes(),
+	)
+	for _, p := range paths {
+		r.POST(p, handlers...)
+	}
+
+	if err := runRPCServer(r, conf); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// newRPCServer returns a server for the JSON-RPC router with the configured timeouts. If HTTP/2 is enabled,
+// cleartext HTTP/2 is accepted alongside HTTP/1.1 so that clients behind a load balancer can multiplex
+// requests over a single connection.
+func newRPCServer(r *gin.Engine, conf *config.Values) *http.Server {
+	var h http.Handler = r
+	if conf.HTTP2Enabled {
+		h = h2c.NewHandler(r, &http2.Server{
+			MaxConcurrentStreams: conf.HTTP2MaxConcurrentStreams,
+			IdleTimeout:          conf.HTTPIdleTimeout,
+		})
+	}
+
+	return &http.Server{
+		Addr:           fmt.Sprintf(":%d", conf.Port),
+		Handler:        h,
+		ReadTimeout:    conf.HTTPReadTimeout,
+		WriteTimeout:   conf.HTTPWriteTimeout,
+		IdleTimeout:    conf.HTTPIdleTimeout,
+		MaxHeaderBytes: conf.HTTPMaxHeaderBytes,
+	}
+}
+
+// runRPCServer serves the JSON-RPC router on the configured port.
+func runRPCServer(r *gin.Engine, conf *config.Values) error {
+	return newRPCServer(r, conf).ListenAndServe()
+}
+
+// runAdminServer serves the admin endpoints on a separate address so that they are never exposed on the
+// public RPC port.
+func runAdminServer(
+	rep *entities.Reputation,
+	mem *mempool.Mempool,
+	conf *config.Values,
+	logr logr.Logger,
+) {
+	if conf.AdminAddr == "" {
+		return
+	}
+
+	r := gin.New()
+	r.Use(
+		logger.WithLogr(logr.WithValues("server", "admin")),
+		gin.Recovery(),
+	)
+	r.GET("/reputation/constants", admin.GetReputationConstants(rep))
+	r.PUT("/reputation/constants", admin.SetReputationConstants(rep))
+	r.GET("/reputation/constants/audit", admin.GetReputationConstantsAudit(rep))
+	r.GET("/mempool/:entryPoint", admin.GetMempoolOps(mem))
+
+	go func() {
+		if err := r.Run(conf.AdminAddr); err != nil {
+			log.Fatal(err)
+		}
+	}()
+}
+
+// useAdminRpc serves the authenticated admin_* namespace on the RPC server if a token is configured.
+func useAdminRpc(
+	r *gin.Engine,
+	rep *entities.Reputation,
+	mem *mempool.Mempool,
+	b *bundler.Bundler,
+	keys *apikey.Store,
+	conf *config.Values,
+) {
+	if conf.AdminRpcToken == "" {
+		return
+	}
+	if !runsBundler(conf) {
+		b = nil
+	}
+
+	r.POST(
+		"/admin",
+		admin.Auth(conf.AdminRpcToken),
+		jsonrpc.Controller(admin.NewRpcAdapter(rep, mem, b, keys, conf.SupportedEntryPoints, func() any {
+			return conf.Redacted()
+		})),
+		jsonrpc.WithOTELTracerAttributes(),
+	)
+}
+
+// runDashboardServer starts recording dashboard snapshots and serves them for the Grafana JSON datasource on a
+// separate address. A nil Recorder is returned if the dashboard is not enabled.
+func runDashboardServer(mem *mempool.Mempool, conf *config.Values, logr logr.Logger) *dashboard.Recorder {
+	if conf.DashboardAddr == "" {
+		return nil
+	}
+
+	rec := dashboard.New(mem, conf.SupportedEntryPoints)
+	rec.SetInterval(conf.DashboardInterval)
+	rec.Run()
+
+	r := gin.New()
+	r.Use(
+		logger.WithLogr(logr.WithValues("server", "dashboard")),
+		gin.Recovery(),
+	)
+	dashboard.Routes(r, rec)
+
+	go func() {
+		if err := r.Run(conf.DashboardAddr); err != nil {
+			log.Fatal(err)
+		}
+	}()
+	return rec
+}
+
+// runGrpcServer serves the gRPC API on a separate address, if configured. The middleware is shared with the
+// JSON-RPC routes so that API keys, size limits, rate limits, and bans apply to all APIs. Clients pass an API
+// key in the x-api-key metadata.
+func runGrpcServer(c *client.Client, middleware []gin.HandlerFunc, conf *config.Values, logr logr.Logger) {
+	if conf.GrpcAddr == "" {
+		return
+	}
+
+	lis, err := net.Listen("tcp", conf.GrpcAddr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	gs := grpc.NewServer(grpcapi.MiddlewareOptions(middleware...)...)
+	grpcapi.New(c).Register(gs)
+
+	go func() {
+		logr.Info("serving gRPC API", "addr", conf.GrpcAddr)
+		if err := gs.Serve(lis); err != nil {
+			log.Fatal(err)
+		}
+	}()
+}
+
+// useRestApi adds the REST facade to the router, if enabled. The middleware is shared with the JSON-RPC
+// routes so that API keys, size limits, rate limits, and bans apply to all APIs. Relayer signatures and replica
+// forwarding are only supported over JSON-RPC.
+func useRestApi(r *gin.Engine, c *client.Client, middleware []gin.HandlerFunc, conf *config.Values) {
+	if !conf.RestApiEnabled {
+		return
+	}
+
+	restapi.New(c).Register(r, middleware...)
+}
+
+// useSubscriptions serves eth_subscribe over WebSocket alongside the HTTP JSON-RPC endpoints. Browser
+// connections are limited to the CORS allowed origins. If API key auth is enabled, clients that cannot set
+// headers on a WebSocket pass their key in the apiKey query param.
+func useSubscriptions(r *gin.Engine, subs *subscription.Manager, auth []gin.HandlerFunc, conf *config.Values) {
+	h, err := subs.Handler(conf.CorsAllowedOrigins)
+	if err != nil {
+		log.Fatal(err)
+	}
+	r.GET(subscriptionPath, append(append([]gin.HandlerFunc{}, auth...), gin.WrapH(h))...)
+}
+
+// getHealthChecker returns a Checker for the eth RPC connection and chain ID. The database, EOA balance, and
+// builder checks are only added if the db, EOA address, or builder URLs are given.
+func getHealthChecker(
+	eth *ethclient.Client,
+	chain *big.Int,
+	db *badger.DB,
+	eoa *common.Address,
+	builderUrls []string,
+	conf *config.Values,
+) *health.Checker {
+	h := health.New(conf.HealthCheckTimeout)
+	h.Add("eth_rpc", true, health.EthClient(eth))
+	h.Add("chain_id", true, health.ChainID(eth, chain))
+	if db != nil {
+		h.Add("badger", true, health.Badger(db))
+	}
+	if eoa != nil {
+		h.Add("eoa_balance", false, health.Balance(eth, *eoa, conf.HealthMinBalance))
+	}
+	for _, u := range builderUrls {
+		h.Add(health.EndpointName("builder", u), false, health.Endpoint(u))
+	}
+	return h
+}
+
+// useHealth adds /livez, /healthz, and /readyz routes for load balancers and Kubernetes probes. The /ping
+// route is kept as an alias of /livez for existing deployments.
+func useHealth(r *gin.Engine, h *health.Checker) {
+	r.GET("/ping", func(g *gin.Context) {
+		g.Status(http.StatusOK)
+	})
+	r.GET("/livez", health.LiveHandler())
+	r.GET("/healthz", h.HealthHandler())
+	r.GET("/readyz", h.ReadyHandler())
+}
+
+// getPrometheusExporter returns an exporter for serving metrics to Prometheus or nil if it is not enabled.
+func getPrometheusExporter(conf *config.Values) *o11y.PrometheusExporter {
+	if !conf.PrometheusEnabled {
+		return nil
+	}
+
+	return o11y.NewPrometheusExporter()
+}
+
+func getPrometheusReaders(prom *o11y.PrometheusExporter) []sdkmetric.Reader {
+	if prom == nil {
+		return []sdkmetric.Reader{}
+	}
+
+	return []sdkmetric.Reader{prom.Reader()}
+}
+
+// usePrometheus adds a /metrics route for Prometheus to scrape, if enabled.
+func usePrometheus(r *gin.Engine, prom *o11y.PrometheusExporter) {
+	if prom == nil {
+		return
+	}
+
+	r.GET("/metrics", prom.Handler())
+}
+
+// getLogTail returns a Tail for buffering recent log events or nil if the endpoint is disabled. The endpoint
+// requires the admin token so it is only enabled if one is configured.
+func getLogTail(conf *config.Values) *logger.Tail {
+	if conf.AdminRpcToken == "" || conf.LogTailSize == 0 {
+		return nil
+	}
+
+	return logger.NewTail(conf.LogTailSize)
+}
+
+// useLogTail adds an authenticated /logs/tail route for streaming log events, if enabled.
+func useLogTail(r *gin.Engine, tail *logger.Tail, conf *config.Values) {
+	if tail == nil {
+		return
+	}
+
+	r.GET("/logs/tail", admin.Auth(conf.AdminRpcToken), logger.TailHandler(tail))
+}
@@ -0,0 +1,83 @@
+package start
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/internal/config"
+	"golang.org/x/net/http2"
+)
+
+func newTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", func(g *gin.Context) {
+		g.String(http.StatusOK, g.Request.Proto)
+	})
+	return r
+}
+
+// getProto returns the protocol of a cleartext HTTP/2 request to srv, or an error if it is not accepted.
+func getProto(t *testing.T, srv *http.Server) (string, error) {
+	ts := httptest.NewUnstartedServer(srv.Handler)
+	ts.Config = srv
+	ts.Start()
+	defer ts.Close()
+
+	c := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+	res, err := c.Get(ts.URL)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	return res.Proto, nil
+}
+
+// TestNewRPCServer verifies that the configured timeouts and header limit are set on the server.
+func TestNewRPCServer(t *testing.T) {
+	conf := &config.Values{
+		Port:               4337,
+		HTTPReadTimeout:    time.Second,
+		HTTPWriteTimeout:   2 * time.Second,
+		HTTPIdleTimeout:    3 * time.Second,
+		HTTPMaxHeaderBytes: 1024,
+	}
+	srv := newRPCServer(newTestRouter(), conf)
+	if srv.Addr != ":4337" {
+		t.Fatalf("got %s, want :4337", srv.Addr)
+	}
+	if srv.ReadTimeout != time.Second || srv.WriteTimeout != 2*time.Second || srv.IdleTimeout != 3*time.Second {
+		t.Fatalf("got %s/%s/%s, want 1s/2s/3s", srv.ReadTimeout, srv.WriteTimeout, srv.IdleTimeout)
+	}
+	if srv.MaxHeaderBytes != 1024 {
+		t.Fatalf("got %d, want 1024", srv.MaxHeaderBytes)
+	}
+}
+
+// TestNewRPCServerHTTP2 verifies that cleartext HTTP/2 is only accepted if it is enabled.
+func TestNewRPCServerHTTP2(t *testing.T) {
+	conf := &config.Values{HTTPMaxHeaderBytes: 1 << 20, HTTP2MaxConcurrentStreams: 250}
+	if _, err := getProto(t, newRPCServer(newTestRouter(), conf)); err == nil {
+		t.Fatal("got nil, want error with HTTP/2 disabled")
+	}
+
+	conf.HTTP2Enabled = true
+	proto, err := getProto(t, newRPCServer(newTestRouter(), conf))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if proto != "HTTP/2.0" {
+		t.Fatalf("got %s, want HTTP/2.0", proto)
+	}
+}
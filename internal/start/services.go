@@ -0,0 +1,269 @@
+package start
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/config"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backup"
+	"github.com/stackup-wallet/stackup-bundler/pkg/bundler"
+	"github.com/stackup-wallet/stackup-bundler/pkg/chainhead"
+	"github.com/stackup-wallet/stackup-bundler/pkg/diskquota"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool/pgstore"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool/redisstore"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool/sqlitestore"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
+	"github.com/stackup-wallet/stackup-bundler/pkg/opstatus"
+	"go.opentelemetry.io/otel"
+)
+
+func runDBGarbageCollection(db *badger.DB) {
+	go func(db *badger.DB) {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+
+		for range ticker.C {
+		again:
+			err := db.RunValueLogGC(0.7)
+			if err == nil {
+				goto again
+			}
+		}
+	}(db)
+}
+
+// getMempool returns a Mempool using the configured storage backend. The badger and sqlite backends are local
+// to this process. Every backend only stores pending ops while reputation and the other stores stay in db.
+// With a shared backend, the in-memory index is periodically reloaded so that ops added or removed by other
+// bundler processes are picked up. All processes can accept ops but only the one holding the lease from
+// getBundlerClaimFunc sends bundles.
+func getMempool(db *badger.DB, conf *config.Values, logr logr.Logger) (*mempool.Mempool, error) {
+	var store mempool.Store
+	switch conf.MempoolBackend {
+	case "redis":
+		rs, err := redisstore.NewFromURL(conf.MempoolRedisUrl)
+		if err != nil {
+			return nil, err
+		}
+		rs.SetPrefix(conf.MempoolRedisPrefix)
+		store = rs
+	case "postgres":
+		ps, err := pgstore.NewFromURL(conf.MempoolPostgresUrl)
+		if err != nil {
+			return nil, err
+		}
+		store = ps
+	case "sqlite":
+		ss, err := sqlitestore.NewFromPath(conf.MempoolSqlitePath)
+		if err != nil {
+			return nil, err
+		}
+		return mempool.NewWithStore(ss)
+	default:
+		return mempool.New(db)
+	}
+
+	mem, err := mempool.NewWithStore(store)
+	if err != nil {
+		return nil, err
+	}
+
+	l := logr.WithName("mempool_sync")
+	go func(mem *mempool.Mempool) {
+		ticker := time.NewTicker(conf.MempoolSyncInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := mem.Reload(); err != nil {
+				l.Error(err, "mempool sync error")
+			}
+		}
+	}(mem)
+
+	return mem, nil
+}
+
+// getBundlerClaimFunc returns a ClaimFunc that leases the mempool to this process for the configured TTL. The
+// lease is renewed on every bundler run and only expires if this process stops bundling. With a local backend
+// the mempool is not shared and the lease is always granted.
+func getBundlerClaimFunc(mem *mempool.Mempool, conf *config.Values) (bundler.ClaimFunc, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	owner := hex.EncodeToString(b)
+
+	return func() (bool, error) {
+		return mem.Claim(owner, conf.MempoolLeaseTTL)
+	}, nil
+}
+
+// runChainHeadWatcher starts a Watcher for new blocks. A separate websocket client is used for subscriptions
+// if one is configured. Otherwise the default client is used, which falls back to polling if it does not
+// support subscriptions.
+func runChainHeadWatcher(eth *ethclient.Client, conf *config.Values, logr logr.Logger) *chainhead.Watcher {
+	if conf.ChainHeadWsUrl != "" {
+		ws, err := ethclient.Dial(conf.ChainHeadWsUrl)
+		if err != nil {
+			log.Fatal(err)
+		}
+		eth = ws
+	}
+
+	w := chainhead.New(eth, logr)
+	w.SetPollInterval(conf.ChainHeadPollInterval)
+	if err := w.UseMeter(otel.GetMeterProvider().Meter("chainhead")); err != nil {
+		log.Fatal(err)
+	}
+	if err := w.Start(); err != nil {
+		log.Fatal(err)
+	}
+	return w
+}
+
+// diskQuotaPruneAge is how long terminal op status records are kept once the data directory is at the soft
+// limit.
+var diskQuotaPruneAge = time.Hour
+
+// runDiskQuota starts monitoring the data directory or returns nil if both limits are disabled. At the soft
+// limit, the DB is garbage collected aggressively and old op status records are pruned.
+func runDiskQuota(db *badger.DB, sts *opstatus.Tracker, conf *config.Values, logr logr.Logger) *diskquota.Quota {
+	if conf.DataDirSoftLimit <= 0 && conf.DataDirHardLimit <= 0 {
+		return nil
+	}
+
+	q := diskquota.New(conf.DataDirectory, conf.DataDirSoftLimit, conf.DataDirHardLimit, logr)
+	q.SetInterval(conf.DataDirCheckInterval)
+	q.OnSoftLimit(func() error {
+		_, err := sts.Prune(time.Now().Add(-diskQuotaPruneAge))
+		return err
+	})
+	q.OnSoftLimit(diskquota.BadgerGC(db))
+	if err := q.Run(); err != nil {
+		log.Fatal(err)
+	}
+	return q
+}
+
+func runBanReview(
+	rep *entities.Reputation,
+	entryPoint common.Address,
+	cooldown time.Duration,
+	logr logr.Logger,
+) {
+	if cooldown <= 0 {
+		return
+	}
+
+	l := logr.WithName("ban_review").WithValues("entrypoint", entryPoint.String())
+	rep.RunBanReview(entryPoint, cooldown, time.Minute, func(results []*entities.BanReviewResult, err error) {
+		if err != nil {
+			l.Error(err, "ban review error")
+			return
+		}
+
+		for _, res := range results {
+			l.WithValues("entity", res.Address.String()).
+				WithValues("banned_at", res.BannedAt.Unix()).
+				WithValues("rejected", res.Rejected).
+				WithValues("ops_seen", res.OpsSeen).
+				WithValues("ops_included", res.OpsIncluded).
+				WithValues("staked", res.Staked).
+				WithValues("restored", res.Restored).
+				Info("ban review ok")
+		}
+	})
+}
+
+func runReputationFlush(
+	rep *entities.Reputation,
+	mem *mempool.Mempool,
+	size int,
+	interval time.Duration,
+	logr logr.Logger,
+) {
+	if size <= 0 {
+		return
+	}
+
+	l := logr.WithName("reputation_flush")
+	rep.SetWriteBuffer(size)
+	if n, err := rep.Replay(mem.DumpAfterVersion); errors.Is(err, mempool.ErrVersionsNotTracked) {
+		l.Info("reputation replay skipped, mempool backend does not track versions")
+	} else if err != nil {
+		log.Fatal(err)
+	} else if n > 0 {
+		l.WithValues("ops_replayed", n).Info("reputation replay ok")
+	}
+
+	go func(rep *entities.Reputation) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := rep.Flush(); err != nil {
+				l.Error(err, "reputation flush error")
+			}
+		}
+	}(rep)
+}
+
+// getBackupStore returns the backup.Store for the configured backup URL. URLs with the s3 scheme are written
+// to the configured S3-compatible endpoint. Any other value is treated as a local directory.
+func getBackupStore(conf *config.Values) (backup.Store, error) {
+	if !strings.HasPrefix(conf.BackupUrl, "s3://") {
+		return backup.NewLocalStore(strings.TrimPrefix(conf.BackupUrl, "file://"))
+	}
+
+	u, err := url.Parse(conf.BackupUrl)
+	if err != nil {
+		return nil, err
+	}
+	return backup.NewS3Store(backup.S3Opts{
+		Endpoint:  conf.BackupS3Endpoint,
+		Region:    conf.BackupS3Region,
+		Bucket:    u.Host,
+		Prefix:    u.Path,
+		AccessKey: conf.BackupS3AccessKey,
+		SecretKey: conf.BackupS3SecretKey,
+	})
+}
+
+func runBackups(db *badger.DB, conf *config.Values, logr logr.Logger) {
+	if conf.BackupUrl == "" {
+		return
+	}
+
+	store, err := getBackupStore(conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	l := logr.WithName("backup")
+	go func(db *badger.DB) {
+		ticker := time.NewTicker(conf.BackupInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			start := time.Now()
+			name, err := backup.Run(db, store)
+			if err != nil {
+				l.Error(err, "backup error")
+				continue
+			}
+			l.WithValues("name", name).
+				WithValues("duration_ms", time.Since(start).Milliseconds()).
+				Info("backup ok")
+		}
+	}(db)
+}
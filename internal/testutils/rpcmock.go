@@ -8,19 +8,30 @@ import (
 )
 
 type mockReq struct {
-	JsonRpc string  `json:"jsonrpc"`
-	ID      float64 `json:"id"`
-	Method  string  `json:"method"`
+	JsonRpc string            `json:"jsonrpc"`
+	ID      float64           `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+type mockErr struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
 }
 
 type mockRes struct {
-	JsonRpc string  `json:"jsonrpc"`
-	ID      float64 `json:"id"`
-	Result  any     `json:"result"`
+	JsonRpc string   `json:"jsonrpc"`
+	ID      float64  `json:"id"`
+	Result  any      `json:"result"`
+	Error   *mockErr `json:"error,omitempty"`
 }
 
 type MethodMocks map[string]any
 
+// MethodFunc can be used as a value in MethodMocks to return a result based on the request params. If an
+// error is returned, the mock responds with a JSON-RPC error instead.
+type MethodFunc func(params []json.RawMessage) (any, error)
+
 func RpcMock(mocks MethodMocks) *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var req mockReq
@@ -41,6 +52,15 @@ func RpcMock(mocks MethodMocks) *httptest.Server {
 			ID:      req.ID,
 			Result:  mock,
 		}
+		if fn, ok := mock.(MethodFunc); ok {
+			result, err := fn(req.Params)
+			if err != nil {
+				res.Result = nil
+				res.Error = &mockErr{Code: -32000, Message: err.Error()}
+			} else {
+				res.Result = result
+			}
+		}
 		w.WriteHeader(http.StatusOK)
 		if err := json.NewEncoder(w).Encode(res); err != nil {
 			panic(err)
@@ -0,0 +1,65 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func queryMempool(mem *mempool.Mempool, g *gin.Context) ([]*userop.UserOperation, error) {
+	ep := g.Param("entryPoint")
+	if !common.IsHexAddress(ep) {
+		return nil, errors.New("entryPoint: invalid address")
+	}
+	epAddr := common.HexToAddress(ep)
+
+	if sender := g.Query("sender"); sender != "" {
+		if !common.IsHexAddress(sender) {
+			return nil, errors.New("sender: invalid address")
+		}
+		return mem.GetOpsBySender(epAddr, common.HexToAddress(sender))
+	}
+	if paymaster := g.Query("paymaster"); paymaster != "" {
+		if !common.IsHexAddress(paymaster) {
+			return nil, errors.New("paymaster: invalid address")
+		}
+		return mem.GetOpsByPaymaster(epAddr, common.HexToAddress(paymaster))
+	}
+	if minMaxFee := g.Query("minMaxFee"); minMaxFee != "" {
+		fee, err := hexutil.DecodeBig(minMaxFee)
+		if err != nil {
+			return nil, errors.New("minMaxFee: invalid hex value")
+		}
+		return mem.DumpByMaxFee(epAddr, fee)
+	}
+	return mem.Dump(epAddr)
+}
+
+// GetMempoolOps returns a handler that responds with the UserOperations in the mempool for an EntryPoint.
+// Results can be filtered using one of the sender, paymaster, or minMaxFee query params which are served from
+// the mempool's indexes.
+func GetMempoolOps(mem *mempool.Mempool) gin.HandlerFunc {
+	return func(g *gin.Context) {
+		ops, err := queryMempool(mem, g)
+		if err != nil {
+			g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		res := []map[string]any{}
+		for _, op := range ops {
+			item, err := op.ToMap()
+			if err != nil {
+				g.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			res = append(res, item)
+		}
+		g.JSON(http.StatusOK, res)
+	}
+}
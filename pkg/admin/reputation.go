@@ -0,0 +1,66 @@
+// Package admin implements gin handlers for operator endpoints used to inspect and tune the bundler at
+// runtime. These handlers have no authentication and should only be served on a private address. The admin_*
+// JSON-RPC namespace requires a bearer token and can be served on the public RPC port.
+package admin
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
+)
+
+// ActorHeader is the request header used to identify the operator in the audit trail. The client IP is used
+// if it is not set.
+const ActorHeader = "X-Admin-Actor"
+
+// GetReputationConstants returns a handler that responds with the ReputationConstants currently in use.
+func GetReputationConstants(rep *entities.Reputation) gin.HandlerFunc {
+	return func(g *gin.Context) {
+		g.JSON(http.StatusOK, rep.GetConstants())
+	}
+}
+
+// SetReputationConstants returns a handler that updates the ReputationConstants. Fields omitted from the
+// request body keep their current value.
+func SetReputationConstants(rep *entities.Reputation) gin.HandlerFunc {
+	return func(g *gin.Context) {
+		body, err := io.ReadAll(g.Request.Body)
+		if err != nil {
+			g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		next := rep.GetConstants()
+		if err := json.Unmarshal(body, &next); err != nil {
+			g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		actor := g.GetHeader(ActorHeader)
+		if actor == "" {
+			actor = g.ClientIP()
+		}
+		change, err := rep.SetConstants(next, actor)
+		if err != nil {
+			g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		g.JSON(http.StatusOK, change)
+	}
+}
+
+// GetReputationConstantsAudit returns a handler that responds with all recorded changes to the
+// ReputationConstants.
+func GetReputationConstantsAudit(rep *entities.Reputation) gin.HandlerFunc {
+	return func(g *gin.Context) {
+		changes, err := rep.GetConstantsAudit()
+		if err != nil {
+			g.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		g.JSON(http.StatusOK, changes)
+	}
+}
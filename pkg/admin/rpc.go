@@ -0,0 +1,298 @@
+package admin
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/apikey"
+	"github.com/stackup-wallet/stackup-bundler/pkg/bundler"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
+	"github.com/stackup-wallet/stackup-bundler/pkg/snapshot"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+var (
+	// ErrBundlerNotRunning is returned by bundling controls in a process that does not run the Bundler.
+	ErrBundlerNotRunning = errors.New("admin: bundler is not run by this process")
+
+	// ErrApiKeyAuthDisabled is returned by API key management methods if API key auth is not enabled.
+	ErrApiKeyAuthDisabled = errors.New("admin: api key auth is not enabled")
+)
+
+// GetConfigFunc returns the current config with all secrets removed.
+type GetConfigFunc = func() any
+
+// Auth returns a middleware that rejects any request without the given bearer token.
+func Auth(token string) gin.HandlerFunc {
+	return func(g *gin.Context) {
+		got, ok := strings.CutPrefix(g.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			g.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		g.Next()
+	}
+}
+
+// Named mempool query type for jsonrpc package.
+type optional_mempoolQuery map[string]any
+
+// Named API key config type for jsonrpc package.
+type apiKeyConfig map[string]any
+
+// Named mempool snapshot type for jsonrpc package.
+type mempoolSnapshot map[string]any
+
+// RpcAdapter routes admin_* JSON-RPC method calls for operational control of a running bundler. Unlike the
+// debug namespace, these methods are safe to use in production.
+type RpcAdapter struct {
+	rep         *entities.Reputation
+	mem         *mempool.Mempool
+	bundler     *bundler.Bundler
+	keys        *apikey.Store
+	entryPoints []common.Address
+	getConfig   GetConfigFunc
+}
+
+// NewRpcAdapter initializes a new RpcAdapter for the admin namespace. The bundler can be nil if it is not run
+// by this process and keys can be nil if API key auth is not enabled.
+func NewRpcAdapter(
+	rep *entities.Reputation,
+	mem *mempool.Mempool,
+	bundler *bundler.Bundler,
+	keys *apikey.Store,
+	entryPoints []common.Address,
+	getConfig GetConfigFunc,
+) *RpcAdapter {
+	return &RpcAdapter{rep, mem, bundler, keys, entryPoints, getConfig}
+}
+
+func parseAddress(address string) (common.Address, error) {
+	if !common.IsHexAddress(address) {
+		return common.Address{}, fmt.Errorf("admin: invalid address %s", address)
+	}
+	return common.HexToAddress(address), nil
+}
+
+type getOpsFunc = func(ep common.Address, entity common.Address) ([]*userop.UserOperation, error)
+
+func (r *RpcAdapter) ban(address string, getOps getOpsFunc) (string, error) {
+	entity, err := parseAddress(address)
+	if err != nil {
+		return "", err
+	}
+	if err := r.rep.Ban(entity); err != nil {
+		return "", err
+	}
+
+	// Drop pending ops so that the ban also applies to the next bundle.
+	for _, ep := range r.entryPoints {
+		ops, err := getOps(ep, entity)
+		if err != nil {
+			return "", err
+		}
+		if err := r.mem.RemoveOps(ep, ops...); err != nil {
+			return "", err
+		}
+	}
+	return "ok", nil
+}
+
+func (r *RpcAdapter) unban(address string) (string, error) {
+	entity, err := parseAddress(address)
+	if err != nil {
+		return "", err
+	}
+	if err := r.rep.Unban(entity); err != nil {
+		return "", err
+	}
+	return "ok", nil
+}
+
+// Admin_banSender bans a sender and removes its pending UserOperations from the mempool.
+func (r *RpcAdapter) Admin_banSender(address string) (string, error) {
+	return r.ban(address, r.mem.GetOpsBySender)
+}
+
+// Admin_unbanSender removes a ban on a sender.
+func (r *RpcAdapter) Admin_unbanSender(address string) (string, error) {
+	return r.unban(address)
+}
+
+// Admin_banPaymaster bans a paymaster and removes all pending UserOperations it sponsors from the mempool.
+func (r *RpcAdapter) Admin_banPaymaster(address string) (string, error) {
+	return r.ban(address, r.mem.GetOpsByPaymaster)
+}
+
+// Admin_unbanPaymaster removes a ban on a paymaster.
+func (r *RpcAdapter) Admin_unbanPaymaster(address string) (string, error) {
+	return r.unban(address)
+}
+
+// Admin_flushMempool removes all pending UserOperations from the mempool. Reputation data is kept.
+func (r *RpcAdapter) Admin_flushMempool() (string, error) {
+	for _, ep := range r.entryPoints {
+		ops, err := r.mem.Dump(ep)
+		if err != nil {
+			return "", err
+		}
+		if err := r.mem.RemoveOps(ep, ops...); err != nil {
+			return "", err
+		}
+	}
+	return "ok", nil
+}
+
+// Admin_dumpMempool returns a page of the UserOperations in the mempool for an EntryPoint. Results can be
+// filtered by sender, paymaster, factory, and a minMaxFee to maxMaxFee range. See mempool.ParseQuery for all
+// supported options.
+func (r *RpcAdapter) Admin_dumpMempool(ep string, q optional_mempoolQuery) (*mempool.QueryResult, error) {
+	epAddr, err := parseAddress(ep)
+	if err != nil {
+		return nil, err
+	}
+	query, err := mempool.ParseQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	return r.mem.Query(epAddr, query)
+}
+
+// Admin_exportMempool returns a snapshot of all pending UserOperations for every supported EntryPoint. The
+// result uses the same JSON format as the mempool export command and can be loaded with admin_importMempool.
+func (r *RpcAdapter) Admin_exportMempool() (*snapshot.Snapshot, error) {
+	return snapshot.Export(r.mem, r.entryPoints)
+}
+
+// ImportMempoolResult is the response of admin_importMempool.
+type ImportMempoolResult struct {
+	Imported int `json:"imported"`
+}
+
+// Admin_importMempool adds every UserOperation in a snapshot from admin_exportMempool to the mempool. See
+// snapshot.Import for how ops are added.
+func (r *RpcAdapter) Admin_importMempool(data mempoolSnapshot) (*ImportMempoolResult, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	s, err := snapshot.Decode(bytes.NewReader(b), snapshot.FormatJSON)
+	if err != nil {
+		return nil, fmt.Errorf("admin: invalid mempool snapshot: %w", err)
+	}
+
+	n, err := snapshot.Import(r.mem, r.entryPoints, s)
+	if err != nil {
+		return nil, err
+	}
+	return &ImportMempoolResult{Imported: n}, nil
+}
+
+// Admin_setApiKey registers an API key or replaces its config. See apikey.Key for the config fields.
+func (r *RpcAdapter) Admin_setApiKey(key string, cfg apiKeyConfig) (string, error) {
+	if r.keys == nil {
+		return "", ErrApiKeyAuthDisabled
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	var k apikey.Key
+	if err := json.Unmarshal(data, &k); err != nil {
+		return "", fmt.Errorf("admin: invalid api key config: %w", err)
+	}
+	if err := r.keys.Put(key, &k); err != nil {
+		return "", err
+	}
+	return "ok", nil
+}
+
+// Admin_removeApiKey revokes an API key that was registered with Admin_setApiKey.
+func (r *RpcAdapter) Admin_removeApiKey(key string) (string, error) {
+	if r.keys == nil {
+		return "", ErrApiKeyAuthDisabled
+	}
+
+	if err := r.keys.Delete(key); err != nil {
+		return "", err
+	}
+	return "ok", nil
+}
+
+// Admin_pauseBundling stops the Bundler from sending bundles. UserOperations are still accepted into the
+// mempool.
+func (r *RpcAdapter) Admin_pauseBundling() (string, error) {
+	if r.bundler == nil {
+		return "", ErrBundlerNotRunning
+	}
+
+	r.bundler.Stop()
+	return "ok", nil
+}
+
+// Admin_resumeBundling restarts a paused Bundler.
+func (r *RpcAdapter) Admin_resumeBundling() (string, error) {
+	if r.bundler == nil {
+		return "", ErrBundlerNotRunning
+	}
+
+	if err := r.bundler.Run(); err != nil {
+		return "", err
+	}
+	return "ok", nil
+}
+
+// BundleOpResult is the response of admin_bundleOpNow.
+type BundleOpResult struct {
+	UserOpHash      string           `json:"userOpHash"`
+	TransactionHash string           `json:"transactionHash,omitempty"`
+	DroppedReason   string           `json:"droppedReason,omitempty"`
+	Receipt         *bundler.Receipt `json:"receipt"`
+}
+
+// Admin_bundleOpNow immediately sends a bundle with only the pending UserOperation matching the given hash.
+// The bundling interval and sorting of other pending UserOperations are bypassed but the op still goes through
+// all batch checks. If a check drops the op, the reason is returned instead of a transaction hash. The full
+// diagnostics of the run are included in the receipt.
+func (r *RpcAdapter) Admin_bundleOpNow(userOpHash string) (*BundleOpResult, error) {
+	if r.bundler == nil {
+		return nil, ErrBundlerNotRunning
+	}
+	b, err := hexutil.Decode(userOpHash)
+	if err != nil || len(b) != common.HashLength {
+		return nil, fmt.Errorf("admin: invalid userOpHash %s", userOpHash)
+	}
+
+	rec, err := r.bundler.ProcessOpWithReceipt(common.BytesToHash(b))
+	if err != nil {
+		return nil, err
+	}
+	res := &BundleOpResult{UserOpHash: userOpHash, Receipt: rec}
+	for _, item := range rec.ExcludedUserOps {
+		if item.Dropped {
+			res.DroppedReason = item.Reason
+		}
+	}
+	if len(rec.SelectedUserOpHashes) > 0 {
+		if rec.TransactionHash == "" {
+			return nil, errors.New("admin: txn_hash not in ctx Data")
+		}
+		res.TransactionHash = rec.TransactionHash
+	}
+	return res, nil
+}
+
+// Admin_dumpConfig returns the config the process was started with. Secrets are redacted.
+func (r *RpcAdapter) Admin_dumpConfig() (any, error) {
+	return r.getConfig(), nil
+}
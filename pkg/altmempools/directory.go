@@ -67,7 +67,7 @@ func New(chain *big.Int, altMempools []*Config) (*Directory, error) {
 		for _, item := range alt.Data["allowlist"].([]any) {
 			config := item.(map[string]any)
 			switch config["rule"].(string) {
-			case "invalidStorageAccess":
+			case InvalidStorageAccessRule:
 				{
 					isaId := invalidStorageAccessID(
 						config["entity"].(string),
@@ -88,3 +88,15 @@ func TestDirectoryIncompatibleChain(t *testing.T) {
 		t.Fatalf("got %v, want []", mempools)
 	}
 }
+
+func TestGetMempoolIdsIsUnique(t *testing.T) {
+	exs := []*altmempools.Exception{
+		{MempoolIds: []string{"1", "2"}},
+		{MempoolIds: []string{"2", "3"}},
+	}
+
+	ids := altmempools.GetMempoolIds(exs)
+	if len(ids) != 3 || ids[0] != "1" || ids[1] != "2" || ids[2] != "3" {
+		t.Fatalf("got %v, want [1 2 3]", ids)
+	}
+}
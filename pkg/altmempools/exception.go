@@ -0,0 +1,30 @@
+package altmempools
+
+// InvalidStorageAccessRule is the alternative mempool rule that allows an entity to access a storage slot
+// that is forbidden in the canonical mempool.
+const InvalidStorageAccessRule = "invalidStorageAccess"
+
+// Exception is a record of an alternative mempool rule that was applied to accept a UserOperation that would
+// have been rejected by the canonical mempool.
+type Exception struct {
+	Rule       string   `json:"rule"`
+	Entity     string   `json:"entity"`
+	Contract   string   `json:"contract"`
+	Slot       string   `json:"slot"`
+	MempoolIds []string `json:"mempoolIds"`
+}
+
+// GetMempoolIds returns the unique alternative mempool ids across all given exceptions.
+func GetMempoolIds(exceptions []*Exception) []string {
+	seen := map[string]bool{}
+	ids := []string{}
+	for _, ex := range exceptions {
+		for _, id := range ex.MempoolIds {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,135 @@
+package apikey
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/ginutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+)
+
+const (
+	// Header is the HTTP header a client sets to its API key.
+	Header = "X-Api-Key"
+
+	// QueryParam is the URL query param a client can use to pass its API key if it cannot set headers.
+	QueryParam = "apiKey"
+)
+
+// parseRequests returns the methods called in a single or batch request along with the id of the first call.
+func parseRequests(body []byte) (methods []string, id any) {
+	for i, r := range jsonrpc.ParseRequests(body) {
+		if i == 0 {
+			id = r.Id
+		}
+		methods = append(methods, r.Method)
+	}
+	return methods, id
+}
+
+func isAllowed(k *Key, method string) bool {
+	if len(k.Methods) == 0 {
+		return true
+	}
+	for _, m := range k.Methods {
+		if jsonrpc.CanonicalMethod(m) == method {
+			return true
+		}
+	}
+	return false
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+// quotas tracks the number of calls made by each key within the current one minute window.
+type quotas struct {
+	mu      sync.Mutex
+	windows map[string]*window
+}
+
+// take records n calls for the key and returns false if doing so would exceed its quota.
+func (q *quotas) take(k *Key, n int, now time.Time) bool {
+	if k.RequestsPerMinute == 0 {
+		return true
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	w, ok := q.windows[k.Name]
+	if !ok || now.Sub(w.start) >= time.Minute {
+		w = &window{start: now}
+		q.windows[k.Name] = w
+	}
+	if w.count+n > k.RequestsPerMinute {
+		return false
+	}
+	w.count += n
+	return true
+}
+
+// Middleware returns a gin middleware that rejects requests without a registered API key. The key is read
+// from the Header or QueryParam. Requests are also rejected if they call a method the key is not allowed to
+// use or if the key has exceeded its quota, where each call in a batch counts towards the quota. Otherwise
+// the key's name is set in the gin context for downstream middleware and logs.
+func Middleware(s *Store, l logr.Logger) gin.HandlerFunc {
+	l = l.WithName("apikey")
+	q := &quotas{windows: make(map[string]*window)}
+
+	return func(g *gin.Context) {
+		body, err := io.ReadAll(g.Request.Body)
+		if err != nil {
+			_ = g.Error(err)
+			g.Abort()
+			return
+		}
+		g.Request.Body = io.NopCloser(bytes.NewReader(body))
+		methods, id := parseRequests(body)
+
+		key := g.GetHeader(Header)
+		if key == "" {
+			key = g.Query(QueryParam)
+		}
+		if key == "" {
+			jsonrpc.AbortWithError(g, errors.UNAUTHORIZED_API_KEY, "apikey: missing api key", id)
+			return
+		}
+		k, err := s.Get(key)
+		if err != nil {
+			_ = g.Error(err)
+			g.Abort()
+			return
+		} else if k == nil {
+			jsonrpc.AbortWithError(g, errors.UNAUTHORIZED_API_KEY, "apikey: invalid api key", id)
+			return
+		}
+
+		for _, m := range methods {
+			if !isAllowed(k, m) {
+				jsonrpc.AbortWithError(g, errors.UNAUTHORIZED_API_KEY, fmt.Sprintf("apikey: %s is not allowed", m), id)
+				return
+			}
+		}
+		if !q.take(k, len(methods), time.Now()) {
+			l.Info("api key quota exceeded", "name", k.Name)
+			jsonrpc.AbortWithError(
+				g,
+				errors.BANNED_OR_THROTTLED_ENTITY,
+				fmt.Sprintf("apikey: exceeded quota of %d requests per minute", k.RequestsPerMinute),
+				id,
+			)
+			return
+		}
+
+		g.Set(ginutils.ApiKeyContextKey, k.Name)
+		g.Next()
+	}
+}
@@ -0,0 +1,153 @@
+package apikey
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/ginutils"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+)
+
+var (
+	sendBody  = []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_sendUserOperation","params":[{},"0x"]}`)
+	batchBody = []byte(`[{"jsonrpc":"2.0","id":1,"method":"eth_chainId"},{"jsonrpc":"2.0","id":2,"method":"eth_chainId"}]`)
+)
+
+func newRouter(s *Store) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/", Middleware(s, logr.Discard()), func(g *gin.Context) {
+		name, _ := ginutils.GetApiKey(g)
+		g.JSON(http.StatusOK, gin.H{"name": name})
+	})
+	return r
+}
+
+func doRequest(t *testing.T, r *gin.Engine, path string, key string, body []byte) map[string]any {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	if key != "" {
+		req.Header.Set(Header, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var res map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func getErrorCode(t *testing.T, res map[string]any) int {
+	e, ok := res["error"].(map[string]any)
+	if !ok {
+		t.Fatalf("got %v, want error", res)
+	}
+	return int(e["code"].(float64))
+}
+
+// TestMiddlewareAcceptsConfigAndDBKeys verifies that keys from config and the DB are accepted from either the
+// header or query param and that the key name is set in the context.
+func TestMiddlewareAcceptsConfigAndDBKeys(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	s := New(db, map[string]*Key{"static": {Name: "alice"}})
+	if err := s.Put("dynamic", &Key{Name: "bob"}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	r := newRouter(s)
+
+	if res := doRequest(t, r, "/", "static", sendBody); res["name"] != "alice" {
+		t.Fatalf("got %v, want alice", res)
+	}
+	if res := doRequest(t, r, "/?"+QueryParam+"=dynamic", "", sendBody); res["name"] != "bob" {
+		t.Fatalf("got %v, want bob", res)
+	}
+}
+
+// TestMiddlewareRejectsUnknownKeys verifies that requests with a missing, invalid, or revoked key are rejected.
+func TestMiddlewareRejectsUnknownKeys(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	s := New(db, nil)
+	if err := s.Put("dynamic", &Key{Name: "bob"}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := s.Delete("dynamic"); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	r := newRouter(s)
+
+	for _, key := range []string{"", "invalid", "dynamic"} {
+		if code := getErrorCode(t, doRequest(t, r, "/", key, sendBody)); code != errors.UNAUTHORIZED_API_KEY {
+			t.Fatalf("key %q: got %d, want %d", key, code, errors.UNAUTHORIZED_API_KEY)
+		}
+	}
+}
+
+// TestMiddlewareEnforcesMethodsAndQuota verifies that a key is limited to its allowed methods and that each
+// call in a batch counts towards its quota.
+func TestMiddlewareEnforcesMethodsAndQuota(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	s := New(db, map[string]*Key{"static": {Name: "alice", RequestsPerMinute: 3, Methods: []string{"eth_chainId"}}})
+	r := newRouter(s)
+
+	if code := getErrorCode(t, doRequest(t, r, "/", "static", sendBody)); code != errors.UNAUTHORIZED_API_KEY {
+		t.Fatalf("got %d, want %d", code, errors.UNAUTHORIZED_API_KEY)
+	}
+	if res := doRequest(t, r, "/", "static", batchBody); res["name"] != "alice" {
+		t.Fatalf("got %v, want alice", res)
+	}
+	if code := getErrorCode(t, doRequest(t, r, "/", "static", batchBody)); code != errors.BANNED_OR_THROTTLED_ENTITY {
+		t.Fatalf("got %d, want %d", code, errors.BANNED_OR_THROTTLED_ENTITY)
+	}
+}
+
+// TestMiddlewareMatchesControllerMethod verifies that the allowed methods are checked against the method the
+// JSON-RPC controller will call, regardless of duplicate or case variant keys in the request.
+func TestMiddlewareMatchesControllerMethod(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	s := New(db, map[string]*Key{"static": {Name: "alice", Methods: []string{"eth_chainId"}}})
+	r := newRouter(s)
+
+	for _, body := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"eth_sendUserOperation","Method":"eth_chainId","params":[]}`,
+		`{"jsonrpc":"2.0","id":1,"method":"eth_chainId","method":"eth_sendUserOperation","params":[]}`,
+		`[{"jsonrpc":"2.0","id":1,"method":"eth_sendUserOperation","METHOD":"eth_chainId","params":[]}]`,
+	} {
+		res := doRequest(t, r, "/", "static", []byte(body))
+		if code := getErrorCode(t, res); code != errors.UNAUTHORIZED_API_KEY {
+			t.Fatalf("%s: got %d, want %d", body, code, errors.UNAUTHORIZED_API_KEY)
+		}
+	}
+
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"Eth_chainId","params":[]}`)
+	if res := doRequest(t, r, "/", "static", body); res["name"] != "alice" {
+		t.Fatalf("got %v, want alice", res)
+	}
+}
+
+// TestParseKeys verifies that keys from config are decoded and malformed entries are rejected.
+func TestParseKeys(t *testing.T) {
+	keys, err := ParseKeys([]string{"abc:alice:10", "def:bob:0"})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if keys["abc"].Name != "alice" || keys["abc"].RequestsPerMinute != 10 || keys["def"].Name != "bob" {
+		t.Fatalf("got %v, want parsed keys", keys)
+	}
+
+	for _, val := range []string{"abc:alice", "abc:alice:-1", ":alice:1"} {
+		if _, err := ParseKeys([]string{val}); err == nil {
+			t.Fatalf("%s: got nil, want err", val)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Package apikey implements optional API key authentication for the RPC server. Keys are loaded from config
+// or managed at runtime through the DB and each key can be limited to a set of methods and a request quota.
+package apikey
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/stackup-wallet/stackup-bundler/internal/dbutils"
+)
+
+var (
+	// KeyPrefix is the prefix for all keys stored in the DB by the apikey package.
+	KeyPrefix = "apikey"
+)
+
+// Key is the configuration of a single API key. A RequestsPerMinute of 0 means unlimited and an empty list of
+// Methods allows all methods.
+type Key struct {
+	Name              string   `json:"name"`
+	RequestsPerMinute int      `json:"requestsPerMinute"`
+	Methods           []string `json:"methods,omitempty"`
+}
+
+// ParseKeys decodes a list of API keys in the form "key:name:requestsPerMinute" into a map of keys to their
+// configuration.
+func ParseKeys(vals []string) (map[string]*Key, error) {
+	keys := make(map[string]*Key)
+	for i, val := range vals {
+		parts := strings.Split(strings.TrimSpace(val), ":")
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("apikey: entry %d must be in the form key:name:requestsPerMinute", i)
+		}
+		rpm, err := strconv.Atoi(parts[2])
+		if err != nil || rpm < 0 {
+			return nil, fmt.Errorf("apikey: %s has an invalid requestsPerMinute", parts[1])
+		}
+
+		keys[parts[0]] = &Key{Name: parts[1], RequestsPerMinute: rpm}
+	}
+	return keys, nil
+}
+
+// hashKey returns the hex encoded sha256 of an API key so that raw keys are never written to disk.
+func hashKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+func getDBKey(key string) []byte {
+	return []byte(dbutils.JoinValues(KeyPrefix, hashKey(key)))
+}
+
+// Store looks up the configuration for an API key. Keys from config take precedence over keys in the DB.
+type Store struct {
+	db     *badger.DB
+	static map[string]*Key
+}
+
+// New returns a Store for the given DB and keys loaded from config.
+func New(db *badger.DB, static map[string]*Key) *Store {
+	if static == nil {
+		static = make(map[string]*Key)
+	}
+	return &Store{db, static}
+}
+
+// Get returns the configuration for an API key or nil if it is not registered.
+func (s *Store) Get(key string) (*Key, error) {
+	if k, ok := s.static[key]; ok {
+		return k, nil
+	}
+
+	var k *Key
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(getDBKey(key))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			k = &Key{}
+			return json.Unmarshal(val, k)
+		})
+	})
+	return k, err
+}
+
+// Put registers an API key in the DB or replaces its configuration.
+func (s *Store) Put(key string, k *Key) error {
+	if key == "" || k.Name == "" {
+		return fmt.Errorf("apikey: key and name cannot be empty")
+	}
+	if k.RequestsPerMinute < 0 {
+		return fmt.Errorf("apikey: requestsPerMinute cannot be negative")
+	}
+	if _, ok := s.static[key]; ok {
+		return fmt.Errorf("apikey: %s is set from config", k.Name)
+	}
+
+	b, err := json.Marshal(k)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(getDBKey(key), b)
+	})
+}
+
+// Delete removes an API key from the DB. Keys from config cannot be removed at runtime.
+func (s *Store) Delete(key string) error {
+	if k, ok := s.static[key]; ok {
+		return fmt.Errorf("apikey: %s is set from config", k.Name)
+	}
+
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(getDBKey(key))
+	})
+}
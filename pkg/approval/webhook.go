@@ -0,0 +1,51 @@
+// Package approval implements external approval systems that can veto or delay signing of bundle
+// transactions.
+package approval
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
+)
+
+// Webhook sends a preview of each handleOps transaction to an HTTP endpoint for approval. The endpoint may
+// hold the request open to delay signing (e.g. while waiting on a second operator) up to the timeout.
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhook returns a Webhook for the given URL. Requests that take longer than timeout are treated as a veto.
+func NewWebhook(url string, timeout time.Duration) *Webhook {
+	return &Webhook{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Approve sends the preview as a JSON POST request. Any 2xx response approves signing. All other responses
+// veto signing with the response body as the reason.
+func (w *Webhook) Approve(p *transaction.Preview) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	res, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	reason, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Errorf("approval: status %d: %s", res.StatusCode, strings.TrimSpace(string(reason)))
+}
@@ -0,0 +1,62 @@
+package approval
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func newPreview() *transaction.Preview {
+	return &transaction.Preview{
+		From:       testutils.DummyEOA.Address,
+		EntryPoint: testutils.ValidAddress1,
+		UserOps:    []*userop.UserOperation{testutils.MockValidInitUserOp()},
+	}
+}
+
+func TestWebhookApproves(t *testing.T) {
+	var got map[string]any
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer s.Close()
+
+	if err := NewWebhook(s.URL, time.Second).Approve(newPreview()); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if ops, ok := got["userOps"].([]any); !ok || len(ops) != 1 {
+		t.Fatalf("got userOps %v, want 1 op", got["userOps"])
+	}
+}
+
+func TestWebhookVetoes(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("risk limit exceeded"))
+	}))
+	defer s.Close()
+
+	err := NewWebhook(s.URL, time.Second).Approve(newPreview())
+	if err == nil || err.Error() != "approval: status 403: risk limit exceeded" {
+		t.Fatalf("got %v, want veto", err)
+	}
+}
+
+func TestWebhookTimeoutVetoes(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer s.Close()
+
+	if err := NewWebhook(s.URL, 10*time.Millisecond).Approve(newPreview()); err == nil {
+		t.Fatal("got nil, want timeout error")
+	}
+}
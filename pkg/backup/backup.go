@@ -0,0 +1,96 @@
+// Package backup implements scheduled online backups of the bundler's Badger DB to a local directory or an
+// S3-compatible object store. Backups are full snapshots taken with Badger's backup stream so that the DB can
+// stay open while a backup is running.
+package backup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v3"
+)
+
+const (
+	// LatestName is the name of the object that points to the most recent backup.
+	LatestName = "latest"
+
+	maxPendingWrites = 256
+)
+
+// Store is a destination that backups can be written to and read from.
+type Store interface {
+	// Put writes size bytes from r to the named object.
+	Put(name string, r io.ReadSeeker, size int64) error
+
+	// Get returns a reader for the named object. The caller must close the reader.
+	Get(name string) (io.ReadCloser, error)
+}
+
+// Name returns the object name for a backup taken at the given time.
+func Name(t time.Time) string {
+	return fmt.Sprintf("badger-%d.bak", t.Unix())
+}
+
+// Run takes a full backup of the DB and writes it to the store. The latest pointer is only updated once the
+// backup has been written so that a failed upload never replaces a good backup. Returns the name of the
+// backup.
+func Run(db *badger.DB, store Store) (string, error) {
+	f, err := os.CreateTemp("", "badger-backup-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if _, err := db.Backup(f, 0); err != nil {
+		return "", err
+	}
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	name := Name(time.Now())
+	if err := store.Put(name, f, size); err != nil {
+		return "", err
+	}
+	if err := store.Put(LatestName, strings.NewReader(name), int64(len(name))); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// Restore loads the named backup from the store into the DB. If name is LatestName, the most recent backup is
+// used. The DB should not be in use by a running bundler.
+func Restore(db *badger.DB, store Store, name string) (string, error) {
+	if name == LatestName {
+		r, err := store.Get(LatestName)
+		if err != nil {
+			return "", err
+		}
+		defer r.Close()
+
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return "", err
+		}
+		name = strings.TrimSpace(string(b))
+	}
+
+	r, err := store.Get(name)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	if err := db.Load(r, maxPendingWrites); err != nil {
+		return "", err
+	}
+	return name, nil
+}
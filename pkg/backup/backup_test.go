@@ -0,0 +1,122 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	badger "github.com/dgraph-io/badger/v3"
+)
+
+func openDB(t *testing.T) *badger.DB {
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLoggingLevel(badger.ERROR))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func assertRoundTrip(t *testing.T, store Store) {
+	src := openDB(t)
+	if err := src.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte("entity:0x01"), []byte("value"))
+	}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	name, err := Run(src, store)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	dst := openDB(t)
+	if restored, err := Restore(dst, store, LatestName); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if restored != name {
+		t.Fatalf("got %s, want %s", restored, name)
+	}
+
+	if err := dst.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte("entity:0x01"))
+		if err != nil {
+			return err
+		}
+		v, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		if string(v) != "value" {
+			t.Fatalf("got %s, want value", v)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
+
+// TestLocalStoreRoundTrip verifies that a backup written to a local directory can be restored into an empty
+// DB.
+func TestLocalStoreRoundTrip(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	assertRoundTrip(t, store)
+}
+
+// TestS3StoreRoundTrip verifies that a backup uploaded to an S3-compatible endpoint is signed and can be
+// restored into an empty DB.
+func TestS3StoreRoundTrip(t *testing.T) {
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			b, _ := io.ReadAll(r.Body)
+			hash := sha256.Sum256(b)
+			if r.Header.Get("x-amz-content-sha256") != hex.EncodeToString(hash[:]) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			objects[r.URL.Path] = b
+		case http.MethodGet:
+			b, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(b)
+		}
+	}))
+	defer srv.Close()
+
+	store, err := NewS3Store(S3Opts{
+		Endpoint:  srv.URL,
+		Region:    "us-east-1",
+		Bucket:    "bundler",
+		Prefix:    "backups/",
+		AccessKey: "key",
+		SecretKey: "secret",
+	})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	assertRoundTrip(t, store)
+
+	if _, ok := objects["/bundler/backups/"+LatestName]; !ok {
+		t.Fatalf("got %v, want latest pointer under prefix", objects)
+	}
+}
@@ -0,0 +1,47 @@
+package backup
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// LocalStore writes backups to a directory on the local filesystem, such as a mounted network volume.
+type LocalStore struct {
+	dir string
+}
+
+// NewLocalStore returns a Store for the given directory. The directory is created if it does not exist.
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStore{dir}, nil
+}
+
+// Put writes the object to a temporary file and renames it so that readers never see a partial backup.
+func (s *LocalStore) Put(name string, r io.ReadSeeker, size int64) error {
+	f, err := os.CreateTemp(s.dir, name+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.CopyN(f, r, size); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(s.dir, name))
+}
+
+// Get opens the object for reading.
+func (s *LocalStore) Get(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(s.dir, name))
+}
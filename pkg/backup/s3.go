@@ -0,0 +1,165 @@
+package backup
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	amzDateFormat = "20060102T150405Z"
+	amzDayFormat  = "20060102"
+)
+
+// S3Opts are the connection details for an S3-compatible object store.
+type S3Opts struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	Prefix    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3Store writes backups to an S3-compatible object store using path-style requests signed with AWS
+// Signature Version 4.
+type S3Store struct {
+	opts   S3Opts
+	client *http.Client
+}
+
+// NewS3Store returns a Store for the given bucket and key prefix.
+func NewS3Store(opts S3Opts) (*S3Store, error) {
+	if _, err := url.ParseRequestURI(opts.Endpoint); err != nil {
+		return nil, err
+	}
+	if opts.Bucket == "" {
+		return nil, fmt.Errorf("backup: s3 bucket not set")
+	}
+	return &S3Store{opts, &http.Client{}}, nil
+}
+
+func (s *S3Store) objectUrl(name string) string {
+	segments := []string{s.opts.Bucket}
+	for _, seg := range strings.Split(strings.Trim(s.opts.Prefix, "/"), "/") {
+		if seg != "" {
+			segments = append(segments, seg)
+		}
+	}
+	segments = append(segments, name)
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return strings.TrimSuffix(s.opts.Endpoint, "/") + "/" + strings.Join(segments, "/")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// sign adds AWS Signature Version 4 headers to a request with the given hex encoded payload hash.
+func (s *S3Store) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format(amzDateFormat)
+	day := now.UTC().Format(amzDayFormat)
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonical))
+
+	scope := strings.Join([]string{day, s.opts.Region, "s3", "aws4_request"}, "/")
+	toSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.opts.SecretKey), day)
+	key = hmacSHA256(key, s.opts.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	sig := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set(
+		"Authorization",
+		fmt.Sprintf(
+			"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+			s.opts.AccessKey,
+			scope,
+			signedHeaders,
+			sig,
+		),
+	)
+}
+
+// Put uploads the object in a single request. The payload is read twice: once to compute its hash for the
+// signature and once to upload.
+func (s *S3Store) Put(name string, r io.ReadSeeker, size int64) error {
+	h := sha256.New()
+	if _, err := io.CopyN(h, r, size); err != nil {
+		return err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, s.objectUrl(name), io.LimitReader(r, size))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	s.sign(req, hex.EncodeToString(h.Sum(nil)), time.Now())
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("backup: s3 put %s failed with status %d: %s", name, res.StatusCode, b)
+	}
+	return nil
+}
+
+// Get downloads the object.
+func (s *S3Store) Get(name string) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, s.objectUrl(name), nil)
+	if err != nil {
+		return nil, err
+	}
+	emptyHash := sha256.Sum256(nil)
+	s.sign(req, hex.EncodeToString(emptyHash[:]), time.Now())
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		b, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return nil, fmt.Errorf("backup: s3 get %s failed with status %d: %s", name, res.StatusCode, b)
+	}
+	return res.Body, nil
+}
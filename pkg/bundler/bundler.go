@@ -3,12 +3,15 @@ package bundler
 
 import (
 	"context"
+	"errors"
 	"math/big"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/go-logr/logr"
 	"github.com/stackup-wallet/stackup-bundler/internal/logger"
+	"github.com/stackup-wallet/stackup-bundler/pkg/clock"
 	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/gasprice"
@@ -18,15 +21,31 @@ import (
 	"go.opentelemetry.io/otel/metric"
 )
 
+// ErrOpNotFound is returned by ProcessOp if the UserOperation is not pending in the mempool.
+var ErrOpNotFound = errors.New("bundler: userOp not found in mempool")
+
+// ErrLeaseNotHeld is returned by ProcessOp if another bundler process holds the lease on a shared mempool.
+var ErrLeaseNotHeld = errors.New("bundler: mempool lease is held by another process")
+
+// ClaimFunc acquires or renews the lease to bundle ops from a mempool that is shared with other bundler
+// processes. It returns false if another process holds the lease.
+type ClaimFunc = func() (bool, error)
+
 // Bundler controls the end to end process of creating a batch of UserOperations from the mempool and sending
-// it to the EntryPoint.
+// it to the EntryPoint. Only one batch is processed at a time so that forced and scheduled runs don't race on
+// the relayer nonce or send the same UserOperation twice.
 type Bundler struct {
+	mu                   sync.Mutex
 	mempool              *mempool.Mempool
 	chainID              *big.Int
 	supportedEntryPoints []common.Address
 	batchHandler         modules.BatchHandlerFunc
+	moduleNames          []string
 	logger               logr.Logger
 	meter                metric.Meter
+	bundlesSent          metric.Int64Counter
+	runErrors            metric.Int64Counter
+	inclusionLatency     metric.Int64Histogram
 	isRunning            bool
 	done                 chan bool
 	stop                 func()
@@ -34,6 +53,10 @@ type Bundler struct {
 	gbf                  gasprice.GetBaseFeeFunc
 	ggt                  gasprice.GetGasTipFunc
 	ggp                  gasprice.GetLegacyGasPriceFunc
+	clock                clock.Clock
+	newHeads             <-chan struct{}
+	gtr                  GetTxReceiptFunc
+	claim                ClaimFunc
 }
 
 // New initializes a new EIP-4337 bundler which can be extended with modules for validating batches and
@@ -44,6 +67,7 @@ func New(mempool *mempool.Mempool, chainID *big.Int, supportedEntryPoints []comm
 		chainID:              chainID,
 		supportedEntryPoints: supportedEntryPoints,
 		batchHandler:         noop.BatchHandler,
+		moduleNames:          []string{},
 		logger:               logger.NewZeroLogr().WithName("bundler"),
 		meter:                otel.GetMeterProvider().Meter("bundler"),
 		isRunning:            false,
@@ -53,6 +77,8 @@ func New(mempool *mempool.Mempool, chainID *big.Int, supportedEntryPoints []comm
 		gbf:                  gasprice.NoopGetBaseFeeFunc(),
 		ggt:                  gasprice.NoopGetGasTipFunc(),
 		ggp:                  gasprice.NoopGetLegacyGasPriceFunc(),
+		clock:                clock.Real(),
+		claim:                func() (bool, error) { return true, nil },
 	}
 }
 
@@ -77,6 +103,19 @@ func (i *Bundler) SetGetLegacyGasPriceFunc(ggp gasprice.GetLegacyGasPriceFunc) {
 	i.ggp = ggp
 }
 
+// SetClock defines the clock used to schedule bundler runs and measure their duration. This allows embedders
+// and tests to control time deterministically. The default value is clock.Real().
+func (i *Bundler) SetClock(c clock.Clock) {
+	i.clock = c
+}
+
+// SetClaimFunc defines the function used to claim the lease on a shared mempool before each bundler run. A
+// run is skipped if the lease is held by another process so that the same ops are not bundled twice. The
+// default always claims the lease.
+func (i *Bundler) SetClaimFunc(claim ClaimFunc) {
+	i.claim = claim
+}
+
 // UseLogger defines the logger object used by the Bundler instance based on the go-logr/logr interface.
 func (i *Bundler) UseLogger(logger logr.Logger) {
 	i.logger = logger.WithName("bundler")
@@ -101,39 +140,53 @@ func (i *Bundler) UserMeter(meter metric.Meter) error {
 			return nil
 		}),
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if err := i.registerRunMetrics(); err != nil {
+		return err
+	}
+
+	return i.registerMempoolCompositionMetrics()
 }
 
 // UseModules defines the BatchHandlers to process batches after it has gone through the standard checks.
 func (i *Bundler) UseModules(handlers ...modules.BatchHandlerFunc) {
 	i.batchHandler = modules.ComposeBatchHandlerFunc(handlers...)
+	i.moduleNames = []string{}
+	for _, h := range handlers {
+		i.moduleNames = append(i.moduleNames, modules.HandlerName(h))
+	}
 }
 
-// Process will create a batch from the mempool and send it through to the EntryPoint.
-func (i *Bundler) Process(ep common.Address) (*modules.BatchHandlerCtx, error) {
-	// Init logger
-	start := time.Now()
-	l := i.logger.
-		WithName("run").
-		WithValues("entrypoint", ep.String()).
-		WithValues("chain_id", i.chainID.String())
+// ModuleNames returns the names of all BatchHandlers used by the Bundler in order of execution.
+func (i *Bundler) ModuleNames() []string {
+	return append([]string{}, i.moduleNames...)
+}
 
+// NewContext creates a BatchHandlerCtx with pending UserOperations from the mempool and the current gas
+// prices. A nil context is returned if there are no pending UserOperations.
+func (i *Bundler) NewContext(ep common.Address) (*modules.BatchHandlerCtx, error) {
 	// Get all pending userOps from the mempool. This will be in FIFO order. Downstream modules should sort it
 	// based on more specific strategies.
 	batch, err := i.mempool.Dump(ep)
 	if err != nil {
-		l.Error(err, "bundler run error")
 		return nil, err
 	}
 	if len(batch) == 0 {
 		return nil, nil
 	}
-	batch = adjustBatchSize(i.maxBatch, batch)
+	return i.newContextWithBatch(ep, adjustBatchSize(i.maxBatch, batch))
+}
 
+// newContextWithBatch creates a BatchHandlerCtx for the given UserOperations and the current gas prices.
+func (i *Bundler) newContextWithBatch(
+	ep common.Address,
+	batch []*userop.UserOperation,
+) (*modules.BatchHandlerCtx, error) {
 	// Get current block basefee
 	bf, err := i.gbf()
 	if err != nil {
-		l.Error(err, "bundler run error")
 		return nil, err
 	}
 
@@ -142,24 +195,114 @@ func (i *Bundler) Process(ep common.Address) (*modules.BatchHandlerCtx, error) {
 	if bf != nil {
 		gt, err = i.ggt()
 		if err != nil {
-			l.Error(err, "bundler run error")
 			return nil, err
 		}
 	}
 
 	// Get suggested gas price (for networks that don't support EIP-1559)
 	gp, err := i.ggp()
+	if err != nil {
+		return nil, err
+	}
+
+	return modules.NewBatchHandlerContext(batch, ep, i.chainID, bf, gt, gp), nil
+}
+
+// Process will create a batch from the mempool and send it through to the EntryPoint.
+func (i *Bundler) Process(ep common.Address) (*modules.BatchHandlerCtx, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	// Init logger
+	start := i.clock.Now()
+	l := i.logger.
+		WithName("run").
+		WithValues("entrypoint", ep.String()).
+		WithValues("chain_id", i.chainID.String())
+
+	ctx, err := i.NewContext(ep)
+	if err != nil {
+		l.Error(err, "bundler run error")
+		i.recordRunError(ep)
+		return nil, err
+	} else if ctx == nil {
+		return nil, nil
+	}
+
+	return i.process(ctx, start, l)
+}
+
+// ProcessOp will create a batch with only the pending UserOperation matching the given hash and send it
+// through to the EntryPoint. Unlike Process, the batch is built immediately regardless of the bundling
+// interval or any other pending UserOperations. ErrOpNotFound is returned if the hash is not in the mempool
+// for any supported EntryPoint and ErrLeaseNotHeld is returned if this process can't claim the lease on the
+// mempool.
+func (i *Bundler) ProcessOp(hash common.Hash) (*modules.BatchHandlerCtx, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	ep, op, err := i.findOp(hash)
+	if err != nil {
+		return nil, err
+	}
+	return i.processOp(ep, op)
+}
+
+// findOp claims the lease on the mempool and returns the pending UserOperation matching the given hash and
+// its EntryPoint.
+func (i *Bundler) findOp(hash common.Hash) (common.Address, *userop.UserOperation, error) {
+	if ok, err := i.claim(); err != nil {
+		return common.Address{}, nil, err
+	} else if !ok {
+		return common.Address{}, nil, ErrLeaseNotHeld
+	}
+
+	for _, ep := range i.supportedEntryPoints {
+		ops, err := i.mempool.Dump(ep)
+		if err != nil {
+			return common.Address{}, nil, err
+		}
+		for _, op := range ops {
+			if op.GetUserOpHash(ep, i.chainID) == hash {
+				return ep, op, nil
+			}
+		}
+	}
+	return common.Address{}, nil, ErrOpNotFound
+}
+
+// processOp sends a batch with only the given UserOperation through to the EntryPoint.
+func (i *Bundler) processOp(ep common.Address, op *userop.UserOperation) (*modules.BatchHandlerCtx, error) {
+	start := i.clock.Now()
+	l := i.logger.
+		WithName("run").
+		WithValues("entrypoint", ep.String()).
+		WithValues("chain_id", i.chainID.String()).
+		WithValues("targeted_userop_hash", op.GetUserOpHash(ep, i.chainID).String())
+	ctx, err := i.newContextWithBatch(ep, []*userop.UserOperation{op})
 	if err != nil {
 		l.Error(err, "bundler run error")
+		i.recordRunError(ep)
 		return nil, err
 	}
+	return i.process(ctx, start, l)
+}
+
+// process executes all modules on the batch and removes its UserOperations from the mempool.
+func (i *Bundler) process(
+	ctx *modules.BatchHandlerCtx,
+	start time.Time,
+	l logr.Logger,
+) (*modules.BatchHandlerCtx, error) {
+	ep := ctx.EntryPoint
 
-	// Create context and execute modules.
-	ctx := modules.NewBatchHandlerContext(batch, ep, i.chainID, bf, gt, gp)
+	// Execute modules.
 	if err := i.batchHandler(ctx); err != nil {
 		l.Error(err, "bundler run error")
+		i.recordRunError(ep)
 		return nil, err
 	}
+	i.recordBundleSent(ep, ctx.Batch)
 
 	// Remove userOps that remain in the context from mempool.
 	rmOps := append([]*userop.UserOperation{}, ctx.Batch...)
@@ -172,6 +315,7 @@ func (i *Bundler) Process(ep common.Address) (*modules.BatchHandlerCtx, error) {
 	}
 	if err := i.mempool.RemoveOps(ep, rmOps...); err != nil {
 		l.Error(err, "bundler run error")
+		i.recordRunError(ep)
 		return nil, err
 	}
 
@@ -187,31 +331,51 @@ func (i *Bundler) Process(ep common.Address) (*modules.BatchHandlerCtx, error) {
 	for k, v := range ctx.Data {
 		l = l.WithValues(k, v)
 	}
-	l = l.WithValues("duration", time.Since(start))
+	l = l.WithValues("duration", i.clock.Since(start))
 	l.Info("bundler run ok")
 	return ctx, nil
 }
 
+// SetNewHeads sets a channel that signals a new block. Each signal triggers a run immediately instead of
+// waiting for the next interval.
+func (i *Bundler) SetNewHeads(ch <-chan struct{}) {
+	i.newHeads = ch
+}
+
+// processAll runs Process for each supported EntryPoint if the lease on the mempool is claimed.
+func (i *Bundler) processAll() {
+	if ok, err := i.claim(); err != nil {
+		i.logger.WithName("run").Error(err, "bundler lease error")
+		return
+	} else if !ok {
+		return
+	}
+
+	for _, ep := range i.supportedEntryPoints {
+		_, err := i.Process(ep)
+		if err != nil {
+			// Already logged.
+			continue
+		}
+	}
+}
+
 // Run starts a goroutine that will continuously process batches from the mempool.
 func (i *Bundler) Run() error {
 	if i.isRunning {
 		return nil
 	}
 
-	ticker := time.NewTicker(1 * time.Second)
+	ticker := i.clock.NewTicker(1 * time.Second)
 	go func(i *Bundler) {
 		for {
 			select {
 			case <-i.done:
 				return
-			case <-ticker.C:
-				for _, ep := range i.supportedEntryPoints {
-					_, err := i.Process(ep)
-					if err != nil {
-						// Already logged.
-						continue
-					}
-				}
+			case <-ticker.C():
+				i.processAll()
+			case <-i.newHeads:
+				i.processAll()
 			}
 		}
 	}(i)
@@ -0,0 +1,257 @@
+package bundler
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// TestProcessOpSingleBatch verifies that only the targeted op is sent and removed from the mempool.
+func TestProcessOpSingleBatch(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := mempool.New(db)
+
+	op1 := testutils.MockValidInitUserOp()
+	op2 := testutils.MockValidInitUserOp()
+	op2.Sender = testutils.ValidAddress2
+	if err := mem.AddOp(testutils.ValidAddress1, op1); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := mem.AddOp(testutils.ValidAddress1, op2); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	b := New(mem, testutils.ChainID, []common.Address{testutils.ValidAddress1})
+	b.SetGetBaseFeeFunc(testutils.GetMockBaseFeeFunc(big.NewInt(1)))
+	sent := 0
+	b.UseModules(func(ctx *modules.BatchHandlerCtx) error {
+		sent = len(ctx.Batch)
+		ctx.Data["txn_hash"] = "0x01"
+		return nil
+	})
+
+	ctx, err := b.ProcessOp(op2.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if sent != 1 || ctx.Batch[0].Sender != op2.Sender {
+		t.Fatalf("got batch of %d, want only the targeted op", sent)
+	}
+	pending, err := mem.Dump(testutils.ValidAddress1)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(pending) != 1 || pending[0].Sender != op1.Sender {
+		t.Fatalf("got %d pending ops, want only the untargeted op", len(pending))
+	}
+}
+
+// TestProcessOpNotFound verifies that an unknown hash returns ErrOpNotFound.
+func TestProcessOpNotFound(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := mempool.New(db)
+
+	b := New(mem, testutils.ChainID, []common.Address{testutils.ValidAddress1})
+	if _, err := b.ProcessOp(common.HexToHash("0x01")); err != ErrOpNotFound {
+		t.Fatalf("got %v, want %v", err, ErrOpNotFound)
+	}
+}
+
+// TestProcessOpRequiresLease verifies that a forced bundle is rejected while another process holds the lease
+// on the mempool.
+func TestProcessOpRequiresLease(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := mempool.New(db)
+	op := testutils.MockValidInitUserOp()
+	if err := mem.AddOp(testutils.ValidAddress1, op); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	b := New(mem, testutils.ChainID, []common.Address{testutils.ValidAddress1})
+	b.SetGetBaseFeeFunc(testutils.GetMockBaseFeeFunc(big.NewInt(1)))
+	sent := 0
+	b.UseModules(func(ctx *modules.BatchHandlerCtx) error {
+		sent += len(ctx.Batch)
+		return nil
+	})
+	b.SetClaimFunc(func() (bool, error) { return false, nil })
+
+	hash := op.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID)
+	if _, err := b.ProcessOp(hash); err != ErrLeaseNotHeld {
+		t.Fatalf("got %v, want %v", err, ErrLeaseNotHeld)
+	}
+	if _, err := b.ProcessOpWithReceipt(hash); err != ErrLeaseNotHeld {
+		t.Fatalf("got %v, want %v", err, ErrLeaseNotHeld)
+	}
+	if sent != 0 {
+		t.Fatalf("got %d sent ops, want 0", sent)
+	}
+}
+
+// TestProcessWithReceipt verifies that the receipt reports included, dropped, and deferred ops along with the
+// estimated and actual gas of the bundle.
+func TestProcessWithReceipt(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := mempool.New(db)
+
+	op1 := testutils.MockValidInitUserOp()
+	op2 := testutils.MockValidInitUserOp()
+	op2.Sender = testutils.ValidAddress2
+	op3 := testutils.MockValidInitUserOp()
+	op3.Sender = testutils.ValidAddress3
+	for _, op := range []*userop.UserOperation{op1, op2, op3} {
+		if err := mem.AddOp(testutils.ValidAddress1, op); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+
+	b := New(mem, testutils.ChainID, []common.Address{testutils.ValidAddress1})
+	b.SetGetBaseFeeFunc(testutils.GetMockBaseFeeFunc(big.NewInt(1)))
+	b.SetGetTxReceiptFunc(func(hash common.Hash) (*types.Receipt, error) {
+		return &types.Receipt{Status: types.ReceiptStatusSuccessful, GasUsed: 80000}, nil
+	})
+	b.UseModules(func(ctx *modules.BatchHandlerCtx) error {
+		batch := []*userop.UserOperation{}
+		for _, op := range ctx.Batch {
+			if op.Sender != op3.Sender {
+				batch = append(batch, op)
+			}
+		}
+		ctx.Batch = batch
+		for i, op := range ctx.Batch {
+			if op.Sender == op2.Sender {
+				ctx.MarkOpIndexForRemoval(i, "bad op")
+			}
+		}
+		ctx.Data["txn_hash"] = common.HexToHash("0x01").String()
+		ctx.Data["estimated_gas"] = uint64(100000)
+		return nil
+	})
+
+	rec, err := b.ProcessWithReceipt(testutils.ValidAddress1)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	hash := func(op *userop.UserOperation) common.Hash {
+		return op.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID)
+	}
+	if len(rec.SelectedUserOpHashes) != 1 || rec.SelectedUserOpHashes[0] != hash(op1) {
+		t.Fatalf("got %v, want only op1 selected", rec.SelectedUserOpHashes)
+	}
+	if len(rec.ExcludedUserOps) != 2 {
+		t.Fatalf("got %d excluded ops, want 2", len(rec.ExcludedUserOps))
+	}
+	if ex := rec.ExcludedUserOps[0]; ex.UserOpHash != hash(op2) || !ex.Dropped || ex.Reason != "bad op" {
+		t.Fatalf("got %+v, want op2 dropped", ex)
+	}
+	if ex := rec.ExcludedUserOps[1]; ex.UserOpHash != hash(op3) || ex.Dropped || ex.Reason != ReasonDeferred {
+		t.Fatalf("got %+v, want op3 deferred", ex)
+	}
+	if rec.EstimatedGas == nil || *rec.EstimatedGas != 100000 {
+		t.Fatalf("got estimated gas %v, want 100000", rec.EstimatedGas)
+	}
+	if rec.ActualGasUsed == nil || *rec.ActualGasUsed != 80000 {
+		t.Fatalf("got actual gas %v, want 80000", rec.ActualGasUsed)
+	}
+}
+
+// TestProcessAllRequiresLease verifies that no bundles are sent while another process holds the lease on the
+// mempool and that bundling resumes once the lease is claimed.
+func TestProcessAllRequiresLease(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := mempool.New(db)
+	if err := mem.AddOp(testutils.ValidAddress1, testutils.MockValidInitUserOp()); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	b := New(mem, testutils.ChainID, []common.Address{testutils.ValidAddress1})
+	b.SetGetBaseFeeFunc(testutils.GetMockBaseFeeFunc(big.NewInt(1)))
+	sent := 0
+	b.UseModules(func(ctx *modules.BatchHandlerCtx) error {
+		sent += len(ctx.Batch)
+		return nil
+	})
+	leased := false
+	b.SetClaimFunc(func() (bool, error) { return leased, nil })
+
+	b.processAll()
+	if sent != 0 {
+		t.Fatalf("got %d sent ops, want 0", sent)
+	}
+
+	leased = true
+	b.processAll()
+	if sent != 1 {
+		t.Fatalf("got %d sent ops, want 1", sent)
+	}
+}
+
+// TestProcessOpDuringRun verifies that a forced ProcessOp and a scheduled Process never run the modules at the
+// same time and that a pending op is only sent once.
+func TestProcessOpDuringRun(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := mempool.New(db)
+	ep := testutils.ValidAddress1
+	op := testutils.MockValidInitUserOp()
+	if err := mem.AddOp(ep, op); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	b := New(mem, testutils.ChainID, []common.Address{ep})
+	b.SetGetBaseFeeFunc(testutils.GetMockBaseFeeFunc(big.NewInt(1)))
+	var mu sync.Mutex
+	inFlight, maxInFlight, sent := 0, 0, 0
+	b.UseModules(func(ctx *modules.BatchHandlerCtx) error {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		sent += len(ctx.Batch)
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if _, err := b.Process(ep); err != nil {
+			t.Errorf("got %v, want nil", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		_, err := b.ProcessOp(op.GetUserOpHash(ep, testutils.ChainID))
+		if err != nil && err != ErrOpNotFound {
+			t.Errorf("got %v, want nil or %v", err, ErrOpNotFound)
+		}
+	}()
+	wg.Wait()
+
+	if maxInFlight != 1 {
+		t.Fatalf("got %d concurrent runs, want 1", maxInFlight)
+	}
+	if sent != 1 {
+		t.Fatalf("got %d sent ops, want 1", sent)
+	}
+}
@@ -0,0 +1,210 @@
+package bundler
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type ageBucket struct {
+	label string
+	max   time.Duration
+}
+
+// ageBuckets are the upper bounds used to group pending UserOperations by how long they have been in the
+// mempool. The last bucket has no upper bound.
+var ageBuckets = []ageBucket{
+	{"<1m", time.Minute},
+	{"1m-5m", 5 * time.Minute},
+	{"5m-15m", 15 * time.Minute},
+	{">15m", 0},
+}
+
+// feePercentiles are the percentiles of MaxPriorityFeePerGas reported for pending UserOperations.
+var feePercentiles = []struct {
+	label string
+	p     int
+}{
+	{"p10", 10},
+	{"p50", 50},
+	{"p90", 90},
+}
+
+type bucketStats struct {
+	count int64
+	gas   *big.Int
+}
+
+type mempoolStats struct {
+	byAge map[string]*bucketStats
+	fees  map[string]*big.Int
+}
+
+func getAgeBucket(age time.Duration) string {
+	for _, b := range ageBuckets {
+		if b.max == 0 || age < b.max {
+			return b.label
+		}
+	}
+	return ageBuckets[len(ageBuckets)-1].label
+}
+
+// getPercentile returns the nearest-rank percentile p of a sorted list of values.
+func getPercentile(sorted []*big.Int, p int) *big.Int {
+	if len(sorted) == 0 {
+		return big.NewInt(0)
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// getMempoolStats groups a batch of pending UserOperations by age bucket and computes the percentiles of
+// their MaxPriorityFeePerGas. The gas of each op is its max gas available.
+func getMempoolStats(
+	batch []*userop.UserOperation,
+	addedAt func(op *userop.UserOperation) time.Time,
+	now time.Time,
+) *mempoolStats {
+	stats := &mempoolStats{
+		byAge: make(map[string]*bucketStats),
+		fees:  make(map[string]*big.Int),
+	}
+	for _, b := range ageBuckets {
+		stats.byAge[b.label] = &bucketStats{gas: big.NewInt(0)}
+	}
+
+	tips := []*big.Int{}
+	for _, op := range batch {
+		bs := stats.byAge[getAgeBucket(now.Sub(addedAt(op)))]
+		bs.count++
+		bs.gas = big.NewInt(0).Add(bs.gas, op.GetMaxGasAvailable())
+		tips = append(tips, op.MaxPriorityFeePerGas)
+	}
+
+	sort.Slice(tips, func(i, j int) bool { return tips[i].Cmp(tips[j]) < 0 })
+	for _, fp := range feePercentiles {
+		stats.fees[fp.label] = getPercentile(tips, fp.p)
+	}
+	return stats
+}
+
+func (i *Bundler) getMempoolStatsByEntryPoint() (map[common.Address]*mempoolStats, error) {
+	now := i.clock.Now()
+	all := make(map[common.Address]*mempoolStats)
+	for _, ep := range i.supportedEntryPoints {
+		batch, err := i.mempool.Dump(ep)
+		if err != nil {
+			return nil, err
+		}
+		all[ep] = getMempoolStats(batch, func(op *userop.UserOperation) time.Time {
+			return i.mempool.AddedAt(ep, op)
+		}, now)
+	}
+	return all, nil
+}
+
+// registerMempoolCompositionMetrics adds gauges for the number and gas of pending UserOperations by age
+// bucket and for percentiles of their MaxPriorityFeePerGas. All gauges are split by EntryPoint.
+func (i *Bundler) registerMempoolCompositionMetrics() error {
+	opsByAge, err := i.meter.Int64ObservableGauge("bundler_mempool_ops_by_age")
+	if err != nil {
+		return err
+	}
+	gasByAge, err := i.meter.Int64ObservableGauge("bundler_mempool_gas_by_age")
+	if err != nil {
+		return err
+	}
+	priorityFee, err := i.meter.Int64ObservableGauge("bundler_mempool_max_priority_fee_per_gas")
+	if err != nil {
+		return err
+	}
+
+	_, err = i.meter.RegisterCallback(
+		func(ctx context.Context, o metric.Observer) error {
+			all, err := i.getMempoolStatsByEntryPoint()
+			if err != nil {
+				return err
+			}
+
+			for ep, stats := range all {
+				epAttr := attribute.String("entrypoint", ep.String())
+				for _, b := range ageBuckets {
+					bs := stats.byAge[b.label]
+					attrs := metric.WithAttributes(epAttr, attribute.String("age", b.label))
+					o.ObserveInt64(opsByAge, bs.count, attrs)
+					o.ObserveInt64(gasByAge, bs.gas.Int64(), attrs)
+				}
+				for _, fp := range feePercentiles {
+					o.ObserveInt64(
+						priorityFee,
+						stats.fees[fp.label].Int64(),
+						metric.WithAttributes(epAttr, attribute.String("percentile", fp.label)),
+					)
+				}
+			}
+			return nil
+		},
+		opsByAge,
+		gasByAge,
+		priorityFee,
+	)
+	return err
+}
+
+// registerRunMetrics adds instruments for the outcome of each bundler run. All instruments are split by
+// EntryPoint.
+func (i *Bundler) registerRunMetrics() error {
+	bundlesSent, err := i.meter.Int64Counter("bundler_bundles_sent")
+	if err != nil {
+		return err
+	}
+	runErrors, err := i.meter.Int64Counter("bundler_run_errors")
+	if err != nil {
+		return err
+	}
+	inclusionLatency, err := i.meter.Int64Histogram(
+		"bundler_op_inclusion_latency",
+		metric.WithDescription("Time from an op entering the mempool to being sent in a bundle"),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		return err
+	}
+
+	i.bundlesSent = bundlesSent
+	i.runErrors = runErrors
+	i.inclusionLatency = inclusionLatency
+	return nil
+}
+
+func (i *Bundler) recordRunError(ep common.Address) {
+	if i.runErrors == nil {
+		return
+	}
+	i.runErrors.Add(context.Background(), 1, metric.WithAttributes(attribute.String("entrypoint", ep.String())))
+}
+
+// recordBundleSent counts a bundle and records the inclusion latency of each op in it. It must be called
+// before the ops are removed from the mempool.
+func (i *Bundler) recordBundleSent(ep common.Address, batch []*userop.UserOperation) {
+	if i.bundlesSent == nil || len(batch) == 0 {
+		return
+	}
+
+	attrs := metric.WithAttributes(attribute.String("entrypoint", ep.String()))
+	i.bundlesSent.Add(context.Background(), 1, attrs)
+	for _, op := range batch {
+		if addedAt := i.mempool.AddedAt(ep, op); !addedAt.IsZero() {
+			i.inclusionLatency.Record(context.Background(), i.clock.Since(addedAt).Milliseconds(), attrs)
+		}
+	}
+}
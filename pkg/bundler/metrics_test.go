@@ -0,0 +1,60 @@
+package bundler
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func TestGetMempoolStatsByAgeAndFee(t *testing.T) {
+	now := time.Now()
+	ages := map[*userop.UserOperation]time.Duration{}
+	batch := []*userop.UserOperation{}
+	for i, age := range []time.Duration{
+		10 * time.Second,
+		2 * time.Minute,
+		3 * time.Minute,
+		10 * time.Minute,
+		time.Hour,
+	} {
+		op := testutils.MockValidInitUserOp()
+		op.MaxPriorityFeePerGas = big.NewInt(int64(i + 1))
+		ages[op] = age
+		batch = append(batch, op)
+	}
+
+	stats := getMempoolStats(batch, func(op *userop.UserOperation) time.Time {
+		return now.Add(-ages[op])
+	}, now)
+
+	for label, want := range map[string]int64{"<1m": 1, "1m-5m": 2, "5m-15m": 1, ">15m": 1} {
+		bs := stats.byAge[label]
+		if bs.count != want {
+			t.Fatalf("%s: got count %d, want %d", label, bs.count, want)
+		}
+		wantGas := big.NewInt(0).Mul(batch[0].GetMaxGasAvailable(), big.NewInt(want))
+		if bs.gas.Cmp(wantGas) != 0 {
+			t.Fatalf("%s: got gas %s, want %s", label, bs.gas, wantGas)
+		}
+	}
+	for label, want := range map[string]int64{"p10": 1, "p50": 3, "p90": 5} {
+		if stats.fees[label].Int64() != want {
+			t.Fatalf("%s: got %s, want %d", label, stats.fees[label], want)
+		}
+	}
+}
+
+func TestGetMempoolStatsEmpty(t *testing.T) {
+	stats := getMempoolStats([]*userop.UserOperation{}, nil, time.Now())
+	for _, b := range ageBuckets {
+		if stats.byAge[b.label].count != 0 {
+			t.Fatalf("%s: got %d, want 0", b.label, stats.byAge[b.label].count)
+		}
+	}
+	if stats.fees["p50"].Sign() != 0 {
+		t.Fatalf("got %s, want 0", stats.fees["p50"])
+	}
+}
@@ -0,0 +1,161 @@
+package bundler
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+const (
+	// ReasonDeferred is the reason given for ops that were selected for a batch but left in the mempool by a
+	// module (e.g. due to the gas limit) for a later bundle.
+	ReasonDeferred = "deferred to a later bundle"
+)
+
+// GetTxReceiptFunc returns the receipt of a mined transaction. It is used to report the actual gas used by a
+// forced bundle.
+type GetTxReceiptFunc = func(hash common.Hash) (*types.Receipt, error)
+
+// ExcludedOp is a UserOperation that was selected from the mempool but not included in the bundle.
+type ExcludedOp struct {
+	UserOpHash common.Hash `json:"userOpHash"`
+	Reason     string      `json:"reason"`
+	Dropped    bool        `json:"dropped"`
+}
+
+// Receipt is a diagnostic summary of a single bundler run. It is returned when a bundle is forced by the
+// debug or admin namespace.
+type Receipt struct {
+	EntryPoint           common.Address  `json:"entryPoint"`
+	TransactionHash      string          `json:"transactionHash,omitempty"`
+	SelectedUserOpHashes []common.Hash   `json:"selectedUserOpHashes"`
+	ExcludedUserOps      []*ExcludedOp   `json:"excludedUserOps"`
+	EstimatedGas         *hexutil.Uint64 `json:"estimatedGas,omitempty"`
+	ActualGasUsed        *hexutil.Uint64 `json:"actualGasUsed,omitempty"`
+	Status               *hexutil.Uint64 `json:"status,omitempty"`
+	BuilderResponses     any             `json:"builderResponses,omitempty"`
+	Data                 map[string]any  `json:"data"`
+}
+
+// SetGetTxReceiptFunc defines the function used to look up the receipt of a forced bundle. If not set,
+// receipts from ProcessWithReceipt and ProcessOpWithReceipt will not include the actual gas used.
+func (i *Bundler) SetGetTxReceiptFunc(fn GetTxReceiptFunc) {
+	i.gtr = fn
+}
+
+// newReceipt returns a Receipt for a run that started with the initial ops and ended with the given context.
+// A nil context means that there was nothing to bundle.
+func (i *Bundler) newReceipt(
+	ep common.Address,
+	initial []*userop.UserOperation,
+	ctx *modules.BatchHandlerCtx,
+) *Receipt {
+	r := &Receipt{
+		EntryPoint:           ep,
+		SelectedUserOpHashes: []common.Hash{},
+		ExcludedUserOps:      []*ExcludedOp{},
+		Data:                 map[string]any{},
+	}
+	if ctx == nil {
+		return r
+	}
+
+	included := make(map[common.Hash]bool)
+	for _, op := range ctx.Batch {
+		hash := op.GetUserOpHash(ep, i.chainID)
+		included[hash] = true
+		r.SelectedUserOpHashes = append(r.SelectedUserOpHashes, hash)
+	}
+	dropped := make(map[common.Hash]bool)
+	for _, item := range ctx.PendingRemoval {
+		hash := item.Op.GetUserOpHash(ep, i.chainID)
+		dropped[hash] = true
+		r.ExcludedUserOps = append(
+			r.ExcludedUserOps,
+			&ExcludedOp{UserOpHash: hash, Reason: item.Reason, Dropped: true},
+		)
+	}
+	for _, op := range initial {
+		hash := op.GetUserOpHash(ep, i.chainID)
+		if !included[hash] && !dropped[hash] {
+			r.ExcludedUserOps = append(r.ExcludedUserOps, &ExcludedOp{UserOpHash: hash, Reason: ReasonDeferred})
+		}
+	}
+
+	for k, v := range ctx.Data {
+		r.Data[k] = v
+	}
+	if est, ok := ctx.Data["estimated_gas"].(uint64); ok {
+		r.EstimatedGas = (*hexutil.Uint64)(&est)
+	}
+	r.BuilderResponses = ctx.Data["builder_responses"]
+	if hash, ok := ctx.Data["txn_hash"].(string); ok && len(ctx.Batch) > 0 {
+		r.TransactionHash = hash
+		i.addTxReceipt(r, common.HexToHash(hash))
+	}
+	return r
+}
+
+// addTxReceipt sets the actual gas used and status of the bundle transaction if it has been mined. Lookup
+// errors are logged since the bundle has already been sent.
+func (i *Bundler) addTxReceipt(r *Receipt, hash common.Hash) {
+	if i.gtr == nil {
+		return
+	}
+	receipt, err := i.gtr(hash)
+	if err != nil || receipt == nil {
+		i.logger.Info("bundle receipt not available", "txn_hash", hash.String())
+		return
+	}
+	gasUsed := hexutil.Uint64(receipt.GasUsed)
+	status := hexutil.Uint64(receipt.Status)
+	r.ActualGasUsed = &gasUsed
+	r.Status = &status
+}
+
+// ProcessWithReceipt works the same as Process but returns a Receipt with the selected ops, excluded ops and
+// their reasons, and gas usage of the bundle.
+func (i *Bundler) ProcessWithReceipt(ep common.Address) (*Receipt, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	start := i.clock.Now()
+	l := i.logger.
+		WithName("run").
+		WithValues("entrypoint", ep.String()).
+		WithValues("chain_id", i.chainID.String())
+
+	ctx, err := i.NewContext(ep)
+	if err != nil {
+		l.Error(err, "bundler run error")
+		i.recordRunError(ep)
+		return nil, err
+	} else if ctx == nil {
+		return i.newReceipt(ep, nil, nil), nil
+	}
+
+	initial := append([]*userop.UserOperation{}, ctx.Batch...)
+	ctx, err = i.process(ctx, start, l)
+	if err != nil {
+		return nil, err
+	}
+	return i.newReceipt(ep, initial, ctx), nil
+}
+
+// ProcessOpWithReceipt works the same as ProcessOp but returns a Receipt for the targeted bundle.
+func (i *Bundler) ProcessOpWithReceipt(hash common.Hash) (*Receipt, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	ep, op, err := i.findOp(hash)
+	if err != nil {
+		return nil, err
+	}
+	ctx, err := i.processOp(ep, op)
+	if err != nil {
+		return nil, err
+	}
+	return i.newReceipt(ep, []*userop.UserOperation{op}, ctx), nil
+}
@@ -0,0 +1,269 @@
+// Package chainhead tracks the latest block header of the connected node. An eth_subscribe newHeads
+// subscription is used when the upstream supports it and polling is used otherwise. This reduces RPC load
+// compared to fetching the latest header on demand and allows other processes to react to new blocks.
+package chainhead
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/gasprice"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var (
+	// DefaultPollInterval is the time between requests for the latest header when subscriptions are not
+	// supported.
+	DefaultPollInterval = time.Second
+
+	// ResubscribeInterval is the time spent polling after a subscription is dropped before subscribing again.
+	ResubscribeInterval = 30 * time.Second
+
+	// maxRecentHeaders is the number of recent block hashes kept for detecting reorgs.
+	maxRecentHeaders = uint64(64)
+)
+
+// Watcher keeps the latest block header and detects reorgs.
+type Watcher struct {
+	eth          *ethclient.Client
+	logger       logr.Logger
+	pollInterval time.Duration
+	reorgCounter metric.Int64Counter
+
+	mu         sync.RWMutex
+	latest     *types.Header
+	recent     map[uint64]common.Hash
+	listeners  []chan struct{}
+	subscribed bool
+
+	done chan struct{}
+}
+
+// New returns a Watcher that uses the given client for both subscriptions and polling.
+func New(eth *ethclient.Client, l logr.Logger) *Watcher {
+	return &Watcher{
+		eth:          eth,
+		logger:       l.WithName("chainhead"),
+		pollInterval: DefaultPollInterval,
+		recent:       make(map[uint64]common.Hash),
+		done:         make(chan struct{}),
+	}
+}
+
+// SetPollInterval sets the time between requests for the latest header when subscriptions are not supported.
+//
+// The default value is 1 second.
+func (w *Watcher) SetPollInterval(interval time.Duration) {
+	w.pollInterval = interval
+}
+
+// UseMeter defines an opentelemetry meter object used by the Watcher to count reorgs.
+func (w *Watcher) UseMeter(meter metric.Meter) error {
+	c, err := meter.Int64Counter("chainhead_reorgs")
+	if err != nil {
+		return err
+	}
+
+	w.reorgCounter = c
+	return nil
+}
+
+// NewHeads returns a channel that receives a signal whenever a new head is seen. Signals are dropped if the
+// previous one has not been received yet.
+func (w *Watcher) NewHeads() <-chan struct{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ch := make(chan struct{}, 1)
+	w.listeners = append(w.listeners, ch)
+	return ch
+}
+
+// IsSubscribed returns true if new heads are currently received through a subscription.
+func (w *Watcher) IsSubscribed() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.subscribed
+}
+
+// Latest returns the latest header seen. If none has been seen yet, it is fetched from the node.
+func (w *Watcher) Latest() (*types.Header, error) {
+	w.mu.RLock()
+	head := w.latest
+	w.mu.RUnlock()
+	if head != nil {
+		return head, nil
+	}
+
+	head, err := w.eth.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+	w.handleHead(head)
+	return head, nil
+}
+
+// GetBaseFeeFunc returns a GetBaseFeeFunc using the basefee of the latest header.
+func (w *Watcher) GetBaseFeeFunc() gasprice.GetBaseFeeFunc {
+	return func() (*big.Int, error) {
+		head, err := w.Latest()
+		if err != nil {
+			return nil, err
+		}
+		return head.BaseFee, nil
+	}
+}
+
+// GetBlockGasLimit returns the gas limit of the latest header.
+func (w *Watcher) GetBlockGasLimit() (uint64, error) {
+	head, err := w.Latest()
+	if err != nil {
+		return 0, err
+	}
+	return head.GasLimit, nil
+}
+
+// handleHead updates the latest header, checks it against recent headers for a reorg, and notifies all
+// listeners.
+func (w *Watcher) handleHead(head *types.Header) {
+	w.mu.Lock()
+	prev := w.latest
+	if prev != nil && prev.Hash() == head.Hash() {
+		w.mu.Unlock()
+		return
+	}
+
+	num := head.Number.Uint64()
+	depth := uint64(0)
+	if parent, ok := w.recent[num-1]; ok && num > 0 && parent != head.ParentHash {
+		depth = 1
+	}
+	if prev != nil && prev.Number.Uint64() >= num {
+		depth = prev.Number.Uint64() - num + 1
+	}
+	for n := range w.recent {
+		if n >= num || n+maxRecentHeaders < num {
+			delete(w.recent, n)
+		}
+	}
+	w.recent[num] = head.Hash()
+	w.latest = head
+	listeners := append([]chan struct{}{}, w.listeners...)
+	w.mu.Unlock()
+
+	if depth > 0 {
+		w.logger.Info("reorg detected", "depth", depth, "block_number", num, "block_hash", head.Hash())
+		if w.reorgCounter != nil {
+			w.reorgCounter.Add(context.Background(), 1)
+		}
+	}
+	for _, ch := range listeners {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (w *Watcher) setSubscribed(subscribed bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.subscribed = subscribed
+}
+
+// consume handles heads from a subscription until it fails or the Watcher is stopped. It returns true if the
+// Watcher was stopped.
+func (w *Watcher) consume(sub ethereum.Subscription, heads <-chan *types.Header) bool {
+	defer sub.Unsubscribe()
+	w.setSubscribed(true)
+	defer w.setSubscribed(false)
+
+	for {
+		select {
+		case <-w.done:
+			return true
+		case err := <-sub.Err():
+			w.logger.Error(err, "newHeads subscription dropped, falling back to polling")
+			return false
+		case head := <-heads:
+			w.handleHead(head)
+		}
+	}
+}
+
+// poll fetches the latest header at each interval for the given duration or forever if it is 0. It returns
+// true if the Watcher was stopped.
+func (w *Watcher) poll(duration time.Duration) bool {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+	var timeout <-chan time.Time
+	if duration > 0 {
+		timer := time.NewTimer(duration)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		select {
+		case <-w.done:
+			return true
+		case <-timeout:
+			return false
+		case <-ticker.C:
+			head, err := w.eth.HeaderByNumber(context.Background(), nil)
+			if err != nil {
+				w.logger.Error(err, "failed to poll latest header")
+				continue
+			}
+			w.handleHead(head)
+		}
+	}
+}
+
+func (w *Watcher) run() {
+	for {
+		heads := make(chan *types.Header, 16)
+		sub, err := w.eth.SubscribeNewHead(context.Background(), heads)
+		if errors.Is(err, rpc.ErrNotificationsUnsupported) {
+			w.logger.Info("upstream does not support subscriptions, polling for new heads")
+			w.poll(0)
+			return
+		} else if err != nil {
+			w.logger.Error(err, "failed to subscribe to newHeads, falling back to polling")
+		} else if w.consume(sub, heads) {
+			return
+		}
+
+		if w.poll(ResubscribeInterval) {
+			return
+		}
+	}
+}
+
+// Start fetches the latest header and starts a goroutine to track new heads.
+func (w *Watcher) Start() error {
+	head, err := w.eth.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	w.handleHead(head)
+
+	go w.run()
+	return nil
+}
+
+// Stop ends the goroutine tracking new heads.
+func (w *Watcher) Stop() {
+	close(w.done)
+}
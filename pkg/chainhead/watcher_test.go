@@ -0,0 +1,106 @@
+package chainhead
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-logr/logr"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func header(num int64, parent common.Hash, extra byte) *types.Header {
+	return &types.Header{
+		Number:     big.NewInt(num),
+		ParentHash: parent,
+		GasLimit:   30_000_000,
+		BaseFee:    big.NewInt(num),
+		Extra:      []byte{extra},
+	}
+}
+
+func reorgs(t *testing.T, reader sdkmetric.Reader) int64 {
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == "chainhead_reorgs" {
+				return m.Data.(metricdata.Sum[int64]).DataPoints[0].Value
+			}
+		}
+	}
+	return 0
+}
+
+func newTestWatcher(t *testing.T) (*Watcher, sdkmetric.Reader) {
+	reader := sdkmetric.NewManualReader()
+	w := New(nil, logr.Discard())
+	if err := w.UseMeter(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	return w, reader
+}
+
+// TestWatcherNotifiesNewHeads verifies that listeners are signaled on new heads and that the latest header is
+// used for basefee and block gas limit.
+func TestWatcherNotifiesNewHeads(t *testing.T) {
+	w, reader := newTestWatcher(t)
+	ch := w.NewHeads()
+
+	h1 := header(1, common.Hash{}, 0)
+	w.handleHead(h1)
+	<-ch
+	h2 := header(2, h1.Hash(), 0)
+	w.handleHead(h2)
+	w.handleHead(h2)
+	<-ch
+	select {
+	case <-ch:
+		t.Fatal("got signal for duplicate head, want none")
+	default:
+	}
+
+	bf, err := w.GetBaseFeeFunc()()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if bf.Cmp(h2.BaseFee) != 0 {
+		t.Fatalf("got basefee %s, want %s", bf, h2.BaseFee)
+	}
+	gl, err := w.GetBlockGasLimit()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if gl != h2.GasLimit {
+		t.Fatalf("got gas limit %d, want %d", gl, h2.GasLimit)
+	}
+	if n := reorgs(t, reader); n != 0 {
+		t.Fatalf("got %d reorgs, want 0", n)
+	}
+}
+
+// TestWatcherDetectsReorgs verifies that heads with a different parent or a lower number are counted as reorgs.
+func TestWatcherDetectsReorgs(t *testing.T) {
+	w, reader := newTestWatcher(t)
+
+	h1 := header(1, common.Hash{}, 0)
+	h2 := header(2, h1.Hash(), 0)
+	w.handleHead(h1)
+	w.handleHead(h2)
+
+	// Same height, different block.
+	h2b := header(2, h1.Hash(), 1)
+	w.handleHead(h2b)
+	if n := reorgs(t, reader); n != 1 {
+		t.Fatalf("got %d reorgs, want 1", n)
+	}
+
+	// Next block does not build on the latest block.
+	w.handleHead(header(3, h2.Hash(), 0))
+	if n := reorgs(t, reader); n != 2 {
+		t.Fatalf("got %d reorgs, want 2", n)
+	}
+}
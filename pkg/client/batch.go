@@ -0,0 +1,107 @@
+package client
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	// MaxSendBatchSize is the maximum number of UserOperations allowed in a single eth_sendUserOperation call.
+	MaxSendBatchSize = 100
+
+	// SendBatchConcurrency is the maximum number of senders validated in parallel for a single
+	// eth_sendUserOperation call.
+	SendBatchConcurrency = 16
+)
+
+var errInvalidSendParam = errors.NewRPCError(
+	errors.INVALID_FIELDS,
+	"Param [0] can't be converted to UserOperation or array of UserOperations",
+	nil,
+)
+
+// SendResult is the per-op result of a batched eth_sendUserOperation call. Only one of UserOpHash or Error is
+// set.
+type SendResult struct {
+	UserOpHash string     `json:"userOpHash,omitempty"`
+	Error      *SendError `json:"error,omitempty"`
+}
+
+// SendError is a JSON-RPC error object for a single UserOperation in a batch.
+type SendError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func newSendResult(hash string, err error) *SendResult {
+	if err == nil {
+		return &SendResult{UserOpHash: hash}
+	}
+	if rpcErr, ok := err.(*errors.RPCError); ok {
+		return &SendResult{Error: &SendError{rpcErr.Code(), rpcErr.Error(), rpcErr.Data()}}
+	}
+	return &SendResult{Error: &SendError{-32601, err.Error(), err.Error()}}
+}
+
+// SendUserOperations implements the method call for eth_sendUserOperation with an array of UserOperations.
+// Each op is handled the same as *Client.SendUserOperation and results are returned in the same order as the
+// given ops. Ops from different senders are validated concurrently while ops from the same sender are
+// validated sequentially in the given order so that nonce and replacement checks remain consistent.
+func (i *Client) SendUserOperations(ops []any, ep string, opts map[string]any) ([]*SendResult, error) {
+	if len(ops) == 0 {
+		return nil, errors.NewRPCError(errors.INVALID_FIELDS, "UserOperation array is empty", nil)
+	}
+	if len(ops) > MaxSendBatchSize {
+		return nil, errors.NewRPCError(
+			errors.INVALID_FIELDS,
+			"UserOperation array exceeds max batch size",
+			MaxSendBatchSize,
+		)
+	}
+
+	// Group op indexes by sender. Ops that cannot be grouped are put in their own group and will fail in
+	// *Client.SendUserOperation.
+	groups := [][]int{}
+	bySender := make(map[common.Address]int)
+	for idx, v := range ops {
+		op, _ := v.(map[string]any)
+		s, ok := op["sender"].(string)
+		if !ok || !common.IsHexAddress(s) {
+			groups = append(groups, []int{idx})
+			continue
+		}
+		sender := common.HexToAddress(s)
+		if g, ok := bySender[sender]; ok {
+			groups[g] = append(groups[g], idx)
+			continue
+		}
+		bySender[sender] = len(groups)
+		groups = append(groups, []int{idx})
+	}
+
+	res := make([]*SendResult, len(ops))
+	eg := new(errgroup.Group)
+	eg.SetLimit(SendBatchConcurrency)
+	for _, g := range groups {
+		g := g
+		eg.Go(func() error {
+			for _, idx := range g {
+				op, ok := ops[idx].(map[string]any)
+				if !ok {
+					res[idx] = newSendResult(
+						"",
+						errors.NewRPCError(errors.INVALID_FIELDS, "UserOperation must be an object", nil),
+					)
+					continue
+				}
+				res[idx] = newSendResult(i.SendUserOperation(op, ep, opts))
+			}
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	return res, nil
+}
@@ -2,21 +2,33 @@
 package client
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/go-logr/logr"
 	"github.com/stackup-wallet/stackup-bundler/internal/logger"
+	"github.com/stackup-wallet/stackup-bundler/pkg/altmempools"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/filter"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/nonce"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/simulation"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
+	"github.com/stackup-wallet/stackup-bundler/pkg/federation"
 	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gasfeedback"
 	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/noop"
+	"github.com/stackup-wallet/stackup-bundler/pkg/opstatus"
+	"github.com/stackup-wallet/stackup-bundler/pkg/origin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/paymaster"
 	"github.com/stackup-wallet/stackup-bundler/pkg/state"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/sync/singleflight"
 )
 
 // Client controls the end to end process of adding incoming UserOperations to the mempool. It also
@@ -27,15 +39,36 @@ type Client struct {
 	chainID              *big.Int
 	supportedEntryPoints []common.Address
 	userOpHandler        modules.UserOpHandlerFunc
+	moduleNames          []string
+	info                 *BundlerInfo
 	logger               logr.Logger
 	getUserOpReceipt     GetUserOpReceiptFunc
 	getGasPrices         GetGasPricesFunc
 	getGasEstimate       GetGasEstimateFunc
 	getUserOpByHash      GetUserOpByHashFunc
+	getUserOpsBySender   GetUserOpsBySenderFunc
 	getStakeFunc         stake.GetStakeFunc
+	getNonceFunc         nonce.GetNonceFunc
 	opLookupLimit        uint64
 	qngWeb3              QngWeb3Func
 	qngCross             QngCrossFunc
+	getTokenValueOfEth   paymaster.GetTokenValueOfEthFunc
+	getPaymasterData     paymaster.GetPaymasterDataFunc
+	getAltMempoolExs     GetAltMempoolExceptionsFunc
+	recordOrigin         RecordOriginFunc
+	getOrigin            GetOriginFunc
+	getFingerprint       GetFingerprintFunc
+	getUserOpStatus      GetUserOpStatusFunc
+	putUserOpStatus      PutUserOpStatusFunc
+	simulateAtBlock      SimulateAtBlockFunc
+	getFederationPeers   GetFederationPeersFunc
+	recordEstimate       RecordEstimateFunc
+	getEstimatePadding   GetEstimatePaddingFunc
+	getGasUsageStats     GetGasUsageStatsFunc
+	hold                 *holdQueue
+	inflight             singleflight.Group
+	accepted             metric.Int64Counter
+	rejected             metric.Int64Counter
 }
 
 // New initializes a new ERC-4337 client which can be extended with modules for validating UserOperations
@@ -53,12 +86,29 @@ func New(
 		chainID:              chainID,
 		supportedEntryPoints: supportedEntryPoints,
 		userOpHandler:        noop.UserOpHandler,
+		moduleNames:          []string{},
+		info:                 &BundlerInfo{},
 		logger:               logger.NewZeroLogr().WithName("client"),
 		getUserOpReceipt:     getUserOpReceiptNoop(),
 		getGasPrices:         getGasPricesNoop(),
 		getGasEstimate:       getGasEstimateNoop(),
 		getUserOpByHash:      getUserOpByHashNoop(),
+		getUserOpsBySender:   getUserOpsBySenderNoop(),
 		getStakeFunc:         stake.GetStakeFuncNoop(),
+		getNonceFunc:         nonce.GetNonceFuncNoop(),
+		getTokenValueOfEth:   paymaster.GetTokenValueOfEthFuncNoop(),
+		getPaymasterData:     paymaster.GetPaymasterDataFuncNoop(),
+		getAltMempoolExs:     getAltMempoolExceptionsNoop(),
+		recordOrigin:         recordOriginNoop(),
+		getOrigin:            getOriginNoop(),
+		getFingerprint:       getFingerprintNoop(),
+		getUserOpStatus:      getUserOpStatusNoop(),
+		putUserOpStatus:      putUserOpStatusNoop(),
+		simulateAtBlock:      simulateAtBlockNoop(),
+		getFederationPeers:   getFederationPeersNoop(),
+		recordEstimate:       recordEstimateNoop(),
+		getEstimatePadding:   getEstimatePaddingNoop(),
+		getGasUsageStats:     getGasUsageStatsNoop(),
 		opLookupLimit:        opLookupLimit,
 	}
 }
@@ -81,6 +131,21 @@ func (i *Client) UseLogger(logger logr.Logger) {
 // UseModules defines the UserOpHandlers to process a userOp after it has gone through the standard checks.
 func (i *Client) UseModules(handlers ...modules.UserOpHandlerFunc) {
 	i.userOpHandler = modules.ComposeUserOpHandlerFunc(handlers...)
+	i.moduleNames = []string{}
+	for _, h := range handlers {
+		i.moduleNames = append(i.moduleNames, modules.HandlerName(h))
+	}
+}
+
+// ModuleNames returns the names of all UserOpHandlers used by the Client in order of execution.
+func (i *Client) ModuleNames() []string {
+	return append([]string{}, i.moduleNames...)
+}
+
+// SetBundlerInfo defines the static identity and capabilities of the bundler instance. Chain ID and supported
+// EntryPoints are always derived from the Client. This is returned in *Client.GetInfo.
+func (i *Client) SetBundlerInfo(info *BundlerInfo) {
+	i.info = info
 }
 
 // SetGetUserOpReceiptFunc defines a general function for fetching a UserOpReceipt given a userOpHash and
@@ -109,11 +174,103 @@ func (i *Client) SetGetUserOpByHashFunc(fn GetUserOpByHashFunc) {
 	i.getUserOpByHash = fn
 }
 
+// SetGetUserOpsBySenderFunc defines a general function for fetching the most recently included UserOperations
+// from a sender. This function is called in *Client.GetUserOperationsBySender.
+func (i *Client) SetGetUserOpsBySenderFunc(fn GetUserOpsBySenderFunc) {
+	i.getUserOpsBySender = fn
+}
+
 // SetGetStakeFunc defines a general function for retrieving the EntryPoint stake for a given address. This
 // function is called in *Client.SendUserOperation to create a context.
 func (i *Client) SetGetStakeFunc(fn stake.GetStakeFunc) {
 	i.getStakeFunc = fn
 }
+
+// SetGetNonceFunc defines a general function for retrieving the next EntryPoint nonce for a given sender and
+// nonce key. This function is called in *Client.GetUserOperationNonce.
+func (i *Client) SetGetNonceFunc(fn nonce.GetNonceFunc) {
+	i.getNonceFunc = fn
+}
+
+// SetGetTokenValueOfEthFunc defines a general function for converting an amount of wei to the amount of ERC-20
+// tokens charged by a paymaster. This function is called in *Client.GetErc20FeeQuote.
+func (i *Client) SetGetTokenValueOfEthFunc(fn paymaster.GetTokenValueOfEthFunc) {
+	i.getTokenValueOfEth = fn
+}
+
+// SetGetAltMempoolExceptionsFunc defines a general function for fetching the alternative mempool exceptions
+// applied to a UserOperation. This function is called in *Client.GetAltMempoolExceptions and
+// *Client.GetUserOperationByHash.
+func (i *Client) SetGetAltMempoolExceptionsFunc(fn GetAltMempoolExceptionsFunc) {
+	i.getAltMempoolExs = fn
+}
+
+// SetRecordOriginFunc defines a general function for storing the dapp id of a UserOperation. This function is
+// called in *Client.SendUserOperation if a dappId is given.
+func (i *Client) SetRecordOriginFunc(fn RecordOriginFunc) {
+	i.recordOrigin = fn
+}
+
+// SetGetOriginFunc defines a general function for fetching the dapp id of a UserOperation. This function is
+// called in *Client.GetUserOperationByHash.
+func (i *Client) SetGetOriginFunc(fn GetOriginFunc) {
+	i.getOrigin = fn
+}
+
+// SetGetFingerprintFunc defines a general function for fetching the account implementation fingerprint of a
+// UserOperation. This function is called in *Client.GetUserOperationByHash.
+func (i *Client) SetGetFingerprintFunc(fn GetFingerprintFunc) {
+	i.getFingerprint = fn
+}
+
+// SetGetUserOpStatusFunc defines a general function for fetching the lifecycle status of a UserOperation.
+// This function is called in *Client.GetUserOperationStatus.
+func (i *Client) SetGetUserOpStatusFunc(fn GetUserOpStatusFunc) {
+	i.getUserOpStatus = fn
+}
+
+// SetPutUserOpStatusFunc defines a general function for persisting the lifecycle status of a UserOperation.
+// This function is called in *Client.GetUserOperationStatus once an op is found to be included.
+func (i *Client) SetPutUserOpStatusFunc(fn PutUserOpStatusFunc) {
+	i.putUserOpStatus = fn
+}
+
+// SetSimulateAtBlockFunc defines a general function for running full validation of a UserOperation against
+// historical state. This function is called in *Client.SimulateAtBlock.
+func (i *Client) SetSimulateAtBlockFunc(fn SimulateAtBlockFunc) {
+	i.simulateAtBlock = fn
+}
+
+// SetGetPaymasterDataFunc defines a general function for calling ERC-7677 paymaster methods. This function is
+// called in *Client.GetPaymasterStubData and *Client.GetPaymasterData.
+func (i *Client) SetGetPaymasterDataFunc(fn paymaster.GetPaymasterDataFunc) {
+	i.getPaymasterData = fn
+}
+
+// SetGetFederationPeersFunc defines a general function for fetching the status beacons of federated bundlers.
+// This function is called in *Client.GetFederationPeers.
+func (i *Client) SetGetFederationPeersFunc(fn GetFederationPeersFunc) {
+	i.getFederationPeers = fn
+}
+
+// SetRecordEstimateFunc defines a general function for storing the gas estimate of a UserOperation. This
+// function is called in *Client.EstimateUserOperationGas.
+func (i *Client) SetRecordEstimateFunc(fn RecordEstimateFunc) {
+	i.recordEstimate = fn
+}
+
+// SetGetEstimatePaddingFunc defines a general function for fetching the percent to add to the callGasLimit
+// estimate of a UserOperation. This function is called in *Client.EstimateUserOperationGas.
+func (i *Client) SetGetEstimatePaddingFunc(fn GetEstimatePaddingFunc) {
+	i.getEstimatePadding = fn
+}
+
+// SetGetGasUsageStatsFunc defines a general function for fetching the gas usage stats of each account
+// implementation. This function is called in *Client.GetGasUsageStats.
+func (i *Client) SetGetGasUsageStatsFunc(fn GetGasUsageStatsFunc) {
+	i.getGasUsageStats = fn
+}
+
 func (i *Client) SetQngWeb3(fn QngWeb3Func) {
 	i.qngWeb3 = fn
 }
@@ -122,11 +279,28 @@ func (i *Client) SetQngCross(fn QngCrossFunc) {
 }
 
 // SendUserOperation implements the method call for eth_sendUserOperation.
-// It returns true if userOp was accepted otherwise returns an error.
-func (i *Client) SendUserOperation(op map[string]any, ep string) (string, error) {
+// It returns true if userOp was accepted otherwise returns an error. An optional dappId can be set in opts to
+// tag the userOp with the dapp that submitted it.
+func (i *Client) SendUserOperation(op map[string]any, ep string, opts map[string]any) (string, error) {
+	hash, err := i.sendUserOperation(op, ep, opts)
+	i.recordSendResult(err)
+	return hash, err
+}
+
+func (i *Client) sendUserOperation(op map[string]any, ep string, opts map[string]any) (string, error) {
 	// Init logger
 	l := i.logger.WithName("eth_sendUserOperation")
 
+	// Check dappId is valid if set.
+	dappId, _ := opts["dappId"].(string)
+	if _, ok := opts["dappId"]; ok {
+		if err := origin.ValidateDappId(dappId); err != nil {
+			l.Error(err, "eth_sendUserOperation error")
+			return "", err
+		}
+		l = l.WithValues("dapp_id", dappId)
+	}
+
 	// Check EntryPoint and userOp is valid.
 	epAddr, err := i.parseEntryPointAddress(ep)
 	if err != nil {
@@ -145,49 +319,120 @@ func (i *Client) SendUserOperation(op map[string]any, ep string) (string, error)
 	hash := userOp.GetUserOpHash(epAddr, i.chainID)
 	l = l.WithValues("userop_hash", hash)
 
-	// Run through client module stack.
-	ctx, err := modules.NewUserOpHandlerContext(
-		userOp,
-		epAddr,
-		i.chainID,
-		i.mempool,
-		i.getStakeFunc,
-	)
-	if err != nil {
+	// Skip validation for exact duplicates of an op already in the mempool.
+	if dup, err := i.isPendingDuplicate(epAddr, userOp); err != nil {
 		l.Error(err, "eth_sendUserOperation error")
 		return "", err
+	} else if dup {
+		l.Info("eth_sendUserOperation duplicate")
+		return hash.String(), nil
 	}
-	if err := i.userOpHandler(ctx); err != nil {
+
+	// Hold the op without validation if the upstream node is still syncing.
+	if held, err := i.holdIfSyncing(epAddr, userOp, dappId); err != nil {
 		l.Error(err, "eth_sendUserOperation error")
 		return "", err
+	} else if held {
+		l.Info("eth_sendUserOperation held")
+		return hash.String(), nil
 	}
 
-	// Add userOp to mempool.
-	if err := i.mempool.AddOp(epAddr, ctx.UserOp); err != nil {
+	// Collapse concurrent submissions of the same op into a single validation.
+	key := hash.String() + hexutil.Encode(userOp.Signature)
+	if _, err, _ := i.inflight.Do(key, func() (any, error) {
+		return nil, i.addUserOperation(epAddr, userOp)
+	}); err != nil {
 		l.Error(err, "eth_sendUserOperation error")
 		return "", err
 	}
 
+	// Failing to tag the userOp should not fail a submission that has already been accepted.
+	if dappId != "" {
+		if err := i.recordOrigin(hash, dappId); err != nil {
+			l.Error(err, "eth_sendUserOperation origin error")
+		}
+	}
+
 	l.Info("eth_sendUserOperation ok")
 	return hash.String(), nil
 }
 
+// isPendingDuplicate returns true if the mempool already holds an op with the same intent and signature.
+func (i *Client) isPendingDuplicate(ep common.Address, op *userop.UserOperation) (bool, error) {
+	penOps, err := i.mempool.GetOps(ep, op.Sender)
+	if err != nil {
+		return false, err
+	}
+	for _, penOp := range penOps {
+		if penOp.IsSameIntent(op) && bytes.Equal(penOp.Signature, op.Signature) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// addUserOperation runs the op through the client module stack and adds it to the mempool. Ops with the same
+// intent as a pending op will replace it, keeping the newest signature.
+func (i *Client) addUserOperation(ep common.Address, op *userop.UserOperation) error {
+	ctx, err := modules.NewUserOpHandlerContext(
+		op,
+		ep,
+		i.chainID,
+		i.mempool,
+		i.getStakeFunc,
+	)
+	if err != nil {
+		return err
+	}
+	if err := i.userOpHandler(ctx); err != nil {
+		return err
+	}
+
+	return i.mempool.AddOp(ep, ctx.UserOp)
+}
+
 // EstimateUserOperationGas returns estimates for PreVerificationGas, VerificationGasLimit, and CallGasLimit
-// given a UserOperation, EntryPoint address, and state OverrideSet. The signature field and current gas
-// values will not be validated although there should be dummy values in place for the most reliable results
-// (e.g. a signature with the correct length).
+// given a UserOperation, EntryPoint address, state OverrideSet, and SignatureHint. The signature field and
+// current gas values will not be validated although there should be dummy values in place for the most
+// reliable results (e.g. a signature with the correct length). For accounts with non-standard signature
+// schemes, the SignatureHint can be used to replace the signature with a realistic dummy value.
 func (i *Client) EstimateUserOperationGas(
 	op map[string]any,
 	ep string,
 	os map[string]any,
+	sh map[string]any,
+) (*gas.GasEstimates, error) {
+	return i.estimateUserOperationGas("eth_estimateUserOperationGas", op, ep, os, sh, false)
+}
+
+// EstimateSponsoredUserOperationGas is a variant of *Client.EstimateUserOperationGas for UserOperations that
+// are sponsored by a paymaster. The paymaster's EntryPoint deposit is overridden so that sponsored users with
+// empty accounts can get accurate estimates regardless of the paymaster's current balance. Paymasters that
+// also check token balances or allowances will require an additional state override for the token contract.
+func (i *Client) EstimateSponsoredUserOperationGas(
+	op map[string]any,
+	ep string,
+	os map[string]any,
+	sh map[string]any,
+) (*gas.GasEstimates, error) {
+	return i.estimateUserOperationGas("bundler_estimateSponsoredUserOperationGas", op, ep, os, sh, true)
+}
+
+func (i *Client) estimateUserOperationGas(
+	method string,
+	op map[string]any,
+	ep string,
+	os map[string]any,
+	sh map[string]any,
+	sponsored bool,
 ) (*gas.GasEstimates, error) {
 	// Init logger
-	l := i.logger.WithName("eth_estimateUserOperationGas")
+	l := i.logger.WithName(method)
 
 	// Check EntryPoint and userOp is valid.
 	epAddr, err := i.parseEntryPointAddress(ep)
 	if err != nil {
-		l.Error(err, "eth_estimateUserOperationGas error")
+		l.Error(err, method+" error")
 		return nil, err
 	}
 	l = l.
@@ -196,7 +441,7 @@ func (i *Client) EstimateUserOperationGas(
 
 	userOp, err := userop.New(op)
 	if err != nil {
-		l.Error(err, "eth_estimateUserOperationGas error")
+		l.Error(err, method+" error")
 		return nil, err
 	}
 	hash := userOp.GetUserOpHash(epAddr, i.chainID)
@@ -205,17 +450,42 @@ func (i *Client) EstimateUserOperationGas(
 	// Parse state override set.
 	sos, err := state.ParseOverrideData(os)
 	if err != nil {
-		l.Error(err, "eth_estimateUserOperationGas error")
+		l.Error(err, method+" error")
 		return nil, err
 	}
 
+	// Override the paymaster deposit for sponsored estimates. Existing stake values are kept so that any
+	// checks on the paymaster's stake still behave as expected.
+	if sponsored {
+		pm := userOp.GetPaymaster()
+		if pm == common.HexToAddress("0x") {
+			err := errors.New("estimate: paymasterAndData is required for a sponsored estimate")
+			l.Error(err, method+" error")
+			return nil, err
+		}
+		dep, err := i.getStakeFunc(epAddr, pm)
+		if err != nil {
+			l.Error(err, method+" error")
+			return nil, err
+		}
+		sos = state.WithMaxDepositOverride(epAddr, pm, dep.Staked, dep.Stake, sos)
+	}
+
+	// Apply signature hint to ensure estimates are based on realistic calldata.
+	hint, err := gas.ParseSignatureHint(sh)
+	if err != nil {
+		l.Error(err, method+" error")
+		return nil, err
+	}
+	hint.Apply(userOp)
+
 	// Override op with suggested gas prices if maxFeePerGas is 0. This allows for more reliable gas
 	// estimations upstream. The default balance override also ensures simulations won't revert on
 	// insufficient funds.
 	if userOp.MaxFeePerGas.Cmp(common.Big0) != 1 {
 		gp, err := i.getGasPrices()
 		if err != nil {
-			l.Error(err, "eth_estimateUserOperationGas error")
+			l.Error(err, method+" error")
 			return nil, err
 		}
 		userOp.MaxFeePerGas = gp.MaxFeePerGas
@@ -225,44 +495,229 @@ func (i *Client) EstimateUserOperationGas(
 	// Estimate gas limits
 	vg, cg, err := i.getGasEstimate(epAddr, userOp, sos)
 	if err != nil {
-		l.Error(err, "eth_estimateUserOperationGas error")
+		l.Error(err, method+" error")
+		return nil, err
+	}
+
+	// Pad callGasLimit for account implementations that have used more gas than estimated in the past.
+	pad, err := i.getEstimatePadding(userOp)
+	if err != nil {
+		l.Error(err, method+" error")
 		return nil, err
 	}
+	cgl := big.NewInt(0).Div(big.NewInt(0).Mul(big.NewInt(int64(cg)), big.NewInt(int64(100+pad))), big.NewInt(100))
 
-	// Calculate PreVerificationGas
-	pvg, err := i.ov.CalcPreVerificationGasWithBuffer(userOp)
+	// Calculate PreVerificationGas and the range that will remain valid for the next few blocks.
+	bounds, err := i.ov.CalcPreVerificationGasBounds(userOp)
 	if err != nil {
-		l.Error(err, "eth_estimateUserOperationGas error")
+		l.Error(err, method+" error")
 		return nil, err
 	}
+	pvg := i.ov.AddPreVerificationGasBuffer(bounds.Min)
 
-	l.Info("eth_estimateUserOperationGas ok")
-	return &gas.GasEstimates{
+	est := &gas.GasEstimates{
 		PreVerificationGas:   pvg,
 		VerificationGasLimit: big.NewInt(int64(vg)),
-		CallGasLimit:         big.NewInt(int64(cg)),
+		CallGasLimit:         cgl,
 
 		// TODO: Deprecate in v0.7
 		VerificationGas: big.NewInt(int64(vg)),
+
+		PreVerificationGasBounds: bounds,
+	}
+	if err := i.recordEstimate(epAddr, userOp, est); err != nil {
+		l.Error(err, method+" error")
+		return nil, err
+	}
+
+	l.Info(method + " ok")
+	return est, nil
+}
+
+// GetErc20FeeQuote returns the amount of ERC-20 tokens the paymaster in a UserOperation will charge for the
+// max gas cost of the op at current gas prices. The paymaster's own quoted exchange rate is used for the
+// conversion.
+func (i *Client) GetErc20FeeQuote(op map[string]any, ep string) (*paymaster.TokenQuote, error) {
+	// Init logger
+	l := i.logger.WithName("bundler_getErc20FeeQuote")
+
+	// Check EntryPoint and userOp is valid.
+	epAddr, err := i.parseEntryPointAddress(ep)
+	if err != nil {
+		l.Error(err, "bundler_getErc20FeeQuote error")
+		return nil, err
+	}
+	l = l.
+		WithValues("entrypoint", epAddr.String()).
+		WithValues("chain_id", i.chainID.String())
+
+	userOp, err := userop.New(op)
+	if err != nil {
+		l.Error(err, "bundler_getErc20FeeQuote error")
+		return nil, err
+	}
+	pm := userOp.GetPaymaster()
+	if pm == common.HexToAddress("0x") {
+		err := errors.New("quote: paymasterAndData is required for an ERC-20 fee quote")
+		l.Error(err, "bundler_getErc20FeeQuote error")
+		return nil, err
+	}
+
+	// Use the current suggested fee unless the op has a lower cap.
+	gp, err := i.getGasPrices()
+	if err != nil {
+		l.Error(err, "bundler_getErc20FeeQuote error")
+		return nil, err
+	}
+	gasPrice := gp.MaxFeePerGas
+	if userOp.MaxFeePerGas.Cmp(common.Big0) == 1 && userOp.MaxFeePerGas.Cmp(gasPrice) == -1 {
+		gasPrice = userOp.MaxFeePerGas
+	}
+
+	maxGas := userOp.GetMaxGasAvailable()
+	maxCost := big.NewInt(0).Mul(maxGas, gasPrice)
+	amt, err := i.getTokenValueOfEth(pm, maxCost)
+	if err != nil {
+		l.Error(err, "bundler_getErc20FeeQuote error")
+		return nil, err
+	}
+
+	l.Info("bundler_getErc20FeeQuote ok")
+	return &paymaster.TokenQuote{
+		Paymaster:   pm,
+		GasPrice:    gasPrice,
+		MaxGas:      maxGas,
+		MaxCost:     maxCost,
+		TokenAmount: amt,
 	}, nil
 }
 
+func (i *Client) callPaymaster(
+	method string,
+	op map[string]any,
+	ep string,
+	chainID string,
+	pmCtx map[string]any,
+) (map[string]any, error) {
+	// Init logger
+	l := i.logger.WithName(method)
+
+	// Check EntryPoint and chain ID match this bundler so that sponsorship is not requested for a UserOperation
+	// that would be sent elsewhere.
+	epAddr, err := i.parseEntryPointAddress(ep)
+	if err != nil {
+		l.Error(err, method+" error")
+		return nil, err
+	}
+	l = l.
+		WithValues("entrypoint", epAddr.String()).
+		WithValues("chain_id", i.chainID.String())
+
+	id, err := hexutil.DecodeBig(chainID)
+	if err != nil {
+		err = fmt.Errorf("chainId: %w", err)
+		l.Error(err, method+" error")
+		return nil, err
+	} else if id.Cmp(i.chainID) != 0 {
+		err = fmt.Errorf("chainId: %s does not match bundler chain %s", id, i.chainID)
+		l.Error(err, method+" error")
+		return nil, err
+	}
+
+	res, err := i.getPaymasterData(method, op, epAddr, i.chainID, pmCtx)
+	if err != nil {
+		l.Error(err, method+" error")
+		return nil, err
+	}
+
+	l.Info(method + " ok")
+	return res, nil
+}
+
+// GetPaymasterStubData implements the ERC-7677 method pm_getPaymasterStubData. It returns paymaster fields
+// that can be used in a UserOperation for gas estimation.
+func (i *Client) GetPaymasterStubData(
+	op map[string]any,
+	ep string,
+	chainID string,
+	pmCtx map[string]any,
+) (map[string]any, error) {
+	return i.callPaymaster(paymaster.MethodGetPaymasterStubData, op, ep, chainID, pmCtx)
+}
+
+// GetPaymasterData implements the ERC-7677 method pm_getPaymasterData. It returns the final paymaster fields
+// for a UserOperation that is ready to be signed.
+func (i *Client) GetPaymasterData(
+	op map[string]any,
+	ep string,
+	chainID string,
+	pmCtx map[string]any,
+) (map[string]any, error) {
+	return i.callPaymaster(paymaster.MethodGetPaymasterData, op, ep, chainID, pmCtx)
+}
+
 // GetUserOperationReceipt fetches a UserOperation receipt based on a userOpHash returned by
-// *Client.SendUserOperation.
+// *Client.SendUserOperation. The logs of every supported EntryPoint are searched and the first match is
+// returned.
 func (i *Client) GetUserOperationReceipt(
 	hash string,
 ) (*filter.UserOperationReceipt, error) {
 	// Init logger
 	l := i.logger.WithName("eth_getUserOperationReceipt").WithValues("userop_hash", hash)
 
-	ev, err := i.getUserOpReceipt(hash, i.supportedEntryPoints[0], i.opLookupLimit)
+	// Search all supported EntryPoints so that a receipt can be found without knowing which version was used.
+	for _, ep := range i.supportedEntryPoints {
+		ev, err := i.getUserOpReceipt(hash, ep, i.opLookupLimit)
+		if err != nil {
+			l.Error(err, "eth_getUserOperationReceipt error")
+			return nil, err
+		} else if ev != nil {
+			l.Info("eth_getUserOperationReceipt ok")
+			return ev, nil
+		}
+	}
+
+	l.Info("eth_getUserOperationReceipt ok")
+	return nil, nil
+}
+
+// GetUserOperationHashPreimage returns the exact values this bundler hashes to derive the userOpHash of an op
+// for the given EntryPoint. It is meant to help debug signature mismatches (AA24) by comparing each step with
+// the hash signed by the wallet. The EntryPoint version can be set with the entryPointVersion option and
+// defaults to userop.EntryPointV06.
+func (i *Client) GetUserOperationHashPreimage(
+	op map[string]any,
+	ep string,
+	opts map[string]any,
+) (*userop.Preimage, error) {
+	// Init logger
+	l := i.logger.WithName("bundler_getUserOperationHashPreimage")
+
+	// Check EntryPoint and userOp is valid.
+	epAddr, err := i.parseEntryPointAddress(ep)
 	if err != nil {
-		l.Error(err, "eth_getUserOperationReceipt error")
+		l.Error(err, "bundler_getUserOperationHashPreimage error")
 		return nil, err
 	}
+	l = l.
+		WithValues("entrypoint", epAddr.String()).
+		WithValues("chain_id", i.chainID.String())
 
-	l.Info("eth_getUserOperationReceipt ok")
-	return ev, nil
+	userOp, err := userop.New(op)
+	if err != nil {
+		l.Error(err, "bundler_getUserOperationHashPreimage error")
+		return nil, err
+	}
+
+	version := userop.EntryPointV06
+	if v, ok := opts["entryPointVersion"]; ok {
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.New("entryPointVersion: must be a string")
+		}
+		version = s
+	}
+	return userOp.GetUserOpHashPreimage(epAddr, i.chainID, version)
 }
 
 // GetUserOperationByHash returns a UserOperation based on a given userOpHash returned by
@@ -276,7 +731,217 @@ func (i *Client) GetUserOperationByHash(hash string) (*filter.HashLookupResult,
 		l.Error(err, "eth_getUserOperationByHash error")
 		return nil, err
 	}
+	if res == nil {
+		return res, nil
+	}
 
+	exs, err := i.getAltMempoolExs(common.HexToHash(hash))
+	if err != nil {
+		l.Error(err, "eth_getUserOperationByHash error")
+		return nil, err
+	}
+	if len(exs) > 0 {
+		res.AltMempoolExceptions = exs
+	}
+
+	dappId, err := i.getOrigin(common.HexToHash(hash))
+	if err != nil {
+		l.Error(err, "eth_getUserOperationByHash error")
+		return nil, err
+	}
+	res.DappId = dappId
+
+	fp, err := i.getFingerprint(common.HexToHash(hash))
+	if err != nil {
+		l.Error(err, "eth_getUserOperationByHash error")
+		return nil, err
+	}
+	res.AccountFingerprint = fp
+
+	return res, nil
+}
+
+// GetUserOperationStatus returns the lifecycle status of a UserOperation based on a given userOpHash returned
+// by *Client.SendUserOperation. An op that has been submitted, or is unknown to this bundler, is checked for
+// an on-chain receipt so that ops included by other bundlers are also reported as included. Included ops are
+// persisted so that their status remains available after the receipt falls outside of the lookup range. Nil
+// is returned if the op is unknown and has no receipt.
+func (i *Client) GetUserOperationStatus(hash string) (*opstatus.Record, error) {
+	// Init logger
+	l := i.logger.WithName("bundler_getUserOperationStatus").WithValues("userop_hash", hash)
+
+	if !filter.IsValidUserOpHash(hash) {
+		//lint:ignore ST1005 This needs to match the bundler test spec.
+		err := errors.New("Missing/invalid userOpHash")
+		l.Error(err, "bundler_getUserOperationStatus error")
+		return nil, err
+	}
+
+	res, err := i.getUserOpStatus(common.HexToHash(hash))
+	if err != nil {
+		l.Error(err, "bundler_getUserOperationStatus error")
+		return nil, err
+	}
+	if res != nil && res.Status != opstatus.Submitted {
+		return res, nil
+	}
+
+	for _, ep := range i.supportedEntryPoints {
+		ev, err := i.getUserOpReceipt(hash, ep, i.opLookupLimit)
+		if err != nil {
+			l.Error(err, "bundler_getUserOperationStatus error")
+			return nil, err
+		} else if ev != nil {
+			res = &opstatus.Record{
+				Status:          opstatus.Included,
+				TransactionHash: ev.Receipt.TransactionHash.String(),
+			}
+			if err := i.putUserOpStatus(common.HexToHash(hash), res); err != nil {
+				l.Error(err, "bundler_getUserOperationStatus error")
+			}
+			return res, nil
+		}
+	}
+	return res, nil
+}
+
+// GetAltMempoolExceptions returns the alternative mempool rule exceptions that were applied when a
+// UserOperation was accepted by this bundler. An empty array means the op was accepted under the canonical
+// mempool rules or is unknown.
+func (i *Client) GetAltMempoolExceptions(hash string) ([]*altmempools.Exception, error) {
+	// Init logger
+	l := i.logger.WithName("debug_bundler_getAltMempoolExceptions").WithValues("userop_hash", hash)
+
+	if !filter.IsValidUserOpHash(hash) {
+		//lint:ignore ST1005 This needs to match the bundler test spec.
+		err := errors.New("Missing/invalid userOpHash")
+		l.Error(err, "debug_bundler_getAltMempoolExceptions error")
+		return nil, err
+	}
+
+	exs, err := i.getAltMempoolExs(common.HexToHash(hash))
+	if err != nil {
+		l.Error(err, "debug_bundler_getAltMempoolExceptions error")
+		return nil, err
+	}
+
+	return exs, nil
+}
+
+// SimulateAtBlock runs full validation of a UserOperation against the state at a given block. This is useful
+// for reproducing why an op was accepted or rejected in the past.
+func (i *Client) SimulateAtBlock(op map[string]any, blockNumber string) (*simulation.ValidationReport, error) {
+	// Init logger
+	l := i.logger.WithName("debug_bundler_simulateAtBlock").WithValues("block_number", blockNumber)
+
+	blk, err := hexutil.DecodeBig(blockNumber)
+	if err != nil {
+		err = fmt.Errorf("blockNumber: %w", err)
+		l.Error(err, "debug_bundler_simulateAtBlock error")
+		return nil, err
+	}
+	userOp, err := userop.New(op)
+	if err != nil {
+		l.Error(err, "debug_bundler_simulateAtBlock error")
+		return nil, err
+	}
+	ep := i.supportedEntryPoints[0]
+	l = l.WithValues("userop_hash", userOp.GetUserOpHash(ep, i.chainID))
+
+	res, err := i.simulateAtBlock(ep, i.chainID, userOp, blk)
+	if err != nil {
+		l.Error(err, "debug_bundler_simulateAtBlock error")
+		return nil, err
+	}
+
+	l.Info("debug_bundler_simulateAtBlock ok")
+	return res, nil
+}
+
+// GetUserOperationNonce returns the next nonce for a sender and a given 2D nonce key. The on-chain nonce from
+// the EntryPoint is incremented past any pending UserOperations in the mempool with the same key so that
+// wallets using parallel nonce keys can submit multiple ops without waiting for inclusion.
+func (i *Client) GetUserOperationNonce(sender string, key string, ep string) (string, error) {
+	// Init logger
+	l := i.logger.WithName("eth_getUserOperationNonce").WithValues("sender", sender).WithValues("key", key)
+
+	epAddr, err := i.parseEntryPointAddress(ep)
+	if err != nil {
+		l.Error(err, "eth_getUserOperationNonce error")
+		return "", err
+	}
+	if !common.IsHexAddress(sender) {
+		err := errors.New("sender: invalid address")
+		l.Error(err, "eth_getUserOperationNonce error")
+		return "", err
+	}
+	senderAddr := common.HexToAddress(sender)
+	k, err := hexutil.DecodeBig(key)
+	if err != nil {
+		l.Error(err, "eth_getUserOperationNonce error")
+		return "", err
+	}
+	if k.BitLen() > 192 {
+		err := errors.New("key: exceeds 192 bits")
+		l.Error(err, "eth_getUserOperationNonce error")
+		return "", err
+	}
+
+	n, err := i.getNonceFunc(epAddr, senderAddr, k)
+	if err != nil {
+		l.Error(err, "eth_getUserOperationNonce error")
+		return "", err
+	}
+
+	pso, err := i.mempool.GetOps(epAddr, senderAddr)
+	if err != nil {
+		l.Error(err, "eth_getUserOperationNonce error")
+		return "", err
+	}
+	pending := make(map[string]bool)
+	for _, op := range pso {
+		if op.GetNonceKey().Cmp(k) == 0 {
+			pending[op.Nonce.String()] = true
+		}
+	}
+	for pending[n.String()] {
+		n = big.NewInt(0).Add(n, common.Big1)
+	}
+
+	l.Info("eth_getUserOperationNonce ok")
+	return hexutil.EncodeBig(n), nil
+}
+
+// GetPendingUserOperations implements the method call for bundler_getPendingUserOperations. It returns all
+// UserOperations in the mempool for a given EntryPoint in order of arrival. This allows peer bundlers to sync
+// their mempool on a cold start.
+func (i *Client) GetPendingUserOperations(ep string) ([]map[string]any, error) {
+	// Init logger
+	l := i.logger.WithName("bundler_getPendingUserOperations")
+
+	epAddr, err := i.parseEntryPointAddress(ep)
+	if err != nil {
+		l.Error(err, "bundler_getPendingUserOperations error")
+		return []map[string]any{}, err
+	}
+
+	ops, err := i.mempool.Dump(epAddr)
+	if err != nil {
+		l.Error(err, "bundler_getPendingUserOperations error")
+		return []map[string]any{}, err
+	}
+
+	res := []map[string]any{}
+	for _, op := range ops {
+		item, err := op.ToMap()
+		if err != nil {
+			l.Error(err, "bundler_getPendingUserOperations error")
+			return []map[string]any{}, err
+		}
+		res = append(res, item)
+	}
+
+	l.Info("bundler_getPendingUserOperations ok")
 	return res, nil
 }
 
@@ -292,6 +957,32 @@ func (i *Client) SupportedEntryPoints() ([]string, error) {
 	return slc, nil
 }
 
+// GetInfo implements the method call for bundler_getInfo. It returns the identity and capabilities of the
+// bundler so that monitoring tools and aggregators can auto-discover supported features.
+func (i *Client) GetInfo() (*BundlerInfo, error) {
+	eps, err := i.SupportedEntryPoints()
+	if err != nil {
+		return nil, err
+	}
+
+	info := *i.info
+	info.ChainID = hexutil.EncodeBig(i.chainID)
+	info.SupportedEntryPoints = eps
+	return &info, nil
+}
+
+// GetFederationPeers returns the latest status beacons of federated bundlers. The result is empty if
+// federation is not enabled.
+func (i *Client) GetFederationPeers() ([]*federation.Beacon, error) {
+	return i.getFederationPeers(), nil
+}
+
+// GetGasUsageStats returns the gas usage of included ops per account implementation compared to their limits
+// and the bundler's estimates. The result is empty if gas feedback is not enabled.
+func (i *Client) GetGasUsageStats() ([]*gasfeedback.Stats, error) {
+	return i.getGasUsageStats()
+}
+
 // ChainID implements the method call for eth_chainId. It returns the current chainID used by the client.
 // This method is used to validate that the client's chainID is in sync with the caller.
 func (i *Client) ChainID() (string, error) {
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func newTestClient(t *testing.T, eps ...common.Address) *Client {
+	db := testutils.DBMock()
+	t.Cleanup(func() { db.Close() })
+	mem, err := mempool.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eps) == 0 {
+		eps = []common.Address{testutils.ValidAddress1}
+	}
+	return New(mem, gas.NewDefaultOverhead(), testutils.ChainID, eps, 1)
+}
+
+func newTestOp(sender common.Address) *userop.UserOperation {
+	op := testutils.MockValidInitUserOp()
+	op.Sender = sender
+	return op
+}
+
+func toMaps(t *testing.T, ops ...*userop.UserOperation) []map[string]any {
+	maps := []map[string]any{}
+	for _, op := range ops {
+		m, err := op.ToMap()
+		if err != nil {
+			t.Fatal(err)
+		}
+		maps = append(maps, m)
+	}
+	return maps
+}
@@ -1,3 +1,5 @@
+//go:build !nodebug
+
 package client
 
 import (
@@ -7,13 +9,33 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/stackup-wallet/stackup-bundler/pkg/bundler"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
 	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
+// DebugSupported is true if the binary was built with the debug namespace. It can be compiled out with the
+// nodebug tag.
+const DebugSupported = true
+
+// StakeInfo is the stake of an entity as returned by debug_bundler_getStakeStatus.
+type StakeInfo struct {
+	Addr            common.Address `json:"addr"`
+	Stake           *hexutil.Big   `json:"stake"`
+	UnstakeDelaySec hexutil.Uint64 `json:"unstakeDelaySec"`
+}
+
+// StakeStatus is the response of debug_bundler_getStakeStatus.
+type StakeStatus struct {
+	StakeInfo *StakeInfo `json:"stakeInfo"`
+	IsStaked  bool       `json:"isStaked"`
+}
+
 // Debug exposes methods used for testing the bundler. These should not be made available in production.
 type Debug struct {
 	eoa         *signer.EOA
@@ -48,6 +70,67 @@ func (d *Debug) ClearState() (string, error) {
 	return "ok", nil
 }
 
+// ClearMempool removes all UserOperations from the mempool without resetting reputation data.
+func (d *Debug) ClearMempool() (string, error) {
+	ops, err := d.mempool.Dump(d.entrypoint)
+	if err != nil {
+		return "", err
+	}
+	if err := d.mempool.RemoveOps(d.entrypoint, ops...); err != nil {
+		return "", err
+	}
+
+	return "ok", nil
+}
+
+// AddUserOps adds UserOperations directly to the mempool without running any validation. This is used to
+// set up mempool state that would otherwise be rejected by the Client.
+func (d *Debug) AddUserOps(ops []any, ep string) (string, error) {
+	epAddr := common.HexToAddress(ep)
+	if epAddr != d.entrypoint {
+		return "", fmt.Errorf("debug: unsupported entrypoint %s", ep)
+	}
+
+	for _, op := range ops {
+		data, ok := op.(map[string]any)
+		if !ok {
+			return "", errors.New("debug: userOp must be an object")
+		}
+
+		userOp, err := userop.New(data)
+		if err != nil {
+			return "", err
+		}
+		if err := d.mempool.AddOp(epAddr, userOp); err != nil {
+			return "", err
+		}
+	}
+
+	return "ok", nil
+}
+
+// GetStakeStatus returns the stake of an address in the EntryPoint and whether it meets the minimum required
+// by the bundler to be considered staked.
+func (d *Debug) GetStakeStatus(address string, ep string) (*StakeStatus, error) {
+	addr := common.HexToAddress(address)
+	if addr == common.HexToAddress("0x") {
+		return nil, errors.New("debug: address is required")
+	}
+	dep, err := stake.GetStakeWithEthClient(d.eth)(common.HexToAddress(ep), addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StakeStatus{
+		StakeInfo: &StakeInfo{
+			Addr:            addr,
+			Stake:           (*hexutil.Big)(dep.Stake),
+			UnstakeDelaySec: hexutil.Uint64(dep.UnstakeDelaySec),
+		},
+		IsStaked: d.rep.IsStaked(dep),
+	}, nil
+}
+
 // DumpMempool dumps the current UserOperations mempool in order of arrival.
 func (d *Debug) DumpMempool(ep string) ([]map[string]any, error) {
 	ops, err := d.mempool.Dump(common.HexToAddress(ep))
@@ -73,6 +156,16 @@ func (d *Debug) DumpMempool(ep string) ([]map[string]any, error) {
 	return res, nil
 }
 
+// QueryMempool returns a page of the current UserOperations mempool that match the filters in opts. See
+// mempool.ParseQuery for the supported options.
+func (d *Debug) QueryMempool(ep string, opts map[string]any) (*mempool.QueryResult, error) {
+	q, err := mempool.ParseQuery(opts)
+	if err != nil {
+		return nil, err
+	}
+	return d.mempool.Query(common.HexToAddress(ep), q)
+}
+
 // SendBundleNow forces the bundler to build and execute a bundle from the mempool as handleOps() transaction.
 func (d *Debug) SendBundleNow() (string, error) {
 	ctx, err := d.bundler.Process(d.entrypoint)
@@ -90,6 +183,12 @@ func (d *Debug) SendBundleNow() (string, error) {
 	return hash, nil
 }
 
+// SendBundleNowWithReceipt works the same as SendBundleNow but returns a receipt with the selected ops,
+// excluded ops and their reasons, estimated and actual gas, the transaction hash, and any builder responses.
+func (d *Debug) SendBundleNowWithReceipt() (*bundler.Receipt, error) {
+	return d.bundler.ProcessWithReceipt(d.entrypoint)
+}
+
 // SetBundlingMode allows the bundler to be stopped so that an explicit call to debug_bundler_sendBundleNow is
 // required to send a bundle.
 func (d *Debug) SetBundlingMode(mode string) (string, error) {
@@ -0,0 +1,35 @@
+//go:build nodebug
+
+package client
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/pkg/bundler"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+)
+
+// DebugSupported is false if the binary was built with the nodebug tag. The debug namespace is not routed and
+// debug mode cannot be enabled.
+const DebugSupported = false
+
+// Debug is a placeholder for builds without the debug namespace.
+type Debug struct{}
+
+// NewDebug always returns nil for builds without the debug namespace.
+func NewDebug(
+	eoa *signer.EOA,
+	eth *ethclient.Client,
+	mempool *mempool.Mempool,
+	rep *entities.Reputation,
+	bundler *bundler.Bundler,
+	chainID *big.Int,
+	entrypoint common.Address,
+	beneficiary common.Address,
+) *Debug {
+	return nil
+}
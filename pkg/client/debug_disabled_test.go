@@ -0,0 +1,29 @@
+//go:build nodebug
+
+package client
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TestDebugNamespaceCompiledOut verifies that no debug methods are routed by the RpcAdapter in builds with the
+// nodebug tag.
+func TestDebugNamespaceCompiledOut(t *testing.T) {
+	if DebugSupported {
+		t.Fatal("got true, want false")
+	}
+	if d := NewDebug(nil, nil, nil, nil, nil, nil, common.Address{}, common.Address{}); d != nil {
+		t.Fatalf("got %v, want nil", d)
+	}
+
+	rt := reflect.TypeOf(&RpcAdapter{})
+	for i := 0; i < rt.NumMethod(); i++ {
+		if name := rt.Method(i).Name; strings.HasPrefix(name, "Debug_") {
+			t.Fatalf("%s is routed, want compiled out", name)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+//go:build !nodebug
+
+package client
+
+import (
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+)
+
+func newTestDebug(t *testing.T) *Debug {
+	c := newTestClient(t)
+	return NewDebug(nil, nil, c.mempool, nil, nil, testutils.ChainID, testutils.ValidAddress1, testutils.ValidAddress1)
+}
+
+// TestAddUserOpsAndClearMempool verifies that ops are added to the mempool without validation and that the
+// mempool can be cleared.
+func TestAddUserOpsAndClearMempool(t *testing.T) {
+	d := newTestDebug(t)
+	ops := []any{}
+	for _, op := range toMaps(t, newTestOp(testutils.ValidAddress2), newTestOp(testutils.ValidAddress3)) {
+		ops = append(ops, op)
+	}
+	if _, err := d.AddUserOps(ops, testutils.ValidAddress1.String()); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	dump, err := d.DumpMempool(testutils.ValidAddress1.String())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if len(dump) != 2 {
+		t.Fatalf("got %d ops, want 2", len(dump))
+	}
+
+	if _, err := d.ClearMempool(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	dump, err = d.DumpMempool(testutils.ValidAddress1.String())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if len(dump) != 0 {
+		t.Fatalf("got %d ops, want 0", len(dump))
+	}
+}
+
+// TestAddUserOpsInvalidInput verifies that ops for another EntryPoint or that cannot be decoded are rejected.
+func TestAddUserOpsInvalidInput(t *testing.T) {
+	d := newTestDebug(t)
+	op := toMaps(t, newTestOp(testutils.ValidAddress2))[0]
+	if _, err := d.AddUserOps([]any{op}, testutils.ValidAddress2.String()); err == nil {
+		t.Fatal("got nil, want error for unsupported EntryPoint")
+	}
+	if _, err := d.AddUserOps([]any{"0x"}, testutils.ValidAddress1.String()); err == nil {
+		t.Fatal("got nil, want error for a userOp that is not an object")
+	}
+}
+
+// TestGetStakeStatusRequiresAddress verifies that the zero address is rejected.
+func TestGetStakeStatusRequiresAddress(t *testing.T) {
+	if _, err := newTestDebug(t).GetStakeStatus("0x", testutils.ValidAddress1.String()); err == nil {
+		t.Fatal("got nil, want error")
+	}
+}
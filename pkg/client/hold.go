@@ -0,0 +1,182 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+var (
+	// DefaultMaxHeldOps is the default max number of UserOperations held while the upstream node is syncing.
+	DefaultMaxHeldOps = 1000
+
+	// SyncCheckInterval is how often the upstream node is checked for sync status while ops are held.
+	SyncCheckInterval = 10 * time.Second
+
+	// SyncStatusTTL is how long the sync status of the upstream node is reused for new submissions.
+	SyncStatusTTL = time.Second
+)
+
+// IsSyncingFunc is a general interface for checking if the upstream node is still syncing.
+type IsSyncingFunc = func() (bool, error)
+
+// IsSyncingWithEthClient returns an implementation of IsSyncingFunc that relies on an eth client to check the
+// sync progress of the node.
+func IsSyncingWithEthClient(eth *ethclient.Client) IsSyncingFunc {
+	return func() (bool, error) {
+		p, err := eth.SyncProgress(context.Background())
+		if err != nil {
+			return false, err
+		}
+		return p != nil, nil
+	}
+}
+
+type heldOp struct {
+	ep     common.Address
+	op     *userop.UserOperation
+	dappId string
+}
+
+// holdQueue stores UserOperations accepted without validation while the upstream node is syncing. Held ops
+// are kept in memory only and will be lost on restart.
+type holdQueue struct {
+	isSyncing IsSyncingFunc
+	maxOps    int
+
+	mu     sync.Mutex
+	ops    []*heldOp
+	hashes map[common.Hash]bool
+	synced bool
+
+	statusMu  sync.Mutex
+	syncing   bool
+	checkedAt time.Time
+}
+
+// isSyncingCached returns the sync status of the upstream node. The status is reused for SyncStatusTTL so that
+// concurrent submissions result in a single call to the node.
+func (q *holdQueue) isSyncingCached() (bool, error) {
+	q.statusMu.Lock()
+	defer q.statusMu.Unlock()
+	if !q.checkedAt.IsZero() && time.Since(q.checkedAt) < SyncStatusTTL {
+		return q.syncing, nil
+	}
+
+	syncing, err := q.isSyncing()
+	if err != nil {
+		return false, err
+	}
+	q.syncing = syncing
+	q.checkedAt = time.Now()
+	return syncing, nil
+}
+
+// SetHoldDuringSync enables accepting UserOperations without validation while the upstream node is syncing.
+// Held ops are validated and added to the mempool once the node is synced. Submissions beyond maxOps are
+// rejected and a value of 0 will use DefaultMaxHeldOps. Once the node has been seen as synced, ops are no
+// longer held.
+//
+// The default is to always validate ops on submission.
+func (i *Client) SetHoldDuringSync(fn IsSyncingFunc, maxOps int) {
+	if maxOps <= 0 {
+		maxOps = DefaultMaxHeldOps
+	}
+	i.hold = &holdQueue{
+		isSyncing: fn,
+		maxOps:    maxOps,
+		ops:       []*heldOp{},
+		hashes:    make(map[common.Hash]bool),
+	}
+}
+
+// holdIfSyncing returns true if the op was held for validation after the upstream node has finished syncing.
+func (i *Client) holdIfSyncing(ep common.Address, op *userop.UserOperation, dappId string) (bool, error) {
+	if i.hold == nil {
+		return false, nil
+	}
+
+	q := i.hold
+	q.mu.Lock()
+	synced := q.synced
+	q.mu.Unlock()
+	if synced {
+		return false, nil
+	}
+
+	syncing, err := q.isSyncingCached()
+	if err != nil {
+		return false, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.synced {
+		return false, nil
+	} else if !syncing {
+		q.synced = true
+		return false, nil
+	}
+
+	hash := op.GetUserOpHash(ep, i.chainID)
+	if q.hashes[hash] {
+		return true, nil
+	} else if len(q.ops) >= q.maxOps {
+		return false, errors.New("node is syncing: max held UserOperations reached, try again later")
+	}
+	q.ops = append(q.ops, &heldOp{ep: ep, op: op, dappId: dappId})
+	q.hashes[hash] = true
+	return true, nil
+}
+
+// ReleaseHeldOps waits for the upstream node to finish syncing and then sends all held UserOperations through
+// the Client's regular validation process. Ops that fail validation are logged and dropped. This is a no-op
+// if ops are not held during sync.
+func (i *Client) ReleaseHeldOps() {
+	if i.hold == nil {
+		return
+	}
+
+	// Init logger
+	l := i.logger.WithName("release_held_ops")
+
+	q := i.hold
+	for {
+		syncing, err := q.isSyncing()
+		if err != nil {
+			l.Error(err, "release_held_ops error")
+		} else if !syncing {
+			break
+		}
+		time.Sleep(SyncCheckInterval)
+	}
+
+	q.mu.Lock()
+	q.synced = true
+	ops := q.ops
+	q.ops = []*heldOp{}
+	q.hashes = make(map[common.Hash]bool)
+	q.mu.Unlock()
+
+	count := 0
+	for _, h := range ops {
+		hash := h.op.GetUserOpHash(h.ep, i.chainID)
+		if err := i.addUserOperation(h.ep, h.op); err != nil {
+			l.WithValues("userop_hash", hash).Error(err, "release_held_ops error")
+			continue
+		}
+		if h.dappId != "" {
+			if err := i.recordOrigin(hash, h.dappId); err != nil {
+				l.WithValues("userop_hash", hash).Error(err, "release_held_ops origin error")
+			}
+		}
+		count++
+	}
+
+	l.WithValues("held", len(ops)).WithValues("accepted", count).Info("release_held_ops ok")
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+)
+
+func mempoolSize(t *testing.T, c *Client) int {
+	ops, err := c.mempool.Dump(testutils.ValidAddress1)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	return len(ops)
+}
+
+// TestHoldDuringSync verifies that ops are accepted without validation while the node is syncing and are
+// validated once it has synced.
+func TestHoldDuringSync(t *testing.T) {
+	syncing := true
+	validated := 0
+	c := newTestClient(t)
+	c.UseModules(func(ctx *modules.UserOpHandlerCtx) error {
+		validated++
+		return nil
+	})
+	c.SetHoldDuringSync(func() (bool, error) { return syncing, nil }, 0)
+
+	op := toMaps(t, newTestOp(testutils.ValidAddress2))[0]
+	ep := testutils.ValidAddress1.String()
+	if _, err := c.SendUserOperation(op, ep, map[string]any{}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if validated != 0 || mempoolSize(t, c) != 0 {
+		t.Fatalf("got %d validated and %d in mempool, want 0 and 0", validated, mempoolSize(t, c))
+	}
+
+	syncing = false
+	c.ReleaseHeldOps()
+	if validated != 1 || mempoolSize(t, c) != 1 {
+		t.Fatalf("got %d validated and %d in mempool, want 1 and 1", validated, mempoolSize(t, c))
+	}
+
+	// Ops are no longer held once the node has synced, even if it falls behind again.
+	syncing = true
+	if _, err := c.SendUserOperation(toMaps(t, newTestOp(testutils.ValidAddress3))[0], ep, map[string]any{}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if validated != 2 || mempoolSize(t, c) != 2 {
+		t.Fatalf("got %d validated and %d in mempool, want 2 and 2", validated, mempoolSize(t, c))
+	}
+}
+
+// TestHoldDuringSyncMaxOps verifies that new ops are rejected once the max number of held ops is reached but
+// that a resubmission of a held op is still accepted.
+func TestHoldDuringSyncMaxOps(t *testing.T) {
+	c := newTestClient(t)
+	c.SetHoldDuringSync(func() (bool, error) { return true, nil }, 1)
+
+	op := toMaps(t, newTestOp(testutils.ValidAddress2))[0]
+	ep := testutils.ValidAddress1.String()
+	if _, err := c.SendUserOperation(op, ep, map[string]any{}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if _, err := c.SendUserOperation(op, ep, map[string]any{}); err != nil {
+		t.Fatalf("got %v, want nil for a held op", err)
+	}
+	if _, err := c.SendUserOperation(toMaps(t, newTestOp(testutils.ValidAddress3))[0], ep, map[string]any{}); err == nil {
+		t.Fatal("got nil, want error")
+	}
+}
+
+// TestHoldDuringSyncCachesStatus verifies that the sync status of the node is reused for new submissions
+// within SyncStatusTTL.
+func TestHoldDuringSyncCachesStatus(t *testing.T) {
+	checks := 0
+	c := newTestClient(t)
+	c.SetHoldDuringSync(func() (bool, error) {
+		checks++
+		return true, nil
+	}, 0)
+
+	ep := testutils.ValidAddress1.String()
+	ops := toMaps(
+		t,
+		newTestOp(testutils.ValidAddress2),
+		newTestOp(testutils.ValidAddress3),
+		newTestOp(testutils.ValidAddress4),
+	)
+	for _, op := range ops {
+		if _, err := c.SendUserOperation(op, ep, map[string]any{}); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+	if checks != 1 {
+		t.Fatalf("got %d sync checks, want 1", checks)
+	}
+}
+
+// TestReleaseHeldOpsDropsInvalidOps verifies that held ops that fail validation are not added to the mempool.
+func TestReleaseHeldOpsDropsInvalidOps(t *testing.T) {
+	syncing := true
+	c := newTestClient(t)
+	c.UseModules(func(ctx *modules.UserOpHandlerCtx) error {
+		if ctx.UserOp.Sender == testutils.ValidAddress3 {
+			return errors.New("invalid op")
+		}
+		return nil
+	})
+	c.SetHoldDuringSync(func() (bool, error) { return syncing, nil }, 0)
+
+	ep := testutils.ValidAddress1.String()
+	for _, op := range toMaps(t, newTestOp(testutils.ValidAddress2), newTestOp(testutils.ValidAddress3)) {
+		if _, err := c.SendUserOperation(op, ep, map[string]any{}); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+
+	syncing = false
+	c.ReleaseHeldOps()
+	if mempoolSize(t, c) != 1 {
+		t.Fatalf("got %d in mempool, want 1", mempoolSize(t, c))
+	}
+}
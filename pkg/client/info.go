@@ -0,0 +1,15 @@
+package client
+
+import "github.com/ethereum/go-ethereum/common"
+
+// BundlerInfo describes the identity and capabilities of a running bundler instance.
+type BundlerInfo struct {
+	Version              string         `json:"version"`
+	Commit               string         `json:"commit"`
+	Mode                 string         `json:"mode"`
+	ChainID              string         `json:"chainId"`
+	SupportedEntryPoints []string       `json:"supportedEntryPoints"`
+	Executor             common.Address `json:"executor"`
+	ClientModules        []string       `json:"clientModules"`
+	BundlerModules       []string       `json:"bundlerModules"`
+}
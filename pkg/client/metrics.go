@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// UseMeter defines an opentelemetry meter object used by the Client to count accepted and rejected
+// UserOperations.
+func (i *Client) UseMeter(meter metric.Meter) error {
+	accepted, err := meter.Int64Counter("client_ops_accepted")
+	if err != nil {
+		return err
+	}
+	rejected, err := meter.Int64Counter("client_ops_rejected")
+	if err != nil {
+		return err
+	}
+
+	i.accepted = accepted
+	i.rejected = rejected
+	return nil
+}
+
+// recordSendResult counts the result of eth_sendUserOperation. Rejections are labeled by JSON-RPC error code
+// if one is set.
+func (i *Client) recordSendResult(err error) {
+	if i.accepted == nil || i.rejected == nil {
+		return
+	}
+
+	if err == nil {
+		i.accepted.Add(context.Background(), 1)
+		return
+	}
+	code := "internal"
+	var rpcErr interface{ Code() int }
+	if errors.As(err, &rpcErr) {
+		code = strconv.Itoa(rpcErr.Code())
+	}
+	i.rejected.Add(context.Background(), 1, metric.WithAttributes(attribute.String("code", code)))
+}
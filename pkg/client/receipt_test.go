@@ -0,0 +1,57 @@
+package client
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/filter"
+)
+
+// TestGetUserOperationReceiptSearchesAllEntryPoints verifies that every supported EntryPoint is searched in
+// order until a receipt is found.
+func TestGetUserOperationReceiptSearchesAllEntryPoints(t *testing.T) {
+	c := newTestClient(t, testutils.ValidAddress1, testutils.ValidAddress2, testutils.ValidAddress3)
+	searched := []common.Address{}
+	c.SetGetUserOpReceiptFunc(func(hash string, ep common.Address, blkRange uint64) (*filter.UserOperationReceipt, error) {
+		searched = append(searched, ep)
+		if ep == testutils.ValidAddress2 {
+			return &filter.UserOperationReceipt{EntryPoint: ep}, nil
+		}
+		return nil, nil
+	})
+
+	ev, err := c.GetUserOperationReceipt(testutils.MockHash)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if ev == nil || ev.EntryPoint != testutils.ValidAddress2 {
+		t.Fatalf("got %+v, want receipt from %s", ev, testutils.ValidAddress2)
+	}
+	if len(searched) != 2 {
+		t.Fatalf("got %d EntryPoints searched, want 2", len(searched))
+	}
+}
+
+// TestGetUserOperationReceiptNotFound verifies that nil is returned if no EntryPoint has a receipt and that an
+// error from any EntryPoint is returned.
+func TestGetUserOperationReceiptNotFound(t *testing.T) {
+	c := newTestClient(t, testutils.ValidAddress1, testutils.ValidAddress2)
+	c.SetGetUserOpReceiptFunc(func(hash string, ep common.Address, blkRange uint64) (*filter.UserOperationReceipt, error) {
+		return nil, nil
+	})
+	if ev, err := c.GetUserOperationReceipt(testutils.MockHash); ev != nil || err != nil {
+		t.Fatalf("got %+v and %v, want nil and nil", ev, err)
+	}
+
+	c.SetGetUserOpReceiptFunc(func(hash string, ep common.Address, blkRange uint64) (*filter.UserOperationReceipt, error) {
+		if ep == testutils.ValidAddress2 {
+			return nil, errors.New("filter error")
+		}
+		return nil, nil
+	})
+	if _, err := c.GetUserOperationReceipt(testutils.MockHash); err == nil {
+		t.Fatal("got nil, want error")
+	}
+}
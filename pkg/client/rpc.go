@@ -1,18 +1,39 @@
 package client
 
 import (
-	"errors"
-
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/filter"
+	"github.com/stackup-wallet/stackup-bundler/pkg/federation"
 	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gasfeedback"
+	"github.com/stackup-wallet/stackup-bundler/pkg/opstatus"
+	"github.com/stackup-wallet/stackup-bundler/pkg/paymaster"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
 // Named UserOperation type for jsonrpc package.
 type userOperation map[string]any
 
+// Named UserOperation or array of UserOperations type for jsonrpc package.
+type userOperationOrArray any
+
 // Named StateOverride type for jsonrpc package.
 type optional_stateOverride map[string]any
 
+// Named SignatureHint type for jsonrpc package.
+type optional_signatureHint map[string]any
+
+// Named SendOptions type for jsonrpc package.
+type optional_sendOptions map[string]any
+
+// Named paymaster context type for jsonrpc package.
+type optional_paymasterContext map[string]any
+
+// Named hash preimage options type for jsonrpc package.
+type optional_preimageOptions map[string]any
+
+// Named mempool query type for jsonrpc package.
+type optional_mempoolQuery map[string]any
+
 // RpcAdapter is an adapter for routing JSON-RPC method calls to the correct client functions.
 type RpcAdapter struct {
 	client *Client
@@ -24,9 +45,21 @@ func NewRpcAdapter(client *Client, debug *Debug) *RpcAdapter {
 	return &RpcAdapter{client, debug}
 }
 
-// Eth_sendUserOperation routes method calls to *Client.SendUserOperation.
-func (r *RpcAdapter) Eth_sendUserOperation(op userOperation, ep string) (string, error) {
-	return r.client.SendUserOperation(op, ep)
+// Eth_sendUserOperation routes method calls to *Client.SendUserOperation. If an array of UserOperations is
+// given, the call is routed to *Client.SendUserOperations instead.
+func (r *RpcAdapter) Eth_sendUserOperation(
+	op userOperationOrArray,
+	ep string,
+	opts optional_sendOptions,
+) (any, error) {
+	switch v := op.(type) {
+	case map[string]any:
+		return r.client.SendUserOperation(v, ep, opts)
+	case []any:
+		return r.client.SendUserOperations(v, ep, opts)
+	default:
+		return nil, errInvalidSendParam
+	}
 }
 
 // Eth_estimateUserOperationGas routes method calls to *Client.EstimateUserOperationGas.
@@ -34,8 +67,44 @@ func (r *RpcAdapter) Eth_estimateUserOperationGas(
 	op userOperation,
 	ep string,
 	os optional_stateOverride,
+	sh optional_signatureHint,
 ) (*gas.GasEstimates, error) {
-	return r.client.EstimateUserOperationGas(op, ep, os)
+	return r.client.EstimateUserOperationGas(op, ep, os, sh)
+}
+
+// Bundler_estimateSponsoredUserOperationGas routes method calls to *Client.EstimateSponsoredUserOperationGas.
+func (r *RpcAdapter) Bundler_estimateSponsoredUserOperationGas(
+	op userOperation,
+	ep string,
+	os optional_stateOverride,
+	sh optional_signatureHint,
+) (*gas.GasEstimates, error) {
+	return r.client.EstimateSponsoredUserOperationGas(op, ep, os, sh)
+}
+
+// Bundler_getErc20FeeQuote routes method calls to *Client.GetErc20FeeQuote.
+func (r *RpcAdapter) Bundler_getErc20FeeQuote(op userOperation, ep string) (*paymaster.TokenQuote, error) {
+	return r.client.GetErc20FeeQuote(op, ep)
+}
+
+// Pm_getPaymasterStubData routes method calls to *Client.GetPaymasterStubData.
+func (r *RpcAdapter) Pm_getPaymasterStubData(
+	op userOperation,
+	ep string,
+	chainId string,
+	ctx optional_paymasterContext,
+) (map[string]any, error) {
+	return r.client.GetPaymasterStubData(op, ep, chainId, ctx)
+}
+
+// Pm_getPaymasterData routes method calls to *Client.GetPaymasterData.
+func (r *RpcAdapter) Pm_getPaymasterData(
+	op userOperation,
+	ep string,
+	chainId string,
+	ctx optional_paymasterContext,
+) (map[string]any, error) {
+	return r.client.GetPaymasterData(op, ep, chainId, ctx)
 }
 
 // Eth_getUserOperationReceipt routes method calls to *Client.GetUserOperationReceipt.
@@ -52,6 +121,11 @@ func (r *RpcAdapter) Eth_getUserOperationByHash(
 	return r.client.GetUserOperationByHash(userOpHash)
 }
 
+// Eth_getUserOperationNonce routes method calls to *Client.GetUserOperationNonce.
+func (r *RpcAdapter) Eth_getUserOperationNonce(sender string, key string, ep string) (string, error) {
+	return r.client.GetUserOperationNonce(sender, key, ep)
+}
+
 // Eth_supportedEntryPoints routes method calls to *Client.SupportedEntryPoints.
 func (r *RpcAdapter) Eth_supportedEntryPoints() ([]string, error) {
 	return r.client.SupportedEntryPoints()
@@ -62,56 +136,41 @@ func (r *RpcAdapter) Eth_chainId() (string, error) {
 	return r.client.ChainID()
 }
 
-// Debug_bundler_clearState routes method calls to *Debug.ClearState.
-func (r *RpcAdapter) Debug_bundler_clearState() (string, error) {
-	if r.debug == nil {
-		return "", errors.New("rpc: debug mode is not enabled")
-	}
-
-	return r.debug.ClearState()
+// Bundler_getInfo routes method calls to *Client.GetInfo.
+func (r *RpcAdapter) Bundler_getInfo() (*BundlerInfo, error) {
+	return r.client.GetInfo()
 }
 
-// Debug_bundler_dumpMempool routes method calls to *Debug.DumpMempool.
-func (r *RpcAdapter) Debug_bundler_dumpMempool(ep string) ([]map[string]any, error) {
-	if r.debug == nil {
-		return []map[string]any{}, errors.New("rpc: debug mode is not enabled")
-	}
-
-	return r.debug.DumpMempool(ep)
+// Bundler_getUserOperationStatus routes method calls to *Client.GetUserOperationStatus.
+func (r *RpcAdapter) Bundler_getUserOperationStatus(userOpHash string) (*opstatus.Record, error) {
+	return r.client.GetUserOperationStatus(userOpHash)
 }
 
-// Debug_bundler_sendBundleNow routes method calls to *Debug.SendBundleNow.
-func (r *RpcAdapter) Debug_bundler_sendBundleNow() (string, error) {
-	if r.debug == nil {
-		return "", errors.New("rpc: debug mode is not enabled")
-	}
-
-	return r.debug.SendBundleNow()
+// Bundler_getGasUsageStats routes method calls to *Client.GetGasUsageStats.
+func (r *RpcAdapter) Bundler_getGasUsageStats() ([]*gasfeedback.Stats, error) {
+	return r.client.GetGasUsageStats()
 }
 
-// Debug_bundler_setBundlingMode routes method calls to *Debug.SetBundlingMode.
-func (r *RpcAdapter) Debug_bundler_setBundlingMode(mode string) (string, error) {
-	if r.debug == nil {
-		return "", errors.New("rpc: debug mode is not enabled")
-	}
-
-	return r.debug.SetBundlingMode(mode)
+// Bundler_getUserOperationHashPreimage routes method calls to *Client.GetUserOperationHashPreimage.
+func (r *RpcAdapter) Bundler_getUserOperationHashPreimage(
+	op userOperation,
+	ep string,
+	opts optional_preimageOptions,
+) (*userop.Preimage, error) {
+	return r.client.GetUserOperationHashPreimage(op, ep, opts)
 }
 
-// Debug_bundler_setReputation routes method calls to *Debug.SetReputation.
-func (r *RpcAdapter) Debug_bundler_setReputation(entries []any, ep string) (string, error) {
-	if r.debug == nil {
-		return "", errors.New("rpc: debug mode is not enabled")
-	}
-
-	return r.debug.SetReputation(entries, ep)
+// Bundler_getUserOperationsBySender routes method calls to *Client.GetUserOperationsBySender.
+func (r *RpcAdapter) Bundler_getUserOperationsBySender(sender string) (*SenderUserOperations, error) {
+	return r.client.GetUserOperationsBySender(sender)
 }
 
-// Debug_bundler_dumpReputation routes method calls to *Debug.DumpReputation.
-func (r *RpcAdapter) Debug_bundler_dumpReputation(ep string) ([]map[string]any, error) {
-	if r.debug == nil {
-		return []map[string]any{}, errors.New("rpc: debug mode is not enabled")
-	}
+// Bundler_getPendingUserOperations routes method calls to *Client.GetPendingUserOperations.
+func (r *RpcAdapter) Bundler_getPendingUserOperations(ep string) ([]map[string]any, error) {
+	return r.client.GetPendingUserOperations(ep)
+}
 
-	return r.debug.DumpReputation(ep)
+// Bundler_getFederationPeers routes method calls to *Client.GetFederationPeers.
+func (r *RpcAdapter) Bundler_getFederationPeers() ([]*federation.Beacon, error) {
+	return r.client.GetFederationPeers()
 }
@@ -0,0 +1,120 @@
+//go:build !nodebug
+
+package client
+
+import (
+	"errors"
+
+	"github.com/stackup-wallet/stackup-bundler/pkg/altmempools"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/simulation"
+)
+
+// Named bundle options type for jsonrpc package.
+type optional_bundleOptions map[string]any
+
+// Debug_bundler_simulateAtBlock routes method calls to *Client.SimulateAtBlock.
+func (r *RpcAdapter) Debug_bundler_simulateAtBlock(
+	op userOperation,
+	blockNumber string,
+) (*simulation.ValidationReport, error) {
+	if r.debug == nil {
+		return nil, errors.New("rpc: debug mode is not enabled")
+	}
+
+	return r.client.SimulateAtBlock(op, blockNumber)
+}
+
+// Debug_bundler_clearState routes method calls to *Debug.ClearState.
+func (r *RpcAdapter) Debug_bundler_clearState() (string, error) {
+	if r.debug == nil {
+		return "", errors.New("rpc: debug mode is not enabled")
+	}
+
+	return r.debug.ClearState()
+}
+
+// Debug_bundler_clearMempool routes method calls to *Debug.ClearMempool.
+func (r *RpcAdapter) Debug_bundler_clearMempool() (string, error) {
+	if r.debug == nil {
+		return "", errors.New("rpc: debug mode is not enabled")
+	}
+
+	return r.debug.ClearMempool()
+}
+
+// Debug_bundler_addUserOps routes method calls to *Debug.AddUserOps.
+func (r *RpcAdapter) Debug_bundler_addUserOps(ops []any, ep string) (string, error) {
+	if r.debug == nil {
+		return "", errors.New("rpc: debug mode is not enabled")
+	}
+
+	return r.debug.AddUserOps(ops, ep)
+}
+
+// Debug_bundler_getStakeStatus routes method calls to *Debug.GetStakeStatus.
+func (r *RpcAdapter) Debug_bundler_getStakeStatus(address string, ep string) (*StakeStatus, error) {
+	if r.debug == nil {
+		return nil, errors.New("rpc: debug mode is not enabled")
+	}
+
+	return r.debug.GetStakeStatus(address, ep)
+}
+
+// Debug_bundler_dumpMempool routes method calls to *Debug.DumpMempool. If a query is given, the call is
+// routed to *Debug.QueryMempool instead.
+func (r *RpcAdapter) Debug_bundler_dumpMempool(ep string, q optional_mempoolQuery) (any, error) {
+	if r.debug == nil {
+		return []map[string]any{}, errors.New("rpc: debug mode is not enabled")
+	}
+
+	if q != nil {
+		return r.debug.QueryMempool(ep, q)
+	}
+	return r.debug.DumpMempool(ep)
+}
+
+// Debug_bundler_sendBundleNow routes method calls to *Debug.SendBundleNow. If the options set receipt to
+// true, the call is routed to *Debug.SendBundleNowWithReceipt instead.
+func (r *RpcAdapter) Debug_bundler_sendBundleNow(opts optional_bundleOptions) (any, error) {
+	if r.debug == nil {
+		return "", errors.New("rpc: debug mode is not enabled")
+	}
+
+	if receipt, _ := opts["receipt"].(bool); receipt {
+		return r.debug.SendBundleNowWithReceipt()
+	}
+	return r.debug.SendBundleNow()
+}
+
+// Debug_bundler_setBundlingMode routes method calls to *Debug.SetBundlingMode.
+func (r *RpcAdapter) Debug_bundler_setBundlingMode(mode string) (string, error) {
+	if r.debug == nil {
+		return "", errors.New("rpc: debug mode is not enabled")
+	}
+
+	return r.debug.SetBundlingMode(mode)
+}
+
+// Debug_bundler_setReputation routes method calls to *Debug.SetReputation.
+func (r *RpcAdapter) Debug_bundler_setReputation(entries []any, ep string) (string, error) {
+	if r.debug == nil {
+		return "", errors.New("rpc: debug mode is not enabled")
+	}
+
+	return r.debug.SetReputation(entries, ep)
+}
+
+// Debug_bundler_dumpReputation routes method calls to *Debug.DumpReputation.
+func (r *RpcAdapter) Debug_bundler_dumpReputation(ep string) ([]map[string]any, error) {
+	if r.debug == nil {
+		return []map[string]any{}, errors.New("rpc: debug mode is not enabled")
+	}
+
+	return r.debug.DumpReputation(ep)
+}
+
+// Debug_bundler_getAltMempoolExceptions routes method calls to *Client.GetAltMempoolExceptions. This method is
+// read-only and is available without debug mode so that alternative mempool policies can be audited.
+func (r *RpcAdapter) Debug_bundler_getAltMempoolExceptions(userOpHash string) ([]*altmempools.Exception, error) {
+	return r.client.GetAltMempoolExceptions(userOpHash)
+}
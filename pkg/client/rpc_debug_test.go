@@ -0,0 +1,24 @@
+//go:build !nodebug
+
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestDebugNamespaceRouted verifies that the debug namespace is routed by the RpcAdapter and that calls fail
+// while debug mode is not enabled.
+func TestDebugNamespaceRouted(t *testing.T) {
+	if !DebugSupported {
+		t.Fatal("got false, want true")
+	}
+	if _, ok := reflect.TypeOf(&RpcAdapter{}).MethodByName("Debug_bundler_clearState"); !ok {
+		t.Fatal("Debug_bundler_clearState is not routed")
+	}
+
+	r := NewRpcAdapter(nil, nil)
+	if _, err := r.Debug_bundler_clearState(); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
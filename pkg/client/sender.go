@@ -0,0 +1,82 @@
+package client
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/filter"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+var (
+	// MaxIncludedOpsBySender is the max number of included UserOperations returned across all EntryPoints by
+	// *Client.GetUserOperationsBySender.
+	MaxIncludedOpsBySender = 50
+
+	// MaxBlockRangeBySender is the max number of recent blocks searched for included UserOperations by
+	// *Client.GetUserOperationsBySender. The configured op lookup limit is used if it is lower.
+	MaxBlockRangeBySender uint64 = 2000
+)
+
+// PendingUserOperation is a UserOperation from the mempool along with its hash and EntryPoint.
+type PendingUserOperation struct {
+	UserOperation *userop.UserOperation `json:"userOperation"`
+	EntryPoint    string                `json:"entryPoint"`
+	UserOpHash    common.Hash           `json:"userOpHash"`
+}
+
+// SenderUserOperations are all known UserOperations for a sender.
+type SenderUserOperations struct {
+	Pending  []*PendingUserOperation    `json:"pending"`
+	Included []*filter.HashLookupResult `json:"included"`
+}
+
+// GetUserOperationsBySender returns the pending UserOperations in the mempool and the most recently included
+// UserOperations for a given sender across all supported EntryPoints. Included ops from all EntryPoints are
+// sorted in order of inclusion by block number and log index. At most MaxIncludedOpsBySender included ops are
+// returned from the last MaxBlockRangeBySender blocks. This allows a wallet to reconstruct the state of an
+// account without maintaining its own index.
+func (i *Client) GetUserOperationsBySender(sender string) (*SenderUserOperations, error) {
+	// Init logger
+	l := i.logger.WithName("bundler_getUserOperationsBySender").WithValues("sender", sender)
+
+	if !common.IsHexAddress(sender) {
+		err := errors.New("sender: invalid address")
+		l.Error(err, "bundler_getUserOperationsBySender error")
+		return nil, err
+	}
+	addr := common.HexToAddress(sender)
+
+	res := &SenderUserOperations{
+		Pending:  []*PendingUserOperation{},
+		Included: []*filter.HashLookupResult{},
+	}
+	for _, ep := range i.supportedEntryPoints {
+		ops, err := i.mempool.GetOps(ep, addr)
+		if err != nil {
+			l.Error(err, "bundler_getUserOperationsBySender error")
+			return nil, err
+		}
+		for _, op := range ops {
+			res.Pending = append(res.Pending, &PendingUserOperation{
+				UserOperation: op,
+				EntryPoint:    ep.String(),
+				UserOpHash:    op.GetUserOpHash(ep, i.chainID),
+			})
+		}
+	}
+
+	blkRange := i.opLookupLimit
+	if blkRange > MaxBlockRangeBySender {
+		blkRange = MaxBlockRangeBySender
+	}
+	inc, err := i.getUserOpsBySender(addr, i.supportedEntryPoints, i.chainID, blkRange, MaxIncludedOpsBySender)
+	if err != nil {
+		l.Error(err, "bundler_getUserOperationsBySender error")
+		return nil, err
+	}
+	res.Included = append(res.Included, inc...)
+
+	l.Info("bundler_getUserOperationsBySender ok")
+	return res, nil
+}
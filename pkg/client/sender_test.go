@@ -0,0 +1,82 @@
+package client
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/filter"
+)
+
+// TestGetUserOperationsBySender verifies that pending ops from every supported EntryPoint are returned and that
+// included ops are fetched once for all EntryPoints with a bounded block range and limit.
+func TestGetUserOperationsBySender(t *testing.T) {
+	eps := []common.Address{testutils.ValidAddress1, testutils.ValidAddress2}
+	c := newTestClient(t, eps...)
+	c.opLookupLimit = MaxBlockRangeBySender * 10
+
+	sender := testutils.ValidAddress3
+	op := newTestOp(sender)
+	if err := c.mempool.AddOp(eps[1], op); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	calls := 0
+	included := []*filter.HashLookupResult{
+		{EntryPoint: eps[1].String(), BlockNumber: big.NewInt(1)},
+		{EntryPoint: eps[0].String(), BlockNumber: big.NewInt(2)},
+	}
+	c.SetGetUserOpsBySenderFunc(func(
+		s common.Address,
+		searched []common.Address,
+		chain *big.Int,
+		blkRange uint64,
+		limit int,
+	) ([]*filter.HashLookupResult, error) {
+		calls++
+		if s != sender || len(searched) != len(eps) {
+			t.Fatalf("got sender %s and %d EntryPoints, want %s and %d", s, len(searched), sender, len(eps))
+		}
+		if blkRange != MaxBlockRangeBySender || limit != MaxIncludedOpsBySender {
+			t.Fatalf("got range %d and limit %d, want %d and %d",
+				blkRange, limit, MaxBlockRangeBySender, MaxIncludedOpsBySender)
+		}
+		return included, nil
+	})
+
+	res, err := c.GetUserOperationsBySender(sender.String())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if calls != 1 {
+		t.Fatalf("got %d lookups, want 1", calls)
+	}
+	if len(res.Pending) != 1 || res.Pending[0].EntryPoint != eps[1].String() ||
+		res.Pending[0].UserOpHash != op.GetUserOpHash(eps[1], testutils.ChainID) {
+		t.Fatalf("got %+v, want 1 pending op on %s", res.Pending, eps[1])
+	}
+	if len(res.Included) != 2 || res.Included[0] != included[0] || res.Included[1] != included[1] {
+		t.Fatalf("got %+v, want %+v", res.Included, included)
+	}
+}
+
+// TestGetUserOperationsBySenderInvalidAddress verifies that an invalid sender returns an error without any
+// lookups.
+func TestGetUserOperationsBySenderInvalidAddress(t *testing.T) {
+	c := newTestClient(t)
+	c.SetGetUserOpsBySenderFunc(func(
+		s common.Address,
+		eps []common.Address,
+		chain *big.Int,
+		blkRange uint64,
+		limit int,
+	) ([]*filter.HashLookupResult, error) {
+		t.Fatal("got lookup, want none")
+		return nil, nil
+	})
+
+	if _, err := c.GetUserOperationsBySender("0x01"); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
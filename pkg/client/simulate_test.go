@@ -0,0 +1,49 @@
+package client
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/simulation"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// TestSimulateAtBlock verifies that the op is simulated on the preferred EntryPoint at the given block.
+func TestSimulateAtBlock(t *testing.T) {
+	c := newTestClient(t, testutils.ValidAddress1, testutils.ValidAddress2)
+	op := newTestOp(testutils.ValidAddress3)
+	c.SetSimulateAtBlockFunc(func(
+		ep common.Address,
+		chainID *big.Int,
+		uo *userop.UserOperation,
+		blockNumber *big.Int,
+	) (*simulation.ValidationReport, error) {
+		if ep != testutils.ValidAddress1 || uo.Sender != op.Sender {
+			t.Fatalf("got %s and %s, want %s and %s", ep, uo.Sender, testutils.ValidAddress1, op.Sender)
+		}
+		return &simulation.ValidationReport{BlockNumber: blockNumber}, nil
+	})
+
+	res, err := c.SimulateAtBlock(toMaps(t, op)[0], "0x10")
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if res.BlockNumber.Cmp(big.NewInt(16)) != 0 {
+		t.Fatalf("got block %s, want 16", res.BlockNumber)
+	}
+}
+
+// TestSimulateAtBlockInvalidInput verifies that a bad block number is rejected and that simulation fails if no
+// SimulateAtBlockFunc is set.
+func TestSimulateAtBlockInvalidInput(t *testing.T) {
+	c := newTestClient(t)
+	op := toMaps(t, newTestOp(testutils.ValidAddress2))[0]
+	if _, err := c.SimulateAtBlock(op, "16"); err == nil {
+		t.Fatal("got nil, want error for a block number that is not hex")
+	}
+	if _, err := c.SimulateAtBlock(op, "0x10"); err == nil {
+		t.Fatal("got nil, want error without a SimulateAtBlockFunc")
+	}
+}
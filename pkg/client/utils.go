@@ -12,10 +12,15 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/altmempools"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/filter"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/simulation"
+	"github.com/stackup-wallet/stackup-bundler/pkg/federation"
 	"github.com/stackup-wallet/stackup-bundler/pkg/fees"
 	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gasfeedback"
 	"github.com/stackup-wallet/stackup-bundler/pkg/meerchange"
+	"github.com/stackup-wallet/stackup-bundler/pkg/opstatus"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
 	"github.com/stackup-wallet/stackup-bundler/pkg/state"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
@@ -45,9 +50,9 @@ func getUserOpReceiptNoop() GetUserOpReceiptFunc {
 
 // GetUserOpReceiptWithEthClient returns an implementation of GetUserOpReceiptFunc that relies on an eth
 // client to fetch a UserOperationReceipt.
-func GetUserOpReceiptWithEthClient(eth *ethclient.Client) GetUserOpReceiptFunc {
+func GetUserOpReceiptWithEthClient(rpc *rpc.Client, eth *ethclient.Client) GetUserOpReceiptFunc {
 	return func(hash string, ep common.Address, blkRange uint64) (*filter.UserOperationReceipt, error) {
-		return filter.GetUserOperationReceipt(eth, hash, ep, blkRange)
+		return filter.GetUserOperationReceipt(rpc, eth, hash, ep, blkRange)
 	}
 }
 
@@ -108,6 +113,7 @@ func GetGasEstimateWithEthClient(
 	chain *big.Int,
 	maxGasLimit *big.Int,
 	tracer string,
+	vglMargin int64,
 ) GetGasEstimateFunc {
 	return func(
 		ep common.Address,
@@ -123,6 +129,7 @@ func GetGasEstimateWithEthClient(
 			ChainID:     chain,
 			MaxGasLimit: maxGasLimit,
 			Tracer:      tracer,
+			VGLMargin:   vglMargin,
 		})
 	}
 }
@@ -139,9 +146,125 @@ func getUserOpByHashNoop() GetUserOpByHashFunc {
 
 // GetUserOpByHashWithEthClient returns an implementation of GetUserOpByHashFunc that relies on an eth client
 // to fetch a UserOperation.
-func GetUserOpByHashWithEthClient(eth *ethclient.Client) GetUserOpByHashFunc {
+func GetUserOpByHashWithEthClient(rpc *rpc.Client, eth *ethclient.Client) GetUserOpByHashFunc {
 	return func(hash string, ep common.Address, chain *big.Int, blkRange uint64) (*filter.HashLookupResult, error) {
-		return filter.GetUserOperationByHash(eth, hash, ep, chain, blkRange)
+		return filter.GetUserOperationByHash(rpc, eth, hash, ep, chain, blkRange)
+	}
+}
+
+// GetUserOpsBySenderFunc is a general interface for fetching the most recently included UserOperations from a
+// sender given a list of EntryPoint addresses, chain ID, block range, and max number of results across all
+// EntryPoints.
+type GetUserOpsBySenderFunc = func(
+	sender common.Address,
+	eps []common.Address,
+	chain *big.Int,
+	blkRange uint64,
+	limit int,
+) ([]*filter.HashLookupResult, error)
+
+func getUserOpsBySenderNoop() GetUserOpsBySenderFunc {
+	return func(
+		sender common.Address,
+		eps []common.Address,
+		chain *big.Int,
+		blkRange uint64,
+		limit int,
+	) ([]*filter.HashLookupResult, error) {
+		return []*filter.HashLookupResult{}, nil
+	}
+}
+
+// GetUserOpsBySenderWithEthClient returns an implementation of GetUserOpsBySenderFunc that relies on an eth
+// client to fetch UserOperations by sender.
+func GetUserOpsBySenderWithEthClient(rpc *rpc.Client, eth *ethclient.Client) GetUserOpsBySenderFunc {
+	return func(
+		sender common.Address,
+		eps []common.Address,
+		chain *big.Int,
+		blkRange uint64,
+		limit int,
+	) ([]*filter.HashLookupResult, error) {
+		return filter.GetUserOperationsBySender(rpc, eth, sender, eps, chain, blkRange, limit)
+	}
+}
+
+// GetAltMempoolExceptionsFunc is a general interface for fetching the alternative mempool exceptions that
+// were applied to accept a UserOperation given its userOpHash.
+type GetAltMempoolExceptionsFunc = func(hash common.Hash) ([]*altmempools.Exception, error)
+
+func getAltMempoolExceptionsNoop() GetAltMempoolExceptionsFunc {
+	return func(hash common.Hash) ([]*altmempools.Exception, error) {
+		return []*altmempools.Exception{}, nil
+	}
+}
+
+// SimulateAtBlockFunc is a general interface for running full validation of a UserOperation against the
+// state at a given block.
+type SimulateAtBlockFunc = func(
+	ep common.Address,
+	chainID *big.Int,
+	op *userop.UserOperation,
+	blockNumber *big.Int,
+) (*simulation.ValidationReport, error)
+
+func simulateAtBlockNoop() SimulateAtBlockFunc {
+	return func(
+		ep common.Address,
+		chainID *big.Int,
+		op *userop.UserOperation,
+		blockNumber *big.Int,
+	) (*simulation.ValidationReport, error) {
+		return nil, errors.New("simulateAtBlock: not supported")
+	}
+}
+
+// RecordOriginFunc is a general interface for storing the dapp id of a UserOperation given its userOpHash.
+type RecordOriginFunc = func(hash common.Hash, dappId string) error
+
+func recordOriginNoop() RecordOriginFunc {
+	return func(hash common.Hash, dappId string) error {
+		return nil
+	}
+}
+
+// GetOriginFunc is a general interface for fetching the dapp id of a UserOperation given its userOpHash. An
+// empty string is returned if the op was not tagged.
+type GetOriginFunc = func(hash common.Hash) (string, error)
+
+func getOriginNoop() GetOriginFunc {
+	return func(hash common.Hash) (string, error) {
+		return "", nil
+	}
+}
+
+// GetFingerprintFunc is a general interface for fetching the account implementation fingerprint of a
+// UserOperation given its userOpHash. An empty string is returned if the op was not fingerprinted.
+type GetFingerprintFunc = func(hash common.Hash) (string, error)
+
+func getFingerprintNoop() GetFingerprintFunc {
+	return func(hash common.Hash) (string, error) {
+		return "", nil
+	}
+}
+
+// GetUserOpStatusFunc is a general interface for fetching the lifecycle status of a UserOperation given its
+// userOpHash. Nil is returned if the op is unknown.
+type GetUserOpStatusFunc = func(hash common.Hash) (*opstatus.Record, error)
+
+func getUserOpStatusNoop() GetUserOpStatusFunc {
+	return func(hash common.Hash) (*opstatus.Record, error) {
+		return nil, nil
+	}
+}
+
+// PutUserOpStatusFunc is a general interface for persisting the lifecycle status of a UserOperation given its
+// userOpHash.
+type PutUserOpStatusFunc = func(hash common.Hash, r *opstatus.Record) error
+
+func putUserOpStatusNoop() PutUserOpStatusFunc {
+	return func(hash common.Hash, r *opstatus.Record) error {
+		return nil
 	}
 }
 
@@ -210,3 +333,42 @@ func QngCrossMeerChange(
 		return tx.Hash().Hex(), nil
 	}
 }
+
+// GetFederationPeersFunc is a general interface for fetching the latest status beacons of federated bundlers.
+type GetFederationPeersFunc = func() []*federation.Beacon
+
+func getFederationPeersNoop() GetFederationPeersFunc {
+	return func() []*federation.Beacon {
+		return []*federation.Beacon{}
+	}
+}
+
+// RecordEstimateFunc is a general interface for storing the gas estimate of a UserOperation so that it can be
+// compared against its gas usage once included.
+type RecordEstimateFunc = func(ep common.Address, op *userop.UserOperation, est *gas.GasEstimates) error
+
+func recordEstimateNoop() RecordEstimateFunc {
+	return func(ep common.Address, op *userop.UserOperation, est *gas.GasEstimates) error {
+		return nil
+	}
+}
+
+// GetEstimatePaddingFunc is a general interface for fetching the percent to add to the callGasLimit estimate
+// of a UserOperation.
+type GetEstimatePaddingFunc = func(op *userop.UserOperation) (int, error)
+
+func getEstimatePaddingNoop() GetEstimatePaddingFunc {
+	return func(op *userop.UserOperation) (int, error) {
+		return 0, nil
+	}
+}
+
+// GetGasUsageStatsFunc is a general interface for fetching the gas usage stats of each account
+// implementation.
+type GetGasUsageStatsFunc = func() ([]*gasfeedback.Stats, error)
+
+func getGasUsageStatsNoop() GetGasUsageStatsFunc {
+	return func() ([]*gasfeedback.Stats, error) {
+		return []*gasfeedback.Stats{}, nil
+	}
+}
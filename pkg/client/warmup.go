@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	// WarmUpTimeout is the max duration to wait for a peer to return its pending UserOperations.
+	WarmUpTimeout = 30 * time.Second
+)
+
+func fetchPeerOps(url string, ep string) ([]map[string]any, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), WarmUpTimeout)
+	defer cancel()
+
+	peer, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer peer.Close()
+
+	// Fallback to the debug dump for peers that do not support the sync RPC.
+	ops := []map[string]any{}
+	if err := peer.CallContext(ctx, &ops, "bundler_getPendingUserOperations", ep); err != nil {
+		if err := peer.CallContext(ctx, &ops, "debug_bundler_dumpMempool", ep); err != nil {
+			return nil, err
+		}
+	}
+	return ops, nil
+}
+
+// WarmUp fetches pending UserOperations from the given peer bundlers and sends each one through the Client's
+// regular validation process. This is a no-op if the local mempool is not empty. It returns the number of
+// UserOperations that were accepted. Invalid ops or failures from a single peer are logged and skipped.
+func (i *Client) WarmUp(peers []string) (int, error) {
+	// Init logger
+	l := i.logger.WithName("mempool_warm_up")
+
+	for _, ep := range i.supportedEntryPoints {
+		ops, err := i.mempool.Dump(ep)
+		if err != nil {
+			l.Error(err, "mempool warm up error")
+			return 0, err
+		}
+		if len(ops) > 0 {
+			return 0, nil
+		}
+	}
+
+	accepted := 0
+	for _, url := range peers {
+		for _, ep := range i.supportedEntryPoints {
+			pl := l.WithValues("peer", url).WithValues("entrypoint", ep.String())
+			ops, err := fetchPeerOps(url, ep.String())
+			if err != nil {
+				pl.Error(err, "mempool warm up error")
+				continue
+			}
+
+			count := 0
+			for _, op := range ops {
+				if _, err := i.SendUserOperation(op, ep.String(), map[string]any{}); err == nil {
+					count++
+				}
+			}
+			accepted += count
+			pl.WithValues("fetched", len(ops)).WithValues("accepted", count).Info("mempool warm up ok")
+		}
+	}
+
+	return accepted, nil
+}
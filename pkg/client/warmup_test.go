@@ -0,0 +1,100 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+)
+
+// TestWarmUpFromPeers verifies that pending ops are fetched from each peer, using the debug dump for peers
+// that do not support bundler_getPendingUserOperations, and added to an empty mempool.
+func TestWarmUpFromPeers(t *testing.T) {
+	op1 := newTestOp(testutils.ValidAddress2)
+	op2 := newTestOp(testutils.ValidAddress3)
+	peer1 := testutils.RpcMock(testutils.MethodMocks{
+		"bundler_getPendingUserOperations": toMaps(t, op1),
+	})
+	defer peer1.Close()
+	peer2 := testutils.RpcMock(testutils.MethodMocks{
+		"debug_bundler_dumpMempool": toMaps(t, op2),
+	})
+	defer peer2.Close()
+
+	c := newTestClient(t)
+	accepted, err := c.WarmUp([]string{peer1.URL, peer2.URL})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if accepted != 2 {
+		t.Fatalf("got %d accepted, want 2", accepted)
+	}
+
+	ops, err := c.mempool.Dump(testutils.ValidAddress1)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if len(ops) != 2 {
+		t.Fatalf("got %d ops in mempool, want 2", len(ops))
+	}
+}
+
+// TestWarmUpSkipsNonEmptyMempool verifies that peers are not contacted if the mempool already has ops.
+func TestWarmUpSkipsNonEmptyMempool(t *testing.T) {
+	c := newTestClient(t)
+	if err := c.mempool.AddOp(testutils.ValidAddress1, newTestOp(testutils.ValidAddress2)); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	peer := testutils.RpcMock(testutils.MethodMocks{
+		"bundler_getPendingUserOperations": toMaps(t, newTestOp(testutils.ValidAddress3)),
+	})
+	defer peer.Close()
+
+	accepted, err := c.WarmUp([]string{peer.URL})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if accepted != 0 {
+		t.Fatalf("got %d accepted, want 0", accepted)
+	}
+}
+
+// TestWarmUpSkipsFailedPeers verifies that a peer that cannot be reached does not stop the warm up.
+func TestWarmUpSkipsFailedPeers(t *testing.T) {
+	bad := testutils.RpcMock(testutils.MethodMocks{})
+	defer bad.Close()
+	good := testutils.RpcMock(testutils.MethodMocks{
+		"bundler_getPendingUserOperations": toMaps(t, newTestOp(testutils.ValidAddress2)),
+	})
+	defer good.Close()
+
+	accepted, err := newTestClient(t).WarmUp([]string{bad.URL, good.URL})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if accepted != 1 {
+		t.Fatalf("got %d accepted, want 1", accepted)
+	}
+}
+
+// TestGetPendingUserOperations verifies that bundler_getPendingUserOperations returns the ops in the mempool
+// for the EntryPoint.
+func TestGetPendingUserOperations(t *testing.T) {
+	c := newTestClient(t)
+	op := newTestOp(testutils.ValidAddress2)
+	if err := c.mempool.AddOp(testutils.ValidAddress1, op); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	ops, err := c.GetPendingUserOperations(testutils.ValidAddress1.String())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if len(ops) != 1 || ops[0]["sender"] != op.Sender.String() {
+		t.Fatalf("got %v, want op from %s", ops, op.Sender)
+	}
+
+	if _, err := c.GetPendingUserOperations(testutils.ValidAddress2.String()); err == nil {
+		t.Fatal("got nil, want error for unsupported EntryPoint")
+	}
+}
@@ -0,0 +1,88 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Mock is a virtual Clock that only moves forward when Add or Set is called. Tickers created by a Mock fire
+// once for each interval that is crossed. Like time.Ticker, ticks are dropped if the receiver falls behind.
+type Mock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*mockTicker
+}
+
+type mockTicker struct {
+	c       chan time.Time
+	d       time.Duration
+	next    time.Time
+	stopped bool
+	mock    *Mock
+}
+
+// NewMock returns a Mock set to the given time.
+func NewMock(now time.Time) *Mock {
+	return &Mock{now: now}
+}
+
+// Now returns the current virtual time.
+func (m *Mock) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.now
+}
+
+// Since returns the virtual time elapsed since t.
+func (m *Mock) Since(t time.Time) time.Duration {
+	return m.Now().Sub(t)
+}
+
+// NewTicker returns a Ticker that fires every d of virtual time.
+func (m *Mock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := &mockTicker{c: make(chan time.Time, 1), d: d, next: m.now.Add(d), mock: m}
+	m.tickers = append(m.tickers, t)
+	return t
+}
+
+// Add moves the virtual time forward by d and fires any tickers that are due.
+func (m *Mock) Add(d time.Duration) {
+	m.Set(m.Now().Add(d))
+}
+
+// Set moves the virtual time to t and fires any tickers that are due. Setting a time in the past does not fire
+// any tickers.
+func (m *Mock) Set(t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.now = t
+	for _, tk := range m.tickers {
+		for !tk.stopped && !tk.next.After(m.now) {
+			select {
+			case tk.c <- tk.next:
+			default:
+			}
+			tk.next = tk.next.Add(tk.d)
+		}
+	}
+}
+
+func (t *mockTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *mockTicker) Stop() {
+	t.mock.mu.Lock()
+	defer t.mock.mu.Unlock()
+
+	t.stopped = true
+}
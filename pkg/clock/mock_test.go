@@ -0,0 +1,57 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+// TestMockAdd verifies that a Mock only moves forward when Add is called.
+func TestMockAdd(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	m := NewMock(start)
+	if !m.Now().Equal(start) {
+		t.Fatalf("got %v, want %v", m.Now(), start)
+	}
+
+	m.Add(time.Minute)
+	if got := m.Since(start); got != time.Minute {
+		t.Fatalf("got %v, want %v", got, time.Minute)
+	}
+}
+
+// TestMockTicker verifies that a ticker fires once per interval crossed and drops ticks if the receiver falls
+// behind.
+func TestMockTicker(t *testing.T) {
+	m := NewMock(time.Unix(0, 0))
+	tk := m.NewTicker(time.Second)
+
+	m.Add(500 * time.Millisecond)
+	select {
+	case <-tk.C():
+		t.Fatal("got tick, want none before interval")
+	default:
+	}
+
+	m.Add(3 * time.Second)
+	select {
+	case got := <-tk.C():
+		if !got.Equal(time.Unix(1, 0)) {
+			t.Fatalf("got %v, want first tick at 1s", got)
+		}
+	default:
+		t.Fatal("got no tick, want one")
+	}
+	select {
+	case <-tk.C():
+		t.Fatal("got second tick, want dropped")
+	default:
+	}
+
+	tk.Stop()
+	m.Add(time.Hour)
+	select {
+	case <-tk.C():
+		t.Fatal("got tick, want none after stop")
+	default:
+	}
+}
@@ -0,0 +1,114 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func TestQuerySnapshots(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := mempool.New(db)
+	ep := testutils.ValidAddress1
+	if err := mem.AddOp(ep, testutils.MockValidInitUserOp()); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := New(mem, []common.Address{ep})
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{testutils.MockValidInitUserOp()},
+		ep,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+	_ = rec.TrackBundles(func(ctx *modules.BatchHandlerCtx) error { return nil })(ctx)
+	_ = rec.TrackBundles(func(ctx *modules.BatchHandlerCtx) error { return transaction.ErrFailedStatus })(ctx)
+	_ = rec.TrackBundles(func(ctx *modules.BatchHandlerCtx) error { return errors.New("boom") })(ctx)
+
+	now := time.Now()
+	if err := rec.Snapshot(now); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	Routes(r, rec)
+	body, _ := json.Marshal(gin.H{
+		"range": gin.H{"from": now.Add(-time.Minute), "to": now.Add(time.Minute)},
+		"targets": []gin.H{
+			{"target": MempoolDepth},
+			{"target": Bundles},
+			{"target": BundleReverts},
+			{"target": BundleFailures},
+		},
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/query", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var res []queryResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 4 {
+		t.Fatalf("got %d series, want 4", len(res))
+	}
+	for _, s := range res {
+		if len(s.Datapoints) != 1 || s.Datapoints[0][0] != 1 {
+			t.Fatalf("%s: got %v, want a single datapoint with value 1", s.Target, s.Datapoints)
+		}
+		if int64(s.Datapoints[0][1]) != now.UnixMilli() {
+			t.Fatalf("%s: got timestamp %v, want %d", s.Target, s.Datapoints[0][1], now.UnixMilli())
+		}
+	}
+}
+
+func TestSnapshotResetsCounters(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := mempool.New(db)
+	rec := New(mem, []common.Address{})
+
+	rec.inc(Bundles)
+	now := time.Now()
+	_ = rec.Snapshot(now)
+	_ = rec.Snapshot(now.Add(time.Second))
+
+	pts := rec.Range(Bundles, now, now.Add(time.Second))
+	if len(pts) != 2 || pts[0][0] != 1 || pts[1][0] != 0 {
+		t.Fatalf("got %v, want values 1 then 0", pts)
+	}
+}
+
+func TestSnapshotRetention(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := mempool.New(db)
+	rec := New(mem, []common.Address{})
+	rec.SetRetention(time.Minute)
+
+	now := time.Now()
+	_ = rec.Snapshot(now.Add(-2 * time.Minute))
+	_ = rec.Snapshot(now)
+
+	if pts := rec.Range(MempoolDepth, now.Add(-time.Hour), now); len(pts) != 1 {
+		t.Fatalf("got %d points, want 1", len(pts))
+	}
+}
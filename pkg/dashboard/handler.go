@@ -0,0 +1,81 @@
+package dashboard
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type queryRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+type queryTarget struct {
+	Target string `json:"target"`
+}
+
+type queryRequest struct {
+	Range   queryRange    `json:"range"`
+	Targets []queryTarget `json:"targets"`
+}
+
+type queryResponse struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// Routes registers the endpoints required by the Grafana JSON datasource plugin on the router.
+func Routes(r gin.IRoutes, rec *Recorder) {
+	r.GET("/", Health())
+	r.POST("/search", Search())
+	r.POST("/metrics", Metrics())
+	r.POST("/query", Query(rec))
+}
+
+// Health returns a handler used by Grafana to test the connection to the datasource.
+func Health() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		g.Status(http.StatusOK)
+	}
+}
+
+// Search returns a handler that responds with the names of all recorded series.
+func Search() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		g.JSON(http.StatusOK, Series)
+	}
+}
+
+// Metrics returns a handler that responds with all recorded series as selectable metrics.
+func Metrics() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		res := []gin.H{}
+		for _, name := range Series {
+			res = append(res, gin.H{"label": name, "value": name})
+		}
+		g.JSON(http.StatusOK, res)
+	}
+}
+
+// Query returns a handler that responds with the datapoints of each requested series within the time range.
+// Datapoints are pairs of value and unix timestamp in milliseconds.
+func Query(rec *Recorder) gin.HandlerFunc {
+	return func(g *gin.Context) {
+		var req queryRequest
+		if err := g.ShouldBindJSON(&req); err != nil {
+			g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		res := []queryResponse{}
+		for _, t := range req.Targets {
+			res = append(res, queryResponse{
+				Target:     t.Target,
+				Datapoints: rec.Range(t.Target, req.Range.From, req.Range.To),
+			})
+		}
+		g.JSON(http.StatusOK, res)
+	}
+}
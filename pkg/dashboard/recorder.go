@@ -0,0 +1,165 @@
+// Package dashboard records time-series snapshots of the bundler and serves them through an HTTP API that is
+// compatible with the Grafana JSON datasource plugin. This allows operators to build dashboards without
+// running an OpenTelemetry collector.
+package dashboard
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+)
+
+const (
+	// MempoolDepth is the number of pending UserOperations across all EntryPoints.
+	MempoolDepth = "mempool_depth"
+
+	// Bundles is the number of bundles sent successfully within each interval.
+	Bundles = "bundles"
+
+	// BundleReverts is the number of bundles included with a failed status within each interval.
+	BundleReverts = "bundle_reverts"
+
+	// BundleFailures is the number of bundles that failed to send for any other reason within each interval.
+	BundleFailures = "bundle_failures"
+)
+
+var (
+	// Series is the list of all series recorded.
+	Series = []string{MempoolDepth, Bundles, BundleReverts, BundleFailures}
+
+	DefaultInterval  = 10 * time.Second
+	DefaultRetention = 24 * time.Hour
+)
+
+type point struct {
+	value float64
+	at    time.Time
+}
+
+// Recorder periodically takes snapshots of the bundler's state and keeps them in memory for the retention
+// period.
+type Recorder struct {
+	mem         *mempool.Mempool
+	entryPoints []common.Address
+	interval    time.Duration
+	retention   time.Duration
+
+	mu       sync.Mutex
+	counters map[string]int64
+	series   map[string][]point
+}
+
+// New returns a Recorder for the mempool and supported EntryPoints.
+func New(mem *mempool.Mempool, entryPoints []common.Address) *Recorder {
+	return &Recorder{
+		mem:         mem,
+		entryPoints: entryPoints,
+		interval:    DefaultInterval,
+		retention:   DefaultRetention,
+		counters:    make(map[string]int64),
+		series:      make(map[string][]point),
+	}
+}
+
+// SetInterval sets the time between snapshots. The default value is 10 seconds.
+func (r *Recorder) SetInterval(interval time.Duration) {
+	r.interval = interval
+}
+
+// SetRetention sets how long snapshots are kept in memory. The default value is 24 hours.
+func (r *Recorder) SetRetention(retention time.Duration) {
+	r.retention = retention
+}
+
+func (r *Recorder) inc(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.counters[name]++
+}
+
+// TrackBundles wraps the BatchHandler that sends bundles in order to count successes, reverts, and failures.
+// It is safe to call on a nil Recorder, in which case send is returned unchanged.
+func (r *Recorder) TrackBundles(send modules.BatchHandlerFunc) modules.BatchHandlerFunc {
+	if r == nil {
+		return send
+	}
+
+	return func(ctx *modules.BatchHandlerCtx) error {
+		if len(ctx.Batch) == 0 {
+			return send(ctx)
+		}
+
+		err := send(ctx)
+		if err == nil {
+			r.inc(Bundles)
+		} else if errors.Is(err, transaction.ErrFailedStatus) {
+			r.inc(BundleReverts)
+		} else {
+			r.inc(BundleFailures)
+		}
+		return err
+	}
+}
+
+// Snapshot records the current value of every series and resets the interval counters.
+func (r *Recorder) Snapshot(now time.Time) error {
+	depth := 0
+	for _, ep := range r.entryPoints {
+		batch, err := r.mem.Dump(ep)
+		if err != nil {
+			return err
+		}
+		depth += len(batch)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	values := map[string]float64{MempoolDepth: float64(depth)}
+	for _, name := range []string{Bundles, BundleReverts, BundleFailures} {
+		values[name] = float64(r.counters[name])
+		r.counters[name] = 0
+	}
+
+	cutoff := now.Add(-r.retention)
+	for name, value := range values {
+		pts := append(r.series[name], point{value: value, at: now})
+		i := 0
+		for i < len(pts) && pts[i].at.Before(cutoff) {
+			i++
+		}
+		r.series[name] = pts[i:]
+	}
+	return nil
+}
+
+// Run starts a goroutine that takes a snapshot on every interval.
+func (r *Recorder) Run() {
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			_ = r.Snapshot(now)
+		}
+	}()
+}
+
+// Range returns the points of a series between from and to, inclusive.
+func (r *Recorder) Range(name string, from time.Time, to time.Time) [][2]float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := [][2]float64{}
+	for _, p := range r.series[name] {
+		if p.at.Before(from) || p.at.After(to) {
+			continue
+		}
+		out = append(out, [2]float64{p.value, float64(p.at.UnixMilli())})
+	}
+	return out
+}
@@ -0,0 +1,149 @@
+package delegate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/ginutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+)
+
+const (
+	// SignatureHeader is the HTTP header a relayer sets to the hex encoded signature of the request envelope.
+	SignatureHeader = "X-Relayer-Signature"
+
+	// TimestampHeader is the HTTP header a relayer sets to the unix timestamp in seconds that was signed.
+	TimestampHeader = "X-Relayer-Timestamp"
+)
+
+var (
+	// MaxClockSkew is the max difference between a signed timestamp and the bundler's clock. This limits the
+	// window in which a captured request can be replayed.
+	MaxClockSkew = 5 * time.Minute
+)
+
+// parseRequests returns the number of UserOperations sent with eth_sendUserOperation in a single or batch
+// request along with the id of the first call.
+func parseRequests(body []byte) (ops int, id any) {
+	for i, r := range jsonrpc.ParseRequests(body) {
+		if i == 0 {
+			id = r.Id
+		}
+		if r.Method != jsonrpc.SendUserOperationMethod {
+			continue
+		}
+
+		// An array of UserOperations counts each op towards the quota.
+		if n := len(r.UserOps()); n > 0 {
+			ops += n
+		} else {
+			ops++
+		}
+	}
+	return ops, id
+}
+
+// EnvelopeHash returns the EIP-191 hash a relayer must sign for a request body and timestamp. The signed
+// message is "<timestamp>:<keccak256(body)>".
+func EnvelopeHash(body []byte, timestamp int64) []byte {
+	msg := fmt.Sprintf("%d:%s", timestamp, crypto.Keccak256Hash(body).Hex())
+	return accounts.TextHash([]byte(msg))
+}
+
+func recoverRelayer(body []byte, timestamp int64, sig []byte) (common.Address, error) {
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("invalid signature length %d", len(sig))
+	}
+	s := append([]byte{}, sig...)
+	if s[crypto.RecoveryIDOffset] >= 27 {
+		s[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pub, err := crypto.SigToPub(EnvelopeHash(body, timestamp), s)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// Middleware returns a gin middleware that authenticates requests signed by a registered relayer. Requests
+// without a signature header are passed through unchanged. Signed requests are rejected if the signature is
+// invalid, the timestamp is outside of MaxClockSkew, the signer is not registered, or the relayer has
+// exceeded its quota. Otherwise the relayer address is set in the gin context so that downstream middleware
+// can apply limits to the relayer instead of the client IP.
+func Middleware(l logr.Logger, relayers ...*Relayer) gin.HandlerFunc {
+	l = l.WithName("delegate")
+	registered := make(map[common.Address]*Relayer)
+	for _, r := range relayers {
+		registered[r.Address] = r
+	}
+	q := newQuotas()
+
+	return func(g *gin.Context) {
+		sigHex := g.GetHeader(SignatureHeader)
+		if sigHex == "" {
+			g.Next()
+			return
+		}
+
+		body, err := io.ReadAll(g.Request.Body)
+		if err != nil {
+			_ = g.Error(err)
+			g.Abort()
+			return
+		}
+		g.Request.Body = io.NopCloser(bytes.NewReader(body))
+		ops, id := parseRequests(body)
+
+		ts, err := strconv.ParseInt(g.GetHeader(TimestampHeader), 10, 64)
+		if err != nil {
+			jsonrpc.AbortWithError(g, errors.UNAUTHORIZED_RELAYER, "relayer: invalid timestamp", id)
+			return
+		}
+		now := time.Now()
+		if skew := now.Sub(time.Unix(ts, 0)); skew > MaxClockSkew || skew < -MaxClockSkew {
+			jsonrpc.AbortWithError(g, errors.UNAUTHORIZED_RELAYER, "relayer: timestamp outside of allowed clock skew", id)
+			return
+		}
+
+		sig, err := hexutil.Decode(sigHex)
+		if err != nil {
+			jsonrpc.AbortWithError(g, errors.UNAUTHORIZED_RELAYER, "relayer: invalid signature", id)
+			return
+		}
+		addr, err := recoverRelayer(body, ts, sig)
+		if err != nil {
+			jsonrpc.AbortWithError(g, errors.UNAUTHORIZED_RELAYER, "relayer: invalid signature", id)
+			return
+		}
+		r, ok := registered[addr]
+		if !ok {
+			jsonrpc.AbortWithError(g, errors.UNAUTHORIZED_RELAYER, fmt.Sprintf("relayer: %s not registered", addr), id)
+			return
+		}
+
+		if !q.take(r, ops, now) {
+			l.Info("relayer quota exceeded", "relayer", addr.String())
+			jsonrpc.AbortWithError(
+				g,
+				errors.BANNED_OR_THROTTLED_ENTITY,
+				fmt.Sprintf("relayer: %s exceeded quota of %d ops per minute", addr, r.OpsPerMinute),
+				id,
+			)
+			return
+		}
+
+		g.Set(ginutils.RelayerContextKey, addr.String())
+		g.Next()
+	}
+}
@@ -0,0 +1,124 @@
+package delegate
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/ginutils"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+)
+
+var sendBody = []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_sendUserOperation","params":[{},"0x"]}`)
+
+func newRouter(relayers ...*Relayer) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/", Middleware(logr.Discard(), relayers...), func(g *gin.Context) {
+		relayer, _ := ginutils.GetRelayer(g)
+		g.JSON(http.StatusOK, gin.H{"relayer": relayer})
+	})
+	return r
+}
+
+func signedRequest(t *testing.T, body []byte, ts int64) *http.Request {
+	sig, err := crypto.Sign(EnvelopeHash(body, ts), testutils.DummyEOA.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set(SignatureHeader, hexutil.Encode(sig))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(ts, 10))
+	return req
+}
+
+func getResponse(t *testing.T, w *httptest.ResponseRecorder) map[string]any {
+	var res map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func getErrorCode(t *testing.T, res map[string]any) int {
+	e, ok := res["error"].(map[string]any)
+	if !ok {
+		t.Fatalf("got %v, want error", res)
+	}
+	return int(e["code"].(float64))
+}
+
+func TestMiddlewareSetsRelayer(t *testing.T) {
+	r := newRouter(&Relayer{Address: testutils.DummyEOA.Address})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, signedRequest(t, sendBody, time.Now().Unix()))
+
+	if res := getResponse(t, w); res["relayer"] != testutils.DummyEOA.Address.String() {
+		t.Fatalf("got %v, want %s", res["relayer"], testutils.DummyEOA.Address)
+	}
+}
+
+func TestMiddlewareRejectsUnregisteredRelayer(t *testing.T) {
+	r := newRouter(&Relayer{Address: testutils.ValidAddress1})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, signedRequest(t, sendBody, time.Now().Unix()))
+
+	if code := getErrorCode(t, getResponse(t, w)); code != errors.UNAUTHORIZED_RELAYER {
+		t.Fatalf("got %d, want %d", code, errors.UNAUTHORIZED_RELAYER)
+	}
+}
+
+func TestMiddlewareRejectsStaleTimestamp(t *testing.T) {
+	r := newRouter(&Relayer{Address: testutils.DummyEOA.Address})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, signedRequest(t, sendBody, time.Now().Add(-2*MaxClockSkew).Unix()))
+
+	if code := getErrorCode(t, getResponse(t, w)); code != errors.UNAUTHORIZED_RELAYER {
+		t.Fatalf("got %d, want %d", code, errors.UNAUTHORIZED_RELAYER)
+	}
+}
+
+func TestMiddlewareEnforcesQuota(t *testing.T) {
+	r := newRouter(&Relayer{Address: testutils.DummyEOA.Address, OpsPerMinute: 1})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, signedRequest(t, sendBody, time.Now().Unix()))
+	if res := getResponse(t, w); res["error"] != nil {
+		t.Fatalf("got %v, want no error", res["error"])
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, signedRequest(t, sendBody, time.Now().Unix()))
+	if code := getErrorCode(t, getResponse(t, w)); code != errors.BANNED_OR_THROTTLED_ENTITY {
+		t.Fatalf("got %d, want %d", code, errors.BANNED_OR_THROTTLED_ENTITY)
+	}
+}
+
+func TestMiddlewarePassesUnsignedRequests(t *testing.T) {
+	r := newRouter()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(sendBody)))
+
+	if res := getResponse(t, w); res["relayer"] != "" {
+		t.Fatalf("got %v, want empty relayer", res["relayer"])
+	}
+}
+
+func TestMiddlewareCountsCaseVariantBatchOps(t *testing.T) {
+	r := newRouter(&Relayer{Address: testutils.DummyEOA.Address, OpsPerMinute: 2})
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"Eth_sendUserOperation","params":[[{},{},{}],"0x"]}`)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, signedRequest(t, body, time.Now().Unix()))
+
+	if code := getErrorCode(t, getResponse(t, w)); code != errors.BANNED_OR_THROTTLED_ENTITY {
+		t.Fatalf("got %d, want %d", code, errors.BANNED_OR_THROTTLED_ENTITY)
+	}
+}
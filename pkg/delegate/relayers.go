@@ -0,0 +1,80 @@
+// Package delegate implements authenticated submission of UserOperations by relayer services on behalf of
+// their end users. A relayer signs each request envelope with a registered key and the bundler applies that
+// relayer's quota instead of the limits it would otherwise apply per IP address.
+package delegate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Relayer is a registered relayer key and the max number of UserOperations it can submit per minute. A quota
+// of 0 means unlimited.
+type Relayer struct {
+	Address      common.Address `json:"address"`
+	OpsPerMinute int            `json:"opsPerMinute"`
+}
+
+// ParseRelayers decodes a list of relayers in the form "address:opsPerMinute".
+func ParseRelayers(vals []string) ([]*Relayer, error) {
+	relayers := []*Relayer{}
+	for _, val := range vals {
+		parts := strings.Split(strings.TrimSpace(val), ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("delegate: relayer %s must be in the form address:opsPerMinute", val)
+		}
+		if !common.IsHexAddress(parts[0]) {
+			return nil, fmt.Errorf("delegate: relayer %s has an invalid address", val)
+		}
+		quota, err := strconv.Atoi(parts[1])
+		if err != nil || quota < 0 {
+			return nil, fmt.Errorf("delegate: relayer %s has an invalid opsPerMinute", val)
+		}
+
+		relayers = append(relayers, &Relayer{
+			Address:      common.HexToAddress(parts[0]),
+			OpsPerMinute: quota,
+		})
+	}
+	return relayers, nil
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+// quotas tracks the number of UserOperations submitted by each relayer within the current one minute window.
+type quotas struct {
+	mu      sync.Mutex
+	windows map[common.Address]*window
+}
+
+func newQuotas() *quotas {
+	return &quotas{windows: make(map[common.Address]*window)}
+}
+
+// take records n ops for the relayer and returns false if doing so would exceed its quota.
+func (q *quotas) take(r *Relayer, n int, now time.Time) bool {
+	if r.OpsPerMinute == 0 {
+		return true
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	w, ok := q.windows[r.Address]
+	if !ok || now.Sub(w.start) >= time.Minute {
+		w = &window{start: now}
+		q.windows[r.Address] = w
+	}
+	if w.count+n > r.OpsPerMinute {
+		return false
+	}
+	w.count += n
+	return true
+}
@@ -0,0 +1,196 @@
+// Package diskquota monitors the size of the bundler's data directory against a soft and hard limit. Cleanup
+// functions (e.g. aggressive garbage collection or pruning old records) are run once the soft limit is
+// reached and new UserOperations are rejected once the hard limit is reached. This prevents a full disk from
+// corrupting the DB.
+package diskquota
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+)
+
+// DefaultInterval is the time between checks of the data directory size.
+var DefaultInterval = time.Minute
+
+// CleanupFunc frees up space in the data directory.
+type CleanupFunc = func() error
+
+// Quota tracks the size of a directory and whether its limits have been reached. A limit of 0 is disabled.
+type Quota struct {
+	dir       string
+	softLimit int64
+	hardLimit int64
+	interval  time.Duration
+	logger    logr.Logger
+
+	mu       sync.Mutex
+	cleanups []CleanupFunc
+
+	size     atomic.Int64
+	exceeded atomic.Bool
+	done     chan struct{}
+}
+
+// New returns a Quota for the given directory with soft and hard limits in bytes.
+func New(dir string, softLimit int64, hardLimit int64, l logr.Logger) *Quota {
+	return &Quota{
+		dir:       dir,
+		softLimit: softLimit,
+		hardLimit: hardLimit,
+		interval:  DefaultInterval,
+		logger:    l.WithName("diskquota"),
+		cleanups:  []CleanupFunc{},
+		done:      make(chan struct{}),
+	}
+}
+
+// SetInterval sets the time between checks of the data directory size.
+//
+// The default value is 1 minute.
+func (q *Quota) SetInterval(interval time.Duration) {
+	q.interval = interval
+}
+
+// OnSoftLimit adds a CleanupFunc that is run on each check while the directory is at or above the soft limit.
+func (q *Quota) OnSoftLimit(fn CleanupFunc) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.cleanups = append(q.cleanups, fn)
+}
+
+// Size returns the directory size in bytes as of the last check.
+func (q *Quota) Size() int64 {
+	return q.size.Load()
+}
+
+// Exceeded returns true if the directory was at or above the hard limit as of the last check.
+func (q *Quota) Exceeded() bool {
+	return q.exceeded.Load()
+}
+
+// dirSize returns the total size of all regular files in a directory.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
+
+// Check measures the directory, runs the cleanup functions if it is at or above the soft limit, and updates
+// whether the hard limit has been reached. The size is measured again after cleanup so that ops are accepted
+// as soon as enough space has been freed.
+func (q *Quota) Check() error {
+	size, err := dirSize(q.dir)
+	if err != nil {
+		return err
+	}
+
+	if q.softLimit > 0 && size >= q.softLimit {
+		q.logger.Info("data directory at soft limit, running cleanup", "size", size, "soft_limit", q.softLimit)
+		q.mu.Lock()
+		cleanups := append([]CleanupFunc{}, q.cleanups...)
+		q.mu.Unlock()
+		for _, fn := range cleanups {
+			if err := fn(); err != nil {
+				q.logger.Error(err, "data directory cleanup failed")
+			}
+		}
+
+		if size, err = dirSize(q.dir); err != nil {
+			return err
+		}
+	}
+
+	exceeded := q.hardLimit > 0 && size >= q.hardLimit
+	if exceeded && !q.exceeded.Load() {
+		q.logger.Info("data directory at hard limit, rejecting new ops", "size", size, "hard_limit", q.hardLimit)
+	} else if !exceeded && q.exceeded.Load() {
+		q.logger.Info("data directory below hard limit, accepting new ops", "size", size, "hard_limit", q.hardLimit)
+	}
+	q.size.Store(size)
+	q.exceeded.Store(exceeded)
+	return nil
+}
+
+// Run checks the directory once and then starts a goroutine to check it at each interval.
+func (q *Quota) Run() error {
+	if err := q.Check(); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(q.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-q.done:
+				return
+			case <-ticker.C:
+				if err := q.Check(); err != nil {
+					q.logger.Error(err, "failed to check data directory size")
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop ends the goroutine started by Run.
+func (q *Quota) Stop() {
+	close(q.done)
+}
+
+// CheckQuota returns a UserOpHandler that is used by the Client to reject new ops while the data directory is
+// at or above the hard limit.
+func (q *Quota) CheckQuota() modules.UserOpHandlerFunc {
+	return func(ctx *modules.UserOpHandlerCtx) error {
+		if q.Exceeded() {
+			return errors.NewRPCError(
+				errors.SERVICE_UNAVAILABLE,
+				fmt.Sprintf("data directory is full: new ops are not accepted until usage is below %d bytes", q.hardLimit),
+				nil,
+			)
+		}
+		return nil
+	}
+}
+
+// BadgerGC returns a CleanupFunc that flattens the LSM tree and runs value log garbage collection with a lower
+// discard ratio than the default scheduled run until there is nothing left to reclaim.
+func BadgerGC(db *badger.DB) CleanupFunc {
+	return func() error {
+		if err := db.Flatten(1); err != nil {
+			return err
+		}
+		for {
+			if err := db.RunValueLogGC(0.3); err == badger.ErrNoRewrite {
+				return nil
+			} else if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package diskquota
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+)
+
+func writeFile(t *testing.T, path string, size int) {
+	if err := os.WriteFile(path, make([]byte, size), 0o600); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
+
+// TestHardLimitRejectsOps verifies that new ops are rejected once the directory reaches the hard limit and
+// accepted again once usage drops below it.
+func TestHardLimitRejectsOps(t *testing.T) {
+	dir := t.TempDir()
+	q := New(dir, 0, 100, logr.Discard())
+	ctx := &modules.UserOpHandlerCtx{}
+
+	writeFile(t, filepath.Join(dir, "a"), 60)
+	if err := q.Check(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := q.CheckQuota()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o700); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	writeFile(t, filepath.Join(dir, "sub", "b"), 60)
+	if err := q.Check(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if q.Size() != 120 {
+		t.Fatalf("got size %d, want 120", q.Size())
+	}
+	if err := q.CheckQuota()(ctx); err == nil {
+		t.Fatal("got nil, want err")
+	}
+
+	if err := os.Remove(filepath.Join(dir, "a")); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := q.Check(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := q.CheckQuota()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
+
+// TestSoftLimitRunsCleanup verifies that cleanup functions run at the soft limit and that the hard limit is
+// evaluated against the size after cleanup.
+func TestSoftLimitRunsCleanup(t *testing.T) {
+	dir := t.TempDir()
+	q := New(dir, 50, 100, logr.Discard())
+	calls := 0
+	q.OnSoftLimit(func() error {
+		calls++
+		return os.Remove(filepath.Join(dir, "a"))
+	})
+
+	writeFile(t, filepath.Join(dir, "a"), 40)
+	if err := q.Check(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if calls != 0 {
+		t.Fatalf("got %d cleanup calls, want 0", calls)
+	}
+
+	writeFile(t, filepath.Join(dir, "b"), 70)
+	if err := q.Check(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if calls != 1 {
+		t.Fatalf("got %d cleanup calls, want 1", calls)
+	}
+	if q.Exceeded() {
+		t.Fatal("got exceeded, want below hard limit after cleanup")
+	}
+	if q.Size() != 70 {
+		t.Fatalf("got size %d, want 70", q.Size())
+	}
+}
@@ -150,24 +150,21 @@ func TraceSimulateHandleOp(in *TraceInput) (*TraceOutput, error) {
 			return out, errors.NewRPCError(errors.EXECUTION_REVERTED, "execution reverted", nil)
 		}
 
-		reason, revErr := errors.DecodeRevert(data)
-		if revErr != nil {
-			code, panErr := errors.DecodePanic(data)
-			if panErr != nil {
-				return nil, errors.NewRPCError(
-					errors.EXECUTION_REVERTED,
-					"execution reverted with data",
-					hexutil.Encode(data),
-				)
-			}
-
-			return out, errors.NewRPCError(
+		rd := errors.NewRevertData("account", data)
+		if _, revErr := errors.DecodeRevert(data); revErr == nil {
+			return out, errors.NewRPCError(errors.EXECUTION_REVERTED, rd.Reason, rd)
+		}
+		if _, panErr := errors.DecodePanic(data); panErr == nil {
+			return out, errors.NewRPCError(errors.EXECUTION_REVERTED, rd.Reason, rd)
+		}
+		if rd.Reason != "" {
+			return nil, errors.NewRPCError(
 				errors.EXECUTION_REVERTED,
-				fmt.Sprintf("panic encountered: %s", code),
-				code,
+				fmt.Sprintf("execution reverted with data: %s", rd.Reason),
+				rd,
 			)
 		}
-		return out, errors.NewRPCError(errors.EXECUTION_REVERTED, reason, reason)
+		return nil, errors.NewRPCError(errors.EXECUTION_REVERTED, "execution reverted with data", rd)
 	}
 
 	return out, nil
@@ -15,6 +15,22 @@ func filterUserOperationEvent(
 	userOpHash string,
 	entryPoint common.Address,
 	blkRange uint64,
+) (*entrypoint.EntrypointUserOperationEventIterator, error) {
+	return filterUserOperationEvents(
+		eth,
+		[][32]byte{common.HexToHash(userOpHash)},
+		[]common.Address{},
+		entryPoint,
+		blkRange,
+	)
+}
+
+func filterUserOperationEvents(
+	eth *ethclient.Client,
+	userOpHashes [][32]byte,
+	senders []common.Address,
+	entryPoint common.Address,
+	blkRange uint64,
 ) (*entrypoint.EntrypointUserOperationEventIterator, error) {
 	ep, err := entrypoint.NewEntrypoint(entryPoint, eth)
 	if err != nil {
@@ -33,8 +49,8 @@ func filterUserOperationEvent(
 
 	return ep.FilterUserOperationEvent(
 		&bind.FilterOpts{Start: startBlk.Uint64()},
-		[][32]byte{common.HexToHash(userOpHash)},
-		[]common.Address{},
+		userOpHashes,
+		senders,
 		[]common.Address{},
 	)
 }
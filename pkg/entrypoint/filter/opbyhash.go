@@ -11,6 +11,9 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/altmempools"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/methods"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
@@ -21,11 +24,22 @@ type HashLookupResult struct {
 	BlockNumber     *big.Int              `json:"blockNumber"`
 	BlockHash       common.Hash           `json:"blockHash"`
 	TransactionHash common.Hash           `json:"transactionHash"`
+	Reason          *ExecutionError       `json:"reason,omitempty"`
+
+	// AltMempoolExceptions is set by the Client if the op was accepted under an alternative mempool.
+	AltMempoolExceptions []*altmempools.Exception `json:"altMempoolExceptions,omitempty"`
+
+	// DappId is set by the Client if the op was tagged with the dapp that submitted it.
+	DappId string `json:"dappId,omitempty"`
+
+	// AccountFingerprint is set by the Client if the sender's account implementation was fingerprinted.
+	AccountFingerprint string `json:"accountFingerprint,omitempty"`
 }
 
 // GetUserOperationByHash filters the EntryPoint contract for UserOperationEvents and returns the
 // corresponding UserOp from a given userOpHash.
 func GetUserOperationByHash(
+	rpc *rpc.Client,
 	eth *ethclient.Client,
 	userOpHash string,
 	entryPoint common.Address,
@@ -43,73 +57,102 @@ func GetUserOperationByHash(
 	}
 
 	if it.Next() {
-		receipt, err := eth.TransactionReceipt(context.Background(), it.Event.Raw.TxHash)
-		if err != nil {
-			return nil, err
+		return lookupUserOperation(rpc, eth, it.Event, entryPoint, chainID)
+	}
+
+	return nil, nil
+}
+
+// lookupUserOperation decodes the handleOps transaction of a UserOperationEvent and returns the matching
+// UserOp.
+func lookupUserOperation(
+	rpc *rpc.Client,
+	eth *ethclient.Client,
+	ev *entrypoint.EntrypointUserOperationEvent,
+	entryPoint common.Address,
+	chainID *big.Int,
+) (*HashLookupResult, error) {
+	receipt, err := eth.TransactionReceipt(context.Background(), ev.Raw.TxHash)
+	if err != nil {
+		return nil, err
+	}
+	tx, isPending, err := eth.TransactionByHash(context.Background(), ev.Raw.TxHash)
+	if err != nil {
+		return nil, err
+	} else if isPending {
+		return nil, nil
+	}
+
+	ops, err := decodeHandleOps(tx.Data())
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range ops {
+		if op.GetUserOpHash(entryPoint, chainID) == common.Hash(ev.UserOpHash) {
+			return &HashLookupResult{
+				UserOperation:   op,
+				EntryPoint:      entryPoint.String(),
+				BlockNumber:     receipt.BlockNumber,
+				BlockHash:       receipt.BlockHash,
+				TransactionHash: ev.Raw.TxHash,
+				Reason:          lookupExecutionError(rpc, eth, receipt, ev),
+			}, nil
 		}
-		tx, isPending, err := eth.TransactionByHash(context.Background(), it.Event.Raw.TxHash)
+	}
+
+	return nil, nil
+}
+
+// decodeHandleOps returns the UserOperations in the calldata of a handleOps transaction. If the calldata is not
+// for handleOps, nil is returned.
+func decodeHandleOps(txData []byte) ([]*userop.UserOperation, error) {
+	hex := hexutil.Encode(txData)
+	if !strings.HasPrefix(hex, methods.HandleOpsSelector) {
+		return nil, nil
+	}
+
+	data := common.Hex2Bytes(hex[len(methods.HandleOpsSelector):])
+	args, err := methods.HandleOpsMethod.Inputs.Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 2 {
+		return nil, fmt.Errorf(
+			"handleOps: invalid input length: expected 2, got %d",
+			len(args),
+		)
+	}
+
+	// TODO: Find better way to convert this
+	abiOps, ok := args[0].([]struct {
+		Sender               common.Address `json:"sender"`
+		Nonce                *big.Int       `json:"nonce"`
+		InitCode             []uint8        `json:"initCode"`
+		CallData             []uint8        `json:"callData"`
+		CallGasLimit         *big.Int       `json:"callGasLimit"`
+		VerificationGasLimit *big.Int       `json:"verificationGasLimit"`
+		PreVerificationGas   *big.Int       `json:"preVerificationGas"`
+		MaxFeePerGas         *big.Int       `json:"maxFeePerGas"`
+		MaxPriorityFeePerGas *big.Int       `json:"maxPriorityFeePerGas"`
+		PaymasterAndData     []uint8        `json:"paymasterAndData"`
+		Signature            []uint8        `json:"signature"`
+	})
+	if !ok {
+		return nil, errors.New("handleOps: cannot assert type: ops is not of type []struct{...}")
+	}
+
+	ops := []*userop.UserOperation{}
+	for _, abiOp := range abiOps {
+		data, err := json.Marshal(abiOp)
 		if err != nil {
 			return nil, err
-		} else if isPending {
-			return nil, nil
 		}
 
-		hex := hexutil.Encode(tx.Data())
-		if strings.HasPrefix(hex, methods.HandleOpsSelector) {
-			data := common.Hex2Bytes(hex[len(methods.HandleOpsSelector):])
-			args, err := methods.HandleOpsMethod.Inputs.Unpack(data)
-			if err != nil {
-				return nil, err
-			}
-			if len(args) != 2 {
-				return nil, fmt.Errorf(
-					"handleOps: invalid input length: expected 2, got %d",
-					len(args),
-				)
-			}
-
-			// TODO: Find better way to convert this
-			ops, ok := args[0].([]struct {
-				Sender               common.Address `json:"sender"`
-				Nonce                *big.Int       `json:"nonce"`
-				InitCode             []uint8        `json:"initCode"`
-				CallData             []uint8        `json:"callData"`
-				CallGasLimit         *big.Int       `json:"callGasLimit"`
-				VerificationGasLimit *big.Int       `json:"verificationGasLimit"`
-				PreVerificationGas   *big.Int       `json:"preVerificationGas"`
-				MaxFeePerGas         *big.Int       `json:"maxFeePerGas"`
-				MaxPriorityFeePerGas *big.Int       `json:"maxPriorityFeePerGas"`
-				PaymasterAndData     []uint8        `json:"paymasterAndData"`
-				Signature            []uint8        `json:"signature"`
-			})
-			if !ok {
-				return nil, errors.New("handleOps: cannot assert type: ops is not of type []struct{...}")
-			}
-
-			for _, abiOp := range ops {
-				data, err := json.Marshal(abiOp)
-				if err != nil {
-					return nil, err
-				}
-
-				var op userop.UserOperation
-				if err = json.Unmarshal(data, &op); err != nil {
-					return nil, err
-				}
-
-				if op.GetUserOpHash(entryPoint, chainID).String() == userOpHash {
-					return &HashLookupResult{
-						UserOperation:   &op,
-						EntryPoint:      entryPoint.String(),
-						BlockNumber:     receipt.BlockNumber,
-						BlockHash:       receipt.BlockHash,
-						TransactionHash: it.Event.Raw.TxHash,
-					}, nil
-				}
-			}
+		var op userop.UserOperation
+		if err = json.Unmarshal(data, &op); err != nil {
+			return nil, err
 		}
-
+		ops = append(ops, &op)
 	}
-
-	return nil, nil
+	return ops, nil
 }
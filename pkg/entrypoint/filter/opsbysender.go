@@ -0,0 +1,80 @@
+package filter
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+)
+
+type senderEvent struct {
+	entryPoint common.Address
+	ev         *entrypoint.EntrypointUserOperationEvent
+}
+
+// latestSenderEvents sorts events from all EntryPoints in order of inclusion by block number and log index and
+// returns only the most recent events up to the given limit.
+func latestSenderEvents(evs []*senderEvent, limit int) []*senderEvent {
+	sort.SliceStable(evs, func(i, j int) bool {
+		if evs[i].ev.Raw.BlockNumber != evs[j].ev.Raw.BlockNumber {
+			return evs[i].ev.Raw.BlockNumber < evs[j].ev.Raw.BlockNumber
+		}
+		return evs[i].ev.Raw.Index < evs[j].ev.Raw.Index
+	})
+	if len(evs) > limit {
+		evs = evs[len(evs)-limit:]
+	}
+	return evs
+}
+
+// GetUserOperationsBySender filters the given EntryPoint contracts for UserOperationEvents from a given sender
+// and returns the corresponding UserOps in order of inclusion across all EntryPoints. Only the most recent ops
+// up to the given limit are returned, so at most limit ops are looked up regardless of the number of
+// EntryPoints.
+func GetUserOperationsBySender(
+	rpc *rpc.Client,
+	eth *ethclient.Client,
+	sender common.Address,
+	entryPoints []common.Address,
+	chainID *big.Int,
+	blkRange uint64,
+	limit int,
+) ([]*HashLookupResult, error) {
+	evs := []*senderEvent{}
+	for _, ep := range entryPoints {
+		it, err := filterUserOperationEvents(eth, [][32]byte{}, []common.Address{sender}, ep, blkRange)
+		if err != nil {
+			return nil, err
+		}
+
+		// Events are returned in order of inclusion so only the last ones for each EntryPoint are kept.
+		epEvs := []*senderEvent{}
+		for it.Next() {
+			epEvs = append(epEvs, &senderEvent{ep, it.Event})
+			if len(epEvs) > limit {
+				epEvs = epEvs[1:]
+			}
+		}
+		err = it.Error()
+		it.Close()
+		if err != nil {
+			return nil, err
+		}
+		evs = append(evs, epEvs...)
+	}
+
+	// Only decode the transactions of the most recent events since each lookup requires extra RPC calls.
+	res := []*HashLookupResult{}
+	for _, sev := range latestSenderEvents(evs, limit) {
+		r, err := lookupUserOperation(rpc, eth, sev.ev, sev.entryPoint, chainID)
+		if err != nil {
+			return nil, err
+		} else if r != nil {
+			res = append(res, r)
+		}
+	}
+	return res, nil
+}
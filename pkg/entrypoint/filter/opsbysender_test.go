@@ -0,0 +1,45 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+)
+
+func newSenderEvent(ep common.Address, block uint64, index uint) *senderEvent {
+	return &senderEvent{ep, &entrypoint.EntrypointUserOperationEvent{
+		Raw: types.Log{BlockNumber: block, Index: index},
+	}}
+}
+
+// TestLatestSenderEvents verifies that events from multiple EntryPoints are merged by block number and log
+// index and that only the most recent events up to the limit are kept.
+func TestLatestSenderEvents(t *testing.T) {
+	ep1 := common.HexToAddress("0x01")
+	ep2 := common.HexToAddress("0x02")
+	evs := []*senderEvent{
+		newSenderEvent(ep1, 1, 0),
+		newSenderEvent(ep1, 3, 5),
+		newSenderEvent(ep1, 4, 0),
+		newSenderEvent(ep2, 2, 0),
+		newSenderEvent(ep2, 3, 2),
+		newSenderEvent(ep2, 5, 1),
+	}
+
+	got := latestSenderEvents(evs, 4)
+	want := [][2]uint64{{3, 2}, {3, 5}, {4, 0}, {5, 1}}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].ev.Raw.BlockNumber != w[0] || uint64(got[i].ev.Raw.Index) != w[1] {
+			t.Fatalf("index %d: got block %d log %d, want block %d log %d",
+				i, got[i].ev.Raw.BlockNumber, got[i].ev.Raw.Index, w[0], w[1])
+		}
+	}
+	if got[0].entryPoint != ep2 || got[1].entryPoint != ep1 {
+		t.Fatalf("got EntryPoints %s and %s, want %s and %s", got[0].entryPoint, got[1].entryPoint, ep2, ep1)
+	}
+}
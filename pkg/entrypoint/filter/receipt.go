@@ -1,6 +1,7 @@
 package filter
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"math/big"
@@ -9,6 +10,8 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
 )
 
 type parsedTransaction struct {
@@ -26,6 +29,7 @@ type parsedTransaction struct {
 
 type UserOperationReceipt struct {
 	UserOpHash    common.Hash        `json:"userOpHash"`
+	EntryPoint    common.Address     `json:"entryPoint"`
 	Sender        common.Address     `json:"sender"`
 	Paymaster     common.Address     `json:"paymaster"`
 	Nonce         string             `json:"nonce"`
@@ -35,11 +39,114 @@ type UserOperationReceipt struct {
 	From          common.Address     `json:"from"`
 	Receipt       *parsedTransaction `json:"receipt"`
 	Logs          []*types.Log       `json:"logs"`
+	Reason        *ExecutionError    `json:"reason,omitempty"`
+	GasBreakdown  *GasBreakdown      `json:"gasBreakdown,omitempty"`
+}
+
+// GasBreakdown splits the actualGasUsed of a UserOperation into its components. ExecutionGasUsed is the gas
+// used by the EntryPoint's innerHandleOp call, which includes the account's execution and any paymaster postOp.
+// VerificationGasUsed is the remainder and also includes the EntryPoint's own overhead. Both are derived from a
+// call trace of the bundle transaction and are omitted if the node does not support tracing.
+type GasBreakdown struct {
+	PreVerificationGas  string `json:"preVerificationGas"`
+	VerificationGasUsed string `json:"verificationGasUsed,omitempty"`
+	ExecutionGasUsed    string `json:"executionGasUsed,omitempty"`
+}
+
+// executionLogs returns the logs emitted during the execution phase of a UserOperation. This is every log
+// after the previous op's UserOperationEvent (or the BeforeExecution event for the first op) up to and
+// including the op's own UserOperationEvent.
+func executionLogs(logs []*types.Log, ev *entrypoint.EntrypointUserOperationEvent) []*types.Log {
+	parsed, err := entrypoint.EntrypointMetaData.GetAbi()
+	if err != nil {
+		return []*types.Log{&ev.Raw}
+	}
+	opEventID := parsed.Events["UserOperationEvent"].ID
+	beforeExecutionID := parsed.Events["BeforeExecution"].ID
+
+	start := 0
+	for i, log := range logs {
+		if log.Address != ev.Raw.Address || len(log.Topics) == 0 {
+			continue
+		}
+		if log.Topics[0] == beforeExecutionID {
+			start = i + 1
+		} else if log.Topics[0] == opEventID {
+			if len(log.Topics) > 1 && log.Topics[1] == ev.UserOpHash {
+				return logs[start : i+1]
+			}
+			start = i + 1
+		}
+	}
+	return []*types.Log{&ev.Raw}
+}
+
+// findInnerHandleOpCalls walks the call tree and returns every innerHandleOp call from the EntryPoint in
+// order of execution.
+func findInnerHandleOpCalls(frame *callFrame, entryPoint common.Address, id []byte) []*callFrame {
+	calls := []*callFrame{}
+	if frame.From == entryPoint && frame.To == entryPoint && bytes.HasPrefix(frame.Input, id) {
+		return append(calls, frame)
+	}
+	for i := range frame.Calls {
+		calls = append(calls, findInnerHandleOpCalls(&frame.Calls[i], entryPoint, id)...)
+	}
+	return calls
+}
+
+// lookupGasBreakdown returns the GasBreakdown for a UserOperationEvent. If the transaction is not a
+// handleOps call, nil is returned. Trace errors are ignored and only the preVerificationGas is set.
+func lookupGasBreakdown(
+	rpc *rpc.Client,
+	tx *types.Transaction,
+	ev *entrypoint.EntrypointUserOperationEvent,
+) *GasBreakdown {
+	ops, err := decodeHandleOps(tx.Data())
+	if err != nil {
+		return nil
+	}
+	idx := -1
+	for i, op := range ops {
+		if op.GetUserOpHash(ev.Raw.Address, tx.ChainId()) == common.Hash(ev.UserOpHash) {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return nil
+	}
+	pvg := ops[idx].PreVerificationGas
+	gb := &GasBreakdown{PreVerificationGas: hexutil.EncodeBig(pvg)}
+
+	parsed, err := entrypoint.EntrypointMetaData.GetAbi()
+	if err != nil {
+		return gb
+	}
+	var root callFrame
+	opts := map[string]any{"tracer": "callTracer"}
+	if err := rpc.CallContext(context.Background(), &root, "debug_traceTransaction", tx.Hash(), opts); err != nil {
+		return gb
+	}
+	calls := findInnerHandleOpCalls(&root, ev.Raw.Address, parsed.Methods["innerHandleOp"].ID)
+	if len(calls) != len(ops) || calls[idx].GasUsed == nil {
+		return gb
+	}
+
+	exec := calls[idx].GasUsed.ToInt()
+	ver := big.NewInt(0).Sub(big.NewInt(0).Sub(ev.ActualGasUsed, pvg), exec)
+	if ver.Sign() < 0 {
+		ver = big.NewInt(0)
+	}
+	gb.ExecutionGasUsed = hexutil.EncodeBig(exec)
+	gb.VerificationGasUsed = hexutil.EncodeBig(ver)
+	return gb
 }
 
 // GetUserOperationReceipt filters the EntryPoint contract for UserOperationEvents and returns a receipt for
-// both the UserOperation and accompanying transaction.
+// both the UserOperation and accompanying transaction. If the UserOperation failed, the decoded execution
+// error is also included.
 func GetUserOperationReceipt(
+	rpc *rpc.Client,
 	eth *ethclient.Client,
 	userOpHash string,
 	entryPoint common.Address,
@@ -83,8 +190,12 @@ func GetUserOperationReceipt(
 			TransactionIndex:  hexutil.EncodeBig(big.NewInt(0).SetUint64(uint64(receipt.TransactionIndex))),
 			EffectiveGasPrice: hexutil.EncodeBig(tx.GasPrice()),
 		}
+		if receipt.EffectiveGasPrice != nil {
+			txnReceipt.EffectiveGasPrice = hexutil.EncodeBig(receipt.EffectiveGasPrice)
+		}
 		return &UserOperationReceipt{
 			UserOpHash:    it.Event.UserOpHash,
+			EntryPoint:    entryPoint,
 			Sender:        it.Event.Sender,
 			Paymaster:     it.Event.Paymaster,
 			Nonce:         hexutil.EncodeBig(it.Event.Nonce),
@@ -93,7 +204,9 @@ func GetUserOperationReceipt(
 			ActualGasUsed: hexutil.EncodeBig(it.Event.ActualGasUsed),
 			From:          from,
 			Receipt:       txnReceipt,
-			Logs:          []*types.Log{&it.Event.Raw},
+			Logs:          executionLogs(receipt.Logs, it.Event),
+			Reason:        lookupExecutionError(rpc, eth, receipt, it.Event),
+			GasBreakdown:  lookupGasBreakdown(rpc, tx, it.Event),
 		}, nil
 	}
 
@@ -0,0 +1,63 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+)
+
+func TestExecutionLogs(t *testing.T) {
+	parsed, _ := entrypoint.EntrypointMetaData.GetAbi()
+	ep := common.HexToAddress("0x01")
+	token := common.HexToAddress("0x02")
+	hash1 := common.HexToHash("0x11")
+	hash2 := common.HexToHash("0x12")
+	opEvent := parsed.Events["UserOperationEvent"].ID
+
+	logs := []*types.Log{
+		{Address: ep, Topics: []common.Hash{parsed.Events["Deposited"].ID}},
+		{Address: ep, Topics: []common.Hash{parsed.Events["BeforeExecution"].ID}},
+		{Address: token, Topics: []common.Hash{common.HexToHash("0xaa")}},
+		{Address: ep, Topics: []common.Hash{opEvent, hash1}},
+		{Address: token, Topics: []common.Hash{common.HexToHash("0xbb")}},
+		{Address: token, Topics: []common.Hash{common.HexToHash("0xcc")}},
+		{Address: ep, Topics: []common.Hash{opEvent, hash2}},
+	}
+
+	ev := &entrypoint.EntrypointUserOperationEvent{UserOpHash: hash2, Raw: *logs[6]}
+	got := executionLogs(logs, ev)
+	if len(got) != 3 || got[0] != logs[4] || got[2] != logs[6] {
+		t.Fatalf("got %v, want logs 4 to 6", got)
+	}
+
+	ev = &entrypoint.EntrypointUserOperationEvent{UserOpHash: hash1, Raw: *logs[3]}
+	got = executionLogs(logs, ev)
+	if len(got) != 2 || got[0] != logs[2] || got[1] != logs[3] {
+		t.Fatalf("got %v, want logs 2 to 3", got)
+	}
+}
+
+func TestFindInnerHandleOpCalls(t *testing.T) {
+	parsed, _ := entrypoint.EntrypointMetaData.GetAbi()
+	id := parsed.Methods["innerHandleOp"].ID
+	ep := common.HexToAddress("0x01")
+	sender := common.HexToAddress("0x02")
+	root := &callFrame{
+		From: common.HexToAddress("0x03"),
+		To:   ep,
+		Calls: []callFrame{
+			{From: ep, To: sender, Input: id},
+			{From: ep, To: ep, Input: id, Calls: []callFrame{
+				{From: ep, To: sender},
+			}},
+			{From: ep, To: ep, Input: append(id, 1)},
+		},
+	}
+
+	calls := findInnerHandleOpCalls(root, ep, id)
+	if len(calls) != 2 || calls[0] != &root.Calls[1] || calls[1] != &root.Calls[2] {
+		t.Fatalf("got %v, want 2 innerHandleOp calls", calls)
+	}
+}
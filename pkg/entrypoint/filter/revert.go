@@ -0,0 +1,114 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+)
+
+// ExecutionError is the decoded revert of a UserOperation that was included on-chain but failed.
+type ExecutionError struct {
+	Reason string `json:"reason"`
+	Data   string `json:"data"`
+}
+
+type callFrame struct {
+	From    common.Address `json:"from"`
+	To      common.Address `json:"to"`
+	Input   hexutil.Bytes  `json:"input"`
+	Output  hexutil.Bytes  `json:"output"`
+	GasUsed *hexutil.Big   `json:"gasUsed"`
+	Error   string         `json:"error"`
+	Calls   []callFrame    `json:"calls"`
+}
+
+func newExecutionError(data []byte, fallback string) *ExecutionError {
+	ee := &ExecutionError{Reason: fallback, Data: hexutil.Encode(data)}
+	if len(data) == 0 {
+		return ee
+	}
+
+	if reason, err := errors.DecodeRevert(data); err == nil {
+		ee.Reason = reason
+	} else if code, err := errors.DecodePanic(data); err == nil {
+		ee.Reason = fmt.Sprintf("panic encountered: %s", code)
+	} else {
+		ee.Reason = "execution reverted with data"
+	}
+	return ee
+}
+
+// findRevertedCall walks the call tree and returns the first failed call from the EntryPoint to the sender.
+func findRevertedCall(frame *callFrame, entryPoint common.Address, sender common.Address) *callFrame {
+	if frame.From == entryPoint && frame.To == sender && frame.Error != "" {
+		return frame
+	}
+	for i := range frame.Calls {
+		if f := findRevertedCall(&frame.Calls[i], entryPoint, sender); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// getExecutionError returns the decoded revert of a failed UserOperation. The UserOperationRevertReason event
+// is used if it was emitted. Otherwise the transaction is re-executed at its block with a call tracer to find
+// the failed call to the sender (e.g. an out of gas error or a revert without data).
+func getExecutionError(
+	rpc *rpc.Client,
+	ep *entrypoint.Entrypoint,
+	receipt *types.Receipt,
+	ev *entrypoint.EntrypointUserOperationEvent,
+) (*ExecutionError, error) {
+	for _, log := range receipt.Logs {
+		if log.Address != ev.Raw.Address || len(log.Topics) < 2 || log.Topics[1] != ev.UserOpHash {
+			continue
+		}
+
+		rr, err := ep.ParseUserOperationRevertReason(*log)
+		if err == nil && len(rr.RevertReason) > 0 {
+			return newExecutionError(rr.RevertReason, "execution reverted"), nil
+		}
+	}
+
+	var root callFrame
+	opts := map[string]any{"tracer": "callTracer"}
+	err := rpc.CallContext(context.Background(), &root, "debug_traceTransaction", receipt.TxHash, opts)
+	if err != nil {
+		return nil, err
+	}
+	if f := findRevertedCall(&root, ev.Raw.Address, ev.Sender); f != nil {
+		return newExecutionError(f.Output, f.Error), nil
+	}
+	return newExecutionError(nil, "execution reverted"), nil
+}
+
+// lookupExecutionError returns the ExecutionError for a UserOperationEvent or nil if the op succeeded. Errors
+// are ignored since not all nodes support tracing and the rest of the lookup should still succeed.
+func lookupExecutionError(
+	rpc *rpc.Client,
+	eth *ethclient.Client,
+	receipt *types.Receipt,
+	ev *entrypoint.EntrypointUserOperationEvent,
+) *ExecutionError {
+	if ev.Success {
+		return nil
+	}
+
+	ep, err := entrypoint.NewEntrypoint(ev.Raw.Address, eth)
+	if err != nil {
+		return nil
+	}
+	ee, err := getExecutionError(rpc, ep, receipt, ev)
+	if err != nil {
+		return nil
+	}
+	return ee
+}
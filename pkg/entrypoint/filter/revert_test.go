@@ -0,0 +1,53 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestFindRevertedCall(t *testing.T) {
+	ep := common.HexToAddress("0x01")
+	sender := common.HexToAddress("0x02")
+	root := &callFrame{
+		From: common.HexToAddress("0x03"),
+		To:   ep,
+		Calls: []callFrame{
+			{From: ep, To: sender},
+			{From: ep, To: ep, Calls: []callFrame{
+				{From: ep, To: sender, Error: "out of gas"},
+			}},
+		},
+	}
+
+	f := findRevertedCall(root, ep, sender)
+	if f == nil || f.Error != "out of gas" {
+		t.Fatalf("got %v, want failed call to sender", f)
+	}
+	if f := findRevertedCall(root, ep, common.HexToAddress("0x04")); f != nil {
+		t.Fatalf("got %v, want nil", f)
+	}
+}
+
+func TestNewExecutionErrorDecodesRevert(t *testing.T) {
+	// Error("boom")
+	data := hexutil.MustDecode(
+		"0x08c379a0" +
+			"0000000000000000000000000000000000000000000000000000000000000020" +
+			"0000000000000000000000000000000000000000000000000000000000000004" +
+			"626f6f6d00000000000000000000000000000000000000000000000000000000",
+	)
+	if ee := newExecutionError(data, "execution reverted"); ee.Reason != "boom" {
+		t.Fatalf("got %s, want boom", ee.Reason)
+	}
+}
+
+func TestNewExecutionErrorFallback(t *testing.T) {
+	if ee := newExecutionError(nil, "out of gas"); ee.Reason != "out of gas" || ee.Data != "0x" {
+		t.Fatalf("got %v, want out of gas with empty data", ee)
+	}
+	if ee := newExecutionError([]byte{1, 2, 3, 4}, "execution reverted"); ee.Reason != "execution reverted with data" {
+		t.Fatalf("got %s, want execution reverted with data", ee.Reason)
+	}
+}
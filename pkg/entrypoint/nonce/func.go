@@ -0,0 +1,32 @@
+package nonce
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+)
+
+// GetNonceFunc provides a general interface for retrieving the next EntryPoint nonce for a given sender and
+// nonce key.
+type GetNonceFunc = func(entryPoint, sender common.Address, key *big.Int) (*big.Int, error)
+
+func GetNonceFuncNoop() GetNonceFunc {
+	return func(entryPoint, sender common.Address, key *big.Int) (*big.Int, error) {
+		return big.NewInt(0).Lsh(key, 64), nil
+	}
+}
+
+// GetNonceWithEthClient returns a GetNonceFunc that relies on an eth client to get the next nonce from the
+// EntryPoint.
+func GetNonceWithEthClient(eth *ethclient.Client) GetNonceFunc {
+	return func(entryPoint, sender common.Address, key *big.Int) (*big.Int, error) {
+		ep, err := entrypoint.NewEntrypoint(entryPoint, eth)
+		if err != nil {
+			return nil, err
+		}
+
+		return ep.GetNonce(nil, sender, key)
+	}
+}
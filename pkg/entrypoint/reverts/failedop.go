@@ -1,18 +1,27 @@
 package reverts
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/rpc"
+	bundlerErrors "github.com/stackup-wallet/stackup-bundler/pkg/errors"
 )
 
+// FailedOpRevert is a decoded FailedOp or FailedOpWithRevert error from the EntryPoint. Entity is the type of
+// entity responsible based on the reason code (i.e. account, factory, or paymaster). RevertReason and
+// RevertData are only set for FailedOpWithRevert.
 type FailedOpRevert struct {
-	OpIndex int
-	Reason  string
+	OpIndex      int    `json:"opIndex"`
+	Reason       string `json:"reason"`
+	Entity       string `json:"entity,omitempty"`
+	RevertReason string `json:"revertReason,omitempty"`
+	RevertData   string `json:"revertData,omitempty"`
 }
 
 func failedOp() abi.Error {
@@ -24,6 +33,35 @@ func failedOp() abi.Error {
 	})
 }
 
+func failedOpWithRevert() abi.Error {
+	opIndex, _ := abi.NewType("uint256", "uint256", nil)
+	reason, _ := abi.NewType("string", "string", nil)
+	inner, _ := abi.NewType("bytes", "bytes", nil)
+	return abi.NewError("FailedOpWithRevert", abi.Arguments{
+		{Name: "opIndex", Type: opIndex},
+		{Name: "reason", Type: reason},
+		{Name: "inner", Type: inner},
+	})
+}
+
+// entityFromReason returns the entity type responsible for a FailedOp based on the AAxx prefix of the reason.
+func entityFromReason(reason string) string {
+	if len(reason) < 4 || !strings.HasPrefix(reason, "AA") {
+		return ""
+	}
+
+	switch reason[2] {
+	case '1':
+		return "factory"
+	case '2':
+		return "account"
+	case '3':
+		return "paymaster"
+	default:
+		return ""
+	}
+}
+
 func NewFailedOp(err error) (*FailedOpRevert, error) {
 	rpcErr, ok := err.(rpc.DataError)
 	if !ok {
@@ -42,8 +80,17 @@ func NewFailedOp(err error) (*FailedOpRevert, error) {
 		)
 	}
 
+	if len(data) < 2 {
+		return nil, fmt.Errorf("failedOp: invalid data: %s", data)
+	}
+	b := common.Hex2Bytes(data[2:])
+	withRevert := failedOpWithRevert()
 	failedOp := failedOp()
-	revert, err := failedOp.Unpack(common.Hex2Bytes(data[2:]))
+	abiErr := failedOp
+	if len(b) >= 4 && bytes.Equal(b[:4], withRevert.ID[:4]) {
+		abiErr = withRevert
+	}
+	revert, err := abiErr.Unpack(b)
 	if err != nil {
 		return nil, fmt.Errorf("failedOp: %s", err)
 	}
@@ -52,8 +99,12 @@ func NewFailedOp(err error) (*FailedOpRevert, error) {
 	if !ok {
 		return nil, errors.New("failedOp: cannot assert type: args is not of type []any")
 	}
-	if len(args) != 2 {
-		return nil, fmt.Errorf("failedOp: invalid args length: expected 2, got %d", len(args))
+	if len(args) != len(abiErr.Inputs) {
+		return nil, fmt.Errorf(
+			"failedOp: invalid args length: expected %d, got %d",
+			len(abiErr.Inputs),
+			len(args),
+		)
 	}
 
 	opIndex, ok := args[0].(*big.Int)
@@ -66,8 +117,19 @@ func NewFailedOp(err error) (*FailedOpRevert, error) {
 		return nil, errors.New("failedOp: cannot assert type: reason is not of type string")
 	}
 
-	return &FailedOpRevert{
+	fo := &FailedOpRevert{
 		OpIndex: int(opIndex.Int64()),
 		Reason:  reason,
-	}, nil
+		Entity:  entityFromReason(reason),
+	}
+	if len(args) == 3 {
+		inner, ok := args[2].([]byte)
+		if !ok {
+			return nil, errors.New("failedOp: cannot assert type: inner is not of type []byte")
+		}
+		rd := bundlerErrors.NewRevertData(fo.Entity, inner)
+		fo.RevertReason = rd.Reason
+		fo.RevertData = rd.RevertData
+	}
+	return fo, nil
 }
@@ -0,0 +1,80 @@
+package reverts
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+)
+
+func packError(t *testing.T, e abi.Error, args ...any) string {
+	data, err := e.Inputs.Pack(args...)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	return hexutil.Encode(append(append([]byte{}, e.ID[:4]...), data...))
+}
+
+func newDataError(t *testing.T, hex string) error {
+	err, parseErr := errors.ParseHexToRpcDataError(hex)
+	if parseErr != nil {
+		t.Fatalf("got %v, want nil", parseErr)
+	}
+	return err
+}
+
+// TestNewFailedOp verifies that a FailedOp error is decoded with the entity derived from the reason code.
+func TestNewFailedOp(t *testing.T) {
+	hex := packError(t, failedOp(), big.NewInt(0), "AA33 reverted (or OOG)")
+
+	fo, err := NewFailedOp(newDataError(t, hex))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if fo.Reason != "AA33 reverted (or OOG)" {
+		t.Fatalf("got %s, want AA33 reverted (or OOG)", fo.Reason)
+	}
+	if fo.Entity != "paymaster" {
+		t.Fatalf("got %s, want paymaster", fo.Entity)
+	}
+	if fo.RevertData != "" {
+		t.Fatalf("got %s, want empty revert data", fo.RevertData)
+	}
+}
+
+// TestNewFailedOpWithRevert verifies that the inner revert of a FailedOpWithRevert error is decoded.
+func TestNewFailedOpWithRevert(t *testing.T) {
+	ownable := customError("OwnableUnauthorizedAccount", "address")
+	inner, _ := hexutil.Decode(packError(t, ownable, common.HexToAddress("0x1")))
+	hex := packError(t, failedOpWithRevert(), big.NewInt(1), "AA23 reverted", inner)
+
+	fo, err := NewFailedOp(newDataError(t, hex))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if fo.OpIndex != 1 {
+		t.Fatalf("got %d, want 1", fo.OpIndex)
+	}
+	if fo.Entity != "account" {
+		t.Fatalf("got %s, want account", fo.Entity)
+	}
+	want := "OwnableUnauthorizedAccount(0x0000000000000000000000000000000000000001)"
+	if fo.RevertReason != want {
+		t.Fatalf("got %s, want %s", fo.RevertReason, want)
+	}
+	if fo.RevertData != hexutil.Encode(inner) {
+		t.Fatalf("got %s, want %s", fo.RevertData, hexutil.Encode(inner))
+	}
+}
+
+func customError(name string, types ...string) abi.Error {
+	args := abi.Arguments{}
+	for _, typ := range types {
+		t, _ := abi.NewType(typ, typ, nil)
+		args = append(args, abi.Argument{Type: t})
+	}
+	return abi.NewError(name, args)
+}
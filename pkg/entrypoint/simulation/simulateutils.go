@@ -1,14 +1,25 @@
 package simulation
 
 import (
+	"math/big"
+
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/reverts"
 )
 
 // EntityStakes provides a mapping for encountered entity addresses and their stake info on the EntryPoint.
 type EntityStakes map[common.Address]*entrypoint.IStakeManagerDepositInfo
 
+// ValidationReport is the result of a UserOperation that passed full validation against the state at a given
+// block.
+type ValidationReport struct {
+	BlockNumber   *big.Int            `json:"blockNumber"`
+	ReturnInfo    *reverts.ReturnInfo `json:"returnInfo"`
+	AltMempoolIds []string            `json:"altMempoolIds"`
+}
+
 var (
 	// Only one create2 opcode is allowed if these two conditions are met:
 	// 	1. op.initcode.length != 0
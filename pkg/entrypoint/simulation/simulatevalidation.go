@@ -3,7 +3,9 @@ package simulation
 import (
 	stdError "errors"
 	"fmt"
+	"math/big"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -19,6 +21,18 @@ func SimulateValidation(
 	rpc *rpc.Client,
 	entryPoint common.Address,
 	op *userop.UserOperation,
+) (*reverts.ValidationResultRevert, error) {
+	return SimulateValidationAtBlock(rpc, entryPoint, op, nil)
+}
+
+// SimulateValidationAtBlock is the same as SimulateValidation but uses the state at a given block. A nil
+// block number will use the latest state. Blocks older than the node's pruning window require an archive
+// node.
+func SimulateValidationAtBlock(
+	rpc *rpc.Client,
+	entryPoint common.Address,
+	op *userop.UserOperation,
+	blockNumber *big.Int,
 ) (*reverts.ValidationResultRevert, error) {
 	ep, err := entrypoint.NewEntrypoint(entryPoint, ethclient.NewClient(rpc))
 	if err != nil {
@@ -27,7 +41,8 @@ func SimulateValidation(
 
 	var res []interface{}
 	rawCaller := &entrypoint.EntrypointRaw{Contract: ep}
-	err = rawCaller.Call(nil, &res, "simulateValidation", entrypoint.UserOperation(*op))
+	opts := &bind.CallOpts{BlockNumber: blockNumber}
+	err = rawCaller.Call(opts, &res, "simulateValidation", entrypoint.UserOperation(*op))
 	if err == nil {
 		return nil, stdError.New("unexpected result from simulateValidation")
 	}
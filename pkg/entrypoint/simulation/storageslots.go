@@ -85,7 +85,7 @@ func isRIP7212Call(isRIP7212Supported bool, addr common.Address) bool {
 	return isRIP7212Supported && addr == rip7212precompile
 }
 
-func (v *storageSlotsValidator) Process() ([]string, error) {
+func (v *storageSlotsValidator) Process() ([]*altmempools.Exception, error) {
 	senderSlots := v.SenderSlots
 	if senderSlots == nil {
 		senderSlots = mapset.NewSet[string]()
@@ -94,11 +94,11 @@ func (v *storageSlotsValidator) Process() ([]string, error) {
 	if entitySlots == nil {
 		entitySlots = mapset.NewSet[string]()
 	}
-	altMempoolIds := []string{}
+	exceptions := []*altmempools.Exception{}
 
 	for ca, csi := range v.EntityContractSizeMap {
 		if ca != v.Op.Sender && csi.ContractSize == 0 && !isRIP7212Call(v.IsRIP7212Supported, ca) {
-			return altMempoolIds, fmt.Errorf(
+			return exceptions, fmt.Errorf(
 				"%s uses %s on an address with no deployed code: %s",
 				v.EntityName,
 				csi.Opcode,
@@ -128,7 +128,7 @@ func (v *storageSlotsValidator) Process() ([]string, error) {
 					slots = append(slots, slot)
 				}
 			} else {
-				return altMempoolIds, fmt.Errorf("cannot decode %s access type: %+v", mode, val)
+				return exceptions, fmt.Errorf("cannot decode %s access type: %+v", mode, val)
 			}
 
 			for _, slot := range slots {
@@ -146,9 +146,15 @@ func (v *storageSlotsValidator) Process() ([]string, error) {
 				); (isAssociatedWith(entitySlots, slot) || mode == accessModeRead) && len(amIds) == 0 {
 					mustStakeSlot = slot
 				} else if len(amIds) > 0 {
-					altMempoolIds = append(altMempoolIds, amIds...)
+					exceptions = append(exceptions, &altmempools.Exception{
+						Rule:       altmempools.InvalidStorageAccessRule,
+						Entity:     v.EntityName,
+						Contract:   addr2KnownEntity(v.Op, addr),
+						Slot:       slot,
+						MempoolIds: amIds,
+					})
 				} else {
-					return altMempoolIds, fmt.Errorf(
+					return exceptions, fmt.Errorf(
 						"%s has forbidden %s to %s slot %s",
 						v.EntityName,
 						mode,
@@ -160,7 +166,7 @@ func (v *storageSlotsValidator) Process() ([]string, error) {
 		}
 
 		if mustStakeSlot != "" && !v.EntityIsStaked {
-			return altMempoolIds, fmt.Errorf(
+			return exceptions, fmt.Errorf(
 				"unstaked %s accessed %s slot %s",
 				v.EntityName,
 				addr2KnownEntity(v.Op, addr),
@@ -169,5 +175,5 @@ func (v *storageSlotsValidator) Process() ([]string, error) {
 		}
 	}
 
-	return altMempoolIds, nil
+	return exceptions, nil
 }
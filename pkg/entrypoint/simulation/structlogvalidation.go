@@ -0,0 +1,36 @@
+package simulation
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/utils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/state"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer"
+)
+
+// traceWithStructLogs makes a debug_traceCall with the built-in structLogger and parses the struct logs into
+// the same result returned by BundlerCollectorTracer.js.
+func traceWithStructLogs(
+	in *TraceInput,
+	req *utils.TraceCallReq,
+	blk string,
+) (*tracer.BundlerCollectorReturn, error) {
+	var logs tracer.StructLogResult
+	opts := utils.StructLogOpts{
+		EnableMemory:   true,
+		DisableStorage: true,
+		StateOverrides: state.WithMaxBalanceOverride(common.HexToAddress("0x"), nil),
+	}
+	if err := in.Rpc.CallContext(context.Background(), &logs, "debug_traceCall", req, blk, &opts); err != nil {
+		return nil, err
+	}
+
+	eth := ethclient.NewClient(in.Rpc)
+	codeSize := func(addr common.Address) (int, error) {
+		code, err := eth.CodeAt(context.Background(), addr, in.BlockNumber)
+		return len(code), err
+	}
+	return tracer.ParseStructLogs(&logs, in.EntryPoint, in.Verifiers.Addresses(), codeSize)
+}
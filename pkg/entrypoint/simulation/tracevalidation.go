@@ -17,6 +17,7 @@ import (
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/methods"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/utils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/passkey"
 	"github.com/stackup-wallet/stackup-bundler/pkg/state"
 	"github.com/stackup-wallet/stackup-bundler/pkg/tracer"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
@@ -31,11 +32,16 @@ type TraceInput struct {
 	Tracer             string
 	Stakes             EntityStakes
 	AltMempools        *altmempools.Directory
+	Verifiers          *passkey.Allowlist
+
+	// Optional block number to trace against. The latest block is used if nil.
+	BlockNumber *big.Int
 }
 
 type TraceOutput struct {
-	TouchedContracts []common.Address
-	AltMempoolIds    []string
+	TouchedContracts     []common.Address
+	AltMempoolIds        []string
+	AltMempoolExceptions []*altmempools.Exception
 }
 
 // TraceSimulateValidation makes a debug_traceCall to Entrypoint.simulateValidation(userop) and returns
@@ -58,6 +64,8 @@ func TraceSimulateValidation(in *TraceInput) (*TraceOutput, error) {
 	t := tracer.Loaded.BundlerCollectorTracer
 	if in.Tracer != "" {
 		t = in.Tracer
+	} else if addrs := in.Verifiers.Addresses(); len(addrs) > 0 {
+		t = tracer.WithSkippedAddresses(t, addrs)
 	}
 
 	var res tracer.BundlerCollectorReturn
@@ -71,12 +79,22 @@ func TraceSimulateValidation(in *TraceInput) (*TraceOutput, error) {
 		Tracer:         t,
 		StateOverrides: state.WithMaxBalanceOverride(common.HexToAddress("0x"), nil),
 	}
-	if err := in.Rpc.CallContext(context.Background(), &res, "debug_traceCall", &req, "latest", &opts); err != nil {
+	blk := "latest"
+	if in.BlockNumber != nil {
+		blk = hexutil.EncodeBig(in.BlockNumber)
+	}
+	if in.Tracer == tracer.StructLogProfile {
+		out, err := traceWithStructLogs(in, &req, blk)
+		if err != nil {
+			return nil, err
+		}
+		res = *out
+	} else if err := in.Rpc.CallContext(context.Background(), &res, "debug_traceCall", &req, blk, &opts); err != nil {
 		return nil, err
 	}
 
 	knownEntity, err := newKnownEntity(in.Op, &res, in.Stakes)
-	altMempoolIds := []string{}
+	altMempoolExceptions := []*altmempools.Exception{}
 	if err != nil {
 		return nil, err
 	}
@@ -105,6 +123,17 @@ func TraceSimulateValidation(in *TraceInput) (*TraceOutput, error) {
 		}
 	}
 
+	// Steps inside allowlisted verifiers are not traced. Use the precomputed gas instead to check for OOG.
+	for _, call := range res.Calls {
+		if v, ok := in.Verifiers.Get(call.To); ok && call.Gas < float64(v.VerificationGas) {
+			return nil, fmt.Errorf(
+				"%s OOG calling passkey verifier %s",
+				addr2KnownEntity(in.Op, call.From),
+				call.To,
+			)
+		}
+	}
+
 	create2Count, ok := knownEntity["factory"].Info.Opcodes[create2OpCode]
 	if ok && (create2Count > 1 || len(in.Op.InitCode) == 0) {
 		return nil, fmt.Errorf("factory with too many %s", create2OpCode)
@@ -134,10 +163,10 @@ func TraceSimulateValidation(in *TraceInput) (*TraceOutput, error) {
 			EntitySlots:           slotsByEntity[entity.Address],
 			EntityIsStaked:        entity.IsStaked,
 		}
-		if ids, err := v.Process(); err != nil {
+		if exs, err := v.Process(); err != nil {
 			return nil, err
 		} else {
-			altMempoolIds = append(altMempoolIds, ids...)
+			altMempoolExceptions = append(altMempoolExceptions, exs...)
 		}
 	}
 
@@ -171,7 +200,8 @@ func TraceSimulateValidation(in *TraceInput) (*TraceOutput, error) {
 	}
 
 	return &TraceOutput{
-		TouchedContracts: ic.ToSlice(),
-		AltMempoolIds:    altMempoolIds,
+		TouchedContracts:     ic.ToSlice(),
+		AltMempoolIds:        altmempools.GetMempoolIds(altMempoolExceptions),
+		AltMempoolExceptions: altMempoolExceptions,
 	}, nil
 }
@@ -1,6 +1,9 @@
 package stake
 
 import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
@@ -36,3 +39,25 @@ func GetStakeWithEthClient(eth *ethclient.Client) GetStakeFunc {
 		return &dep, nil
 	}
 }
+
+// GetStakeAtBlockWithEthClient returns a GetStakeFunc that relies on an eth client to get stake info from the
+// EntryPoint at a given block.
+func GetStakeAtBlockWithEthClient(eth *ethclient.Client, blockNumber *big.Int) GetStakeFunc {
+	return func(entryPoint, addr common.Address) (*entrypoint.IStakeManagerDepositInfo, error) {
+		if addr == common.HexToAddress("0x") {
+			return nil, nil
+		}
+
+		ep, err := entrypoint.NewEntrypoint(entryPoint, eth)
+		if err != nil {
+			return nil, err
+		}
+
+		dep, err := ep.GetDepositInfo(&bind.CallOpts{BlockNumber: blockNumber}, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		return &dep, nil
+	}
+}
@@ -0,0 +1,71 @@
+package transaction
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// ErrSigningNotApproved is returned when an ApproveFunc vetoes signing a handleOps transaction.
+var ErrSigningNotApproved = errors.New("transaction: signing not approved")
+
+// Preview is the decoded handleOps transaction that is about to be signed by the bundler's EOA.
+type Preview struct {
+	ChainID      *hexutil.Big            `json:"chainId"`
+	From         common.Address          `json:"from"`
+	EntryPoint   common.Address          `json:"entryPoint"`
+	Beneficiary  common.Address          `json:"beneficiary"`
+	Nonce        hexutil.Uint64          `json:"nonce"`
+	GasLimit     hexutil.Uint64          `json:"gasLimit"`
+	GasPrice     *hexutil.Big            `json:"gasPrice"`
+	GasFeeCap    *hexutil.Big            `json:"maxFeePerGas"`
+	GasTipCap    *hexutil.Big            `json:"maxPriorityFeePerGas"`
+	UserOps      []*userop.UserOperation `json:"userOps"`
+	UserOpHashes []common.Hash           `json:"userOpHashes"`
+}
+
+// ApproveFunc is called with a preview of each handleOps transaction before it is signed. Returning an error
+// will veto signing and blocking will delay it.
+type ApproveFunc = func(p *Preview) error
+
+func newPreview(opts *Opts, tx *types.Transaction) *Preview {
+	hashes := []common.Hash{}
+	for _, op := range opts.Batch {
+		hashes = append(hashes, op.GetUserOpHash(opts.EntryPoint, opts.ChainID))
+	}
+
+	return &Preview{
+		ChainID:      (*hexutil.Big)(opts.ChainID),
+		From:         opts.EOA.Address,
+		EntryPoint:   opts.EntryPoint,
+		Beneficiary:  opts.Beneficiary,
+		Nonce:        hexutil.Uint64(tx.Nonce()),
+		GasLimit:     hexutil.Uint64(tx.Gas()),
+		GasPrice:     (*hexutil.Big)(tx.GasPrice()),
+		GasFeeCap:    (*hexutil.Big)(tx.GasFeeCap()),
+		GasTipCap:    (*hexutil.Big)(tx.GasTipCap()),
+		UserOps:      append([]*userop.UserOperation{}, opts.Batch...),
+		UserOpHashes: hashes,
+	}
+}
+
+// withApproval wraps a transaction signer so that opts.Approve is called before signing.
+func withApproval(opts *Opts, signer func(common.Address, *types.Transaction) (*types.Transaction, error)) func(
+	common.Address,
+	*types.Transaction,
+) (*types.Transaction, error) {
+	if opts.Approve == nil {
+		return signer
+	}
+
+	return func(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if err := opts.Approve(newPreview(opts, tx)); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrSigningNotApproved, err)
+		}
+		return signer(addr, tx)
+	}
+}
@@ -0,0 +1,53 @@
+package transaction
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func signed(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+	return tx, nil
+}
+
+func TestWithApprovalPreviewsTransaction(t *testing.T) {
+	var got *Preview
+	opts := &Opts{
+		EOA:        testutils.DummyEOA,
+		ChainID:    testutils.ChainID,
+		EntryPoint: testutils.ValidAddress1,
+		Batch:      []*userop.UserOperation{testutils.MockValidInitUserOp()},
+		Approve: func(p *Preview) error {
+			got = p
+			return nil
+		},
+	}
+	tx := types.NewTx(&types.DynamicFeeTx{Nonce: 7, Gas: 100000, GasFeeCap: big.NewInt(2), GasTipCap: big.NewInt(1)})
+
+	if _, err := withApproval(opts, signed)(opts.EOA.Address, tx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if got == nil || uint64(got.Nonce) != 7 || uint64(got.GasLimit) != 100000 || len(got.UserOpHashes) != 1 {
+		t.Fatalf("got preview %+v, want nonce 7, gas limit 100000, and 1 op", got)
+	}
+}
+
+func TestWithApprovalVeto(t *testing.T) {
+	opts := &Opts{
+		EOA:     testutils.DummyEOA,
+		ChainID: testutils.ChainID,
+		Approve: func(p *Preview) error {
+			return errors.New("vetoed")
+		},
+	}
+	tx := types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(1)})
+
+	if _, err := withApproval(opts, signed)(opts.EOA.Address, tx); !errors.Is(err, ErrSigningNotApproved) {
+		t.Fatalf("got %v, want ErrSigningNotApproved", err)
+	}
+}
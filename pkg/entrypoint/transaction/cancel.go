@@ -0,0 +1,82 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	// ReplacementFeeBump is the percentage that fees are increased by when replacing a pending transaction.
+	// Most clients require a minimum of 10%.
+	ReplacementFeeBump = int64(20)
+)
+
+// BumpFee returns the given fee increased by ReplacementFeeBump percent plus 1 wei to account for rounding.
+func BumpFee(fee *big.Int) *big.Int {
+	a := big.NewInt(0).Mul(fee, big.NewInt(100+ReplacementFeeBump))
+	b := big.NewInt(0).Div(a, big.NewInt(100))
+	return big.NewInt(0).Add(b, big.NewInt(1))
+}
+
+func maxBig(x *big.Int, y *big.Int) *big.Int {
+	if x.Cmp(y) == 1 {
+		return x
+	}
+	return y
+}
+
+// Cancel creates a signed zero value self-transfer from the bundler EOA with the given nonce. It is used to
+// replace a stuck transaction that is blocking all subsequent transactions from the EOA. Fees are set to the
+// current suggested values bumped by ReplacementFeeBump. If prev is not nil (i.e. a previous replacement
+// attempt at the same nonce), fees are guaranteed to also be bumped relative to it. If opts.NoSend is false,
+// the transaction will also be broadcasted.
+func Cancel(opts *Opts, nonce uint64, prev *types.Transaction) (*types.Transaction, error) {
+	var tx types.TxData
+	if opts.BaseFee != nil && opts.Tip != nil {
+		tip := BumpFee(opts.Tip)
+		fc := BumpFee(big.NewInt(0).Add(opts.Tip, big.NewInt(0).Mul(opts.BaseFee, big.NewInt(2))))
+		if prev != nil {
+			tip = maxBig(tip, BumpFee(prev.GasTipCap()))
+			fc = maxBig(fc, BumpFee(prev.GasFeeCap()))
+		}
+		tx = &types.DynamicFeeTx{
+			ChainID:   opts.ChainID,
+			Nonce:     nonce,
+			GasTipCap: tip,
+			GasFeeCap: maxBig(fc, tip),
+			Gas:       params.TxGas,
+			To:        &opts.EOA.Address,
+			Value:     big.NewInt(0),
+		}
+	} else if opts.GasPrice != nil {
+		gp := BumpFee(opts.GasPrice)
+		if prev != nil {
+			gp = maxBig(gp, BumpFee(prev.GasPrice()))
+		}
+		tx = &types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: gp,
+			Gas:      params.TxGas,
+			To:       &opts.EOA.Address,
+			Value:    big.NewInt(0),
+		}
+	} else {
+		return nil, errors.New("transaction: either the dynamic or legacy gas fees must be set")
+	}
+
+	signed, err := types.SignNewTx(opts.EOA.PrivateKey, types.LatestSignerForChainID(opts.ChainID), tx)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.NoSend {
+		if err := opts.Eth.SendTransaction(context.Background(), signed); err != nil {
+			return nil, err
+		}
+	}
+
+	return signed, nil
+}
@@ -0,0 +1,17 @@
+package transaction
+
+import (
+	"math/big"
+	"testing"
+)
+
+// TestBumpFee verifies that a bumped fee is always strictly greater than the minimum replacement fee required
+// by most clients (i.e. 10%).
+func TestBumpFee(t *testing.T) {
+	for _, fee := range []int64{0, 1, 9, 100, 1_000_000_007} {
+		min := big.NewInt(0).Div(big.NewInt(0).Mul(big.NewInt(fee), big.NewInt(110)), big.NewInt(100))
+		if bumped := BumpFee(big.NewInt(fee)); bumped.Cmp(min) != 1 {
+			t.Fatalf("fee %d: got %d, want > %d", fee, bumped.Int64(), min.Int64())
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package transaction
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/state"
+)
+
+type compensationTraceReq struct {
+	From     common.Address `json:"from"`
+	To       common.Address `json:"to"`
+	Data     hexutil.Bytes  `json:"data"`
+	Gas      hexutil.Uint64 `json:"gas"`
+	GasPrice hexutil.Big    `json:"gasPrice"`
+}
+
+type compensationTraceOpts struct {
+	Tracer         string            `json:"tracer"`
+	TracerConfig   map[string]any    `json:"tracerConfig"`
+	StateOverrides state.OverrideSet `json:"stateOverrides"`
+}
+
+type prestateAccount struct {
+	Balance *hexutil.Big `json:"balance"`
+}
+
+type prestateDiff struct {
+	Pre  map[common.Address]prestateAccount `json:"pre"`
+	Post map[common.Address]prestateAccount `json:"post"`
+}
+
+// Compensation is the result of simulating handleOps for a batch.
+type Compensation struct {
+	// Gas is the estimated gas for the handleOps transaction.
+	Gas uint64
+
+	// Delta is the change in the beneficiary's balance after handleOps.
+	Delta *big.Int
+
+	// Cost is the projected transaction cost given the gas and effective gas price.
+	Cost *big.Int
+}
+
+// IsProfitable returns true if the beneficiary is compensated for at least the cost of the transaction.
+func (c *Compensation) IsProfitable() bool {
+	return c.Delta.Cmp(c.Cost) >= 0
+}
+
+// Margin returns the difference between the beneficiary's balance change and the transaction cost.
+func (c *Compensation) Margin() *big.Int {
+	return big.NewInt(0).Sub(c.Delta, c.Cost)
+}
+
+// SimulateCompensation estimates gas for calling handleOps with the given batch and traces the call with a
+// prestateTracer in diff mode to measure the change in the beneficiary's balance. The call is made from the
+// zero address so that the beneficiary balance is not affected by the transaction fee. An error is returned if
+// handleOps would revert.
+func SimulateCompensation(rpc *rpc.Client, opts *Opts, effectiveGasPrice *big.Int) (*Compensation, error) {
+	parsed, err := entrypoint.EntrypointMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	data, err := parsed.Pack("handleOps", toAbiType(opts.Batch), opts.Beneficiary)
+	if err != nil {
+		return nil, err
+	}
+
+	from := common.HexToAddress("0x")
+	est, err := opts.Eth.EstimateGas(context.Background(), ethereum.CallMsg{
+		From: from,
+		To:   &opts.EntryPoint,
+		Data: data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var res prestateDiff
+	req := compensationTraceReq{
+		From:     from,
+		To:       opts.EntryPoint,
+		Data:     data,
+		Gas:      hexutil.Uint64(est),
+		GasPrice: hexutil.Big(*effectiveGasPrice),
+	}
+	tOpts := compensationTraceOpts{
+		Tracer:         "prestateTracer",
+		TracerConfig:   map[string]any{"diffMode": true},
+		StateOverrides: state.WithMaxBalanceOverride(from, nil),
+	}
+	if err := rpc.CallContext(
+		context.Background(),
+		&res,
+		"debug_traceCall",
+		&req,
+		"latest",
+		&tOpts,
+	); err != nil {
+		return nil, err
+	}
+
+	delta := big.NewInt(0)
+	if post, ok := res.Post[opts.Beneficiary]; ok && post.Balance != nil {
+		delta = big.NewInt(0).Set(post.Balance.ToInt())
+		if pre, ok := res.Pre[opts.Beneficiary]; ok && pre.Balance != nil {
+			delta = big.NewInt(0).Sub(delta, pre.Balance.ToInt())
+		}
+	}
+
+	return &Compensation{
+		Gas:   est,
+		Delta: delta,
+		Cost:  big.NewInt(0).Mul(big.NewInt(0).SetUint64(est), effectiveGasPrice),
+	}, nil
+}
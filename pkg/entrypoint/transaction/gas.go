@@ -55,3 +55,26 @@ func SuggestMeanGasPrice(gasPrice *big.Int, batch []*userop.UserOperation) *big.
 	}
 	return gasPrice
 }
+
+// SuggestEffectiveGasPrice returns the projected gas price paid by a transaction to submit a batch of
+// UserOperations to the EntryPoint. For EIP-1559 transactions, this is the lesser of the suggested max fee or
+// the basefee plus suggested tip. Otherwise it is the suggested gas price for a legacy transaction. Returns nil
+// if neither the dynamic nor legacy gas fees are set.
+func SuggestEffectiveGasPrice(
+	basefee *big.Int,
+	tip *big.Int,
+	gasPrice *big.Int,
+	batch []*userop.UserOperation,
+) *big.Int {
+	if basefee != nil && tip != nil {
+		mf := SuggestMeanGasFeeCap(basefee, tip, batch)
+		egp := big.NewInt(0).Add(basefee, SuggestMeanGasTipCap(tip, batch))
+		if egp.Cmp(mf) == 1 {
+			return mf
+		}
+		return egp
+	} else if gasPrice != nil {
+		return SuggestMeanGasPrice(gasPrice, batch)
+	}
+	return nil
+}
@@ -38,6 +38,9 @@ type Opts struct {
 	GasLimit    uint64
 	NoSend      bool
 	WaitTimeout time.Duration
+
+	// Optional hook called before signing the handleOps transaction
+	Approve ApproveFunc
 }
 
 func toAbiType(batch []*userop.UserOperation) []entrypoint.UserOperation {
@@ -104,6 +107,7 @@ func HandleOps(opts *Opts) (txn *types.Transaction, err error) {
 	}
 	auth.GasLimit = opts.GasLimit
 	auth.NoSend = opts.NoSend
+	auth.Signer = withApproval(opts, auth.Signer)
 
 	nonce, err := opts.Eth.NonceAt(context.Background(), opts.EOA.Address, nil)
 	if err != nil {
@@ -131,3 +135,32 @@ func HandleOps(opts *Opts) (txn *types.Transaction, err error) {
 
 	return Wait(txn, opts.Eth, opts.WaitTimeout)
 }
+
+// SimulateHandleOps executes handleOps() with the exact gas limit and fees that would be used by HandleOps in
+// an eth_call against the pending block. Unlike EstimateHandleOpsGas, this will also catch reverts caused by
+// an insufficient gas limit. A failed call will return the cause of the revert if it was due to a FailedOp.
+func SimulateHandleOps(opts *Opts) (revert *reverts.FailedOpRevert, err error) {
+	sOpts := *opts
+	sOpts.NoSend = true
+	sOpts.Approve = nil
+	tx, err := HandleOps(&sOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = opts.Eth.PendingCallContract(context.Background(), ethereum.CallMsg{
+		From:       opts.EOA.Address,
+		To:         tx.To(),
+		Gas:        tx.Gas(),
+		GasPrice:   tx.GasPrice(),
+		GasFeeCap:  tx.GasFeeCap(),
+		GasTipCap:  tx.GasTipCap(),
+		Value:      tx.Value(),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+	})
+	if err != nil {
+		return reverts.NewFailedOp(err)
+	}
+	return nil, nil
+}
@@ -0,0 +1,57 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Payout creates a signed transaction from the bundler EOA to the beneficiary with the given calldata. It is
+// intended to be sent directly after a handleOps transaction for beneficiaries that are contracts (e.g. to
+// split revenue on-chain). The nonce must be explicitly set since the preceding handleOps transaction may not
+// have been included yet. If opts.NoSend is false, the transaction will also be broadcasted.
+//
+// The gas limit is not estimated since the payout usually depends on revenue from the handleOps transaction,
+// which is not in the state that an estimate would run against.
+func Payout(opts *Opts, data []byte, nonce uint64, gasLimit uint64) (*types.Transaction, error) {
+	if gasLimit == 0 {
+		return nil, errors.New("transaction: payout gas limit must be set")
+	}
+
+	var tx types.TxData
+	if opts.BaseFee != nil && opts.Tip != nil {
+		tx = &types.DynamicFeeTx{
+			ChainID:   opts.ChainID,
+			Nonce:     nonce,
+			GasTipCap: opts.Tip,
+			GasFeeCap: big.NewInt(0).Add(opts.Tip, big.NewInt(0).Mul(opts.BaseFee, big.NewInt(2))),
+			Gas:       gasLimit,
+			To:        &opts.Beneficiary,
+			Data:      data,
+		}
+	} else if opts.GasPrice != nil {
+		tx = &types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: opts.GasPrice,
+			Gas:      gasLimit,
+			To:       &opts.Beneficiary,
+			Data:     data,
+		}
+	} else {
+		return nil, errors.New("transaction: either the dynamic or legacy gas fees must be set")
+	}
+
+	signed, err := types.SignNewTx(opts.EOA.PrivateKey, types.LatestSignerForChainID(opts.ChainID), tx)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.NoSend {
+		if err := opts.Eth.SendTransaction(context.Background(), signed); err != nil {
+			return nil, err
+		}
+	}
+
+	return signed, nil
+}
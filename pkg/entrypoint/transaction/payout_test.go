@@ -0,0 +1,45 @@
+package transaction
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+)
+
+func newPayoutOpts() *Opts {
+	return &Opts{
+		EOA:         testutils.DummyEOA,
+		ChainID:     testutils.ChainID,
+		Beneficiary: testutils.ValidAddress1,
+		BaseFee:     big.NewInt(1),
+		Tip:         big.NewInt(1),
+		NoSend:      true,
+	}
+}
+
+// TestPayoutUsesGasLimit verifies that the payout transaction is signed with the given gas limit and nonce
+// without estimating gas against a state that doesn't include the handleOps transaction yet. Opts has no
+// eth client so any RPC call would fail.
+func TestPayoutUsesGasLimit(t *testing.T) {
+	data := common.Hex2Bytes("deadbeef")
+	txn, err := Payout(newPayoutOpts(), data, 7, 150000)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if txn.Gas() != 150000 || txn.Nonce() != 7 {
+		t.Fatalf("got gas %d and nonce %d, want 150000 and 7", txn.Gas(), txn.Nonce())
+	}
+	if *txn.To() != testutils.ValidAddress1 || !bytes.Equal(txn.Data(), data) {
+		t.Fatalf("got to %s and data %x, want beneficiary and payout calldata", txn.To(), txn.Data())
+	}
+}
+
+// TestPayoutRequiresGasLimit verifies that a payout transaction is not signed without a gas limit.
+func TestPayoutRequiresGasLimit(t *testing.T) {
+	if _, err := Payout(newPayoutOpts(), common.Hex2Bytes("deadbeef"), 7, 0); err == nil {
+		t.Fatal("got nil, want error")
+	}
+}
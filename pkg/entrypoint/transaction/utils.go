@@ -12,6 +12,9 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
+// ErrFailedStatus is returned by Wait when a transaction is included on-chain but reverted.
+var ErrFailedStatus = errors.New("transaction: failed status")
+
 // ToRawTxHex Takes a Geth types.Transaction and returns the encoded raw hex string.
 func ToRawTxHex(txn *types.Transaction) string {
 	rawTxn := new(bytes.Buffer)
@@ -28,7 +31,7 @@ func Wait(txn *types.Transaction, eth *ethclient.Client, timeout time.Duration)
 	} else if receipt.Status == types.ReceiptStatusFailed {
 		// Return an error here so that the current batch stays in the mempool. In the next bundler iteration,
 		// the offending userOps will be dropped during gas estimation.
-		return nil, errors.New("transaction: failed status")
+		return nil, ErrFailedStatus
 	}
 	return txn, nil
 }
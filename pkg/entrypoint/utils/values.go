@@ -29,6 +29,13 @@ type TraceCallOpts struct {
 	StateOverrides state.OverrideSet `json:"stateOverrides"`
 }
 
+// StructLogOpts are the debug_traceCall options for the built-in structLogger.
+type StructLogOpts struct {
+	EnableMemory   bool              `json:"enableMemory"`
+	DisableStorage bool              `json:"disableStorage"`
+	StateOverrides state.OverrideSet `json:"stateOverrides,omitempty"`
+}
+
 var (
 	// A dummy private key used to build *bind.TransactOpts for simulation.
 	DummyPk, _ = crypto.GenerateKey()
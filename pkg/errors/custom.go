@@ -0,0 +1,92 @@
+package errors
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// RevertData is the data field of a JSON-RPC error for a simulation that reverted. Reason is empty if the
+// revert data could not be decoded.
+type RevertData struct {
+	Reason     string `json:"reason,omitempty"`
+	Entity     string `json:"entity,omitempty"`
+	RevertData string `json:"revertData"`
+}
+
+func customError(name string, types ...string) abi.Error {
+	args := abi.Arguments{}
+	for _, t := range types {
+		typ, _ := abi.NewType(t, t, nil)
+		args = append(args, abi.Argument{Type: typ})
+	}
+	return abi.NewError(name, args)
+}
+
+// customErrors are common custom errors thrown by accounts, paymasters, and the contracts they depend on.
+var customErrors = []abi.Error{
+	customError("ECDSAInvalidSignature"),
+	customError("ECDSAInvalidSignatureLength", "uint256"),
+	customError("ECDSAInvalidSignatureS", "bytes32"),
+	customError("OwnableUnauthorizedAccount", "address"),
+	customError("AccessControlUnauthorizedAccount", "address", "bytes32"),
+	customError("InvalidInitialization"),
+	customError("NotInitializing"),
+	customError("ReentrancyGuardReentrantCall"),
+	customError("EnforcedPause"),
+	customError("AddressEmptyCode", "address"),
+	customError("FailedInnerCall"),
+	customError("SafeERC20FailedOperation", "address"),
+	customError("ERC20InsufficientBalance", "address", "uint256", "uint256"),
+	customError("ERC20InsufficientAllowance", "address", "uint256", "uint256"),
+	customError("ERC1967InvalidImplementation", "address"),
+	customError("UUPSUnauthorizedCallContext"),
+}
+
+// DecodeCustomError returns a readable form of revert data from one of the common custom errors (e.g.
+// "ERC20InsufficientBalance(0x..., 0, 100)").
+func DecodeCustomError(data []byte) (string, error) {
+	if len(data) < 4 {
+		return "", errors.New("custom error: data too short")
+	}
+
+	for _, e := range customErrors {
+		if !bytes.Equal(data[:4], e.ID[:4]) {
+			continue
+		}
+		args, err := e.Inputs.Unpack(data[4:])
+		if err != nil {
+			return "", fmt.Errorf("custom error: %s", err)
+		}
+
+		vals := make([]string, len(args))
+		for i, arg := range args {
+			switch v := arg.(type) {
+			case [32]byte:
+				vals[i] = hexutil.Encode(v[:])
+			default:
+				vals[i] = fmt.Sprint(v)
+			}
+		}
+		return fmt.Sprintf("%s(%s)", e.Name, strings.Join(vals, ", ")), nil
+	}
+	return "", errors.New("custom error: unknown selector")
+}
+
+// NewRevertData returns a RevertData for revert data from the given entity. The data is decoded as an
+// Error(string), Panic(uint256), or a common custom error if possible.
+func NewRevertData(entity string, data []byte) *RevertData {
+	rd := &RevertData{Entity: entity, RevertData: hexutil.Encode(data)}
+	if reason, err := DecodeRevert(data); err == nil {
+		rd.Reason = reason
+	} else if code, err := DecodePanic(data); err == nil {
+		rd.Reason = fmt.Sprintf("panic encountered: %s", code)
+	} else if reason, err := DecodeCustomError(data); err == nil {
+		rd.Reason = reason
+	}
+	return rd
+}
@@ -0,0 +1,56 @@
+package errors
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func pack(t *testing.T, e abi.Error, args ...any) []byte {
+	data, err := e.Inputs.Pack(args...)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	return append(append([]byte{}, e.ID[:4]...), data...)
+}
+
+// TestNewRevertDataReason verifies that an Error(string) revert is decoded.
+func TestNewRevertDataReason(t *testing.T) {
+	rd := NewRevertData("account", pack(t, revertError(), "not owner"))
+	if rd.Reason != "not owner" {
+		t.Fatalf("got %s, want not owner", rd.Reason)
+	}
+	if rd.Entity != "account" {
+		t.Fatalf("got %s, want account", rd.Entity)
+	}
+}
+
+// TestNewRevertDataCustomError verifies that a common custom error is decoded with its arguments.
+func TestNewRevertDataCustomError(t *testing.T) {
+	data := pack(
+		t,
+		customError("ERC20InsufficientBalance", "address", "uint256", "uint256"),
+		common.HexToAddress("0x1"),
+		big.NewInt(0),
+		big.NewInt(100),
+	)
+
+	rd := NewRevertData("paymaster", data)
+	want := "ERC20InsufficientBalance(0x0000000000000000000000000000000000000001, 0, 100)"
+	if rd.Reason != want {
+		t.Fatalf("got %s, want %s", rd.Reason, want)
+	}
+}
+
+// TestNewRevertDataUnknown verifies that unknown revert data is returned as hex without a reason.
+func TestNewRevertDataUnknown(t *testing.T) {
+	rd := NewRevertData("account", []byte{0xde, 0xad, 0xbe, 0xef})
+	if rd.Reason != "" {
+		t.Fatalf("got %s, want empty reason", rd.Reason)
+	}
+	if rd.RevertData != "0xdeadbeef" {
+		t.Fatalf("got %s, want 0xdeadbeef", rd.RevertData)
+	}
+}
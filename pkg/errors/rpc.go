@@ -9,6 +9,13 @@ var (
 	INVALID_ENTITY_STAKE       = -32505
 	INVALID_AGGREGATOR         = -32506
 	INVALID_SIGNATURE          = -32507
+	REJECTED_BY_POLICY         = -32508
+	EXCEEDS_CALL_GAS_CEILING   = -32509
+	EXCEEDS_OP_GAS_CEILING     = -32510
+	SERVICE_UNAVAILABLE        = -32511
+	UNAUTHORIZED_RELAYER       = -32512
+	READ_ONLY                  = -32513
+	UNAUTHORIZED_API_KEY       = -32514
 	INVALID_FIELDS             = -32602
 
 	EXECUTION_REVERTED = -32521
@@ -0,0 +1,82 @@
+// Package federation implements lightweight status beacons that allow independent bundler instances to
+// advertise themselves through a shared registry endpoint. Each instance periodically publishes its own beacon
+// and consumes the beacons of its peers so that smart clients can choose among federated bundlers.
+//
+// The registry is any HTTP endpoint that accepts a beacon as a JSON encoded POST request and returns the
+// latest beacon for each known bundler as a JSON encoded array on a GET request.
+package federation
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+)
+
+// Beacon is a point in time status of a bundler instance.
+type Beacon struct {
+	Url                     string   `json:"url"`
+	ChainID                 string   `json:"chainId"`
+	SupportedEntryPoints    []string `json:"supportedEntryPoints"`
+	MinMaxFeePerGas         string   `json:"minMaxFeePerGas"`
+	MinMaxPriorityFeePerGas string   `json:"minMaxPriorityFeePerGas"`
+	MempoolDepth            int      `json:"mempoolDepth"`
+	ChainHead               string   `json:"chainHead"`
+	Timestamp               int64    `json:"timestamp"`
+}
+
+// GetStatusFunc returns the current status of the local bundler. The Url and Timestamp fields are set by the
+// Node.
+type GetStatusFunc = func() (*Beacon, error)
+
+// GetStatusWithEthClient returns an implementation of GetStatusFunc that relies on an eth client for the
+// current chain head and fees, and the mempool for the number of pending UserOperations.
+func GetStatusWithEthClient(
+	eth *ethclient.Client,
+	mem *mempool.Mempool,
+	chainID *big.Int,
+	eps []common.Address,
+) GetStatusFunc {
+	return func() (*Beacon, error) {
+		head, err := eth.HeaderByNumber(context.Background(), nil)
+		if err != nil {
+			return nil, err
+		}
+		tip, err := eth.SuggestGasTipCap(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		maxFee := big.NewInt(0).Set(tip)
+		if head.BaseFee != nil {
+			maxFee.Add(maxFee, head.BaseFee)
+		}
+
+		depth := 0
+		supported := []string{}
+		for _, ep := range eps {
+			ops, err := mem.Dump(ep)
+			if err != nil {
+				return nil, err
+			}
+			depth += len(ops)
+			supported = append(supported, ep.String())
+		}
+
+		return &Beacon{
+			ChainID:                 hexutil.EncodeBig(chainID),
+			SupportedEntryPoints:    supported,
+			MinMaxFeePerGas:         hexutil.EncodeBig(maxFee),
+			MinMaxPriorityFeePerGas: hexutil.EncodeBig(tip),
+			MempoolDepth:            depth,
+			ChainHead:               hexutil.EncodeBig(head.Number),
+		}, nil
+	}
+}
+
+func (b *Beacon) isExpired(ttl time.Duration) bool {
+	return time.Since(time.Unix(b.Timestamp, 0)) > ttl
+}
@@ -0,0 +1,151 @@
+package federation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+var (
+	// DefaultInterval is the default duration between publishing beacons to the registry.
+	DefaultInterval = 30 * time.Second
+
+	// RequestTimeout is the max duration to wait for a response from the registry.
+	RequestTimeout = 10 * time.Second
+)
+
+// Node publishes the status of the local bundler to a registry and keeps track of the latest beacons from
+// its peers. Peer beacons that have not been refreshed within three intervals are dropped.
+type Node struct {
+	url       string
+	registry  string
+	interval  time.Duration
+	getStatus GetStatusFunc
+	client    *http.Client
+	logger    logr.Logger
+
+	mu    sync.RWMutex
+	peers map[string]*Beacon
+}
+
+// New returns a Node that advertises the local bundler under the given public URL to a registry.
+func New(url string, registry string, getStatus GetStatusFunc, l logr.Logger) *Node {
+	return &Node{
+		url:       url,
+		registry:  registry,
+		interval:  DefaultInterval,
+		getStatus: getStatus,
+		client:    &http.Client{Timeout: RequestTimeout},
+		logger:    l.WithName("federation"),
+		peers:     make(map[string]*Beacon),
+	}
+}
+
+// SetInterval sets the duration between publishing beacons to the registry.
+func (n *Node) SetInterval(d time.Duration) {
+	n.interval = d
+}
+
+func (n *Node) publish() error {
+	b, err := n.getStatus()
+	if err != nil {
+		return err
+	}
+	b.Url = n.url
+	b.Timestamp = time.Now().Unix()
+
+	body, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, n.registry, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("federation: registry returned status %d on publish", res.StatusCode)
+	}
+	return nil
+}
+
+func (n *Node) fetch() error {
+	res, err := n.client.Get(n.registry)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("federation: registry returned status %d on fetch", res.StatusCode)
+	}
+
+	beacons := []*Beacon{}
+	if err := json.NewDecoder(res.Body).Decode(&beacons); err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, b := range beacons {
+		if b.Url == "" || b.Url == n.url {
+			continue
+		}
+		if prev, ok := n.peers[b.Url]; !ok || b.Timestamp > prev.Timestamp {
+			n.peers[b.Url] = b
+		}
+	}
+	for url, b := range n.peers {
+		if b.isExpired(3 * n.interval) {
+			delete(n.peers, url)
+		}
+	}
+	return nil
+}
+
+// Sync publishes the local beacon and refreshes peer beacons from the registry once.
+func (n *Node) Sync() error {
+	if err := n.publish(); err != nil {
+		return err
+	}
+	return n.fetch()
+}
+
+// Run starts a process to sync with the registry at the set interval. Errors are logged and retried on the
+// next interval.
+func (n *Node) Run() {
+	go func() {
+		for {
+			if err := n.Sync(); err != nil {
+				n.logger.Error(err, "federation sync error")
+			}
+			time.Sleep(n.interval)
+		}
+	}()
+}
+
+// Peers returns the latest beacon for each known peer sorted by URL.
+func (n *Node) Peers() []*Beacon {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	peers := []*Beacon{}
+	for _, b := range n.peers {
+		if !b.isExpired(3 * n.interval) {
+			peers = append(peers, b)
+		}
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].Url < peers[j].Url })
+	return peers
+}
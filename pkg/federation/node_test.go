@@ -0,0 +1,79 @@
+package federation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+func newRegistry() *httptest.Server {
+	var mu sync.Mutex
+	beacons := map[string]*Beacon{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodPost {
+			var b Beacon
+			if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			beacons[b.Url] = &b
+			return
+		}
+
+		out := []*Beacon{}
+		for _, b := range beacons {
+			out = append(out, b)
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	}))
+}
+
+func getStatusFunc(depth int) GetStatusFunc {
+	return func() (*Beacon, error) {
+		return &Beacon{ChainID: "0x1", MempoolDepth: depth}, nil
+	}
+}
+
+func TestSyncConsumesPeerBeacons(t *testing.T) {
+	reg := newRegistry()
+	defer reg.Close()
+
+	n1 := New("http://bundler-1", reg.URL, getStatusFunc(1), logr.Discard())
+	n2 := New("http://bundler-2", reg.URL, getStatusFunc(2), logr.Discard())
+	if err := n1.Sync(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := n2.Sync(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := n1.Sync(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	peers := n1.Peers()
+	if len(peers) != 1 {
+		t.Fatalf("got %d peers, want 1", len(peers))
+	} else if peers[0].Url != "http://bundler-2" || peers[0].MempoolDepth != 2 {
+		t.Fatalf("got peer %s with depth %d, want http://bundler-2 with depth 2", peers[0].Url, peers[0].MempoolDepth)
+	}
+}
+
+func TestPeersDropsExpiredBeacons(t *testing.T) {
+	n := New("http://bundler-1", "", getStatusFunc(0), logr.Discard())
+	n.SetInterval(time.Second)
+	n.peers["http://bundler-2"] = &Beacon{
+		Url:       "http://bundler-2",
+		Timestamp: time.Now().Add(-time.Minute).Unix(),
+	}
+
+	if peers := n.Peers(); len(peers) != 0 {
+		t.Fatalf("got %d peers, want 0", len(peers))
+	}
+}
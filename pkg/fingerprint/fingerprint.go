@@ -0,0 +1,75 @@
+// Package fingerprint identifies the smart account implementation of each UserOperation sender so that
+// bundler operators can see which wallet vendors and versions cause reverts or griefing.
+package fingerprint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// GetCodeFunc returns the deployed bytecode at an address.
+type GetCodeFunc = func(addr common.Address) ([]byte, error)
+
+// Known maps account implementations to a label such as "vendor@version". Deployed accounts are matched by the
+// keccak256 hash of their code and undeployed accounts are matched by the factory in their initCode.
+type Known struct {
+	CodeHashes map[common.Hash]string
+	Factories  map[common.Address]string
+}
+
+// ParseKnown parses a list of values in the format "<key>=<label>". A 32 byte key is matched against the code
+// hash of deployed accounts and a 20 byte key is matched against the factory of undeployed accounts.
+func ParseKnown(values []string) (*Known, error) {
+	k := &Known{CodeHashes: map[common.Hash]string{}, Factories: map[common.Address]string{}}
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+
+		key, label, ok := strings.Cut(v, "=")
+		if !ok || label == "" {
+			return nil, fmt.Errorf("fingerprint: %s must be in the format <key>=<label>", v)
+		}
+		b, err := hexutil.Decode(key)
+		if err != nil {
+			return nil, fmt.Errorf("fingerprint: %s: %w", key, err)
+		}
+		switch len(b) {
+		case common.HashLength:
+			k.CodeHashes[common.BytesToHash(b)] = label
+		case common.AddressLength:
+			k.Factories[common.BytesToAddress(b)] = label
+		default:
+			return nil, fmt.Errorf("fingerprint: %s must be a code hash or factory address", key)
+		}
+	}
+	return k, nil
+}
+
+// Identify returns the fingerprint of the sender's account implementation. If the implementation is not known,
+// the fingerprint is derived from the code hash or factory address so that similar accounts are still grouped
+// together.
+func (k *Known) Identify(getCode GetCodeFunc, op *userop.UserOperation) (string, error) {
+	if len(op.InitCode) > 0 {
+		factory := op.GetFactory()
+		if label, ok := k.Factories[factory]; ok {
+			return label, nil
+		}
+		return "factory:" + strings.ToLower(factory.Hex()), nil
+	}
+
+	code, err := getCode(op.Sender)
+	if err != nil {
+		return "", err
+	}
+	hash := crypto.Keccak256Hash(code)
+	if label, ok := k.CodeHashes[hash]; ok {
+		return label, nil
+	}
+	return "code:" + hash.Hex()[:10], nil
+}
@@ -0,0 +1,104 @@
+package fingerprint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+)
+
+func getCodeMock(code []byte) GetCodeFunc {
+	return func(addr common.Address) ([]byte, error) {
+		return code, nil
+	}
+}
+
+// TestParseKnown verifies that code hashes and factories are parsed by key length.
+func TestParseKnown(t *testing.T) {
+	hash := crypto.Keccak256Hash(testutils.MockByteCode)
+	k, err := ParseKnown([]string{
+		hash.Hex() + "=wallet@1.0.0",
+		testutils.ValidAddress1.Hex() + "=wallet-factory@1.0.0",
+	})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if k.CodeHashes[hash] != "wallet@1.0.0" {
+		t.Fatalf("got %s, want wallet@1.0.0", k.CodeHashes[hash])
+	}
+	if k.Factories[testutils.ValidAddress1] != "wallet-factory@1.0.0" {
+		t.Fatalf("got %s, want wallet-factory@1.0.0", k.Factories[testutils.ValidAddress1])
+	}
+}
+
+// TestParseKnownInvalid verifies that malformed values return an error.
+func TestParseKnownInvalid(t *testing.T) {
+	for _, v := range []string{"0x1234=wallet", testutils.ValidAddress1.Hex(), "zz=wallet"} {
+		if _, err := ParseKnown([]string{v}); err == nil {
+			t.Fatalf("%s: got nil, want err", v)
+		}
+	}
+}
+
+// TestIdentifyDeployedAccount verifies that deployed accounts are matched by code hash and that unknown code
+// is grouped by a short code hash.
+func TestIdentifyDeployedAccount(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	op.InitCode = []byte{}
+	hash := crypto.Keccak256Hash(testutils.MockByteCode)
+
+	k, _ := ParseKnown([]string{hash.Hex() + "=wallet@1.0.0"})
+	if fp, err := k.Identify(getCodeMock(testutils.MockByteCode), op); err != nil || fp != "wallet@1.0.0" {
+		t.Fatalf("got %s, %v, want wallet@1.0.0", fp, err)
+	}
+
+	k, _ = ParseKnown([]string{})
+	fp, err := k.Identify(getCodeMock(testutils.MockByteCode), op)
+	if err != nil || fp != "code:"+hash.Hex()[:10] {
+		t.Fatalf("got %s, %v, want code:%s", fp, err, hash.Hex()[:10])
+	}
+}
+
+// TestIdentifyUndeployedAccount verifies that undeployed accounts are matched by factory.
+func TestIdentifyUndeployedAccount(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+
+	k, _ := ParseKnown([]string{op.GetFactory().Hex() + "=wallet@1.0.0"})
+	if fp, err := k.Identify(getCodeMock(nil), op); err != nil || fp != "wallet@1.0.0" {
+		t.Fatalf("got %s, %v, want wallet@1.0.0", fp, err)
+	}
+
+	k, _ = ParseKnown([]string{})
+	want := "factory:" + strings.ToLower(op.GetFactory().Hex())
+	if fp, err := k.Identify(getCodeMock(nil), op); err != nil || fp != want {
+		t.Fatalf("got %s, %v, want %s", fp, err, want)
+	}
+}
+
+// TestRecordAndGet verifies that a recorded fingerprint can be fetched by userOpHash.
+func TestRecordAndGet(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	k, _ := ParseKnown([]string{})
+	tr, err := New(db, getCodeMock(nil), k, logr.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := testutils.MockValidInitUserOp()
+	ctx := &modules.UserOpHandlerCtx{UserOp: op, EntryPoint: testutils.ValidAddress1, ChainID: testutils.ChainID}
+
+	if err := tr.Record()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	fp, err := tr.Get(op.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if want := "factory:" + strings.ToLower(op.GetFactory().Hex()); fp != want {
+		t.Fatalf("got %s, want %s", fp, want)
+	}
+}
@@ -0,0 +1,145 @@
+package fingerprint
+
+import (
+	"context"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/dbutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var (
+	// KeyPrefix is the prefix for all keys stored in the DB by the fingerprint package.
+	KeyPrefix = "fingerprint"
+
+	// RecordTTL is how long the fingerprint of a UserOperation is kept after submission.
+	RecordTTL = 7 * 24 * time.Hour
+
+	opPrefix = dbutils.JoinValues(KeyPrefix, "op")
+)
+
+func getOpKey(userOpHash common.Hash) []byte {
+	return []byte(dbutils.JoinValues(opPrefix, userOpHash.String()))
+}
+
+// Tracker stores the fingerprint of each UserOperation and records per account implementation metrics.
+type Tracker struct {
+	db       *badger.DB
+	getCode  GetCodeFunc
+	known    *Known
+	logger   logr.Logger
+	received metric.Int64Counter
+	included metric.Int64Counter
+	dropped  metric.Int64Counter
+}
+
+// New returns a Tracker that uses the global meter provider for per account implementation metrics.
+func New(db *badger.DB, getCode GetCodeFunc, known *Known, l logr.Logger) (*Tracker, error) {
+	meter := otel.GetMeterProvider().Meter("fingerprint")
+	received, err := meter.Int64Counter("bundler_account_ops_received")
+	if err != nil {
+		return nil, err
+	}
+	included, err := meter.Int64Counter("bundler_account_ops_included")
+	if err != nil {
+		return nil, err
+	}
+	dropped, err := meter.Int64Counter("bundler_account_ops_dropped")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tracker{
+		db:       db,
+		getCode:  getCode,
+		known:    known,
+		logger:   l.WithName("fingerprint"),
+		received: received,
+		included: included,
+		dropped:  dropped,
+	}, nil
+}
+
+// Record returns a UserOpHandler that stores the fingerprint of the sender's account implementation. This
+// module should be used after validation so that only accepted ops are counted.
+func (t *Tracker) Record() modules.UserOpHandlerFunc {
+	return func(ctx *modules.UserOpHandlerCtx) error {
+		fp, err := t.known.Identify(t.getCode, ctx.UserOp)
+		if err != nil {
+			return err
+		}
+
+		hash := ctx.UserOp.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)
+		err = t.db.Update(func(txn *badger.Txn) error {
+			e := badger.NewEntry(getOpKey(hash), []byte(fp)).WithTTL(RecordTTL)
+			return txn.SetEntry(e)
+		})
+		if err != nil {
+			return err
+		}
+
+		t.received.Add(context.Background(), 1, metric.WithAttributes(attribute.String("account", fp)))
+		return nil
+	}
+}
+
+// Get returns the fingerprint of a UserOperation. An empty string is returned if the op was not recorded.
+func (t *Tracker) Get(userOpHash common.Hash) (string, error) {
+	fp := ""
+	err := t.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(getOpKey(userOpHash))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			fp = string(val)
+			return nil
+		})
+	})
+
+	return fp, err
+}
+
+// Track returns a BatchHandler used by the Bundler to record metrics and events for ops in a batch that has
+// been sent and ops that were dropped during the run. This module should be used after the relayer.
+func (t *Tracker) Track() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		for _, op := range ctx.Batch {
+			fp, err := t.Get(op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID))
+			if err != nil {
+				return err
+			} else if fp == "" {
+				continue
+			}
+
+			t.included.Add(context.Background(), 1, metric.WithAttributes(attribute.String("account", fp)))
+		}
+
+		for _, item := range ctx.PendingRemoval {
+			hash := item.Op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)
+			fp, err := t.Get(hash)
+			if err != nil {
+				return err
+			} else if fp == "" {
+				continue
+			}
+
+			t.dropped.Add(context.Background(), 1, metric.WithAttributes(attribute.String("account", fp)))
+			t.logger.
+				WithValues("userop_hash", hash.String()).
+				WithValues("account", fp).
+				WithValues("reason", item.Reason).
+				Info("account op dropped")
+		}
+		return nil
+	}
+}
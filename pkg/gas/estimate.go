@@ -15,7 +15,6 @@ import (
 var (
 	fallBackBinarySearchCutoff = int64(30000)
 	maxRetries                 = int64(7)
-	baseVGLBuffer              = int64(25)
 )
 
 func isPrefundNotPaid(err error) bool {
@@ -52,6 +51,10 @@ type EstimateInput struct {
 	MaxGasLimit *big.Int
 	Tracer      string
 
+	// VGLMargin is the percentage added to the minimum verificationGasLimit found by simulation. It is applied
+	// to validation gas net of any refunds observed in the trace.
+	VGLMargin int64
+
 	attempts int64
 	lastVGL  int64
 }
@@ -70,6 +73,7 @@ func retryEstimateGas(err error, vgl int64, in *EstimateInput) (uint64, uint64,
 			ChainID:     in.ChainID,
 			MaxGasLimit: in.MaxGasLimit,
 			Tracer:      in.Tracer,
+			VGLMargin:   in.VGLMargin,
 			attempts:    in.attempts + 1,
 			lastVGL:     vgl,
 		})
@@ -77,6 +81,17 @@ func retryEstimateGas(err error, vgl int64, in *EstimateInput) (uint64, uint64,
 	return 0, 0, err
 }
 
+// withVGLMargin returns the verificationGasLimit with a safety margin applied on top of the minimum value
+// found by simulation. Gas refunded during validation is not charged, so the margin only covers validation gas
+// net of refunds. The result is never below the minimum.
+func withVGLMargin(vgl int64, refund int64, margin int64) int64 {
+	net := vgl - refund
+	if net < 0 {
+		net = 0
+	}
+	return vgl + (net*margin)/100
+}
+
 // EstimateGas uses the simulateHandleOp method on the EntryPoint to derive an estimate for
 // verificationGasLimit and callGasLimit.
 func EstimateGas(in *EstimateInput) (verificationGas uint64, callGas uint64, err error) {
@@ -143,8 +158,7 @@ func EstimateGas(in *EstimateInput) (verificationGas uint64, callGas uint64, err
 	if f == 0 {
 		return 0, 0, simErr
 	}
-	f = (f * (100 + baseVGLBuffer)) / 100
-	data["verificationGasLimit"] = hexutil.EncodeBig(big.NewInt(int64(f)))
+	data["verificationGasLimit"] = hexutil.EncodeBig(big.NewInt(withVGLMargin(f, 0, in.VGLMargin)))
 
 	// Find the optimal callGasLimit by setting the gas price to 0 and maxing out the gas limit. We use the
 	// original state override to account for insufficient balance reverts unless the caller has explicitly
@@ -170,7 +184,7 @@ func EstimateGas(in *EstimateInput) (verificationGas uint64, callGas uint64, err
 	}
 
 	// Calculate final values for verificationGasLimit and callGasLimit.
-	vgl := simOp.VerificationGasLimit
+	vgl := big.NewInt(withVGLMargin(f, int64(out.Trace.ValidationRefund), in.VGLMargin))
 	cgl := big.NewInt(int64(out.Trace.ExecutionGasLimit))
 	if cgl.Cmp(in.Ov.NonZeroValueCall()) < 0 {
 		cgl = in.Ov.NonZeroValueCall()
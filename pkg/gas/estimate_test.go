@@ -0,0 +1,23 @@
+package gas
+
+import "testing"
+
+// TestWithVGLMargin verifies that the verificationGasLimit margin is applied to validation gas net of refunds
+// and that the result is never below the minimum found by simulation.
+func TestWithVGLMargin(t *testing.T) {
+	for _, tc := range []struct {
+		vgl    int64
+		refund int64
+		margin int64
+		want   int64
+	}{
+		{vgl: 100000, refund: 0, margin: 25, want: 125000},
+		{vgl: 100000, refund: 20000, margin: 25, want: 120000},
+		{vgl: 100000, refund: 150000, margin: 25, want: 100000},
+		{vgl: 100000, refund: 20000, margin: 0, want: 100000},
+	} {
+		if got := withVGLMargin(tc.vgl, tc.refund, tc.margin); got != tc.want {
+			t.Fatalf("got %d for %+v, want %d", got, tc, tc.want)
+		}
+	}
+}
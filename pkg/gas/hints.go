@@ -0,0 +1,52 @@
+package gas
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+var (
+	maxSignatureLength = 8192
+
+	ErrBadSignatureHint = errors.New("cannot decode signature hint")
+)
+
+// SignatureHint provides a realistic signature for gas estimation. This is useful for accounts with signature
+// schemes that differ in length from a standard ECDSA signature (e.g. passkeys or BLS).
+type SignatureHint struct {
+	DummySignature  *hexutil.Bytes  `json:"dummySignature"`
+	SignatureLength *hexutil.Uint64 `json:"signatureLength"`
+}
+
+// ParseSignatureHint decodes a map into a SignatureHint and validates all the fields are correctly typed.
+func ParseSignatureHint(data map[string]any) (*SignatureHint, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBadSignatureHint, err)
+	}
+
+	sh := &SignatureHint{}
+	if err := json.Unmarshal(b, sh); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBadSignatureHint, err)
+	}
+	if sh.SignatureLength != nil && uint64(*sh.SignatureLength) > uint64(maxSignatureLength) {
+		return nil, fmt.Errorf("%w: signatureLength exceeds %d bytes", ErrBadSignatureHint, maxSignatureLength)
+	}
+	return sh, nil
+}
+
+// Apply replaces the signature on a UserOperation with the dummy signature if given. Otherwise if only a
+// length is given, the signature is replaced with non-zero bytes of that length so that calldata costs are not
+// underestimated.
+func (sh *SignatureHint) Apply(op *userop.UserOperation) {
+	if sh.DummySignature != nil {
+		op.Signature = append([]byte{}, *sh.DummySignature...)
+	} else if sh.SignatureLength != nil && len(op.Signature) != int(*sh.SignatureLength) {
+		op.Signature = bytes.Repeat([]byte{0xff}, int(*sh.SignatureLength))
+	}
+}
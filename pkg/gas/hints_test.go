@@ -0,0 +1,67 @@
+package gas_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+)
+
+// TestSignatureHintDummySignature verifies that a dummy signature replaces the signature on the UserOperation.
+func TestSignatureHintDummySignature(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	sh, err := gas.ParseSignatureHint(map[string]any{"dummySignature": "0xdeadbeef"})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	sh.Apply(op)
+	if !bytes.Equal(op.Signature, []byte{0xde, 0xad, 0xbe, 0xef}) {
+		t.Fatalf("got %x, want deadbeef", op.Signature)
+	}
+}
+
+// TestSignatureHintLength verifies that a signature length replaces the signature with non-zero bytes of that
+// length.
+func TestSignatureHintLength(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	sh, err := gas.ParseSignatureHint(map[string]any{"signatureLength": "0x100"})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	sh.Apply(op)
+	if !bytes.Equal(op.Signature, bytes.Repeat([]byte{0xff}, 256)) {
+		t.Fatalf("got %x, want 256 non-zero bytes", op.Signature)
+	}
+}
+
+// TestSignatureHintEmpty verifies that an empty hint leaves the signature unchanged.
+func TestSignatureHintEmpty(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	sig := append([]byte{}, op.Signature...)
+	sh, err := gas.ParseSignatureHint(map[string]any{})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	sh.Apply(op)
+	if !bytes.Equal(op.Signature, sig) {
+		t.Fatalf("got %x, want %x", op.Signature, sig)
+	}
+}
+
+// TestSignatureHintInvalid verifies that badly typed fields and lengths over the max are rejected.
+func TestSignatureHintInvalid(t *testing.T) {
+	for _, data := range []map[string]any{
+		{"dummySignature": "not hex"},
+		{"signatureLength": 65},
+		{"signatureLength": "0x2001"},
+	} {
+		if _, err := gas.ParseSignatureHint(data); !errors.Is(err, gas.ErrBadSignatureHint) {
+			t.Fatalf("got %v for %v, want %v", err, data, gas.ErrBadSignatureHint)
+		}
+	}
+}
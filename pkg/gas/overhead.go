@@ -28,6 +28,7 @@ type Overhead struct {
 	sanitizedCGL        *big.Int
 	calcPVGFunc         CalcPreVerificationGasFunc
 	pvgBufferFactor     int64
+	pvgBoundsBlocks     uint64
 }
 
 // NewDefaultOverhead returns an instance of Overhead using parameters defined by the Ethereum protocol.
@@ -48,6 +49,7 @@ func NewDefaultOverhead() *Overhead {
 		sanitizedCGL:        big.NewInt(1000000),
 		calcPVGFunc:         calcPVGFuncNoop(),
 		pvgBufferFactor:     0,
+		pvgBoundsBlocks:     3,
 	}
 }
 
@@ -65,6 +67,14 @@ func (ov *Overhead) SetPreVerificationGasBufferFactor(factor int64) {
 	ov.pvgBufferFactor = factor
 }
 
+// SetPreVerificationGasBoundsBlocks defines the number of blocks that the recommended preVerificationGas
+// returned in *Overhead.CalcPreVerificationGasBounds should remain valid for.
+//
+// The default value is 3.
+func (ov *Overhead) SetPreVerificationGasBoundsBlocks(blocks uint64) {
+	ov.pvgBoundsBlocks = blocks
+}
+
 // CalcCallDataCost calculates the additional gas cost required to serialize the userOp when making the
 // transaction to submit the entire batch.
 func (ov *Overhead) CalcCallDataCost(op *userop.UserOperation) float64 {
@@ -95,10 +105,17 @@ func (ov *Overhead) CalcPerUserOpCost(op *userop.UserOperation) float64 {
 
 // CalcPreVerificationGas returns an expected gas cost for processing a UserOperation from a batch.
 func (ov *Overhead) CalcPreVerificationGas(op *userop.UserOperation) (*big.Int, error) {
+	_, pvg, err := ov.calcPreVerificationGas(op)
+	return pvg, err
+}
+
+// calcPreVerificationGas returns both the static PVG derived from the default overheads and the PVG from
+// CalcPreVerificationGasFunc. The difference between the two is the L1 gas component on rollups.
+func (ov *Overhead) calcPreVerificationGas(op *userop.UserOperation) (*big.Int, *big.Int, error) {
 	// Sanitize fields to reduce as much variability due to length and zero bytes
 	data, err := op.ToMap()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	data["preVerificationGas"] = hexutil.EncodeBig(ov.sanitizedPVG)
 	data["verificationGasLimit"] = hexutil.EncodeBig(ov.sanitizedVGL)
@@ -106,7 +123,7 @@ func (ov *Overhead) CalcPreVerificationGas(op *userop.UserOperation) (*big.Int,
 	data["signature"] = hexutil.Encode(bytes.Repeat([]byte{1}, len(op.Signature)))
 	tmp, err := userop.New(data)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Calculate the additional gas for adding this userOp to a batch.
@@ -120,12 +137,12 @@ func (ov *Overhead) CalcPreVerificationGas(op *userop.UserOperation) (*big.Int,
 	// Use value from CalcPreVerificationGasFunc if set, otherwise return the static value.
 	g, err := ov.calcPVGFunc(tmp, static)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if g != nil {
-		return g, nil
+		return static, g, nil
 	}
-	return static, nil
+	return static, static, nil
 }
 
 // CalcPreVerificationGasWithBuffer returns CalcPreVerificationGas increased by the set PVG buffer factor.
@@ -134,7 +151,42 @@ func (ov *Overhead) CalcPreVerificationGasWithBuffer(op *userop.UserOperation) (
 	if err != nil {
 		return nil, err
 	}
-	return utils.AddBuffer(pvg, ov.pvgBufferFactor), nil
+	return ov.AddPreVerificationGasBuffer(pvg), nil
+}
+
+// AddPreVerificationGasBuffer returns pvg increased by the set PVG buffer factor.
+func (ov *Overhead) AddPreVerificationGasBuffer(pvg *big.Int) *big.Int {
+	return utils.AddBuffer(pvg, ov.pvgBufferFactor)
+}
+
+// CalcPreVerificationGasBounds returns the range of preVerificationGas the bundler currently accepts for a
+// UserOperation. The recommended value assumes the L1 gas component rises by the max EIP-1559 basefee change
+// in every block for the set number of blocks so that an op using it will not be rejected if it is sent
+// shortly after an estimate. On networks without an L1 gas component, this is equal to the buffered PVG.
+func (ov *Overhead) CalcPreVerificationGasBounds(op *userop.UserOperation) (*PreVerificationGasBounds, error) {
+	static, pvg, err := ov.calcPreVerificationGas(op)
+	if err != nil {
+		return nil, err
+	}
+
+	l1 := big.NewInt(0).Sub(pvg, static)
+	if l1.Sign() < 0 {
+		l1 = big.NewInt(0)
+	}
+	for i := uint64(0); i < ov.pvgBoundsBlocks; i++ {
+		// The basefee can increase by at most 12.5% per block.
+		l1 = big.NewInt(0).Div(big.NewInt(0).Mul(l1, big.NewInt(1125)), big.NewInt(1000))
+	}
+	rec := big.NewInt(0).Add(static, l1)
+	if buf := ov.AddPreVerificationGasBuffer(pvg); buf.Cmp(rec) > 0 {
+		rec = buf
+	}
+
+	return &PreVerificationGasBounds{
+		Min:            pvg,
+		Recommended:    rec,
+		ValidForBlocks: ov.pvgBoundsBlocks,
+	}, nil
 }
 
 // NonZeroValueCall returns an expected gas cost of using the CALL opcode with non-zero value.
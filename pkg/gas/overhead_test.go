@@ -0,0 +1,71 @@
+package gas_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// TestCalcPreVerificationGasBoundsNoL1 verifies that the recommended PVG is equal to the min PVG on networks
+// without an L1 gas component.
+func TestCalcPreVerificationGasBoundsNoL1(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	ov := gas.NewDefaultOverhead()
+	pvg, _ := ov.CalcPreVerificationGas(op)
+
+	b, err := ov.CalcPreVerificationGasBounds(op)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if b.Min.Cmp(pvg) != 0 {
+		t.Fatalf("got min %s, want %s", b.Min, pvg)
+	}
+	if b.Recommended.Cmp(pvg) != 0 {
+		t.Fatalf("got recommended %s, want %s", b.Recommended, pvg)
+	}
+	if b.ValidForBlocks != 3 {
+		t.Fatalf("got %d, want 3", b.ValidForBlocks)
+	}
+}
+
+// TestCalcPreVerificationGasBoundsWithL1 verifies that the recommended PVG increases the L1 gas component by
+// the max basefee change for each block.
+func TestCalcPreVerificationGasBoundsWithL1(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	ov := gas.NewDefaultOverhead()
+	static, _ := ov.CalcPreVerificationGas(op)
+	ov.SetCalcPreVerificationGasFunc(func(op *userop.UserOperation, static *big.Int) (*big.Int, error) {
+		return big.NewInt(0).Add(static, big.NewInt(64000)), nil
+	})
+	ov.SetPreVerificationGasBoundsBlocks(2)
+
+	b, err := ov.CalcPreVerificationGasBounds(op)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if want := big.NewInt(0).Add(static, big.NewInt(64000)); b.Min.Cmp(want) != 0 {
+		t.Fatalf("got min %s, want %s", b.Min, want)
+	}
+	if want := big.NewInt(0).Add(static, big.NewInt(81000)); b.Recommended.Cmp(want) != 0 {
+		t.Fatalf("got recommended %s, want %s", b.Recommended, want)
+	}
+}
+
+// TestCalcPreVerificationGasBoundsWithBuffer verifies that the recommended PVG is at least the buffered PVG.
+func TestCalcPreVerificationGasBoundsWithBuffer(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	ov := gas.NewDefaultOverhead()
+	ov.SetPreVerificationGasBufferFactor(16)
+	pvg, _ := ov.CalcPreVerificationGasWithBuffer(op)
+
+	b, err := ov.CalcPreVerificationGasBounds(op)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if b.Recommended.Cmp(pvg) != 0 {
+		t.Fatalf("got recommended %s, want %s", b.Recommended, pvg)
+	}
+}
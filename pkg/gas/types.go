@@ -10,4 +10,16 @@ type GasEstimates struct {
 
 	// TODO: Deprecate in v0.7
 	VerificationGas *big.Int `json:"verificationGas"`
+
+	// PreVerificationGasBounds is an extension field and is omitted if not set.
+	PreVerificationGasBounds *PreVerificationGasBounds `json:"preVerificationGasBounds,omitempty"`
+}
+
+// PreVerificationGasBounds provides the range of preVerificationGas the bundler accepts given the current
+// basefee and L1 data cost. Min is the lowest value that will currently pass validation. Recommended is
+// expected to remain valid for at least ValidForBlocks blocks.
+type PreVerificationGasBounds struct {
+	Min            *big.Int `json:"min"`
+	Recommended    *big.Int `json:"recommended"`
+	ValidForBlocks uint64   `json:"validForBlocks"`
 }
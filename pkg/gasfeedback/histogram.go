@@ -0,0 +1,64 @@
+package gasfeedback
+
+// Buckets are the upper bounds, in percent, of each Histogram bucket. Values above the last bound are counted
+// in an overflow bucket.
+var Buckets = []int{25, 50, 60, 70, 80, 85, 90, 95, 100}
+
+// Histogram counts the ratio of gas used to a gas value in percent.
+type Histogram struct {
+	Buckets []int    `json:"buckets"`
+	Counts  []uint64 `json:"counts"`
+}
+
+func newHistogram() *Histogram {
+	return &Histogram{
+		Buckets: append([]int{}, Buckets...),
+		Counts:  make([]uint64, len(Buckets)+1),
+	}
+}
+
+// Total returns the number of observations.
+func (h *Histogram) Total() uint64 {
+	total := uint64(0)
+	for _, c := range h.Counts {
+		total += c
+	}
+	return total
+}
+
+// Observe adds a value in percent to the Histogram.
+func (h *Histogram) Observe(pct int) {
+	for i, b := range h.Buckets {
+		if pct <= b {
+			h.Counts[i]++
+			return
+		}
+	}
+	h.Counts[len(h.Buckets)]++
+}
+
+// Quantile returns the upper bound of the bucket containing the q quantile. If it is in the overflow bucket,
+// the last bound plus the distance to the previous bound is returned. 0 is returned if the Histogram is empty.
+func (h *Histogram) Quantile(q float64) int {
+	total := h.Total()
+	if total == 0 {
+		return 0
+	}
+
+	rank := uint64(q * float64(total))
+	if rank == 0 {
+		rank = 1
+	}
+	seen := uint64(0)
+	for i, b := range h.Buckets {
+		seen += h.Counts[i]
+		if seen >= rank {
+			return b
+		}
+	}
+	n := len(h.Buckets)
+	if n < 2 {
+		return h.Buckets[n-1]
+	}
+	return h.Buckets[n-1] + (h.Buckets[n-1] - h.Buckets[n-2])
+}
@@ -0,0 +1,309 @@
+// Package gasfeedback compares the gas used by included UserOperations against the limits they supplied and
+// the bundler's own estimates. The distribution is kept per account implementation and used to tune the
+// padding added to future estimates for implementations that use more gas than estimated.
+package gasfeedback
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/dbutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+var (
+	// KeyPrefix is the prefix for all keys stored in the DB by the gasfeedback package.
+	KeyPrefix = "gasfeedback"
+
+	// EstimateTTL is how long an estimate is kept for comparing against an included op.
+	EstimateTTL = time.Hour
+
+	// DefaultTargetUtilization is the default percent of the estimate that ops are expected to use.
+	DefaultTargetUtilization = 90
+
+	// DefaultMaxPadding is the default upper limit on the percent added to CallGasLimit estimates.
+	DefaultMaxPadding = 50
+
+	// DefaultMinSamples is the default number of estimated ops required before padding is applied.
+	DefaultMinSamples = uint64(20)
+
+	estimatePrefix = dbutils.JoinValues(KeyPrefix, "estimate")
+	statsPrefix    = dbutils.JoinValues(KeyPrefix, "stats")
+)
+
+func getEstimateKey(ep common.Address, sender common.Address, nonce *big.Int) []byte {
+	return []byte(dbutils.JoinValues(estimatePrefix, ep.String(), sender.String(), nonce.String()))
+}
+
+func getStatsKey(account string) []byte {
+	return []byte(dbutils.JoinValues(statsPrefix, account))
+}
+
+// GetReceiptFunc returns the receipt of a transaction.
+type GetReceiptFunc = func(txHash common.Hash) (*types.Receipt, error)
+
+// IdentifyFunc returns the account implementation of a UserOperation sender.
+type IdentifyFunc = func(op *userop.UserOperation) (string, error)
+
+// Stats is the gas usage distribution of an account implementation. LimitUtilization is the actualGasUsed
+// as a percent of the max gas the op supplied. EstimateUtilization is the actualGasUsed as a percent of the
+// bundler's estimate and only includes ops that were estimated by this bundler.
+type Stats struct {
+	Account             string     `json:"account"`
+	Ops                 uint64     `json:"ops"`
+	Failed              uint64     `json:"failed"`
+	LimitUtilization    *Histogram `json:"limitUtilization"`
+	EstimateUtilization *Histogram `json:"estimateUtilization"`
+	PaddingPercent      int        `json:"paddingPercent"`
+}
+
+func newStats(account string) *Stats {
+	return &Stats{
+		Account:             account,
+		LimitUtilization:    newHistogram(),
+		EstimateUtilization: newHistogram(),
+	}
+}
+
+// Tracker records the gas usage of included ops per account implementation.
+type Tracker struct {
+	db         *badger.DB
+	getReceipt GetReceiptFunc
+	identify   IdentifyFunc
+	logger     logr.Logger
+	target     int
+	maxPadding int
+	minSamples uint64
+}
+
+// New returns a Tracker that persists gas usage stats to the given DB.
+func New(db *badger.DB, getReceipt GetReceiptFunc, identify IdentifyFunc, l logr.Logger) *Tracker {
+	return &Tracker{
+		db:         db,
+		getReceipt: getReceipt,
+		identify:   identify,
+		logger:     l.WithName("gasfeedback"),
+		target:     DefaultTargetUtilization,
+		maxPadding: DefaultMaxPadding,
+		minSamples: DefaultMinSamples,
+	}
+}
+
+// SetTargetUtilization sets the percent of the estimate that ops are expected to use. Padding is added when
+// the 95th percentile of EstimateUtilization is above the target.
+//
+// The default value is 90.
+func (t *Tracker) SetTargetUtilization(pct int) {
+	t.target = pct
+}
+
+// SetMaxPadding sets the upper limit on the percent added to CallGasLimit estimates. A value of 0 only
+// records stats without tuning estimates.
+//
+// The default value is 50.
+func (t *Tracker) SetMaxPadding(pct int) {
+	t.maxPadding = pct
+}
+
+// SetMinSamples sets the number of estimated ops required for an account implementation before padding is
+// applied.
+//
+// The default value is 20.
+func (t *Tracker) SetMinSamples(n uint64) {
+	t.minSamples = n
+}
+
+func (t *Tracker) padding(s *Stats) int {
+	if s.EstimateUtilization.Total() < t.minSamples {
+		return 0
+	}
+
+	pad := s.EstimateUtilization.Quantile(0.95) - t.target
+	if pad < 0 {
+		return 0
+	} else if pad > t.maxPadding {
+		return t.maxPadding
+	}
+	return pad
+}
+
+func (t *Tracker) getStats(txn *badger.Txn, account string) (*Stats, error) {
+	item, err := txn.Get(getStatsKey(account))
+	if err == badger.ErrKeyNotFound {
+		return newStats(account), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	s := &Stats{}
+	err = item.Value(func(val []byte) error {
+		return json.Unmarshal(val, s)
+	})
+	return s, err
+}
+
+// RecordEstimate stores the total gas estimate of an op so that it can be compared against the actualGasUsed
+// once the op is included.
+func (t *Tracker) RecordEstimate(ep common.Address, op *userop.UserOperation, est *gas.GasEstimates) error {
+	total := big.NewInt(0).Add(est.PreVerificationGas, est.VerificationGasLimit)
+	total.Add(total, est.CallGasLimit)
+	return t.db.Update(func(txn *badger.Txn) error {
+		e := badger.NewEntry(getEstimateKey(ep, op.Sender, op.Nonce), total.Bytes()).WithTTL(EstimateTTL)
+		return txn.SetEntry(e)
+	})
+}
+
+// Padding returns the percent to add to the CallGasLimit estimate of an op based on the gas usage of previous
+// ops from the same account implementation.
+func (t *Tracker) Padding(op *userop.UserOperation) (int, error) {
+	if t.maxPadding <= 0 {
+		return 0, nil
+	}
+
+	account, err := t.identify(op)
+	if err != nil {
+		return 0, err
+	}
+	var s *Stats
+	err = t.db.View(func(txn *badger.Txn) error {
+		s, err = t.getStats(txn, account)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return t.padding(s), nil
+}
+
+// Stats returns the gas usage stats of all account implementations seen so far.
+func (t *Tracker) Stats() ([]*Stats, error) {
+	all := []*Stats{}
+	err := t.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(dbutils.JoinValues(statsPrefix, ""))
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			err := it.Item().Value(func(val []byte) error {
+				s := &Stats{}
+				if err := json.Unmarshal(val, s); err != nil {
+					return err
+				}
+				s.PaddingPercent = t.padding(s)
+				all = append(all, s)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return all, err
+}
+
+// percentOf returns a as a percent of b.
+func percentOf(a *big.Int, b *big.Int) int {
+	if b.Sign() <= 0 {
+		return 0
+	}
+	return int(big.NewInt(0).Div(big.NewInt(0).Mul(a, big.NewInt(100)), b).Int64())
+}
+
+func (t *Tracker) record(
+	txn *badger.Txn,
+	ep common.Address,
+	op *userop.UserOperation,
+	ev *entrypoint.EntrypointUserOperationEvent,
+) error {
+	account, err := t.identify(op)
+	if err != nil {
+		return err
+	}
+	s, err := t.getStats(txn, account)
+	if err != nil {
+		return err
+	}
+
+	s.Ops++
+	if !ev.Success {
+		s.Failed++
+	}
+	s.LimitUtilization.Observe(percentOf(ev.ActualGasUsed, op.GetMaxGasAvailable()))
+
+	item, err := txn.Get(getEstimateKey(ep, op.Sender, op.Nonce))
+	if err == nil {
+		err = item.Value(func(val []byte) error {
+			s.EstimateUtilization.Observe(percentOf(ev.ActualGasUsed, big.NewInt(0).SetBytes(val)))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := txn.Delete(item.KeyCopy(nil)); err != nil {
+			return err
+		}
+	} else if err != badger.ErrKeyNotFound {
+		return err
+	}
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return txn.Set(getStatsKey(account), b)
+}
+
+// Track returns a BatchHandler used by the Bundler to record the gas usage of ops in a batch once it has been
+// included. This module should be used after the relayer with a wait timeout so that the receipt is available.
+// Batches without a receipt are skipped.
+func (t *Tracker) Track() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		txHash, ok := ctx.Data["txn_hash"].(string)
+		if !ok || len(ctx.Batch) == 0 {
+			return nil
+		}
+		l := t.logger.WithValues("txn_hash", txHash)
+
+		receipt, err := t.getReceipt(common.HexToHash(txHash))
+		if err != nil {
+			l.Info("skipping gas feedback without receipt", "error", err.Error())
+			return nil
+		}
+		ep, err := entrypoint.NewEntrypointFilterer(ctx.EntryPoint, nil)
+		if err != nil {
+			return err
+		}
+		events := map[common.Hash]*entrypoint.EntrypointUserOperationEvent{}
+		for _, log := range receipt.Logs {
+			if log.Address != ctx.EntryPoint || len(log.Topics) == 0 {
+				continue
+			}
+			if ev, err := ep.ParseUserOperationEvent(*log); err == nil {
+				events[ev.UserOpHash] = ev
+			}
+		}
+
+		return t.db.Update(func(txn *badger.Txn) error {
+			for _, op := range ctx.Batch {
+				ev, ok := events[op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)]
+				if !ok {
+					continue
+				}
+				if err := t.record(txn, ctx.EntryPoint, op, ev); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+}
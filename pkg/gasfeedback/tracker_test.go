@@ -0,0 +1,138 @@
+package gasfeedback
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func identifyMock(op *userop.UserOperation) (string, error) {
+	return "wallet@1.0.0", nil
+}
+
+func newTrackerMock(db *badger.DB) *Tracker {
+	return New(db, nil, identifyMock, logr.Discard())
+}
+
+func recordUsage(t *testing.T, gf *Tracker, op *userop.UserOperation, estimate int64, used int64) {
+	est := &gas.GasEstimates{
+		PreVerificationGas:   big.NewInt(0),
+		VerificationGasLimit: big.NewInt(0),
+		CallGasLimit:         big.NewInt(estimate),
+	}
+	if err := gf.RecordEstimate(testutils.ValidAddress1, op, est); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	ev := &entrypoint.EntrypointUserOperationEvent{Success: true, ActualGasUsed: big.NewInt(used)}
+	err := gf.db.Update(func(txn *badger.Txn) error {
+		return gf.record(txn, testutils.ValidAddress1, op, ev)
+	})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
+
+// TestHistogramQuantile verifies that quantiles return the upper bound of the matching bucket.
+func TestHistogramQuantile(t *testing.T) {
+	h := newHistogram()
+	if q := h.Quantile(0.95); q != 0 {
+		t.Fatalf("got %d, want 0", q)
+	}
+
+	for i := 0; i < 19; i++ {
+		h.Observe(50)
+	}
+	h.Observe(120)
+	if q := h.Quantile(0.5); q != 50 {
+		t.Fatalf("got %d, want 50", q)
+	}
+	if q := h.Quantile(1); q != 105 {
+		t.Fatalf("got %d, want 105", q)
+	}
+}
+
+// TestPaddingBelowMinSamples verifies that no padding is applied until enough ops have been estimated.
+func TestPaddingBelowMinSamples(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	gf := newTrackerMock(db)
+
+	op := testutils.MockValidInitUserOp()
+	recordUsage(t, gf, op, 100000, 130000)
+	if pad, err := gf.Padding(op); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if pad != 0 {
+		t.Fatalf("got %d, want 0", pad)
+	}
+}
+
+// TestPaddingAboveTarget verifies that padding is the distance from the target to the 95th percentile of
+// estimate utilization, capped at the max padding.
+func TestPaddingAboveTarget(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	gf := newTrackerMock(db)
+	gf.SetMinSamples(2)
+
+	op := testutils.MockValidInitUserOp()
+	for i := 0; i < 2; i++ {
+		op.Nonce = big.NewInt(int64(i))
+		recordUsage(t, gf, op, 100000, 99000)
+	}
+	if pad, err := gf.Padding(op); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if pad != 10 {
+		t.Fatalf("got %d, want 10", pad)
+	}
+
+	gf.SetMaxPadding(5)
+	if pad, err := gf.Padding(op); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if pad != 5 {
+		t.Fatalf("got %d, want 5", pad)
+	}
+}
+
+// TestStats verifies that stats count included ops and only compare estimates for ops estimated by the
+// bundler.
+func TestStats(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	gf := newTrackerMock(db)
+
+	op := testutils.MockValidInitUserOp()
+	recordUsage(t, gf, op, 100000, 50000)
+	ev := &entrypoint.EntrypointUserOperationEvent{Success: false, ActualGasUsed: big.NewInt(1)}
+	op.Nonce = big.NewInt(1)
+	err := db.Update(func(txn *badger.Txn) error {
+		return gf.record(txn, common.Address{}, op, ev)
+	})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	all, err := gf.Stats()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(all) != 1 {
+		t.Fatalf("got %d stats, want 1", len(all))
+	}
+	s := all[0]
+	if s.Account != "wallet@1.0.0" || s.Ops != 2 || s.Failed != 1 {
+		t.Fatalf("got %+v, want 2 ops with 1 failed", s)
+	}
+	if s.LimitUtilization.Total() != 2 || s.EstimateUtilization.Total() != 1 {
+		t.Fatalf(
+			"got %d limit and %d estimate observations, want 2 and 1",
+			s.LimitUtilization.Total(),
+			s.EstimateUtilization.Total(),
+		)
+	}
+}
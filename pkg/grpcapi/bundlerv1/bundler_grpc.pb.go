@@ -0,0 +1,256 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: bundler/v1/bundler.proto
+
+package bundlerv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Bundler_SendUserOperation_FullMethodName = "/bundler.v1.Bundler/SendUserOperation"
+	Bundler_EstimateGas_FullMethodName       = "/bundler.v1.Bundler/EstimateGas"
+	Bundler_GetReceipt_FullMethodName        = "/bundler.v1.Bundler/GetReceipt"
+	Bundler_StreamStatus_FullMethodName      = "/bundler.v1.Bundler/StreamStatus"
+)
+
+// BundlerClient is the client API for Bundler service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BundlerClient interface {
+	// SendUserOperation validates a UserOperation and adds it to the mempool.
+	SendUserOperation(ctx context.Context, in *SendUserOperationRequest, opts ...grpc.CallOption) (*SendUserOperationResponse, error)
+	// EstimateGas returns gas estimates for a UserOperation.
+	EstimateGas(ctx context.Context, in *EstimateGasRequest, opts ...grpc.CallOption) (*EstimateGasResponse, error)
+	// GetReceipt returns the receipt of an included UserOperation.
+	GetReceipt(ctx context.Context, in *GetReceiptRequest, opts ...grpc.CallOption) (*GetReceiptResponse, error)
+	// StreamStatus sends the status of a UserOperation each time it changes until it reaches a terminal status.
+	StreamStatus(ctx context.Context, in *StreamStatusRequest, opts ...grpc.CallOption) (Bundler_StreamStatusClient, error)
+}
+
+type bundlerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBundlerClient(cc grpc.ClientConnInterface) BundlerClient {
+	return &bundlerClient{cc}
+}
+
+func (c *bundlerClient) SendUserOperation(ctx context.Context, in *SendUserOperationRequest, opts ...grpc.CallOption) (*SendUserOperationResponse, error) {
+	out := new(SendUserOperationResponse)
+	err := c.cc.Invoke(ctx, Bundler_SendUserOperation_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bundlerClient) EstimateGas(ctx context.Context, in *EstimateGasRequest, opts ...grpc.CallOption) (*EstimateGasResponse, error) {
+	out := new(EstimateGasResponse)
+	err := c.cc.Invoke(ctx, Bundler_EstimateGas_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bundlerClient) GetReceipt(ctx context.Context, in *GetReceiptRequest, opts ...grpc.CallOption) (*GetReceiptResponse, error) {
+	out := new(GetReceiptResponse)
+	err := c.cc.Invoke(ctx, Bundler_GetReceipt_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bundlerClient) StreamStatus(ctx context.Context, in *StreamStatusRequest, opts ...grpc.CallOption) (Bundler_StreamStatusClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Bundler_ServiceDesc.Streams[0], Bundler_StreamStatus_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &bundlerStreamStatusClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Bundler_StreamStatusClient interface {
+	Recv() (*StatusUpdate, error)
+	grpc.ClientStream
+}
+
+type bundlerStreamStatusClient struct {
+	grpc.ClientStream
+}
+
+func (x *bundlerStreamStatusClient) Recv() (*StatusUpdate, error) {
+	m := new(StatusUpdate)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// BundlerServer is the server API for Bundler service.
+// All implementations must embed UnimplementedBundlerServer
+// for forward compatibility
+type BundlerServer interface {
+	// SendUserOperation validates a UserOperation and adds it to the mempool.
+	SendUserOperation(context.Context, *SendUserOperationRequest) (*SendUserOperationResponse, error)
+	// EstimateGas returns gas estimates for a UserOperation.
+	EstimateGas(context.Context, *EstimateGasRequest) (*EstimateGasResponse, error)
+	// GetReceipt returns the receipt of an included UserOperation.
+	GetReceipt(context.Context, *GetReceiptRequest) (*GetReceiptResponse, error)
+	// StreamStatus sends the status of a UserOperation each time it changes until it reaches a terminal status.
+	StreamStatus(*StreamStatusRequest, Bundler_StreamStatusServer) error
+	mustEmbedUnimplementedBundlerServer()
+}
+
+// UnimplementedBundlerServer must be embedded to have forward compatible implementations.
+type UnimplementedBundlerServer struct {
+}
+
+func (UnimplementedBundlerServer) SendUserOperation(context.Context, *SendUserOperationRequest) (*SendUserOperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendUserOperation not implemented")
+}
+func (UnimplementedBundlerServer) EstimateGas(context.Context, *EstimateGasRequest) (*EstimateGasResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EstimateGas not implemented")
+}
+func (UnimplementedBundlerServer) GetReceipt(context.Context, *GetReceiptRequest) (*GetReceiptResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReceipt not implemented")
+}
+func (UnimplementedBundlerServer) StreamStatus(*StreamStatusRequest, Bundler_StreamStatusServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamStatus not implemented")
+}
+func (UnimplementedBundlerServer) mustEmbedUnimplementedBundlerServer() {}
+
+// UnsafeBundlerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BundlerServer will
+// result in compilation errors.
+type UnsafeBundlerServer interface {
+	mustEmbedUnimplementedBundlerServer()
+}
+
+func RegisterBundlerServer(s grpc.ServiceRegistrar, srv BundlerServer) {
+	s.RegisterService(&Bundler_ServiceDesc, srv)
+}
+
+func _Bundler_SendUserOperation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendUserOperationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BundlerServer).SendUserOperation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Bundler_SendUserOperation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BundlerServer).SendUserOperation(ctx, req.(*SendUserOperationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Bundler_EstimateGas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EstimateGasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BundlerServer).EstimateGas(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Bundler_EstimateGas_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BundlerServer).EstimateGas(ctx, req.(*EstimateGasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Bundler_GetReceipt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReceiptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BundlerServer).GetReceipt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Bundler_GetReceipt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BundlerServer).GetReceipt(ctx, req.(*GetReceiptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Bundler_StreamStatus_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamStatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BundlerServer).StreamStatus(m, &bundlerStreamStatusServer{stream})
+}
+
+type Bundler_StreamStatusServer interface {
+	Send(*StatusUpdate) error
+	grpc.ServerStream
+}
+
+type bundlerStreamStatusServer struct {
+	grpc.ServerStream
+}
+
+func (x *bundlerStreamStatusServer) Send(m *StatusUpdate) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Bundler_ServiceDesc is the grpc.ServiceDesc for Bundler service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Bundler_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bundler.v1.Bundler",
+	HandlerType: (*BundlerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SendUserOperation",
+			Handler:    _Bundler_SendUserOperation_Handler,
+		},
+		{
+			MethodName: "EstimateGas",
+			Handler:    _Bundler_EstimateGas_Handler,
+		},
+		{
+			MethodName: "GetReceipt",
+			Handler:    _Bundler_GetReceipt_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamStatus",
+			Handler:       _Bundler_StreamStatus_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "bundler/v1/bundler.proto",
+}
@@ -0,0 +1,228 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stdErr "errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/grpcapi/bundlerv1"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// The JSON-RPC middleware (API keys, rate limits, size limits, etc.) reads the methods and params from a
+// JSON-RPC request and the errors from a JSON-RPC response. gRPC calls are run through the same middleware by
+// presenting each call as an HTTP request with the equivalent JSON-RPC body. Metadata is passed as headers
+// and the peer address is used as the client IP.
+
+type callKey struct{}
+
+// call holds the gRPC handler for a request while it is run through the middleware.
+type call struct {
+	run func() error
+	ran bool
+	err error
+}
+
+type rpcRequest struct {
+	JsonRpc string `json:"jsonrpc"`
+	Id      int    `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+type rpcResponse struct {
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    any    `json:"data"`
+	} `json:"error"`
+}
+
+// responseBuffer is a minimal http.ResponseWriter for running middleware outside of an HTTP server.
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *responseBuffer) Header() http.Header {
+	return w.header
+}
+
+func (w *responseBuffer) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *responseBuffer) WriteHeader(status int) {
+	w.status = status
+}
+
+// rpcStatus is a gRPC status error that keeps the JSON-RPC error it was converted from.
+type rpcStatus struct {
+	st  *status.Status
+	err *errors.RPCError
+}
+
+func (e *rpcStatus) Error() string {
+	return e.st.Err().Error()
+}
+
+// GRPCStatus is used by grpc to send the status to the client.
+func (e *rpcStatus) GRPCStatus() *status.Status {
+	return e.st
+}
+
+// toRPC returns the JSON-RPC request that is equivalent to a gRPC request message.
+func toRPC(req any) *rpcRequest {
+	r := &rpcRequest{JsonRpc: "2.0", Id: 1, Params: []any{}}
+	switch m := req.(type) {
+	case *bundlerv1.SendUserOperationRequest:
+		r.Method = jsonrpc.SendUserOperationMethod
+		r.Params = []any{toMap(m.UserOp), m.EntryPoint}
+	case *bundlerv1.EstimateGasRequest:
+		r.Method = "eth_estimateUserOperationGas"
+		r.Params = []any{toMap(m.UserOp), m.EntryPoint}
+	case *bundlerv1.GetReceiptRequest:
+		r.Method = "eth_getUserOperationReceipt"
+		r.Params = []any{m.UserOpHash}
+	case *bundlerv1.StreamStatusRequest:
+		r.Method = "bundler_getUserOperationStatus"
+		r.Params = []any{m.UserOpHash}
+	}
+	return r
+}
+
+// final is the last handler in the middleware chain. It runs the gRPC handler and writes the equivalent
+// JSON-RPC response for the middleware to inspect.
+func final(g *gin.Context) {
+	c := g.Request.Context().Value(callKey{}).(*call)
+	c.ran = true
+	c.err = c.run()
+	if c.err == nil {
+		g.JSON(http.StatusOK, gin.H{"jsonrpc": "2.0", "id": 1, "result": nil})
+		return
+	}
+
+	var rs *rpcStatus
+	if stdErr.As(c.err, &rs) {
+		g.JSON(http.StatusOK, jsonrpc.ErrorResponse(rs.err.Code(), rs.err.Error(), rs.err.Data(), 1))
+		return
+	}
+	g.JSON(http.StatusOK, jsonrpc.ErrorResponse(-32603, "Internal error", c.err.Error(), 1))
+}
+
+// fromRPCError returns the gRPC status for a JSON-RPC error written by middleware that aborted a call.
+func fromRPCError(w *responseBuffer) error {
+	var res rpcResponse
+	if err := json.Unmarshal(w.body.Bytes(), &res); err != nil || res.Error == nil {
+		return status.Error(codes.Internal, "request rejected")
+	}
+	return toStatus(errors.NewRPCError(res.Error.Code, res.Error.Message, res.Error.Data))
+}
+
+type middlewareChain struct {
+	engine *gin.Engine
+}
+
+func newMiddlewareChain(middleware []gin.HandlerFunc) *middlewareChain {
+	engine := gin.New()
+	// The peer address is the client IP since there are no proxies between the client and a gRPC server.
+	_ = engine.SetTrustedProxies(nil)
+	engine.POST("/", append(append([]gin.HandlerFunc{}, middleware...), final)...)
+	return &middlewareChain{engine}
+}
+
+// serve runs a gRPC call through the middleware. The handler in c is only run if no middleware rejects the
+// call.
+func (m *middlewareChain) serve(ctx context.Context, req any, c *call) error {
+	body, err := json.Marshal(toRPC(req))
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	ctx = context.WithValue(ctx, callKey{}, c)
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, "/", bytes.NewReader(body))
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for k, vals := range md {
+			if strings.HasPrefix(k, ":") || k == "content-type" {
+				continue
+			}
+			for _, v := range vals {
+				r.Header.Add(k, v)
+			}
+		}
+	}
+	r.Header.Set("Content-Type", "application/json")
+	if p, ok := peer.FromContext(ctx); ok {
+		r.RemoteAddr = p.Addr.String()
+	}
+
+	w := &responseBuffer{header: http.Header{}, status: http.StatusOK}
+	m.engine.ServeHTTP(w, r)
+	if !c.ran {
+		return fromRPCError(w)
+	}
+	return c.err
+}
+
+// checkedStream runs the first message received on a server stream through the middleware.
+type checkedStream struct {
+	grpc.ServerStream
+	m       *middlewareChain
+	checked bool
+}
+
+func (s *checkedStream) RecvMsg(msg any) error {
+	if err := s.ServerStream.RecvMsg(msg); err != nil {
+		return err
+	}
+	if s.checked {
+		return nil
+	}
+	s.checked = true
+	return s.m.serve(s.Context(), msg, &call{run: func() error { return nil }})
+}
+
+// MiddlewareOptions returns options for a gRPC server that run every call through the same middleware as the
+// JSON-RPC API. Each call is seen by the middleware as the equivalent JSON-RPC method and a rejected call
+// returns the middleware's error as a gRPC status. For streams, only the request that opens the stream is
+// checked.
+func MiddlewareOptions(middleware ...gin.HandlerFunc) []grpc.ServerOption {
+	m := newMiddlewareChain(middleware)
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(
+			ctx context.Context,
+			req any,
+			info *grpc.UnaryServerInfo,
+			handler grpc.UnaryHandler,
+		) (any, error) {
+			var res any
+			err := m.serve(ctx, req, &call{run: func() error {
+				var err error
+				res, err = handler(ctx, req)
+				return err
+			}})
+			return res, err
+		}),
+		grpc.ChainStreamInterceptor(func(
+			srv any,
+			ss grpc.ServerStream,
+			info *grpc.StreamServerInfo,
+			handler grpc.StreamHandler,
+		) error {
+			return handler(srv, &checkedStream{ServerStream: ss, m: m})
+		}),
+	}
+}
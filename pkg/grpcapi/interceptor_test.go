@@ -0,0 +1,132 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/filter"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/grpcapi/bundlerv1"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// captureWriter records the response body written by the handlers after a middleware.
+type captureWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func deny(g *gin.Context) {
+	jsonrpc.AbortWithError(g, errors.UNAUTHORIZED_API_KEY, "invalid api key", nil)
+}
+
+// TestMiddlewareRejectsCall verifies that a call rejected by middleware returns the middleware's error as a
+// gRPC status and never reaches the Server.
+func TestMiddlewareRejectsCall(t *testing.T) {
+	called := false
+	c := newTestClient(t)
+	c.SetGetUserOpReceiptFunc(func(hash string, ep common.Address, blkRange uint64) (*filter.UserOperationReceipt, error) {
+		called = true
+		return nil, nil
+	})
+
+	_, err := dial(t, c, MiddlewareOptions(deny)...).GetReceipt(
+		context.Background(),
+		&bundlerv1.GetReceiptRequest{UserOpHash: testHash},
+	)
+	if got := status.Code(err); got != codes.PermissionDenied {
+		t.Fatalf("got %v, want %v", got, codes.PermissionDenied)
+	}
+	if called {
+		t.Fatal("got call to Server, want none")
+	}
+}
+
+// TestMiddlewareSeesJSONRPC verifies that middleware sees a call as the equivalent JSON-RPC request with the
+// metadata as headers.
+func TestMiddlewareSeesJSONRPC(t *testing.T) {
+	var method, key string
+	var params []any
+	record := func(g *gin.Context) {
+		body, _ := g.GetRawData()
+		reqs := jsonrpc.ParseRequests(body)
+		if len(reqs) == 1 {
+			method, params = reqs[0].Method, reqs[0].Params
+		}
+		key = g.GetHeader("X-Api-Key")
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "secret")
+	_, err := dial(t, newTestClient(t), MiddlewareOptions(record)...).GetReceipt(
+		ctx,
+		&bundlerv1.GetReceiptRequest{UserOpHash: testHash},
+	)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if method != "eth_getUserOperationReceipt" || len(params) != 1 || params[0] != testHash {
+		t.Fatalf("got %s %v, want eth_getUserOperationReceipt [%s]", method, params, testHash)
+	}
+	if key != "secret" {
+		t.Fatalf("got key %q, want secret", key)
+	}
+}
+
+// TestMiddlewareSeesJSONRPCError verifies that middleware sees an error from the Server as the equivalent
+// JSON-RPC error and that the client still gets the mapped gRPC status.
+func TestMiddlewareSeesJSONRPCError(t *testing.T) {
+	c := newTestClient(t)
+	c.SetGetUserOpReceiptFunc(func(hash string, ep common.Address, blkRange uint64) (*filter.UserOperationReceipt, error) {
+		return nil, errors.NewRPCError(errors.INVALID_FIELDS, "bad hash", nil)
+	})
+
+	code := 0
+	record := func(g *gin.Context) {
+		w := &captureWriter{ResponseWriter: g.Writer}
+		g.Writer = w
+		g.Next()
+
+		var res rpcResponse
+		if err := json.Unmarshal(w.body.Bytes(), &res); err == nil && res.Error != nil {
+			code = res.Error.Code
+		}
+	}
+
+	_, err := dial(t, c, MiddlewareOptions(record)...).GetReceipt(
+		context.Background(),
+		&bundlerv1.GetReceiptRequest{UserOpHash: testHash},
+	)
+	if got := status.Code(err); got != codes.InvalidArgument {
+		t.Fatalf("got %v, want %v", got, codes.InvalidArgument)
+	}
+	if code != errors.INVALID_FIELDS {
+		t.Fatalf("got code %d, want %d", code, errors.INVALID_FIELDS)
+	}
+}
+
+// TestMiddlewareRejectsStream verifies that a stream is rejected if the request that opens it is rejected by
+// middleware.
+func TestMiddlewareRejectsStream(t *testing.T) {
+	stream, err := dial(t, newTestClient(t), MiddlewareOptions(deny)...).StreamStatus(
+		context.Background(),
+		&bundlerv1.StreamStatusRequest{UserOpHash: testHash},
+	)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("got %v, want %v", err, codes.PermissionDenied)
+	}
+}
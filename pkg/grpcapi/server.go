@@ -0,0 +1,187 @@
+// Package grpcapi implements a gRPC service for sending UserOperations and tracking their status alongside the
+// JSON-RPC API. This allows infra teams to integrate the bundler into internal service meshes with typed
+// clients and streaming. The service definition is in proto/bundler/v1/bundler.proto.
+package grpcapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/stackup-wallet/stackup-bundler/pkg/client"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/grpcapi/bundlerv1"
+	"github.com/stackup-wallet/stackup-bundler/pkg/opstatus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultPollInterval is the time between status lookups for each StreamStatus call.
+var DefaultPollInterval = time.Second
+
+// Server implements the Bundler gRPC service using the same Client as the JSON-RPC API.
+type Server struct {
+	bundlerv1.UnimplementedBundlerServer
+
+	client       *client.Client
+	pollInterval time.Duration
+}
+
+// New returns a Server for the given Client.
+func New(c *client.Client) *Server {
+	return &Server{
+		client:       c,
+		pollInterval: DefaultPollInterval,
+	}
+}
+
+// SetPollInterval sets the time between status lookups for each StreamStatus call.
+//
+// The default value is 1 second.
+func (s *Server) SetPollInterval(interval time.Duration) {
+	s.pollInterval = interval
+}
+
+// Register adds the Bundler service to a gRPC server.
+func (s *Server) Register(gs *grpc.Server) {
+	bundlerv1.RegisterBundlerServer(gs, s)
+}
+
+// toMap converts a UserOperation to the map form accepted by the Client.
+func toMap(op *bundlerv1.UserOperation) map[string]any {
+	if op == nil {
+		op = &bundlerv1.UserOperation{}
+	}
+	return map[string]any{
+		"sender":               op.Sender,
+		"nonce":                op.Nonce,
+		"initCode":             op.InitCode,
+		"callData":             op.CallData,
+		"callGasLimit":         op.CallGasLimit,
+		"verificationGasLimit": op.VerificationGasLimit,
+		"preVerificationGas":   op.PreVerificationGas,
+		"maxFeePerGas":         op.MaxFeePerGas,
+		"maxPriorityFeePerGas": op.MaxPriorityFeePerGas,
+		"paymasterAndData":     op.PaymasterAndData,
+		"signature":            op.Signature,
+	}
+}
+
+// toStatus converts an error from the Client to a gRPC status error. The JSON-RPC error code is mapped to the
+// closest gRPC code.
+func toStatus(err error) error {
+	rpcErr, ok := err.(*errors.RPCError)
+	if !ok {
+		return status.Error(codes.Unknown, err.Error())
+	}
+
+	code := codes.FailedPrecondition
+	switch rpcErr.Code() {
+	case errors.INVALID_FIELDS:
+		code = codes.InvalidArgument
+	case errors.BANNED_OR_THROTTLED_ENTITY:
+		code = codes.ResourceExhausted
+	case errors.SERVICE_UNAVAILABLE:
+		code = codes.Unavailable
+	case errors.UNAUTHORIZED_RELAYER, errors.UNAUTHORIZED_API_KEY:
+		code = codes.PermissionDenied
+	}
+	return &rpcStatus{st: status.New(code, rpcErr.Error()), err: rpcErr}
+}
+
+// SendUserOperation implements the Bundler service.
+func (s *Server) SendUserOperation(
+	ctx context.Context,
+	req *bundlerv1.SendUserOperationRequest,
+) (*bundlerv1.SendUserOperationResponse, error) {
+	hash, err := s.client.SendUserOperation(toMap(req.UserOp), req.EntryPoint, nil)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &bundlerv1.SendUserOperationResponse{UserOpHash: hash}, nil
+}
+
+// EstimateGas implements the Bundler service.
+func (s *Server) EstimateGas(
+	ctx context.Context,
+	req *bundlerv1.EstimateGasRequest,
+) (*bundlerv1.EstimateGasResponse, error) {
+	est, err := s.client.EstimateUserOperationGas(toMap(req.UserOp), req.EntryPoint, nil, nil)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &bundlerv1.EstimateGasResponse{
+		PreVerificationGas:   est.PreVerificationGas.String(),
+		VerificationGasLimit: est.VerificationGasLimit.String(),
+		CallGasLimit:         est.CallGasLimit.String(),
+	}, nil
+}
+
+// GetReceipt implements the Bundler service.
+func (s *Server) GetReceipt(
+	ctx context.Context,
+	req *bundlerv1.GetReceiptRequest,
+) (*bundlerv1.GetReceiptResponse, error) {
+	ev, err := s.client.GetUserOperationReceipt(req.UserOpHash)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if ev == nil {
+		return &bundlerv1.GetReceiptResponse{}, nil
+	}
+
+	r := &bundlerv1.Receipt{
+		UserOpHash:    ev.UserOpHash.String(),
+		EntryPoint:    ev.EntryPoint.String(),
+		Sender:        ev.Sender.String(),
+		Paymaster:     ev.Paymaster.String(),
+		Nonce:         ev.Nonce,
+		Success:       ev.Success,
+		ActualGasCost: ev.ActualGasCost,
+		ActualGasUsed: ev.ActualGasUsed,
+	}
+	if ev.Receipt != nil {
+		r.TransactionHash = ev.Receipt.TransactionHash.String()
+		r.BlockHash = ev.Receipt.BlockHash.String()
+		r.BlockNumber = ev.Receipt.BlockNumber
+	}
+	if ev.Reason != nil {
+		r.RevertReason = ev.Reason.Reason
+	}
+	return &bundlerv1.GetReceiptResponse{Receipt: r}, nil
+}
+
+// StreamStatus implements the Bundler service. The status is looked up at each poll interval and sent when
+// it changes. The stream ends once a terminal status is sent.
+func (s *Server) StreamStatus(req *bundlerv1.StreamStatusRequest, stream bundlerv1.Bundler_StreamStatusServer) error {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	var last *opstatus.Record
+	for {
+		rec, err := s.client.GetUserOperationStatus(req.UserOpHash)
+		if err != nil {
+			return toStatus(err)
+		}
+		if rec != nil && (last == nil || *rec != *last) {
+			if err := stream.Send(&bundlerv1.StatusUpdate{
+				Status:          rec.Status,
+				TransactionHash: rec.TransactionHash,
+				Reason:          rec.Reason,
+				UpdatedAt:       rec.UpdatedAt,
+			}); err != nil {
+				return err
+			}
+			if opstatus.IsTerminal(rec.Status) {
+				return nil
+			}
+			last = rec
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-ticker.C:
+		}
+	}
+}
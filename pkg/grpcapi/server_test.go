@@ -0,0 +1,149 @@
+package grpcapi
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/client"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/filter"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/grpcapi/bundlerv1"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/opstatus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var testHash = common.HexToHash("0x01").String()
+
+func newTestClient(t *testing.T) *client.Client {
+	db := testutils.DBMock()
+	t.Cleanup(func() { db.Close() })
+	mem, err := mempool.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client.New(mem, gas.NewDefaultOverhead(), testutils.ChainID, []common.Address{testutils.ValidAddress1}, 1)
+}
+
+func dial(t *testing.T, c *client.Client, opts ...grpc.ServerOption) bundlerv1.BundlerClient {
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer(opts...)
+	s := New(c)
+	s.SetPollInterval(time.Millisecond)
+	s.Register(gs)
+	go func() { _ = gs.Serve(lis) }()
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.Dial(
+		"bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return bundlerv1.NewBundlerClient(conn)
+}
+
+// TestGetReceipt verifies that a receipt from the Client is converted to the protobuf message.
+func TestGetReceipt(t *testing.T) {
+	c := newTestClient(t)
+	c.SetGetUserOpReceiptFunc(func(hash string, ep common.Address, blkRange uint64) (*filter.UserOperationReceipt, error) {
+		return &filter.UserOperationReceipt{
+			UserOpHash: common.HexToHash(hash),
+			EntryPoint: ep,
+			Success:    true,
+			Nonce:      "0x1",
+		}, nil
+	})
+
+	res, err := dial(t, c).GetReceipt(context.Background(), &bundlerv1.GetReceiptRequest{UserOpHash: testHash})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if res.Receipt == nil || !res.Receipt.Success || res.Receipt.EntryPoint != testutils.ValidAddress1.String() {
+		t.Fatalf("got %v, want successful receipt", res.Receipt)
+	}
+}
+
+// TestGetReceiptNotFound verifies that no receipt is returned for an op that has not been included.
+func TestGetReceiptNotFound(t *testing.T) {
+	res, err := dial(t, newTestClient(t)).GetReceipt(
+		context.Background(),
+		&bundlerv1.GetReceiptRequest{UserOpHash: testHash},
+	)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if res.Receipt != nil {
+		t.Fatalf("got %v, want nil", res.Receipt)
+	}
+}
+
+// TestStreamStatus verifies that each status change is streamed until a terminal status is reached.
+func TestStreamStatus(t *testing.T) {
+	statuses := []string{opstatus.Pending, opstatus.Pending, opstatus.Bundling, opstatus.Dropped}
+	i := 0
+	c := newTestClient(t)
+	c.SetGetUserOpStatusFunc(func(hash common.Hash) (*opstatus.Record, error) {
+		r := &opstatus.Record{Status: statuses[i]}
+		if i < len(statuses)-1 {
+			i++
+		}
+		return r, nil
+	})
+
+	stream, err := dial(t, c).StreamStatus(context.Background(), &bundlerv1.StreamStatusRequest{UserOpHash: testHash})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	got := []string{}
+	for {
+		u, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		got = append(got, u.Status)
+	}
+
+	want := []string{opstatus.Pending, opstatus.Bundling, opstatus.Dropped}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for j := range want {
+		if got[j] != want[j] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+// TestToStatus verifies that JSON-RPC error codes are mapped to gRPC codes.
+func TestToStatus(t *testing.T) {
+	cases := map[int]codes.Code{
+		errors.INVALID_FIELDS:             codes.InvalidArgument,
+		errors.BANNED_OR_THROTTLED_ENTITY: codes.ResourceExhausted,
+		errors.SERVICE_UNAVAILABLE:        codes.Unavailable,
+		errors.REJECTED_BY_POLICY:         codes.FailedPrecondition,
+	}
+	for rpcCode, want := range cases {
+		err := toStatus(errors.NewRPCError(rpcCode, "msg", nil))
+		if got := status.Code(err); got != want {
+			t.Fatalf("got %v for %d, want %v", got, rpcCode, want)
+		}
+	}
+}
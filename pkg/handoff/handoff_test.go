@@ -0,0 +1,173 @@
+package handoff
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+var testDict = userop.NewDictionary(testutils.MockValidInitUserOp().InitCode[:24])
+
+func newTestBackend(t *testing.T, handlers ...modules.UserOpHandlerFunc) (*mempool.Mempool, string) {
+	db := testutils.DBMock()
+	t.Cleanup(func() { db.Close() })
+	mem, err := mempool.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	Routes(r, mem, testutils.ChainID, []common.Address{testutils.ValidAddress1}, testDict, handlers...)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return mem, srv.URL
+}
+
+func newTestFrontend(t *testing.T) *mempool.Mempool {
+	db := testutils.DBMock()
+	t.Cleanup(func() { db.Close() })
+	mem, err := mempool.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mem
+}
+
+// TestPushAddsOpToBackend verifies that a handed off UserOperation is added to the back-end mempool without
+// any changes.
+func TestPushAddsOpToBackend(t *testing.T) {
+	mem, url := newTestBackend(t)
+	op := testutils.MockValidInitUserOp()
+	ctx := &modules.UserOpHandlerCtx{UserOp: op, EntryPoint: testutils.ValidAddress1, ChainID: testutils.ChainID}
+
+	if err := Push(url, DefaultTimeout, nil)(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	ops, err := mem.GetOps(testutils.ValidAddress1, op.Sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 1 {
+		t.Fatalf("got length %d, want 1", len(ops))
+	}
+	if !testutils.IsOpsEqual(ops[0], op) {
+		t.Fatalf("ops not equal: %s", testutils.GetOpsDiff(ops[0], op))
+	}
+}
+
+// TestPushCompressedAddsOpToBackend verifies that a compressed UserOperation is added to the back-end
+// mempool without any changes.
+func TestPushCompressedAddsOpToBackend(t *testing.T) {
+	mem, url := newTestBackend(t)
+	op := testutils.MockValidInitUserOp()
+	ctx := &modules.UserOpHandlerCtx{UserOp: op, EntryPoint: testutils.ValidAddress1, ChainID: testutils.ChainID}
+
+	if err := Push(url, DefaultTimeout, testDict)(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	ops, err := mem.GetOps(testutils.ValidAddress1, op.Sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 1 {
+		t.Fatalf("got length %d, want 1", len(ops))
+	}
+	if !testutils.IsOpsEqual(ops[0], op) {
+		t.Fatalf("ops not equal: %s", testutils.GetOpsDiff(ops[0], op))
+	}
+}
+
+// TestPushCompressedDictionaryMismatch verifies that the back-end rejects UserOperations compressed with a
+// different dictionary.
+func TestPushCompressedDictionaryMismatch(t *testing.T) {
+	_, url := newTestBackend(t)
+	ctx := &modules.UserOpHandlerCtx{
+		UserOp:     testutils.MockValidInitUserOp(),
+		EntryPoint: testutils.ValidAddress1,
+		ChainID:    testutils.ChainID,
+	}
+
+	if err := Push(url, DefaultTimeout, userop.NewDictionary())(ctx); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
+
+// TestPushReturnsBackendError verifies that an error is returned to the sender if the back-end does not
+// accept the UserOperation.
+func TestPushReturnsBackendError(t *testing.T) {
+	mem, url := newTestBackend(t, func(ctx *modules.UserOpHandlerCtx) error {
+		return errors.New("rejected")
+	})
+	op := testutils.MockValidInitUserOp()
+	ctx := &modules.UserOpHandlerCtx{UserOp: op, EntryPoint: testutils.ValidAddress1, ChainID: testutils.ChainID}
+
+	if err := Push(url, DefaultTimeout, nil)(ctx); err == nil {
+		t.Fatal("got nil, want err")
+	}
+	if ops, _ := mem.Dump(testutils.ValidAddress1); len(ops) != 0 {
+		t.Fatalf("got length %d, want 0", len(ops))
+	}
+}
+
+// TestPushUnsupportedEntryPoint verifies that the back-end rejects UserOperations for unsupported
+// EntryPoints.
+func TestPushUnsupportedEntryPoint(t *testing.T) {
+	_, url := newTestBackend(t)
+	ctx := &modules.UserOpHandlerCtx{
+		UserOp:     testutils.MockValidInitUserOp(),
+		EntryPoint: testutils.ValidAddress2,
+		ChainID:    testutils.ChainID,
+	}
+
+	if err := Push(url, DefaultTimeout, nil)(ctx); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
+
+// TestSyncRemovesOpsNoLongerPending verifies that the front-end drops UserOperations that have been removed
+// from the back-end mempool.
+func TestSyncRemovesOpsNoLongerPending(t *testing.T) {
+	backend, url := newTestBackend(t)
+	frontend := newTestFrontend(t)
+	ep := testutils.ValidAddress1
+	op := testutils.MockValidInitUserOp()
+	ctx := &modules.UserOpHandlerCtx{UserOp: op, EntryPoint: ep, ChainID: testutils.ChainID}
+	if err := Push(url, DefaultTimeout, nil)(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := frontend.AddOp(ep, op); err != nil {
+		t.Fatal(err)
+	}
+	rec := NewReconciler(frontend, url, testutils.ChainID, []common.Address{ep}, logr.Discard())
+
+	if err := rec.Sync(context.Background()); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if ops, _ := frontend.Dump(ep); len(ops) != 1 {
+		t.Fatalf("got length %d, want 1", len(ops))
+	}
+
+	if err := backend.RemoveOps(ep, op); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	if err := rec.Sync(context.Background()); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if ops, _ := frontend.Dump(ep); len(ops) != 0 {
+		t.Fatalf("got length %d, want 0", len(ops))
+	}
+}
@@ -0,0 +1,93 @@
+// Package handoff allows the client and bundler to run in separate processes. An ingest front-end validates
+// incoming UserOperations and hands them off to a bundle back-end which owns the mempool that batches are
+// built from. Each process has its own DB so that they can be scaled and restarted independently.
+package handoff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+const (
+	// OpsPath is the path on the bundle back-end used to hand off and list UserOperations.
+	OpsPath = "/handoff/ops"
+
+	// CompressedContentType is the content type of a handoff request with a compressed UserOperation. The body
+	// is the EntryPoint address followed by the UserOperation encoded with a userop.Dictionary.
+	CompressedContentType = "application/x-userop"
+
+	// DictionaryHeader is the HTTP header set to the ID of the userop.Dictionary used for compression.
+	DictionaryHeader = "X-Userop-Dictionary"
+)
+
+var (
+	// DefaultTimeout is the default duration to wait for the bundle back-end to accept a UserOperation.
+	DefaultTimeout = 10 * time.Second
+)
+
+type payload struct {
+	EntryPoint common.Address  `json:"entryPoint"`
+	UserOp     json.RawMessage `json:"userOp"`
+}
+
+// Push returns a UserOpHandler that hands off each UserOperation to the bundle back-end at the given base URL.
+// If the back-end does not accept the UserOperation, an error is returned to the sender. This should be the
+// last client module so that only fully validated UserOperations are handed off. If dict is not nil,
+// UserOperations are compressed and the back-end must use a Dictionary with the same prefixes.
+func Push(url string, timeout time.Duration, dict *userop.Dictionary) modules.UserOpHandlerFunc {
+	c := &http.Client{Timeout: timeout}
+
+	return func(ctx *modules.UserOpHandlerCtx) error {
+		req, err := newPushRequest(url, ctx, dict)
+		if err != nil {
+			return err
+		}
+
+		resp, err := c.Do(req)
+		if err != nil {
+			return fmt.Errorf("handoff: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return fmt.Errorf("handoff: unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+		}
+		return nil
+	}
+}
+
+func newPushRequest(url string, ctx *modules.UserOpHandlerCtx, dict *userop.Dictionary) (*http.Request, error) {
+	if dict != nil {
+		body := append(ctx.EntryPoint.Bytes(), dict.Compress(ctx.UserOp)...)
+		req, err := http.NewRequest(http.MethodPost, url+OpsPath, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", CompressedContentType)
+		req.Header.Set(DictionaryHeader, dict.ID())
+		return req, nil
+	}
+
+	op, err := ctx.UserOp.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(&payload{EntryPoint: ctx.EntryPoint, UserOp: op})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, url+OpsPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
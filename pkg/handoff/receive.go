@@ -0,0 +1,126 @@
+package handoff
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// Routes registers the endpoints used by ingest front-ends on the bundle back-end's router. UserOperations
+// received on these endpoints skip validation, so the back-end should only be reachable by trusted front-ends.
+func Routes(
+	r gin.IRoutes,
+	mem *mempool.Mempool,
+	chainID *big.Int,
+	entryPoints []common.Address,
+	dict *userop.Dictionary,
+	handlers ...modules.UserOpHandlerFunc,
+) {
+	r.POST(OpsPath, Receive(mem, chainID, entryPoints, dict, handlers...))
+	r.GET(OpsPath, List(mem, chainID, entryPoints))
+}
+
+// Receive returns a gin handler that adds UserOperations handed off from an ingest front-end to the mempool.
+// UserOperations are assumed to be already validated by the front-end. Any handlers, such as incrementing
+// the opsSeen counters of entities, are run before the UserOperation is added. Compressed UserOperations are
+// only accepted if dict is not nil and has the same ID as the front-end's.
+func Receive(
+	mem *mempool.Mempool,
+	chainID *big.Int,
+	entryPoints []common.Address,
+	dict *userop.Dictionary,
+	handlers ...modules.UserOpHandlerFunc,
+) gin.HandlerFunc {
+	supported := map[common.Address]bool{}
+	for _, ep := range entryPoints {
+		supported[ep] = true
+	}
+	fn := modules.ComposeUserOpHandlerFunc(handlers...)
+
+	return func(g *gin.Context) {
+		ep, op, err := decodeRequest(g, dict)
+		if err != nil {
+			g.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		if !supported[ep] {
+			g.String(http.StatusBadRequest, "entryPoint not supported")
+			return
+		}
+
+		ctx := &modules.UserOpHandlerCtx{UserOp: op, EntryPoint: ep, ChainID: chainID}
+		if err := fn(ctx); err != nil {
+			g.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		if err := mem.AddOp(ep, op); err != nil {
+			g.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		g.Status(http.StatusOK)
+	}
+}
+
+func decodeRequest(g *gin.Context, dict *userop.Dictionary) (common.Address, *userop.UserOperation, error) {
+	if g.ContentType() == CompressedContentType {
+		if dict == nil || g.GetHeader(DictionaryHeader) != dict.ID() {
+			return common.Address{}, nil, errors.New("dictionary mismatch")
+		}
+		body, err := io.ReadAll(g.Request.Body)
+		if err != nil {
+			return common.Address{}, nil, err
+		}
+		if len(body) < common.AddressLength {
+			return common.Address{}, nil, userop.ErrBadCompressedOp
+		}
+		op, err := dict.Decompress(body[common.AddressLength:])
+		if err != nil {
+			return common.Address{}, nil, err
+		}
+		return common.BytesToAddress(body[:common.AddressLength]), op, nil
+	}
+
+	var req payload
+	if err := g.ShouldBindJSON(&req); err != nil {
+		return common.Address{}, nil, err
+	}
+	var data map[string]any
+	if err := json.Unmarshal(req.UserOp, &data); err != nil {
+		return common.Address{}, nil, err
+	}
+	op, err := userop.New(data)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	return req.EntryPoint, op, nil
+}
+
+// List returns a gin handler that responds with the hashes of all UserOperations in the mempool keyed by
+// EntryPoint. Ingest front-ends use this to drop UserOperations that are no longer pending.
+func List(mem *mempool.Mempool, chainID *big.Int, entryPoints []common.Address) gin.HandlerFunc {
+	return func(g *gin.Context) {
+		res := map[common.Address][]common.Hash{}
+		for _, ep := range entryPoints {
+			batch, err := mem.Dump(ep)
+			if err != nil {
+				g.String(http.StatusInternalServerError, err.Error())
+				return
+			}
+
+			hashes := []common.Hash{}
+			for _, op := range batch {
+				hashes = append(hashes, op.GetUserOpHash(ep, chainID))
+			}
+			res[ep] = hashes
+		}
+		g.JSON(http.StatusOK, res)
+	}
+}
@@ -0,0 +1,129 @@
+package handoff
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+var (
+	// DefaultSyncInterval is the default duration between each sync from the bundle back-end.
+	DefaultSyncInterval = 5 * time.Second
+)
+
+// Reconciler keeps the mempool of an ingest front-end consistent with the bundle back-end. The front-end
+// mempool is still needed to validate new UserOperations against pending ones, but since the front-end does
+// not bundle, any UserOperation that is no longer pending on the back-end must be dropped.
+type Reconciler struct {
+	mem         *mempool.Mempool
+	url         string
+	chainID     *big.Int
+	entryPoints []common.Address
+	interval    time.Duration
+	client      *http.Client
+	logger      logr.Logger
+}
+
+// NewReconciler returns a Reconciler that will sync from the bundle back-end at the given base URL.
+func NewReconciler(
+	mem *mempool.Mempool,
+	url string,
+	chainID *big.Int,
+	entryPoints []common.Address,
+	l logr.Logger,
+) *Reconciler {
+	return &Reconciler{
+		mem:         mem,
+		url:         url,
+		chainID:     chainID,
+		entryPoints: entryPoints,
+		interval:    DefaultSyncInterval,
+		client:      &http.Client{Timeout: time.Minute},
+		logger:      l.WithName("handoff_reconciler"),
+	}
+}
+
+// SetSyncInterval sets the duration between each sync from the bundle back-end.
+func (r *Reconciler) SetSyncInterval(interval time.Duration) {
+	r.interval = interval
+}
+
+func (r *Reconciler) fetch(ctx context.Context) (map[common.Address][]common.Hash, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url+OpsPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("handoff: unexpected list status %d", resp.StatusCode)
+	}
+
+	var res map[common.Address][]common.Hash
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Sync removes all UserOperations from the local mempool that are no longer pending on the bundle back-end.
+// UserOperations added locally after the sync started are kept since they may not be listed by the back-end
+// yet.
+func (r *Reconciler) Sync(ctx context.Context) error {
+	start := time.Now()
+	pending, err := r.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, ep := range r.entryPoints {
+		keep := map[common.Hash]bool{}
+		for _, hash := range pending[ep] {
+			keep[hash] = true
+		}
+
+		batch, err := r.mem.Dump(ep)
+		if err != nil {
+			return err
+		}
+		rm := []*userop.UserOperation{}
+		for _, op := range batch {
+			if !keep[op.GetUserOpHash(ep, r.chainID)] && r.mem.AddedAt(ep, op).Before(start) {
+				rm = append(rm, op)
+			}
+		}
+		if len(rm) > 0 {
+			if err := r.mem.RemoveOps(ep, rm...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Run starts a goroutine that syncs from the bundle back-end at every interval.
+func (r *Reconciler) Run() {
+	go func(r *Reconciler) {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := r.Sync(context.Background()); err != nil {
+				r.logger.Error(err, "handoff sync error")
+			} else {
+				r.logger.V(1).Info("handoff sync ok")
+			}
+		}
+	}(r)
+}
@@ -0,0 +1,226 @@
+// Package health implements liveness and readiness endpoints that check the bundler's dependencies and
+// return a structured JSON report for load balancers and Kubernetes probes.
+package health
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// StatusOK is reported for passing checks and healthy reports.
+	StatusOK = "ok"
+
+	// StatusFail is reported for failing checks and unhealthy reports.
+	StatusFail = "fail"
+)
+
+// CheckFunc returns an error if a dependency is unhealthy.
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name     string
+	critical bool
+	fn       CheckFunc
+}
+
+// Result is the outcome of a single check.
+type Result struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Critical   bool   `json:"critical"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// Report is the JSON body returned by the health endpoints.
+type Report struct {
+	Status string    `json:"status"`
+	Checks []*Result `json:"checks"`
+}
+
+// Checker runs a set of named checks.
+type Checker struct {
+	checks  []*check
+	timeout time.Duration
+}
+
+// New returns a Checker with no checks. Each check that takes longer than timeout fails.
+func New(timeout time.Duration) *Checker {
+	return &Checker{
+		checks:  []*check{},
+		timeout: timeout,
+	}
+}
+
+// Add registers a check. Critical checks are required for the bundler to be ready to serve traffic. All
+// checks are required for the bundler to be healthy.
+func (c *Checker) Add(name string, critical bool, fn CheckFunc) {
+	c.checks = append(c.checks, &check{name: name, critical: critical, fn: fn})
+}
+
+// Run executes all checks concurrently and returns a Report. If criticalOnly is true, the Report status only
+// depends on critical checks.
+func (c *Checker) Run(ctx context.Context, criticalOnly bool) *Report {
+	results := make([]*Result, len(c.checks))
+	var wg sync.WaitGroup
+	for i, chk := range c.checks {
+		wg.Add(1)
+		go func(i int, chk *check) {
+			defer wg.Done()
+
+			cctx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+			start := time.Now()
+			err := chk.fn(cctx)
+			res := &Result{
+				Name:       chk.name,
+				Status:     StatusOK,
+				Critical:   chk.critical,
+				DurationMs: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				res.Status = StatusFail
+				res.Error = err.Error()
+			}
+			results[i] = res
+		}(i, chk)
+	}
+	wg.Wait()
+
+	status := StatusOK
+	for _, res := range results {
+		if res.Status == StatusFail && (res.Critical || !criticalOnly) {
+			status = StatusFail
+		}
+	}
+	return &Report{Status: status, Checks: results}
+}
+
+func (c *Checker) handler(criticalOnly bool) gin.HandlerFunc {
+	return func(g *gin.Context) {
+		r := c.Run(g.Request.Context(), criticalOnly)
+		if r.Status != StatusOK {
+			g.JSON(http.StatusServiceUnavailable, r)
+			return
+		}
+		g.JSON(http.StatusOK, r)
+	}
+}
+
+// HealthHandler returns a gin handler that responds with 503 if any check fails.
+func (c *Checker) HealthHandler() gin.HandlerFunc {
+	return c.handler(false)
+}
+
+// ReadyHandler returns a gin handler that responds with 503 if any critical check fails.
+func (c *Checker) ReadyHandler() gin.HandlerFunc {
+	return c.handler(true)
+}
+
+// LiveHandler returns a gin handler that responds with 200 as long as the server is able to handle requests.
+// It does not run any checks so that a failing dependency does not cause the process to be restarted.
+func LiveHandler() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		g.JSON(http.StatusOK, &Report{Status: StatusOK, Checks: []*Result{}})
+	}
+}
+
+// EthClient returns a CheckFunc that verifies the RPC connection by fetching the latest block number.
+func EthClient(eth *ethclient.Client) CheckFunc {
+	return func(ctx context.Context) error {
+		_, err := eth.BlockNumber(ctx)
+		return err
+	}
+}
+
+// ChainID returns a CheckFunc that verifies the node is still on the expected chain.
+func ChainID(eth *ethclient.Client, expected *big.Int) CheckFunc {
+	return func(ctx context.Context) error {
+		chain, err := eth.ChainID(ctx)
+		if err != nil {
+			return err
+		}
+		if chain.Cmp(expected) != 0 {
+			return fmt.Errorf("chain ID %s does not match expected %s", chain, expected)
+		}
+		return nil
+	}
+}
+
+// Badger returns a CheckFunc that verifies the database is open and readable.
+func Badger(db *badger.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		if db.IsClosed() {
+			return fmt.Errorf("database is closed")
+		}
+		return db.View(func(txn *badger.Txn) error {
+			_, err := txn.Get([]byte("health"))
+			if err == badger.ErrKeyNotFound {
+				return nil
+			}
+			return err
+		})
+	}
+}
+
+// Balance returns a CheckFunc that verifies the account balance is at least min.
+func Balance(eth *ethclient.Client, account common.Address, min *big.Int) CheckFunc {
+	return func(ctx context.Context) error {
+		bal, err := eth.BalanceAt(ctx, account, nil)
+		if err != nil {
+			return err
+		}
+		if bal.Cmp(min) < 0 {
+			return fmt.Errorf("balance %s is below %s", bal, min)
+		}
+		return nil
+	}
+}
+
+// Endpoint returns a CheckFunc that verifies the JSON-RPC endpoint at rawUrl is reachable. Any HTTP response
+// below 500 is considered reachable since builders may not support standard methods.
+func Endpoint(rawUrl string) CheckFunc {
+	client := &http.Client{}
+	body := `{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}`
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawUrl, strings.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		res, err := client.Do(req)
+		if err != nil {
+			// Do not leak credentials in the URL.
+			if uerr, ok := err.(*url.Error); ok {
+				return uerr.Err
+			}
+			return err
+		}
+		defer res.Body.Close()
+		if res.StatusCode >= 500 {
+			return fmt.Errorf("status %d", res.StatusCode)
+		}
+		return nil
+	}
+}
+
+// EndpointName returns a check name for a URL without any path or credentials.
+func EndpointName(prefix string, rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Host == "" {
+		return prefix
+	}
+	return prefix + ":" + u.Host
+}
@@ -0,0 +1,114 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+)
+
+func pass(ctx context.Context) error {
+	return nil
+}
+
+func fail(ctx context.Context) error {
+	return fmt.Errorf("unavailable")
+}
+
+func serve(t *testing.T, h gin.HandlerFunc) (int, *Report) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", h)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var rep Report
+	if err := json.Unmarshal(w.Body.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	return w.Code, &rep
+}
+
+// TestReadyIgnoresNonCriticalFailures verifies that only critical checks affect readiness while all checks
+// affect health.
+func TestReadyIgnoresNonCriticalFailures(t *testing.T) {
+	c := New(time.Second)
+	c.Add("rpc", true, pass)
+	c.Add("balance", false, fail)
+
+	if code, rep := serve(t, c.ReadyHandler()); code != http.StatusOK || rep.Status != StatusOK {
+		t.Fatalf("got %d %s, want 200 %s", code, rep.Status, StatusOK)
+	}
+	code, rep := serve(t, c.HealthHandler())
+	if code != http.StatusServiceUnavailable || rep.Status != StatusFail {
+		t.Fatalf("got %d %s, want 503 %s", code, rep.Status, StatusFail)
+	}
+	if len(rep.Checks) != 2 || rep.Checks[1].Name != "balance" || rep.Checks[1].Error != "unavailable" {
+		t.Fatalf("got checks %+v, want failing balance check", rep.Checks)
+	}
+}
+
+// TestReadyFailsOnCriticalFailure verifies that a failing critical check returns 503 from readiness.
+func TestReadyFailsOnCriticalFailure(t *testing.T) {
+	c := New(time.Second)
+	c.Add("rpc", true, fail)
+
+	if code, _ := serve(t, c.ReadyHandler()); code != http.StatusServiceUnavailable {
+		t.Fatalf("got %d, want 503", code)
+	}
+	if code, _ := serve(t, LiveHandler()); code != http.StatusOK {
+		t.Fatalf("got %d, want 200", code)
+	}
+}
+
+// TestCheckTimeout verifies that a check exceeding the timeout fails.
+func TestCheckTimeout(t *testing.T) {
+	c := New(10 * time.Millisecond)
+	c.Add("slow", true, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	if rep := c.Run(context.Background(), true); rep.Status != StatusFail {
+		t.Fatalf("got %s, want %s", rep.Status, StatusFail)
+	}
+}
+
+// TestBadgerCheck verifies that the Badger check fails once the database is closed.
+func TestBadgerCheck(t *testing.T) {
+	db := testutils.DBMock()
+	chk := Badger(db)
+	if err := chk(context.Background()); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	_ = db.Close()
+	if err := chk(context.Background()); err == nil {
+		t.Fatal("got nil, want error")
+	}
+}
+
+// TestEndpointCheck verifies that endpoints are reachable unless they respond with a server error.
+func TestEndpointCheck(t *testing.T) {
+	status := http.StatusMethodNotAllowed
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	chk := Endpoint(srv.URL)
+	if err := chk(context.Background()); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	status = http.StatusBadGateway
+	if err := chk(context.Background()); err == nil {
+		t.Fatal("got nil, want error")
+	}
+}
@@ -0,0 +1,98 @@
+package ipguard
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Location is the network origin of an IP. Country is an ISO 3166-1 alpha-2 code and ASN is the autonomous
+// system number. Either can be empty if unknown.
+type Location struct {
+	Country string
+	ASN     uint32
+}
+
+type ipRange struct {
+	start net.IP
+	end   net.IP
+	loc   Location
+}
+
+// ASNTable maps IP ranges to their Location. It can be loaded from a database in the ip2asn TSV format where
+// each line is "range_start range_end AS_number country_code AS_description".
+type ASNTable struct {
+	ranges []*ipRange
+}
+
+// ParseASNTable decodes an ip2asn TSV database. Lines for unrouted ranges (AS 0) are skipped.
+func ParseASNTable(r io.Reader) (*ASNTable, error) {
+	t := &ASNTable{}
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("ipguard: line %d: expected at least 4 tab separated fields", n)
+		}
+		start := net.ParseIP(fields[0]).To16()
+		end := net.ParseIP(fields[1]).To16()
+		if start == nil || end == nil || bytes.Compare(start, end) > 0 {
+			return nil, fmt.Errorf("ipguard: line %d: invalid range %s - %s", n, fields[0], fields[1])
+		}
+		asn, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("ipguard: line %d: invalid AS number %s", n, fields[2])
+		}
+		if asn == 0 {
+			continue
+		}
+
+		country := strings.ToUpper(fields[3])
+		if country == "NONE" {
+			country = ""
+		}
+		t.ranges = append(t.ranges, &ipRange{start, end, Location{Country: country, ASN: uint32(asn)}})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(t.ranges, func(i, j int) bool { return bytes.Compare(t.ranges[i].start, t.ranges[j].start) < 0 })
+	return t, nil
+}
+
+// LoadASNTable reads an ip2asn TSV database from a file.
+func LoadASNTable(path string) (*ASNTable, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseASNTable(f)
+}
+
+// Lookup returns the Location of the IP and false if it is not in any range.
+func (t *ASNTable) Lookup(ip net.IP) (Location, bool) {
+	ip = ip.To16()
+	if ip == nil {
+		return Location{}, false
+	}
+
+	// Find the last range that starts at or before the IP.
+	i := sort.Search(len(t.ranges), func(i int) bool { return bytes.Compare(t.ranges[i].start, ip) > 0 }) - 1
+	if i < 0 || bytes.Compare(ip, t.ranges[i].end) > 0 {
+		return Location{}, false
+	}
+	return t.ranges[i].loc, true
+}
@@ -0,0 +1,192 @@
+package ipguard
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/dbutils"
+	"github.com/stackup-wallet/stackup-bundler/internal/ginutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/clock"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/ratelimit"
+)
+
+type rpcResponse struct {
+	Error *json.RawMessage `json:"error"`
+}
+
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// parseRequests returns the number of calls in a single or batch request along with the id of the first call.
+func parseRequests(body []byte) (n int, id any) {
+	reqs := jsonrpc.ParseRequests(body)
+	if len(reqs) == 0 {
+		return 1, nil
+	}
+	return len(reqs), reqs[0].Id
+}
+
+// countErrors returns the number of error objects in a single or batch JSON-RPC response.
+func countErrors(body []byte) int {
+	var res []rpcResponse
+	var r rpcResponse
+	if err := json.Unmarshal(body, &r); err == nil {
+		res = []rpcResponse{r}
+	} else if err := json.Unmarshal(body, &res); err != nil {
+		return 0
+	}
+
+	n := 0
+	for _, r := range res {
+		if r.Error != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Guard rejects requests based on the reputation and network origin of the client IP.
+type Guard struct {
+	reputation    *Reputation
+	table         *ASNTable
+	countryHeader string
+	policies      []*Policy
+	store         *ratelimit.MemoryStore
+	clock         clock.Clock
+}
+
+// New returns a Guard that applies the given policies in order. Reputation is optional and if nil, IPs are
+// not blocked based on their error count.
+func New(reputation *Reputation, policies []*Policy) *Guard {
+	return &Guard{
+		reputation: reputation,
+		policies:   policies,
+		store:      ratelimit.NewMemoryStore(),
+		clock:      clock.Real(),
+	}
+}
+
+// SetASNTable sets the table used to look up the country and ASN of an IP.
+func (r *Guard) SetASNTable(t *ASNTable) {
+	r.table = t
+}
+
+// SetCountryHeader sets a request header with the ISO country code of the client set by a trusted proxy
+// (e.g. CF-IPCountry). If set, it takes precedence over the country from the ASN table.
+func (r *Guard) SetCountryHeader(h string) {
+	r.countryHeader = h
+}
+
+// SetClock sets the Clock used for decaying scores and refilling buckets.
+//
+// The default value is clock.Real().
+func (r *Guard) SetClock(c clock.Clock) {
+	r.clock = c
+}
+
+// locate returns the Location of the client IP.
+func (r *Guard) locate(g *gin.Context, ip string) Location {
+	loc := Location{}
+	if r.table != nil {
+		if l, ok := r.table.Lookup(net.ParseIP(strings.TrimSpace(ip))); ok {
+			loc = l
+		}
+	}
+	if r.countryHeader != "" {
+		if c := strings.ToUpper(strings.TrimSpace(g.GetHeader(r.countryHeader))); len(c) == 2 {
+			loc.Country = c
+		}
+	}
+	return loc
+}
+
+// match returns the first policy that applies to the Location or nil if there are none.
+func (r *Guard) match(loc Location) *Policy {
+	for _, p := range r.policies {
+		if p.Matches(loc) {
+			return p
+		}
+	}
+	return nil
+}
+
+// Middleware returns a gin middleware that rejects requests from IPs with a poor reputation or from a
+// network with a deny policy, and applies the rate limit of a matching limit policy. Every JSON-RPC error
+// returned to an IP adds to its error score. Requests with an API key or from a delegated relayer are skipped
+// since they are identified by other means. It must run after the apikey and delegate middleware.
+func (r *Guard) Middleware(l logr.Logger) gin.HandlerFunc {
+	l = l.WithName("ipguard")
+
+	return func(g *gin.Context) {
+		if _, ok := ginutils.GetRelayer(g); ok {
+			g.Next()
+			return
+		}
+		if _, ok := ginutils.GetApiKey(g); ok {
+			g.Next()
+			return
+		}
+
+		body, err := io.ReadAll(g.Request.Body)
+		if err != nil {
+			_ = g.Error(err)
+			g.Abort()
+			return
+		}
+		g.Request.Body = io.NopCloser(bytes.NewReader(body))
+		n, id := parseRequests(body)
+
+		ip := g.ClientIP()
+		now := r.clock.Now()
+		if r.reputation != nil && r.reputation.IsBlocked(ip, now) {
+			l.Info("ip blocked by reputation", "ip", ip)
+			jsonrpc.AbortWithError(g, errors.BANNED_OR_THROTTLED_ENTITY, "ipguard: temporarily blocked due to repeated errors", id)
+			return
+		}
+
+		if p := r.match(r.locate(g, ip)); p != nil {
+			if p.Deny {
+				l.Info("ip denied by policy", "ip", ip, "policy", p.key())
+				jsonrpc.AbortWithError(g, errors.BANNED_OR_THROTTLED_ENTITY, "ipguard: requests from this network are not allowed", id)
+				return
+			}
+
+			ok, err := r.store.Take(dbutils.JoinValues(p.key(), ip), p.Limit, n, now)
+			if err != nil {
+				_ = g.Error(err)
+				g.Abort()
+				return
+			} else if !ok {
+				l.Info("ip rate limit exceeded", "ip", ip, "policy", p.key())
+				jsonrpc.AbortWithError(g, errors.BANNED_OR_THROTTLED_ENTITY, "ipguard: too many requests from this network", id)
+				return
+			}
+		}
+
+		if r.reputation == nil {
+			g.Next()
+			return
+		}
+		rec := &bodyRecorder{ResponseWriter: g.Writer, body: &bytes.Buffer{}}
+		g.Writer = rec
+		g.Next()
+
+		if errs := countErrors(rec.body.Bytes()); errs > 0 {
+			r.reputation.RecordErrors(ip, errs, r.clock.Now())
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package ipguard
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/pkg/clock"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+)
+
+var (
+	chainIdBody = []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_chainId"}`)
+	badBody     = []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_bad"}`)
+	asnDatabase = "1.0.0.0\t1.0.0.255\t13335\tUS\tCLOUDFLARENET\n" +
+		"2.0.0.0\t2.0.0.255\t14061\tNL\tDIGITALOCEAN-ASN\n" +
+		"3.0.0.0\t3.0.0.255\t0\tNone\tNot routed\n"
+)
+
+func newRouter(guard *Guard) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if err := r.SetTrustedProxies([]string{"192.0.2.1"}); err != nil {
+		panic(err)
+	}
+	r.POST("/", guard.Middleware(logr.Discard()), func(g *gin.Context) {
+		if strings.Contains(g.GetHeader("X-Test-Method"), "bad") {
+			g.JSON(http.StatusOK, gin.H{"error": gin.H{"code": -32601}})
+			return
+		}
+		g.JSON(http.StatusOK, gin.H{"result": true})
+	})
+	return r
+}
+
+func doRequest(t *testing.T, r *gin.Engine, ip string, country string, body []byte) map[string]any {
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("X-Forwarded-For", ip)
+	if country != "" {
+		req.Header.Set("CF-IPCountry", country)
+	}
+	if bytes.Equal(body, badBody) {
+		req.Header.Set("X-Test-Method", "bad")
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var res map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func isThrottled(res map[string]any) bool {
+	e, ok := res["error"].(map[string]any)
+	return ok && int(e["code"].(float64)) == errors.BANNED_OR_THROTTLED_ENTITY
+}
+
+// TestASNTableLookup verifies that IPs are matched to the range they fall in and that unrouted ranges are
+// skipped.
+func TestASNTableLookup(t *testing.T) {
+	table, err := ParseASNTable(strings.NewReader(asnDatabase))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if loc, ok := table.Lookup([]byte{2, 0, 0, 128}); !ok || loc.ASN != 14061 || loc.Country != "NL" {
+		t.Fatalf("got %v %v, want AS14061 NL", loc, ok)
+	}
+	if loc, ok := table.Lookup([]byte{1, 0, 0, 255}); !ok || loc.ASN != 13335 {
+		t.Fatalf("got %v %v, want AS13335", loc, ok)
+	}
+	if loc, ok := table.Lookup([]byte{3, 0, 0, 1}); ok {
+		t.Fatalf("got %v, want not found", loc)
+	}
+	if loc, ok := table.Lookup([]byte{0, 0, 0, 1}); ok {
+		t.Fatalf("got %v, want not found", loc)
+	}
+}
+
+// TestParsePoliciesInvalid verifies that malformed policies are rejected.
+func TestParsePoliciesInvalid(t *testing.T) {
+	for _, val := range []string{
+		"country:CN",
+		"country:CHN=deny",
+		"asn:abc=deny",
+		"ip:1.1.1.1=deny",
+		"country:CN=allow",
+		"asn:14061=limit:0:1",
+		"asn:14061=limit:1",
+	} {
+		if _, err := ParsePolicies([]string{val}); err == nil {
+			t.Fatalf("%s: got nil, want err", val)
+		}
+	}
+}
+
+// TestMiddlewarePolicies verifies that deny policies reject requests, that limit policies apply a per IP
+// bucket, and that the country header takes precedence over the ASN table.
+func TestMiddlewarePolicies(t *testing.T) {
+	table, err := ParseASNTable(strings.NewReader(asnDatabase))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	policies, err := ParsePolicies([]string{"country:CN=deny", "asn:AS14061=limit:1:2"})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	c := clock.NewMock(time.Unix(1000, 0))
+	guard := New(nil, policies)
+	guard.SetASNTable(table)
+	guard.SetCountryHeader("CF-IPCountry")
+	guard.SetClock(c)
+	r := newRouter(guard)
+
+	if res := doRequest(t, r, "1.0.0.1", "CN", chainIdBody); !isThrottled(res) {
+		t.Fatalf("got %v, want denied", res)
+	}
+	if res := doRequest(t, r, "1.0.0.1", "", chainIdBody); isThrottled(res) {
+		t.Fatalf("got %v, want result", res)
+	}
+	for i := 0; i < 2; i++ {
+		if res := doRequest(t, r, "2.0.0.1", "", chainIdBody); isThrottled(res) {
+			t.Fatalf("call %d: got %v, want result", i, res)
+		}
+	}
+	if res := doRequest(t, r, "2.0.0.1", "", chainIdBody); !isThrottled(res) {
+		t.Fatalf("got %v, want throttled", res)
+	}
+	if res := doRequest(t, r, "2.0.0.2", "", chainIdBody); isThrottled(res) {
+		t.Fatalf("got %v, want result for separate IP", res)
+	}
+
+	c.Add(time.Second)
+	if res := doRequest(t, r, "2.0.0.1", "", chainIdBody); isThrottled(res) {
+		t.Fatalf("got %v, want refilled result", res)
+	}
+}
+
+// TestMiddlewareReputation verifies that an IP is blocked once its error count reaches the threshold and is
+// unblocked after the score decays.
+func TestMiddlewareReputation(t *testing.T) {
+	c := clock.NewMock(time.Unix(1000, 0))
+	rep := NewReputation(3)
+	rep.SetHalfLife(time.Minute)
+	guard := New(rep, nil)
+	guard.SetClock(c)
+	r := newRouter(guard)
+
+	for i := 0; i < 3; i++ {
+		if res := doRequest(t, r, "1.1.1.1", "", badBody); isThrottled(res) {
+			t.Fatalf("call %d: got %v, want error response", i, res)
+		}
+	}
+	if res := doRequest(t, r, "1.1.1.1", "", chainIdBody); !isThrottled(res) {
+		t.Fatalf("got %v, want blocked", res)
+	}
+	if res := doRequest(t, r, "2.2.2.2", "", chainIdBody); isThrottled(res) {
+		t.Fatalf("got %v, want result for separate IP", res)
+	}
+
+	c.Add(time.Minute)
+	if res := doRequest(t, r, "1.1.1.1", "", chainIdBody); isThrottled(res) {
+		t.Fatalf("got %v, want result after decay", res)
+	}
+}
+
+// TestMiddlewareIgnoresUntrustedForwardedFor verifies that a client that is not a trusted proxy can't set
+// X-Forwarded-For to avoid a deny policy on its own IP.
+func TestMiddlewareIgnoresUntrustedForwardedFor(t *testing.T) {
+	table, err := ParseASNTable(strings.NewReader(asnDatabase))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	policies, err := ParsePolicies([]string{"asn:AS14061=deny"})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	guard := New(nil, policies)
+	guard.SetASNTable(table)
+	r := newRouter(guard)
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(chainIdBody))
+	req.RemoteAddr = "2.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "1.0.0.1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var res map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if !isThrottled(res) {
+		t.Fatalf("got %v, want denied", res)
+	}
+}
@@ -0,0 +1,95 @@
+package ipguard
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/stackup-wallet/stackup-bundler/pkg/ratelimit"
+)
+
+const (
+	matchCountry = "country"
+	matchASN     = "asn"
+
+	actionDeny  = "deny"
+	actionLimit = "limit"
+)
+
+// Policy is applied to requests from IPs in a matching country or ASN. A request is either denied or limited
+// by a stricter token bucket shared by all calls from the same IP, where each call in a batch takes one
+// token.
+type Policy struct {
+	Country string
+	ASN     uint32
+	Deny    bool
+	Limit   *ratelimit.Limit
+}
+
+// Matches returns true if the policy applies to the given Location.
+func (p *Policy) Matches(loc Location) bool {
+	if p.Country != "" {
+		return p.Country == loc.Country
+	}
+	return p.ASN != 0 && p.ASN == loc.ASN
+}
+
+// key returns a unique identifier of the policy's match condition.
+func (p *Policy) key() string {
+	if p.Country != "" {
+		return matchCountry + ":" + p.Country
+	}
+	return matchASN + ":" + strconv.FormatUint(uint64(p.ASN), 10)
+}
+
+// ParsePolicies decodes a list of policies in the form "country:CODE=ACTION" or "asn:NUMBER=ACTION" where
+// ACTION is either "deny" or "limit:rate:burst". Policies are matched in the given order.
+func ParsePolicies(vals []string) ([]*Policy, error) {
+	policies := []*Policy{}
+	for _, val := range vals {
+		match, action, ok := strings.Cut(strings.TrimSpace(val), "=")
+		if !ok {
+			return nil, fmt.Errorf("ipguard: policy %s must be in the form match=action", val)
+		}
+
+		p := &Policy{}
+		kind, target, _ := strings.Cut(match, ":")
+		switch kind {
+		case matchCountry:
+			if len(target) != 2 {
+				return nil, fmt.Errorf("ipguard: policy %s has an invalid country code", val)
+			}
+			p.Country = strings.ToUpper(target)
+		case matchASN:
+			asn, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(target), "AS"), 10, 32)
+			if err != nil || asn == 0 {
+				return nil, fmt.Errorf("ipguard: policy %s has an invalid AS number", val)
+			}
+			p.ASN = uint32(asn)
+		default:
+			return nil, fmt.Errorf("ipguard: policy %s must match on country or asn", val)
+		}
+
+		parts := strings.Split(action, ":")
+		switch {
+		case len(parts) == 1 && parts[0] == actionDeny:
+			p.Deny = true
+		case len(parts) == 3 && parts[0] == actionLimit:
+			rate, err := strconv.ParseFloat(parts[1], 64)
+			if err != nil || rate <= 0 || math.IsInf(rate, 0) {
+				return nil, fmt.Errorf("ipguard: policy %s has an invalid rate", val)
+			}
+			burst, err := strconv.Atoi(parts[2])
+			if err != nil || burst <= 0 {
+				return nil, fmt.Errorf("ipguard: policy %s has an invalid burst", val)
+			}
+			p.Limit = &ratelimit.Limit{Rate: rate, Burst: burst}
+		default:
+			return nil, fmt.Errorf("ipguard: policy %s must have an action of deny or limit:rate:burst", val)
+		}
+
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
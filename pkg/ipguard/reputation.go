@@ -0,0 +1,105 @@
+// Package ipguard applies per-IP reputation and geo/ASN based policies at the HTTP layer. Public bundler
+// endpoints attract scripted abuse from specific networks and these checks run before any request reaches
+// validation.
+package ipguard
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultHalfLife is the default duration for the error score of an IP to decay by half.
+	DefaultHalfLife = 10 * time.Minute
+
+	// minScore is the score below which an idle entry is dropped from memory.
+	minScore = 0.01
+)
+
+type score struct {
+	value float64
+	at    time.Time
+}
+
+// decayed returns the value of the score at now.
+func (s *score) decayed(halfLife time.Duration, now time.Time) float64 {
+	elapsed := now.Sub(s.at)
+	if elapsed <= 0 {
+		return s.value
+	}
+	return s.value * math.Pow(0.5, elapsed.Seconds()/halfLife.Seconds())
+}
+
+// Reputation keeps an exponentially decayed count of errors returned to each IP. An IP with a score at or
+// above the threshold is blocked until enough time has passed for its score to decay below it. Scores are
+// kept in memory and lost on restart.
+type Reputation struct {
+	threshold float64
+	halfLife  time.Duration
+
+	mu     sync.Mutex
+	scores map[string]*score
+	swept  time.Time
+}
+
+// NewReputation returns a Reputation that blocks IPs with a decayed error count at or above threshold.
+func NewReputation(threshold float64) *Reputation {
+	return &Reputation{
+		threshold: threshold,
+		halfLife:  DefaultHalfLife,
+		scores:    make(map[string]*score),
+	}
+}
+
+// SetHalfLife sets the duration for the error score of an IP to decay by half.
+//
+// The default value is 10 minutes.
+func (r *Reputation) SetHalfLife(d time.Duration) {
+	r.halfLife = d
+}
+
+// sweep removes entries that have decayed to a negligible score once per minute to bound memory usage.
+func (r *Reputation) sweep(now time.Time) {
+	if now.Sub(r.swept) < time.Minute {
+		return
+	}
+	r.swept = now
+	for ip, s := range r.scores {
+		if s.decayed(r.halfLife, now) < minScore {
+			delete(r.scores, ip)
+		}
+	}
+}
+
+// RecordErrors adds n errors to the score of the IP.
+func (r *Reputation) RecordErrors(ip string, n int, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sweep(now)
+	s, ok := r.scores[ip]
+	if !ok {
+		s = &score{at: now}
+		r.scores[ip] = s
+	}
+	s.value = s.decayed(r.halfLife, now) + float64(n)
+	s.at = now
+}
+
+// Score returns the decayed error count of the IP at now.
+func (r *Reputation) Score(ip string, now time.Time) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.scores[ip]
+	if !ok {
+		return 0
+	}
+	return s.decayed(r.halfLife, now)
+}
+
+// IsBlocked returns true if the score of the IP is at or above the threshold.
+func (r *Reputation) IsBlocked(ip string, now time.Time) bool {
+	return r.Score(ip, now) >= r.threshold
+}
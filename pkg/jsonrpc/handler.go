@@ -16,6 +16,9 @@ import (
 )
 
 var (
+	// MaxBatchSize is the maximum number of requests allowed in a single batch.
+	MaxBatchSize = 100
+
 	optionalTypePrefix = "optional_"
 )
 
@@ -24,8 +27,8 @@ func formatConversionErrMsg(i int, call *reflect.Value) string {
 	return fmt.Sprintf("Param [%d] can't be converted to %s", i, s)
 }
 
-func jsonrpcError(c *gin.Context, code int, message string, data any, id any) {
-	c.JSON(http.StatusOK, gin.H{
+func errorResponse(code int, message string, data any, id any) gin.H {
+	return gin.H{
 		"jsonrpc": "2.0",
 		"error": gin.H{
 			"code":    code,
@@ -33,7 +36,24 @@ func jsonrpcError(c *gin.Context, code int, message string, data any, id any) {
 			"data":    data,
 		},
 		"id": id,
-	})
+	}
+}
+
+func resultResponse(result any, id any) gin.H {
+	return gin.H{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"result":  result,
+	}
+}
+
+func isErrorResponse(res gin.H) bool {
+	_, ok := res["error"]
+	return ok
+}
+
+func jsonrpcError(c *gin.Context, code int, message string, data any, id any) {
+	c.JSON(http.StatusOK, errorResponse(code, message, data, id))
 	c.Abort()
 }
 
@@ -49,67 +69,63 @@ func parseRequestId(data map[string]any) (any, bool) {
 	return nil, false
 }
 
-// hasOptionalInput checks if the API method has defined an optional final input:
-//  1. The input must start with the "optional_" prefix in its name.
-//  2. The input must be of kind Map.
-func hasOptionalInput(numIn int, call *reflect.Value) bool {
-	return numIn > 0 &&
-		strings.HasPrefix(call.Type().In(numIn-1).Name(), optionalTypePrefix) &&
-		call.Type().In(numIn-1).Kind() == reflect.Map
+// countOptionalInputs returns the number of optional trailing inputs defined by the API method:
+//  1. Each input must start with the "optional_" prefix in its name.
+//  2. Each input must be of kind Map.
+func countOptionalInputs(numIn int, call *reflect.Value) int {
+	n := 0
+	for i := numIn - 1; i >= 0; i-- {
+		if !strings.HasPrefix(call.Type().In(i).Name(), optionalTypePrefix) ||
+			call.Type().In(i).Kind() != reflect.Map {
+			break
+		}
+		n++
+	}
+	return n
 }
 
 // hasValidParamLength checks if the number of parameters in the request is correct:
 //  1. Ok if the number of params equals number of method inputs.
-//  2. Ok if optional input is defined and number of params is one less the number of method inputs.
-func hasValidParamLength(numParams, numIn int, hasOptional bool) bool {
-	return numParams == numIn || (hasOptional && numParams == numIn-1)
-}
-
-// isOptionalParamUndefined checks if the optional input has been left unset in the request.
-func isOptionalParamUndefined(numParams, numIn int, hasOptional bool) bool {
-	return hasOptional && numParams == numIn-1
+//  2. Ok if optional inputs are defined and only a subset of them are left unset at the end of the params.
+func hasValidParamLength(numParams, numIn, numOptional int) bool {
+	return numParams <= numIn && numParams >= numIn-numOptional
 }
 
-// handleRequest includes the core logic for parsing individual JSON-RPC requests and returning its id,
-// result, and success flag.
-func handleRequest(api interface{}, c *gin.Context, data map[string]any) (id any, result any, success bool) {
+// handleRequest includes the core logic for parsing an individual JSON-RPC request and returning its response
+// object. The response contains either a result or an error field.
+func handleRequest(api interface{}, c *gin.Context, data map[string]any) gin.H {
 	id, ok := parseRequestId(data)
 	if !ok {
-		jsonrpcError(c, -32600, "Invalid Request", "No or invalid 'id' in request", nil)
-		return id, nil, false
+		return errorResponse(-32600, "Invalid Request", "No or invalid 'id' in request", nil)
 	}
 
 	if data["jsonrpc"] != "2.0" {
-		jsonrpcError(c, -32600, "Invalid Request", "Version of jsonrpc is not 2.0", &id)
-		return id, nil, false
+		return errorResponse(-32600, "Invalid Request", "Version of jsonrpc is not 2.0", &id)
 	}
 
 	method, ok := data["method"].(string)
 	if !ok {
-		jsonrpcError(c, -32600, "Invalid Request", "No or invalid 'method' in request", &id)
-		return id, nil, false
+		return errorResponse(-32600, "Invalid Request", "No or invalid 'method' in request", &id)
 	}
 
 	params, ok := data["params"].([]interface{})
 	if !ok {
-		jsonrpcError(c, -32602, "Invalid params", "No or invalid 'params' in request", &id)
-		return id, nil, false
+		return errorResponse(-32602, "Invalid params", "No or invalid 'params' in request", &id)
 	}
 	callMethod := cases.Title(language.Und, cases.NoLower).String(method)
 	call := reflect.ValueOf(api).MethodByName(callMethod)
 	if !call.IsValid() {
-		jsonrpcError(c, -32601, "Method not found", "Method not found", &id)
-		return id, nil, false
+		return errorResponse(-32601, "Method not found", "Method not found", &id)
 	}
 
 	numIn := call.Type().NumIn()
 	numParams := len(params)
-	hasOptional := hasOptionalInput(numIn, &call)
-	if !hasValidParamLength(numParams, numIn, hasOptional) {
-		jsonrpcError(c, -32602, "Invalid params", "Invalid number of params", &id)
-		return id, nil, false
+	numOptional := countOptionalInputs(numIn, &call)
+	if !hasValidParamLength(numParams, numIn, numOptional) {
+		return errorResponse(-32602, "Invalid params", "Invalid number of params", &id)
 	}
-	if isOptionalParamUndefined(numParams, numIn, hasOptional) {
+	for numParams < numIn {
+		// Optional params left unset in the request.
 		params = append(params, map[string]any{})
 		numParams++
 	}
@@ -120,28 +136,24 @@ func handleRequest(api interface{}, c *gin.Context, data map[string]any) (id any
 		case reflect.Float32:
 			val, ok := arg.(float32)
 			if !ok {
-				jsonrpcError(
-					c,
+				return errorResponse(
 					-32602,
 					"Invalid params",
 					formatConversionErrMsg(i, &call),
 					&id,
 				)
-				return id, nil, false
 			}
 			args[i] = reflect.ValueOf(val)
 
 		case reflect.Float64:
 			val, ok := arg.(float64)
 			if !ok {
-				jsonrpcError(
-					c,
+				return errorResponse(
 					-32602,
 					"Invalid params",
 					formatConversionErrMsg(i, &call),
 					&id,
 				)
-				return id, nil, false
 			}
 			args[i] = reflect.ValueOf(val)
 
@@ -156,14 +168,12 @@ func handleRequest(api interface{}, c *gin.Context, data map[string]any) (id any
 			}
 
 			if !ok {
-				jsonrpcError(
-					c,
+				return errorResponse(
 					-32602,
 					"Invalid params",
 					formatConversionErrMsg(i, &call),
 					&id,
 				)
-				return id, nil, false
 			}
 			args[i] = reflect.ValueOf(val)
 
@@ -177,14 +187,12 @@ func handleRequest(api interface{}, c *gin.Context, data map[string]any) (id any
 				}
 			}
 			if !ok {
-				jsonrpcError(
-					c,
+				return errorResponse(
 					-32602,
 					"Invalid params",
 					formatConversionErrMsg(i, &call),
 					&id,
 				)
-				return id, nil, false
 			}
 			args[i] = reflect.ValueOf(val)
 
@@ -198,14 +206,12 @@ func handleRequest(api interface{}, c *gin.Context, data map[string]any) (id any
 				}
 			}
 			if !ok {
-				jsonrpcError(
-					c,
+				return errorResponse(
 					-32602,
 					"Invalid params",
 					formatConversionErrMsg(i, &call),
 					&id,
 				)
-				return id, nil, false
 			}
 			args[i] = reflect.ValueOf(val)
 
@@ -219,14 +225,12 @@ func handleRequest(api interface{}, c *gin.Context, data map[string]any) (id any
 				}
 			}
 			if !ok {
-				jsonrpcError(
-					c,
+				return errorResponse(
 					-32602,
 					"Invalid params",
 					formatConversionErrMsg(i, &call),
 					&id,
 				)
-				return id, nil, false
 			}
 			args[i] = reflect.ValueOf(val)
 
@@ -240,59 +244,59 @@ func handleRequest(api interface{}, c *gin.Context, data map[string]any) (id any
 				}
 			}
 			if !ok {
-				jsonrpcError(
-					c,
+				return errorResponse(
 					-32602,
 					"Invalid params",
 					formatConversionErrMsg(i, &call),
 					&id,
 				)
-				return id, nil, false
 			}
 			args[i] = reflect.ValueOf(val)
 
 		case reflect.Interface:
+			if arg == nil {
+				return errorResponse(
+					-32602,
+					"Invalid params",
+					formatConversionErrMsg(i, &call),
+					&id,
+				)
+			}
 			args[i] = reflect.ValueOf(arg)
 
 		case reflect.Map:
 			val, ok := arg.(map[string]any)
 			if !ok {
-				jsonrpcError(
-					c,
+				return errorResponse(
 					-32602,
 					"Invalid params",
 					formatConversionErrMsg(i, &call),
 					&id,
 				)
-				return id, nil, false
 			}
 			args[i] = reflect.ValueOf(val)
 
 		case reflect.Slice:
 			val, ok := arg.([]interface{})
 			if !ok {
-				jsonrpcError(
-					c,
+				return errorResponse(
 					-32602,
 					"Invalid params",
 					formatConversionErrMsg(i, &call),
 					&id,
 				)
-				return id, nil, false
 			}
 			args[i] = reflect.ValueOf(val)
 
 		case reflect.String:
 			val, ok := arg.(string)
 			if !ok {
-				jsonrpcError(
-					c,
+				return errorResponse(
 					-32602,
 					"Invalid params",
 					formatConversionErrMsg(i, &call),
 					&id,
 				)
-				return id, nil, false
 			}
 			args[i] = reflect.ValueOf(val)
 
@@ -306,14 +310,12 @@ func handleRequest(api interface{}, c *gin.Context, data map[string]any) (id any
 				}
 			}
 			if !ok {
-				jsonrpcError(
-					c,
+				return errorResponse(
 					-32602,
 					"Invalid params",
 					formatConversionErrMsg(i, &call),
 					&id,
 				)
-				return id, nil, false
 			}
 			args[i] = reflect.ValueOf(val)
 
@@ -327,14 +329,12 @@ func handleRequest(api interface{}, c *gin.Context, data map[string]any) (id any
 				}
 			}
 			if !ok {
-				jsonrpcError(
-					c,
+				return errorResponse(
 					-32602,
 					"Invalid params",
 					formatConversionErrMsg(i, &call),
 					&id,
 				)
-				return id, nil, false
 			}
 			args[i] = reflect.ValueOf(val)
 
@@ -348,14 +348,12 @@ func handleRequest(api interface{}, c *gin.Context, data map[string]any) (id any
 				}
 			}
 			if !ok {
-				jsonrpcError(
-					c,
+				return errorResponse(
 					-32602,
 					"Invalid params",
 					formatConversionErrMsg(i, &call),
 					&id,
 				)
-				return id, nil, false
 			}
 			args[i] = reflect.ValueOf(val)
 
@@ -369,14 +367,12 @@ func handleRequest(api interface{}, c *gin.Context, data map[string]any) (id any
 				}
 			}
 			if !ok {
-				jsonrpcError(
-					c,
+				return errorResponse(
 					-32602,
 					"Invalid params",
 					formatConversionErrMsg(i, &call),
 					&id,
 				)
-				return id, nil, false
 			}
 			args[i] = reflect.ValueOf(val)
 
@@ -390,14 +386,12 @@ func handleRequest(api interface{}, c *gin.Context, data map[string]any) (id any
 				}
 			}
 			if !ok {
-				jsonrpcError(
-					c,
+				return errorResponse(
 					-32602,
 					"Invalid params",
 					formatConversionErrMsg(i, &call),
 					&id,
 				)
-				return id, nil, false
 			}
 			args[i] = reflect.ValueOf(val)
 
@@ -410,8 +404,7 @@ func handleRequest(api interface{}, c *gin.Context, data map[string]any) (id any
 
 		default:
 			if !ok {
-				jsonrpcError(c, -32603, "Internal error", "Invalid method definition", &id)
-				return id, nil, false
+				return errorResponse(-32603, "Internal error", "Invalid method definition", &id)
 			}
 		}
 	}
@@ -422,15 +415,17 @@ func handleRequest(api interface{}, c *gin.Context, data map[string]any) (id any
 		rpcErr, ok := err.(*errors.RPCError)
 
 		if ok {
-			jsonrpcError(c, rpcErr.Code(), rpcErr.Error(), rpcErr.Data(), &id)
-		} else {
-			jsonrpcError(c, -32601, err.Error(), err.Error(), &id)
+			return errorResponse(rpcErr.Code(), rpcErr.Error(), rpcErr.Data(), &id)
 		}
-		return id, nil, false
+		return errorResponse(-32601, err.Error(), err.Error(), &id)
 	} else if len(value) > 0 {
-		return id, value[0].Interface(), true
+		res, err := EncodeHexQuantities(method, value[0].Interface())
+		if err != nil {
+			return errorResponse(-32603, "Internal error", err.Error(), &id)
+		}
+		return resultResponse(res, id)
 	} else {
-		return id, nil, true
+		return resultResponse(nil, id)
 	}
 }
 
@@ -441,8 +436,11 @@ func handleRequest(api interface{}, c *gin.Context, data map[string]any) (id any
 //
 // If request is valid it will also set the data on the Gin context with the key "json-rpc-request".
 //
+// Batch requests are handled in order and respond with an array containing a response object for each entry.
+// An entry that fails will have an error object without affecting the other entries.
+//
 // NOTE: For batched requests in the current version, "json-rpc-request" on the Gin context contains only the
-// last request in the array.
+// last valid request in the array.
 func Controller(api interface{}) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if c.Request.Method != "POST" {
@@ -464,33 +462,36 @@ func Controller(api interface{}) gin.HandlerFunc {
 		data := make(map[string]any)
 		err = json.Unmarshal(body, &data)
 		if err != nil { // batch request
-			var batch []map[string]any
+			var batch []json.RawMessage
 			err = json.Unmarshal(body, &batch)
 			if err != nil {
 				jsonrpcError(c, -32700, "Parse error", "Error parsing json request", nil)
 				return
 			}
+			if len(batch) == 0 {
+				jsonrpcError(c, -32600, "Invalid Request", "Empty batch", nil)
+				return
+			}
+			if len(batch) > MaxBatchSize {
+				jsonrpcError(c, -32600, "Invalid Request", fmt.Sprintf("Batch exceeds %d requests", MaxBatchSize), nil)
+				return
+			}
 
-			var result []gin.H
-			for _, data := range batch {
-				id, res, success := handleRequest(api, c, data)
-				if !success {
-					return
+			result := []gin.H{}
+			for _, raw := range batch {
+				data := make(map[string]any)
+				if err := json.Unmarshal(raw, &data); err != nil {
+					result = append(result, errorResponse(-32600, "Invalid Request", "Request is not an object", nil))
+					continue
 				}
-
-				result = append(result, gin.H{
-					"jsonrpc": "2.0",
-					"id":      id,
-					"result":  res,
-				})
+				result = append(result, handleRequest(api, c, data))
 			}
 			c.JSON(http.StatusOK, result)
-		} else if id, res, success := handleRequest(api, c, data); success { // single request
-			c.JSON(http.StatusOK, gin.H{
-				"jsonrpc": "2.0",
-				"id":      id,
-				"result":  res,
-			})
+		} else if res := handleRequest(api, c, data); isErrorResponse(res) { // single request
+			c.JSON(http.StatusOK, res)
+			c.Abort()
+		} else {
+			c.JSON(http.StatusOK, res)
 		}
 	}
 }
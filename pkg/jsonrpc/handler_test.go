@@ -0,0 +1,204 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type testApi struct{}
+
+func (a *testApi) Eth_chainId() (string, error) {
+	return "0x1", nil
+}
+
+func (a *testApi) Eth_fail() (string, error) {
+	return "", errors.New("failed")
+}
+
+type optional_testA map[string]any
+
+type optional_testB map[string]any
+
+func (a *testApi) Eth_optionals(x string, oa optional_testA, ob optional_testB) ([]int, error) {
+	return []int{len(oa), len(ob)}, nil
+}
+
+func (a *testApi) Eth_any(x any, y string) (string, error) {
+	return y, nil
+}
+
+type testQuantities struct {
+	CallGasLimit int `json:"callGasLimit"`
+	Total        int `json:"total"`
+}
+
+func (a *testApi) Eth_quantities() (*testQuantities, error) {
+	return &testQuantities{CallGasLimit: 16, Total: 16}, nil
+}
+
+func (a *testApi) Bundler_quantities() (*testQuantities, error) {
+	return &testQuantities{CallGasLimit: 16, Total: 16}, nil
+}
+
+type testResponse struct {
+	ID     any `json:"id"`
+	Result any `json:"result"`
+	Error  *struct {
+		Code int `json:"code"`
+	} `json:"error"`
+}
+
+func doRequest(t *testing.T, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/", Controller(&testApi{}))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	return w
+}
+
+// TestBatchRequest verifies that a batch responds with an ordered response for each entry and that a failed
+// entry does not affect the others.
+func TestBatchRequest(t *testing.T) {
+	w := doRequest(t, `[
+		{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]},
+		{"jsonrpc":"2.0","id":2,"method":"eth_fail","params":[]},
+		{"jsonrpc":"2.0","id":3,"method":"eth_unknown","params":[]},
+		1,
+		{"jsonrpc":"2.0","id":"4","method":"eth_chainId","params":[]}
+	]`)
+
+	var res []testResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 5 {
+		t.Fatalf("got %d responses, want 5", len(res))
+	}
+	if res[0].ID != float64(1) || res[0].Result != "0x1" || res[0].Error != nil {
+		t.Fatalf("got %+v, want result 0x1 for id 1", res[0])
+	}
+	if res[1].ID != float64(2) || res[1].Error == nil {
+		t.Fatalf("got %+v, want error for id 2", res[1])
+	}
+	if res[2].ID != float64(3) || res[2].Error == nil || res[2].Error.Code != -32601 {
+		t.Fatalf("got %+v, want method not found for id 3", res[2])
+	}
+	if res[3].Error == nil || res[3].Error.Code != -32600 {
+		t.Fatalf("got %+v, want invalid request", res[3])
+	}
+	if res[4].ID != "4" || res[4].Result != "0x1" {
+		t.Fatalf("got %+v, want result 0x1 for id 4", res[4])
+	}
+}
+
+// TestEmptyBatchRequest verifies that an empty batch responds with a single invalid request error.
+func TestEmptyBatchRequest(t *testing.T) {
+	w := doRequest(t, `[]`)
+
+	var res testResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Error == nil || res.Error.Code != -32600 {
+		t.Fatalf("got %+v, want invalid request", res)
+	}
+}
+
+// TestSingleRequestError verifies that a failed single request responds with an error object.
+func TestSingleRequestError(t *testing.T) {
+	w := doRequest(t, `{"jsonrpc":"2.0","id":1,"method":"eth_fail","params":[]}`)
+
+	var res testResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Error == nil {
+		t.Fatalf("got %+v, want error", res)
+	}
+}
+
+// TestHexQuantitiesOnlyInEthNamespace verifies that the Controller hex encodes quantity fields in eth namespace
+// results and leaves results of other namespaces unchanged.
+func TestHexQuantitiesOnlyInEthNamespace(t *testing.T) {
+	w := doRequest(t, `[
+		{"jsonrpc":"2.0","id":1,"method":"eth_quantities","params":[]},
+		{"jsonrpc":"2.0","id":2,"method":"bundler_quantities","params":[]}
+	]`)
+
+	var res []testResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 {
+		t.Fatalf("got %d responses, want 2", len(res))
+	}
+	eth, _ := json.Marshal(res[0].Result)
+	if string(eth) != `{"callGasLimit":"0x10","total":16}` {
+		t.Fatalf("got %s, want hex callGasLimit only", eth)
+	}
+	bundler, _ := json.Marshal(res[1].Result)
+	if string(bundler) != `{"callGasLimit":16,"total":16}` {
+		t.Fatalf("got %s, want unchanged result", bundler)
+	}
+}
+
+// TestOptionalParams verifies that any number of trailing optional params can be left unset and are passed
+// as empty maps.
+func TestOptionalParams(t *testing.T) {
+	for body, want := range map[string]string{
+		`{"jsonrpc":"2.0","id":1,"method":"eth_optionals","params":["0x"]}`:                       "[0,0]",
+		`{"jsonrpc":"2.0","id":1,"method":"eth_optionals","params":["0x",{"a":1}]}`:               "[1,0]",
+		`{"jsonrpc":"2.0","id":1,"method":"eth_optionals","params":["0x",{"a":1},{"b":1,"c":2}]}`: "[1,2]",
+	} {
+		var res testResponse
+		if err := json.Unmarshal(doRequest(t, body).Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := json.Marshal(res.Result); string(got) != want || res.Error != nil {
+			t.Fatalf("got %s and %+v, want %s for %s", got, res.Error, want, body)
+		}
+	}
+
+	for _, body := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"eth_optionals","params":[]}`,
+		`{"jsonrpc":"2.0","id":1,"method":"eth_optionals","params":["0x",{},{},{}]}`,
+	} {
+		var res testResponse
+		if err := json.Unmarshal(doRequest(t, body).Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Error == nil || res.Error.Code != -32602 {
+			t.Fatalf("got %+v, want invalid params for %s", res, body)
+		}
+	}
+}
+
+// TestNilInterfaceParam verifies that a null param for an interface argument responds with an invalid params
+// error instead of making the call.
+func TestNilInterfaceParam(t *testing.T) {
+	var res testResponse
+	body := `{"jsonrpc":"2.0","id":1,"method":"eth_any","params":[null,"0x"]}`
+	if err := json.Unmarshal(doRequest(t, body).Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Error == nil || res.Error.Code != -32602 {
+		t.Fatalf("got %+v, want invalid params", res)
+	}
+
+	res = testResponse{}
+	body = `{"jsonrpc":"2.0","id":1,"method":"eth_any","params":[{},"0x"]}`
+	if err := json.Unmarshal(doRequest(t, body).Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Error != nil || res.Result != "0x" {
+		t.Fatalf("got %+v, want result 0x", res)
+	}
+}
@@ -0,0 +1,89 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// quantityFields are the fields in eth namespace results (gas estimates, receipts, and UserOperations) that
+// the spec defines as hex quantities.
+var quantityFields = map[string]bool{
+	// Gas estimates
+	"preVerificationGas":   true,
+	"verificationGasLimit": true,
+	"callGasLimit":         true,
+	"verificationGas":      true,
+	"min":                  true,
+	"recommended":          true,
+
+	// UserOperations
+	"nonce":                true,
+	"maxFeePerGas":         true,
+	"maxPriorityFeePerGas": true,
+
+	// Receipts
+	"actualGasCost":       true,
+	"actualGasUsed":       true,
+	"blockNumber":         true,
+	"cumulativeGasUsed":   true,
+	"gasUsed":             true,
+	"transactionIndex":    true,
+	"effectiveGasPrice":   true,
+	"verificationGasUsed": true,
+	"executionGasUsed":    true,
+}
+
+// EncodeHexQuantities returns the JSON representation of the result of an eth namespace method with every
+// non-negative integer in a quantity field encoded as a hex quantity string (e.g. 16 becomes "0x10"). This
+// guarantees that responses are consistent with the ERC-4337 spec regardless of whether a value was
+// represented as a *big.Int, a native integer, or a hex string internally. Results of other namespaces are
+// returned unchanged since their integers, such as counts and timestamps, are not quantities.
+func EncodeHexQuantities(method string, v any) (any, error) {
+	if !strings.HasPrefix(CanonicalMethod(method), "eth_") {
+		return v, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out any
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return toHexQuantities(out, false), nil
+}
+
+func toHexQuantities(v any, quantity bool) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			val[k] = toHexQuantities(item, quantityFields[k])
+		}
+		return val
+	case []any:
+		for i, item := range val {
+			val[i] = toHexQuantities(item, quantity)
+		}
+		return val
+	case json.Number:
+		s := val.String()
+		if !quantity || strings.ContainsAny(s, ".eE-") {
+			return val
+		}
+		n, ok := big.NewInt(0).SetString(s, 10)
+		if !ok {
+			return val
+		}
+		return hexutil.EncodeBig(n)
+	default:
+		return val
+	}
+}
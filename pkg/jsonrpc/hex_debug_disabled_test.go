@@ -0,0 +1,8 @@
+//go:build nodebug
+
+package jsonrpc_test
+
+// debugGoldens returns no goldens in builds without the debug namespace.
+func debugGoldens() []golden {
+	return nil
+}
@@ -0,0 +1,29 @@
+//go:build !nodebug
+
+package jsonrpc_test
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stackup-wallet/stackup-bundler/pkg/client"
+)
+
+// debugGoldens returns goldens for debug namespace results with types that only exist in builds with the
+// debug namespace.
+func debugGoldens() []golden {
+	return []golden{
+		{"debug_bundler_getStakeStatus", &client.StakeStatus{
+			StakeInfo: &client.StakeInfo{
+				Addr:            common.HexToAddress(testAddr),
+				Stake:           (*hexutil.Big)(big.NewInt(1000000000000000000)),
+				UnstakeDelaySec: 86400,
+			},
+			IsStaked: true,
+		}, `{
+			"stakeInfo": {"addr": "` + testAddr + `", "stake": "0xde0b6b3a7640000", "unstakeDelaySec": "0x15180"},
+			"isStaked": true
+		}`},
+	}
+}
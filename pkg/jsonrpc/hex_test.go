@@ -0,0 +1,448 @@
+package jsonrpc_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/admin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/altmempools"
+	"github.com/stackup-wallet/stackup-bundler/pkg/bundler"
+	"github.com/stackup-wallet/stackup-bundler/pkg/client"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/filter"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/reverts"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/simulation"
+	"github.com/stackup-wallet/stackup-bundler/pkg/federation"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gasfeedback"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/opstatus"
+	"github.com/stackup-wallet/stackup-bundler/pkg/paymaster"
+	"github.com/stackup-wallet/stackup-bundler/pkg/snapshot"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// golden is the expected JSON result of a method. Variants of a method's result share the same method.
+type golden struct {
+	method string
+	result any
+	want   string
+}
+
+const (
+	testAddr = "0x0000000000000000000000000000000000000001"
+	testHash = "0x0000000000000000000000000000000000000000000000000000000000000001"
+	okResult = `"ok"`
+)
+
+func toJSON(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// goldens returns a golden result for every JSON-RPC method. Only eth namespace quantity fields are hex encoded
+// and results of every other namespace are unchanged.
+func goldens() []golden {
+	op := testutils.MockValidInitUserOp()
+	opJson := toJSON(op)
+	opMap, _ := op.ToMap()
+	estimates := &gas.GasEstimates{
+		PreVerificationGas:   big.NewInt(50000),
+		VerificationGasLimit: big.NewInt(100000),
+		CallGasLimit:         big.NewInt(21000),
+		VerificationGas:      big.NewInt(100000),
+	}
+	lookup := &filter.HashLookupResult{
+		UserOperation:   op,
+		EntryPoint:      testAddr,
+		BlockNumber:     big.NewInt(100),
+		BlockHash:       common.HexToHash("0x2"),
+		TransactionHash: common.HexToHash("0x3"),
+	}
+	lookupJson := func(blockNumber string) string {
+		return `{
+			"userOperation": ` + opJson + `,
+			"entryPoint": "` + testAddr + `",
+			"blockNumber": ` + blockNumber + `,
+			"blockHash": "0x0000000000000000000000000000000000000000000000000000000000000002",
+			"transactionHash": "0x0000000000000000000000000000000000000000000000000000000000000003"
+		}`
+	}
+	status := hexutil.Uint64(1)
+	receipt := &bundler.Receipt{
+		EntryPoint:           common.HexToAddress(testAddr),
+		TransactionHash:      testHash,
+		SelectedUserOpHashes: []common.Hash{common.HexToHash(testHash)},
+		ExcludedUserOps:      []*bundler.ExcludedOp{},
+		Status:               &status,
+		Data:                 map[string]any{"gas_limit": 100000},
+	}
+	receiptJson := `{
+		"entryPoint": "` + testAddr + `",
+		"transactionHash": "` + testHash + `",
+		"selectedUserOpHashes": ["` + testHash + `"],
+		"excludedUserOps": [],
+		"status": "0x1",
+		"data": {"gas_limit": 100000}
+	}`
+	page := &mempool.QueryResult{UserOps: []*userop.UserOperation{op}, Total: 1, Offset: 0, Limit: 100}
+	pageJson := `{"userOps": [` + opJson + `], "total": 1, "offset": 0, "limit": 100}`
+
+	gs := []golden{
+		// eth namespace
+		{"eth_sendUserOperation", testHash, `"` + testHash + `"`},
+		{"eth_sendUserOperation", []string{testHash}, `["` + testHash + `"]`},
+		{"eth_estimateUserOperationGas", estimates, `{
+			"preVerificationGas": "0xc350",
+			"verificationGasLimit": "0x186a0",
+			"callGasLimit": "0x5208",
+			"verificationGas": "0x186a0"
+		}`},
+		{"eth_estimateUserOperationGas", &gas.GasEstimates{
+			PreVerificationGas:   big.NewInt(50000),
+			VerificationGasLimit: big.NewInt(100000),
+			CallGasLimit:         big.NewInt(21000),
+			VerificationGas:      big.NewInt(100000),
+			PreVerificationGasBounds: &gas.PreVerificationGasBounds{
+				Min:            big.NewInt(50000),
+				Recommended:    big.NewInt(60000),
+				ValidForBlocks: 3,
+			},
+		}, `{
+			"preVerificationGas": "0xc350",
+			"verificationGasLimit": "0x186a0",
+			"callGasLimit": "0x5208",
+			"verificationGas": "0x186a0",
+			"preVerificationGasBounds": {"min": "0xc350", "recommended": "0xea60", "validForBlocks": 3}
+		}`},
+		{"eth_getUserOperationReceipt", &filter.UserOperationReceipt{
+			UserOpHash:    common.HexToHash("0x1"),
+			EntryPoint:    common.HexToAddress("0x2"),
+			Sender:        common.HexToAddress("0x3"),
+			Paymaster:     common.HexToAddress("0x0"),
+			Nonce:         "0x0",
+			Success:       true,
+			ActualGasCost: "0x64",
+			ActualGasUsed: "0xa",
+			From:          common.HexToAddress("0x4"),
+			GasBreakdown:  &filter.GasBreakdown{PreVerificationGas: "0x5", ExecutionGasUsed: "0x3"},
+		}, `{
+			"userOpHash": "0x0000000000000000000000000000000000000000000000000000000000000001",
+			"entryPoint": "0x0000000000000000000000000000000000000002",
+			"sender": "0x0000000000000000000000000000000000000003",
+			"paymaster": "0x0000000000000000000000000000000000000000",
+			"nonce": "0x0",
+			"success": true,
+			"actualGasCost": "0x64",
+			"actualGasUsed": "0xa",
+			"from": "0x0000000000000000000000000000000000000004",
+			"receipt": null,
+			"logs": null,
+			"gasBreakdown": {"preVerificationGas": "0x5", "executionGasUsed": "0x3"}
+		}`},
+		{"eth_getUserOperationReceipt", (*filter.UserOperationReceipt)(nil), `null`},
+		{"eth_getUserOperationByHash", lookup, lookupJson(`"0x64"`)},
+		{"eth_getUserOperationByHash", (*filter.HashLookupResult)(nil), `null`},
+		{"eth_getUserOperationNonce", "0x0", `"0x0"`},
+		{"eth_supportedEntryPoints", []string{testAddr}, `["` + testAddr + `"]`},
+		{"eth_chainId", "0x1", `"0x1"`},
+
+		// bundler namespace
+		{"bundler_estimateSponsoredUserOperationGas", estimates, `{
+			"preVerificationGas": 50000,
+			"verificationGasLimit": 100000,
+			"callGasLimit": 21000,
+			"verificationGas": 100000
+		}`},
+		{"bundler_getErc20FeeQuote", &paymaster.TokenQuote{
+			Paymaster:   common.HexToAddress(testAddr),
+			GasPrice:    big.NewInt(1000000000),
+			MaxGas:      big.NewInt(200000),
+			MaxCost:     big.NewInt(0),
+			TokenAmount: big.NewInt(255),
+		}, `{
+			"paymaster": "` + testAddr + `",
+			"gasPrice": 1000000000,
+			"maxGas": 200000,
+			"maxCost": 0,
+			"tokenAmount": 255
+		}`},
+		{"bundler_getInfo", &client.BundlerInfo{
+			Version:              "v0.0.0",
+			Commit:               "abc",
+			Mode:                 "private",
+			ChainID:              "0x1",
+			SupportedEntryPoints: []string{testAddr},
+			Executor:             common.HexToAddress(testAddr),
+			ClientModules:        []string{},
+			BundlerModules:       []string{},
+		}, `{
+			"version": "v0.0.0",
+			"commit": "abc",
+			"mode": "private",
+			"chainId": "0x1",
+			"supportedEntryPoints": ["` + testAddr + `"],
+			"executor": "` + testAddr + `",
+			"clientModules": [],
+			"bundlerModules": []
+		}`},
+		{"bundler_getUserOperationStatus", &opstatus.Record{
+			Status:          opstatus.Included,
+			TransactionHash: testHash,
+			UpdatedAt:       1700000000,
+		}, `{"status": "included", "transactionHash": "` + testHash + `", "updatedAt": 1700000000}`},
+		{"bundler_getGasUsageStats", []*gasfeedback.Stats{{
+			Account:             testAddr,
+			Ops:                 10,
+			Failed:              1,
+			LimitUtilization:    &gasfeedback.Histogram{Buckets: []int{50}, Counts: []uint64{4, 6}},
+			EstimateUtilization: &gasfeedback.Histogram{Buckets: []int{50}, Counts: []uint64{9, 1}},
+			PaddingPercent:      10,
+		}}, `[{
+			"account": "` + testAddr + `",
+			"ops": 10,
+			"failed": 1,
+			"limitUtilization": {"buckets": [50], "counts": [4, 6]},
+			"estimateUtilization": {"buckets": [50], "counts": [9, 1]},
+			"paddingPercent": 10
+		}]`},
+		{"bundler_getUserOperationHashPreimage", &userop.Preimage{
+			EntryPointVersion: "v0.6",
+			EntryPoint:        common.HexToAddress(testAddr),
+			ChainID:           (*hexutil.Big)(big.NewInt(1)),
+			Fields:            []*userop.PreimageField{{Name: "nonce", Type: "uint256", Value: "0x1"}},
+			PackedUserOp:      hexutil.Bytes{0x01},
+			PackedUserOpHash:  common.HexToHash(testHash),
+			Encoded:           hexutil.Bytes{0x02},
+			UserOpHash:        common.HexToHash(testHash),
+		}, `{
+			"entryPointVersion": "v0.6",
+			"entryPoint": "` + testAddr + `",
+			"chainId": "0x1",
+			"fields": [{"name": "nonce", "type": "uint256", "value": "0x1"}],
+			"packedUserOp": "0x01",
+			"packedUserOpHash": "` + testHash + `",
+			"encoded": "0x02",
+			"userOpHash": "` + testHash + `"
+		}`},
+		{"bundler_getUserOperationsBySender", &client.SenderUserOperations{
+			Pending: []*client.PendingUserOperation{{
+				UserOperation: op,
+				EntryPoint:    testAddr,
+				UserOpHash:    common.HexToHash(testHash),
+			}},
+			Included: []*filter.HashLookupResult{lookup},
+		}, `{
+			"pending": [{"userOperation": ` + opJson + `, "entryPoint": "` + testAddr + `", "userOpHash": "` +
+			testHash + `"}],
+			"included": [` + lookupJson(`100`) + `]
+		}`},
+		{"bundler_getPendingUserOperations", []map[string]any{opMap}, `[` + toJSON(opMap) + `]`},
+		{"bundler_getFederationPeers", []*federation.Beacon{{
+			Url:                     "http://bundler",
+			ChainID:                 "0x1",
+			SupportedEntryPoints:    []string{},
+			MinMaxFeePerGas:         "0x2",
+			MinMaxPriorityFeePerGas: "0x1",
+			MempoolDepth:            10,
+			ChainHead:               "0x64",
+			Timestamp:               1700000000,
+		}}, `[{
+			"url": "http://bundler",
+			"chainId": "0x1",
+			"supportedEntryPoints": [],
+			"minMaxFeePerGas": "0x2",
+			"minMaxPriorityFeePerGas": "0x1",
+			"mempoolDepth": 10,
+			"chainHead": "0x64",
+			"timestamp": 1700000000
+		}]`},
+
+		// pm namespace
+		{"pm_getPaymasterStubData", map[string]any{"paymasterAndData": "0x01", "isFinal": false},
+			`{"paymasterAndData": "0x01", "isFinal": false}`},
+		{"pm_getPaymasterData", map[string]any{"paymasterAndData": "0x02"}, `{"paymasterAndData": "0x02"}`},
+
+		// qng namespace
+		{"qng_getBalance", map[string]any{"balance": 100}, `{"balance": 100}`},
+		{"qng_addBalance", true, `true`},
+		{"qng_getUTXOs", []any{map[string]any{"txid": testHash, "idx": 0, "amount": 5}},
+			`[{"txid": "` + testHash + `", "idx": 0, "amount": 5}]`},
+		{"qng_sendRawTransaction", testHash, `"` + testHash + `"`},
+		{"qng_crossSend", nil, `null`},
+
+		// debug namespace
+		{"debug_bundler_simulateAtBlock", &simulation.ValidationReport{
+			BlockNumber: big.NewInt(16),
+			ReturnInfo: &reverts.ReturnInfo{
+				PreOpGas:         big.NewInt(1000),
+				Prefund:          big.NewInt(2000),
+				SigFailed:        false,
+				ValidAfter:       big.NewInt(0),
+				ValidUntil:       big.NewInt(0),
+				PaymasterContext: []byte{},
+			},
+			AltMempoolIds: []string{},
+		}, `{
+			"blockNumber": 16,
+			"returnInfo": {
+				"preOpGas": 1000,
+				"prefund": 2000,
+				"sigFailed": false,
+				"validAfter": 0,
+				"validUntil": 0,
+				"paymasterContext": ""
+			},
+			"altMempoolIds": []
+		}`},
+		{"debug_bundler_clearState", "ok", okResult},
+		{"debug_bundler_clearMempool", "ok", okResult},
+		{"debug_bundler_addUserOps", "ok", okResult},
+		{"debug_bundler_dumpMempool", []map[string]any{opMap}, `[` + toJSON(opMap) + `]`},
+		{"debug_bundler_dumpMempool", page, pageJson},
+		{"debug_bundler_sendBundleNow", testHash, `"` + testHash + `"`},
+		{"debug_bundler_sendBundleNow", receipt, receiptJson},
+		{"debug_bundler_setBundlingMode", "ok", okResult},
+		{"debug_bundler_setReputation", "ok", okResult},
+		{"debug_bundler_dumpReputation", []map[string]any{{
+			"address":     testAddr,
+			"opsSeen":     10,
+			"opsIncluded": 1,
+			"status":      "ok",
+		}}, `[{"address": "` + testAddr + `", "opsSeen": 10, "opsIncluded": 1, "status": "ok"}]`},
+		{"debug_bundler_getAltMempoolExceptions", []*altmempools.Exception{{
+			Rule:       "storage",
+			Entity:     "paymaster",
+			Contract:   testAddr,
+			Slot:       "0x0",
+			MempoolIds: []string{"1"},
+		}}, `[{
+			"rule": "storage",
+			"entity": "paymaster",
+			"contract": "` + testAddr + `",
+			"slot": "0x0",
+			"mempoolIds": ["1"]
+		}]`},
+
+		// admin namespace
+		{"admin_banSender", "ok", okResult},
+		{"admin_unbanSender", "ok", okResult},
+		{"admin_banPaymaster", "ok", okResult},
+		{"admin_unbanPaymaster", "ok", okResult},
+		{"admin_flushMempool", "ok", okResult},
+		{"admin_dumpMempool", page, pageJson},
+		{"admin_exportMempool", &snapshot.Snapshot{
+			Version:   1,
+			CreatedAt: 1700000000,
+			Pools:     []*snapshot.Pool{{EntryPoint: common.HexToAddress(testAddr), UserOps: []*userop.UserOperation{op}}},
+		}, `{
+			"version": 1,
+			"createdAt": 1700000000,
+			"pools": [{"entryPoint": "` + testAddr + `", "userOps": [` + opJson + `]}]
+		}`},
+		{"admin_importMempool", &admin.ImportMempoolResult{Imported: 12}, `{"imported": 12}`},
+		{"admin_setApiKey", "ok", okResult},
+		{"admin_removeApiKey", "ok", okResult},
+		{"admin_pauseBundling", "ok", okResult},
+		{"admin_resumeBundling", "ok", okResult},
+		{"admin_bundleOpNow", &admin.BundleOpResult{
+			UserOpHash:      testHash,
+			TransactionHash: testHash,
+			Receipt:         receipt,
+		}, `{
+			"userOpHash": "` + testHash + `",
+			"transactionHash": "` + testHash + `",
+			"receipt": ` + receiptJson + `
+		}`},
+		{"admin_dumpConfig", map[string]any{"maxBatchGasLimit": 25000000, "ethClientUrl": "http://node"},
+			`{"maxBatchGasLimit": 25000000, "ethClientUrl": "http://node"}`},
+	}
+	return append(gs, debugGoldens()...)
+}
+
+// normalize returns data re-encoded with sorted keys so that JSON can be compared with any formatting.
+func normalize(t *testing.T, data []byte) string {
+	t.Helper()
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func assertGolden(t *testing.T, method string, result any, golden string) {
+	t.Helper()
+
+	res, err := jsonrpc.EncodeHexQuantities(method, result)
+	if err != nil {
+		t.Fatalf("%s: got %v, want nil", method, err)
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		t.Fatalf("%s: got %v, want nil", method, err)
+	}
+
+	if got, want := normalize(t, data), normalize(t, []byte(golden)); got != want {
+		t.Fatalf("%s:\ngot  %s\nwant %s", method, got, want)
+	}
+}
+
+// TestHexEncodingGoldens verifies the encoded result of every JSON-RPC method against its golden.
+func TestHexEncodingGoldens(t *testing.T) {
+	for _, g := range goldens() {
+		assertGolden(t, g.method, g.result, g.want)
+	}
+}
+
+// TestHexEncodingCoversEveryMethod verifies that there is a golden for every method of the client and admin
+// RPC adapters.
+func TestHexEncodingCoversEveryMethod(t *testing.T) {
+	covered := make(map[string]bool)
+	for _, g := range goldens() {
+		covered[g.method] = true
+	}
+
+	for _, api := range []any{&client.RpcAdapter{}, &admin.RpcAdapter{}} {
+		typ := reflect.TypeOf(api)
+		for i := 0; i < typ.NumMethod(); i++ {
+			name := typ.Method(i).Name
+			if !strings.Contains(name, "_") {
+				continue
+			}
+			if method := jsonrpc.CanonicalMethod(name); !covered[method] {
+				t.Fatalf("got no golden for %s, want one", method)
+			}
+		}
+	}
+}
+
+// TestHexEncodingCanonicalMethod verifies that the namespace is matched on the canonical method name.
+func TestHexEncodingCanonicalMethod(t *testing.T) {
+	assertGolden(t, "Eth_estimateUserOperationGas", &gas.GasEstimates{CallGasLimit: big.NewInt(16)}, `{
+		"preVerificationGas": null,
+		"verificationGasLimit": null,
+		"callGasLimit": "0x10",
+		"verificationGas": null
+	}`)
+}
+
+// TestHexEncodingLeavesNonQuantities verifies that only non-negative integers in quantity fields are encoded.
+func TestHexEncodingLeavesNonQuantities(t *testing.T) {
+	assertGolden(
+		t,
+		"eth_chainId",
+		map[string]any{"ratio": 0.5, "nonce": -1, "count": 3, "callGasLimit": 3},
+		`{"ratio": 0.5, "nonce": -1, "count": 3, "callGasLimit": "0x3"}`,
+	)
+}
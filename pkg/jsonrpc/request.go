@@ -0,0 +1,130 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// SendUserOperationMethod is the canonical name of the RPC method for submitting UserOperations.
+const SendUserOperationMethod = "eth_sendUserOperation"
+
+// Request is a single call parsed from a JSON-RPC request body.
+type Request struct {
+	Id     any
+	Method string
+	Params []any
+}
+
+// CanonicalMethod returns the method name as it resolves in the Controller. The Controller title cases the
+// method to find the API function, so "Eth_chainId" and "eth_chainId" both call Eth_chainId. Middleware must
+// compare against the canonical name so that a change in case can't be used to get around a method rule.
+func CanonicalMethod(method string) string {
+	m := cases.Title(language.Und, cases.NoLower).String(method)
+	r, n := utf8.DecodeRuneInString(m)
+	if r == utf8.RuneError {
+		return m
+	}
+	return string(unicode.ToLower(r)) + m[n:]
+}
+
+func newRequest(data map[string]any) *Request {
+	method, _ := data["method"].(string)
+	params, _ := data["params"].([]any)
+	return &Request{
+		Id:     data["id"],
+		Method: CanonicalMethod(method),
+		Params: params,
+	}
+}
+
+// ParseRequests returns the calls in a single or batch request body. The body is decoded the same way as in
+// the Controller so that middleware always sees the same method and params that will be called. In
+// particular, keys are matched exactly and the last of any duplicate keys wins. Batch entries that are not
+// objects are skipped and nil is returned if the body cannot be parsed.
+func ParseRequests(body []byte) []*Request {
+	data := make(map[string]any)
+	if err := json.Unmarshal(body, &data); err == nil {
+		return []*Request{newRequest(data)}
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return nil
+	}
+	reqs := []*Request{}
+	for _, raw := range batch {
+		data := make(map[string]any)
+		if err := json.Unmarshal(raw, &data); err != nil {
+			continue
+		}
+		reqs = append(reqs, newRequest(data))
+	}
+	return reqs
+}
+
+// UserOps returns the UserOperation objects passed as the first param of the call. The first param is either
+// a single UserOperation or an array of them. Nil is returned if the param is neither.
+func (r *Request) UserOps() []map[string]any {
+	if len(r.Params) == 0 {
+		return nil
+	}
+
+	switch p := r.Params[0].(type) {
+	case map[string]any:
+		return []map[string]any{p}
+	case []any:
+		ops := []map[string]any{}
+		for _, v := range p {
+			if op, ok := v.(map[string]any); ok {
+				ops = append(ops, op)
+			}
+		}
+		return ops
+	default:
+		return nil
+	}
+}
+
+// UserOpFieldValues returns the string values that userop.New may decode for a field of a UserOperation
+// object. An exact match on the key is always used if it is set. Otherwise keys are matched case-insensitively
+// and since any of them may be picked, all matches are returned.
+func UserOpFieldValues(op map[string]any, name string) []string {
+	if v, ok := op[name]; ok {
+		if s, ok := v.(string); ok {
+			return []string{s}
+		}
+		return nil
+	}
+
+	vals := []string{}
+	for k, v := range op {
+		if s, ok := v.(string); ok && strings.EqualFold(k, name) {
+			vals = append(vals, s)
+		}
+	}
+	return vals
+}
+
+// ErrorResponse returns a JSON-RPC response object with an error field.
+func ErrorResponse(code int, message string, data any, id any) gin.H {
+	return errorResponse(code, message, data, id)
+}
+
+// AbortWithError writes a JSON-RPC error response with the given code and message and aborts the remaining
+// handlers. It is used by middleware that rejects a request before it reaches the Controller.
+func AbortWithError(c *gin.Context, code int, message string, id any) {
+	AbortWithStatusError(c, http.StatusOK, code, message, id)
+}
+
+// AbortWithStatusError is the same as AbortWithError but also sets the HTTP status of the response.
+func AbortWithStatusError(c *gin.Context, status int, code int, message string, id any) {
+	c.JSON(status, errorResponse(code, message, nil, id))
+	c.Abort()
+}
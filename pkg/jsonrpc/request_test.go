@@ -0,0 +1,70 @@
+package jsonrpc
+
+import (
+	"testing"
+)
+
+// TestCanonicalMethod verifies that methods are normalized to the name that resolves in the Controller.
+func TestCanonicalMethod(t *testing.T) {
+	cases := map[string]string{
+		"eth_sendUserOperation":     "eth_sendUserOperation",
+		"Eth_sendUserOperation":     "eth_sendUserOperation",
+		"Admin_setReputation":       "admin_setReputation",
+		"debug_bundler_dumpMempool": "debug_bundler_dumpMempool",
+		"":                          "",
+	}
+	for in, want := range cases {
+		if got := CanonicalMethod(in); got != want {
+			t.Fatalf("got %s, want %s", got, want)
+		}
+	}
+}
+
+// TestParseRequestsExactKeys verifies that only the exact "method" key is read and that the last duplicate
+// key wins, the same as in the Controller.
+func TestParseRequestsExactKeys(t *testing.T) {
+	body := []byte(`{"jsonrpc":"2.0","id":1,"Method":"admin_x","method":"eth_chainId","METHOD":"admin_y","params":[]}`)
+	reqs := ParseRequests(body)
+	if len(reqs) != 1 || reqs[0].Method != "eth_chainId" {
+		t.Fatalf("got %v, want eth_chainId", reqs)
+	}
+
+	body = []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_chainId","method":"admin_x","params":[]}`)
+	reqs = ParseRequests(body)
+	if len(reqs) != 1 || reqs[0].Method != "admin_x" {
+		t.Fatalf("got %v, want admin_x", reqs)
+	}
+}
+
+// TestParseRequestsBatch verifies that each object in a batch is parsed and that other entries are skipped.
+func TestParseRequestsBatch(t *testing.T) {
+	body := []byte(`[{"id":1,"method":"Eth_chainId"},1,{"id":2,"method":"eth_sendUserOperation","params":[[{},{}]]}]`)
+	reqs := ParseRequests(body)
+	if len(reqs) != 2 {
+		t.Fatalf("got %d requests, want 2", len(reqs))
+	}
+	if reqs[0].Method != "eth_chainId" || reqs[0].Id != float64(1) {
+		t.Fatalf("got %v, want eth_chainId with id 1", reqs[0])
+	}
+	if n := len(reqs[1].UserOps()); n != 2 {
+		t.Fatalf("got %d ops, want 2", n)
+	}
+
+	if reqs := ParseRequests([]byte(`not json`)); reqs != nil {
+		t.Fatalf("got %v, want nil", reqs)
+	}
+}
+
+// TestUserOpFieldValues verifies that an exact key takes precedence and that all case-insensitive matches are
+// returned otherwise.
+func TestUserOpFieldValues(t *testing.T) {
+	op := map[string]any{"callData": "0x01", "CALLDATA": "0x0202"}
+	if vals := UserOpFieldValues(op, "callData"); len(vals) != 1 || vals[0] != "0x01" {
+		t.Fatalf("got %v, want [0x01]", vals)
+	}
+
+	op = map[string]any{"CallData": "0x01", "CALLDATA": "0x0202"}
+	if vals := UserOpFieldValues(op, "callData"); len(vals) != 2 {
+		t.Fatalf("got %v, want 2 values", vals)
+	}
+}
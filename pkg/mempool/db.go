@@ -39,7 +39,55 @@ func getUserOpFromDBValue(value []byte) (*userop.UserOperation, error) {
 	return op, nil
 }
 
-func loadFromDisk(db *badger.DB, q *userOpQueues) error {
+// BadgerStore is a Store that persists UserOperations to an embedded DB. It is the default backend for a
+// single bundler process.
+type BadgerStore struct {
+	db *badger.DB
+}
+
+// NewBadgerStore returns a Store backed by the given embedded DB.
+func NewBadgerStore(db *badger.DB) *BadgerStore {
+	return &BadgerStore{db}
+}
+
+// Put implements the Store interface.
+func (s *BadgerStore) Put(entryPoint common.Address, op *userop.UserOperation) error {
+	data, err := op.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(getUniqueKey(entryPoint, op.Sender, op.Nonce), data)
+	})
+}
+
+// Delete implements the Store interface.
+func (s *BadgerStore) Delete(entryPoint common.Address, ops ...*userop.UserOperation) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		for _, op := range ops {
+			err := txn.Delete(getUniqueKey(entryPoint, op.Sender, op.Nonce))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Load implements the Store interface.
+func (s *BadgerStore) Load(fn LoadFunc) error {
+	return loadFromDisk(s.db, fn)
+}
+
+// Clear implements the Store interface. This drops the entire embedded DB, not only the mempool keys, so that
+// all state derived from pending ops is reset as well.
+func (s *BadgerStore) Clear() error {
+	return s.db.DropAll()
+}
+
+func loadFromDisk(db *badger.DB, fn LoadFunc) error {
 	return db.View(func(txn *badger.Txn) error {
 		opts := badger.DefaultIteratorOptions
 		opts.PrefetchSize = 10
@@ -57,7 +105,40 @@ func loadFromDisk(db *badger.DB, q *userOpQueues) error {
 					return err
 				}
 
-				q.AddOp(ep, op)
+				return fn(ep, op)
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func loadAfterVersion(db *badger.DB, version uint64) ([]*userop.UserOperation, error) {
+	ops := []*userop.UserOperation{}
+	err := db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchSize = 10
+		it := txn.NewIterator(opts)
+		prefix := []byte(keyPrefix)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			if item.Version() <= version {
+				continue
+			}
+
+			err := item.Value(func(v []byte) error {
+				op, err := getUserOpFromDBValue(v)
+				if err != nil {
+					return err
+				}
+
+				ops = append(ops, op)
 				return nil
 			})
 
@@ -68,4 +149,6 @@ func loadFromDisk(db *badger.DB, q *userOpQueues) error {
 
 		return nil
 	})
+
+	return ops, err
 }
@@ -3,48 +3,103 @@
 package mempool
 
 import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
 	badger "github.com/dgraph-io/badger/v3"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
+// ErrVersionsNotTracked is returned by DumpAfterVersion if the Store does not record write versions.
+var ErrVersionsNotTracked = errors.New("mempool: store does not track versions")
+
 // Mempool provides read and write access to a pool of pending UserOperations which have passed all Client
 // checks.
 type Mempool struct {
-	db    *badger.DB
+	mu    sync.Mutex
+	store Store
 	queue *userOpQueues
 }
 
 // New creates an instance of a mempool that uses an embedded DB to persist and load UserOperations from disk
 // incase of a reset.
 func New(db *badger.DB) (*Mempool, error) {
+	return NewWithStore(NewBadgerStore(db))
+}
+
+// NewWithStore creates an instance of a mempool that uses the given Store to persist and load UserOperations.
+func NewWithStore(store Store) (*Mempool, error) {
 	queue := newUserOpQueue()
-	err := loadFromDisk(db, queue)
+	err := store.Load(func(entryPoint common.Address, op *userop.UserOperation) error {
+		queue.AddOp(entryPoint, op)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &Mempool{db, queue}, nil
+	return &Mempool{store: store, queue: queue}, nil
 }
 
 // GetOps returns all the UserOperations associated with an EntryPoint and Sender address.
 func (m *Mempool) GetOps(entryPoint common.Address, sender common.Address) ([]*userop.UserOperation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	ops := m.queue.GetOps(entryPoint, sender)
 	return ops, nil
 }
 
+// GetOpsBySender returns the UserOperations associated with an EntryPoint where the given address is the
+// Sender. Unlike GetOps, ops where the address is only the factory or paymaster are excluded.
+func (m *Mempool) GetOpsBySender(
+	entryPoint common.Address,
+	sender common.Address,
+) ([]*userop.UserOperation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ops := []*userop.UserOperation{}
+	for _, op := range m.queue.GetOps(entryPoint, sender) {
+		if op.Sender == sender {
+			ops = append(ops, op)
+		}
+	}
+	return ops, nil
+}
+
+// GetOpsByPaymaster returns the UserOperations associated with an EntryPoint that are sponsored by the given
+// paymaster in the order they arrived.
+func (m *Mempool) GetOpsByPaymaster(
+	entryPoint common.Address,
+	paymaster common.Address,
+) ([]*userop.UserOperation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.queue.GetOpsByPaymaster(entryPoint, paymaster), nil
+}
+
+// DumpByMaxFee returns the UserOperations associated with an EntryPoint with a MaxFeePerGas of at least
+// minMaxFee, ordered from highest to lowest MaxFeePerGas.
+func (m *Mempool) DumpByMaxFee(entryPoint common.Address, minMaxFee *big.Int) ([]*userop.UserOperation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.queue.AllByMaxFee(entryPoint, minMaxFee), nil
+}
+
 // AddOp adds a UserOperation to the mempool or replace an existing one with the same EntryPoint, Sender, and
 // Nonce values.
 func (m *Mempool) AddOp(entryPoint common.Address, op *userop.UserOperation) error {
-	data, err := op.MarshalJSON()
-	if err != nil {
-		return err
-	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	err = m.db.Update(func(txn *badger.Txn) error {
-		return txn.Set(getUniqueKey(entryPoint, op.Sender, op.Nonce), data)
-	})
-	if err != nil {
+	if err := m.store.Put(entryPoint, op); err != nil {
 		return err
 	}
 
@@ -54,17 +109,10 @@ func (m *Mempool) AddOp(entryPoint common.Address, op *userop.UserOperation) err
 
 // RemoveOps removes a list of UserOperations from the mempool by EntryPoint, Sender, and Nonce values.
 func (m *Mempool) RemoveOps(entryPoint common.Address, ops ...*userop.UserOperation) error {
-	err := m.db.Update(func(txn *badger.Txn) error {
-		for _, op := range ops {
-			err := txn.Delete(getUniqueKey(entryPoint, op.Sender, op.Nonce))
-			if err != nil {
-				return err
-			}
-		}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-		return nil
-	})
-	if err != nil {
+	if err := m.store.Delete(entryPoint, ops...); err != nil {
 		return err
 	}
 
@@ -74,12 +122,83 @@ func (m *Mempool) RemoveOps(entryPoint common.Address, ops ...*userop.UserOperat
 
 // Dump will return a list of UserOperations from the mempool by EntryPoint in the order it arrived.
 func (m *Mempool) Dump(entryPoint common.Address) ([]*userop.UserOperation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	return m.queue.All(entryPoint), nil
 }
 
-// Clear will clear the entire embedded db and reset it to a clean state.
+// AddedAt returns the time a UserOperation with the same EntryPoint, Sender, and Nonce was first added to the
+// mempool. Replacing an op does not reset this time. Ops loaded from disk on startup are timestamped at load.
+// The zero time is returned if the op is not in the mempool.
+func (m *Mempool) AddedAt(entryPoint common.Address, op *userop.UserOperation) time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.queue.AddedAt(entryPoint, op)
+}
+
+// DumpAfterVersion will return a list of UserOperations across all EntryPoints that were last written to the
+// embedded db after the given version. This allows side effects of adding an op to be recovered after a restart.
+// Versions are only tracked by a BadgerStore and ErrVersionsNotTracked is returned for any other Store.
+func (m *Mempool) DumpAfterVersion(version uint64) ([]*userop.UserOperation, error) {
+	bs, ok := m.store.(*BadgerStore)
+	if !ok {
+		return nil, ErrVersionsNotTracked
+	}
+	return loadAfterVersion(bs.db, version)
+}
+
+// Reload merges the in-memory index with the Store. This is used to pick up changes written by other bundler
+// processes sharing the same Store. Ops that are new or were replaced in the Store are added, ops that are no
+// longer in the Store are removed, and ops that were already known keep their original added time. Writes
+// from this process are blocked until the merge is done so that none of them are lost.
+func (m *Mempool) Reload() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	loaded := make(map[string]bool)
+	err := m.store.Load(func(entryPoint common.Address, op *userop.UserOperation) error {
+		loaded[string(getUniqueKey(entryPoint, op.Sender, op.Nonce))] = true
+		curr := m.queue.Get(entryPoint, op.Sender, op.Nonce)
+		if curr == nil || !bytes.Equal(curr.Pack(), op.Pack()) {
+			m.queue.AddOp(entryPoint, op)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, ep := range m.queue.EntryPoints() {
+		stale := []*userop.UserOperation{}
+		for _, op := range m.queue.All(ep) {
+			if !loaded[string(getUniqueKey(ep, op.Sender, op.Nonce))] {
+				stale = append(stale, op)
+			}
+		}
+		m.queue.RemoveOps(ep, stale...)
+	}
+	return nil
+}
+
+// Claim acquires or renews the lease to bundle ops for the given owner if the Store is shared and implements
+// Leaser. It returns false if another owner holds the lease. A Store that is not a Leaser is assumed to be
+// used by a single process and the lease is always granted.
+func (m *Mempool) Claim(owner string, ttl time.Duration) (bool, error) {
+	l, ok := m.store.(Leaser)
+	if !ok {
+		return true, nil
+	}
+	return l.Claim(owner, ttl)
+}
+
+// Clear will clear the Store and reset it to a clean state.
 func (m *Mempool) Clear() error {
-	if err := m.db.DropAll(); err != nil {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.store.Clear(); err != nil {
 		return err
 	}
 	m.queue = newUserOpQueue()
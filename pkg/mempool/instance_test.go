@@ -6,6 +6,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
 // TestAddOpToMempool verifies that a UserOperation can be added to the mempool and later retrieved without
@@ -170,3 +171,177 @@ func TestNewMempoolLoadsFromDisk(t *testing.T) {
 		t.Fatalf("ops not equal: %s", testutils.GetOpsDiff(op2, memOps[0]))
 	}
 }
+
+// TestDumpByMaxFeeFromMempool verifies that ops below the minimum MaxFeePerGas are excluded and the remaining
+// ops are ordered from highest to lowest MaxFeePerGas.
+func TestDumpByMaxFeeFromMempool(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	ep := testutils.ValidAddress1
+
+	op1 := testutils.MockValidInitUserOp()
+	op1.MaxFeePerGas = big.NewInt(4)
+
+	op2 := testutils.MockValidInitUserOp()
+	op2.Sender = testutils.ValidAddress2
+	op2.MaxFeePerGas = big.NewInt(6)
+
+	op3 := testutils.MockValidInitUserOp()
+	op3.Sender = testutils.ValidAddress3
+	op3.MaxFeePerGas = big.NewInt(5)
+
+	for _, op := range []*userop.UserOperation{op1, op2, op3} {
+		if err := mem.AddOp(ep, op); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+
+	if memOps, err := mem.DumpByMaxFee(ep, big.NewInt(5)); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(memOps) != 2 {
+		t.Fatalf("got length %d, want 2", len(memOps))
+	} else if !testutils.IsOpsEqual(memOps[0], op2) {
+		t.Fatal("incorrect order: first op out of place")
+	} else if !testutils.IsOpsEqual(memOps[1], op3) {
+		t.Fatal("incorrect order: second op out of place")
+	}
+}
+
+// TestGetOpsByPaymasterAfterReplace verifies that replacing an op with a different paymaster updates the
+// paymaster index.
+func TestGetOpsByPaymasterAfterReplace(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	ep := testutils.ValidAddress1
+	pm := testutils.ValidAddress4
+
+	op1 := testutils.MockValidInitUserOp()
+	op1.PaymasterAndData = pm.Bytes()
+	op2 := testutils.MockValidInitUserOp()
+	op2.MaxPriorityFeePerGas = big.NewInt(0).Add(op1.MaxPriorityFeePerGas, common.Big1)
+
+	if err := mem.AddOp(ep, op1); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if memOps, _ := mem.GetOpsByPaymaster(ep, pm); len(memOps) != 1 {
+		t.Fatalf("got length %d, want 1", len(memOps))
+	}
+
+	if err := mem.AddOp(ep, op2); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if memOps, _ := mem.GetOpsByPaymaster(ep, pm); len(memOps) != 0 {
+		t.Fatalf("got length %d, want 0", len(memOps))
+	}
+	if memOps, _ := mem.GetOpsBySender(ep, op2.Sender); len(memOps) != 1 {
+		t.Fatalf("got length %d, want 1", len(memOps))
+	}
+}
+
+// TestAddedAtIsKeptOnReplacement verifies that replacing a UserOperation does not reset the time it was first
+// added to the mempool and that removing it clears the time.
+func TestAddedAtIsKeptOnReplacement(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	ep := testutils.ValidAddress1
+	op1 := testutils.MockValidInitUserOp()
+	op2 := testutils.MockValidInitUserOp()
+	op2.MaxPriorityFeePerGas = big.NewInt(0).Add(op1.MaxPriorityFeePerGas, common.Big1)
+
+	if err := mem.AddOp(ep, op1); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	addedAt := mem.AddedAt(ep, op1)
+	if addedAt.IsZero() {
+		t.Fatal("got zero time, want non-zero")
+	}
+
+	if err := mem.AddOp(ep, op2); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if got := mem.AddedAt(ep, op2); !got.Equal(addedAt) {
+		t.Fatalf("got %v, want %v", got, addedAt)
+	}
+
+	if err := mem.RemoveOps(ep, op2); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if got := mem.AddedAt(ep, op2); !got.IsZero() {
+		t.Fatalf("got %v, want zero time", got)
+	}
+}
+
+// TestReloadFromSharedStore verifies that a mempool picks up ops written to a shared Store by another mempool
+// on Reload and that known ops keep their original added time.
+func TestReloadFromSharedStore(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	store := NewBadgerStore(db)
+	mem1, _ := NewWithStore(store)
+	mem2, _ := NewWithStore(store)
+	ep := testutils.ValidAddress1
+	op1 := testutils.MockValidInitUserOp()
+	op2 := testutils.MockValidInitUserOp()
+	op2.Nonce = big.NewInt(0).Add(op1.Nonce, common.Big1)
+
+	if err := mem1.AddOp(ep, op1); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := mem2.Reload(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	addedAt := mem2.AddedAt(ep, op1)
+	if addedAt.IsZero() {
+		t.Fatal("got zero time, want non-zero")
+	}
+
+	if err := mem1.AddOp(ep, op2); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := mem2.Reload(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	memOps, _ := mem2.Dump(ep)
+	if len(memOps) != 2 {
+		t.Fatalf("got length %d, want 2", len(memOps))
+	}
+	if got := mem2.AddedAt(ep, op1); !got.Equal(addedAt) {
+		t.Fatalf("got %v, want %v", got, addedAt)
+	}
+}
+
+// TestReloadDuringWrites verifies that ops added while Reload runs concurrently are never lost from the
+// in-memory index.
+func TestReloadDuringWrites(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := NewWithStore(NewBadgerStore(db))
+	ep := testutils.ValidAddress1
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			if err := mem.Reload(); err != nil {
+				t.Errorf("got %v, want nil", err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		op := testutils.MockValidInitUserOp()
+		op.Nonce = big.NewInt(int64(i))
+		if err := mem.AddOp(ep, op); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+	<-done
+
+	memOps, _ := mem.Dump(ep)
+	if len(memOps) != 50 {
+		t.Fatalf("got length %d, want 50", len(memOps))
+	}
+}
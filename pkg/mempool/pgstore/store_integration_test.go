@@ -0,0 +1,36 @@
+//go:build integration
+
+package pgstore
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool/storetest"
+)
+
+// TestStoreIntegration verifies that the Store satisfies the mempool Store contract against the PostgreSQL
+// instance at MEMPOOL_POSTGRES_URL. Run with `go test -tags integration`.
+func TestStoreIntegration(t *testing.T) {
+	url := os.Getenv("MEMPOOL_POSTGRES_URL")
+	if url == "" {
+		t.Skip("MEMPOOL_POSTGRES_URL is not set")
+	}
+
+	storetest.Run(t, func(t *testing.T) mempool.Store {
+		s, err := NewFromURL(url)
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		t.Cleanup(func() { s.db.Close() })
+
+		if err := s.Clear(); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		if _, err := s.db.Exec(`DELETE FROM mempool_lease`); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		return s
+	})
+}
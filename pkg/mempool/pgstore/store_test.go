@@ -0,0 +1,138 @@
+package pgstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func newMockStore(t *testing.T) (*Store, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("got %v, want nil", err)
+		}
+		db.Close()
+	})
+
+	mock.ExpectExec(schema).WillReturnResult(sqlmock.NewResult(0, 0))
+	s, err := New(db)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	return s, mock
+}
+
+// TestPutWritesMetadata verifies that an op is upserted along with its indexed metadata columns.
+func TestPutWritesMetadata(t *testing.T) {
+	s, mock := newMockStore(t)
+	ep := testutils.ValidAddress1
+	op := testutils.MockValidInitUserOp()
+	data, _ := op.MarshalJSON()
+
+	mock.ExpectExec(upsertOp).
+		WithArgs(
+			ep.String(),
+			op.Sender.String(),
+			op.Nonce.String(),
+			op.GetFactory().String(),
+			op.GetPaymaster().String(),
+			op.CallGasLimit.String(),
+			op.VerificationGasLimit.String(),
+			op.PreVerificationGas.String(),
+			op.MaxFeePerGas.String(),
+			op.MaxPriorityFeePerGas.String(),
+			string(data),
+		).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := s.Put(ep, op); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
+
+// TestDeleteRollsBackOnError verifies that no ops are deleted if any delete in the transaction fails.
+func TestDeleteRollsBackOnError(t *testing.T) {
+	s, mock := newMockStore(t)
+	ep := testutils.ValidAddress1
+	op1 := testutils.MockValidInitUserOp()
+	op2 := testutils.MockValidInitUserOp()
+	op2.Sender = testutils.ValidAddress2
+	errDelete := errors.New("delete failed")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(deleteOp).
+		WithArgs(ep.String(), op1.Sender.String(), op1.Nonce.String()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(deleteOp).
+		WithArgs(ep.String(), op2.Sender.String(), op2.Nonce.String()).
+		WillReturnError(errDelete)
+	mock.ExpectRollback()
+	if err := s.Delete(ep, op1, op2); !errors.Is(err, errDelete) {
+		t.Fatalf("got %v, want %v", err, errDelete)
+	}
+}
+
+// TestLoadInRowOrder verifies that ops are passed to the LoadFunc in the order of the rows returned.
+func TestLoadInRowOrder(t *testing.T) {
+	s, mock := newMockStore(t)
+	op1 := testutils.MockValidInitUserOp()
+	op2 := testutils.MockValidInitUserOp()
+	op2.Sender = testutils.ValidAddress2
+	data1, _ := op1.MarshalJSON()
+	data2, _ := op2.MarshalJSON()
+
+	mock.ExpectQuery(selectOps).
+		WillReturnRows(
+			sqlmock.NewRows([]string{"entry_point", "op"}).
+				AddRow(testutils.ValidAddress1.String(), data1).
+				AddRow(testutils.ValidAddress2.String(), data2),
+		)
+	eps := []common.Address{}
+	ops := []*userop.UserOperation{}
+	if err := s.Load(func(ep common.Address, op *userop.UserOperation) error {
+		eps = append(eps, ep)
+		ops = append(ops, op)
+		return nil
+	}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if len(ops) != 2 {
+		t.Fatalf("got length %d, want 2", len(ops))
+	}
+	if eps[0] != testutils.ValidAddress1 || !testutils.IsOpsEqual(ops[0], op1) {
+		t.Fatalf("entry 0: ops not equal: %s", testutils.GetOpsDiff(ops[0], op1))
+	}
+	if eps[1] != testutils.ValidAddress2 || !testutils.IsOpsEqual(ops[1], op2) {
+		t.Fatalf("entry 1: ops not equal: %s", testutils.GetOpsDiff(ops[1], op2))
+	}
+}
+
+// TestClaim verifies that the lease is granted if a row is returned and refused if the lease is held by
+// another owner.
+func TestClaim(t *testing.T) {
+	s, mock := newMockStore(t)
+	ttl := 10 * time.Second
+
+	mock.ExpectQuery(claimLease).
+		WithArgs("a", ttl.Milliseconds()).
+		WillReturnRows(sqlmock.NewRows([]string{"owner"}).AddRow("a"))
+	if ok, err := s.Claim("a", ttl); err != nil || !ok {
+		t.Fatalf("got %v %v, want true nil", ok, err)
+	}
+
+	mock.ExpectQuery(claimLease).
+		WithArgs("b", ttl.Milliseconds()).
+		WillReturnRows(sqlmock.NewRows([]string{"owner"}))
+	if ok, err := s.Claim("b", ttl); err != nil || ok {
+		t.Fatalf("got %v %v, want false nil", ok, err)
+	}
+}
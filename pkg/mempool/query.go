@@ -0,0 +1,147 @@
+package mempool
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+var (
+	// DefaultQueryLimit is the page size used by Query if a limit is not set.
+	DefaultQueryLimit = 100
+
+	// MaxQueryLimit is the largest page size allowed by Query.
+	MaxQueryLimit = 1000
+)
+
+// Query filters and paginates the UserOperations in the mempool for an EntryPoint. All set filters must match
+// for an op to be included. MinMaxFee and MaxMaxFee are inclusive bounds on MaxFeePerGas.
+type Query struct {
+	Sender    *common.Address
+	Paymaster *common.Address
+	Factory   *common.Address
+	MinMaxFee *big.Int
+	MaxMaxFee *big.Int
+	Offset    int
+	Limit     int
+}
+
+// QueryResult is a page of UserOperations matching a Query.
+type QueryResult struct {
+	UserOps []*userop.UserOperation `json:"userOps"`
+	Total   int                     `json:"total"`
+	Offset  int                     `json:"offset"`
+	Limit   int                     `json:"limit"`
+}
+
+type queryJSON struct {
+	Sender    *common.Address `json:"sender"`
+	Paymaster *common.Address `json:"paymaster"`
+	Factory   *common.Address `json:"factory"`
+	MinMaxFee *hexutil.Big    `json:"minMaxFee"`
+	MaxMaxFee *hexutil.Big    `json:"maxMaxFee"`
+	Offset    hexutil.Uint64  `json:"offset"`
+	Limit     hexutil.Uint64  `json:"limit"`
+}
+
+// ParseQuery decodes a Query from a JSON-RPC options object where all values are hex encoded.
+func ParseQuery(opts map[string]any) (*Query, error) {
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var q queryJSON
+	if err := json.Unmarshal(data, &q); err != nil {
+		return nil, fmt.Errorf("mempool: invalid query: %w", err)
+	}
+	if uint64(q.Limit) > uint64(MaxQueryLimit) {
+		return nil, fmt.Errorf("mempool: invalid query: limit must be between 0 and %d", MaxQueryLimit)
+	}
+
+	return &Query{
+		Sender:    q.Sender,
+		Paymaster: q.Paymaster,
+		Factory:   q.Factory,
+		MinMaxFee: (*big.Int)(q.MinMaxFee),
+		MaxMaxFee: (*big.Int)(q.MaxMaxFee),
+		Offset:    int(q.Offset),
+		Limit:     int(q.Limit),
+	}, nil
+}
+
+func (q *Query) matches(op *userop.UserOperation) bool {
+	if q.Sender != nil && op.Sender != *q.Sender {
+		return false
+	}
+	if q.Paymaster != nil && op.GetPaymaster() != *q.Paymaster {
+		return false
+	}
+	if q.Factory != nil && op.GetFactory() != *q.Factory {
+		return false
+	}
+	if q.MinMaxFee != nil && op.MaxFeePerGas.Cmp(q.MinMaxFee) < 0 {
+		return false
+	}
+	if q.MaxMaxFee != nil && op.MaxFeePerGas.Cmp(q.MaxMaxFee) > 0 {
+		return false
+	}
+	return true
+}
+
+// candidates returns the smallest superset of matching ops that can be served from the mempool's indexes.
+func (m *Mempool) candidates(entryPoint common.Address, q *Query) []*userop.UserOperation {
+	switch {
+	case q.Sender != nil:
+		return m.queue.GetOps(entryPoint, *q.Sender)
+	case q.Paymaster != nil:
+		return m.queue.GetOpsByPaymaster(entryPoint, *q.Paymaster)
+	case q.Factory != nil:
+		return m.queue.GetOps(entryPoint, *q.Factory)
+	case q.MinMaxFee != nil:
+		return m.queue.AllByMaxFee(entryPoint, q.MinMaxFee)
+	default:
+		return m.queue.All(entryPoint)
+	}
+}
+
+// Query returns a page of UserOperations associated with an EntryPoint that match all filters in q, along with
+// the total number of matching ops. Ops are in order of arrival unless only a gas price range is given, in
+// which case they are ordered from highest to lowest MaxFeePerGas.
+func (m *Mempool) Query(entryPoint common.Address, q *Query) (*QueryResult, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	} else if limit > MaxQueryLimit {
+		limit = MaxQueryLimit
+	}
+
+	matched := []*userop.UserOperation{}
+	for _, op := range m.candidates(entryPoint, q) {
+		if q.matches(op) {
+			matched = append(matched, op)
+		}
+	}
+
+	total := len(matched)
+	start := q.Offset
+	if start < 0 {
+		start = 0
+	} else if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+	return &QueryResult{
+		UserOps: matched[start:end],
+		Total:   total,
+		Offset:  start,
+		Limit:   limit,
+	}, nil
+}
@@ -0,0 +1,112 @@
+package mempool
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func queryTestOps(t *testing.T, mem *Mempool, ep common.Address) []*userop.UserOperation {
+	ops := []*userop.UserOperation{}
+	for i, sender := range []common.Address{
+		testutils.ValidAddress2,
+		testutils.ValidAddress3,
+		testutils.ValidAddress4,
+		testutils.ValidAddress5,
+	} {
+		op := testutils.MockValidInitUserOp()
+		op.Sender = sender
+		op.MaxFeePerGas = big.NewInt(int64(i+1) * 10)
+		if err := mem.AddOp(ep, op); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// TestQueryPaginates verifies that Query returns pages in order of arrival along with the total count.
+func TestQueryPaginates(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	ep := testutils.ValidAddress1
+	ops := queryTestOps(t, mem, ep)
+
+	res, err := mem.Query(ep, &Query{Offset: 1, Limit: 2})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if res.Total != 4 {
+		t.Fatalf("got total %d, want 4", res.Total)
+	}
+	if len(res.UserOps) != 2 || res.UserOps[0] != ops[1] || res.UserOps[1] != ops[2] {
+		t.Fatalf("got %d ops, want ops 1 and 2", len(res.UserOps))
+	}
+
+	res, err = mem.Query(ep, &Query{Offset: 10})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if len(res.UserOps) != 0 || res.Total != 4 {
+		t.Fatalf("got %d ops and total %d, want 0 and 4", len(res.UserOps), res.Total)
+	}
+}
+
+// TestQueryFilters verifies that all set filters must match for an op to be returned.
+func TestQueryFilters(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	ep := testutils.ValidAddress1
+	ops := queryTestOps(t, mem, ep)
+
+	res, err := mem.Query(ep, &Query{MinMaxFee: big.NewInt(20), MaxMaxFee: big.NewInt(30)})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if res.Total != 2 || res.UserOps[0] != ops[2] || res.UserOps[1] != ops[1] {
+		t.Fatalf("got total %d, want ops 2 and 1", res.Total)
+	}
+
+	sender := ops[3].Sender
+	factory := ops[3].GetFactory()
+	res, err = mem.Query(ep, &Query{Sender: &sender, Factory: &factory})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if res.Total != 1 || res.UserOps[0] != ops[3] {
+		t.Fatalf("got total %d, want op 3", res.Total)
+	}
+
+	res, err = mem.Query(ep, &Query{Sender: &sender, MaxMaxFee: big.NewInt(10)})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if res.Total != 0 {
+		t.Fatalf("got total %d, want 0", res.Total)
+	}
+}
+
+// TestParseQuery verifies that hex encoded JSON-RPC options are decoded and invalid limits are rejected.
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery(map[string]any{
+		"sender":    testutils.ValidAddress2.Hex(),
+		"minMaxFee": "0xa",
+		"offset":    "0x2",
+		"limit":     "0x5",
+	})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if *q.Sender != testutils.ValidAddress2 || q.MinMaxFee.Int64() != 10 || q.Offset != 2 || q.Limit != 5 {
+		t.Fatalf("got %+v, want parsed query", q)
+	}
+
+	if _, err := ParseQuery(map[string]any{"limit": "0x3e9"}); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
@@ -1,7 +1,10 @@
 package mempool
 
 import (
+	"math"
+	"math/big"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
@@ -9,8 +12,11 @@ import (
 )
 
 type set struct {
-	all      *sortedset.SortedSet
-	entities map[common.Address]*sortedset.SortedSet
+	all        *sortedset.SortedSet
+	entities   map[common.Address]*sortedset.SortedSet
+	paymasters map[common.Address]*sortedset.SortedSet
+	maxFees    *sortedset.SortedSet
+	addedAt    map[string]time.Time
 }
 
 func (s *set) getEntitiesSortedSet(entity common.Address) *sortedset.SortedSet {
@@ -21,6 +27,31 @@ func (s *set) getEntitiesSortedSet(entity common.Address) *sortedset.SortedSet {
 	return s.entities[entity]
 }
 
+func (s *set) getPaymastersSortedSet(paymaster common.Address) *sortedset.SortedSet {
+	if _, ok := s.paymasters[paymaster]; !ok {
+		s.paymasters[paymaster] = sortedset.New()
+	}
+
+	return s.paymasters[paymaster]
+}
+
+// removeFromIndexes removes an op from all secondary indexes. This is required before replacing an op since
+// the replacement may reference a different factory or paymaster.
+func (s *set) removeFromIndexes(key string, op *userop.UserOperation) {
+	s.getEntitiesSortedSet(op.Sender).Remove(key)
+	s.getEntitiesSortedSet(op.GetFactory()).Remove(key)
+	s.getEntitiesSortedSet(op.GetPaymaster()).Remove(key)
+	s.getPaymastersSortedSet(op.GetPaymaster()).Remove(key)
+	s.maxFees.Remove(key)
+}
+
+func getMaxFeeScore(maxFee *big.Int) sortedset.SCORE {
+	if !maxFee.IsInt64() {
+		return sortedset.SCORE(math.MaxInt64)
+	}
+	return sortedset.SCORE(maxFee.Int64())
+}
+
 type userOpQueues struct {
 	setsByEntryPoint sync.Map
 }
@@ -29,8 +60,11 @@ func (q *userOpQueues) getEntryPointSet(entryPoint common.Address) *set {
 	val, ok := q.setsByEntryPoint.Load(entryPoint)
 	if !ok {
 		val = &set{
-			all:      sortedset.New(),
-			entities: make(map[common.Address]*sortedset.SortedSet),
+			all:        sortedset.New(),
+			entities:   make(map[common.Address]*sortedset.SortedSet),
+			paymasters: make(map[common.Address]*sortedset.SortedSet),
+			maxFees:    sortedset.New(),
+			addedAt:    make(map[string]time.Time),
 		}
 		q.setsByEntryPoint.Store(entryPoint, val)
 	}
@@ -41,9 +75,15 @@ func (q *userOpQueues) getEntryPointSet(entryPoint common.Address) *set {
 func (q *userOpQueues) AddOp(entryPoint common.Address, op *userop.UserOperation) {
 	eps := q.getEntryPointSet(entryPoint)
 	key := string(getUniqueKey(entryPoint, op.Sender, op.Nonce))
+	if n := eps.all.GetByKey(key); n != nil {
+		eps.removeFromIndexes(key, n.Value.(*userop.UserOperation))
+	}
+	if _, ok := eps.addedAt[key]; !ok {
+		eps.addedAt[key] = time.Now()
+	}
 
 	eps.all.AddOrUpdate(key, sortedset.SCORE(eps.all.GetCount()), op)
-	eps.getEntitiesSortedSet(op.Sender).AddOrUpdate(key, sortedset.SCORE(op.Nonce.Int64()), op)
+	eps.getEntitiesSortedSet(op.Sender).AddOrUpdate(key, sortedset.SCORE(op.GetNonceSequence().Int64()), op)
 	if factory := op.GetFactory(); factory != common.HexToAddress("0x") {
 		fss := eps.getEntitiesSortedSet(factory)
 		fss.AddOrUpdate(key, sortedset.SCORE(fss.GetCount()), op)
@@ -51,7 +91,11 @@ func (q *userOpQueues) AddOp(entryPoint common.Address, op *userop.UserOperation
 	if paymaster := op.GetPaymaster(); paymaster != common.HexToAddress("0x") {
 		pss := eps.getEntitiesSortedSet(paymaster)
 		pss.AddOrUpdate(key, sortedset.SCORE(pss.GetCount()), op)
+
+		pms := eps.getPaymastersSortedSet(paymaster)
+		pms.AddOrUpdate(key, sortedset.SCORE(pms.GetCount()), op)
 	}
+	eps.maxFees.AddOrUpdate(key, getMaxFeeScore(op.MaxFeePerGas), op)
 }
 
 func (q *userOpQueues) GetOps(entryPoint common.Address, entity common.Address) []*userop.UserOperation {
@@ -66,6 +110,32 @@ func (q *userOpQueues) GetOps(entryPoint common.Address, entity common.Address)
 	return batch
 }
 
+func (q *userOpQueues) GetOpsByPaymaster(
+	entryPoint common.Address,
+	paymaster common.Address,
+) []*userop.UserOperation {
+	eps := q.getEntryPointSet(entryPoint)
+	pms := eps.getPaymastersSortedSet(paymaster)
+	nodes := pms.GetByRankRange(1, -1, false)
+	batch := []*userop.UserOperation{}
+	for _, n := range nodes {
+		batch = append(batch, n.Value.(*userop.UserOperation))
+	}
+
+	return batch
+}
+
+func (q *userOpQueues) AllByMaxFee(entryPoint common.Address, minMaxFee *big.Int) []*userop.UserOperation {
+	eps := q.getEntryPointSet(entryPoint)
+	nodes := eps.maxFees.GetByScoreRange(sortedset.SCORE(math.MaxInt64), getMaxFeeScore(minMaxFee), nil)
+	batch := []*userop.UserOperation{}
+	for _, n := range nodes {
+		batch = append(batch, n.Value.(*userop.UserOperation))
+	}
+
+	return batch
+}
+
 func (q *userOpQueues) All(entryPoint common.Address) []*userop.UserOperation {
 	eps := q.getEntryPointSet(entryPoint)
 	nodes := eps.all.GetByRankRange(1, -1, false)
@@ -77,14 +147,36 @@ func (q *userOpQueues) All(entryPoint common.Address) []*userop.UserOperation {
 	return batch
 }
 
+func (q *userOpQueues) AddedAt(entryPoint common.Address, op *userop.UserOperation) time.Time {
+	eps := q.getEntryPointSet(entryPoint)
+	return eps.addedAt[string(getUniqueKey(entryPoint, op.Sender, op.Nonce))]
+}
+
+func (q *userOpQueues) Get(entryPoint common.Address, sender common.Address, nonce *big.Int) *userop.UserOperation {
+	eps := q.getEntryPointSet(entryPoint)
+	n := eps.all.GetByKey(string(getUniqueKey(entryPoint, sender, nonce)))
+	if n == nil {
+		return nil
+	}
+	return n.Value.(*userop.UserOperation)
+}
+
+func (q *userOpQueues) EntryPoints() []common.Address {
+	eps := []common.Address{}
+	q.setsByEntryPoint.Range(func(key, _ any) bool {
+		eps = append(eps, key.(common.Address))
+		return true
+	})
+	return eps
+}
+
 func (q *userOpQueues) RemoveOps(entryPoint common.Address, ops ...*userop.UserOperation) {
 	eps := q.getEntryPointSet(entryPoint)
 	for _, op := range ops {
 		key := string(getUniqueKey(entryPoint, op.Sender, op.Nonce))
 		eps.all.Remove(key)
-		eps.getEntitiesSortedSet(op.Sender).Remove(key)
-		eps.getEntitiesSortedSet(op.GetFactory()).Remove(key)
-		eps.getEntitiesSortedSet(op.GetPaymaster()).Remove(key)
+		delete(eps.addedAt, key)
+		eps.removeFromIndexes(key, op)
 	}
 }
 
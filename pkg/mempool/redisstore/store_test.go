@@ -0,0 +1,77 @@
+package redisstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool/storetest"
+)
+
+func newTestStore(t *testing.T) (*Store, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return New(rdb), mr
+}
+
+// TestStore verifies that the Store satisfies the mempool Store contract.
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) mempool.Store {
+		s, _ := newTestStore(t)
+		return s
+	})
+}
+
+// TestPrefixIsolatesStores verifies that Stores with different prefixes on the same Redis instance don't see
+// or clear each other's ops.
+func TestPrefixIsolatesStores(t *testing.T) {
+	s1, mr := newTestStore(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	s2 := New(rdb)
+	s2.SetPrefix("other")
+	ep := testutils.ValidAddress1
+
+	if err := s1.Put(ep, testutils.MockValidInitUserOp()); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := s2.Clear(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	mem, err := mempool.NewWithStore(s1)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if ops, _ := mem.Dump(ep); len(ops) != 1 {
+		t.Fatalf("got length %d, want 1", len(ops))
+	}
+	mem, err = mempool.NewWithStore(s2)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if ops, _ := mem.Dump(ep); len(ops) != 0 {
+		t.Fatalf("got length %d, want 0", len(ops))
+	}
+}
+
+// TestClaimExpires verifies that the lease can be claimed by another owner once it expires without renewal.
+func TestClaimExpires(t *testing.T) {
+	s, mr := newTestStore(t)
+
+	if ok, err := s.Claim("a", time.Second); err != nil || !ok {
+		t.Fatalf("got %v %v, want true nil", ok, err)
+	}
+	if ok, err := s.Claim("b", time.Second); err != nil || ok {
+		t.Fatalf("got %v %v, want false nil", ok, err)
+	}
+
+	mr.FastForward(2 * time.Second)
+	if ok, err := s.Claim("b", time.Second); err != nil || !ok {
+		t.Fatalf("got %v %v, want true nil", ok, err)
+	}
+}
@@ -0,0 +1,154 @@
+// Package sqlitestore implements a mempool Store backed by a local SQLite file. It only replaces the storage
+// of pending UserOperations. Reputation and the other stores still use BadgerDB, so the bundler opens both
+// when this backend is selected. The driver is pure Go and doesn't require cgo.
+package sqlitestore
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+
+	// Registers the sqlite driver.
+	_ "modernc.org/sqlite"
+)
+
+// schema creates the table used by the Store. Rows are keyed by EntryPoint, sender, and nonce. The seq column
+// is never updated on a replacement so that the original arrival order is kept.
+const schema = `
+CREATE TABLE IF NOT EXISTS mempool_ops (
+	seq         INTEGER PRIMARY KEY AUTOINCREMENT,
+	entry_point TEXT    NOT NULL,
+	sender      TEXT    NOT NULL,
+	nonce       TEXT    NOT NULL,
+	op          BLOB    NOT NULL,
+	UNIQUE (entry_point, sender, nonce)
+);
+`
+
+const upsertOp = `
+INSERT INTO mempool_ops (entry_point, sender, nonce, op) VALUES (?, ?, ?, ?)
+ON CONFLICT (entry_point, sender, nonce) DO UPDATE SET op = excluded.op
+`
+
+const deleteOp = `DELETE FROM mempool_ops WHERE entry_point = ? AND sender = ? AND nonce = ?`
+
+const selectOps = `SELECT entry_point, op FROM mempool_ops ORDER BY seq`
+
+const clearOps = `DELETE FROM mempool_ops`
+
+// Store persists UserOperations to a mempool_ops table in SQLite.
+type Store struct {
+	db *sql.DB
+}
+
+var _ mempool.Store = (*Store)(nil)
+
+// New returns a Store using the given DB. The mempool_ops table is created if it doesn't exist.
+func New(db *sql.DB) (*Store, error) {
+	if _, err := db.Exec(schema); err != nil {
+		return nil, err
+	}
+	return &Store{db}, nil
+}
+
+// NewFromPath returns a Store using the SQLite file at the given path. The file is created if it doesn't
+// exist. The Store is meant to be used by a single bundler process, so writes go through one connection.
+func NewFromPath(path string) (*Store, error) {
+	db, err := sql.Open(
+		"sqlite",
+		"file:"+path+"?_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_pragma=busy_timeout(5000)",
+	)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	if err := db.Ping(); err != nil {
+		return nil, err
+	}
+	return New(db)
+}
+
+func getUserOpFromValue(value []byte) (*userop.UserOperation, error) {
+	data := make(map[string]any)
+	if err := json.Unmarshal(value, &data); err != nil {
+		return nil, err
+	}
+	return userop.New(data)
+}
+
+// Put implements the mempool.Store interface.
+func (s *Store) Put(entryPoint common.Address, op *userop.UserOperation) error {
+	data, err := op.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(upsertOp, entryPoint.String(), op.Sender.String(), op.Nonce.String(), data)
+	return err
+}
+
+// Delete implements the mempool.Store interface. All ops are removed in a single transaction.
+func (s *Store) Delete(entryPoint common.Address, ops ...*userop.UserOperation) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, op := range ops {
+		if _, err := tx.Exec(deleteOp, entryPoint.String(), op.Sender.String(), op.Nonce.String()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Load implements the mempool.Store interface.
+func (s *Store) Load(fn mempool.LoadFunc) error {
+	rows, err := s.db.Query(selectOps)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// Only one connection is open so all rows are read before any are passed to fn, which may write back to
+	// the Store.
+	type entry struct {
+		ep   string
+		data []byte
+	}
+	entries := []entry{}
+	for rows.Next() {
+		var e entry
+		if err := rows.Scan(&e.ep, &e.data); err != nil {
+			return err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	for _, e := range entries {
+		op, err := getUserOpFromValue(e.data)
+		if err != nil {
+			return err
+		}
+		if err := fn(common.HexToAddress(e.ep), op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clear implements the mempool.Store interface.
+func (s *Store) Clear() error {
+	_, err := s.db.Exec(clearOps)
+	return err
+}
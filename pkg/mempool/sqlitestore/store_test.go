@@ -0,0 +1,60 @@
+package sqlitestore
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool/storetest"
+)
+
+func newStore(tb testing.TB) mempool.Store {
+	s, err := NewFromPath(filepath.Join(tb.TempDir(), "mempool.sqlite"))
+	if err != nil {
+		tb.Fatalf("got %v, want nil", err)
+	}
+	tb.Cleanup(func() { s.db.Close() })
+	return s
+}
+
+// TestStore verifies that the Store satisfies the mempool.Store contract.
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) mempool.Store { return newStore(t) })
+}
+
+// TestStorePersists verifies that ops are loaded again after the file is reopened.
+func TestStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mempool.sqlite")
+	s, err := NewFromPath(path)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	mem, err := mempool.NewWithStore(s)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	ep := testutils.ValidAddress1
+	if err := mem.AddOp(ep, testutils.MockValidInitUserOp()); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	s.db.Close()
+
+	s, err = NewFromPath(path)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	defer s.db.Close()
+	mem, err = mempool.NewWithStore(s)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if ops, _ := mem.Dump(ep); len(ops) != 1 {
+		t.Fatalf("got length %d, want 1", len(ops))
+	}
+}
+
+// BenchmarkStore runs the shared Store benchmarks. Compare with BenchmarkBadgerStore in the mempool package.
+func BenchmarkStore(b *testing.B) {
+	storetest.Bench(b, func(b *testing.B) mempool.Store { return newStore(b) })
+}
@@ -0,0 +1,38 @@
+package mempool
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// LoadFunc is called by a Store for each persisted UserOperation in the order it was first added.
+type LoadFunc = func(entryPoint common.Address, op *userop.UserOperation) error
+
+// Store is the storage backend of a Mempool. The Mempool keeps an in-memory index of all pending
+// UserOperations for fast reads and writes through to the Store so that it can be rebuilt on a restart or
+// shared between multiple bundler processes.
+type Store interface {
+	// Put adds a UserOperation or replaces an existing one with the same EntryPoint, Sender, and Nonce values.
+	// A replacement keeps the position of the original op.
+	Put(entryPoint common.Address, op *userop.UserOperation) error
+
+	// Delete removes a list of UserOperations by EntryPoint, Sender, and Nonce values.
+	Delete(entryPoint common.Address, ops ...*userop.UserOperation) error
+
+	// Load calls fn for every UserOperation in the Store.
+	Load(fn LoadFunc) error
+
+	// Clear removes all UserOperations from the Store.
+	Clear() error
+}
+
+// Leaser is implemented by a Store that can be shared between bundler processes. Every process can accept ops
+// into a shared Store but only the one holding the lease should bundle them. Otherwise the same op would be
+// sent in more than one bundle and all but one would revert.
+type Leaser interface {
+	// Claim acquires the lease for owner, or renews it if owner already holds it, until ttl has passed. It
+	// returns false if the lease is held by a different owner and has not expired.
+	Claim(owner string, ttl time.Duration) (bool, error)
+}
@@ -0,0 +1,27 @@
+package mempool_test
+
+import (
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool/storetest"
+)
+
+// TestBadgerStore verifies that the BadgerStore satisfies the Store contract.
+func TestBadgerStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) mempool.Store {
+		db := testutils.DBMock()
+		t.Cleanup(func() { db.Close() })
+		return mempool.NewBadgerStore(db)
+	})
+}
+
+// BenchmarkBadgerStore is the baseline for comparing other Store backends.
+func BenchmarkBadgerStore(b *testing.B) {
+	storetest.Bench(b, func(b *testing.B) mempool.Store {
+		db := testutils.DBMock()
+		b.Cleanup(func() { db.Close() })
+		return mempool.NewBadgerStore(db)
+	})
+}
@@ -0,0 +1,260 @@
+// Package storetest implements a suite of tests that every mempool.Store backend must pass. Backends run it
+// from their own tests with a constructor that returns an empty Store.
+package storetest
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// NewStoreFunc returns an empty Store for a single test.
+type NewStoreFunc = func(t *testing.T) mempool.Store
+
+type entry struct {
+	ep common.Address
+	op *userop.UserOperation
+}
+
+func load(t *testing.T, s mempool.Store) []entry {
+	entries := []entry{}
+	if err := s.Load(func(ep common.Address, op *userop.UserOperation) error {
+		entries = append(entries, entry{ep, op})
+		return nil
+	}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	return entries
+}
+
+func put(t *testing.T, s mempool.Store, ep common.Address, ops ...*userop.UserOperation) {
+	for _, op := range ops {
+		if err := s.Put(ep, op); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+}
+
+func withNonce(nonce int64) *userop.UserOperation {
+	op := testutils.MockValidInitUserOp()
+	op.Nonce = big.NewInt(nonce)
+	return op
+}
+
+func assertEntries(t *testing.T, got []entry, want []entry) {
+	if len(got) != len(want) {
+		t.Fatalf("got length %d, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].ep != want[i].ep {
+			t.Fatalf("entry %d: got entry point %s, want %s", i, got[i].ep, want[i].ep)
+		}
+		if !testutils.IsOpsEqual(got[i].op, want[i].op) {
+			t.Fatalf("entry %d: ops not equal: %s", i, testutils.GetOpsDiff(got[i].op, want[i].op))
+		}
+	}
+}
+
+// Run runs the Store contract tests against Stores returned by newStore. If the Store also implements
+// mempool.Leaser, the lease is tested as well.
+func Run(t *testing.T, newStore NewStoreFunc) {
+	t.Run("PutAndLoadInOrder", func(t *testing.T) {
+		testPutAndLoadInOrder(t, newStore(t))
+	})
+	t.Run("ReplaceKeepsPosition", func(t *testing.T) {
+		testReplaceKeepsPosition(t, newStore(t))
+	})
+	t.Run("Delete", func(t *testing.T) {
+		testDelete(t, newStore(t))
+	})
+	t.Run("Clear", func(t *testing.T) {
+		testClear(t, newStore(t))
+	})
+	t.Run("SharedByMempools", func(t *testing.T) {
+		testSharedByMempools(t, newStore(t))
+	})
+	t.Run("Claim", func(t *testing.T) {
+		l, ok := newStore(t).(mempool.Leaser)
+		if !ok {
+			t.Skip("store is not a mempool.Leaser")
+		}
+		testClaim(t, l)
+	})
+}
+
+// testPutAndLoadInOrder verifies that ops across EntryPoints are loaded in the order they were first added.
+func testPutAndLoadInOrder(t *testing.T, s mempool.Store) {
+	ep1 := testutils.ValidAddress1
+	ep2 := testutils.ValidAddress2
+	op1 := withNonce(1)
+	op2 := withNonce(2)
+	op3 := withNonce(3)
+	put(t, s, ep1, op1, op2)
+	put(t, s, ep2, op3)
+
+	got := load(t, s)
+	ep1Entries := []entry{}
+	ep2Entries := []entry{}
+	for _, e := range got {
+		if e.ep == ep1 {
+			ep1Entries = append(ep1Entries, e)
+		} else {
+			ep2Entries = append(ep2Entries, e)
+		}
+	}
+	assertEntries(t, ep1Entries, []entry{{ep1, op1}, {ep1, op2}})
+	assertEntries(t, ep2Entries, []entry{{ep2, op3}})
+}
+
+// testReplaceKeepsPosition verifies that an op with the same sender and nonce replaces the original in place.
+func testReplaceKeepsPosition(t *testing.T, s mempool.Store) {
+	ep := testutils.ValidAddress1
+	op1 := withNonce(1)
+	op2 := withNonce(2)
+	put(t, s, ep, op1, op2)
+
+	replacement := withNonce(1)
+	replacement.MaxPriorityFeePerGas = big.NewInt(0).Add(op1.MaxPriorityFeePerGas, common.Big1)
+	put(t, s, ep, replacement)
+
+	assertEntries(t, load(t, s), []entry{{ep, replacement}, {ep, op2}})
+}
+
+// testDelete verifies that only the given ops are deleted and that deleting unknown ops is not an error.
+func testDelete(t *testing.T, s mempool.Store) {
+	ep := testutils.ValidAddress1
+	op1 := withNonce(1)
+	op2 := withNonce(2)
+	op3 := withNonce(3)
+	put(t, s, ep, op1, op2, op3)
+
+	if err := s.Delete(ep, op1, op3); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := s.Delete(testutils.ValidAddress2, op2); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := s.Delete(ep); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	assertEntries(t, load(t, s), []entry{{ep, op2}})
+}
+
+// testClear verifies that all ops are removed and that the Store can be written to again.
+func testClear(t *testing.T, s mempool.Store) {
+	ep := testutils.ValidAddress1
+	put(t, s, ep, withNonce(1), withNonce(2))
+
+	if err := s.Clear(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	assertEntries(t, load(t, s), []entry{})
+
+	op := withNonce(3)
+	put(t, s, ep, op)
+	assertEntries(t, load(t, s), []entry{{ep, op}})
+}
+
+// testSharedByMempools verifies that a Mempool picks up ops added and removed by another Mempool on the same
+// Store after a Reload.
+func testSharedByMempools(t *testing.T, s mempool.Store) {
+	ep := testutils.ValidAddress1
+	op1 := withNonce(1)
+	op2 := withNonce(2)
+	mem1, err := mempool.NewWithStore(s)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	mem2, err := mempool.NewWithStore(s)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if err := mem1.AddOp(ep, op1); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := mem1.AddOp(ep, op2); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := mem2.Reload(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if ops, _ := mem2.Dump(ep); len(ops) != 2 {
+		t.Fatalf("got length %d, want 2", len(ops))
+	}
+
+	if err := mem1.RemoveOps(ep, op1); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := mem2.Reload(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	ops, _ := mem2.Dump(ep)
+	if len(ops) != 1 || !testutils.IsOpsEqual(ops[0], op2) {
+		t.Fatalf("got %v, want [op2]", ops)
+	}
+}
+
+// testClaim verifies that only one owner can hold the lease at a time and that the owner can renew it.
+func testClaim(t *testing.T, l mempool.Leaser) {
+	ttl := time.Minute
+	if ok, err := l.Claim("a", ttl); err != nil || !ok {
+		t.Fatalf("got %v %v, want true nil", ok, err)
+	}
+	if ok, err := l.Claim("b", ttl); err != nil || ok {
+		t.Fatalf("got %v %v, want false nil", ok, err)
+	}
+	if ok, err := l.Claim("a", ttl); err != nil || !ok {
+		t.Fatalf("got %v %v, want true nil", ok, err)
+	}
+}
+
+// NewBenchStoreFunc returns an empty Store for a single benchmark.
+type NewBenchStoreFunc = func(b *testing.B) mempool.Store
+
+// Bench runs the same benchmarks against Stores returned by newStore so that backends can be compared with
+// benchstat.
+func Bench(b *testing.B, newStore NewBenchStoreFunc) {
+	ep := testutils.ValidAddress1
+	b.Run("Put", func(b *testing.B) {
+		s := newStore(b)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := s.Put(ep, withNonce(int64(i))); err != nil {
+				b.Fatalf("got %v, want nil", err)
+			}
+		}
+	})
+	b.Run("PutAndDelete", func(b *testing.B) {
+		s := newStore(b)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			op := withNonce(int64(i))
+			if err := s.Put(ep, op); err != nil {
+				b.Fatalf("got %v, want nil", err)
+			}
+			if err := s.Delete(ep, op); err != nil {
+				b.Fatalf("got %v, want nil", err)
+			}
+		}
+	})
+	b.Run("Load1000", func(b *testing.B) {
+		s := newStore(b)
+		for i := 0; i < 1000; i++ {
+			if err := s.Put(ep, withNonce(int64(i))); err != nil {
+				b.Fatalf("got %v, want nil", err)
+			}
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := s.Load(func(common.Address, *userop.UserOperation) error { return nil }); err != nil {
+				b.Fatalf("got %v, want nil", err)
+			}
+		}
+	})
+}
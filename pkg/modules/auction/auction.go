@@ -0,0 +1,197 @@
+// Package auction implements a module for offering hints about UserOperations in a batch to an MEV-share style
+// orderflow auction. Backruns returned by the auction are included after handleOps in the submitted bundle
+// and a share of each backrun's value is refunded to the op sender.
+package auction
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// DefaultRefundPercent is the default share of a backrun's value refunded to the op sender.
+var DefaultRefundPercent = 90
+
+// Hint is the partial information about a UserOperation revealed to searchers in the auction. The full
+// callData and signature are never shared.
+type Hint struct {
+	UserOpHash common.Hash    `json:"userOpHash"`
+	Sender     common.Address `json:"sender"`
+	Selector   hexutil.Bytes  `json:"selector"`
+	Paymaster  common.Address `json:"paymaster"`
+}
+
+// Refund is the share of a backrun's value that must be paid to the recipient for the backrun to be
+// accepted.
+type Refund struct {
+	UserOpHash common.Hash    `json:"userOpHash"`
+	Recipient  common.Address `json:"recipient"`
+	Percent    int            `json:"percent"`
+}
+
+// Request is the JSON body sent to the auction for each batch.
+type Request struct {
+	EntryPoint common.Address `json:"entryPoint"`
+	ChainID    *hexutil.Big   `json:"chainId"`
+	Hints      []*Hint        `json:"hints"`
+	Refunds    []*Refund      `json:"refunds"`
+}
+
+// Backrun is a list of signed raw transactions that a searcher wants included after handleOps.
+type Backrun struct {
+	UserOpHash common.Hash     `json:"userOpHash"`
+	Txs        []hexutil.Bytes `json:"txs"`
+}
+
+// Response is the JSON body expected from the auction. Backruns for ops that were not offered are ignored.
+type Response struct {
+	Backruns []*Backrun `json:"backruns"`
+}
+
+// Auction offers hints about each batch to an external orderflow auction.
+type Auction struct {
+	url           string
+	client        *http.Client
+	refundPercent int
+	logger        logr.Logger
+}
+
+// New returns an Auction for the given URL. Requests that take longer than timeout are treated as an auction
+// without any backruns so that bundling is never delayed further.
+func New(url string, timeout time.Duration, l logr.Logger) *Auction {
+	return &Auction{
+		url:           url,
+		client:        &http.Client{Timeout: timeout},
+		refundPercent: DefaultRefundPercent,
+		logger:        l.WithName("auction"),
+	}
+}
+
+// SetRefundPercent sets the share of a backrun's value, in percent, that must be refunded to the op sender.
+// The remainder is kept by the bundler.
+//
+// The default value is 90.
+func (a *Auction) SetRefundPercent(pct int) {
+	a.refundPercent = pct
+}
+
+// isEligible returns true if the op can be offered in the auction. Ops without a function call have nothing
+// to backrun.
+func isEligible(op *userop.UserOperation) bool {
+	return len(op.CallData) >= 4
+}
+
+// Offer sends the request to the auction and returns its decoded response.
+func (a *Auction) Offer(req *Request) (*Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := a.client.Post(a.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		reason, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return nil, fmt.Errorf("auction: status %d: %s", res.StatusCode, strings.TrimSpace(string(reason)))
+	}
+	var out Response
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&out); err != nil {
+		return nil, fmt.Errorf("auction: invalid response: %w", err)
+	}
+	return &out, nil
+}
+
+// decodeBackrun returns the hex encoded transactions of a backrun and their hashes. An error is returned if
+// any transaction is invalid since the backrun is only valuable to the searcher as a whole.
+func decodeBackrun(br *Backrun) (txs []string, hashes []string, err error) {
+	if len(br.Txs) == 0 {
+		return nil, nil, fmt.Errorf("auction: backrun has no transactions")
+	}
+	for _, raw := range br.Txs {
+		txn := &types.Transaction{}
+		if err := txn.UnmarshalBinary(raw); err != nil {
+			return nil, nil, err
+		}
+		txs = append(txs, hexutil.Encode(raw))
+		hashes = append(hashes, txn.Hash().String())
+	}
+	return txs, hashes, nil
+}
+
+// BatchHandler returns a BatchHandler that is used by the Bundler to offer hints for eligible ops in the
+// batch. Valid backruns are set in ctx.Data under backrun_txs and backrun_txn_hashes for the builder to
+// include after handleOps. Auction failures are logged and the batch continues without backruns. This module
+// should be used after all batch checks and before sending the bundle.
+func (a *Auction) BatchHandler() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		req := &Request{
+			EntryPoint: ctx.EntryPoint,
+			ChainID:    (*hexutil.Big)(big.NewInt(0).Set(ctx.ChainID)),
+			Hints:      []*Hint{},
+			Refunds:    []*Refund{},
+		}
+		offered := map[common.Hash]bool{}
+		for _, op := range ctx.Batch {
+			if !isEligible(op) {
+				continue
+			}
+			hash := op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)
+			offered[hash] = true
+			req.Hints = append(req.Hints, &Hint{
+				UserOpHash: hash,
+				Sender:     op.Sender,
+				Selector:   op.CallData[:4],
+				Paymaster:  op.GetPaymaster(),
+			})
+			req.Refunds = append(req.Refunds, &Refund{
+				UserOpHash: hash,
+				Recipient:  op.Sender,
+				Percent:    a.refundPercent,
+			})
+		}
+		if len(req.Hints) == 0 {
+			return nil
+		}
+
+		res, err := a.Offer(req)
+		if err != nil {
+			a.logger.Error(err, "auction failed, sending without backruns")
+			return nil
+		}
+		txs := []string{}
+		hashes := []string{}
+		for _, br := range res.Backruns {
+			if !offered[br.UserOpHash] {
+				continue
+			}
+			brTxs, brHashes, err := decodeBackrun(br)
+			if err != nil {
+				a.logger.Info("skipping invalid backrun", "userop_hash", br.UserOpHash, "error", err.Error())
+				continue
+			}
+			txs = append(txs, brTxs...)
+			hashes = append(hashes, brHashes...)
+		}
+		if len(txs) > 0 {
+			ctx.Data["backrun_txs"] = txs
+			ctx.Data["backrun_txn_hashes"] = hashes
+		}
+		return nil
+	}
+}
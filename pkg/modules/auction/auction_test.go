@@ -0,0 +1,136 @@
+package auction
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func newCtx(ops ...*userop.UserOperation) *modules.BatchHandlerCtx {
+	return modules.NewBatchHandlerContext(
+		ops,
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		big.NewInt(1),
+		big.NewInt(1),
+		big.NewInt(1),
+	)
+}
+
+func signedTx(t *testing.T) (hexutil.Bytes, common.Hash) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	txn, err := types.SignTx(
+		types.NewTransaction(0, testutils.ValidAddress2, big.NewInt(0), 21000, big.NewInt(1), nil),
+		types.LatestSignerForChainID(testutils.ChainID),
+		key,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := txn.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw, txn.Hash()
+}
+
+func newServer(t *testing.T, handle func(req *Request) *Response) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("got %v, want nil", err)
+		}
+		_ = json.NewEncoder(w).Encode(handle(&req))
+	}))
+}
+
+// TestBatchHandlerIncludesBackruns verifies that hints and refunds are offered for eligible ops and valid
+// backruns are set in ctx.Data.
+func TestBatchHandlerIncludesBackruns(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	op.CallData = []byte{0xde, 0xad, 0xbe, 0xef, 0x01}
+	hash := op.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID)
+	raw, txHash := signedTx(t)
+
+	s := newServer(t, func(req *Request) *Response {
+		if len(req.Hints) != 1 || req.Hints[0].Selector.String() != "0xdeadbeef" {
+			t.Errorf("got %v, want one hint with selector 0xdeadbeef", req.Hints)
+		}
+		if len(req.Refunds) != 1 || req.Refunds[0].Recipient != op.Sender || req.Refunds[0].Percent != 80 {
+			t.Errorf("got %v, want 80%% refund to sender", req.Refunds)
+		}
+		return &Response{Backruns: []*Backrun{
+			{UserOpHash: hash, Txs: []hexutil.Bytes{raw}},
+			{UserOpHash: common.HexToHash("0x01"), Txs: []hexutil.Bytes{raw}},
+		}}
+	})
+	defer s.Close()
+
+	a := New(s.URL, time.Second, logr.Discard())
+	a.SetRefundPercent(80)
+	ctx := newCtx(op)
+	if err := a.BatchHandler()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	txs, _ := ctx.Data["backrun_txs"].([]string)
+	hashes, _ := ctx.Data["backrun_txn_hashes"].([]string)
+	if len(txs) != 1 || txs[0] != raw.String() {
+		t.Fatalf("got %v, want only the backrun for the offered op", txs)
+	}
+	if len(hashes) != 1 || hashes[0] != txHash.String() {
+		t.Fatalf("got %v, want %s", hashes, txHash)
+	}
+}
+
+// TestBatchHandlerSkipsIneligibleOps verifies that the auction is not called if no op has a function call.
+func TestBatchHandlerSkipsIneligibleOps(t *testing.T) {
+	called := false
+	s := newServer(t, func(req *Request) *Response {
+		called = true
+		return &Response{}
+	})
+	defer s.Close()
+
+	op := testutils.MockValidInitUserOp()
+	op.CallData = []byte{}
+	ctx := newCtx(op)
+	if err := New(s.URL, time.Second, logr.Discard()).BatchHandler()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if called {
+		t.Fatal("got auction call, want none")
+	}
+}
+
+// TestBatchHandlerFailsOpen verifies that an auction failure does not fail the batch.
+func TestBatchHandlerFailsOpen(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer s.Close()
+
+	op := testutils.MockValidInitUserOp()
+	op.CallData = []byte{0xde, 0xad, 0xbe, 0xef}
+	ctx := newCtx(op)
+	if err := New(s.URL, time.Second, logr.Discard()).BatchHandler()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if _, ok := ctx.Data["backrun_txs"]; ok {
+		t.Fatal("got backruns, want none")
+	}
+}
@@ -0,0 +1,145 @@
+package batch
+
+import (
+	"sync"
+	"time"
+
+	"github.com/stackup-wallet/stackup-bundler/pkg/clock"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+)
+
+const adaptiveStartedAtKey = "adaptive_started_at"
+
+// AdaptiveLimiter tunes the max number of ops per batch based on observed simulation latency and deadline
+// misses. The limit is halved whenever simulation exceeds the target latency or a batch misses its deadline,
+// and grows by one for every saturated batch that simulates well within the target. Ops cut by the limit are
+// left in the mempool for the next cycle.
+type AdaptiveLimiter struct {
+	min           int
+	max           int
+	targetLatency time.Duration
+	deadline      time.Duration
+	clock         clock.Clock
+	mu            sync.Mutex
+	current       int
+	saturated     bool
+}
+
+// NewAdaptiveLimiter returns an AdaptiveLimiter that keeps the per batch op count between min and max. The
+// limit starts at max.
+func NewAdaptiveLimiter(min, max int, targetLatency, deadline time.Duration) *AdaptiveLimiter {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	return &AdaptiveLimiter{
+		min:           min,
+		max:           max,
+		targetLatency: targetLatency,
+		deadline:      deadline,
+		clock:         clock.Real(),
+		current:       max,
+	}
+}
+
+// SetClock sets the Clock used to measure simulation latency and batch deadlines.
+//
+// The default value is clock.Real().
+func (a *AdaptiveLimiter) SetClock(c clock.Clock) {
+	a.clock = c
+}
+
+// Limit returns the current max number of ops per batch.
+func (a *AdaptiveLimiter) Limit() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.current
+}
+
+func (a *AdaptiveLimiter) decrease() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.current = a.current / 2
+	if a.current < a.min {
+		a.current = a.min
+	}
+}
+
+func (a *AdaptiveLimiter) increase() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.saturated && a.current < a.max {
+		a.current++
+	}
+}
+
+// CapBatch returns a BatchHandlerFunc that truncates the batch to the current limit like LimitBatch but without
+// affecting how the limit adjusts. It is used to preview the batches that LimitBatch will allow, e.g. for
+// pre-signed bundle templates.
+func (a *AdaptiveLimiter) CapBatch() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		if len(ctx.Batch) > a.current {
+			ctx.Batch = ctx.Batch[:a.current]
+		}
+		return nil
+	}
+}
+
+// LimitBatch returns a BatchHandlerFunc that truncates the batch to the current limit. It also marks the start
+// of the batch deadline and should run before any expensive handlers.
+func (a *AdaptiveLimiter) LimitBatch() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		ctx.Data[adaptiveStartedAtKey] = a.clock.Now()
+
+		a.mu.Lock()
+		a.saturated = len(ctx.Batch) >= a.current
+		if len(ctx.Batch) > a.current {
+			ctx.Data["delayed_by_adaptive_limit"] = len(ctx.Batch) - a.current
+			ctx.Batch = ctx.Batch[:a.current]
+		}
+		a.mu.Unlock()
+
+		return nil
+	}
+}
+
+// TrackSimulation returns a BatchHandlerFunc that runs the given simulation handler and adjusts the limit based
+// on how long it took.
+func (a *AdaptiveLimiter) TrackSimulation(h modules.BatchHandlerFunc) modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		start := a.clock.Now()
+		err := h(ctx)
+		elapsed := a.clock.Since(start)
+		ctx.Data["simulation_latency_ms"] = elapsed.Milliseconds()
+
+		if elapsed > a.targetLatency {
+			a.decrease()
+		} else if elapsed <= a.targetLatency/2 {
+			a.increase()
+		}
+		return err
+	}
+}
+
+// TrackDeadline returns a BatchHandlerFunc that runs the given send handler and shrinks the limit if the batch
+// took longer than the deadline to reach the builder, measured from LimitBatch.
+func (a *AdaptiveLimiter) TrackDeadline(h modules.BatchHandlerFunc) modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		err := h(ctx)
+
+		start, ok := ctx.Data[adaptiveStartedAtKey].(time.Time)
+		if ok && a.deadline > 0 && a.clock.Since(start) > a.deadline {
+			ctx.Data["missed_deadline"] = true
+			a.decrease()
+		}
+		return err
+	}
+}
@@ -0,0 +1,146 @@
+package batch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/clock"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func runAdaptive(
+	t *testing.T,
+	a *AdaptiveLimiter,
+	c *clock.Mock,
+	simLatency time.Duration,
+	sendLatency time.Duration,
+	batch ...*userop.UserOperation,
+) *modules.BatchHandlerCtx {
+	ctx := modules.NewBatchHandlerContext(batch, testutils.ValidAddress1, testutils.ChainID, nil, nil, nil)
+	sim := func(ctx *modules.BatchHandlerCtx) error {
+		c.Add(simLatency)
+		return nil
+	}
+	send := func(ctx *modules.BatchHandlerCtx) error {
+		c.Add(sendLatency)
+		return nil
+	}
+	if err := modules.ComposeBatchHandlerFunc(
+		a.LimitBatch(),
+		a.TrackSimulation(sim),
+		a.TrackDeadline(send),
+	)(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	return ctx
+}
+
+// TestAdaptiveLimiterShrinksOnSlowSimulation verifies that the limit is halved when simulation is slower than
+// the target and grows back once simulation is fast again.
+func TestAdaptiveLimiterShrinksOnSlowSimulation(t *testing.T) {
+	op1 := sequenceOp(testutils.ValidAddress2, 0, false)
+	op2 := sequenceOp(testutils.ValidAddress3, 0, false)
+	op3 := sequenceOp(testutils.ValidAddress4, 0, false)
+	op4 := sequenceOp(testutils.ValidAddress5, 0, false)
+	c := clock.NewMock(time.Unix(0, 0))
+	a := NewAdaptiveLimiter(1, 4, time.Second, time.Minute)
+	a.SetClock(c)
+
+	ctx := runAdaptive(t, a, c, 2*time.Second, 0, op1, op2, op3, op4)
+	assertBatch(t, ctx.Batch, op1, op2, op3, op4)
+	if a.Limit() != 2 {
+		t.Fatalf("got limit %d, want 2", a.Limit())
+	}
+
+	ctx = runAdaptive(t, a, c, 100*time.Millisecond, 0, op1, op2, op3, op4)
+	assertBatch(t, ctx.Batch, op1, op2)
+	if ctx.Data["delayed_by_adaptive_limit"] != 2 {
+		t.Fatalf("got %v delayed, want 2", ctx.Data["delayed_by_adaptive_limit"])
+	}
+	if a.Limit() != 3 {
+		t.Fatalf("got limit %d, want 3", a.Limit())
+	}
+}
+
+// TestAdaptiveLimiterDoesNotGrowWhenUnsaturated verifies that the limit only grows when batches are filled to
+// the current limit.
+func TestAdaptiveLimiterDoesNotGrowWhenUnsaturated(t *testing.T) {
+	op1 := sequenceOp(testutils.ValidAddress2, 0, false)
+	c := clock.NewMock(time.Unix(0, 0))
+	a := NewAdaptiveLimiter(1, 4, time.Second, time.Minute)
+	a.SetClock(c)
+
+	runAdaptive(t, a, c, 2*time.Second, 0, op1)
+	if a.Limit() != 2 {
+		t.Fatalf("got limit %d, want 2", a.Limit())
+	}
+
+	runAdaptive(t, a, c, 0, 0, op1)
+	if a.Limit() != 2 {
+		t.Fatalf("got limit %d, want 2", a.Limit())
+	}
+}
+
+// TestAdaptiveLimiterShrinksOnMissedDeadline verifies that the limit is halved when a batch takes longer than
+// the deadline to send, and never drops below the min.
+func TestAdaptiveLimiterShrinksOnMissedDeadline(t *testing.T) {
+	op1 := sequenceOp(testutils.ValidAddress2, 0, false)
+	op2 := sequenceOp(testutils.ValidAddress3, 0, false)
+	c := clock.NewMock(time.Unix(0, 0))
+	a := NewAdaptiveLimiter(1, 2, time.Second, 5*time.Second)
+	a.SetClock(c)
+
+	ctx := runAdaptive(t, a, c, 800*time.Millisecond, 5*time.Second, op1, op2)
+	if ctx.Data["missed_deadline"] != true {
+		t.Fatalf("got %v missed deadline, want true", ctx.Data["missed_deadline"])
+	}
+	if a.Limit() != 1 {
+		t.Fatalf("got limit %d, want 1", a.Limit())
+	}
+
+	runAdaptive(t, a, c, 2*time.Second, 5*time.Second, op1, op2)
+	if a.Limit() != 1 {
+		t.Fatalf("got limit %d, want 1", a.Limit())
+	}
+}
+
+// TestCapBatchDoesNotAdjustLimit verifies that CapBatch truncates the batch to the current limit without
+// counting it as a saturated batch that lets the limit grow.
+func TestCapBatchDoesNotAdjustLimit(t *testing.T) {
+	op1 := sequenceOp(testutils.ValidAddress2, 0, false)
+	op2 := sequenceOp(testutils.ValidAddress3, 0, false)
+	op3 := sequenceOp(testutils.ValidAddress4, 0, false)
+	c := clock.NewMock(time.Unix(0, 0))
+	a := NewAdaptiveLimiter(1, 4, time.Second, time.Minute)
+	a.SetClock(c)
+
+	runAdaptive(t, a, c, 2*time.Second, 0, op1)
+	if a.Limit() != 2 {
+		t.Fatalf("got limit %d, want 2", a.Limit())
+	}
+
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{op1, op2, op3},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+	if err := a.CapBatch()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	assertBatch(t, ctx.Batch, op1, op2)
+	if _, ok := ctx.Data["delayed_by_adaptive_limit"]; ok {
+		t.Fatalf("got %v delayed, want none", ctx.Data["delayed_by_adaptive_limit"])
+	}
+
+	if err := a.TrackSimulation(func(ctx *modules.BatchHandlerCtx) error { return nil })(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if a.Limit() != 2 {
+		t.Fatalf("got limit %d, want 2", a.Limit())
+	}
+}
@@ -0,0 +1,70 @@
+package batch
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// SnapshotHash returns a hash that uniquely identifies the set of ops in a batch regardless of the order they
+// were received in. It is computed as keccak256 over all userOpHashes sorted in ascending byte order.
+func SnapshotHash(batch []*userop.UserOperation, ep common.Address, chainID *big.Int) common.Hash {
+	hashes := []common.Hash{}
+	for _, op := range batch {
+		hashes = append(hashes, op.GetUserOpHash(ep, chainID))
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i].Bytes(), hashes[j].Bytes()) == -1
+	})
+
+	data := []byte{}
+	for _, h := range hashes {
+		data = append(data, h.Bytes()...)
+	}
+	return crypto.Keccak256Hash(data)
+}
+
+// SortDeterministic returns a BatchHandlerFunc that orders the batch so that the result depends only on the
+// set of ops in the batch and the given seed. Ops are sorted by:
+//
+//  1. Highest effective gas price first. This is the dynamic gas price if a basefee is set, otherwise
+//     maxFeePerGas.
+//  2. Ties are broken by ascending keccak256(seed ++ userOpHash).
+//
+// The snapshot hash of the batch and the seed are recorded in the context data so that the ordering of each
+// bundle can be independently verified. This should be used in place of gasprice.SortByGasPrice and before
+// SortByNonce.
+func SortDeterministic(seed []byte) modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		ctx.Data["snapshot_hash"] = SnapshotHash(ctx.Batch, ctx.EntryPoint, ctx.ChainID).String()
+		ctx.Data["ordering_seed"] = hexutil.Encode(seed)
+
+		price := func(op *userop.UserOperation) *big.Int {
+			if ctx.BaseFee != nil {
+				return op.GetDynamicGasPrice(ctx.BaseFee)
+			}
+			return op.MaxFeePerGas
+		}
+		tiebreak := make(map[*userop.UserOperation][]byte)
+		for _, op := range ctx.Batch {
+			h := op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)
+			tiebreak[op] = crypto.Keccak256(seed, h.Bytes())
+		}
+
+		sort.SliceStable(ctx.Batch, func(i, j int) bool {
+			a, b := ctx.Batch[i], ctx.Batch[j]
+			if c := price(a).Cmp(price(b)); c != 0 {
+				return c == 1
+			}
+			return bytes.Compare(tiebreak[a], tiebreak[b]) == -1
+		})
+
+		return nil
+	}
+}
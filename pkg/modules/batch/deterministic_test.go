@@ -0,0 +1,45 @@
+package batch
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func sortDeterministicWithOrder(t *testing.T, seed []byte, batch ...*userop.UserOperation) *modules.BatchHandlerCtx {
+	ctx := modules.NewBatchHandlerContext(batch, testutils.ValidAddress1, testutils.ChainID, nil, nil, big.NewInt(1))
+	if err := SortDeterministic(seed)(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	return ctx
+}
+
+// TestSortDeterministicIgnoresArrivalOrder verifies that the same set of ops results in the same ordering and
+// snapshot hash regardless of the order they were received in.
+func TestSortDeterministicIgnoresArrivalOrder(t *testing.T) {
+	op1 := testutils.MockValidInitUserOp()
+	op1.Nonce = big.NewInt(1)
+	op2 := testutils.MockValidInitUserOp()
+	op2.Nonce = big.NewInt(2)
+	op3 := testutils.MockValidInitUserOp()
+	op3.Nonce = big.NewInt(3)
+	op3.MaxFeePerGas = big.NewInt(0).Add(op3.MaxFeePerGas, big.NewInt(1))
+	seed := []byte("seed")
+
+	ctx1 := sortDeterministicWithOrder(t, seed, op1, op2, op3)
+	ctx2 := sortDeterministicWithOrder(t, seed, op3, op2, op1)
+	if ctx1.Data["snapshot_hash"] != ctx2.Data["snapshot_hash"] {
+		t.Fatalf("got snapshot hashes %s and %s, want equal", ctx1.Data["snapshot_hash"], ctx2.Data["snapshot_hash"])
+	}
+	if ctx1.Batch[0] != op3 {
+		t.Fatalf("got nonce %s first, want highest gas price op first", ctx1.Batch[0].Nonce)
+	}
+	for i := range ctx1.Batch {
+		if ctx1.Batch[i] != ctx2.Batch[i] {
+			t.Fatalf("batch index %d: got nonce %s, want %s", i, ctx2.Batch[i].Nonce, ctx1.Batch[i].Nonce)
+		}
+	}
+}
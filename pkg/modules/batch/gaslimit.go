@@ -1,13 +1,42 @@
 package batch
 
 import (
+	"context"
 	"math/big"
+	"sync"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 )
 
+// splitByGasLimit returns the longest prefix of the batch with a max gas below the allowed threshold and the
+// remaining ops that were cut.
+func splitByGasLimit(
+	staticOv *gas.Overhead,
+	maxBatchGasLimit *big.Int,
+	batch []*userop.UserOperation,
+) ([]*userop.UserOperation, []*userop.UserOperation, error) {
+	sum := big.NewInt(0)
+	for i, op := range batch {
+		static, err := staticOv.CalcPreVerificationGas(op)
+		if err != nil {
+			return nil, nil, err
+		}
+		mgl := big.NewInt(0).Sub(op.GetMaxGasAvailable(), op.PreVerificationGas)
+		mga := big.NewInt(0).Add(mgl, static)
+
+		sum = big.NewInt(0).Add(sum, mga)
+		if sum.Cmp(maxBatchGasLimit) >= 0 {
+			return batch[:i], batch[i:], nil
+		}
+	}
+	return batch, []*userop.UserOperation{}, nil
+}
+
 // MaintainGasLimit returns a BatchHandlerFunc that ensures the max gas used from the entire batch does not
 // exceed the allowed threshold.
 func MaintainGasLimit(maxBatchGasLimit *big.Int) modules.BatchHandlerFunc {
@@ -15,24 +44,149 @@ func MaintainGasLimit(maxBatchGasLimit *big.Int) modules.BatchHandlerFunc {
 	staticOv := gas.NewDefaultOverhead()
 
 	return func(ctx *modules.BatchHandlerCtx) error {
-		bat := []*userop.UserOperation{}
-		sum := big.NewInt(0)
+		bat, _, err := splitByGasLimit(staticOv, maxBatchGasLimit, ctx.Batch)
+		if err != nil {
+			return err
+		}
+		ctx.Batch = bat
+
+		return nil
+	}
+}
+
+// GasLimiter maintains the batch gas limit like MaintainGasLimit but also remembers the ops that were cut so
+// that they can lead the next batch. This prevents large or low priced ops from being starved when the
+// mempool is under gas limit pressure.
+type GasLimiter struct {
+	maxBatchGasLimit *big.Int
+	getBlockGasLimit func() (uint64, error)
+	staticOv         *gas.Overhead
+	mu               sync.Mutex
+	delayed          map[common.Address]map[common.Hash]bool
+	delayedCounter   metric.Int64Counter
+}
+
+// NewGasLimiter returns a GasLimiter for the given max batch gas limit.
+func NewGasLimiter(maxBatchGasLimit *big.Int) *GasLimiter {
+	return &GasLimiter{
+		maxBatchGasLimit: maxBatchGasLimit,
+		// See comment in pkg/modules/checks/gas.go
+		staticOv: gas.NewDefaultOverhead(),
+		delayed:  make(map[common.Address]map[common.Hash]bool),
+	}
+}
+
+// UseMeter defines an opentelemetry meter object used by the GasLimiter to count ops delayed due to the gas
+// limit.
+func (g *GasLimiter) UseMeter(meter metric.Meter) error {
+	c, err := meter.Int64Counter("bundler_ops_delayed_gas_limit")
+	if err != nil {
+		return err
+	}
+
+	g.delayedCounter = c
+	return nil
+}
+
+// SetGetBlockGasLimitFunc sets a function that returns the gas limit of the latest block. If set, batches
+// are also capped at the block gas limit when it is lower than the max batch gas limit.
+func (g *GasLimiter) SetGetBlockGasLimitFunc(fn func() (uint64, error)) {
+	g.getBlockGasLimit = fn
+}
+
+// limit returns the lower of the max batch gas limit and the latest block gas limit.
+func (g *GasLimiter) limit() (*big.Int, error) {
+	if g.getBlockGasLimit == nil {
+		return g.maxBatchGasLimit, nil
+	}
+	blk, err := g.getBlockGasLimit()
+	if err != nil {
+		return nil, err
+	}
+	if lim := big.NewInt(0).SetUint64(blk); lim.Cmp(g.maxBatchGasLimit) < 0 {
+		return lim, nil
+	}
+	return g.maxBatchGasLimit, nil
+}
+
+func (g *GasLimiter) isDelayed(ep common.Address, hash common.Hash) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.delayed[ep][hash]
+}
+
+// PrioritizeDelayed returns a BatchHandlerFunc that moves ops cut from the previous batch due to the gas limit
+// to the front of the batch. The relative order of ops is otherwise unchanged. This should run after any
+// sorting by gas price and before the gas limit is applied.
+func (g *GasLimiter) PrioritizeDelayed() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		first := []*userop.UserOperation{}
+		rest := []*userop.UserOperation{}
 		for _, op := range ctx.Batch {
-			static, err := staticOv.CalcPreVerificationGas(op)
-			if err != nil {
-				return err
+			if g.isDelayed(ctx.EntryPoint, op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)) {
+				first = append(first, op)
+			} else {
+				rest = append(rest, op)
 			}
-			mgl := big.NewInt(0).Sub(op.GetMaxGasAvailable(), op.PreVerificationGas)
-			mga := big.NewInt(0).Add(mgl, static)
+		}
+		ctx.Batch = append(first, rest...)
 
-			sum = big.NewInt(0).Add(sum, mga)
-			if sum.Cmp(maxBatchGasLimit) >= 0 {
-				break
-			}
-			bat = append(bat, op)
+		return nil
+	}
+}
+
+// CapGasLimit returns a BatchHandlerFunc that applies the same gas limit as MaintainGasLimit but does not
+// remember the ops that are cut. It is used to preview the batches that MaintainGasLimit will allow, e.g. for
+// pre-signed bundle templates.
+func (g *GasLimiter) CapGasLimit() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		lim, err := g.limit()
+		if err != nil {
+			return err
+		}
+		bat, _, err := splitByGasLimit(g.staticOv, lim, ctx.Batch)
+		if err != nil {
+			return err
 		}
 		ctx.Batch = bat
 
 		return nil
 	}
 }
+
+// MaintainGasLimit returns a BatchHandlerFunc that ensures the max gas used from the entire batch does not
+// exceed the allowed threshold. Ops that are cut are remembered as delayed until the next batch.
+func (g *GasLimiter) MaintainGasLimit() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		lim, err := g.limit()
+		if err != nil {
+			return err
+		}
+		bat, cut, err := splitByGasLimit(g.staticOv, lim, ctx.Batch)
+		if err != nil {
+			return err
+		}
+		ctx.Batch = bat
+
+		delayed := make(map[common.Hash]bool)
+		for _, op := range cut {
+			delayed[op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)] = true
+		}
+		g.mu.Lock()
+		g.delayed[ctx.EntryPoint] = delayed
+		g.mu.Unlock()
+
+		if len(cut) > 0 {
+			ctx.Data["delayed_by_gas_limit"] = len(cut)
+			if g.delayedCounter != nil {
+				g.delayedCounter.Add(
+					context.Background(),
+					int64(len(cut)),
+					metric.WithAttributes(attribute.String("entrypoint", ctx.EntryPoint.String())),
+				)
+			}
+		}
+		return nil
+	}
+}
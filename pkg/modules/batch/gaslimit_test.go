@@ -0,0 +1,127 @@
+package batch
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func opGas(t *testing.T, op *userop.UserOperation) *big.Int {
+	static, err := gas.NewDefaultOverhead().CalcPreVerificationGas(op)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgl := big.NewInt(0).Sub(op.GetMaxGasAvailable(), op.PreVerificationGas)
+	return big.NewInt(0).Add(mgl, static)
+}
+
+// TestGasLimiterPrioritizesDelayedOps verifies that ops cut due to the gas limit lead the next batch.
+func TestGasLimiterPrioritizesDelayedOps(t *testing.T) {
+	op1 := sequenceOp(testutils.ValidAddress2, 0, false)
+	op2 := sequenceOp(testutils.ValidAddress3, 0, false)
+	op3 := sequenceOp(testutils.ValidAddress4, 0, false)
+	max := big.NewInt(0).Add(opGas(t, op1), opGas(t, op2))
+	if alt := big.NewInt(0).Add(opGas(t, op3), opGas(t, op1)); alt.Cmp(max) > 0 {
+		max = alt
+	}
+	g := NewGasLimiter(big.NewInt(0).Add(max, big.NewInt(1)))
+	run := func() *modules.BatchHandlerCtx {
+		ctx := modules.NewBatchHandlerContext(
+			[]*userop.UserOperation{op1, op2, op3},
+			testutils.ValidAddress1,
+			testutils.ChainID,
+			nil,
+			nil,
+			nil,
+		)
+		if err := modules.ComposeBatchHandlerFunc(g.PrioritizeDelayed(), g.MaintainGasLimit())(ctx); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		return ctx
+	}
+
+	ctx := run()
+	assertBatch(t, ctx.Batch, op1, op2)
+	if ctx.Data["delayed_by_gas_limit"] != 1 {
+		t.Fatalf("got %v delayed, want 1", ctx.Data["delayed_by_gas_limit"])
+	}
+
+	ctx = run()
+	assertBatch(t, ctx.Batch, op3, op1)
+}
+
+// TestMaintainGasLimitIsStateless verifies that MaintainGasLimit does not reorder ops between runs.
+func TestMaintainGasLimitIsStateless(t *testing.T) {
+	op1 := sequenceOp(testutils.ValidAddress2, 0, false)
+	op2 := sequenceOp(testutils.ValidAddress3, 0, false)
+	max := big.NewInt(0).Add(opGas(t, op1), big.NewInt(1))
+
+	for i := 0; i < 2; i++ {
+		ctx := modules.NewBatchHandlerContext(
+			[]*userop.UserOperation{op1, op2},
+			testutils.ValidAddress1,
+			testutils.ChainID,
+			nil,
+			nil,
+			nil,
+		)
+		if err := MaintainGasLimit(max)(ctx); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		assertBatch(t, ctx.Batch, op1)
+	}
+}
+
+// TestGasLimiterCapsAtBlockGasLimit verifies that the block gas limit is used when it is lower than the max
+// batch gas limit.
+func TestGasLimiterCapsAtBlockGasLimit(t *testing.T) {
+	op1 := sequenceOp(testutils.ValidAddress2, 0, false)
+	op2 := sequenceOp(testutils.ValidAddress3, 0, false)
+	g := NewGasLimiter(big.NewInt(0).Mul(opGas(t, op1), big.NewInt(10)))
+	g.SetGetBlockGasLimitFunc(func() (uint64, error) {
+		return opGas(t, op1).Uint64() + 1, nil
+	})
+
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{op1, op2},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+	if err := g.MaintainGasLimit()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	assertBatch(t, ctx.Batch, op1)
+}
+
+// TestCapGasLimitDoesNotDelayOps verifies that CapGasLimit cuts the batch like MaintainGasLimit without
+// marking the cut ops as delayed for the next batch.
+func TestCapGasLimitDoesNotDelayOps(t *testing.T) {
+	op1 := sequenceOp(testutils.ValidAddress2, 0, false)
+	op2 := sequenceOp(testutils.ValidAddress3, 0, false)
+	g := NewGasLimiter(big.NewInt(0).Add(opGas(t, op1), big.NewInt(1)))
+
+	for i := 0; i < 2; i++ {
+		ctx := modules.NewBatchHandlerContext(
+			[]*userop.UserOperation{op1, op2},
+			testutils.ValidAddress1,
+			testutils.ChainID,
+			nil,
+			nil,
+			nil,
+		)
+		if err := modules.ComposeBatchHandlerFunc(g.PrioritizeDelayed(), g.CapGasLimit())(ctx); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		assertBatch(t, ctx.Batch, op1)
+		if _, ok := ctx.Data["delayed_by_gas_limit"]; ok {
+			t.Fatalf("got %v delayed, want none", ctx.Data["delayed_by_gas_limit"])
+		}
+	}
+}
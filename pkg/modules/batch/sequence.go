@@ -0,0 +1,77 @@
+package batch
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// isRiskyInSequence returns true if op cannot be safely included after prev in a sequence from the same
+// sender and nonce key. This is the case if there is a gap in the nonce sequence or if op attempts to deploy
+// an account that would already be deployed by an earlier op.
+func isRiskyInSequence(prev *userop.UserOperation, op *userop.UserOperation) bool {
+	next := big.NewInt(0).Add(prev.GetNonceSequence(), big.NewInt(1))
+	return op.GetNonceSequence().Cmp(next) != 0 || len(op.InitCode) != 0
+}
+
+// SortBySenderSequence returns a BatchHandlerFunc that places ops with the same sender and nonce key next to
+// each other at the position of the group's first op, ordered by ascending nonce sequence. Since a failure of
+// one op in the sequence would invalidate all the ones after it, each sequence is truncated at the first risky
+// op. An op is risky if it follows a nonce gap, redeploys the account, or an earlier op in its sequence has
+// already been marked for removal in the current run.
+//
+// Truncated ops are not marked for removal and will remain in the mempool to be included in a later batch.
+// This module can be used multiple times to repair sequences after other modules have dropped ops.
+func SortBySenderSequence() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		removed := make(map[nonceGroup]*big.Int)
+		for _, item := range ctx.PendingRemoval {
+			g := nonceGroup{sender: item.Op.Sender, key: item.Op.GetNonceKey().String()}
+			seq := item.Op.GetNonceSequence()
+			if min, ok := removed[g]; !ok || seq.Cmp(min) < 0 {
+				removed[g] = seq
+			}
+		}
+
+		seqs := make(map[nonceGroup][]*userop.UserOperation)
+		groups := []nonceGroup{}
+		for _, op := range ctx.Batch {
+			g := nonceGroup{sender: op.Sender, key: op.GetNonceKey().String()}
+			if _, ok := seqs[g]; !ok {
+				groups = append(groups, g)
+			}
+			seqs[g] = append(seqs[g], op)
+		}
+
+		batch := []*userop.UserOperation{}
+		truncated := []string{}
+		for _, g := range groups {
+			ops := seqs[g]
+			sort.SliceStable(ops, func(i, j int) bool {
+				return ops[i].GetNonceSequence().Cmp(ops[j].GetNonceSequence()) == -1
+			})
+
+			for i, op := range ops {
+				min, ok := removed[g]
+				if (ok && op.GetNonceSequence().Cmp(min) > 0) || (i > 0 && isRiskyInSequence(ops[i-1], op)) {
+					for _, t := range ops[i:] {
+						truncated = append(truncated, t.GetUserOpHash(ctx.EntryPoint, ctx.ChainID).String())
+					}
+					break
+				}
+				batch = append(batch, op)
+			}
+		}
+
+		ctx.Batch = batch
+		if len(truncated) > 0 {
+			if prev, ok := ctx.Data["truncated_sequence_userop_hashes"].([]string); ok {
+				truncated = append(prev, truncated...)
+			}
+			ctx.Data["truncated_sequence_userop_hashes"] = truncated
+		}
+		return nil
+	}
+}
@@ -0,0 +1,97 @@
+package batch
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func sequenceOp(sender common.Address, seq int64, deploy bool) *userop.UserOperation {
+	op := testutils.MockValidInitUserOp()
+	op.Sender = sender
+	op.Nonce = nonceWithKey(0, seq)
+	if !deploy {
+		op.InitCode = []byte{}
+	}
+	return op
+}
+
+func sortBySenderSequence(t *testing.T, batch ...*userop.UserOperation) *modules.BatchHandlerCtx {
+	ctx := modules.NewBatchHandlerContext(batch, testutils.ValidAddress1, testutils.ChainID, nil, nil, nil)
+	if err := SortBySenderSequence()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	return ctx
+}
+
+func assertBatch(t *testing.T, got []*userop.UserOperation, want ...*userop.UserOperation) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got length %d, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("index %d: got sender %s nonce %s, want sender %s nonce %s",
+				i, got[i].Sender, got[i].Nonce, want[i].Sender, want[i].Nonce)
+		}
+	}
+}
+
+// TestSortBySenderSequencePlacesSequencesTogether verifies that ops from the same sender are placed next to each
+// other in nonce order at the position of the sender's first op.
+func TestSortBySenderSequencePlacesSequencesTogether(t *testing.T) {
+	a1 := sequenceOp(testutils.ValidAddress2, 1, false)
+	a2 := sequenceOp(testutils.ValidAddress2, 2, false)
+	b0 := sequenceOp(testutils.ValidAddress3, 0, true)
+
+	ctx := sortBySenderSequence(t, a2, b0, a1)
+	assertBatch(t, ctx.Batch, a1, a2, b0)
+}
+
+// TestSortBySenderSequenceTruncatesAtGap verifies that a sequence is truncated at the first nonce gap.
+func TestSortBySenderSequenceTruncatesAtGap(t *testing.T) {
+	a1 := sequenceOp(testutils.ValidAddress2, 1, false)
+	a3 := sequenceOp(testutils.ValidAddress2, 3, false)
+	a4 := sequenceOp(testutils.ValidAddress2, 4, false)
+
+	ctx := sortBySenderSequence(t, a1, a3, a4)
+	assertBatch(t, ctx.Batch, a1)
+	if len(ctx.PendingRemoval) != 0 {
+		t.Fatalf("got %d pending removals, want 0", len(ctx.PendingRemoval))
+	}
+}
+
+// TestSortBySenderSequenceTruncatesAtRedeploy verifies that a sequence is truncated at an op that attempts to
+// deploy an account that would already be deployed.
+func TestSortBySenderSequenceTruncatesAtRedeploy(t *testing.T) {
+	a0 := sequenceOp(testutils.ValidAddress2, 0, true)
+	a1 := sequenceOp(testutils.ValidAddress2, 1, true)
+
+	ctx := sortBySenderSequence(t, a0, a1)
+	assertBatch(t, ctx.Batch, a0)
+}
+
+// TestSortBySenderSequenceAfterRemoval verifies that ops are dropped from the batch if an earlier op in the same
+// sequence has been marked for removal.
+func TestSortBySenderSequenceAfterRemoval(t *testing.T) {
+	a1 := sequenceOp(testutils.ValidAddress2, 1, false)
+	a2 := sequenceOp(testutils.ValidAddress2, 2, false)
+	b1 := sequenceOp(testutils.ValidAddress3, 1, false)
+
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{a1, a2, b1},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+	ctx.MarkOpIndexForRemoval(0, "test")
+	if err := SortBySenderSequence()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	assertBatch(t, ctx.Batch, b1)
+}
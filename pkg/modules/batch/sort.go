@@ -3,17 +3,44 @@ package batch
 import (
 	"sort"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
-// SortByNonce returns a BatchHandlerFunc that ensures ops with same sender is ordered by ascending nonce
-// regardless of gas price.
+type nonceGroup struct {
+	sender common.Address
+	key    string
+}
+
+// SortByNonce returns a BatchHandlerFunc that ensures ops with same sender and nonce key is ordered by
+// ascending nonce sequence regardless of gas price. Each nonce key is treated as an independent sequence and
+// ops keep the same batch positions held by their group prior to sorting.
 func SortByNonce() modules.BatchHandlerFunc {
 	return func(ctx *modules.BatchHandlerCtx) error {
-		sort.SliceStable(ctx.Batch, func(i, j int) bool {
-			return ctx.Batch[i].Sender == ctx.Batch[j].Sender &&
-				ctx.Batch[i].Nonce.Cmp(ctx.Batch[j].Nonce) == -1
-		})
+		idx := make(map[nonceGroup][]int)
+		groups := []nonceGroup{}
+		for i, op := range ctx.Batch {
+			g := nonceGroup{sender: op.Sender, key: op.GetNonceKey().String()}
+			if _, ok := idx[g]; !ok {
+				groups = append(groups, g)
+			}
+			idx[g] = append(idx[g], i)
+		}
+
+		for _, g := range groups {
+			pos := idx[g]
+			ops := []*userop.UserOperation{}
+			for _, i := range pos {
+				ops = append(ops, ctx.Batch[i])
+			}
+			sort.SliceStable(ops, func(i, j int) bool {
+				return ops[i].GetNonceSequence().Cmp(ops[j].GetNonceSequence()) == -1
+			})
+			for n, i := range pos {
+				ctx.Batch[i] = ops[n]
+			}
+		}
 
 		return nil
 	}
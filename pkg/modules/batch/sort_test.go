@@ -0,0 +1,49 @@
+package batch
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func nonceWithKey(key int64, seq int64) *big.Int {
+	return big.NewInt(0).Add(big.NewInt(0).Lsh(big.NewInt(key), 64), big.NewInt(seq))
+}
+
+// TestSortByNonceWithParallelKeys verifies that SortByNonce orders ops by sequence within each nonce key
+// without reordering ops across different keys.
+func TestSortByNonceWithParallelKeys(t *testing.T) {
+	op1 := testutils.MockValidInitUserOp()
+	op1.Nonce = nonceWithKey(1, 1)
+	op2 := testutils.MockValidInitUserOp()
+	op2.Nonce = nonceWithKey(0, 1)
+	op3 := testutils.MockValidInitUserOp()
+	op3.Nonce = nonceWithKey(1, 0)
+	op4 := testutils.MockValidInitUserOp()
+	op4.Nonce = nonceWithKey(0, 0)
+
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{op1, op2, op3, op4},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+	if err := SortByNonce()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	seen := map[string]int64{}
+	for _, op := range ctx.Batch {
+		key := op.GetNonceKey().String()
+		seq := op.GetNonceSequence().Int64()
+		if last, ok := seen[key]; ok && seq < last {
+			t.Fatalf("key %s: got sequence %d after %d, want ascending", key, seq, last)
+		}
+		seen[key] = seq
+	}
+}
@@ -0,0 +1,177 @@
+package builder
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/metachris/flashbotsrpc"
+)
+
+const (
+	// FormatFlashbots sends eth_sendBundle with an X-Flashbots-Signature header signed by the bundler EOA.
+	FormatFlashbots = "flashbots"
+
+	// FormatBloxroute sends blxr_submit_bundle with the auth value in the Authorization header.
+	FormatBloxroute = "bloxroute"
+
+	// FormatTitan sends eth_sendBundle without a signature header.
+	FormatTitan = "titan"
+
+	// FormatRawTx sends each transaction with eth_sendRawTransaction for chain-native endpoints without a
+	// bundle API. Transactions in the bundle are not guaranteed to be included atomically.
+	FormatRawTx = "rawtx"
+)
+
+// Bundle is a list of signed raw transactions targeting a block number. RevertingTxHashes are transactions
+// in the bundle that are allowed to revert without invalidating the whole bundle.
+type Bundle struct {
+	Txs               []string
+	BlockNumber       *big.Int
+	RevertingTxHashes []string
+}
+
+// Adapter submits a Bundle to a single builder endpoint in the format it expects.
+type Adapter interface {
+	// Name returns an identifier for the endpoint that is safe to log.
+	Name() string
+
+	// SendBundle submits the bundle. The key is the bundler EOA and can be used for signing the request.
+	SendBundle(key *ecdsa.PrivateKey, bundle *Bundle) error
+}
+
+// Endpoint is the configuration for a single builder endpoint.
+type Endpoint struct {
+	Url    string
+	Format string
+	Auth   string
+}
+
+// NewAdapter returns an Adapter for the endpoint based on its format. If auth is set, it is sent in the
+// Authorization header of each request.
+func NewAdapter(e *Endpoint) (Adapter, error) {
+	rpc := flashbotsrpc.NewFlashbotsRPC(e.Url)
+	if e.Auth != "" {
+		rpc.Headers["Authorization"] = e.Auth
+	}
+	base := &baseAdapter{name: endpointName(e.Url), rpc: rpc}
+
+	switch e.Format {
+	case FormatFlashbots, "":
+		return &flashbotsAdapter{base}, nil
+	case FormatBloxroute:
+		return &bloxrouteAdapter{base}, nil
+	case FormatTitan:
+		return &titanAdapter{base}, nil
+	case FormatRawTx:
+		return &rawTxAdapter{base}, nil
+	default:
+		return nil, fmt.Errorf("builder: unknown format %s", e.Format)
+	}
+}
+
+// NewAdapters returns an Adapter for each endpoint.
+func NewAdapters(endpoints []*Endpoint) ([]Adapter, error) {
+	adapters := []Adapter{}
+	for _, e := range endpoints {
+		a, err := NewAdapter(e)
+		if err != nil {
+			return nil, err
+		}
+		adapters = append(adapters, a)
+	}
+	return adapters, nil
+}
+
+// endpointName returns the host of a URL so that paths or credentials are not logged.
+func endpointName(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Host == "" {
+		return "builder"
+	}
+	return u.Host
+}
+
+type baseAdapter struct {
+	name string
+	rpc  *flashbotsrpc.FlashbotsRPC
+}
+
+func (a *baseAdapter) Name() string {
+	return a.name
+}
+
+func (a *baseAdapter) wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", a.name, err)
+}
+
+func sendBundleRequest(bundle *Bundle) flashbotsrpc.FlashbotsSendBundleRequest {
+	req := flashbotsrpc.FlashbotsSendBundleRequest{
+		Txs:         bundle.Txs,
+		BlockNumber: hexutil.EncodeBig(bundle.BlockNumber),
+	}
+	if len(bundle.RevertingTxHashes) > 0 {
+		req.RevertingTxs = &bundle.RevertingTxHashes
+	}
+	return req
+}
+
+type flashbotsAdapter struct {
+	*baseAdapter
+}
+
+func (a *flashbotsAdapter) SendBundle(key *ecdsa.PrivateKey, bundle *Bundle) error {
+	_, err := a.rpc.CallWithFlashbotsSignature("eth_sendBundle", key, sendBundleRequest(bundle))
+	return a.wrap(err)
+}
+
+type titanAdapter struct {
+	*baseAdapter
+}
+
+func (a *titanAdapter) SendBundle(key *ecdsa.PrivateKey, bundle *Bundle) error {
+	_, err := a.rpc.Call("eth_sendBundle", sendBundleRequest(bundle))
+	return a.wrap(err)
+}
+
+// bloxrouteBundleRequest is the params object for blxr_submit_bundle. Transactions are hex encoded without a
+// 0x prefix.
+type bloxrouteBundleRequest struct {
+	Transaction []string `json:"transaction"`
+	BlockNumber string   `json:"block_number"`
+}
+
+type bloxrouteAdapter struct {
+	*baseAdapter
+}
+
+func (a *bloxrouteAdapter) SendBundle(key *ecdsa.PrivateKey, bundle *Bundle) error {
+	txs := make([]string, len(bundle.Txs))
+	for i, tx := range bundle.Txs {
+		txs[i] = strings.TrimPrefix(tx, "0x")
+	}
+	_, err := a.rpc.Call("blxr_submit_bundle", bloxrouteBundleRequest{
+		Transaction: txs,
+		BlockNumber: hexutil.EncodeBig(bundle.BlockNumber),
+	})
+	return a.wrap(err)
+}
+
+type rawTxAdapter struct {
+	*baseAdapter
+}
+
+func (a *rawTxAdapter) SendBundle(key *ecdsa.PrivateKey, bundle *Bundle) error {
+	for _, tx := range bundle.Txs {
+		if _, err := a.rpc.Call("eth_sendRawTransaction", tx); err != nil {
+			return a.wrap(err)
+		}
+	}
+	return nil
+}
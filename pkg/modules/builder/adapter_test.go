@@ -0,0 +1,117 @@
+package builder
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+)
+
+type capturedRequest struct {
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	header http.Header
+}
+
+func builderMock(t *testing.T) (*httptest.Server, *[]*capturedRequest) {
+	reqs := []*capturedRequest{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req capturedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		req.header = r.Header
+		reqs = append(reqs, &req)
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &reqs
+}
+
+func sendTestBundle(t *testing.T, e *Endpoint) {
+	a, err := NewAdapter(e)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := a.SendBundle(
+		testutils.DummyEOA.PrivateKey,
+		&Bundle{Txs: []string{"0x01", "0x02"}, BlockNumber: big.NewInt(16)},
+	); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
+
+// TestFlashbotsAdapter verifies that the Flashbots format sends a signed eth_sendBundle.
+func TestFlashbotsAdapter(t *testing.T) {
+	srv, reqs := builderMock(t)
+	sendTestBundle(t, &Endpoint{Url: srv.URL, Format: FormatFlashbots})
+
+	req := (*reqs)[0]
+	if req.Method != "eth_sendBundle" {
+		t.Fatalf("got method %s, want eth_sendBundle", req.Method)
+	}
+	if req.header.Get("X-Flashbots-Signature") == "" {
+		t.Fatal("got no signature header, want signature")
+	}
+	if string(req.Params[0]) != `{"txs":["0x01","0x02"],"blockNumber":"0x10"}` {
+		t.Fatalf("got params %s, want txs and blockNumber", req.Params[0])
+	}
+}
+
+// TestBloxrouteAdapter verifies that the bloXroute format sends blxr_submit_bundle with an auth header and
+// unprefixed transactions.
+func TestBloxrouteAdapter(t *testing.T) {
+	srv, reqs := builderMock(t)
+	sendTestBundle(t, &Endpoint{Url: srv.URL, Format: FormatBloxroute, Auth: "secret"})
+
+	req := (*reqs)[0]
+	if req.Method != "blxr_submit_bundle" {
+		t.Fatalf("got method %s, want blxr_submit_bundle", req.Method)
+	}
+	if req.header.Get("Authorization") != "secret" {
+		t.Fatalf("got auth %s, want secret", req.header.Get("Authorization"))
+	}
+	if string(req.Params[0]) != `{"transaction":["01","02"],"block_number":"0x10"}` {
+		t.Fatalf("got params %s, want transaction and block_number", req.Params[0])
+	}
+}
+
+// TestTitanAdapter verifies that the Titan format sends eth_sendBundle without a signature.
+func TestTitanAdapter(t *testing.T) {
+	srv, reqs := builderMock(t)
+	sendTestBundle(t, &Endpoint{Url: srv.URL, Format: FormatTitan})
+
+	req := (*reqs)[0]
+	if req.Method != "eth_sendBundle" {
+		t.Fatalf("got method %s, want eth_sendBundle", req.Method)
+	}
+	if req.header.Get("X-Flashbots-Signature") != "" {
+		t.Fatal("got signature header, want none")
+	}
+}
+
+// TestRawTxAdapter verifies that the raw transaction format sends each transaction in order.
+func TestRawTxAdapter(t *testing.T) {
+	srv, reqs := builderMock(t)
+	sendTestBundle(t, &Endpoint{Url: srv.URL, Format: FormatRawTx})
+
+	if len(*reqs) != 2 {
+		t.Fatalf("got %d requests, want 2", len(*reqs))
+	}
+	for i, want := range []string{`"0x01"`, `"0x02"`} {
+		req := (*reqs)[i]
+		if req.Method != "eth_sendRawTransaction" || string(req.Params[0]) != want {
+			t.Fatalf("got %s %s, want eth_sendRawTransaction %s", req.Method, req.Params[0], want)
+		}
+	}
+}
+
+// TestUnknownAdapterFormat verifies that an unknown format returns an error.
+func TestUnknownAdapterFormat(t *testing.T) {
+	if _, err := NewAdapter(&Endpoint{Url: "http://localhost", Format: "unknown"}); err == nil {
+		t.Fatal("got nil, want error")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -16,6 +17,7 @@ import (
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+	"go.opentelemetry.io/otel/metric"
 )
 
 // BuilderClient provides a connection to a block builder API to enable UserOperations to be sent through the
@@ -24,9 +26,18 @@ type BuilderClient struct {
 	eoa               *signer.EOA
 	eth               *ethclient.Client
 	rpc               *flashbotsrpc.BuilderBroadcastRPC
+	adapters          []Adapter
 	beneficiary       common.Address
 	blocksInTheFuture int
 	waitTimeout       time.Duration
+	payoutCallData    []byte
+	payoutGasLimit    uint64
+	templates         *templateCache
+	getHead           GetHeadFunc
+	sim               *flashbotsrpc.FlashbotsRPC
+	simGasPrice       metric.Int64Histogram
+	errCounter        metric.Int64Counter
+	approve           transaction.ApproveFunc
 }
 
 // New returns an instance of a BuilderClient with modules to send UserOperation bundles via the mev-boost
@@ -45,6 +56,7 @@ func New(
 		beneficiary:       beneficiary,
 		blocksInTheFuture: blocksInTheFuture,
 		waitTimeout:       DefaultWaitTimeout,
+		payoutGasLimit:    DefaultPayoutGasLimit,
 	}
 }
 
@@ -57,82 +69,235 @@ func (b *BuilderClient) SetWaitTimeout(timeout time.Duration) {
 	b.waitTimeout = timeout
 }
 
+// SetPayoutCallData enables support for a beneficiary that is a payout contract. If set, a second transaction
+// calling the beneficiary with the given calldata will be appended directly after handleOps in each bundle
+// (e.g. to split revenue on-chain). Both transactions are included atomically by the block builder.
+//
+// The default value is nil. Setting the value to nil or an empty byte array will disable the payout
+// transaction.
+func (b *BuilderClient) SetPayoutCallData(data []byte) {
+	b.payoutCallData = data
+}
+
+// SetPayoutGasLimit sets the gas limit of the payout transaction. The payout is signed before the handleOps
+// transaction is included, so the limit must be high enough for the beneficiary contract to pay out the
+// revenue from the bundle.
+//
+// The default value is 100000.
+func (b *BuilderClient) SetPayoutGasLimit(gasLimit uint64) {
+	b.payoutGasLimit = gasLimit
+}
+
+// SetApproveFunc sets a hook that is called with a preview of each handleOps transaction before it is signed.
+// The hook can veto signing by returning an error or delay it by blocking.
+//
+// The default value is nil. Setting the value to nil will sign transactions without approval.
+func (b *BuilderClient) SetApproveFunc(fn transaction.ApproveFunc) {
+	b.approve = fn
+}
+
+// SetAdapters sets the builder endpoints to send bundles to, each with its own submission format. If set, the
+// adapters are used instead of broadcasting with the flashbotsrpc client given to New.
+//
+// The default value is nil.
+func (b *BuilderClient) SetAdapters(adapters []Adapter) {
+	b.adapters = adapters
+}
+
+// Response is the result of a single bundle submission to a builder for a target block.
+type Response struct {
+	Builder     string       `json:"builder"`
+	BlockNumber *hexutil.Big `json:"blockNumber"`
+	Error       string       `json:"error,omitempty"`
+}
+
+func newResponse(builder string, bundle *Bundle, err error) *Response {
+	r := &Response{Builder: builder, BlockNumber: (*hexutil.Big)(bundle.BlockNumber)}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// broadcast sends the bundle to all builders and returns an error for each failed submission along with the
+// response of every builder. The bool is true if at least one submission was successful.
+func (b *BuilderClient) broadcast(bundle *Bundle) ([]*Response, bool, error) {
+	ok := false
+	var errs error
+	res := []*Response{}
+	if len(b.adapters) == 0 {
+		results := b.rpc.BroadcastBundle(b.eoa.PrivateKey, sendBundleRequest(bundle))
+		for i, result := range results {
+			res = append(res, newResponse(fmt.Sprintf("builder-%d", i), bundle, result.Err))
+			if result.Err != nil {
+				errs = errors.Join(errs, result.Err)
+			} else {
+				ok = true
+			}
+		}
+		return res, ok, errs
+	}
+
+	results := make([]error, len(b.adapters))
+	var wg sync.WaitGroup
+	for i, a := range b.adapters {
+		wg.Add(1)
+		go func(i int, a Adapter) {
+			defer wg.Done()
+			results[i] = a.SendBundle(b.eoa.PrivateKey, bundle)
+		}(i, a)
+	}
+	wg.Wait()
+	for i, err := range results {
+		res = append(res, newResponse(b.adapters[i].Name(), bundle, err))
+		if err != nil {
+			errs = errors.Join(errs, err)
+		} else {
+			ok = true
+		}
+	}
+	return res, ok, errs
+}
+
+func (b *BuilderClient) newOpts(ctx *modules.BatchHandlerCtx) transaction.Opts {
+	return transaction.Opts{
+		EOA:         b.eoa,
+		Eth:         b.eth,
+		ChainID:     ctx.ChainID,
+		EntryPoint:  ctx.EntryPoint,
+		Batch:       ctx.Batch,
+		Beneficiary: b.beneficiary,
+		BaseFee:     ctx.BaseFee,
+		Tip:         ctx.Tip,
+		GasPrice:    ctx.GasPrice,
+		GasLimit:    0,
+		NoSend:      true,
+		WaitTimeout: b.waitTimeout,
+		Approve:     b.approve,
+	}
+}
+
+// sign creates the signed handleOps transaction and optional payout transaction for the batch in opts
+// targeting the block after the given head.
+func (b *BuilderClient) sign(opts *transaction.Opts, head uint64) (*template, error) {
+	// Calculate the max base fee up to a future block number.
+	nbn := big.NewInt(0).Add(big.NewInt(0).SetUint64(head), big.NewInt(1))
+	mbf := opts.BaseFee
+	for i := 0; mbf != nil && i < b.blocksInTheFuture; i++ {
+		a := big.NewInt(0).Mul(mbf, big.NewInt(1125))
+		b := big.NewInt(0).Div(a, big.NewInt(1000))
+		mbf = big.NewInt(0).Add(b, big.NewInt(1))
+	}
+	opts.BaseFee = mbf
+
+	// Create no send transaction to the EntryPoint
+	txn, err := transaction.HandleOps(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Append an optional payout transaction to the beneficiary contract.
+	t := &template{head: head, nextBlock: nbn, txn: txn, txs: []string{transaction.ToRawTxHex(txn)}}
+	if len(b.payoutCallData) > 0 {
+		ptxn, err := transaction.Payout(opts, b.payoutCallData, txn.Nonce()+1, b.payoutGasLimit)
+		if err != nil {
+			return nil, err
+		}
+		t.txs = append(t.txs, transaction.ToRawTxHex(ptxn))
+		t.payoutHash = ptxn.Hash().String()
+	}
+	return t, nil
+}
+
+// head returns the latest block number from the GetHeadFunc given to RunTemplates, so that templates are
+// looked up at the same head they were built on, or from the eth client otherwise.
+func (b *BuilderClient) head() (uint64, error) {
+	if b.getHead != nil {
+		return b.getHead()
+	}
+	return b.eth.BlockNumber(context.Background())
+}
+
 // SendUserOperation returns a BatchHandler that is used by the Bundler to send batches to a block builder
-// that supports eth_sendBundle.
+// that supports eth_sendBundle. If a pre-signed template exists for the batch at the current head, it is
+// submitted as is.
 func (b *BuilderClient) SendUserOperation() modules.BatchHandlerFunc {
+	send := b.sendUserOperation()
 	return func(ctx *modules.BatchHandlerCtx) error {
-		opts := transaction.Opts{
-			EOA:         b.eoa,
-			Eth:         b.eth,
-			ChainID:     ctx.ChainID,
-			EntryPoint:  ctx.EntryPoint,
-			Batch:       ctx.Batch,
-			Beneficiary: b.beneficiary,
-			BaseFee:     ctx.BaseFee,
-			Tip:         ctx.Tip,
-			GasPrice:    ctx.GasPrice,
-			GasLimit:    0,
-			NoSend:      true,
-			WaitTimeout: b.waitTimeout,
+		err := send(ctx)
+		b.recordError(err)
+		return err
+	}
+}
+
+func (b *BuilderClient) sendUserOperation() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		bn, err := b.head()
+		if err != nil {
+			return err
 		}
-		// Estimate gas for handleOps() and drop all userOps that cause unexpected reverts.
-		for len(ctx.Batch) > 0 {
-			est, revert, err := transaction.EstimateHandleOpsGas(&opts)
 
+		opts := b.newOpts(ctx)
+		t := b.templates.get(getTemplateKey(ctx), bn)
+		if t != nil {
+			ctx.Data["presigned"] = true
+		} else {
+			// Estimate gas for handleOps() and drop all userOps that cause unexpected reverts.
+			for len(ctx.Batch) > 0 {
+				est, revert, err := transaction.EstimateHandleOpsGas(&opts)
+
+				if err != nil {
+					return err
+				} else if revert != nil {
+					ctx.MarkOpIndexForRemoval(revert.OpIndex, revert.Reason)
+					opts.Batch = ctx.Batch
+				} else {
+					opts.GasLimit = est
+					break
+				}
+			}
+
+			// No need to continue if the batch size is 0. Otherwise we would just be sending empty batches.
+			if len(ctx.Batch) == 0 {
+				return nil
+			}
+
+			t, err = b.sign(&opts, bn)
 			if err != nil {
 				return err
-			} else if revert != nil {
-				ctx.MarkOpIndexForRemoval(revert.OpIndex, revert.Reason)
-			} else {
-				opts.GasLimit = est
-				break
 			}
 		}
-
-		// No need to continue if the batch size is 0. Otherwise we would just be sending empty batches.
-		if len(ctx.Batch) == 0 {
-			return nil
-		}
-
-		// Calculate the max base fee up to a future block number.
-		bn, err := b.eth.BlockNumber(context.Background())
-		if err != nil {
+		if err := b.simulate(ctx, t); err != nil {
 			return err
 		}
-		nbn := big.NewInt(0).Add(big.NewInt(0).SetUint64(bn), big.NewInt(1))
-		mbf := ctx.BaseFee
-		for i := 0; i < b.blocksInTheFuture; i++ {
-			a := big.NewInt(0).Mul(mbf, big.NewInt(1125))
-			b := big.NewInt(0).Div(a, big.NewInt(1000))
-			mbf = big.NewInt(0).Add(b, big.NewInt(1))
+		if t.payoutHash != "" {
+			ctx.Data["payout_txn_hash"] = t.payoutHash
 		}
-		opts.BaseFee = mbf
 
-		// Create no send transaction to the EntryPoint
-		txn, err := transaction.HandleOps(&opts)
-		if err != nil {
-			return err
+		// Append backruns from an orderflow auction after handleOps. These are allowed to revert so that a bad
+		// backrun can't prevent the batch from being included.
+		txs := t.txs
+		var reverting []string
+		if backruns, ok := ctx.Data["backrun_txs"].([]string); ok {
+			txs = append(append([]string{}, t.txs...), backruns...)
+			reverting, _ = ctx.Data["backrun_txn_hashes"].([]string)
 		}
 
 		// Broadcast bundle to a list of ethereum block builders for all blocks up to a future block.
 		shouldFail := true
 		var errs error
+		responses := []*Response{}
 		for i := 0; i < b.blocksInTheFuture; i++ {
-			fbn := big.NewInt(0).Add(nbn, big.NewInt(int64(i)))
-			sendBundleArgs := flashbotsrpc.FlashbotsSendBundleRequest{
-				Txs:         []string{transaction.ToRawTxHex(txn)},
-				BlockNumber: hexutil.EncodeBig(fbn),
-			}
-
-			results := b.rpc.BroadcastBundle(b.eoa.PrivateKey, sendBundleArgs)
-			for _, result := range results {
-				if result.Err != nil {
-					errs = errors.Join(errs, result.Err)
-				} else {
-					shouldFail = false
-				}
+			fbn := big.NewInt(0).Add(t.nextBlock, big.NewInt(int64(i)))
+			res, ok, err := b.broadcast(&Bundle{Txs: txs, BlockNumber: fbn, RevertingTxHashes: reverting})
+			if ok {
+				shouldFail = false
 			}
+			responses = append(responses, res...)
+			errs = errors.Join(errs, err)
 		}
+		ctx.Data["builder_responses"] = responses
+		ctx.Data["estimated_gas"] = t.txn.Gas()
 
 		// If there are no successful broadcast, return an error.
 		if shouldFail {
@@ -140,10 +305,10 @@ func (b *BuilderClient) SendUserOperation() modules.BatchHandlerFunc {
 		}
 
 		// Wait for transaction to be included on-chain.
-		if _, err := transaction.Wait(txn, opts.Eth, opts.WaitTimeout); err != nil {
+		if _, err := transaction.Wait(t.txn, opts.Eth, opts.WaitTimeout); err != nil {
 			return err
 		}
-		ctx.Data["txn_hash"] = txn.Hash().String()
+		ctx.Data["txn_hash"] = t.txn.Hash().String()
 
 		return nil
 	}
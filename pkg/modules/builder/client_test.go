@@ -118,3 +118,116 @@ func TestSendUserOperationWithNoUpstreamErrors(t *testing.T) {
 		t.Fatalf("got %v, want nil", err)
 	}
 }
+
+func TestSendUserOperationWithPayout(t *testing.T) {
+	n := testutils.RpcMock(testutils.MethodMocks{
+		"eth_blockNumber":           "0x1",
+		"eth_gasPrice":              "0x1",
+		"eth_getTransactionCount":   "0x1",
+		"eth_estimateGas":           "0x1",
+		"eth_getBlockByNumber":      testutils.NewBlockMock(),
+		"eth_getTransactionReceipt": testutils.NewTransactionReceiptMock(),
+	})
+	r, _ := rpc.Dial(n.URL)
+	eth := ethclient.NewClient(r)
+
+	bb := testutils.RpcMock(testutils.MethodMocks{
+		"eth_sendBundle": map[string]string{
+			"bundleHash": testutils.MockHash,
+		},
+	})
+	fb := flashbotsrpc.NewBuilderBroadcastRPC([]string{bb.URL})
+	b := New(testutils.DummyEOA, eth, fb, testutils.ValidAddress1, 1)
+	b.SetPayoutCallData(common.Hex2Bytes("deadbeef"))
+
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{testutils.MockValidInitUserOp()},
+		common.HexToAddress("0x"),
+		testutils.ChainID,
+		big.NewInt(1),
+		big.NewInt(1),
+		big.NewInt(1),
+	)
+	if err := b.SendUserOperation()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if _, ok := ctx.Data["payout_txn_hash"]; !ok {
+		t.Fatal("got no payout_txn_hash, want payout transaction")
+	}
+}
+
+func TestSendUserOperationWithSimulationRevert(t *testing.T) {
+	n := testutils.RpcMock(testutils.MethodMocks{
+		"eth_blockNumber":           "0x1",
+		"eth_gasPrice":              "0x1",
+		"eth_getTransactionCount":   "0x1",
+		"eth_estimateGas":           "0x1",
+		"eth_getBlockByNumber":      testutils.NewBlockMock(),
+		"eth_getTransactionReceipt": testutils.NewTransactionReceiptMock(),
+	})
+	r, _ := rpc.Dial(n.URL)
+	eth := ethclient.NewClient(r)
+
+	bb := testutils.RpcMock(testutils.MethodMocks{
+		"eth_sendBundle": map[string]string{
+			"bundleHash": testutils.MockHash,
+		},
+	})
+	sim := testutils.RpcMock(testutils.MethodMocks{
+		"eth_callBundle": map[string]any{
+			"bundleGasPrice": "1",
+			"results": []map[string]string{
+				{"txHash": testutils.MockHash, "revert": "execution reverted"},
+			},
+		},
+	})
+	fb := flashbotsrpc.NewBuilderBroadcastRPC([]string{bb.URL})
+	bc := New(testutils.DummyEOA, eth, fb, testutils.DummyEOA.Address, 1)
+	bc.SetSimulator(flashbotsrpc.New(sim.URL))
+
+	if err := bc.SendUserOperation()(
+		modules.NewBatchHandlerContext(
+			[]*userop.UserOperation{testutils.MockValidInitUserOp()},
+			common.HexToAddress("0x"),
+			testutils.ChainID,
+			big.NewInt(1),
+			big.NewInt(1),
+			big.NewInt(1),
+		),
+	); !errors.Is(err, ErrBundleSimulationRevert) {
+		t.Fatalf("got %v, want ErrBundleSimulationRevert", err)
+	}
+}
+
+func TestSendUserOperationWithLegacyGasPrice(t *testing.T) {
+	n := testutils.RpcMock(testutils.MethodMocks{
+		"eth_blockNumber":           "0x1",
+		"eth_gasPrice":              "0x1",
+		"eth_getTransactionCount":   "0x1",
+		"eth_estimateGas":           "0x1",
+		"eth_getBlockByNumber":      testutils.NewBlockMock(),
+		"eth_getTransactionReceipt": testutils.NewTransactionReceiptMock(),
+	})
+	r, _ := rpc.Dial(n.URL)
+	eth := ethclient.NewClient(r)
+
+	bb := testutils.RpcMock(testutils.MethodMocks{
+		"eth_sendBundle": map[string]string{
+			"bundleHash": testutils.MockHash,
+		},
+	})
+	fb := flashbotsrpc.NewBuilderBroadcastRPC([]string{bb.URL})
+	fn := New(testutils.DummyEOA, eth, fb, testutils.DummyEOA.Address, 1).SendUserOperation()
+
+	if err := fn(
+		modules.NewBatchHandlerContext(
+			[]*userop.UserOperation{testutils.MockValidInitUserOp()},
+			common.HexToAddress("0x"),
+			testutils.ChainID,
+			nil,
+			nil,
+			big.NewInt(1),
+		),
+	); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
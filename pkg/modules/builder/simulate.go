@@ -0,0 +1,115 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/metachris/flashbotsrpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// SetSimulator enables simulating every bundle with eth_callBundle against the given builder or relay before
+// it is broadcast. Bundles that revert or that would pay a higher effective gas price than the lowest priced
+// UserOperation in the batch are rejected.
+//
+// The default value is nil which will broadcast bundles without simulation.
+func (b *BuilderClient) SetSimulator(sim *flashbotsrpc.FlashbotsRPC) {
+	b.sim = sim
+}
+
+// UseMeter defines an opentelemetry meter object used by the BuilderClient to record the effective gas price
+// of simulated bundles and errors sending bundles.
+func (b *BuilderClient) UseMeter(meter metric.Meter) error {
+	egp, err := meter.Int64Histogram(
+		"builder_bundle_sim_effective_gas_price",
+		metric.WithUnit("wei"),
+	)
+	if err != nil {
+		return err
+	}
+	errCounter, err := meter.Int64Counter("builder_errors")
+	if err != nil {
+		return err
+	}
+
+	b.simGasPrice = egp
+	b.errCounter = errCounter
+	return nil
+}
+
+// recordError counts a failed attempt to send a bundle by the kind of error.
+func (b *BuilderClient) recordError(err error) {
+	if err == nil || b.errCounter == nil {
+		return
+	}
+
+	kind := "other"
+	switch {
+	case errors.Is(err, ErrFlashbotsBroadcastBundle):
+		kind = "broadcast"
+	case errors.Is(err, ErrBundleSimulationRevert):
+		kind = "simulation_revert"
+	case errors.Is(err, ErrBundleSimulationLoss):
+		kind = "simulation_loss"
+	}
+	b.errCounter.Add(context.Background(), 1, metric.WithAttributes(attribute.String("kind", kind)))
+}
+
+// simulate runs the bundle in template t at its target block and returns an error if it should not be
+// broadcast.
+func (b *BuilderClient) simulate(ctx *modules.BatchHandlerCtx, t *template) error {
+	if b.sim == nil {
+		return nil
+	}
+
+	res, err := b.sim.FlashbotsCallBundle(b.eoa.PrivateKey, flashbotsrpc.FlashbotsCallBundleParam{
+		Txs:              t.txs,
+		BlockNumber:      hexutil.EncodeBig(t.nextBlock),
+		StateBlockNumber: "latest",
+	})
+	if err != nil {
+		return err
+	}
+	for _, r := range res.Results {
+		if r.Error != "" || r.Revert != "" {
+			return fmt.Errorf("%w: tx %s: %s%s", ErrBundleSimulationRevert, r.TxHash, r.Error, r.Revert)
+		}
+	}
+
+	egp, ok := big.NewInt(0).SetString(res.BundleGasPrice, 10)
+	if !ok {
+		return fmt.Errorf("%w: invalid bundleGasPrice %s", ErrBundleSimulationRevert, res.BundleGasPrice)
+	}
+	ctx.Data["sim_effective_gas_price"] = egp.String()
+	if b.simGasPrice != nil && egp.IsInt64() {
+		b.simGasPrice.Record(
+			context.Background(),
+			egp.Int64(),
+			metric.WithAttributes(attribute.String("entrypoint", ctx.EntryPoint.String())),
+		)
+	}
+
+	// Each UserOperation compensates the beneficiary at its own gas price. If the bundle pays more per gas
+	// than the lowest priced op, that op is subsidized by the bundler. On networks without a basefee, each op
+	// pays its MaxFeePerGas.
+	for _, op := range ctx.Batch {
+		gp := op.MaxFeePerGas
+		if ctx.BaseFee != nil {
+			gp = op.GetDynamicGasPrice(ctx.BaseFee)
+		}
+		if gp.Cmp(egp) < 0 {
+			return fmt.Errorf(
+				"%w: effective gas price %s is above op gas price %s",
+				ErrBundleSimulationLoss,
+				egp,
+				gp,
+			)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package builder
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/noop"
+)
+
+// template is a pre-built and pre-signed bundle for a batch that is only valid at the head it was built on.
+type template struct {
+	head       uint64
+	nextBlock  *big.Int
+	txn        *types.Transaction
+	txs        []string
+	payoutHash string
+}
+
+// templateCache holds pre-signed templates for the current head. All templates are discarded once the head
+// changes since the EOA nonce, gas estimates, and target block may no longer be valid.
+type templateCache struct {
+	mu        sync.Mutex
+	head      uint64
+	templates map[common.Hash]*template
+}
+
+func getTemplateKey(ctx *modules.BatchHandlerCtx) common.Hash {
+	data := append([]byte{}, ctx.EntryPoint.Bytes()...)
+	for _, fee := range []*big.Int{ctx.BaseFee, ctx.Tip, ctx.GasPrice} {
+		if fee != nil {
+			data = append(data, fee.Bytes()...)
+		}
+		data = append(data, 0)
+	}
+	for _, op := range ctx.Batch {
+		data = append(data, op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID).Bytes()...)
+	}
+	return crypto.Keccak256Hash(data)
+}
+
+// get returns a template for the key if one was built at the given head. It is safe to call on a nil cache.
+func (c *templateCache) get(key common.Hash, head uint64) *template {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.templates[key]
+	if !ok || t.head != head {
+		return nil
+	}
+	delete(c.templates, key)
+	return t
+}
+
+func (c *templateCache) reset(head uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.head = head
+	c.templates = make(map[common.Hash]*template)
+}
+
+func (c *templateCache) set(key common.Hash, t *template) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t.head == c.head {
+		c.templates[key] = t
+	}
+}
+
+// GetHeadFunc returns the block number of the latest head.
+type GetHeadFunc = func() (uint64, error)
+
+// NewContextFunc returns a BatchHandlerCtx with the current pending UserOperations for an EntryPoint. A nil
+// context is returned if there are none.
+type NewContextFunc = func(ep common.Address) (*modules.BatchHandlerCtx, error)
+
+// RunTemplates starts a pipeline that pre-builds and pre-signs bundles for the top n candidate batches on
+// each signal from newHeads. Candidates are created by passing the pending UserOperations through the given
+// ordering modules and taking the n largest prefixes of the resulting batch. When SendUserOperation is called
+// with a batch that matches a candidate at the same head, the pre-signed bundle is submitted immediately.
+// Once running, SendUserOperation also uses getHead so that both agree on the current head.
+//
+// Ordering modules should match the ordering of the Bundler's batch handlers so that candidates match the
+// batches it sends. They must be free of side effects since their results are only used to build candidates.
+func (b *BuilderClient) RunTemplates(
+	newCtx NewContextFunc,
+	eps []common.Address,
+	n int,
+	newHeads <-chan struct{},
+	getHead GetHeadFunc,
+	l logr.Logger,
+	ordering ...modules.BatchHandlerFunc,
+) {
+	if n <= 0 {
+		return
+	}
+
+	b.templates = &templateCache{templates: make(map[common.Hash]*template)}
+	b.getHead = getHead
+	order := noop.BatchHandler
+	if len(ordering) > 0 {
+		order = modules.ComposeBatchHandlerFunc(ordering...)
+	}
+	l = l.WithName("builder_templates")
+
+	go func() {
+		// Every signal is a new head, including a reorg at the same height, so templates are always rebuilt.
+		for range newHeads {
+			bn, err := getHead()
+			if err != nil {
+				l.Error(err, "builder templates error")
+				continue
+			}
+			b.templates.reset(bn)
+
+			for _, ep := range eps {
+				count, err := b.prebuild(newCtx, ep, bn, n, order)
+				if err != nil {
+					l.WithValues("entrypoint", ep.String()).Error(err, "builder templates error")
+					continue
+				}
+				l.WithValues("entrypoint", ep.String()).
+					WithValues("block_number", bn).
+					WithValues("templates", count).
+					Info("builder templates ok")
+			}
+		}
+	}()
+}
+
+func (b *BuilderClient) prebuild(
+	newCtx NewContextFunc,
+	ep common.Address,
+	head uint64,
+	n int,
+	order modules.BatchHandlerFunc,
+) (int, error) {
+	ctx, err := newCtx(ep)
+	if err != nil || ctx == nil {
+		return 0, err
+	}
+	if err := order(ctx); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	candidates := ctx.Batch
+	for size := len(candidates); size > 0 && count < n; size-- {
+		cctx := modules.NewBatchHandlerContext(
+			candidates[:size],
+			ctx.EntryPoint,
+			ctx.ChainID,
+			ctx.BaseFee,
+			ctx.Tip,
+			ctx.GasPrice,
+		)
+		opts := b.newOpts(cctx)
+		est, revert, err := transaction.EstimateHandleOpsGas(&opts)
+		if err != nil {
+			return count, err
+		} else if revert != nil {
+			// Candidates that revert will be handled by the regular send path.
+			continue
+		}
+		opts.GasLimit = est
+
+		t, err := b.sign(&opts, head)
+		if err != nil {
+			return count, err
+		}
+		b.templates.set(getTemplateKey(cctx), t)
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,108 @@
+package builder
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func newTemplateCtx(tip int64, ops ...*userop.UserOperation) *modules.BatchHandlerCtx {
+	return modules.NewBatchHandlerContext(
+		ops,
+		common.HexToAddress("0x"),
+		testutils.ChainID,
+		big.NewInt(1),
+		big.NewInt(tip),
+		big.NewInt(1),
+	)
+}
+
+func TestTemplateKeyIsOrderAndFeeSensitive(t *testing.T) {
+	op1 := testutils.MockValidInitUserOp()
+	op2 := testutils.MockValidInitUserOp()
+	op2.Sender = testutils.ValidAddress2
+
+	k := getTemplateKey(newTemplateCtx(1, op1, op2))
+	if k != getTemplateKey(newTemplateCtx(1, op1, op2)) {
+		t.Fatal("got different keys for the same batch")
+	} else if k == getTemplateKey(newTemplateCtx(1, op2, op1)) {
+		t.Fatal("got same key for a different order")
+	} else if k == getTemplateKey(newTemplateCtx(2, op1, op2)) {
+		t.Fatal("got same key for a different tip")
+	}
+}
+
+func TestTemplateCacheDiscardsStaleHeads(t *testing.T) {
+	c := &templateCache{}
+	c.reset(1)
+	key := getTemplateKey(newTemplateCtx(1, testutils.MockValidInitUserOp()))
+	c.set(key, &template{head: 1})
+
+	if tmpl := c.get(key, 2); tmpl != nil {
+		t.Fatal("got template, want nil for a different head")
+	}
+	if tmpl := c.get(key, 1); tmpl == nil {
+		t.Fatal("got nil, want template")
+	}
+	if tmpl := c.get(key, 1); tmpl != nil {
+		t.Fatal("got template, want nil after it was used")
+	}
+
+	c.reset(2)
+	c.set(key, &template{head: 1})
+	if tmpl := c.get(key, 1); tmpl != nil {
+		t.Fatal("got template, want nil for a template built on a previous head")
+	}
+}
+
+func TestNilTemplateCache(t *testing.T) {
+	var c *templateCache
+	if tmpl := c.get(common.Hash{}, 1); tmpl != nil {
+		t.Fatal("got template, want nil")
+	}
+}
+
+// TestRunTemplatesResetsOnNewHeads verifies that templates are rebuilt on every signal from newHeads, including
+// a signal for a head at the same height, and that the head from getHead is shared with SendUserOperation.
+func TestRunTemplatesResetsOnNewHeads(t *testing.T) {
+	newHeads := make(chan struct{})
+	heads := make(chan uint64, 2)
+	builds := make(chan common.Address, 2)
+	b := New(testutils.DummyEOA, nil, nil, testutils.DummyEOA.Address, 1)
+	b.RunTemplates(
+		func(ep common.Address) (*modules.BatchHandlerCtx, error) {
+			builds <- ep
+			return nil, nil
+		},
+		[]common.Address{testutils.ValidAddress1},
+		1,
+		newHeads,
+		func() (uint64, error) { return <-heads, nil },
+		logr.Discard(),
+	)
+	defer close(newHeads)
+
+	for _, head := range []uint64{1, 1} {
+		heads <- head
+		newHeads <- struct{}{}
+		select {
+		case ep := <-builds:
+			if ep != testutils.ValidAddress1 {
+				t.Fatalf("got %s, want %s", ep, testutils.ValidAddress1)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("got no build, want one for each new head")
+		}
+	}
+
+	heads <- 2
+	if bn, err := b.head(); err != nil || bn != 2 {
+		t.Fatalf("got %d and %v, want 2 and nil", bn, err)
+	}
+}
@@ -18,5 +18,11 @@ var (
 
 	DefaultWaitTimeout = 72 * time.Second
 
+	DefaultPayoutGasLimit = uint64(100000)
+
 	ErrFlashbotsBroadcastBundle = errors.New("flashbots broadcast bundle error")
+
+	ErrBundleSimulationRevert = errors.New("bundle simulation reverted")
+
+	ErrBundleSimulationLoss = errors.New("bundle simulation resulted in a loss")
 )
@@ -2,15 +2,21 @@ package checks
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/dgraph-io/badger/v3"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stackup-wallet/stackup-bundler/internal/dbutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/altmempools"
 )
 
 var (
 	keyPrefix        = dbutils.JoinValues("checks")
 	codeHashesPrefix = dbutils.JoinValues(keyPrefix, "codeHashes")
+	altMempoolPrefix = dbutils.JoinValues(keyPrefix, "altMempoolExceptions")
+
+	// AltMempoolExceptionsTTL is how long applied alternative mempool exceptions are kept for auditing.
+	AltMempoolExceptionsTTL = 7 * 24 * time.Hour
 )
 
 func getCodeHashesKey(userOpHash common.Hash) []byte {
@@ -54,3 +60,37 @@ func removeSavedCodeHashes(db *badger.DB, userOpHashes ...common.Hash) error {
 		return nil
 	})
 }
+
+func getAltMempoolExceptionsKey(userOpHash common.Hash) []byte {
+	return []byte(dbutils.JoinValues(altMempoolPrefix, userOpHash.String()))
+}
+
+func saveAltMempoolExceptions(db *badger.DB, userOpHash common.Hash, exceptions []*altmempools.Exception) error {
+	return db.Update(func(txn *badger.Txn) error {
+		data, err := json.Marshal(exceptions)
+		if err != nil {
+			return err
+		}
+
+		e := badger.NewEntry(getAltMempoolExceptionsKey(userOpHash), data).WithTTL(AltMempoolExceptionsTTL)
+		return txn.SetEntry(e)
+	})
+}
+
+func getSavedAltMempoolExceptions(db *badger.DB, userOpHash common.Hash) ([]*altmempools.Exception, error) {
+	exceptions := []*altmempools.Exception{}
+	err := db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(getAltMempoolExceptionsKey(userOpHash))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &exceptions)
+		})
+	})
+
+	return exceptions, err
+}
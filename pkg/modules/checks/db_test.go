@@ -0,0 +1,41 @@
+package checks
+
+import (
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/altmempools"
+)
+
+func TestSavedAltMempoolExceptions(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	op := testutils.MockValidInitUserOp()
+	hash := op.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID)
+
+	exs, err := getSavedAltMempoolExceptions(db, hash)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(exs) != 0 {
+		t.Fatalf("got %v, want []", exs)
+	}
+
+	want := []*altmempools.Exception{
+		{
+			Rule:       altmempools.InvalidStorageAccessRule,
+			Entity:     "account",
+			Contract:   "0x0000000000000000000000000000000000000000",
+			Slot:       "0x0",
+			MempoolIds: []string{"1"},
+		},
+	}
+	if err := saveAltMempoolExceptions(db, hash, want); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	exs, err = getSavedAltMempoolExceptions(db, hash)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(exs) != 1 || exs[0].Entity != "account" || exs[0].MempoolIds[0] != "1" {
+		t.Fatalf("got %+v, want %+v", exs, want)
+	}
+}
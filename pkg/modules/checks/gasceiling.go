@@ -0,0 +1,40 @@
+package checks
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// ValidateGasCeilings checks that the callGasLimit and the total gas of an op are below independent per-op
+// ceilings. This prevents a single large op from monopolizing a batch. A ceiling that is nil or 0 is
+// disabled.
+func ValidateGasCeilings(op *userop.UserOperation, maxCallGasLimit *big.Int, maxOpGas *big.Int) error {
+	if maxCallGasLimit != nil && maxCallGasLimit.Cmp(common.Big0) == 1 &&
+		op.CallGasLimit.Cmp(maxCallGasLimit) > 0 {
+		return errors.NewRPCError(
+			errors.EXCEEDS_CALL_GAS_CEILING,
+			fmt.Sprintf("callGasLimit: exceeds maxCallGasLimit of %s", maxCallGasLimit.String()),
+			nil,
+		)
+	}
+
+	if maxOpGas != nil && maxOpGas.Cmp(common.Big0) == 1 {
+		mga, err := getMaxExecutionGas(op)
+		if err != nil {
+			return err
+		}
+		if mga.Cmp(maxOpGas) > 0 {
+			return errors.NewRPCError(
+				errors.EXCEEDS_OP_GAS_CEILING,
+				fmt.Sprintf("gasLimit: exceeds maxOpGas of %s", maxOpGas.String()),
+				nil,
+			)
+		}
+	}
+
+	return nil
+}
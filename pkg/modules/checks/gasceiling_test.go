@@ -0,0 +1,50 @@
+package checks
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	bundlerErrors "github.com/stackup-wallet/stackup-bundler/pkg/errors"
+)
+
+func getRPCErrorCode(t *testing.T, err error) int {
+	var rpcErr *bundlerErrors.RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("got %v, want RPCError", err)
+	}
+	return rpcErr.Code()
+}
+
+// TestGasCeilingsDisabled calls checks.ValidateGasCeilings with no ceilings set. Expect nil.
+func TestGasCeilingsDisabled(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	if err := ValidateGasCeilings(op, nil, big.NewInt(0)); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
+
+// TestCallGasLimitAboveCeiling calls checks.ValidateGasCeilings where callGasLimit > maxCallGasLimit. Expect
+// error with EXCEEDS_CALL_GAS_CEILING code.
+func TestCallGasLimitAboveCeiling(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	max := big.NewInt(0).Sub(op.CallGasLimit, common.Big1)
+	err := ValidateGasCeilings(op, max, nil)
+
+	if code := getRPCErrorCode(t, err); code != bundlerErrors.EXCEEDS_CALL_GAS_CEILING {
+		t.Fatalf("got code %d, want %d", code, bundlerErrors.EXCEEDS_CALL_GAS_CEILING)
+	}
+}
+
+// TestOpGasAboveCeiling calls checks.ValidateGasCeilings where the op's max gas > maxOpGas. Expect error with
+// EXCEEDS_OP_GAS_CEILING code.
+func TestOpGasAboveCeiling(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	err := ValidateGasCeilings(op, op.CallGasLimit, op.CallGasLimit)
+
+	if code := getRPCErrorCode(t, err); code != bundlerErrors.EXCEEDS_OP_GAS_CEILING {
+		t.Fatalf("got code %d, want %d", code, bundlerErrors.EXCEEDS_OP_GAS_CEILING)
+	}
+}
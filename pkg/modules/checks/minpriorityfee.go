@@ -0,0 +1,51 @@
+package checks
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// MinPriorityFees sets the minimum maxPriorityFeePerGas for each class of UserOperation. Ops that use a
+// paymaster or deploy an account via initCode carry a higher risk of failing in a bundle and can be priced
+// accordingly. A nil or 0 value disables the minimum for that class.
+type MinPriorityFees struct {
+	Paymaster *big.Int
+	InitCode  *big.Int
+	Sender    *big.Int
+}
+
+// getMinPriorityFee returns the highest minimum of all classes that apply to the op. Plain deployed-sender ops
+// that use neither a paymaster nor initCode only use the Sender minimum.
+func (m *MinPriorityFees) getMinPriorityFee(op *userop.UserOperation) (*big.Int, string) {
+	min, class := m.Sender, "sender"
+	if len(op.PaymasterAndData) == 0 && len(op.InitCode) == 0 {
+		return min, class
+	}
+
+	min, class = big.NewInt(0), ""
+	if len(op.PaymasterAndData) != 0 && m.Paymaster != nil && m.Paymaster.Cmp(min) > 0 {
+		min, class = m.Paymaster, "paymaster"
+	}
+	if len(op.InitCode) != 0 && m.InitCode != nil && m.InitCode.Cmp(min) > 0 {
+		min, class = m.InitCode, "initCode"
+	}
+	return min, class
+}
+
+// ValidateMinPriorityFee checks the maxPriorityFeePerGas is at least the minimum for the op's class.
+func ValidateMinPriorityFee(op *userop.UserOperation, fees *MinPriorityFees) error {
+	if fees == nil {
+		return nil
+	}
+
+	min, class := fees.getMinPriorityFee(op)
+	if min == nil || min.Sign() == 0 {
+		return nil
+	}
+	if op.MaxPriorityFeePerGas.Cmp(min) < 0 {
+		return fmt.Errorf("maxPriorityFeePerGas: must be equal to or greater than %s for %s ops", min, class)
+	}
+	return nil
+}
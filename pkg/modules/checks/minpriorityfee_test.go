@@ -0,0 +1,76 @@
+package checks
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+)
+
+var testMinPriorityFees = &MinPriorityFees{
+	Paymaster: big.NewInt(30),
+	InitCode:  big.NewInt(20),
+	Sender:    big.NewInt(10),
+}
+
+// TestMinPriorityFeeSender calls checks.ValidateMinPriorityFee on a plain deployed-sender op. Expect the
+// sender minimum to apply.
+func TestMinPriorityFeeSender(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	op.InitCode = []byte{}
+	op.PaymasterAndData = []byte{}
+
+	op.MaxPriorityFeePerGas = big.NewInt(9)
+	if err := ValidateMinPriorityFee(op, testMinPriorityFees); err == nil {
+		t.Fatal("got nil, want err")
+	}
+	op.MaxPriorityFeePerGas = big.NewInt(10)
+	if err := ValidateMinPriorityFee(op, testMinPriorityFees); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
+
+// TestMinPriorityFeeInitCode calls checks.ValidateMinPriorityFee on an op with initCode. Expect the initCode
+// minimum to apply.
+func TestMinPriorityFeeInitCode(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	op.PaymasterAndData = []byte{}
+
+	op.MaxPriorityFeePerGas = big.NewInt(19)
+	if err := ValidateMinPriorityFee(op, testMinPriorityFees); err == nil {
+		t.Fatal("got nil, want err")
+	}
+	op.MaxPriorityFeePerGas = big.NewInt(20)
+	if err := ValidateMinPriorityFee(op, testMinPriorityFees); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
+
+// TestMinPriorityFeePaymasterAndInitCode calls checks.ValidateMinPriorityFee on an op with both a paymaster and
+// initCode. Expect the highest minimum to apply.
+func TestMinPriorityFeePaymasterAndInitCode(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	op.PaymasterAndData = testutils.ValidAddress1.Bytes()
+
+	op.MaxPriorityFeePerGas = big.NewInt(29)
+	if err := ValidateMinPriorityFee(op, testMinPriorityFees); err == nil {
+		t.Fatal("got nil, want err")
+	}
+	op.MaxPriorityFeePerGas = big.NewInt(30)
+	if err := ValidateMinPriorityFee(op, testMinPriorityFees); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
+
+// TestMinPriorityFeeDisabled calls checks.ValidateMinPriorityFee with no minimums set. Expect nil.
+func TestMinPriorityFeeDisabled(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	op.MaxPriorityFeePerGas = big.NewInt(0)
+
+	if err := ValidateMinPriorityFee(op, &MinPriorityFees{}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := ValidateMinPriorityFee(op, nil); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
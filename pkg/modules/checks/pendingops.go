@@ -1,55 +1,119 @@
 package checks
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
 var (
-	minPriceBump                = int64(10)
-	ErrReplacementOpUnderpriced = fmt.Errorf(
-		"pending ops: replacement op must increase maxFeePerGas and MaxPriorityFeePerGas by >= %d%%",
-		minPriceBump,
-	)
+	// DefaultReplacementFeeBump is the default percentage that both maxFeePerGas and maxPriorityFeePerGas
+	// must increase by for an op to replace a pending op with the same sender and nonce.
+	DefaultReplacementFeeBump = int64(10)
+
+	ErrReplacementOpUnderpriced = errors.New("replacement underpriced")
 )
 
-// calcNewThresholds returns new threshold values where newFee = oldFee  * (100 + minPriceBump) / 100.
-func calcNewThresholds(cap *big.Int, tip *big.Int) (newCap *big.Int, newTip *big.Int) {
-	a := big.NewInt(100 + minPriceBump)
-	aFeeCap := big.NewInt(0).Mul(a, cap)
-	aTip := big.NewInt(0).Mul(a, tip)
+// ReplacementUnderpricedError is returned when an op with the same sender and nonce as a pending op does not
+// increase both fees by the required percentage. It includes the fees of the pending op and the minimum fees
+// required for a replacement.
+type ReplacementUnderpricedError struct {
+	FeeBump                     int64
+	CurrentMaxFeePerGas         *big.Int
+	CurrentMaxPriorityFeePerGas *big.Int
+	MinMaxFeePerGas             *big.Int
+	MinMaxPriorityFeePerGas     *big.Int
+}
 
-	b := big.NewInt(100)
-	newCap = aFeeCap.Div(aFeeCap, b)
-	newTip = aTip.Div(aTip, b)
+func (e *ReplacementUnderpricedError) Error() string {
+	return fmt.Sprintf(
+		"%s: replacement op must increase maxFeePerGas and maxPriorityFeePerGas by >= %d%%",
+		ErrReplacementOpUnderpriced,
+		e.FeeBump,
+	)
+}
 
-	return newCap, newTip
+func (e *ReplacementUnderpricedError) Unwrap() error {
+	return ErrReplacementOpUnderpriced
+}
+
+// Data returns the fees of the pending op and the minimum fees for a replacement to be used as RPC error data.
+func (e *ReplacementUnderpricedError) Data() map[string]any {
+	return map[string]any{
+		"currentMaxFeePerGas":         (*hexutil.Big)(e.CurrentMaxFeePerGas),
+		"currentMaxPriorityFeePerGas": (*hexutil.Big)(e.CurrentMaxPriorityFeePerGas),
+		"minMaxFeePerGas":             (*hexutil.Big)(e.MinMaxFeePerGas),
+		"minMaxPriorityFeePerGas":     (*hexutil.Big)(e.MinMaxPriorityFeePerGas),
+	}
+}
+
+// calcThreshold returns the minimum replacement fee where newFee = ceil(oldFee * (100 + bump) / 100). The
+// result is always greater than oldFee so that a replacement can never keep the same fee due to rounding.
+func calcThreshold(fee *big.Int, bump int64) *big.Int {
+	a := big.NewInt(0).Mul(fee, big.NewInt(100+bump))
+	a.Add(a, big.NewInt(99))
+	a.Div(a, big.NewInt(100))
+
+	if min := big.NewInt(0).Add(fee, big.NewInt(1)); a.Cmp(min) < 0 {
+		return min
+	}
+	return a
+}
+
+// calcNewThresholds returns new threshold values for both fees using DefaultReplacementFeeBump.
+func calcNewThresholds(cap *big.Int, tip *big.Int) (newCap *big.Int, newTip *big.Int) {
+	return calcThreshold(cap, DefaultReplacementFeeBump), calcThreshold(tip, DefaultReplacementFeeBump)
 }
 
 // ValidatePendingOps checks the pending UserOperations by the same sender and only passes if:
 //
 //  1. Sender doesn't have another UserOperation already present in the pool.
 //  2. It replaces an existing UserOperation with same nonce and higher fee.
+//  3. It replaces an existing UserOperation with the same intent (i.e. only the signature is different).
+//
+// Replacements must increase both fees by at least DefaultReplacementFeeBump percent.
 func ValidatePendingOps(
 	op *userop.UserOperation,
 	penOps []*userop.UserOperation,
 ) error {
-	if len(penOps) > 0 {
-		var oldOp *userop.UserOperation
-		for _, penOp := range penOps {
-			if op.Nonce.Cmp(penOp.Nonce) == 0 {
-				oldOp = penOp
-			}
+	return ValidatePendingOpsWithFeeBump(op, penOps, DefaultReplacementFeeBump)
+}
+
+// ValidatePendingOpsWithFeeBump works the same as ValidatePendingOps but requires replacements to increase
+// both fees by at least the given percentage. A *ReplacementUnderpricedError is returned otherwise.
+func ValidatePendingOpsWithFeeBump(
+	op *userop.UserOperation,
+	penOps []*userop.UserOperation,
+	bump int64,
+) error {
+	var oldOp *userop.UserOperation
+	for _, penOp := range penOps {
+		if op.Sender == penOp.Sender && op.Nonce.Cmp(penOp.Nonce) == 0 {
+			oldOp = penOp
 		}
+	}
+	if oldOp == nil {
+		return nil
+	}
 
-		if oldOp != nil {
-			newMf, newMpf := calcNewThresholds(oldOp.MaxFeePerGas, oldOp.MaxPriorityFeePerGas)
+	isResigned := op.IsSameIntent(oldOp) && !bytes.Equal(op.Signature, oldOp.Signature)
+	if isResigned {
+		return nil
+	}
 
-			if op.MaxFeePerGas.Cmp(newMf) < 0 || op.MaxPriorityFeePerGas.Cmp(newMpf) < 0 {
-				return ErrReplacementOpUnderpriced
-			}
+	newMf := calcThreshold(oldOp.MaxFeePerGas, bump)
+	newMpf := calcThreshold(oldOp.MaxPriorityFeePerGas, bump)
+	if op.MaxFeePerGas.Cmp(newMf) < 0 || op.MaxPriorityFeePerGas.Cmp(newMpf) < 0 {
+		return &ReplacementUnderpricedError{
+			FeeBump:                     bump,
+			CurrentMaxFeePerGas:         oldOp.MaxFeePerGas,
+			CurrentMaxPriorityFeePerGas: oldOp.MaxPriorityFeePerGas,
+			MinMaxFeePerGas:             newMf,
+			MinMaxPriorityFeePerGas:     newMpf,
 		}
 	}
 	return nil
@@ -95,3 +95,69 @@ func TestPendingOpsWithOkGasFeeReplacement(t *testing.T) {
 		t.Fatalf("got err %v, want nil", err)
 	}
 }
+
+func TestPendingOpsWithSameIntentReplacement(t *testing.T) {
+	penOp := testutils.MockValidInitUserOp()
+	penOps := []*userop.UserOperation{penOp}
+	op := testutils.MockValidInitUserOp()
+	op.Signature = []byte{1, 2, 3}
+	err := ValidatePendingOps(
+		op,
+		penOps,
+	)
+
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+}
+
+// TestPendingOpsWithFeeBump verifies that the required increase follows the given percentage and that the
+// error includes the minimum replacement fees.
+func TestPendingOpsWithFeeBump(t *testing.T) {
+	penOp := testutils.MockValidInitUserOp()
+	penOp.MaxFeePerGas = big.NewInt(100)
+	penOp.MaxPriorityFeePerGas = big.NewInt(10)
+	op := testutils.MockValidInitUserOp()
+	op.MaxFeePerGas = big.NewInt(120)
+	op.MaxPriorityFeePerGas = big.NewInt(12)
+
+	err := ValidatePendingOpsWithFeeBump(op, []*userop.UserOperation{penOp}, 25)
+	var ru *ReplacementUnderpricedError
+	if !errors.As(err, &ru) || !errors.Is(err, ErrReplacementOpUnderpriced) {
+		t.Fatalf("got %v, want ReplacementUnderpricedError", err)
+	}
+	if ru.MinMaxFeePerGas.Cmp(big.NewInt(125)) != 0 || ru.MinMaxPriorityFeePerGas.Cmp(big.NewInt(13)) != 0 {
+		t.Fatalf("got min fees %s and %s, want 125 and 13", ru.MinMaxFeePerGas, ru.MinMaxPriorityFeePerGas)
+	}
+
+	if err := ValidatePendingOpsWithFeeBump(op, []*userop.UserOperation{penOp}, 20); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+}
+
+// TestPendingOpsWithRoundedFeeReplacement verifies that a replacement must increase small fees even if the
+// percentage rounds down to 0.
+func TestPendingOpsWithRoundedFeeReplacement(t *testing.T) {
+	penOp := testutils.MockValidInitUserOp()
+	penOp.MaxFeePerGas = big.NewInt(5)
+	penOp.MaxPriorityFeePerGas = big.NewInt(0)
+	op := testutils.MockValidInitUserOp()
+	op.MaxFeePerGas = big.NewInt(5)
+	op.MaxPriorityFeePerGas = big.NewInt(0)
+
+	if err := ValidatePendingOps(op, []*userop.UserOperation{penOp}); !errors.Is(err, ErrReplacementOpUnderpriced) {
+		t.Fatalf("got %v, want ErrReplacementOpUnderpriced", err)
+	}
+}
+
+// TestPendingOpsWithSameNonceDifferentSender verifies that pending ops where the sender is only referenced as
+// another entity are not treated as replacements.
+func TestPendingOpsWithSameNonceDifferentSender(t *testing.T) {
+	penOp := testutils.MockValidInitUserOp()
+	penOp.Sender = testutils.ValidAddress1
+	op := testutils.MockValidInitUserOp()
+
+	if err := ValidatePendingOps(op, []*userop.UserOperation{penOp}); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+}
@@ -0,0 +1,178 @@
+package checks
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/gasprice"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// revertCallGasLimit is the callGasLimit of a mocked op that causes handleOps to revert.
+const revertCallGasLimit = 1
+
+// simulateOp returns an op where the callGasLimit is the amount the mocked handleOps pays the beneficiary.
+func simulateOp(sender common.Address, seq int64, payment int64) *userop.UserOperation {
+	op := testutils.MockValidInitUserOp()
+	op.Sender = sender
+	op.Nonce = big.NewInt(seq)
+	op.InitCode = []byte{}
+	op.CallGasLimit = big.NewInt(payment)
+	return op
+}
+
+// decodeHandleOps returns the callGasLimit of each op in the handleOps call data of the first request param.
+func decodeHandleOps(params []json.RawMessage) ([]int64, error) {
+	var req struct {
+		Data hexutil.Bytes `json:"data"`
+	}
+	if err := json.Unmarshal(params[0], &req); err != nil {
+		return nil, err
+	}
+	parsed, err := entrypoint.EntrypointMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	args, err := parsed.Methods["handleOps"].Inputs.Unpack(req.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+
+	ops := reflect.ValueOf(args[0])
+	payments := []int64{}
+	for i := 0; i < ops.Len(); i++ {
+		payments = append(payments, ops.Index(i).FieldByName("CallGasLimit").Interface().(*big.Int).Int64())
+	}
+	return payments, nil
+}
+
+// simulateBatch runs SimulateBatch against a mocked RPC where handleOps uses 100 * n^2 gas for a batch of n
+// ops and pays the beneficiary the sum of each op's callGasLimit. The gas price is 1.
+func simulateBatch(t *testing.T, batch ...*userop.UserOperation) *modules.BatchHandlerCtx {
+	estimateGas := func(params []json.RawMessage) (any, error) {
+		payments, err := decodeHandleOps(params)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range payments {
+			if p == revertCallGasLimit {
+				return nil, errors.New("execution reverted")
+			}
+		}
+		n := uint64(len(payments))
+		return hexutil.Uint64(100 * n * n), nil
+	}
+	traceCall := func(params []json.RawMessage) (any, error) {
+		payments, err := decodeHandleOps(params)
+		if err != nil {
+			return nil, err
+		}
+		sum := int64(0)
+		for _, p := range payments {
+			sum += p
+		}
+		bene := testutils.ValidAddress5
+		return map[string]any{
+			"pre":  map[common.Address]any{bene: map[string]any{"balance": "0x0"}},
+			"post": map[common.Address]any{bene: map[string]any{"balance": hexutil.EncodeBig(big.NewInt(sum))}},
+		}, nil
+	}
+	server := testutils.RpcMock(testutils.MethodMocks{
+		"eth_estimateGas": testutils.MethodFunc(estimateGas),
+		"debug_traceCall": testutils.MethodFunc(traceCall),
+	})
+	t.Cleanup(server.Close)
+	rpcClient, err := rpc.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(rpcClient.Close)
+
+	s := New(nil, rpcClient, nil, nil, nil, nil, false, "", nil)
+	s.SetProfitModel(gasprice.ProfitModelBaseFeeRebate)
+	ctx := modules.NewBatchHandlerContext(batch, testutils.ValidAddress1, testutils.ChainID, common.Big1, nil, nil)
+	if err := s.SimulateBatch(testutils.ValidAddress5)(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	return ctx
+}
+
+func assertSimulatedBatch(
+	t *testing.T,
+	ctx *modules.BatchHandlerCtx,
+	batch []*userop.UserOperation,
+	reasons ...string,
+) {
+	t.Helper()
+	if !reflect.DeepEqual(ctx.Batch, batch) {
+		t.Fatalf("got batch of length %d, want %d", len(ctx.Batch), len(batch))
+	}
+	if len(ctx.PendingRemoval) != len(reasons) {
+		t.Fatalf("got %d pending removals, want %d", len(ctx.PendingRemoval), len(reasons))
+	}
+	for i, r := range reasons {
+		if ctx.PendingRemoval[i].Reason != r {
+			t.Fatalf("got reason %s, want %s", ctx.PendingRemoval[i].Reason, r)
+		}
+	}
+}
+
+// TestSimulateBatchDropsUnprofitableOp verifies that an op that doesn't cover its own cost is dropped.
+func TestSimulateBatchDropsUnprofitableOp(t *testing.T) {
+	a := simulateOp(testutils.ValidAddress2, 0, 300)
+	b := simulateOp(testutils.ValidAddress3, 0, 50)
+	c := simulateOp(testutils.ValidAddress4, 0, 300)
+
+	ctx := simulateBatch(t, a, b, c)
+	assertSimulatedBatch(t, ctx, []*userop.UserOperation{a, c}, "unprofitable op")
+	if ctx.PendingRemoval[0].Op != b {
+		t.Fatalf("got %s removed, want %s", ctx.PendingRemoval[0].Op.Sender, b.Sender)
+	}
+}
+
+// TestSimulateBatchDropsLowestMargin verifies that ops are dropped in order of lowest margin until the batch
+// is profitable.
+func TestSimulateBatchDropsLowestMargin(t *testing.T) {
+	a := simulateOp(testutils.ValidAddress2, 0, 300)
+	b := simulateOp(testutils.ValidAddress3, 0, 250)
+	c := simulateOp(testutils.ValidAddress4, 0, 210)
+
+	ctx := simulateBatch(t, a, b, c)
+	assertSimulatedBatch(t, ctx, []*userop.UserOperation{a, b}, "unprofitable batch")
+	if ctx.PendingRemoval[0].Op != c {
+		t.Fatalf("got %s removed, want %s", ctx.PendingRemoval[0].Op.Sender, c.Sender)
+	}
+}
+
+// TestSimulateBatchRevertPassthrough verifies that ops and batches that revert are left unchanged for the
+// downstream gas estimation to handle.
+func TestSimulateBatchRevertPassthrough(t *testing.T) {
+	a := simulateOp(testutils.ValidAddress2, 0, 300)
+	b := simulateOp(testutils.ValidAddress3, 0, revertCallGasLimit)
+
+	ctx := simulateBatch(t, a, b)
+	assertSimulatedBatch(t, ctx, []*userop.UserOperation{a, b})
+}
+
+// TestSimulateBatchRepairsSenderSequence verifies that later ops from the same sender are taken out of the
+// batch once an earlier op in the sequence is dropped.
+func TestSimulateBatchRepairsSenderSequence(t *testing.T) {
+	a0 := simulateOp(testutils.ValidAddress2, 0, 50)
+	a1 := simulateOp(testutils.ValidAddress2, 1, 300)
+	b := simulateOp(testutils.ValidAddress3, 0, 300)
+
+	ctx := simulateBatch(t, a0, a1, b)
+	assertSimulatedBatch(t, ctx, []*userop.UserOperation{b}, "unprofitable op")
+	if ctx.PendingRemoval[0].Op != a0 {
+		t.Fatalf("got nonce %s removed, want %s", ctx.PendingRemoval[0].Op.Nonce, a0.Nonce)
+	}
+}
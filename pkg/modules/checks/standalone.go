@@ -4,24 +4,31 @@ package checks
 
 import (
 	"math/big"
-	"time"
 
 	"github.com/dgraph-io/badger/v3"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/stackup-wallet/stackup-bundler/pkg/altmempools"
+	"github.com/stackup-wallet/stackup-bundler/pkg/clock"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/simulation"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
 	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
 	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/batch"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/gasprice"
+	"github.com/stackup-wallet/stackup-bundler/pkg/passkey"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 	"golang.org/x/sync/errgroup"
 )
 
+// SimulateBatchConcurrency is the maximum number of ops simulated in parallel by SimulateBatch.
+var SimulateBatchConcurrency = 8
+
 // Standalone exposes modules to perform basic Client and Bundler checks as specified in EIP-4337. It is
 // intended for bundlers that are independent of an Ethereum node and hence relies on a given ethClient to
 // query blockchain state.
@@ -36,6 +43,14 @@ type Standalone struct {
 	isRIP7212Supported bool
 	tracer             string
 	repConst           *entities.ReputationConstants
+	maxCallGasLimit    *big.Int
+	maxOpGas           *big.Int
+	skipAltMempoolLog  bool
+	verifiers          *passkey.Allowlist
+	minPriorityFees    *MinPriorityFees
+	profitModel        string
+	clock              clock.Clock
+	replacementFeeBump int64
 }
 
 // New returns a Standalone instance with methods that can be used in Client and Bundler modules to perform
@@ -63,15 +78,93 @@ func New(
 		isRIP7212Supported,
 		tracer,
 		repConst,
+		nil,
+		nil,
+		false,
+		nil,
+		nil,
+		gasprice.ProfitModelPriorityFee,
+		clock.Real(),
+		DefaultReplacementFeeBump,
 	}
 }
 
+// SetGasCeilings sets independent per-op ceilings for callGasLimit and the total gas of an op. These are
+// enforced in addition to maxVerificationGas and maxBatchGasLimit.
+//
+// The default values are nil. Setting a value to nil or 0 will disable the ceiling.
+func (s *Standalone) SetGasCeilings(maxCallGasLimit *big.Int, maxOpGas *big.Int) {
+	s.maxCallGasLimit = maxCallGasLimit
+	s.maxOpGas = maxOpGas
+}
+
+// SetPasskeyVerifiers sets an allowlist of P256/WebAuthn verifier contracts that will not be traced
+// step by step during simulation.
+//
+// The default value is nil.
+func (s *Standalone) SetPasskeyVerifiers(verifiers *passkey.Allowlist) {
+	s.verifiers = verifiers
+}
+
+// SetMinPriorityFees sets the minimum maxPriorityFeePerGas for ops that use a paymaster, deploy via initCode,
+// or are plain deployed-sender ops.
+//
+// The default value is nil, which disables all minimums.
+func (s *Standalone) SetMinPriorityFees(fees *MinPriorityFees) {
+	s.minPriorityFees = fees
+}
+
+// SetProfitModel sets the model used by SimulateBatch to project the gas price paid by the bundle
+// transaction. This should match the model used by the gasprice.FilterUnderpricedWithProfitModel module.
+//
+// The default value is gasprice.ProfitModelPriorityFee.
+func (s *Standalone) SetProfitModel(model string) {
+	s.profitModel = model
+}
+
+// SetClock sets the Clock used to check if the validity window of a UserOperation expires too soon.
+//
+// The default value is clock.Real().
+func (s *Standalone) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// SetReplacementFeeBump sets the percentage that both maxFeePerGas and maxPriorityFeePerGas must increase
+// by for an op to replace a pending op with the same sender and nonce.
+//
+// The default value is 10.
+func (s *Standalone) SetReplacementFeeBump(pct int64) {
+	s.replacementFeeBump = pct
+}
+
+// WithAltMempools returns a copy of the Standalone instance that uses a different set of alternative
+// mempools. This is useful for evaluating a new alternative mempool rule set in shadow mode. The copy does
+// not record applied alternative mempool exceptions so that it cannot overwrite records from the enforced
+// rule set.
+func (s *Standalone) WithAltMempools(alt *altmempools.Directory) *Standalone {
+	cpy := *s
+	cpy.alt = alt
+	cpy.skipAltMempoolLog = true
+	return &cpy
+}
+
+// GetAltMempoolExceptions returns the alternative mempool exceptions that were applied when a UserOperation
+// was accepted. An empty array is returned if the op did not require any exceptions.
+func (s *Standalone) GetAltMempoolExceptions(userOpHash common.Hash) ([]*altmempools.Exception, error) {
+	return getSavedAltMempoolExceptions(s.db, userOpHash)
+}
+
 // ValidateOpValues returns a UserOpHandler that runs through some first line sanity checks for new UserOps
 // received by the Client. This should be one of the first modules executed by the Client.
 func (s *Standalone) ValidateOpValues() modules.UserOpHandlerFunc {
 	return func(ctx *modules.UserOpHandlerCtx) error {
 		gc := getCodeWithEthClient(s.eth)
 
+		// Gas ceilings are checked first so that distinct error codes can be returned.
+		if err := ValidateGasCeilings(ctx.UserOp, s.maxCallGasLimit, s.maxOpGas); err != nil {
+			return err
+		}
+
 		g := new(errgroup.Group)
 		g.Go(func() error { return ValidateSender(ctx.UserOp, gc) })
 		g.Go(func() error { return ValidateInitCode(ctx.UserOp) })
@@ -79,10 +172,16 @@ func (s *Standalone) ValidateOpValues() modules.UserOpHandlerFunc {
 		g.Go(func() error { return ValidatePaymasterAndData(ctx.UserOp, ctx.GetPaymasterDepositInfo(), gc) })
 		g.Go(func() error { return ValidateCallGasLimit(ctx.UserOp, s.ov) })
 		g.Go(func() error { return ValidateFeePerGas(ctx.UserOp, gasprice.GetBaseFeeWithEthClient(s.eth)) })
-		g.Go(func() error { return ValidatePendingOps(ctx.UserOp, ctx.GetPendingSenderOps()) })
+		g.Go(func() error { return ValidateMinPriorityFee(ctx.UserOp, s.minPriorityFees) })
+		g.Go(func() error {
+			return ValidatePendingOpsWithFeeBump(ctx.UserOp, ctx.GetPendingSenderOps(), s.replacementFeeBump)
+		})
 		g.Go(func() error { return ValidateGasAvailable(ctx.UserOp, s.maxBatchGasLimit) })
 
 		if err := g.Wait(); err != nil {
+			if ru, ok := err.(*ReplacementUnderpricedError); ok {
+				return errors.NewRPCError(errors.INVALID_FIELDS, ru.Error(), ru.Data())
+			}
 			return errors.NewRPCError(errors.INVALID_FIELDS, err.Error(), err.Error())
 		}
 		return nil
@@ -97,7 +196,9 @@ func (s *Standalone) SimulateOp() modules.UserOpHandlerFunc {
 		g.Go(func() error {
 			sim, err := simulation.SimulateValidation(s.rpc, ctx.EntryPoint, ctx.UserOp)
 
-			if err != nil {
+			if _, ok := err.(*errors.RPCError); ok {
+				return err
+			} else if err != nil {
 				return errors.NewRPCError(errors.REJECTED_BY_EP_OR_ACCOUNT, err.Error(), err.Error())
 			}
 			if sim.ReturnInfo.SigFailed {
@@ -108,7 +209,7 @@ func (s *Standalone) SimulateOp() modules.UserOpHandlerFunc {
 				)
 			}
 			if sim.ReturnInfo.ValidUntil.Cmp(common.Big0) != 0 &&
-				time.Now().Unix() >= sim.ReturnInfo.ValidUntil.Int64()-30 {
+				s.clock.Now().Unix() >= sim.ReturnInfo.ValidUntil.Int64()-30 {
 				return errors.NewRPCError(
 					errors.SHORT_DEADLINE,
 					"expires too soon",
@@ -126,6 +227,7 @@ func (s *Standalone) SimulateOp() modules.UserOpHandlerFunc {
 				ChainID:            ctx.ChainID,
 				IsRIP7212Supported: s.isRIP7212Supported,
 				Tracer:             s.tracer,
+				Verifiers:          s.verifiers,
 				Stakes: simulation.EntityStakes{
 					ctx.UserOp.Sender:         ctx.GetSenderDepositInfo(),
 					ctx.UserOp.GetFactory():   ctx.GetFactoryDepositInfo(),
@@ -136,17 +238,80 @@ func (s *Standalone) SimulateOp() modules.UserOpHandlerFunc {
 				return errors.NewRPCError(errors.BANNED_OPCODE, err.Error(), err.Error())
 			}
 
+			hash := ctx.UserOp.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)
+			if len(out.AltMempoolExceptions) > 0 && !s.skipAltMempoolLog {
+				if err := saveAltMempoolExceptions(s.db, hash, out.AltMempoolExceptions); err != nil {
+					return err
+				}
+			}
+
 			ch, err := getCodeHashes(out.TouchedContracts, gc)
 			if err != nil {
 				return errors.NewRPCError(errors.BANNED_OPCODE, err.Error(), err.Error())
 			}
-			return saveCodeHashes(s.db, ctx.UserOp.GetUserOpHash(ctx.EntryPoint, ctx.ChainID), ch)
+			return saveCodeHashes(s.db, hash, ch)
 		})
 
 		return g.Wait()
 	}
 }
 
+// SimulateOpAtBlock runs full validation of a UserOperation against the state at a given block. This can be
+// used to reproduce why an op was accepted or rejected in the past and requires an archive node for blocks
+// older than the node's pruning window. Time based checks on the validity window are skipped.
+func (s *Standalone) SimulateOpAtBlock(
+	entryPoint common.Address,
+	chainID *big.Int,
+	op *userop.UserOperation,
+	blockNumber *big.Int,
+) (*simulation.ValidationReport, error) {
+	gs := stake.GetStakeAtBlockWithEthClient(s.eth, blockNumber)
+	stakes := simulation.EntityStakes{}
+	for _, addr := range []common.Address{op.Sender, op.GetFactory(), op.GetPaymaster()} {
+		dep, err := gs(entryPoint, addr)
+		if err != nil {
+			return nil, err
+		}
+		stakes[addr] = dep
+	}
+
+	sim, err := simulation.SimulateValidationAtBlock(s.rpc, entryPoint, op, blockNumber)
+	if _, ok := err.(*errors.RPCError); ok {
+		return nil, err
+	} else if err != nil {
+		return nil, errors.NewRPCError(errors.REJECTED_BY_EP_OR_ACCOUNT, err.Error(), err.Error())
+	}
+	if sim.ReturnInfo.SigFailed {
+		return nil, errors.NewRPCError(
+			errors.INVALID_SIGNATURE,
+			"Invalid UserOp signature or paymaster signature",
+			nil,
+		)
+	}
+
+	out, err := simulation.TraceSimulateValidation(&simulation.TraceInput{
+		Rpc:                s.rpc,
+		EntryPoint:         entryPoint,
+		AltMempools:        s.alt,
+		Op:                 op,
+		ChainID:            chainID,
+		IsRIP7212Supported: s.isRIP7212Supported,
+		Tracer:             s.tracer,
+		Verifiers:          s.verifiers,
+		Stakes:             stakes,
+		BlockNumber:        blockNumber,
+	})
+	if err != nil {
+		return nil, errors.NewRPCError(errors.BANNED_OPCODE, err.Error(), err.Error())
+	}
+
+	return &simulation.ValidationReport{
+		BlockNumber:   blockNumber,
+		ReturnInfo:    sim.ReturnInfo,
+		AltMempoolIds: out.AltMempoolIds,
+	}, nil
+}
+
 // CodeHashes returns a BatchHandler that verifies the code for any interacted contracts has not changed since
 // the first simulation.
 func (s *Standalone) CodeHashes() modules.BatchHandlerFunc {
@@ -208,9 +373,82 @@ func (s *Standalone) PaymasterDeposit() modules.BatchHandlerFunc {
 	}
 }
 
-// TODO: Implement
-func (s *Standalone) SimulateBatch() modules.BatchHandlerFunc {
+// SimulateBatch returns a BatchHandler that asserts the beneficiary's balance change from handleOps covers the
+// projected transaction cost. Each op is first simulated individually and dropped if it is unprofitable. If
+// the remaining batch is still unprofitable, ops are dropped in order of lowest margin until it is not. Ops
+// that cannot be simulated individually (e.g. sequential nonces from the same sender) are not assessed and any
+// batch that reverts is left for the downstream gas estimation to handle. After ops are dropped, sender
+// sequences are repaired with batch.SortBySenderSequence so that later ops from the same sender are not
+// simulated or sent without the ones they depend on.
+func (s *Standalone) SimulateBatch(beneficiary common.Address) modules.BatchHandlerFunc {
+	repair := batch.SortBySenderSequence()
 	return func(ctx *modules.BatchHandlerCtx) error {
+		egp := gasprice.EffectiveGasPrice(s.profitModel, ctx.BaseFee, ctx.Tip, ctx.GasPrice, ctx.Batch)
+		if len(ctx.Batch) == 0 || egp == nil {
+			return nil
+		}
+		opts := func(ops []*userop.UserOperation) *transaction.Opts {
+			return &transaction.Opts{
+				Eth:         s.eth,
+				ChainID:     ctx.ChainID,
+				EntryPoint:  ctx.EntryPoint,
+				Batch:       ops,
+				Beneficiary: beneficiary,
+			}
+		}
+
+		results := make([]*big.Int, len(ctx.Batch))
+		g := new(errgroup.Group)
+		g.SetLimit(SimulateBatchConcurrency)
+		for i, op := range ctx.Batch {
+			i, op := i, op
+			g.Go(func() error {
+				if c, err := transaction.SimulateCompensation(s.rpc, opts([]*userop.UserOperation{op}), egp); err == nil {
+					results[i] = c.Margin()
+				}
+				return nil
+			})
+		}
+		_ = g.Wait()
+
+		margins := make(map[*userop.UserOperation]*big.Int)
+		for i, op := range ctx.Batch {
+			margins[op] = results[i]
+		}
+		dropped := false
+		for i := len(ctx.Batch) - 1; i >= 0; i-- {
+			if m := margins[ctx.Batch[i]]; m != nil && m.Sign() < 0 {
+				ctx.MarkOpIndexForRemoval(i, "unprofitable op")
+				dropped = true
+			}
+		}
+
+		for len(ctx.Batch) > 0 {
+			if dropped {
+				if err := repair(ctx); err != nil {
+					return err
+				}
+				if len(ctx.Batch) == 0 {
+					return nil
+				}
+			}
+
+			c, err := transaction.SimulateCompensation(s.rpc, opts(ctx.Batch), egp)
+			if err != nil || c.IsProfitable() {
+				return nil
+			}
+
+			idx := len(ctx.Batch) - 1
+			for i, op := range ctx.Batch {
+				m, lowest := margins[op], margins[ctx.Batch[idx]]
+				if m != nil && (lowest == nil || m.Cmp(lowest) < 0) {
+					idx = i
+				}
+			}
+			ctx.MarkOpIndexForRemoval(idx, "unprofitable batch")
+			dropped = true
+		}
+
 		return nil
 	}
 }
@@ -1,5 +1,12 @@
 package modules
 
+import (
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
 // ComposeBatchHandlerFunc combines many BatchHandlers into one.
 func ComposeBatchHandlerFunc(fns ...BatchHandlerFunc) BatchHandlerFunc {
 	return func(ctx *BatchHandlerCtx) error {
@@ -27,3 +34,17 @@ func ComposeUserOpHandlerFunc(fns ...UserOpHandlerFunc) UserOpHandlerFunc {
 		return nil
 	}
 }
+
+var handlerNameSuffix = regexp.MustCompile(`(\.func\d+)+(\.\d+)*$`)
+
+// HandlerName returns a short name for a module handler based on the function that created it. For example, a
+// handler returned by (*entities.Reputation).CheckStatus will be named "entities.CheckStatus".
+func HandlerName(fn any) string {
+	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	name = name[strings.LastIndex(name, "/")+1:]
+	name = handlerNameSuffix.ReplaceAllString(name, "")
+	if i, j := strings.Index(name, ".("), strings.Index(name, ")."); i >= 0 && j > i {
+		name = name[:i+1] + name[j+2:]
+	}
+	return name
+}
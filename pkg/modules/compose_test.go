@@ -0,0 +1,28 @@
+package modules
+
+import "testing"
+
+type testModule struct{}
+
+func (m *testModule) Handler() BatchHandlerFunc {
+	return func(ctx *BatchHandlerCtx) error {
+		return nil
+	}
+}
+
+func testHandler() UserOpHandlerFunc {
+	return func(ctx *UserOpHandlerCtx) error {
+		return nil
+	}
+}
+
+// TestHandlerName verifies that HandlerName returns a short name for both method and function based handlers.
+func TestHandlerName(t *testing.T) {
+	m := &testModule{}
+	if name := HandlerName(m.Handler()); name != "modules.Handler" {
+		t.Fatalf("got %s, want modules.Handler", name)
+	}
+	if name := HandlerName(testHandler()); name != "modules.testHandler" {
+		t.Fatalf("got %s, want modules.testHandler", name)
+	}
+}
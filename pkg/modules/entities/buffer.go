@@ -0,0 +1,212 @@
+package entities
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/dbutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+var (
+	flushedAtKey = []byte(dbutils.JoinValues(KeyPrefix, "flushedAt"))
+)
+
+// GetOpsAfterVersionFunc returns all persisted UserOperations that were written to the DB after the given
+// version.
+type GetOpsAfterVersionFunc = func(version uint64) ([]*userop.UserOperation, error)
+
+type opsCountDelta struct {
+	seen     int
+	included int
+}
+
+// writeBuffer holds reputation increments in memory so that they can be written to the DB in a single
+// transaction instead of one per UserOperation.
+type writeBuffer struct {
+	mu      sync.Mutex
+	deltas  map[common.Address]*opsCountDelta
+	size    int
+	maxSize int
+}
+
+func newWriteBuffer(maxSize int) *writeBuffer {
+	return &writeBuffer{deltas: make(map[common.Address]*opsCountDelta), maxSize: maxSize}
+}
+
+func (b *writeBuffer) get(entity common.Address) *opsCountDelta {
+	d, ok := b.deltas[entity]
+	if !ok {
+		d = &opsCountDelta{}
+		b.deltas[entity] = d
+	}
+	return d
+}
+
+// addSeen buffers an opsSeen increment for each entity and returns true if the buffer has reached its max
+// size.
+func (b *writeBuffer) addSeen(entities ...common.Address) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, entity := range entities {
+		b.get(entity).seen++
+	}
+	b.size++
+	return b.size >= b.maxSize
+}
+
+func (b *writeBuffer) addIncluded(count addressCounter) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for entity, n := range count {
+		b.get(entity).included += n
+	}
+}
+
+// swap returns all buffered increments and the number of UserOperations they were buffered for, and resets
+// the buffer.
+func (b *writeBuffer) swap() (map[common.Address]*opsCountDelta, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	deltas, size := b.deltas, b.size
+	b.deltas = make(map[common.Address]*opsCountDelta)
+	b.size = 0
+	return deltas, size
+}
+
+// merge adds increments from a previous swap back to the buffer. It is used when they could not be written
+// so that they are retried on the next flush.
+func (b *writeBuffer) merge(deltas map[common.Address]*opsCountDelta, size int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for entity, d := range deltas {
+		bd := b.get(entity)
+		bd.seen += d.seen
+		bd.included += d.included
+	}
+	b.size += size
+}
+
+func applyOpsCountDeltas(txn *badger.Txn, deltas map[common.Address]*opsCountDelta, now time.Time) error {
+	for entity, d := range deltas {
+		opsSeen, opsIncluded, err := getOpsCountByEntity(txn, entity, now)
+		if err != nil {
+			return err
+		}
+
+		e := badger.NewEntry(
+			getOpsCountKey(entity),
+			getOpsCountValue(opsSeen+d.seen, opsIncluded+d.included, now),
+		)
+		if err := txn.SetEntry(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setFlushedAt records the read timestamp of the flush transaction. Any UserOperation persisted after this
+// version may have an opsSeen increment that was never flushed.
+func setFlushedAt(txn *badger.Txn) error {
+	return txn.Set(flushedAtKey, []byte(strconv.FormatUint(txn.ReadTs(), 10)))
+}
+
+func getFlushedAt(txn *badger.Txn) (version uint64, found bool, err error) {
+	item, err := txn.Get(flushedAtKey)
+	if err != nil && err == badger.ErrKeyNotFound {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+
+	err = item.Value(func(val []byte) error {
+		version, err = strconv.ParseUint(string(val), 10, 64)
+		return err
+	})
+	return version, true, err
+}
+
+func getEntities(op *userop.UserOperation) []common.Address {
+	entities := []common.Address{op.Sender}
+	if factory := op.GetFactory(); factory != common.HexToAddress("0x") {
+		entities = append(entities, factory)
+	}
+	if paymaster := op.GetPaymaster(); paymaster != common.HexToAddress("0x") {
+		entities = append(entities, paymaster)
+	}
+	return entities
+}
+
+// SetWriteBuffer enables buffering of the opsSeen and opsIncluded counters in memory. Buffered increments
+// are written in a single transaction once maxSize UserOperations have been seen, a batch has been included,
+// or Flush is called. Until then, they are not reflected in an entity's status.
+func (r *Reputation) SetWriteBuffer(maxSize int) {
+	r.buf = newWriteBuffer(maxSize)
+}
+
+// Flush writes all buffered increments to the DB. If the write fails, the increments are kept in the buffer
+// for the next flush.
+func (r *Reputation) Flush() error {
+	if r.buf == nil {
+		return nil
+	}
+
+	deltas, size := r.buf.swap()
+	err := r.db.Update(func(txn *badger.Txn) error {
+		if err := applyOpsCountDeltas(txn, deltas, r.clock.Now()); err != nil {
+			return err
+		}
+		return setFlushedAt(txn)
+	})
+	if err != nil {
+		r.buf.merge(deltas, size)
+	}
+	return err
+}
+
+// Replay increments the opsSeen counters for all UserOperations that were persisted after the last flush.
+// This recovers increments that were still buffered when the process stopped. An increment that was flushed
+// concurrently with its UserOperation being persisted may be counted twice.
+func (r *Reputation) Replay(getOps GetOpsAfterVersionFunc) (int, error) {
+	var version uint64
+	var found bool
+	if err := r.db.View(func(txn *badger.Txn) error {
+		var err error
+		version, found, err = getFlushedAt(txn)
+		return err
+	}); err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, r.db.Update(setFlushedAt)
+	}
+
+	ops, err := getOps(version)
+	if err != nil {
+		return 0, err
+	}
+
+	deltas := make(map[common.Address]*opsCountDelta)
+	for _, op := range ops {
+		for _, entity := range getEntities(op) {
+			if _, ok := deltas[entity]; !ok {
+				deltas[entity] = &opsCountDelta{}
+			}
+			deltas[entity].seen++
+		}
+	}
+	return len(ops), r.db.Update(func(txn *badger.Txn) error {
+		if err := applyOpsCountDeltas(txn, deltas, r.clock.Now()); err != nil {
+			return err
+		}
+		return setFlushedAt(txn)
+	})
+}
@@ -0,0 +1,158 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func getOpsCount(t *testing.T, r *Reputation, entity common.Address) (int, int) {
+	var seen, included int
+	if err := r.db.Update(func(txn *badger.Txn) error {
+		var err error
+		seen, included, err = getOpsCountByEntity(txn, entity, r.clock.Now())
+		return err
+	}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	return seen, included
+}
+
+// TestWriteBufferFlushesOnSize verifies that opsSeen increments are only written once the buffer is full.
+func TestWriteBufferFlushesOnSize(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	c := validConstants()
+	r := New(db, nil, &c)
+	r.SetWriteBuffer(2)
+
+	op := testutils.MockValidInitUserOp()
+	ctx := &modules.UserOpHandlerCtx{UserOp: op, EntryPoint: testutils.ValidAddress1, ChainID: testutils.ChainID}
+	if err := r.IncOpsSeen()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if seen, _ := getOpsCount(t, r, op.Sender); seen != 0 {
+		t.Fatalf("got %d, want 0", seen)
+	}
+
+	if err := r.IncOpsSeen()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if seen, _ := getOpsCount(t, r, op.Sender); seen != 2 {
+		t.Fatalf("got %d, want 2", seen)
+	}
+	if seen, _ := getOpsCount(t, r, op.GetFactory()); seen != 2 {
+		t.Fatalf("got %d, want 2", seen)
+	}
+}
+
+// TestWriteBufferFlushesOnInclusion verifies that a sent batch writes all buffered increments together.
+func TestWriteBufferFlushesOnInclusion(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	c := validConstants()
+	r := New(db, nil, &c)
+	r.SetWriteBuffer(100)
+
+	op := testutils.MockValidInitUserOp()
+	ctx := &modules.UserOpHandlerCtx{UserOp: op, EntryPoint: testutils.ValidAddress1, ChainID: testutils.ChainID}
+	if err := r.IncOpsSeen()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	bctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{op},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+	if err := r.IncOpsIncluded()(bctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if seen, included := getOpsCount(t, r, op.Sender); seen != 1 || included != 1 {
+		t.Fatalf("got %d/%d, want 1/1", seen, included)
+	}
+}
+
+// TestReplayUnflushedOps verifies that ops persisted after the last flush have their opsSeen increments
+// recovered on startup.
+func TestReplayUnflushedOps(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	c := validConstants()
+	r := New(db, nil, &c)
+	r.SetWriteBuffer(100)
+	mem, err := mempool.New(db)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if n, err := r.Replay(mem.DumpAfterVersion); err != nil || n != 0 {
+		t.Fatalf("got %d, %v, want 0, nil", n, err)
+	}
+
+	op := testutils.MockValidInitUserOp()
+	ctx := &modules.UserOpHandlerCtx{UserOp: op, EntryPoint: testutils.ValidAddress1, ChainID: testutils.ChainID}
+	if err := r.IncOpsSeen()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := mem.AddOp(testutils.ValidAddress1, op); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	// Simulate a restart where the buffered increment was lost.
+	r = New(db, nil, &c)
+	r.SetWriteBuffer(100)
+	if n, err := r.Replay(mem.DumpAfterVersion); err != nil || n != 1 {
+		t.Fatalf("got %d, %v, want 1, nil", n, err)
+	}
+	if seen, _ := getOpsCount(t, r, op.Sender); seen != 1 {
+		t.Fatalf("got %d, want 1", seen)
+	}
+
+	if n, err := r.Replay(mem.DumpAfterVersion); err != nil || n != 0 {
+		t.Fatalf("got %d, %v, want 0, nil", n, err)
+	}
+}
+
+// TestWriteBufferKeepsDeltasOnFailedFlush verifies that buffered increments are not lost if they could not be
+// written to the DB.
+func TestWriteBufferKeepsDeltasOnFailedFlush(t *testing.T) {
+	db := testutils.DBMock()
+	c := validConstants()
+	r := New(db, nil, &c)
+	r.SetWriteBuffer(100)
+
+	op := testutils.MockValidInitUserOp()
+	ctx := &modules.UserOpHandlerCtx{UserOp: op, EntryPoint: testutils.ValidAddress1, ChainID: testutils.ChainID}
+	if err := r.IncOpsSeen()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	r.buf.addIncluded(addressCounter{op.Sender: 1})
+	if err := r.IncOpsSeen()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	db.Close()
+	if err := r.Flush(); err == nil {
+		t.Fatal("got nil, want error")
+	}
+
+	deltas, size := r.buf.swap()
+	if size != 2 {
+		t.Fatalf("got size %d, want 2", size)
+	}
+	if d := deltas[op.Sender]; d == nil || d.seen != 2 || d.included != 1 {
+		t.Fatalf("got %+v, want 2 seen and 1 included", d)
+	}
+	if d := deltas[op.GetFactory()]; d == nil || d.seen != 2 {
+		t.Fatalf("got %+v, want 2 seen", d)
+	}
+}
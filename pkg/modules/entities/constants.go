@@ -0,0 +1,106 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stackup-wallet/stackup-bundler/internal/dbutils"
+)
+
+var constantsAuditPrefix = dbutils.JoinValues(KeyPrefix, "constantsAudit")
+
+// ConstantsChange is an audit record for a runtime change to the ReputationConstants.
+type ConstantsChange struct {
+	Time     time.Time           `json:"time"`
+	Actor    string              `json:"actor"`
+	Previous ReputationConstants `json:"previous"`
+	Next     ReputationConstants `json:"next"`
+}
+
+// Validate returns an error if the ReputationConstants would result in an invalid reputation state.
+func (c *ReputationConstants) Validate() error {
+	switch {
+	case c.MinInclusionRateDenominator <= 0:
+		return errors.New("constants: minInclusionRateDenominator must be greater than 0")
+	case c.ThrottlingSlack < 0:
+		return errors.New("constants: throttlingSlack must not be negative")
+	case c.BanSlack < c.ThrottlingSlack:
+		return errors.New("constants: banSlack must not be less than throttlingSlack")
+	case c.SameSenderMempoolCount <= 0:
+		return errors.New("constants: sameSenderMempoolCount must be greater than 0")
+	case c.SameUnstakedEntityMempoolCount <= 0:
+		return errors.New("constants: sameUnstakedEntityMempoolCount must be greater than 0")
+	case c.ThrottledEntityMempoolCount <= 0:
+		return errors.New("constants: throttledEntityMempoolCount must be greater than 0")
+	case c.MinUnstakeDelay < 0 || c.MinStakeValue < 0:
+		return errors.New("constants: minimum stake values must not be negative")
+	}
+	return nil
+}
+
+func getConstantsAuditKey(t time.Time) []byte {
+	return []byte(dbutils.JoinValues(constantsAuditPrefix, fmt.Sprintf("%020d", t.UnixNano())))
+}
+
+// GetConstants returns a copy of the ReputationConstants currently in use.
+func (r *Reputation) GetConstants() ReputationConstants {
+	return *r.repConst.Load()
+}
+
+// SetConstants validates and swaps in a new set of ReputationConstants. All modules will use the new values
+// from their next evaluation onwards while evaluations in progress will complete with the previous values.
+// The change is recorded in an audit trail along with the given actor. Changes are not persisted across
+// restarts.
+func (r *Reputation) SetConstants(next ReputationConstants, actor string) (*ConstantsChange, error) {
+	if err := next.Validate(); err != nil {
+		return nil, err
+	}
+
+	change := &ConstantsChange{
+		Time:     r.clock.Now(),
+		Actor:    actor,
+		Previous: r.GetConstants(),
+		Next:     next,
+	}
+	data, err := json.Marshal(change)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(getConstantsAuditKey(change.Time), data)
+	}); err != nil {
+		return nil, err
+	}
+
+	r.repConst.Store(&next)
+	return change, nil
+}
+
+// GetConstantsAudit returns all recorded changes to the ReputationConstants from oldest to newest.
+func (r *Reputation) GetConstantsAudit() ([]*ConstantsChange, error) {
+	changes := []*ConstantsChange{}
+	err := r.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefix := []byte(dbutils.JoinValues(constantsAuditPrefix, ""))
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			if err := it.Item().Value(func(val []byte) error {
+				var c ConstantsChange
+				if err := json.Unmarshal(val, &c); err != nil {
+					return err
+				}
+				changes = append(changes, &c)
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return changes, err
+}
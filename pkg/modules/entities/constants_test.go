@@ -0,0 +1,81 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/clock"
+)
+
+func validConstants() ReputationConstants {
+	return ReputationConstants{
+		MinUnstakeDelay:                86400,
+		MinStakeValue:                  2000000000000000,
+		SameSenderMempoolCount:         4,
+		SameUnstakedEntityMempoolCount: 11,
+		ThrottledEntityMempoolCount:    4,
+		ThrottledEntityLiveBlocks:      10,
+		ThrottledEntityBundleCount:     4,
+		MinInclusionRateDenominator:    10,
+		ThrottlingSlack:                10,
+		BanSlack:                       50,
+	}
+}
+
+func TestValidateConstants(t *testing.T) {
+	c := validConstants()
+	if err := c.Validate(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
+
+func TestValidateConstantsZeroDenominator(t *testing.T) {
+	c := validConstants()
+	c.MinInclusionRateDenominator = 0
+	if err := c.Validate(); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
+
+func TestValidateConstantsBanSlackBelowThrottlingSlack(t *testing.T) {
+	c := validConstants()
+	c.BanSlack = c.ThrottlingSlack - 1
+	if err := c.Validate(); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
+
+func TestSetConstantsRecordsAudit(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	prev := validConstants()
+	r := New(db, nil, &prev)
+	m := clock.NewMock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	r.SetClock(m)
+
+	next := validConstants()
+	next.ThrottlingSlack = 20
+	if _, err := r.SetConstants(next, "tester"); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if got := r.GetConstants(); got != next {
+		t.Fatalf("got %+v, want %+v", got, next)
+	}
+
+	next.BanSlack = 0
+	if _, err := r.SetConstants(next, "tester"); err == nil {
+		t.Fatal("got nil, want err for invalid constants")
+	}
+
+	audit, err := r.GetConstantsAudit()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if len(audit) != 1 || audit[0].Actor != "tester" || audit[0].Previous != prev {
+		t.Fatalf("got %+v, want a single audit record from tester", audit)
+	}
+	if !audit[0].Time.Equal(m.Now()) {
+		t.Fatalf("got time %s, want %s", audit[0].Time, m.Now())
+	}
+}
@@ -0,0 +1,54 @@
+package entities
+
+import (
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// GasPriceDiscount sets the requirements for an entity to be considered reliable and the discount applied to
+// the minimum gas price of its userOps.
+type GasPriceDiscount struct {
+	// Percent is the discount applied to the minimum gas price for reliable entities.
+	Percent int64
+
+	// MinOpsIncluded is the minimum number of ops an entity must have had included.
+	MinOpsIncluded int
+
+	// MinInclusionPercent is the minimum percentage of ops seen that must have been included.
+	MinInclusionPercent int
+}
+
+func (d *GasPriceDiscount) isReliable(opsSeen int, opsIncluded int) bool {
+	return opsIncluded >= d.MinOpsIncluded && opsIncluded*100 >= opsSeen*d.MinInclusionPercent
+}
+
+// GetGasPriceDiscountFunc returns a function that can be used with gasprice.FilterUnderpricedWithDiscount.
+// The entity paying for the userOp is checked, which is the paymaster if one is set and the sender otherwise.
+// Unknown entities and entities without a sufficient inclusion history receive no discount.
+func (r *Reputation) GetGasPriceDiscountFunc(d *GasPriceDiscount) func(op *userop.UserOperation) (int64, error) {
+	return func(op *userop.UserOperation) (int64, error) {
+		if d.Percent <= 0 {
+			return 0, nil
+		}
+
+		payer := op.Sender
+		if pm := op.GetPaymaster(); pm != common.HexToAddress("0x") {
+			payer = pm
+		}
+
+		var reliable bool
+		err := r.db.Update(func(txn *badger.Txn) error {
+			opsSeen, opsIncluded, err := getOpsCountByEntity(txn, payer, r.clock.Now())
+			if err != nil {
+				return err
+			}
+			reliable = d.isReliable(opsSeen, opsIncluded)
+			return nil
+		})
+		if err != nil || !reliable {
+			return 0, err
+		}
+		return d.Percent, nil
+	}
+}
@@ -0,0 +1,63 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+)
+
+func TestGasPriceDiscountForReliablePayer(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	c := validConstants()
+	r := New(db, nil, &c)
+	d := &GasPriceDiscount{Percent: 10, MinOpsIncluded: 100, MinInclusionPercent: 95}
+
+	op := testutils.MockValidInitUserOp()
+	if err := r.Override([]*ReputationOverride{
+		{Address: op.Sender, OpsSeen: 200, OpsIncluded: 195},
+	}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if p, err := r.GetGasPriceDiscountFunc(d)(op); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if p != 10 {
+		t.Fatalf("got %d, want 10", p)
+	}
+}
+
+func TestGasPriceDiscountForUnknownPayer(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	c := validConstants()
+	r := New(db, nil, &c)
+	d := &GasPriceDiscount{Percent: 10, MinOpsIncluded: 100, MinInclusionPercent: 95}
+
+	if p, err := r.GetGasPriceDiscountFunc(d)(testutils.MockValidInitUserOp()); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if p != 0 {
+		t.Fatalf("got %d, want 0", p)
+	}
+}
+
+func TestGasPriceDiscountForLowInclusionRate(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	c := validConstants()
+	r := New(db, nil, &c)
+	d := &GasPriceDiscount{Percent: 10, MinOpsIncluded: 100, MinInclusionPercent: 95}
+
+	op := testutils.MockValidInitUserOp()
+	if err := r.Override([]*ReputationOverride{
+		{Address: op.Sender, OpsSeen: 400, OpsIncluded: 200},
+	}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if p, err := r.GetGasPriceDiscountFunc(d)(op); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if p != 0 {
+		t.Fatalf("got %d, want 0", p)
+	}
+}
@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Ban sets an entity to a banned status regardless of its opsSeen and opsIncluded counters. The entity stays
+// banned until Unban is called and is not restored by ReviewBannedEntities.
+func (r *Reputation) Ban(entity common.Address) error {
+	return r.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(getManualBanKey(entity), []byte{})
+	})
+}
+
+// Unban removes a manual ban on an entity. If the entity would still be banned by its counters, they are
+// reset so that it starts again with an ok status.
+func (r *Reputation) Unban(entity common.Address) error {
+	repConst := r.repConst.Load()
+	return r.db.Update(func(txn *badger.Txn) error {
+		now := r.clock.Now()
+		if err := txn.Delete(getManualBanKey(entity)); err != nil {
+			return err
+		}
+
+		if s, err := getStatus(txn, entity, repConst, now); err != nil {
+			return err
+		} else if s != banned {
+			return nil
+		}
+		if err := overrideEntity(txn, &ReputationOverride{Address: entity}, now); err != nil {
+			return err
+		}
+		return removeBannedAtByEntity(txn, entity)
+	})
+}
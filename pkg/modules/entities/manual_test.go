@@ -0,0 +1,53 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+)
+
+// TestBanAndUnban verifies that a manual ban overrides an ok status and that unbanning restores it.
+func TestBanAndUnban(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	c := validConstants()
+	r := New(db, nil, &c)
+
+	if err := r.Ban(testutils.ValidAddress1); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if s, err := r.GetStatus(testutils.ValidAddress1); err != nil || s != "banned" {
+		t.Fatalf("got %s, %v, want banned, nil", s, err)
+	}
+
+	if err := r.Unban(testutils.ValidAddress1); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if s, err := r.GetStatus(testutils.ValidAddress1); err != nil || s != "ok" {
+		t.Fatalf("got %s, %v, want ok, nil", s, err)
+	}
+}
+
+// TestUnbanResetsCounters verifies that unbanning an entity banned by its counters gives it a fresh start.
+func TestUnbanResetsCounters(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	c := validConstants()
+	r := New(db, nil, &c)
+
+	if err := r.Override([]*ReputationOverride{
+		{Address: testutils.ValidAddress1, OpsSeen: 10000, OpsIncluded: 0},
+	}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if s, err := r.GetStatus(testutils.ValidAddress1); err != nil || s != "banned" {
+		t.Fatalf("got %s, %v, want banned, nil", s, err)
+	}
+
+	if err := r.Unban(testutils.ValidAddress1); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if s, err := r.GetStatus(testutils.ValidAddress1); err != nil || s != "ok" {
+		t.Fatalf("got %s, %v, want ok, nil", s, err)
+	}
+}
@@ -5,10 +5,14 @@ package entities
 import (
 	stdErr "errors"
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/dgraph-io/badger/v3"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/pkg/clock"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
 	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
 )
@@ -18,12 +22,41 @@ import (
 type Reputation struct {
 	db       *badger.DB
 	eth      *ethclient.Client
-	repConst *ReputationConstants
+	repConst atomic.Pointer[ReputationConstants]
+	buf      *writeBuffer
+	clock    clock.Clock
 }
 
 // New returns an instance of a Reputation object to track and appropriately process userOps by entity status.
 func New(db *badger.DB, eth *ethclient.Client, repConst *ReputationConstants) *Reputation {
-	return &Reputation{db, eth, repConst}
+	r := &Reputation{db: db, eth: eth, clock: clock.Real()}
+	r.repConst.Store(repConst)
+	return r
+}
+
+// SetClock sets the Clock used to track how long entities have been banned.
+//
+// The default value is clock.Real().
+func (r *Reputation) SetClock(c clock.Clock) {
+	r.clock = c
+}
+
+// GetStatus returns the current status of an entity as either "ok", "throttled", or "banned".
+func (r *Reputation) GetStatus(entity common.Address) (string, error) {
+	repConst := r.repConst.Load()
+	var s status
+	err := r.db.Update(func(txn *badger.Txn) error {
+		var err error
+		s, err = getStatus(txn, entity, repConst, r.clock.Now())
+		return err
+	})
+	return s.String(), err
+}
+
+// IsStaked returns true if the deposit info meets the minimum stake and unstake delay required by the current
+// reputation constants.
+func (r *Reputation) IsStaked(dep *entrypoint.IStakeManagerDepositInfo) bool {
+	return isStaked(dep, r.repConst.Load())
 }
 
 // CheckStatus returns a UserOpHandler that is used by the Client to determine if the userOp is allowed based
@@ -32,65 +65,78 @@ func New(db *badger.DB, eth *ethclient.Client, repConst *ReputationConstants) *R
 //  2. throttled: No new ops from the entity is allowed if one already exists. And it can only stays in
 //     the pool for 10 blocks
 //  3. banned: No ops from the entity is allowed
+//
+// Rejected ops from banned entities are counted for ReviewBannedEntities.
 func (r *Reputation) CheckStatus() modules.UserOpHandlerFunc {
 	return func(ctx *modules.UserOpHandlerCtx) error {
-		return r.db.Update(func(txn *badger.Txn) error {
-			if status, err := getStatus(txn, ctx.UserOp.Sender, r.repConst); err != nil {
+		repConst := r.repConst.Load()
+		var rejected error
+		err := r.db.Update(func(txn *badger.Txn) error {
+			now := r.clock.Now()
+			var err error
+			rejected, err = checkEntityStatus(txn, ctx.UserOp.Sender, len(ctx.GetPendingSenderOps()), repConst, now)
+			if err != nil || rejected != nil {
 				return err
-			} else if status == banned {
-				return errors.NewRPCError(
-					errors.BANNED_OR_THROTTLED_ENTITY,
-					fmt.Sprintf("banned entity: %s", ctx.UserOp.Sender.Hex()),
-					nil,
-				)
-			} else if status == throttled && len(ctx.GetPendingSenderOps()) == r.repConst.ThrottledEntityMempoolCount {
-				return errors.NewRPCError(
-					errors.BANNED_OR_THROTTLED_ENTITY,
-					fmt.Sprintf("throttled entity: %s", ctx.UserOp.Sender.Hex()),
-					nil,
-				)
 			}
 
 			factory := ctx.UserOp.GetFactory()
 			if factory != common.HexToAddress("0x") {
-				if status, err := getStatus(txn, factory, r.repConst); err != nil {
+				rejected, err = checkEntityStatus(txn, factory, len(ctx.GetPendingFactoryOps()), repConst, now)
+				if err != nil || rejected != nil {
 					return err
-				} else if status == banned {
-					return errors.NewRPCError(
-						errors.BANNED_OR_THROTTLED_ENTITY,
-						fmt.Sprintf("banned entity: %s", factory.Hex()),
-						nil,
-					)
-				} else if status == throttled && len(ctx.GetPendingFactoryOps()) == r.repConst.ThrottledEntityMempoolCount {
-					return errors.NewRPCError(
-						errors.BANNED_OR_THROTTLED_ENTITY,
-						fmt.Sprintf("throttled entity: %s", factory.Hex()),
-						nil,
-					)
 				}
 			}
 
 			paymaster := ctx.UserOp.GetPaymaster()
 			if paymaster != common.HexToAddress("0x") {
-				if status, err := getStatus(txn, paymaster, r.repConst); err != nil {
+				rejected, err = checkEntityStatus(txn, paymaster, len(ctx.GetPendingPaymasterOps()), repConst, now)
+				if err != nil || rejected != nil {
 					return err
-				} else if status == banned {
-					return errors.NewRPCError(
-						errors.BANNED_OR_THROTTLED_ENTITY,
-						fmt.Sprintf("banned entity: %s", paymaster.Hex()),
-						nil,
-					)
-				} else if status == throttled && len(ctx.GetPendingPaymasterOps()) == r.repConst.ThrottledEntityMempoolCount {
-					return errors.NewRPCError(
-						errors.BANNED_OR_THROTTLED_ENTITY,
-						fmt.Sprintf("throttled entity: %s", paymaster.Hex()),
-						nil,
-					)
 				}
 			}
 
 			return nil
 		})
+		if err != nil {
+			return err
+		}
+		return rejected
+	}
+}
+
+// checkEntityStatus returns an RPC error if the entity is banned or if it is throttled and already has the max
+// number of pending ops. The error is returned as a value instead of failing the transaction so that the
+// rejection of a banned entity is saved.
+func checkEntityStatus(
+	txn *badger.Txn,
+	entity common.Address,
+	pending int,
+	repConst *ReputationConstants,
+	now time.Time,
+) (rejected error, err error) {
+	status, err := getStatus(txn, entity, repConst, now)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case status == banned:
+		if err := incrementRejectedByEntity(txn, entity); err != nil {
+			return nil, err
+		}
+		return errors.NewRPCError(
+			errors.BANNED_OR_THROTTLED_ENTITY,
+			fmt.Sprintf("banned entity: %s", entity.Hex()),
+			nil,
+		), nil
+	case status == throttled && pending == repConst.ThrottledEntityMempoolCount:
+		return errors.NewRPCError(
+			errors.BANNED_OR_THROTTLED_ENTITY,
+			fmt.Sprintf("throttled entity: %s", entity.Hex()),
+			nil,
+		), nil
+	default:
+		return nil, nil
 	}
 }
 
@@ -98,15 +144,16 @@ func (r *Reputation) CheckStatus() modules.UserOpHandlerFunc {
 // based on the entities stake and the number of pending ops in the mempool.
 func (r *Reputation) ValidateOpLimit() modules.UserOpHandlerFunc {
 	return func(ctx *modules.UserOpHandlerCtx) error {
+		repConst := r.repConst.Load()
 		pso := ctx.GetPendingSenderOps()
 		sd := ctx.GetSenderDepositInfo()
-		if !sd.Staked && len(pso) == r.repConst.SameSenderMempoolCount {
+		if !sd.Staked && len(pso) == repConst.SameSenderMempoolCount {
 			return errors.NewRPCError(
 				errors.INVALID_ENTITY_STAKE,
 				fmt.Sprintf(
 					"unstaked entity: %s exceeds pending ops limit of %d",
 					ctx.UserOp.Sender.Hex(),
-					r.repConst.SameSenderMempoolCount,
+					repConst.SameSenderMempoolCount,
 				),
 				nil,
 			)
@@ -116,13 +163,13 @@ func (r *Reputation) ValidateOpLimit() modules.UserOpHandlerFunc {
 		if factory != common.HexToAddress("0x") {
 			pfo := ctx.GetPendingFactoryOps()
 			fd := ctx.GetFactoryDepositInfo()
-			if !fd.Staked && len(pfo) == r.repConst.SameUnstakedEntityMempoolCount {
+			if !fd.Staked && len(pfo) == repConst.SameUnstakedEntityMempoolCount {
 				return errors.NewRPCError(
 					errors.INVALID_ENTITY_STAKE,
 					fmt.Sprintf(
 						"unstaked entity: %s exceeds pending ops limit of %d",
 						factory.Hex(),
-						r.repConst.SameUnstakedEntityMempoolCount,
+						repConst.SameUnstakedEntityMempoolCount,
 					),
 					nil,
 				)
@@ -133,13 +180,13 @@ func (r *Reputation) ValidateOpLimit() modules.UserOpHandlerFunc {
 		if paymaster != common.HexToAddress("0x") {
 			ppo := ctx.GetPendingPaymasterOps()
 			pd := ctx.GetPaymasterDepositInfo()
-			if !pd.Staked && len(ppo) == r.repConst.SameUnstakedEntityMempoolCount {
+			if !pd.Staked && len(ppo) == repConst.SameUnstakedEntityMempoolCount {
 				return errors.NewRPCError(
 					errors.INVALID_ENTITY_STAKE,
 					fmt.Sprintf(
 						"unstaked entity: %s exceeds pending ops limit of %d",
 						paymaster.Hex(),
-						r.repConst.SameUnstakedEntityMempoolCount,
+						repConst.SameUnstakedEntityMempoolCount,
 					),
 					nil,
 				)
@@ -154,18 +201,26 @@ func (r *Reputation) ValidateOpLimit() modules.UserOpHandlerFunc {
 // included entities.
 func (r *Reputation) IncOpsSeen() modules.UserOpHandlerFunc {
 	return func(ctx *modules.UserOpHandlerCtx) error {
+		if r.buf != nil {
+			if r.buf.addSeen(getEntities(ctx.UserOp)...) {
+				return r.Flush()
+			}
+			return nil
+		}
+
 		return r.db.Update(func(txn *badger.Txn) error {
 			var err error
-			err = stdErr.Join(err, incrementOpsSeenByEntity(txn, ctx.UserOp.Sender))
+			now := r.clock.Now()
+			err = stdErr.Join(err, incrementOpsSeenByEntity(txn, ctx.UserOp.Sender, now))
 
 			factory := ctx.UserOp.GetFactory()
 			if factory != common.HexToAddress("0x") {
-				err = stdErr.Join(err, incrementOpsSeenByEntity(txn, factory))
+				err = stdErr.Join(err, incrementOpsSeenByEntity(txn, factory, now))
 			}
 
 			paymaster := ctx.UserOp.GetPaymaster()
 			if paymaster != common.HexToAddress("0x") {
-				err = stdErr.Join(err, incrementOpsSeenByEntity(txn, paymaster))
+				err = stdErr.Join(err, incrementOpsSeenByEntity(txn, paymaster, now))
 			}
 
 			return err
@@ -177,34 +232,44 @@ func (r *Reputation) IncOpsSeen() modules.UserOpHandlerFunc {
 // relevant entities in the batch. This module should be used last once batches have been sent.
 func (r *Reputation) IncOpsIncluded() modules.BatchHandlerFunc {
 	return func(ctx *modules.BatchHandlerCtx) error {
-		return r.db.Update(func(txn *badger.Txn) error {
-			c := make(addressCounter)
-			for _, op := range ctx.Batch {
-				if _, ok := c[op.Sender]; !ok {
-					c[op.Sender] = 0
+		c := make(addressCounter)
+		for _, op := range ctx.Batch {
+			if _, ok := c[op.Sender]; !ok {
+				c[op.Sender] = 0
+			}
+			c[op.Sender]++
+
+			factory := op.GetFactory()
+			if factory != common.HexToAddress("0x") {
+				if _, ok := c[factory]; !ok {
+					c[factory] = 0
 				}
-				c[op.Sender]++
 
-				factory := op.GetFactory()
-				if factory != common.HexToAddress("0x") {
-					if _, ok := c[factory]; !ok {
-						c[factory] = 0
-					}
+				c[factory]++
+			}
 
-					c[factory]++
+			paymaster := op.GetPaymaster()
+			if paymaster != common.HexToAddress("0x") {
+				if _, ok := c[paymaster]; !ok {
+					c[paymaster] = 0
 				}
 
-				paymaster := op.GetPaymaster()
-				if paymaster != common.HexToAddress("0x") {
-					if _, ok := c[paymaster]; !ok {
-						c[paymaster] = 0
-					}
+				c[paymaster]++
+			}
+		}
 
-					c[paymaster]++
-				}
+		// A sent batch is a natural point to write all buffered increments together. This also keeps
+		// opsIncluded counters from being lost since they cannot be replayed.
+		if r.buf != nil {
+			if len(c) > 0 {
+				r.buf.addIncluded(c)
+				return r.Flush()
 			}
+			return nil
+		}
 
-			return incrementOpsIncludedByEntity(txn, c)
+		return r.db.Update(func(txn *badger.Txn) error {
+			return incrementOpsIncludedByEntity(txn, c, r.clock.Now())
 		})
 	}
 }
@@ -212,8 +277,9 @@ func (r *Reputation) IncOpsIncluded() modules.BatchHandlerFunc {
 func (r *Reputation) Override(entries []*ReputationOverride) error {
 	return r.db.Update(func(txn *badger.Txn) error {
 		var err error
+		now := r.clock.Now()
 		for _, entry := range entries {
-			stdErr.Join(err, overrideEntity(txn, entry))
+			stdErr.Join(err, overrideEntity(txn, entry, now))
 		}
 		return err
 	})
@@ -0,0 +1,37 @@
+package entities
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+)
+
+// TestIsStaked verifies that an entity is only staked if it meets both the min stake and min unstake delay of
+// the current reputation constants.
+func TestIsStaked(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	c := validConstants()
+	r := New(db, nil, &c)
+
+	lowStake := *testutils.StakedDepositInfo
+	lowStake.Stake = big.NewInt(c.MinStakeValue - 1)
+	lowDelay := *testutils.StakedDepositInfo
+	lowDelay.UnstakeDelaySec = uint32(c.MinUnstakeDelay - 1)
+	for _, tc := range []struct {
+		name string
+		dep  *entrypoint.IStakeManagerDepositInfo
+		want bool
+	}{
+		{name: "staked", dep: testutils.StakedDepositInfo, want: true},
+		{name: "not staked", dep: testutils.NonStakedDepositInfo, want: false},
+		{name: "stake below min", dep: &lowStake, want: false},
+		{name: "unstake delay below min", dep: &lowDelay, want: false},
+	} {
+		if got := r.IsStaked(tc.dep); got != tc.want {
+			t.Fatalf("got %v for %s, want %v", got, tc.name, tc.want)
+		}
+	}
+}
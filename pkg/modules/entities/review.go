@@ -0,0 +1,182 @@
+package entities
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+)
+
+// BanReviewResult is an audit record for a banned entity that has been re-evaluated after its cool-down
+// period.
+type BanReviewResult struct {
+	Address     common.Address
+	BannedAt    time.Time
+	Rejected    int
+	OpsSeen     int
+	OpsIncluded int
+	Staked      bool
+	Restored    bool
+}
+
+// ReviewBannedEntities re-evaluates all entities that have been banned for longer than the given cool-down.
+// An entity is restored to a throttled status if none of its UserOperations were rejected by CheckStatus
+// during the cool-down or if it now has a sufficient stake in the EntryPoint. Otherwise a new cool-down period
+// is started.
+//
+// Entities are only considered banned from the first review that observes the banned status. Manually banned
+// entities are skipped since they stay banned until Unban is called. The returned results should be used for
+// audit logging.
+func (r *Reputation) ReviewBannedEntities(
+	entryPoint common.Address,
+	cooldown time.Duration,
+) ([]*BanReviewResult, error) {
+	repConst := r.repConst.Load()
+
+	// Scan for banned entities and existing cool-downs in a read-only transaction. Writes are made per entity
+	// below so that a large number of entities can't exceed the transaction size limit.
+	var banned, tracked []common.Address
+	err := r.db.View(func(txn *badger.Txn) error {
+		var err error
+		banned, err = getBannedEntities(txn, repConst, r.clock.Now())
+		tracked = getBannedAtEntities(txn)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Clear cool-downs of entities that are no longer banned.
+	isBanned := make(map[common.Address]bool)
+	for _, entity := range banned {
+		isBanned[entity] = true
+	}
+	for _, entity := range tracked {
+		if isBanned[entity] {
+			continue
+		}
+		if err := r.db.Update(func(txn *badger.Txn) error {
+			return removeBannedAtByEntity(txn, entity)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	// Start a cool-down for newly banned entities and collect all entities that are due for review.
+	due := []*BanReviewResult{}
+	for _, entity := range banned {
+		var res *BanReviewResult
+		if err := r.db.Update(func(txn *badger.Txn) error {
+			now := r.clock.Now()
+			rejected, bannedAt, found, err := getBannedAtByEntity(txn, entity)
+			if err != nil {
+				return err
+			} else if !found {
+				return setBannedAtByEntity(txn, entity, 0, now)
+			} else if now.Sub(bannedAt) >= cooldown {
+				res = &BanReviewResult{Address: entity, BannedAt: bannedAt, Rejected: rejected}
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		if res != nil {
+			due = append(due, res)
+		}
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	// Check for any stake changes outside of the DB transaction.
+	ep, err := entrypoint.NewEntrypoint(entryPoint, r.eth)
+	if err != nil {
+		return nil, err
+	}
+	for _, res := range due {
+		dep, err := ep.GetDepositInfo(nil, res.Address)
+		if err != nil {
+			return nil, err
+		}
+		res.Staked = isStaked(&dep, repConst)
+	}
+
+	// Restore entities with good behavior or reset the cool-down for the rest. Entities that were manually
+	// banned while the stake was checked are left for the next review to clean up.
+	reviewed := []*BanReviewResult{}
+	for _, res := range due {
+		skipped := false
+		if err := r.db.Update(func(txn *badger.Txn) error {
+			now := r.clock.Now()
+			if manual, err := isManuallyBanned(txn, res.Address); err != nil {
+				return err
+			} else if manual {
+				skipped = true
+				return nil
+			}
+
+			rejected, _, _, err := getBannedAtByEntity(txn, res.Address)
+			if err != nil {
+				return err
+			}
+			res.Rejected = rejected
+
+			if res.Staked || rejected == 0 {
+				res.OpsSeen, res.OpsIncluded, err = restoreToThrottled(txn, res.Address, repConst, now)
+				if err != nil {
+					return err
+				}
+				res.Restored = true
+				return removeBannedAtByEntity(txn, res.Address)
+			}
+
+			res.OpsSeen, res.OpsIncluded, err = getOpsCountByEntity(txn, res.Address, now)
+			if err != nil {
+				return err
+			}
+			return setBannedAtByEntity(txn, res.Address, 0, now)
+		}); err != nil {
+			return nil, err
+		}
+		if !skipped {
+			reviewed = append(reviewed, res)
+		}
+	}
+
+	return reviewed, nil
+}
+
+// RunBanReview calls ReviewBannedEntities on every interval of the Reputation's Clock and passes the results
+// to the given handler. The returned function stops the review and waits for a review in progress to finish.
+func (r *Reputation) RunBanReview(
+	entryPoint common.Address,
+	cooldown time.Duration,
+	interval time.Duration,
+	handler func(results []*BanReviewResult, err error),
+) (stop func()) {
+	ticker := r.clock.NewTicker(interval)
+	done := make(chan bool)
+	exited := make(chan bool)
+	go func() {
+		defer close(exited)
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C():
+				handler(r.ReviewBannedEntities(entryPoint, cooldown))
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+			<-exited
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package entities
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/clock"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+)
+
+const reviewCooldown = time.Hour
+
+// depositInfoMock returns the ABI encoded result of EntryPoint.getDepositInfo.
+func depositInfoMock(c ReputationConstants, staked bool) string {
+	words := make([]byte, 5*32)
+	if staked {
+		words[2*32-1] = 1
+		big.NewInt(c.MinStakeValue).FillBytes(words[2*32 : 3*32])
+		big.NewInt(int64(c.MinUnstakeDelay)).FillBytes(words[3*32 : 4*32])
+	}
+	return hexutil.Encode(words)
+}
+
+func newReviewReputation(t *testing.T, staked bool) (*Reputation, *clock.Mock) {
+	c := validConstants()
+	n := testutils.RpcMock(testutils.MethodMocks{"eth_call": depositInfoMock(c, staked)})
+	t.Cleanup(n.Close)
+	cl, err := rpc.Dial(n.URL)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	db := testutils.DBMock()
+	t.Cleanup(func() { db.Close() })
+
+	r := New(db, ethclient.NewClient(cl), &c)
+	m := clock.NewMock(time.Now())
+	r.SetClock(m)
+	return r, m
+}
+
+func setCounters(t *testing.T, r *Reputation, entity common.Address, opsSeen int) {
+	if err := r.Override([]*ReputationOverride{{Address: entity, OpsSeen: opsSeen}}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
+
+// submit sends an op from the entity through CheckStatus and expects it to be rejected.
+func submit(t *testing.T, r *Reputation, entity common.Address) {
+	mem, err := mempool.New(r.db)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	op := testutils.MockValidInitUserOp()
+	op.Sender = entity
+	ctx, err := modules.NewUserOpHandlerContext(
+		op,
+		testutils.ValidAddress5,
+		testutils.ChainID,
+		mem,
+		stake.GetStakeFuncNoop(),
+	)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := r.CheckStatus()(ctx); err == nil {
+		t.Fatal("got nil, want banned entity error")
+	}
+}
+
+func review(t *testing.T, r *Reputation) []*BanReviewResult {
+	results, err := r.ReviewBannedEntities(testutils.ValidAddress5, reviewCooldown)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	return results
+}
+
+func assertStatus(t *testing.T, r *Reputation, entity common.Address, want string) {
+	if s, err := r.GetStatus(entity); err != nil || s != want {
+		t.Fatalf("got %s, %v, want %s, nil", s, err, want)
+	}
+}
+
+// TestReviewBannedEntitiesRestoresAfterCooldown verifies that a banned entity is only reviewed once the
+// cool-down has passed on the injected clock and is restored to throttled if it was not seen again.
+func TestReviewBannedEntitiesRestoresAfterCooldown(t *testing.T) {
+	r, m := newReviewReputation(t, false)
+	entity := testutils.ValidAddress1
+	setCounters(t, r, entity, 10000)
+
+	if results := review(t, r); len(results) != 0 {
+		t.Fatalf("got %d results, want 0", len(results))
+	}
+	m.Add(reviewCooldown - time.Minute)
+	if results := review(t, r); len(results) != 0 {
+		t.Fatalf("got %d results, want 0", len(results))
+	}
+
+	m.Add(time.Minute)
+	results := review(t, r)
+	if len(results) != 1 || results[0].Address != entity || !results[0].Restored || results[0].Staked {
+		t.Fatalf("got %+v, want restored unstaked %s", results, entity)
+	}
+	assertStatus(t, r, entity, "throttled")
+}
+
+// TestReviewBannedEntitiesResetsCooldown verifies that an entity with ops rejected while banned stays banned
+// and starts a new cool-down.
+func TestReviewBannedEntitiesResetsCooldown(t *testing.T) {
+	r, m := newReviewReputation(t, false)
+	entity := testutils.ValidAddress1
+	setCounters(t, r, entity, 10000)
+	review(t, r)
+
+	submit(t, r, entity)
+	m.Add(reviewCooldown)
+	results := review(t, r)
+	if len(results) != 1 || results[0].Restored || results[0].Rejected != 1 {
+		t.Fatalf("got %+v, want 1 result not restored with 1 rejected", results)
+	}
+	assertStatus(t, r, entity, "banned")
+
+	m.Add(reviewCooldown)
+	results = review(t, r)
+	if len(results) != 1 || !results[0].Restored {
+		t.Fatalf("got %+v, want 1 result restored", results)
+	}
+	assertStatus(t, r, entity, "throttled")
+}
+
+// TestReviewBannedEntitiesKeepsBan verifies that an entity that keeps sending ops stays banned for every
+// cool-down in which its ops were rejected.
+func TestReviewBannedEntitiesKeepsBan(t *testing.T) {
+	r, m := newReviewReputation(t, false)
+	entity := testutils.ValidAddress1
+	setCounters(t, r, entity, 10000)
+	review(t, r)
+
+	for i := 0; i < 3; i++ {
+		submit(t, r, entity)
+		submit(t, r, entity)
+		m.Add(reviewCooldown)
+		results := review(t, r)
+		if len(results) != 1 || results[0].Restored || results[0].Rejected != 2 {
+			t.Fatalf("review %d: got %+v, want 1 result not restored with 2 rejected", i, results)
+		}
+		assertStatus(t, r, entity, "banned")
+	}
+}
+
+// TestReviewBannedEntitiesSkipsManualBans verifies that manually banned entities are never reviewed and their
+// counters are left untouched.
+func TestReviewBannedEntitiesSkipsManualBans(t *testing.T) {
+	r, m := newReviewReputation(t, false)
+	entity := testutils.ValidAddress1
+	setCounters(t, r, entity, 10000)
+	if err := r.Ban(entity); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	review(t, r)
+	m.Add(reviewCooldown)
+	if results := review(t, r); len(results) != 0 {
+		t.Fatalf("got %+v, want 0 results", results)
+	}
+	assertStatus(t, r, entity, "banned")
+
+	if err := r.db.View(func(txn *badger.Txn) error {
+		if _, _, found, err := getBannedAtByEntity(txn, entity); err != nil || found {
+			t.Fatalf("got %v, %v, want false, nil", found, err)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := r.db.Update(func(txn *badger.Txn) error {
+		_, included, err := getOpsCountByEntity(txn, entity, r.clock.Now())
+		if included != 0 {
+			t.Fatalf("got opsIncluded %d, want 0", included)
+		}
+		return err
+	}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
+
+// TestOpsCountDecaysOnClock verifies that the hourly decay of the opsSeen and opsIncluded counters follows the
+// injected clock.
+func TestOpsCountDecaysOnClock(t *testing.T) {
+	r, m := newReviewReputation(t, false)
+	entity := testutils.ValidAddress1
+	if err := r.Override([]*ReputationOverride{{Address: entity, OpsSeen: 2400, OpsIncluded: 240}}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	m.Add(time.Hour)
+	var seen, included int
+	if err := r.db.Update(func(txn *badger.Txn) error {
+		var err error
+		seen, included, err = getOpsCountByEntity(txn, entity, r.clock.Now())
+		return err
+	}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if seen != 2300 || included != 230 {
+		t.Fatalf("got %d, %d, want 2300, 230", seen, included)
+	}
+}
+
+// TestRunBanReviewOnClock verifies that RunBanReview reviews banned entities on every interval of the injected
+// clock and stops once the returned function is called.
+func TestRunBanReviewOnClock(t *testing.T) {
+	r, m := newReviewReputation(t, false)
+	entity := testutils.ValidAddress1
+	setCounters(t, r, entity, 10000)
+
+	reviewed := make(chan []*BanReviewResult, 100)
+	handler := func(res []*BanReviewResult, err error) {
+		if err != nil {
+			t.Errorf("got %v, want nil", err)
+		}
+		reviewed <- res
+	}
+	stop := r.RunBanReview(testutils.ValidAddress5, reviewCooldown, time.Minute, handler)
+	defer stop()
+
+	m.Add(time.Minute)
+	if res := <-reviewed; len(res) != 0 {
+		t.Fatalf("got %+v, want 0 results", res)
+	}
+	m.Add(reviewCooldown)
+	if res := <-reviewed; len(res) != 1 || !res[0].Restored {
+		t.Fatalf("got %+v, want 1 result restored", res)
+	}
+}
@@ -13,14 +13,14 @@ type ReputationOverride struct {
 // ReputationConstants are a collection of values for determining the appropriate status of a UserOperation
 // coming into the mempool.
 type ReputationConstants struct {
-	MinUnstakeDelay                int
-	MinStakeValue                  int64
-	SameSenderMempoolCount         int
-	SameUnstakedEntityMempoolCount int
-	ThrottledEntityMempoolCount    int
-	ThrottledEntityLiveBlocks      int
-	ThrottledEntityBundleCount     int
-	MinInclusionRateDenominator    int
-	ThrottlingSlack                int
-	BanSlack                       int
+	MinUnstakeDelay                int   `json:"minUnstakeDelay"`
+	MinStakeValue                  int64 `json:"minStakeValue"`
+	SameSenderMempoolCount         int   `json:"sameSenderMempoolCount"`
+	SameUnstakedEntityMempoolCount int   `json:"sameUnstakedEntityMempoolCount"`
+	ThrottledEntityMempoolCount    int   `json:"throttledEntityMempoolCount"`
+	ThrottledEntityLiveBlocks      int   `json:"throttledEntityLiveBlocks"`
+	ThrottledEntityBundleCount     int   `json:"throttledEntityBundleCount"`
+	MinInclusionRateDenominator    int   `json:"minInclusionRateDenominator"`
+	ThrottlingSlack                int   `json:"throttlingSlack"`
+	BanSlack                       int   `json:"banSlack"`
 }
@@ -2,12 +2,14 @@ package entities
 
 import (
 	"fmt"
+	"math/big"
 	"strconv"
 	"time"
 
 	"github.com/dgraph-io/badger/v3"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stackup-wallet/stackup-bundler/internal/dbutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
 )
 
 type addressCounter map[common.Address]int
@@ -20,22 +22,52 @@ const (
 	banned
 )
 
+func (s status) String() string {
+	switch s {
+	case throttled:
+		return "throttled"
+	case banned:
+		return "banned"
+	default:
+		return "ok"
+	}
+}
+
 var (
-	emaHours       = 24
-	opsCountPrefix = dbutils.JoinValues("entity", "opsCount")
+	// KeyPrefix is the prefix for all keys stored in the DB by the entities package.
+	KeyPrefix = "entity"
+
+	emaHours        = 24
+	opsCountPrefix  = dbutils.JoinValues(KeyPrefix, "opsCount")
+	bannedAtPrefix  = dbutils.JoinValues(KeyPrefix, "bannedAt")
+	manualBanPrefix = dbutils.JoinValues(KeyPrefix, "manualBan")
 )
 
 func getOpsCountKey(entity common.Address) []byte {
 	return []byte(dbutils.JoinValues(opsCountPrefix, entity.String()))
 }
 
-func getOpsCountValue(opsSeen int, opsIncluded int) []byte {
+func getBannedAtKey(entity common.Address) []byte {
+	return []byte(dbutils.JoinValues(bannedAtPrefix, entity.String()))
+}
+
+func getManualBanKey(entity common.Address) []byte {
+	return []byte(dbutils.JoinValues(manualBanPrefix, entity.String()))
+}
+
+func getBannedAtValue(rejected int, bannedAt time.Time) []byte {
+	return []byte(dbutils.JoinValues(strconv.Itoa(rejected), fmt.Sprint(bannedAt.Unix())))
+}
+
+func getOpsCountValue(opsSeen int, opsIncluded int, now time.Time) []byte {
 	return []byte(
-		dbutils.JoinValues(strconv.Itoa(opsSeen), strconv.Itoa(opsIncluded), fmt.Sprint(time.Now().Unix())),
+		dbutils.JoinValues(strconv.Itoa(opsSeen), strconv.Itoa(opsIncluded), fmt.Sprint(now.Unix())),
 	)
 }
 
-func applyExpWeights(txn *badger.Txn, key []byte, value []byte) (opsSeen int, opsIncluded int, err error) {
+// decayOpsCount returns the opsSeen and opsIncluded counters of a stored value after applying the hourly
+// decay up to the given time.
+func decayOpsCount(value []byte, now time.Time) (opsSeen int, opsIncluded int, err error) {
 	counts := dbutils.SplitValues(string(value))
 	opsSeen, err = strconv.Atoi(counts[0])
 	if err != nil {
@@ -50,7 +82,7 @@ func applyExpWeights(txn *badger.Txn, key []byte, value []byte) (opsSeen int, op
 		return 0, 0, err
 	}
 
-	dur := time.Since(time.Unix(lastUpdated, 0))
+	dur := now.Sub(time.Unix(lastUpdated, 0))
 	for i := int(dur.Hours()); i > 0; i-- {
 		if opsSeen < 24 && opsIncluded < 24 {
 			break
@@ -60,7 +92,21 @@ func applyExpWeights(txn *badger.Txn, key []byte, value []byte) (opsSeen int, op
 		opsIncluded -= opsIncluded / emaHours
 	}
 
-	e := badger.NewEntry(key, getOpsCountValue(opsSeen, opsIncluded))
+	return opsSeen, opsIncluded, nil
+}
+
+func applyExpWeights(
+	txn *badger.Txn,
+	key []byte,
+	value []byte,
+	now time.Time,
+) (opsSeen int, opsIncluded int, err error) {
+	opsSeen, opsIncluded, err = decayOpsCount(value, now)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	e := badger.NewEntry(key, getOpsCountValue(opsSeen, opsIncluded, now))
 	err = txn.SetEntry(e)
 
 	return opsSeen, opsIncluded, err
@@ -69,6 +115,7 @@ func applyExpWeights(txn *badger.Txn, key []byte, value []byte) (opsSeen int, op
 func getOpsCountByEntity(
 	txn *badger.Txn,
 	entity common.Address,
+	now time.Time,
 ) (opsSeen int, opsIncluded int, err error) {
 	key := getOpsCountKey(entity)
 	item, err := txn.Get(key)
@@ -87,29 +134,29 @@ func getOpsCountByEntity(
 		return 0, 0, err
 	}
 
-	return applyExpWeights(txn, key, value)
+	return applyExpWeights(txn, key, value, now)
 }
 
-func incrementOpsSeenByEntity(txn *badger.Txn, entity common.Address) error {
-	opsSeen, opsIncluded, err := getOpsCountByEntity(txn, entity)
+func incrementOpsSeenByEntity(txn *badger.Txn, entity common.Address, now time.Time) error {
+	opsSeen, opsIncluded, err := getOpsCountByEntity(txn, entity, now)
 	if err != nil {
 		return err
 	}
 
-	e := badger.NewEntry(getOpsCountKey(entity), getOpsCountValue(opsSeen+1, opsIncluded))
+	e := badger.NewEntry(getOpsCountKey(entity), getOpsCountValue(opsSeen+1, opsIncluded, now))
 	return txn.SetEntry(e)
 }
 
-func incrementOpsIncludedByEntity(txn *badger.Txn, count addressCounter) error {
+func incrementOpsIncludedByEntity(txn *badger.Txn, count addressCounter, now time.Time) error {
 	for entity, n := range count {
-		opsSeen, opsIncluded, err := getOpsCountByEntity(txn, entity)
+		opsSeen, opsIncluded, err := getOpsCountByEntity(txn, entity, now)
 		if err != nil {
 			return err
 		}
 
 		e := badger.NewEntry(
 			getOpsCountKey(entity),
-			getOpsCountValue(opsSeen, opsIncluded+n),
+			getOpsCountValue(opsSeen, opsIncluded+n, now),
 		)
 		if err := txn.SetEntry(e); err != nil {
 			return err
@@ -119,27 +166,183 @@ func incrementOpsIncludedByEntity(txn *badger.Txn, count addressCounter) error {
 	return nil
 }
 
-func getStatus(txn *badger.Txn, entity common.Address, repConst *ReputationConstants) (status, error) {
-	opsSeen, opsIncluded, err := getOpsCountByEntity(txn, entity)
+func getStatus(
+	txn *badger.Txn,
+	entity common.Address,
+	repConst *ReputationConstants,
+	now time.Time,
+) (status, error) {
+	if manual, err := isManuallyBanned(txn, entity); err != nil {
+		return ok, err
+	} else if manual {
+		return banned, nil
+	}
+
+	opsSeen, opsIncluded, err := getOpsCountByEntity(txn, entity, now)
 	if err != nil {
 		return ok, err
 	}
+	return getStatusByOpsCount(opsSeen, opsIncluded, repConst), nil
+}
+
+func getStatusByOpsCount(opsSeen int, opsIncluded int, repConst *ReputationConstants) status {
 	if opsSeen == 0 {
-		return ok, nil
+		return ok
 	}
 
 	minExpectedIncluded := opsSeen / repConst.MinInclusionRateDenominator
 	if minExpectedIncluded <= opsIncluded+repConst.ThrottlingSlack {
-		return ok, nil
+		return ok
 	} else if minExpectedIncluded <= opsIncluded+repConst.BanSlack {
-		return throttled, nil
+		return throttled
 	} else {
-		return banned, nil
+		return banned
 	}
 }
 
-func overrideEntity(txn *badger.Txn, entry *ReputationOverride) error {
+func overrideEntity(txn *badger.Txn, entry *ReputationOverride, now time.Time) error {
 	return txn.SetEntry(
-		badger.NewEntry(getOpsCountKey(entry.Address), getOpsCountValue(entry.OpsSeen, entry.OpsIncluded)),
+		badger.NewEntry(getOpsCountKey(entry.Address), getOpsCountValue(entry.OpsSeen, entry.OpsIncluded, now)),
 	)
 }
+
+// getBannedEntities returns all entities that are banned by their ops count, excluding manual bans. It only
+// reads from the DB and can be used with a read-only transaction.
+func getBannedEntities(
+	txn *badger.Txn,
+	repConst *ReputationConstants,
+	now time.Time,
+) ([]common.Address, error) {
+	it := txn.NewIterator(badger.DefaultIteratorOptions)
+	defer it.Close()
+	prefix := []byte(dbutils.JoinValues(opsCountPrefix, ""))
+
+	out := []common.Address{}
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		slc := dbutils.SplitValues(string(it.Item().Key()))
+		entity := common.HexToAddress(slc[len(slc)-1])
+
+		var opsSeen, opsIncluded int
+		if err := it.Item().Value(func(val []byte) error {
+			var err error
+			opsSeen, opsIncluded, err = decayOpsCount(val, now)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+		if getStatusByOpsCount(opsSeen, opsIncluded, repConst) != banned {
+			continue
+		}
+
+		if manual, err := isManuallyBanned(txn, entity); err != nil {
+			return nil, err
+		} else if !manual {
+			out = append(out, entity)
+		}
+	}
+	return out, nil
+}
+
+func getBannedAtByEntity(
+	txn *badger.Txn,
+	entity common.Address,
+) (rejected int, bannedAt time.Time, found bool, err error) {
+	item, err := txn.Get(getBannedAtKey(entity))
+	if err != nil && err == badger.ErrKeyNotFound {
+		return 0, time.Time{}, false, nil
+	} else if err != nil {
+		return 0, time.Time{}, false, err
+	}
+
+	var value []byte
+	err = item.Value(func(val []byte) error {
+		value = append([]byte{}, val...)
+		return nil
+	})
+	if err != nil {
+		return 0, time.Time{}, false, err
+	}
+
+	values := dbutils.SplitValues(string(value))
+	rejected, err = strconv.Atoi(values[0])
+	if err != nil {
+		return 0, time.Time{}, false, err
+	}
+	ts, err := strconv.ParseInt(values[1], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, false, err
+	}
+
+	return rejected, time.Unix(ts, 0), true, nil
+}
+
+func setBannedAtByEntity(txn *badger.Txn, entity common.Address, rejected int, bannedAt time.Time) error {
+	return txn.SetEntry(badger.NewEntry(getBannedAtKey(entity), getBannedAtValue(rejected, bannedAt)))
+}
+
+// incrementRejectedByEntity counts an op from a banned entity that was rejected during its cool-down. Nothing
+// is recorded if the cool-down has not been started by ReviewBannedEntities.
+func incrementRejectedByEntity(txn *badger.Txn, entity common.Address) error {
+	rejected, bannedAt, found, err := getBannedAtByEntity(txn, entity)
+	if err != nil || !found {
+		return err
+	}
+	return setBannedAtByEntity(txn, entity, rejected+1, bannedAt)
+}
+
+func isManuallyBanned(txn *badger.Txn, entity common.Address) (bool, error) {
+	if _, err := txn.Get(getManualBanKey(entity)); err == nil {
+		return true, nil
+	} else if err != badger.ErrKeyNotFound {
+		return false, err
+	}
+	return false, nil
+}
+
+func removeBannedAtByEntity(txn *badger.Txn, entity common.Address) error {
+	return txn.Delete(getBannedAtKey(entity))
+}
+
+// restoreToThrottled sets the opsIncluded count of an entity to the lowest value that will still result in a
+// throttled status. This gives the entity another chance while ensuring continued bad behavior will quickly
+// result in another ban.
+func restoreToThrottled(
+	txn *badger.Txn,
+	entity common.Address,
+	repConst *ReputationConstants,
+	now time.Time,
+) (opsSeen int, opsIncluded int, err error) {
+	opsSeen, opsIncluded, err = getOpsCountByEntity(txn, entity, now)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	minExpectedIncluded := opsSeen / repConst.MinInclusionRateDenominator
+	if n := minExpectedIncluded - repConst.BanSlack; n > opsIncluded {
+		opsIncluded = n
+	}
+
+	e := badger.NewEntry(getOpsCountKey(entity), getOpsCountValue(opsSeen, opsIncluded, now))
+	return opsSeen, opsIncluded, txn.SetEntry(e)
+}
+
+func getBannedAtEntities(txn *badger.Txn) []common.Address {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	it := txn.NewIterator(opts)
+	prefix := []byte(dbutils.JoinValues(bannedAtPrefix, ""))
+	defer it.Close()
+
+	entities := []common.Address{}
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		slc := dbutils.SplitValues(string(it.Item().Key()))
+		entities = append(entities, common.HexToAddress(slc[len(slc)-1]))
+	}
+	return entities
+}
+
+func isStaked(dep *entrypoint.IStakeManagerDepositInfo, repConst *ReputationConstants) bool {
+	return dep.Staked &&
+		dep.Stake.Cmp(big.NewInt(repConst.MinStakeValue)) >= 0 &&
+		dep.UnstakeDelaySec >= uint32(repConst.MinUnstakeDelay)
+}
@@ -0,0 +1,186 @@
+// Package epstatus implements modules that detect when an EntryPoint is unable to include UserOperations and
+// reject new submissions early rather than accepting ops that will never be included.
+package epstatus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/pkg/clock"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+)
+
+var (
+	DefaultFailureThreshold = 3
+	DefaultRecoveryInterval = time.Minute
+	DefaultCodeCheckTTL     = 30 * time.Second
+)
+
+// GetCodeFunc returns the deployed bytecode at an address.
+type GetCodeFunc = func(addr common.Address) ([]byte, error)
+
+// GetCodeWithEthClient returns an implementation of GetCodeFunc that relies on an eth client to fetch the
+// code at the latest block.
+func GetCodeWithEthClient(eth *ethclient.Client) GetCodeFunc {
+	return func(addr common.Address) ([]byte, error) {
+		return eth.CodeAt(context.Background(), addr, nil)
+	}
+}
+
+type codeCheck struct {
+	ok        bool
+	checkedAt time.Time
+}
+
+type failures struct {
+	count  int
+	lastAt time.Time
+	reason string
+}
+
+// Monitor tracks the state of each EntryPoint. An EntryPoint is considered unavailable if it has no code or
+// if handleOps has failed a consecutive number of times equal to the failure threshold. Once the recovery
+// interval has passed since the last failure, submissions are accepted again so that the next batch can
+// confirm whether the issue has been resolved.
+type Monitor struct {
+	getCode          GetCodeFunc
+	logger           logr.Logger
+	failureThreshold int
+	recoveryInterval time.Duration
+	codeCheckTTL     time.Duration
+	clock            clock.Clock
+
+	mu       sync.Mutex
+	code     map[common.Address]*codeCheck
+	failures map[common.Address]*failures
+}
+
+// New returns a Monitor with default settings.
+func New(getCode GetCodeFunc, l logr.Logger) *Monitor {
+	return &Monitor{
+		getCode:          getCode,
+		logger:           l.WithName("epstatus"),
+		failureThreshold: DefaultFailureThreshold,
+		recoveryInterval: DefaultRecoveryInterval,
+		codeCheckTTL:     DefaultCodeCheckTTL,
+		clock:            clock.Real(),
+		code:             make(map[common.Address]*codeCheck),
+		failures:         make(map[common.Address]*failures),
+	}
+}
+
+// SetFailureThreshold sets the number of consecutive handleOps failures before an EntryPoint is considered
+// unavailable. A value of 0 disables failure tracking.
+func (m *Monitor) SetFailureThreshold(n int) {
+	m.failureThreshold = n
+}
+
+// SetRecoveryInterval sets the duration after the last handleOps failure before new submissions are accepted
+// again.
+func (m *Monitor) SetRecoveryInterval(d time.Duration) {
+	m.recoveryInterval = d
+}
+
+// SetClock sets the Clock used for code check caching and failure recovery.
+//
+// The default value is clock.Real().
+func (m *Monitor) SetClock(c clock.Clock) {
+	m.clock = c
+}
+
+func (m *Monitor) hasCode(ep common.Address) (bool, error) {
+	m.mu.Lock()
+	c, ok := m.code[ep]
+	m.mu.Unlock()
+	if ok && m.clock.Since(c.checkedAt) < m.codeCheckTTL {
+		return c.ok, nil
+	}
+
+	code, err := m.getCode(ep)
+	if err != nil {
+		return false, err
+	}
+
+	m.mu.Lock()
+	m.code[ep] = &codeCheck{ok: len(code) > 0, checkedAt: m.clock.Now()}
+	m.mu.Unlock()
+	return len(code) > 0, nil
+}
+
+// Status returns a non-empty reason if the EntryPoint is currently unavailable.
+func (m *Monitor) Status(ep common.Address) (string, error) {
+	ok, err := m.hasCode(ep)
+	if err != nil {
+		return "", err
+	} else if !ok {
+		return "entryPoint has no code", nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, ok := m.failures[ep]
+	if !ok || m.failureThreshold <= 0 || f.count < m.failureThreshold {
+		return "", nil
+	} else if m.clock.Since(f.lastAt) >= m.recoveryInterval {
+		return "", nil
+	}
+	return fmt.Sprintf("handleOps failed %d consecutive times: %s", f.count, f.reason), nil
+}
+
+// CheckAvailable returns a UserOpHandler that is used by the Client to reject UserOperations sent to an
+// EntryPoint that is currently unavailable.
+func (m *Monitor) CheckAvailable() modules.UserOpHandlerFunc {
+	return func(ctx *modules.UserOpHandlerCtx) error {
+		reason, err := m.Status(ctx.EntryPoint)
+		if err != nil {
+			return err
+		} else if reason != "" {
+			return errors.NewRPCError(
+				errors.SERVICE_UNAVAILABLE,
+				fmt.Sprintf("entryPoint %s unavailable: %s", ctx.EntryPoint.String(), reason),
+				nil,
+			)
+		}
+		return nil
+	}
+}
+
+// TrackHandleOps wraps the BatchHandler that sends batches to the EntryPoint and records whether handleOps
+// succeeded. Consecutive failures are reset after the next successful batch.
+func (m *Monitor) TrackHandleOps(send modules.BatchHandlerFunc) modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		if len(ctx.Batch) == 0 {
+			return send(ctx)
+		}
+
+		err := send(ctx)
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if err == nil {
+			delete(m.failures, ctx.EntryPoint)
+			return nil
+		}
+
+		f, ok := m.failures[ctx.EntryPoint]
+		if !ok {
+			f = &failures{}
+			m.failures[ctx.EntryPoint] = f
+		}
+		f.count++
+		f.lastAt = m.clock.Now()
+		f.reason = err.Error()
+		if m.failureThreshold > 0 && f.count == m.failureThreshold {
+			m.logger.
+				WithValues("entrypoint", ctx.EntryPoint.String()).
+				WithValues("failures", f.count).
+				Error(err, "entryPoint unavailable")
+		}
+		return err
+	}
+}
@@ -0,0 +1,92 @@
+package epstatus
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func getCodeFunc(code []byte) GetCodeFunc {
+	return func(addr common.Address) ([]byte, error) {
+		return code, nil
+	}
+}
+
+func newBatchCtx() *modules.BatchHandlerCtx {
+	return modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{testutils.MockValidInitUserOp()},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+}
+
+func newUserOpCtx() *modules.UserOpHandlerCtx {
+	return &modules.UserOpHandlerCtx{
+		UserOp:     testutils.MockValidInitUserOp(),
+		EntryPoint: testutils.ValidAddress1,
+		ChainID:    testutils.ChainID,
+	}
+}
+
+func TestCheckAvailableNoCode(t *testing.T) {
+	m := New(getCodeFunc([]byte{}), logr.Discard())
+	if err := m.CheckAvailable()(newUserOpCtx()); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
+
+func TestCheckAvailableAfterConsecutiveFailures(t *testing.T) {
+	m := New(getCodeFunc([]byte{1}), logr.Discard())
+	fail := m.TrackHandleOps(func(ctx *modules.BatchHandlerCtx) error {
+		return errors.New("execution reverted")
+	})
+	for i := 0; i < DefaultFailureThreshold-1; i++ {
+		_ = fail(newBatchCtx())
+	}
+	if err := m.CheckAvailable()(newUserOpCtx()); err != nil {
+		t.Fatalf("got %v, want nil below threshold", err)
+	}
+
+	_ = fail(newBatchCtx())
+	if err := m.CheckAvailable()(newUserOpCtx()); err == nil {
+		t.Fatal("got nil, want err at threshold")
+	}
+}
+
+func TestCheckAvailableResetsOnSuccess(t *testing.T) {
+	m := New(getCodeFunc([]byte{1}), logr.Discard())
+	m.SetFailureThreshold(1)
+	_ = m.TrackHandleOps(func(ctx *modules.BatchHandlerCtx) error {
+		return errors.New("execution reverted")
+	})(newBatchCtx())
+	_ = m.TrackHandleOps(func(ctx *modules.BatchHandlerCtx) error {
+		return nil
+	})(newBatchCtx())
+
+	if err := m.CheckAvailable()(newUserOpCtx()); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
+
+func TestCheckAvailableAfterRecoveryInterval(t *testing.T) {
+	m := New(getCodeFunc([]byte{1}), logr.Discard())
+	m.SetFailureThreshold(1)
+	m.SetRecoveryInterval(time.Minute)
+	_ = m.TrackHandleOps(func(ctx *modules.BatchHandlerCtx) error {
+		return errors.New("execution reverted")
+	})(newBatchCtx())
+	m.failures[testutils.ValidAddress1].lastAt = time.Now().Add(-2 * time.Minute)
+
+	if err := m.CheckAvailable()(newUserOpCtx()); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
@@ -4,12 +4,17 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/clock"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
 )
 
+// Reason is the removal reason set on UserOperations dropped by DropExpired.
+const Reason = "op expired"
+
 type ExpireHandler struct {
 	seenAt map[common.Hash]time.Time
 	ttl    time.Duration
+	clock  clock.Clock
 }
 
 // New returns an ExpireHandler which contains a BatchHandlerFunc to track and drop UserOperations that have
@@ -18,9 +23,17 @@ func New(ttl time.Duration) *ExpireHandler {
 	return &ExpireHandler{
 		seenAt: make(map[common.Hash]time.Time),
 		ttl:    ttl,
+		clock:  clock.Real(),
 	}
 }
 
+// SetClock sets the Clock used to track how long UserOperations have been in the mempool.
+//
+// The default value is clock.Real().
+func (e *ExpireHandler) SetClock(c clock.Clock) {
+	e.clock = c
+}
+
 // DropExpired returns a BatchHandlerFunc that will drop UserOperations from the mempool if it has been around
 // for longer than the TTL duration.
 func (e *ExpireHandler) DropExpired() modules.BatchHandlerFunc {
@@ -29,9 +42,9 @@ func (e *ExpireHandler) DropExpired() modules.BatchHandlerFunc {
 		for i := end; i >= 0; i-- {
 			hash := ctx.Batch[i].GetUserOpHash(ctx.EntryPoint, ctx.ChainID)
 			if seenAt, ok := e.seenAt[hash]; !ok {
-				e.seenAt[hash] = time.Now()
-			} else if seenAt.Add(e.ttl).Before(time.Now()) {
-				ctx.MarkOpIndexForRemoval(i, "op expired")
+				e.seenAt[hash] = e.clock.Now()
+			} else if seenAt.Add(e.ttl).Before(e.clock.Now()) {
+				ctx.MarkOpIndexForRemoval(i, Reason)
 			}
 		}
 		return nil
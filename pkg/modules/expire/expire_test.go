@@ -6,6 +6,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/clock"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
@@ -43,3 +44,40 @@ func TestDropExpired(t *testing.T) {
 	}
 
 }
+
+// TestDropExpiredWithClock verifies that ops are only dropped once the TTL has passed on the given Clock.
+func TestDropExpiredWithClock(t *testing.T) {
+	c := clock.NewMock(time.Unix(1700000000, 0))
+	exp := New(time.Second * 30)
+	exp.SetClock(c)
+	op := testutils.MockValidInitUserOp()
+
+	newCtx := func() *modules.BatchHandlerCtx {
+		return modules.NewBatchHandlerContext(
+			[]*userop.UserOperation{op},
+			testutils.ValidAddress1,
+			testutils.ChainID,
+			nil,
+			nil,
+			nil,
+		)
+	}
+
+	for _, d := range []time.Duration{0, 30 * time.Second} {
+		c.Add(d)
+		ctx := newCtx()
+		if err := exp.DropExpired()(ctx); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		} else if len(ctx.PendingRemoval) != 0 {
+			t.Fatalf("got pending removal length %d after %s, want 0", len(ctx.PendingRemoval), d)
+		}
+	}
+
+	c.Add(time.Second)
+	ctx := newCtx()
+	if err := exp.DropExpired()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(ctx.PendingRemoval) != 1 {
+		t.Fatalf("got pending removal length %d, want 1", len(ctx.PendingRemoval))
+	}
+}
@@ -0,0 +1,88 @@
+package factories
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// Limits are the stricter rules applied to ops that deploy through an unknown factory. A value of 0 disables
+// the limit.
+type Limits struct {
+	// MaxPendingOps is the max number of ops in the mempool that deploy through the same unknown factory.
+	MaxPendingOps int
+
+	// MaxVerificationGas is the max verificationGasLimit of an op that deploys through an unknown factory.
+	MaxVerificationGas *big.Int
+
+	// MaxOpsPerBatch is the max number of ops that deploy through an unknown factory in a single batch.
+	// Any excess ops are left in the mempool for a later batch.
+	MaxOpsPerBatch int
+}
+
+// isUnknown returns true if the op deploys an account through a factory that is not in the registry.
+func (r *Registry) isUnknown(op *userop.UserOperation) bool {
+	factory := op.GetFactory()
+	return factory != common.HexToAddress("0x") && !r.IsKnown(factory)
+}
+
+// CheckUnknown returns a UserOpHandler that is used by the Client to enforce the limits on ops that deploy
+// through an unknown factory. Ops without initCode or with a known factory are not affected.
+func (r *Registry) CheckUnknown(lim Limits) modules.UserOpHandlerFunc {
+	return func(ctx *modules.UserOpHandlerCtx) error {
+		if !r.isUnknown(ctx.UserOp) {
+			return nil
+		}
+
+		factory := ctx.UserOp.GetFactory()
+		if lim.MaxPendingOps > 0 && len(ctx.GetPendingFactoryOps()) >= lim.MaxPendingOps {
+			return errors.NewRPCError(
+				errors.REJECTED_BY_POLICY,
+				fmt.Sprintf("factories: unknown factory %s has %d pending ops", factory, lim.MaxPendingOps),
+				nil,
+			)
+		}
+		if lim.MaxVerificationGas != nil &&
+			lim.MaxVerificationGas.Sign() > 0 &&
+			ctx.UserOp.VerificationGasLimit.Cmp(lim.MaxVerificationGas) > 0 {
+			return errors.NewRPCError(
+				errors.REJECTED_BY_POLICY,
+				fmt.Sprintf(
+					"factories: verificationGasLimit exceeds %s for unknown factory %s",
+					lim.MaxVerificationGas,
+					factory,
+				),
+				nil,
+			)
+		}
+		return nil
+	}
+}
+
+// Deprioritize returns a BatchHandlerFunc that moves ops deploying through an unknown factory to the back of
+// the batch and caps how many of them are included. The relative order of ops is otherwise unchanged. This
+// should run after any sorting by gas price and before the gas limit is applied.
+func (r *Registry) Deprioritize(lim Limits) modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		known := []*userop.UserOperation{}
+		unknown := []*userop.UserOperation{}
+		for _, op := range ctx.Batch {
+			if r.isUnknown(op) {
+				unknown = append(unknown, op)
+			} else {
+				known = append(known, op)
+			}
+		}
+		if lim.MaxOpsPerBatch > 0 && len(unknown) > lim.MaxOpsPerBatch {
+			ctx.Data["delayed_by_unknown_factory"] = len(unknown) - lim.MaxOpsPerBatch
+			unknown = unknown[:lim.MaxOpsPerBatch]
+		}
+		ctx.Batch = append(known, unknown...)
+
+		return nil
+	}
+}
@@ -0,0 +1,132 @@
+// Package factories implements a registry of known-good account factories per chain. Ops that deploy an
+// account through a factory outside of the registry can be held to stricter limits at ingestion and moved to
+// a lower priority lane when batching, since bundle failures often trace back to buggy factories.
+package factories
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-logr/logr"
+)
+
+var (
+	// DefaultRefreshInterval is the default duration between fetches of the remote feed.
+	DefaultRefreshInterval = 10 * time.Minute
+
+	// RequestTimeout is the max duration to wait for a response from the remote feed.
+	RequestTimeout = 10 * time.Second
+)
+
+// Feed is the JSON body expected from a remote factory feed. Factories are keyed by the decimal chain ID so
+// that a single feed can be shared across networks.
+type Feed map[string][]common.Address
+
+// Registry holds the audited factory addresses for a single chain. The set is the union of a static list
+// from config and the latest successful fetch of an optional remote feed.
+type Registry struct {
+	chainID  *big.Int
+	static   map[common.Address]bool
+	feedUrl  string
+	interval time.Duration
+	client   *http.Client
+	logger   logr.Logger
+
+	mu     sync.RWMutex
+	remote map[common.Address]bool
+}
+
+// New returns a Registry for the given chain with a static list of known factories.
+func New(chainID *big.Int, known []common.Address, l logr.Logger) *Registry {
+	static := make(map[common.Address]bool)
+	for _, addr := range known {
+		static[addr] = true
+	}
+	return &Registry{
+		chainID:  chainID,
+		static:   static,
+		interval: DefaultRefreshInterval,
+		client:   &http.Client{Timeout: RequestTimeout},
+		logger:   l.WithName("factories"),
+		remote:   make(map[common.Address]bool),
+	}
+}
+
+// SetFeedUrl sets the URL of a remote feed to merge with the static list. An empty URL disables the feed.
+func (r *Registry) SetFeedUrl(url string) {
+	r.feedUrl = url
+}
+
+// SetRefreshInterval sets the duration between fetches of the remote feed.
+//
+// The default value is 10 minutes.
+func (r *Registry) SetRefreshInterval(d time.Duration) {
+	r.interval = d
+}
+
+// IsKnown returns true if the factory is in the static list or the remote feed.
+func (r *Registry) IsKnown(factory common.Address) bool {
+	if r.static[factory] {
+		return true
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.remote[factory]
+}
+
+// Fetch replaces the remote set with the factories listed for the Registry's chain in the feed. On failure
+// the previous set is kept.
+func (r *Registry) Fetch() error {
+	if r.feedUrl == "" {
+		return nil
+	}
+
+	res, err := r.client.Get(r.feedUrl)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		reason, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("factories: status %d: %s", res.StatusCode, strings.TrimSpace(string(reason)))
+	}
+
+	var feed Feed
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&feed); err != nil {
+		return fmt.Errorf("factories: invalid feed: %w", err)
+	}
+	remote := make(map[common.Address]bool)
+	for _, addr := range feed[r.chainID.String()] {
+		remote[addr] = true
+	}
+
+	r.mu.Lock()
+	r.remote = remote
+	r.mu.Unlock()
+	return nil
+}
+
+// Run starts a process to fetch the remote feed at the set interval. Errors are logged and retried on the
+// next interval. This is a no-op if no feed URL is set.
+func (r *Registry) Run() {
+	if r.feedUrl == "" {
+		return
+	}
+
+	go func() {
+		for {
+			if err := r.Fetch(); err != nil {
+				r.logger.Error(err, "factory feed fetch error")
+			}
+			time.Sleep(r.interval)
+		}
+	}()
+}
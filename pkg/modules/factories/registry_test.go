@@ -0,0 +1,137 @@
+package factories
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func opWithFactory(sender common.Address, factory common.Address) *userop.UserOperation {
+	op := testutils.MockValidInitUserOp()
+	op.Sender = sender
+	op.InitCode = factory.Bytes()
+	return op
+}
+
+func getCode(err error) int {
+	if rpcErr, ok := err.(*errors.RPCError); ok {
+		return rpcErr.Code()
+	}
+	return 0
+}
+
+// TestFetchMergesFeedForChain verifies that only the factories listed for the registry's chain are merged
+// with the static list and that a failed fetch keeps the previous set.
+func TestFetchMergesFeedForChain(t *testing.T) {
+	status := http.StatusOK
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"` + testutils.ChainID.String() + `":["` + testutils.ValidAddress2.String() +
+			`"],"999999":["` + testutils.ValidAddress3.String() + `"]}`))
+	}))
+	defer s.Close()
+
+	r := New(testutils.ChainID, []common.Address{testutils.ValidAddress1}, logr.Discard())
+	r.SetFeedUrl(s.URL)
+	if err := r.Fetch(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if !r.IsKnown(testutils.ValidAddress1) || !r.IsKnown(testutils.ValidAddress2) {
+		t.Fatal("got unknown, want static and feed factories known")
+	}
+	if r.IsKnown(testutils.ValidAddress3) {
+		t.Fatal("got known, want factory from another chain unknown")
+	}
+
+	status = http.StatusInternalServerError
+	if err := r.Fetch(); err == nil {
+		t.Fatal("got nil, want error")
+	}
+	if !r.IsKnown(testutils.ValidAddress2) {
+		t.Fatal("got unknown, want previous feed kept")
+	}
+}
+
+// TestCheckUnknownLimits verifies that ops deploying through an unknown factory are rejected when they exceed
+// the limits and that known factories are not affected.
+func TestCheckUnknownLimits(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := mempool.New(db)
+	if err := mem.AddOp(testutils.ValidAddress5, opWithFactory(testutils.ValidAddress1, testutils.ValidAddress4)); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	r := New(testutils.ChainID, []common.Address{testutils.ValidAddress3}, logr.Discard())
+	cases := []struct {
+		factory common.Address
+		lim     Limits
+		want    int
+	}{
+		{testutils.ValidAddress4, Limits{MaxPendingOps: 1}, errors.REJECTED_BY_POLICY},
+		{testutils.ValidAddress4, Limits{MaxPendingOps: 2}, 0},
+		{testutils.ValidAddress4, Limits{MaxVerificationGas: big.NewInt(1)}, errors.REJECTED_BY_POLICY},
+		{testutils.ValidAddress3, Limits{MaxPendingOps: 1, MaxVerificationGas: big.NewInt(1)}, 0},
+	}
+	for _, c := range cases {
+		ctx, err := modules.NewUserOpHandlerContext(
+			opWithFactory(testutils.ValidAddress2, c.factory),
+			testutils.ValidAddress5,
+			testutils.ChainID,
+			mem,
+			stake.GetStakeFuncNoop(),
+		)
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		if got := getCode(r.CheckUnknown(c.lim)(ctx)); got != c.want {
+			t.Fatalf("got code %d, want %d for %+v", got, c.want, c.lim)
+		}
+	}
+}
+
+// TestDeprioritizeMovesUnknownToBack verifies that ops deploying through an unknown factory are moved behind
+// all other ops and capped per batch.
+func TestDeprioritizeMovesUnknownToBack(t *testing.T) {
+	unknown1 := opWithFactory(testutils.ValidAddress1, testutils.ValidAddress4)
+	unknown2 := opWithFactory(testutils.ValidAddress2, testutils.ValidAddress4)
+	known := opWithFactory(testutils.ValidAddress3, testutils.ValidAddress5)
+	deployed := testutils.MockValidInitUserOp()
+	deployed.InitCode = []byte{}
+
+	r := New(testutils.ChainID, []common.Address{testutils.ValidAddress5}, logr.Discard())
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{unknown1, known, unknown2, deployed},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		big.NewInt(1),
+		big.NewInt(1),
+		big.NewInt(1),
+	)
+	if err := r.Deprioritize(Limits{MaxOpsPerBatch: 1})(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	want := []*userop.UserOperation{known, deployed, unknown1}
+	if len(ctx.Batch) != len(want) {
+		t.Fatalf("got batch of %d, want %d", len(ctx.Batch), len(want))
+	}
+	for i, op := range ctx.Batch {
+		if op != want[i] {
+			t.Fatalf("got %s at %d, want %s", op.Sender, i, want[i].Sender)
+		}
+	}
+	if ctx.Data["delayed_by_unknown_factory"] != 1 {
+		t.Fatalf("got %v, want 1 delayed op", ctx.Data["delayed_by_unknown_factory"])
+	}
+}
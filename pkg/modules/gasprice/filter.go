@@ -8,25 +8,68 @@ import (
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
+// GetDiscountFunc returns the percentage that the minimum gas price can be discounted by for a given
+// userOp. A value of 0 means no discount.
+type GetDiscountFunc = func(op *userop.UserOperation) (int64, error)
+
+func applyDiscount(gp *big.Int, percent int64) *big.Int {
+	if percent <= 0 {
+		return gp
+	}
+	d := big.NewInt(0).Mul(gp, big.NewInt(100-percent))
+	return d.Div(d, big.NewInt(100))
+}
+
+func filterUnderpriced(ctx *modules.BatchHandlerCtx, model string, discount GetDiscountFunc) error {
+	b := []*userop.UserOperation{}
+	for _, op := range ctx.Batch {
+		var percent int64
+		if discount != nil {
+			p, err := discount(op)
+			if err != nil {
+				return err
+			}
+			percent = p
+		}
+
+		if ctx.BaseFee != nil && ctx.BaseFee.Cmp(common.Big0) != 0 && ctx.Tip != nil {
+			gp := applyDiscount(minDynamicGasPrice(model, ctx.BaseFee, ctx.Tip), percent)
+			if op.GetDynamicGasPrice(ctx.BaseFee).Cmp(gp) >= 0 {
+				b = append(b, op)
+			}
+		} else if ctx.GasPrice != nil {
+			if op.MaxFeePerGas.Cmp(applyDiscount(ctx.GasPrice, percent)) >= 0 {
+				b = append(b, op)
+			}
+		}
+	}
+
+	ctx.Batch = b
+	return nil
+}
+
 // FilterUnderpriced returns a BatchHandlerFunc that will filter out all the userOps that are below either the
 // dynamic or legacy GasPrice set in the context.
 func FilterUnderpriced() modules.BatchHandlerFunc {
 	return func(ctx *modules.BatchHandlerCtx) error {
-		b := []*userop.UserOperation{}
-		for _, op := range ctx.Batch {
-			if ctx.BaseFee != nil && ctx.BaseFee.Cmp(common.Big0) != 0 && ctx.Tip != nil {
-				gp := big.NewInt(0).Add(ctx.BaseFee, ctx.Tip)
-				if op.GetDynamicGasPrice(ctx.BaseFee).Cmp(gp) >= 0 {
-					b = append(b, op)
-				}
-			} else if ctx.GasPrice != nil {
-				if op.MaxFeePerGas.Cmp(ctx.GasPrice) >= 0 {
-					b = append(b, op)
-				}
-			}
-		}
+		return filterUnderpriced(ctx, ProfitModelPriorityFee, nil)
+	}
+}
 
-		ctx.Batch = b
-		return nil
+// FilterUnderpricedWithDiscount returns a BatchHandlerFunc that works the same as FilterUnderpriced but
+// lowers the minimum gas price for each userOp by the percentage returned from the discount function. This
+// can be used to relax the threshold for entities with a proven history of reliable orderflow.
+func FilterUnderpricedWithDiscount(discount GetDiscountFunc) modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		return filterUnderpriced(ctx, ProfitModelPriorityFee, discount)
+	}
+}
+
+// FilterUnderpricedWithProfitModel returns a BatchHandlerFunc that works the same as
+// FilterUnderpricedWithDiscount but uses the given profit model to determine the minimum dynamic gas price.
+// The same model should be used for the profitability check in SimulateBatch.
+func FilterUnderpricedWithProfitModel(model string, discount GetDiscountFunc) modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		return filterUnderpriced(ctx, model, discount)
 	}
 }
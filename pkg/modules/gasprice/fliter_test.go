@@ -84,3 +84,71 @@ func TestFilterUnderpricedGasPrice(t *testing.T) {
 		t.Fatal("incorrect order: second op out of place")
 	}
 }
+
+// TestFilterUnderpricedWithDiscount verifies that FilterUnderpricedWithDiscount will keep UserOperations that
+// are below the context GasPrice but within the discount returned for them.
+func TestFilterUnderpricedWithDiscount(t *testing.T) {
+	op1 := testutils.MockValidInitUserOp()
+	op1.Sender = testutils.ValidAddress2
+	op1.MaxFeePerGas = big.NewInt(8)
+	op1.MaxPriorityFeePerGas = big.NewInt(8)
+
+	op2 := testutils.MockValidInitUserOp()
+	op2.Sender = testutils.ValidAddress3
+	op2.MaxFeePerGas = big.NewInt(8)
+	op2.MaxPriorityFeePerGas = big.NewInt(8)
+
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{op1, op2},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		nil,
+		nil,
+		big.NewInt(10),
+	)
+	discount := func(op *userop.UserOperation) (int64, error) {
+		if op.Sender == testutils.ValidAddress2 {
+			return 20, nil
+		}
+		return 0, nil
+	}
+	if err := gasprice.FilterUnderpricedWithDiscount(discount)(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(ctx.Batch) != 1 {
+		t.Fatalf("got length %d, want 1", len(ctx.Batch))
+	} else if !testutils.IsOpsEqual(ctx.Batch[0], op1) {
+		t.Fatal("incorrect op: discounted op not kept")
+	}
+}
+
+// TestFilterUnderpricedWithBaseFeeRebate verifies that FilterUnderpricedWithProfitModel will keep
+// UserOperations that only cover the basefee when using the basefee rebate model.
+func TestFilterUnderpricedWithBaseFeeRebate(t *testing.T) {
+	bf := big.NewInt(4)
+	tip := big.NewInt(1)
+
+	op1 := testutils.MockValidInitUserOp()
+	op1.MaxFeePerGas = big.NewInt(4)
+	op1.MaxPriorityFeePerGas = big.NewInt(0)
+
+	op2 := testutils.MockValidInitUserOp()
+	op2.Sender = testutils.ValidAddress2
+	op2.MaxFeePerGas = big.NewInt(3)
+	op2.MaxPriorityFeePerGas = big.NewInt(0)
+
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{op1, op2},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		bf,
+		tip,
+		big.NewInt(10),
+	)
+	if err := gasprice.FilterUnderpricedWithProfitModel(gasprice.ProfitModelBaseFeeRebate, nil)(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(ctx.Batch) != 1 {
+		t.Fatalf("got length %d, want 1", len(ctx.Batch))
+	} else if !testutils.IsOpsEqual(ctx.Batch[0], op1) {
+		t.Fatal("incorrect op: op covering basefee not kept")
+	}
+}
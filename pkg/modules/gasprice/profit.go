@@ -0,0 +1,44 @@
+package gasprice
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+const (
+	// ProfitModelPriorityFee assumes the bundle transaction pays the basefee plus a priority fee. UserOperations
+	// must cover both to be profitable.
+	ProfitModelPriorityFee = "priority_fee"
+
+	// ProfitModelBaseFeeRebate is for chains where the priority fee is effectively zero and the bundle
+	// transaction only pays the basefee. UserOperations only need to cover the basefee to be profitable.
+	ProfitModelBaseFeeRebate = "base_fee_rebate"
+)
+
+// minDynamicGasPrice returns the lowest dynamic gas price a UserOperation must pay under the given profit
+// model.
+func minDynamicGasPrice(model string, baseFee *big.Int, tip *big.Int) *big.Int {
+	if model == ProfitModelBaseFeeRebate {
+		return big.NewInt(0).Set(baseFee)
+	}
+	return big.NewInt(0).Add(baseFee, tip)
+}
+
+// EffectiveGasPrice returns the projected gas price paid by the bundle transaction under the given profit
+// model. This is used to assert that the beneficiary is compensated for at least the cost of a batch. Returns
+// nil if neither the dynamic nor legacy gas fees are set.
+func EffectiveGasPrice(
+	model string,
+	baseFee *big.Int,
+	tip *big.Int,
+	gasPrice *big.Int,
+	batch []*userop.UserOperation,
+) *big.Int {
+	if model == ProfitModelBaseFeeRebate && baseFee != nil && baseFee.Cmp(common.Big0) != 0 {
+		return big.NewInt(0).Set(baseFee)
+	}
+	return transaction.SuggestEffectiveGasPrice(baseFee, tip, gasPrice, batch)
+}
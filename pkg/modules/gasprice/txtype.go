@@ -0,0 +1,40 @@
+package gasprice
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// IsDynamicFeeSupportedWithEthClient returns true if the network accepts EIP-1559 transactions. This requires
+// the latest block to have a basefee, the node to suggest a gas tip, and a call with dynamic fee fields to be
+// accepted. Some EVM sidechains report a basefee but reject typed transactions, in which case legacy gas
+// pricing should be used instead. An error is only returned if the latest block could not be fetched.
+func IsDynamicFeeSupportedWithEthClient(eth *ethclient.Client, from common.Address) (bool, error) {
+	head, err := eth.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return false, err
+	}
+	if head.BaseFee == nil {
+		return false, nil
+	}
+
+	tip, err := eth.SuggestGasTipCap(context.Background())
+	if err != nil {
+		return false, nil
+	}
+
+	if _, err := eth.EstimateGas(context.Background(), ethereum.CallMsg{
+		From:      from,
+		To:        &from,
+		GasFeeCap: big.NewInt(0).Add(tip, big.NewInt(0).Mul(head.BaseFee, big.NewInt(2))),
+		GasTipCap: tip,
+		Value:     big.NewInt(0),
+	}); err != nil {
+		return false, nil
+	}
+	return true, nil
+}
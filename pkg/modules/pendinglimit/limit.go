@@ -0,0 +1,61 @@
+// Package pendinglimit implements a module for capping the number of pending UserOperations per sender and
+// per paymaster at ingestion. Unlike the reputation rules for unstaked entities, these caps apply to every
+// entity regardless of stake so that a single account or paymaster can't monopolize the mempool.
+package pendinglimit
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// Limits are the max number of pending ops in the mempool for a single entity. A value of 0 disables the
+// limit.
+type Limits struct {
+	MaxPerSender    int
+	MaxPerPaymaster int
+}
+
+// countOthers returns the number of pending ops that would remain alongside op. A pending op with the same
+// sender and nonce is not counted since op would replace it.
+func countOthers(op *userop.UserOperation, pending []*userop.UserOperation) int {
+	n := 0
+	for _, penOp := range pending {
+		if penOp.Sender == op.Sender && penOp.Nonce.Cmp(op.Nonce) == 0 {
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+func limitError(role string, entity common.Address, max int) error {
+	return errors.NewRPCError(
+		errors.REJECTED_BY_POLICY,
+		fmt.Sprintf("pendinglimit: %s %s exceeds pending ops limit of %d", role, entity.Hex(), max),
+		nil,
+	)
+}
+
+// ValidateLimits returns a UserOpHandler that is used by the Client to reject ops from a sender or paymaster
+// that already has the max number of pending ops in the mempool. Replacements of a pending op are always
+// allowed since they do not increase the count.
+func ValidateLimits(lim Limits) modules.UserOpHandlerFunc {
+	return func(ctx *modules.UserOpHandlerCtx) error {
+		op := ctx.UserOp
+		if lim.MaxPerSender > 0 && countOthers(op, ctx.GetPendingSenderOps()) >= lim.MaxPerSender {
+			return limitError("sender", op.Sender, lim.MaxPerSender)
+		}
+
+		paymaster := op.GetPaymaster()
+		if lim.MaxPerPaymaster > 0 &&
+			paymaster != common.HexToAddress("0x") &&
+			countOthers(op, ctx.GetPendingPaymasterOps()) >= lim.MaxPerPaymaster {
+			return limitError("paymaster", paymaster, lim.MaxPerPaymaster)
+		}
+		return nil
+	}
+}
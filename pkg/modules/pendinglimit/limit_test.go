@@ -0,0 +1,75 @@
+package pendinglimit
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func newOp(sender common.Address, nonce int64, paymaster common.Address) *userop.UserOperation {
+	op := testutils.MockValidInitUserOp()
+	op.Sender = sender
+	op.Nonce = big.NewInt(nonce)
+	op.PaymasterAndData = paymaster.Bytes()
+	return op
+}
+
+func getCode(err error) int {
+	if rpcErr, ok := err.(*errors.RPCError); ok {
+		return rpcErr.Code()
+	}
+	return 0
+}
+
+// TestValidateLimits verifies that new ops are rejected once a sender or paymaster reaches its limit while
+// replacements and ops from other entities are allowed.
+func TestValidateLimits(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := mempool.New(db)
+	for _, op := range []*userop.UserOperation{
+		newOp(testutils.ValidAddress1, 0, testutils.ValidAddress4),
+		newOp(testutils.ValidAddress1, 1, testutils.ValidAddress4),
+		newOp(testutils.ValidAddress2, 0, testutils.ValidAddress4),
+	} {
+		if err := mem.AddOp(testutils.ValidAddress5, op); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+
+	pm := testutils.ValidAddress4
+	cases := []struct {
+		op   *userop.UserOperation
+		lim  Limits
+		want int
+	}{
+		{newOp(testutils.ValidAddress1, 2, common.Address{}), Limits{MaxPerSender: 2}, errors.REJECTED_BY_POLICY},
+		{newOp(testutils.ValidAddress1, 1, common.Address{}), Limits{MaxPerSender: 2}, 0},
+		{newOp(testutils.ValidAddress2, 1, common.Address{}), Limits{MaxPerSender: 2}, 0},
+		{newOp(testutils.ValidAddress3, 0, pm), Limits{MaxPerPaymaster: 3}, errors.REJECTED_BY_POLICY},
+		{newOp(testutils.ValidAddress2, 0, pm), Limits{MaxPerPaymaster: 3}, 0},
+		{newOp(testutils.ValidAddress1, 2, pm), Limits{}, 0},
+	}
+	for i, c := range cases {
+		ctx, err := modules.NewUserOpHandlerContext(
+			c.op,
+			testutils.ValidAddress5,
+			testutils.ChainID,
+			mem,
+			stake.GetStakeFuncNoop(),
+		)
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		if got := getCode(ValidateLimits(c.lim)(ctx)); got != c.want {
+			t.Fatalf("case %d: got code %d, want %d", i, got, c.want)
+		}
+	}
+}
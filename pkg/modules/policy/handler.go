@@ -0,0 +1,76 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+)
+
+func newEntity(
+	addr common.Address,
+	dep *entrypoint.IStakeManagerDepositInfo,
+	gr GetReputationFunc,
+) (*Entity, error) {
+	rep, err := gr(addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Entity{Address: addr, Deposit: dep, Reputation: rep}, nil
+}
+
+// UserOpHandler returns a UserOpHandler that is used by the Client to evaluate each UserOperation against the
+// policy script. Any action other than accept will return an error to the caller.
+func (s *Script) UserOpHandler(gr GetReputationFunc, gp GetGasPricesFunc) modules.UserOpHandlerFunc {
+	return func(ctx *modules.UserOpHandlerCtx) error {
+		op, err := ctx.UserOp.ToMap()
+		if err != nil {
+			return err
+		}
+		in := &Input{UserOp: op, EntryPoint: ctx.EntryPoint, ChainID: ctx.ChainID}
+
+		if in.Sender, err = newEntity(ctx.UserOp.Sender, ctx.GetSenderDepositInfo(), gr); err != nil {
+			return err
+		}
+		if factory := ctx.UserOp.GetFactory(); factory != common.HexToAddress("0x") {
+			if in.Factory, err = newEntity(factory, ctx.GetFactoryDepositInfo(), gr); err != nil {
+				return err
+			}
+		}
+		if paymaster := ctx.UserOp.GetPaymaster(); paymaster != common.HexToAddress("0x") {
+			if in.Paymaster, err = newEntity(paymaster, ctx.GetPaymasterDepositInfo(), gr); err != nil {
+				return err
+			}
+		}
+
+		prices, err := gp()
+		if err != nil {
+			return err
+		}
+		in.GasPrices = &GasPrices{
+			MaxFeePerGas:         prices.MaxFeePerGas,
+			MaxPriorityFeePerGas: prices.MaxPriorityFeePerGas,
+		}
+
+		d, err := s.Evaluate(in)
+		if err != nil {
+			return err
+		}
+		switch d.Action {
+		case Accept:
+			return nil
+		case Reject:
+			return errors.NewRPCError(errors.REJECTED_BY_POLICY, fmt.Sprintf("rejected by policy: %s", d.Reason), nil)
+		case Route:
+			return errors.NewRPCError(
+				errors.REJECTED_BY_POLICY,
+				fmt.Sprintf("routed by policy: %s", d.Reason),
+				map[string]string{"route": d.Route},
+			)
+		default:
+			return fmt.Errorf("policy: unknown action %q", d.Action)
+		}
+	}
+}
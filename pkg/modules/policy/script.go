@@ -0,0 +1,148 @@
+// Package policy implements a module for evaluating operator supplied business rules against each
+// UserOperation without recompiling the bundler. Rules are written as a script in any language (e.g. JS with
+// node or WASM with a WASI runtime) that reads one JSON Input per line from stdin and writes one JSON Decision
+// per line to stdout.
+package policy
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+var (
+	// DefaultTimeout is the max duration to wait for a decision from the policy script.
+	DefaultTimeout = 500 * time.Millisecond
+
+	ErrPolicyTimeout = errors.New("policy: script timeout")
+)
+
+type result struct {
+	line []byte
+	err  error
+}
+
+// Script manages a long running policy script process.
+type Script struct {
+	mu      sync.Mutex
+	command []string
+	timeout time.Duration
+	logger  logr.Logger
+
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	results chan result
+}
+
+// New starts the given command as a policy script. The first element is the executable and the rest are its
+// arguments.
+func New(command []string, l logr.Logger) (*Script, error) {
+	if len(command) == 0 {
+		return nil, errors.New("policy: command not set")
+	}
+
+	s := &Script{
+		command: command,
+		timeout: DefaultTimeout,
+		logger:  l.WithName("policy"),
+	}
+	if err := s.start(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetTimeout sets the max duration to wait for a decision from the policy script. If a timeout is reached,
+// the script is restarted and an error is returned.
+func (s *Script) SetTimeout(timeout time.Duration) {
+	s.timeout = timeout
+}
+
+func (s *Script) start() error {
+	cmd := exec.Command(s.command[0], s.command[1:]...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	results := make(chan result)
+	go func() {
+		r := bufio.NewReader(stdout)
+		for {
+			line, err := r.ReadBytes('\n')
+			results <- result{line, err}
+			if err != nil {
+				close(results)
+				return
+			}
+		}
+	}()
+
+	s.cmd = cmd
+	s.stdin = stdin
+	s.results = results
+	return nil
+}
+
+func (s *Script) restart() {
+	_ = s.stdin.Close()
+	_ = s.cmd.Process.Kill()
+	go func(cmd *exec.Cmd, results chan result) {
+		// Drain any pending output so the reader goroutine can exit.
+		for range results {
+		}
+		_ = cmd.Wait()
+	}(s.cmd, s.results)
+
+	if err := s.start(); err != nil {
+		s.logger.Error(err, "policy restart error")
+	}
+}
+
+// Evaluate sends the input to the policy script and waits for a decision.
+func (s *Script) Evaluate(in *Input) (*Decision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.stdin.Write(append(data, '\n')); err != nil {
+		s.restart()
+		return nil, err
+	}
+
+	select {
+	case res, ok := <-s.results:
+		if !ok || res.err != nil {
+			s.restart()
+			return nil, fmt.Errorf("policy: script exited: %v", res.err)
+		}
+
+		var d Decision
+		if err := json.Unmarshal(res.line, &d); err != nil {
+			return nil, fmt.Errorf("policy: bad decision: %w", err)
+		}
+		return &d, nil
+	case <-time.After(s.timeout):
+		s.restart()
+		return nil, ErrPolicyTimeout
+	}
+}
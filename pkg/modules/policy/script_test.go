@@ -0,0 +1,42 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/logger"
+)
+
+// TestEvaluateDecision verifies that a decision written by the script is returned for each input.
+func TestEvaluateDecision(t *testing.T) {
+	s, err := New(
+		[]string{"sh", "-c", `while read l; do echo '{"action":"reject","reason":"test"}'; done`},
+		logger.NewZeroLogr(),
+	)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		d, err := s.Evaluate(&Input{})
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		} else if d.Action != Reject || d.Reason != "test" {
+			t.Fatalf("got %+v, want reject with reason test", d)
+		}
+	}
+}
+
+// TestEvaluateTimeout verifies that an unresponsive script returns ErrPolicyTimeout.
+func TestEvaluateTimeout(t *testing.T) {
+	s, err := New([]string{"sh", "-c", "sleep 10"}, logger.NewZeroLogr())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	s.SetTimeout(50 * time.Millisecond)
+
+	if _, err := s.Evaluate(&Input{}); !errors.Is(err, ErrPolicyTimeout) {
+		t.Fatalf("got %v, want ErrPolicyTimeout", err)
+	}
+}
@@ -0,0 +1,58 @@
+package policy
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/fees"
+)
+
+const (
+	// Accept allows the UserOperation to continue through the remaining Client modules.
+	Accept = "accept"
+
+	// Reject returns an error to the caller with the given reason.
+	Reject = "reject"
+
+	// Route rejects the UserOperation and includes the route target in the error data so that a front-end
+	// can resubmit it to a different endpoint.
+	Route = "route"
+)
+
+// GetReputationFunc is a general interface for fetching the status of an entity.
+type GetReputationFunc = func(entity common.Address) (string, error)
+
+// GetGasPricesFunc is a general interface for fetching the current suggested gas prices.
+type GetGasPricesFunc = func() (*fees.GasPrices, error)
+
+// Entity contains the on-chain stake and local reputation of an entity in the UserOperation.
+type Entity struct {
+	Address    common.Address                       `json:"address"`
+	Deposit    *entrypoint.IStakeManagerDepositInfo `json:"deposit"`
+	Reputation string                               `json:"reputation"`
+}
+
+// GasPrices contains the current suggested gas prices for the network.
+type GasPrices struct {
+	MaxFeePerGas         *big.Int `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *big.Int `json:"maxPriorityFeePerGas"`
+}
+
+// Input is the JSON object written to the policy script for each UserOperation.
+type Input struct {
+	UserOp     map[string]any `json:"userOp"`
+	EntryPoint common.Address `json:"entryPoint"`
+	ChainID    *big.Int       `json:"chainId"`
+	Sender     *Entity        `json:"sender"`
+	Factory    *Entity        `json:"factory,omitempty"`
+	Paymaster  *Entity        `json:"paymaster,omitempty"`
+	GasPrices  *GasPrices     `json:"gasPrices"`
+}
+
+// Decision is the JSON object expected from the policy script for each UserOperation.
+type Decision struct {
+	Action string `json:"action"`
+	Reason string `json:"reason,omitempty"`
+	Route  string `json:"route,omitempty"`
+}
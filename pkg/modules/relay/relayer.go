@@ -9,9 +9,11 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/pkg/clock"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+	"go.opentelemetry.io/otel/metric"
 )
 
 // Relayer provides a module that can relay batches with a regular EOA. Relaying batches to the EntryPoint
@@ -30,6 +32,16 @@ type Relayer struct {
 	beneficiary common.Address
 	logger      logr.Logger
 	waitTimeout time.Duration
+	approve     transaction.ApproveFunc
+	clock       clock.Clock
+
+	stuckTimeout   time.Duration
+	stuck          *stuckTracker
+	stuckDetected  metric.Int64Counter
+	stuckCancelled metric.Int64Counter
+
+	safe            *safeMode
+	safeModeEntered metric.Int64Counter
 }
 
 // New initializes a new EOA relayer for sending batches to the EntryPoint.
@@ -47,6 +59,9 @@ func New(
 		beneficiary: beneficiary,
 		logger:      l.WithName("relayer"),
 		waitTimeout: DefaultWaitTimeout,
+		clock:       clock.Real(),
+
+		stuckTimeout: DefaultStuckTxTimeout,
 	}
 }
 
@@ -59,6 +74,21 @@ func (r *Relayer) SetWaitTimeout(timeout time.Duration) {
 	r.waitTimeout = timeout
 }
 
+// SetApproveFunc sets a hook that is called with a preview of each handleOps transaction before it is signed.
+// The hook can veto signing by returning an error or delay it by blocking.
+//
+// The default value is nil. Setting the value to nil will sign transactions without approval.
+func (r *Relayer) SetApproveFunc(fn transaction.ApproveFunc) {
+	r.approve = fn
+}
+
+// SetClock sets the Clock used to detect stuck transactions.
+//
+// The default value is clock.Real().
+func (r *Relayer) SetClock(c clock.Clock) {
+	r.clock = c
+}
+
 // SendUserOperation returns a BatchHandler that is used by the Bundler to send batches in a regular EOA
 // transaction.
 func (r *Relayer) SendUserOperation() modules.BatchHandlerFunc {
@@ -75,7 +105,16 @@ func (r *Relayer) SendUserOperation() modules.BatchHandlerFunc {
 			GasPrice:    ctx.GasPrice,
 			GasLimit:    0,
 			WaitTimeout: r.waitTimeout,
+			Approve:     r.approve,
+		}
+		// Unblock the EOA if a previous transaction is stuck in the tx pool. Otherwise the current batch would
+		// queue behind it.
+		if err := r.cancelStuckTx(&opts); err != nil {
+			return err
 		}
+		r.applySafeMode(ctx)
+		opts.Batch = ctx.Batch
+
 		// Estimate gas for handleOps() and drop all userOps that cause unexpected reverts.
 		for len(ctx.Batch) > 0 {
 			est, revert, err := transaction.EstimateHandleOpsGas(&opts)
@@ -84,8 +123,14 @@ func (r *Relayer) SendUserOperation() modules.BatchHandlerFunc {
 				return err
 			} else if revert != nil {
 				ctx.MarkOpIndexForRemoval(revert.OpIndex, revert.Reason)
-			} else {
-				opts.GasLimit = est
+				opts.Batch = ctx.Batch
+				continue
+			}
+
+			opts.GasLimit = est
+			if ok, err := r.simulateInSafeMode(ctx, &opts); err != nil {
+				return err
+			} else if ok {
 				break
 			}
 		}
@@ -93,10 +138,13 @@ func (r *Relayer) SendUserOperation() modules.BatchHandlerFunc {
 		// Call handleOps() with gas estimate. Any userOps that cause a revert at this stage will be
 		// caught and dropped in the next iteration.
 		if len(ctx.Batch) > 0 {
-			if txn, err := transaction.HandleOps(&opts); err != nil {
+			txn, err := transaction.HandleOps(&opts)
+			r.recordSafeModeResult(err)
+			if err != nil {
 				return err
 			} else {
 				ctx.Data["txn_hash"] = txn.Hash().String()
+				ctx.Data["estimated_gas"] = opts.GasLimit
 			}
 		}
 
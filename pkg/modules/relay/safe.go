@@ -0,0 +1,145 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ErrSafeModeEntered is logged when the relayer enters safe mode after repeated handleOps reverts.
+var ErrSafeModeEntered = errors.New("relay: entered safe mode after consecutive handleOps reverts")
+
+// safeMode tracks consecutive handleOps reverts and successes to decide when the relayer should reduce the
+// risk of each batch.
+type safeMode struct {
+	mu        sync.Mutex
+	threshold int
+	recovery  int
+	reverts   int
+	successes int
+	active    bool
+}
+
+// onRevert records a reverted batch and returns true if this caused safe mode to be entered.
+func (s *safeMode) onRevert() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.successes = 0
+	s.reverts++
+	if !s.active && s.reverts >= s.threshold {
+		s.active = true
+		return true
+	}
+	return false
+}
+
+// onSuccess records an included batch and returns true if this caused safe mode to be exited.
+func (s *safeMode) onSuccess() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reverts = 0
+	if !s.active {
+		return false
+	}
+	s.successes++
+	if s.successes >= s.recovery {
+		s.active = false
+		s.successes = 0
+		return true
+	}
+	return false
+}
+
+func (s *safeMode) isActive() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.active
+}
+
+// SetSafeMode enables safe mode after the given number of consecutive handleOps transactions are included
+// with a failed status. While in safe mode, batches are limited to a single UserOperation and each batch is
+// simulated with its exact gas limit before being sent. Normal operation is restored after the given number
+// of batches are included successfully.
+//
+// Reverts can only be detected if the wait timeout is greater than 0. Setting reverts to 0 will disable safe
+// mode.
+func (r *Relayer) SetSafeMode(reverts int, recovery int) {
+	if reverts == 0 {
+		r.safe = nil
+		return
+	}
+	r.safe = &safeMode{threshold: reverts, recovery: recovery}
+}
+
+// IsSafeMode returns true if the relayer is currently in safe mode.
+func (r *Relayer) IsSafeMode() bool {
+	return r.safe != nil && r.safe.isActive()
+}
+
+// applySafeMode limits the batch to a single UserOperation if the relayer is in safe mode. Remaining ops are
+// kept in the mempool for later batches.
+func (r *Relayer) applySafeMode(ctx *modules.BatchHandlerCtx) {
+	if !r.IsSafeMode() {
+		return
+	}
+
+	ctx.Data["safe_mode"] = true
+	if len(ctx.Batch) > 1 {
+		ctx.Batch = ctx.Batch[:1]
+	}
+}
+
+// simulateInSafeMode runs an extra simulation of the batch with its exact gas limit and fees if the relayer
+// is in safe mode. Returns false if an op was marked for removal and the batch should be estimated again.
+func (r *Relayer) simulateInSafeMode(ctx *modules.BatchHandlerCtx, opts *transaction.Opts) (bool, error) {
+	if !r.IsSafeMode() {
+		return true, nil
+	}
+
+	revert, err := transaction.SimulateHandleOps(opts)
+	if err != nil {
+		return false, err
+	} else if revert != nil {
+		ctx.MarkOpIndexForRemoval(revert.OpIndex, revert.Reason)
+		opts.Batch = ctx.Batch
+		return false, nil
+	}
+	return true, nil
+}
+
+// recordSafeModeResult updates safe mode with the result of sending a batch.
+func (r *Relayer) recordSafeModeResult(err error) {
+	if r.safe == nil || r.waitTimeout == 0 {
+		return
+	}
+
+	l := r.logger.WithValues("reverts_threshold", r.safe.threshold, "recovery_threshold", r.safe.recovery)
+	if errors.Is(err, transaction.ErrFailedStatus) {
+		if r.safe.onRevert() {
+			l.Error(ErrSafeModeEntered, "relayer safe mode alert")
+			if r.safeModeEntered != nil {
+				r.safeModeEntered.Add(context.Background(), 1)
+			}
+		}
+	} else if err == nil {
+		if r.safe.onSuccess() {
+			l.Info("relayer safe mode exited")
+		}
+	}
+}
+
+func (r *Relayer) observeSafeMode(_ context.Context, o metric.Int64Observer) error {
+	if r.IsSafeMode() {
+		o.Observe(1)
+	} else {
+		o.Observe(0)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package relay
+
+import "testing"
+
+func TestSafeModeEnterAndRecover(t *testing.T) {
+	s := &safeMode{threshold: 2, recovery: 2}
+
+	if s.onRevert() {
+		t.Fatal("entered safe mode after 1 revert, want 2")
+	}
+	if !s.onRevert() {
+		t.Fatal("got not entered, want entered after 2 reverts")
+	}
+	if s.onRevert() {
+		t.Fatal("got entered again, want already active")
+	}
+
+	if s.onSuccess() {
+		t.Fatal("exited safe mode after 1 success, want 2")
+	}
+	if !s.onSuccess() {
+		t.Fatal("got not exited, want exited after 2 successes")
+	}
+	if s.isActive() {
+		t.Fatal("got active, want inactive")
+	}
+}
+
+func TestSafeModeRevertsMustBeConsecutive(t *testing.T) {
+	s := &safeMode{threshold: 2, recovery: 1}
+
+	s.onRevert()
+	s.onSuccess()
+	if s.onRevert() {
+		t.Fatal("entered safe mode after non-consecutive reverts")
+	}
+}
+
+func TestSafeModeRevertResetsRecovery(t *testing.T) {
+	s := &safeMode{threshold: 1, recovery: 2}
+
+	s.onRevert()
+	s.onSuccess()
+	s.onRevert()
+	if s.onSuccess() {
+		t.Fatal("exited safe mode after non-consecutive successes")
+	}
+	if !s.isActive() {
+		t.Fatal("got inactive, want active")
+	}
+}
@@ -0,0 +1,110 @@
+package relay
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type stuckTracker struct {
+	nonce     uint64
+	firstSeen time.Time
+	cancelTxn *types.Transaction
+}
+
+// SetStuckTxTimeout sets the duration that the EOA's next nonce can remain unconfirmed while a higher nonce is
+// pending before the relayer considers it stuck (e.g. due to being underpriced). A stuck transaction will be
+// replaced with a zero value self-transfer at bumped fees to unblock subsequent batches.
+//
+// The default value is 120 seconds. Setting the value to 0 will disable stuck transaction detection.
+func (r *Relayer) SetStuckTxTimeout(timeout time.Duration) {
+	r.stuckTimeout = timeout
+}
+
+// UseMeter defines an opentelemetry meter object used by the Relayer to capture metrics on stuck transactions
+// and safe mode.
+func (r *Relayer) UseMeter(meter metric.Meter) error {
+	detected, err := meter.Int64Counter("relayer_stuck_txs_detected")
+	if err != nil {
+		return err
+	}
+	cancelled, err := meter.Int64Counter("relayer_stuck_txs_cancelled")
+	if err != nil {
+		return err
+	}
+
+	entered, err := meter.Int64Counter("relayer_safe_mode_entered")
+	if err != nil {
+		return err
+	}
+	_, err = meter.Int64ObservableGauge("relayer_safe_mode", metric.WithInt64Callback(r.observeSafeMode))
+	if err != nil {
+		return err
+	}
+
+	r.stuckDetected = detected
+	r.stuckCancelled = cancelled
+	r.safeModeEntered = entered
+	return nil
+}
+
+// cancelStuckTx checks if the EOA has a pending transaction at the next nonce that has not been included within
+// the stuck timeout. If so, it is replaced with a self-transfer at bumped fees. Returns an error if the
+// replacement could not be sent or included, in which case the current batch should not be attempted.
+func (r *Relayer) cancelStuckTx(opts *transaction.Opts) error {
+	if r.stuckTimeout == 0 {
+		return nil
+	}
+
+	latest, err := r.eth.NonceAt(context.Background(), r.eoa.Address, nil)
+	if err != nil {
+		return err
+	}
+	pending, err := r.eth.PendingNonceAt(context.Background(), r.eoa.Address)
+	if err != nil {
+		return err
+	}
+	if pending <= latest {
+		r.stuck = nil
+		return nil
+	}
+
+	if r.stuck == nil || r.stuck.nonce != latest {
+		r.stuck = &stuckTracker{nonce: latest, firstSeen: r.clock.Now()}
+		return nil
+	}
+	if r.clock.Since(r.stuck.firstSeen) < r.stuckTimeout {
+		return nil
+	}
+
+	l := r.logger.WithValues("nonce", latest, "stuck_for", r.clock.Since(r.stuck.firstSeen).String())
+	if r.stuckDetected != nil {
+		r.stuckDetected.Add(context.Background(), 1)
+	}
+
+	cOpts := *opts
+	cOpts.NoSend = false
+	txn, err := transaction.Cancel(&cOpts, latest, r.stuck.cancelTxn)
+	if err != nil {
+		l.Error(err, "cancel stuck tx error")
+		return err
+	}
+	r.stuck.cancelTxn = txn
+	r.stuck.firstSeen = r.clock.Now()
+	l = l.WithValues("cancel_txn_hash", txn.Hash().String())
+	if r.stuckCancelled != nil {
+		r.stuckCancelled.Add(context.Background(), 1)
+	}
+
+	if r.waitTimeout > 0 {
+		if _, err := transaction.Wait(txn, r.eth, r.waitTimeout); err != nil {
+			l.Error(err, "cancel stuck tx error")
+			return err
+		}
+	}
+	l.Info("cancel stuck tx ok")
+	return nil
+}
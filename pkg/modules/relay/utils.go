@@ -5,5 +5,6 @@ import (
 )
 
 var (
-	DefaultWaitTimeout = 72 * time.Second
+	DefaultWaitTimeout    = 72 * time.Second
+	DefaultStuckTxTimeout = 120 * time.Second
 )
@@ -0,0 +1,142 @@
+// Package risk implements a module for screening each UserOperation with an external risk scoring service.
+// This allows compliance checks to be centralized outside of the bundler.
+package risk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+)
+
+const (
+	// Allow accepts the UserOperation unless its score exceeds the max score.
+	Allow = "allow"
+
+	// Deny rejects the UserOperation.
+	Deny = "deny"
+)
+
+// Request is the JSON body sent to the risk service for each UserOperation.
+type Request struct {
+	UserOp     map[string]any `json:"userOp"`
+	UserOpHash common.Hash    `json:"userOpHash"`
+	EntryPoint common.Address `json:"entryPoint"`
+	ChainID    *hexutil.Big   `json:"chainId"`
+}
+
+// Response is the JSON body expected from the risk service. Score is optional and only checked if a max score
+// is set.
+type Response struct {
+	Decision string   `json:"decision"`
+	Score    *float64 `json:"score,omitempty"`
+	Reason   string   `json:"reason,omitempty"`
+}
+
+// Service forwards UserOperations to an external risk API.
+type Service struct {
+	url      string
+	client   *http.Client
+	failOpen bool
+	maxScore float64
+	logger   logr.Logger
+}
+
+// New returns a Service for the given URL. Requests that take longer than timeout are treated as a failure.
+func New(url string, timeout time.Duration, l logr.Logger) *Service {
+	return &Service{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+		logger: l.WithName("risk"),
+	}
+}
+
+// SetFailOpen sets whether UserOperations are accepted if the risk service fails to respond with a valid
+// decision.
+//
+// The default value is false.
+func (s *Service) SetFailOpen(failOpen bool) {
+	s.failOpen = failOpen
+}
+
+// SetMaxScore sets the highest score an allowed UserOperation can have. A value of 0 ignores the score.
+//
+// The default value is 0.
+func (s *Service) SetMaxScore(maxScore float64) {
+	s.maxScore = maxScore
+}
+
+// Screen sends the request to the risk service and returns its decoded response.
+func (s *Service) Screen(req *Request) (*Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		reason, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return nil, fmt.Errorf("risk: status %d: %s", res.StatusCode, strings.TrimSpace(string(reason)))
+	}
+	var out Response
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&out); err != nil {
+		return nil, fmt.Errorf("risk: invalid response: %w", err)
+	}
+	if out.Decision != Allow && out.Decision != Deny {
+		return nil, fmt.Errorf("risk: unknown decision %q", out.Decision)
+	}
+	return &out, nil
+}
+
+// UserOpHandler returns a UserOpHandler that is used by the Client to screen each UserOperation. Denied ops
+// or ops with a score above the max score are rejected. If the service fails, the op is rejected unless the
+// Service is set to fail open.
+func (s *Service) UserOpHandler() modules.UserOpHandlerFunc {
+	return func(ctx *modules.UserOpHandlerCtx) error {
+		op, err := ctx.UserOp.ToMap()
+		if err != nil {
+			return err
+		}
+		hash := ctx.UserOp.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)
+		res, err := s.Screen(&Request{
+			UserOp:     op,
+			UserOpHash: hash,
+			EntryPoint: ctx.EntryPoint,
+			ChainID:    (*hexutil.Big)(big.NewInt(0).Set(ctx.ChainID)),
+		})
+		if err != nil {
+			if s.failOpen {
+				s.logger.Error(err, "risk service failed, accepting op", "userop_hash", hash)
+				return nil
+			}
+			return errors.NewRPCError(errors.SERVICE_UNAVAILABLE, "risk service unavailable", nil)
+		}
+
+		if res.Decision == Deny {
+			return errors.NewRPCError(errors.REJECTED_BY_POLICY, fmt.Sprintf("rejected by risk service: %s", res.Reason), nil)
+		}
+		if s.maxScore > 0 && res.Score != nil && *res.Score > s.maxScore {
+			return errors.NewRPCError(
+				errors.REJECTED_BY_POLICY,
+				fmt.Sprintf("rejected by risk service: score %g exceeds %g", *res.Score, s.maxScore),
+				nil,
+			)
+		}
+		return nil
+	}
+}
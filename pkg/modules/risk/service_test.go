@@ -0,0 +1,89 @@
+package risk
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+)
+
+func newCtx() *modules.UserOpHandlerCtx {
+	return &modules.UserOpHandlerCtx{
+		UserOp:     testutils.MockValidInitUserOp(),
+		EntryPoint: testutils.ValidAddress1,
+		ChainID:    testutils.ChainID,
+	}
+}
+
+func newServer(t *testing.T, status int, res string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserOp["sender"] == nil {
+			t.Errorf("got %v, want request with op", err)
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(res))
+	}))
+}
+
+func getCode(err error) int {
+	if rpcErr, ok := err.(*errors.RPCError); ok {
+		return rpcErr.Code()
+	}
+	return 0
+}
+
+// TestUserOpHandlerActsOnDecision verifies that ops are accepted or rejected based on the decision and score.
+func TestUserOpHandlerActsOnDecision(t *testing.T) {
+	cases := []struct {
+		res  string
+		want int
+	}{
+		{`{"decision":"allow","score":10}`, 0},
+		{`{"decision":"allow","score":90}`, errors.REJECTED_BY_POLICY},
+		{`{"decision":"deny","reason":"sanctioned"}`, errors.REJECTED_BY_POLICY},
+	}
+	for _, c := range cases {
+		s := newServer(t, http.StatusOK, c.res)
+		svc := New(s.URL, time.Second, logr.Discard())
+		svc.SetMaxScore(50)
+
+		err := svc.UserOpHandler()(newCtx())
+		s.Close()
+		if c.want == 0 && err != nil {
+			t.Fatalf("%s: got %v, want nil", c.res, err)
+		} else if c.want != 0 && getCode(err) != c.want {
+			t.Fatalf("%s: got %v, want code %d", c.res, err, c.want)
+		}
+	}
+}
+
+// TestUserOpHandlerFailurePolicy verifies that service failures reject ops unless the service is set to fail
+// open.
+func TestUserOpHandlerFailurePolicy(t *testing.T) {
+	for _, res := range []struct {
+		status int
+		body   string
+	}{
+		{http.StatusInternalServerError, "down"},
+		{http.StatusOK, `{"decision":"maybe"}`},
+	} {
+		s := newServer(t, res.status, res.body)
+		svc := New(s.URL, time.Second, logr.Discard())
+
+		if err := svc.UserOpHandler()(newCtx()); getCode(err) != errors.SERVICE_UNAVAILABLE {
+			t.Fatalf("got %v, want code %d", err, errors.SERVICE_UNAVAILABLE)
+		}
+		svc.SetFailOpen(true)
+		if err := svc.UserOpHandler()(newCtx()); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		s.Close()
+	}
+}
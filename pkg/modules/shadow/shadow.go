@@ -0,0 +1,84 @@
+// Package shadow implements a wrapper for running new validation rules in a log-only mode alongside the rules
+// that are currently enforced. This allows operators to evaluate rule changes against live traffic before
+// enforcing them.
+package shadow
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Shadow provides wrappers for Client and Bundler modules that record what would have been rejected without
+// affecting the outcome of the UserOperation or batch.
+type Shadow struct {
+	logger     logr.Logger
+	rejections metric.Int64Counter
+}
+
+// New returns an instance of Shadow that logs and counts rejections by rule name.
+func New(l logr.Logger) (*Shadow, error) {
+	rejections, err := otel.GetMeterProvider().Meter("shadow").Int64Counter("bundler_shadow_rejections")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Shadow{
+		logger:     l.WithName("shadow"),
+		rejections: rejections,
+	}, nil
+}
+
+func (s *Shadow) record(rule string) {
+	s.rejections.Add(context.Background(), 1, metric.WithAttributes(attribute.String("rule", rule)))
+}
+
+// UserOpHandler wraps a UserOpHandler so that any returned error is logged as a shadow rejection instead of
+// being returned to the Client.
+func (s *Shadow) UserOpHandler(rule string, fn modules.UserOpHandlerFunc) modules.UserOpHandlerFunc {
+	return func(ctx *modules.UserOpHandlerCtx) error {
+		if err := fn(ctx); err != nil {
+			s.record(rule)
+			s.logger.
+				WithValues("rule", rule).
+				WithValues("entrypoint", ctx.EntryPoint.String()).
+				WithValues("userop_hash", ctx.UserOp.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)).
+				Info(err.Error())
+		}
+		return nil
+	}
+}
+
+// BatchHandler wraps a BatchHandler so that it runs against a copy of the batch. Any ops that would have
+// been dropped or any returned error is logged as a shadow rejection and the original batch is left unchanged.
+func (s *Shadow) BatchHandler(rule string, fn modules.BatchHandlerFunc) modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		l := s.logger.
+			WithValues("rule", rule).
+			WithValues("entrypoint", ctx.EntryPoint.String())
+
+		cpy := modules.NewBatchHandlerContext(
+			ctx.Batch,
+			ctx.EntryPoint,
+			ctx.ChainID,
+			ctx.BaseFee,
+			ctx.Tip,
+			ctx.GasPrice,
+		)
+		if err := fn(cpy); err != nil {
+			s.record(rule)
+			l.Info(err.Error())
+			return nil
+		}
+
+		for _, item := range cpy.PendingRemoval {
+			s.record(rule)
+			l.WithValues("userop_hash", item.Op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)).Info(item.Reason)
+		}
+		return nil
+	}
+}
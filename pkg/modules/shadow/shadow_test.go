@@ -0,0 +1,41 @@
+package shadow
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/logger"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// TestBatchHandlerDoesNotModifyBatch verifies that a shadowed BatchHandler which drops ops and returns an error
+// does not affect the original batch.
+func TestBatchHandlerDoesNotModifyBatch(t *testing.T) {
+	shd, err := New(logger.NewZeroLogr())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	fn := shd.BatchHandler("test", func(ctx *modules.BatchHandlerCtx) error {
+		ctx.MarkOpIndexForRemoval(0, "shadow rule")
+		return errors.New("shadow error")
+	})
+
+	op := testutils.MockValidInitUserOp()
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{op},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+	if err := fn(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(ctx.Batch) != 1 {
+		t.Fatalf("got batch length %d, want 1", len(ctx.Batch))
+	} else if len(ctx.PendingRemoval) != 0 {
+		t.Fatalf("got pending removal length %d, want 0", len(ctx.PendingRemoval))
+	}
+}
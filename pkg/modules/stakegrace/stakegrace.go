@@ -0,0 +1,221 @@
+// Package stakegrace implements modules that give a paymaster time to top up its stake if it drops below the
+// minimum while it has UserOperations in the mempool. During the grace period, new ops from the paymaster are
+// rejected while existing ops are kept and flagged. Once the grace period ends, any remaining ops are dropped
+// and the paymaster is treated like any other unstaked entity.
+package stakegrace
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/pkg/clock"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// Reason is the removal reason set on UserOperations dropped by DropAfterGrace.
+const Reason = "paymaster stake below minimum"
+
+// DefaultGracePeriod is the time a paymaster has to top up its stake before its pending ops are dropped.
+var DefaultGracePeriod = 10 * time.Minute
+
+// IsStakedFunc returns true if the deposit info meets the minimum stake requirements.
+type IsStakedFunc = func(dep *entrypoint.IStakeManagerDepositInfo) bool
+
+// NotifyFunc is called once when a paymaster enters a grace period.
+type NotifyFunc = func(ev *Event)
+
+// Event is the payload sent to the NotifyFunc when a paymaster's stake drops below the minimum.
+type Event struct {
+	EntryPoint      common.Address `json:"entryPoint"`
+	Paymaster       common.Address `json:"paymaster"`
+	Stake           *hexutil.Big   `json:"stake"`
+	UnstakeDelaySec uint32         `json:"unstakeDelaySec"`
+	GraceEndsAt     int64          `json:"graceEndsAt"`
+	UserOpHashes    []common.Hash  `json:"userOpHashes"`
+}
+
+type state int
+
+const (
+	stateOk state = iota
+	stateGrace
+	stateExpired
+)
+
+type key struct {
+	entryPoint common.Address
+	paymaster  common.Address
+}
+
+// Monitor tracks paymasters that have been seen with a sufficient stake so that a drop below the minimum can
+// be detected.
+type Monitor struct {
+	getStake    stake.GetStakeFunc
+	isStaked    IsStakedFunc
+	logger      logr.Logger
+	gracePeriod time.Duration
+	clock       clock.Clock
+	notify      NotifyFunc
+
+	mu      sync.Mutex
+	staked  map[key]bool
+	flagged map[key]time.Time
+}
+
+// New returns a Monitor with default settings.
+func New(getStake stake.GetStakeFunc, isStaked IsStakedFunc, l logr.Logger) *Monitor {
+	return &Monitor{
+		getStake:    getStake,
+		isStaked:    isStaked,
+		logger:      l.WithName("stakegrace"),
+		gracePeriod: DefaultGracePeriod,
+		clock:       clock.Real(),
+		notify:      func(ev *Event) {},
+		staked:      make(map[key]bool),
+		flagged:     make(map[key]time.Time),
+	}
+}
+
+// SetGracePeriod sets the time a paymaster has to top up its stake before its pending ops are dropped.
+//
+// The default value is 10 minutes.
+func (m *Monitor) SetGracePeriod(d time.Duration) {
+	m.gracePeriod = d
+}
+
+// SetClock sets the Clock used to track grace periods.
+//
+// The default value is clock.Real().
+func (m *Monitor) SetClock(c clock.Clock) {
+	m.clock = c
+}
+
+// SetNotifyFunc sets the function called when a paymaster enters a grace period.
+func (m *Monitor) SetNotifyFunc(fn NotifyFunc) {
+	m.notify = fn
+}
+
+// update checks the paymaster's current stake and returns its state. A grace period is started if a paymaster
+// that was previously staked is no longer staked and has pending ops.
+func (m *Monitor) update(
+	ep common.Address,
+	pm common.Address,
+	dep *entrypoint.IStakeManagerDepositInfo,
+	pending []common.Hash,
+) state {
+	k := key{ep, pm}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if dep != nil && m.isStaked(dep) {
+		if _, ok := m.flagged[k]; ok {
+			m.logger.Info("paymaster stake restored", "entrypoint", ep, "paymaster", pm)
+			delete(m.flagged, k)
+		}
+		m.staked[k] = true
+		return stateOk
+	}
+
+	if at, ok := m.flagged[k]; ok {
+		if m.clock.Since(at) <= m.gracePeriod {
+			return stateGrace
+		}
+		delete(m.flagged, k)
+		delete(m.staked, k)
+		m.logger.Info("paymaster stake grace period ended", "entrypoint", ep, "paymaster", pm)
+		return stateExpired
+	}
+	if !m.staked[k] || len(pending) == 0 {
+		return stateOk
+	}
+
+	now := m.clock.Now()
+	m.flagged[k] = now
+	ev := &Event{
+		EntryPoint:   ep,
+		Paymaster:    pm,
+		GraceEndsAt:  now.Add(m.gracePeriod).Unix(),
+		UserOpHashes: pending,
+	}
+	if dep != nil {
+		ev.Stake = (*hexutil.Big)(dep.Stake)
+		ev.UnstakeDelaySec = dep.UnstakeDelaySec
+	}
+	m.logger.Info(
+		"paymaster stake below minimum, flagging pending ops",
+		"entrypoint", ep,
+		"paymaster", pm,
+		"userop_hashes", pending,
+		"grace_ends_at", ev.GraceEndsAt,
+	)
+	m.notify(ev)
+	return stateGrace
+}
+
+func getHashes(ops []*userop.UserOperation, ep common.Address, chainID *big.Int) []common.Hash {
+	hashes := []common.Hash{}
+	for _, op := range ops {
+		hashes = append(hashes, op.GetUserOpHash(ep, chainID))
+	}
+	return hashes
+}
+
+// CheckStake returns a UserOpHandler that is used by the Client to reject new ops from a paymaster that is in
+// a grace period.
+func (m *Monitor) CheckStake() modules.UserOpHandlerFunc {
+	return func(ctx *modules.UserOpHandlerCtx) error {
+		pm := ctx.UserOp.GetPaymaster()
+		if pm == common.HexToAddress("0x") {
+			return nil
+		}
+
+		pending := getHashes(ctx.GetPendingPaymasterOps(), ctx.EntryPoint, ctx.ChainID)
+		if m.update(ctx.EntryPoint, pm, ctx.GetPaymasterDepositInfo(), pending) == stateGrace {
+			return errors.NewRPCError(
+				errors.INVALID_ENTITY_STAKE,
+				fmt.Sprintf("paymaster stake below minimum: %s", pm.Hex()),
+				nil,
+			)
+		}
+		return nil
+	}
+}
+
+// DropAfterGrace returns a BatchHandlerFunc that checks the stake of each paymaster in the batch. Ops from a
+// paymaster in a grace period are kept and ops from a paymaster whose grace period has ended are dropped.
+func (m *Monitor) DropAfterGrace() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		byPaymaster := make(map[common.Address][]*userop.UserOperation)
+		for _, op := range ctx.Batch {
+			if pm := op.GetPaymaster(); pm != common.HexToAddress("0x") {
+				byPaymaster[pm] = append(byPaymaster[pm], op)
+			}
+		}
+
+		states := make(map[common.Address]state)
+		for pm, ops := range byPaymaster {
+			dep, err := m.getStake(ctx.EntryPoint, pm)
+			if err != nil {
+				return err
+			}
+			states[pm] = m.update(ctx.EntryPoint, pm, dep, getHashes(ops, ctx.EntryPoint, ctx.ChainID))
+		}
+
+		end := len(ctx.Batch) - 1
+		for i := end; i >= 0; i-- {
+			if states[ctx.Batch[i].GetPaymaster()] == stateExpired {
+				ctx.MarkOpIndexForRemoval(i, Reason)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,128 @@
+package stakegrace
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/clock"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func isStaked(dep *entrypoint.IStakeManagerDepositInfo) bool {
+	return dep.Staked
+}
+
+func newTestMonitor(staked *bool) (*Monitor, *clock.Mock, *[]*Event) {
+	getStake := func(ep, addr common.Address) (*entrypoint.IStakeManagerDepositInfo, error) {
+		return &entrypoint.IStakeManagerDepositInfo{Staked: *staked, Stake: big.NewInt(0)}, nil
+	}
+	c := clock.NewMock(time.Unix(1700000000, 0))
+	events := []*Event{}
+	m := New(getStake, isStaked, logr.Discard())
+	m.SetGracePeriod(time.Minute)
+	m.SetClock(c)
+	m.SetNotifyFunc(func(ev *Event) { events = append(events, ev) })
+	return m, c, &events
+}
+
+func newTestBatch() *modules.BatchHandlerCtx {
+	op := testutils.MockValidInitUserOp()
+	op.PaymasterAndData = testutils.ValidAddress2.Bytes()
+	return modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{op},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+}
+
+// TestDropAfterGrace verifies that ops from a paymaster whose stake drops are kept during the grace period and
+// dropped after it ends.
+func TestDropAfterGrace(t *testing.T) {
+	staked := true
+	m, c, events := newTestMonitor(&staked)
+
+	ctx := newTestBatch()
+	if err := m.DropAfterGrace()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(ctx.PendingRemoval) != 0 || len(*events) != 0 {
+		t.Fatalf("got %d removed and %d events, want 0 and 0", len(ctx.PendingRemoval), len(*events))
+	}
+
+	staked = false
+	ctx = newTestBatch()
+	if err := m.DropAfterGrace()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(ctx.PendingRemoval) != 0 {
+		t.Fatalf("got %d removed during grace period, want 0", len(ctx.PendingRemoval))
+	} else if len(*events) != 1 || (*events)[0].Paymaster != testutils.ValidAddress2 {
+		t.Fatalf("got %d events, want 1 for paymaster", len(*events))
+	} else if len((*events)[0].UserOpHashes) != 1 {
+		t.Fatalf("got %d flagged ops, want 1", len((*events)[0].UserOpHashes))
+	}
+
+	c.Add(time.Minute)
+	ctx = newTestBatch()
+	if err := m.DropAfterGrace()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(ctx.PendingRemoval) != 0 || len(*events) != 1 {
+		t.Fatalf("got %d removed and %d events, want 0 and 1", len(ctx.PendingRemoval), len(*events))
+	}
+
+	c.Add(time.Second)
+	ctx = newTestBatch()
+	if err := m.DropAfterGrace()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(ctx.PendingRemoval) != 1 || ctx.PendingRemoval[0].Reason != Reason {
+		t.Fatalf("got %d removed, want 1 after grace period", len(ctx.PendingRemoval))
+	}
+}
+
+// TestStakeRestored verifies that a paymaster that tops up its stake during the grace period is no longer
+// flagged.
+func TestStakeRestored(t *testing.T) {
+	staked := true
+	m, c, _ := newTestMonitor(&staked)
+	dep := &entrypoint.IStakeManagerDepositInfo{Staked: true, Stake: big.NewInt(0)}
+	pending := []common.Hash{common.HexToHash("0x1")}
+
+	if s := m.update(testutils.ValidAddress1, testutils.ValidAddress2, dep, pending); s != stateOk {
+		t.Fatalf("got %d, want ok", s)
+	}
+	dep.Staked = false
+	if s := m.update(testutils.ValidAddress1, testutils.ValidAddress2, dep, pending); s != stateGrace {
+		t.Fatalf("got %d, want grace", s)
+	}
+	dep.Staked = true
+	if s := m.update(testutils.ValidAddress1, testutils.ValidAddress2, dep, pending); s != stateOk {
+		t.Fatalf("got %d, want ok", s)
+	}
+
+	c.Add(2 * time.Minute)
+	dep.Staked = false
+	if s := m.update(testutils.ValidAddress1, testutils.ValidAddress2, dep, pending); s != stateGrace {
+		t.Fatalf("got %d, want a new grace period", s)
+	}
+}
+
+// TestNeverStakedNotFlagged verifies that a paymaster that has never been seen with a sufficient stake is not
+// given a grace period so that the standard unstaked entity rules apply.
+func TestNeverStakedNotFlagged(t *testing.T) {
+	staked := false
+	m, _, events := newTestMonitor(&staked)
+
+	ctx := newTestBatch()
+	if err := m.DropAfterGrace()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(ctx.PendingRemoval) != 0 || len(*events) != 0 {
+		t.Fatalf("got %d removed and %d events, want 0 and 0", len(ctx.PendingRemoval), len(*events))
+	}
+}
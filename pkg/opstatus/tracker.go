@@ -0,0 +1,226 @@
+// Package opstatus tracks the lifecycle of UserOperations from the time they are accepted into the mempool
+// until they are included on-chain or dropped. Each transition is written by a Client or Bundler module so
+// that a single lookup can tell a user where their op is.
+package opstatus
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/dbutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/expire"
+)
+
+const (
+	// Pending is set when an op is accepted into the mempool.
+	Pending = "pending"
+
+	// Bundling is set when an op has passed all Bundler checks and is about to be sent in a batch.
+	Bundling = "bundling"
+
+	// Submitted is set when the batch containing an op has been sent.
+	Submitted = "submitted"
+
+	// Included is set when a receipt for an op has been found on-chain.
+	Included = "included"
+
+	// Dropped is set when an op is removed from the mempool without being included.
+	Dropped = "dropped"
+
+	// Expired is set when an op is dropped for being in the mempool for longer than its TTL.
+	Expired = "expired"
+
+	// Replaced is set when an op is replaced in the mempool by another op with the same sender and nonce.
+	Replaced = "replaced"
+)
+
+var (
+	// KeyPrefix is the prefix for all keys stored in the DB by the opstatus package.
+	KeyPrefix = "opstatus"
+
+	// RecordTTL is the default for how long the status of a UserOperation is kept after its last transition.
+	RecordTTL = 7 * 24 * time.Hour
+)
+
+// IsTerminal returns true if no further transitions are expected for a status.
+func IsTerminal(status string) bool {
+	return status == Included || status == Dropped || status == Expired || status == Replaced
+}
+
+func getStatusKey(userOpHash common.Hash) []byte {
+	return []byte(dbutils.JoinValues(KeyPrefix, userOpHash.String()))
+}
+
+// Record is the latest known status of a UserOperation.
+type Record struct {
+	Status          string `json:"status"`
+	TransactionHash string `json:"transactionHash,omitempty"`
+	Reason          string `json:"reason,omitempty"`
+	UpdatedAt       int64  `json:"updatedAt"`
+}
+
+// Tracker stores status transitions of UserOperations keyed by userOpHash.
+type Tracker struct {
+	db  *badger.DB
+	ttl time.Duration
+}
+
+// New returns a Tracker that persists status records to the given DB.
+func New(db *badger.DB) *Tracker {
+	return &Tracker{db, RecordTTL}
+}
+
+// SetRecordTTL defines how long the status of a UserOperation is kept after its last transition.
+//
+// The default value is RecordTTL.
+func (t *Tracker) SetRecordTTL(ttl time.Duration) {
+	t.ttl = ttl
+}
+
+func (t *Tracker) setRecord(txn *badger.Txn, userOpHash common.Hash, r *Record) error {
+	r.UpdatedAt = time.Now().Unix()
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return txn.SetEntry(badger.NewEntry(getStatusKey(userOpHash), b).WithTTL(t.ttl))
+}
+
+// Set stores the status of a UserOperation.
+func (t *Tracker) Set(userOpHash common.Hash, r *Record) error {
+	return t.db.Update(func(txn *badger.Txn) error {
+		return t.setRecord(txn, userOpHash, r)
+	})
+}
+
+// Get returns the status of a UserOperation or nil if it is unknown.
+func (t *Tracker) Get(userOpHash common.Hash) (*Record, error) {
+	var r *Record
+	err := t.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(getStatusKey(userOpHash))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			r = &Record{}
+			return json.Unmarshal(val, r)
+		})
+	})
+
+	return r, err
+}
+
+// Prune deletes terminal records that were last updated before the cutoff and returns the number deleted. This
+// is used to free up space ahead of the record TTL.
+func (t *Tracker) Prune(before time.Time) (int, error) {
+	keys := [][]byte{}
+	err := t.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(dbutils.JoinValues(KeyPrefix, ""))
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			err := item.Value(func(val []byte) error {
+				r := &Record{}
+				if err := json.Unmarshal(val, r); err != nil {
+					return err
+				}
+				if IsTerminal(r.Status) && r.UpdatedAt < before.Unix() {
+					keys = append(keys, item.KeyCopy(nil))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	wb := t.db.NewWriteBatch()
+	defer wb.Cancel()
+	for _, k := range keys {
+		if err := wb.Delete(k); err != nil {
+			return 0, err
+		}
+	}
+	if err := wb.Flush(); err != nil {
+		return 0, err
+	}
+	return len(keys), nil
+}
+
+// RecordPending returns a UserOpHandler used by the Client to set an accepted op to pending. Any pending op
+// from the same sender with the same nonce is set to replaced with the hash of the new op as the reason. This
+// module should be used after all validation modules.
+func (t *Tracker) RecordPending() modules.UserOpHandlerFunc {
+	return func(ctx *modules.UserOpHandlerCtx) error {
+		hash := ctx.UserOp.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)
+		return t.db.Update(func(txn *badger.Txn) error {
+			for _, op := range ctx.GetPendingSenderOps() {
+				prev := op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)
+				if op.Nonce.Cmp(ctx.UserOp.Nonce) != 0 || prev == hash {
+					continue
+				}
+				if err := t.setRecord(txn, prev, &Record{Status: Replaced, Reason: hash.String()}); err != nil {
+					return err
+				}
+			}
+			return t.setRecord(txn, hash, &Record{Status: Pending})
+		})
+	}
+}
+
+func (t *Tracker) recordBatch(ctx *modules.BatchHandlerCtx, status string) error {
+	txnHash, _ := ctx.Data["txn_hash"].(string)
+	return t.db.Update(func(txn *badger.Txn) error {
+		for _, op := range ctx.Batch {
+			hash := op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)
+			if err := t.setRecord(txn, hash, &Record{Status: status, TransactionHash: txnHash}); err != nil {
+				return err
+			}
+		}
+		for _, item := range ctx.PendingRemoval {
+			hash := item.Op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)
+			dropped := Dropped
+			if item.Reason == expire.Reason {
+				dropped = Expired
+			}
+			if err := t.setRecord(txn, hash, &Record{Status: dropped, Reason: item.Reason}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RecordBundling returns a BatchHandler used by the Bundler to set all ops in a batch to bundling and all ops
+// marked for removal to dropped or expired. This module should be used right before the relayer.
+func (t *Tracker) RecordBundling() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		return t.recordBatch(ctx, Bundling)
+	}
+}
+
+// RecordSubmitted returns a BatchHandler used by the Bundler to set all ops in a sent batch to submitted
+// along with the transaction hash. Ops dropped by the relayer are set to dropped. This module should be used
+// after the relayer.
+func (t *Tracker) RecordSubmitted() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		if _, ok := ctx.Data["txn_hash"].(string); !ok && len(ctx.Batch) > 0 {
+			return nil
+		}
+		return t.recordBatch(ctx, Submitted)
+	}
+}
@@ -0,0 +1,187 @@
+package opstatus
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/expire"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// TestBatchTransitions verifies that ops in a batch move from bundling to submitted with the transaction hash
+// while ops marked for removal are set to dropped with a reason.
+func TestBatchTransitions(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	sts := New(db)
+
+	op1 := testutils.MockValidInitUserOp()
+	op2 := testutils.MockValidInitUserOp()
+	op2.Sender = testutils.ValidAddress2
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{op1, op2},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		big.NewInt(1),
+		big.NewInt(1),
+		big.NewInt(1),
+	)
+	ctx.MarkOpIndexForRemoval(1, "AA21 didn't pay prefund")
+	hash1 := op1.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)
+	hash2 := op2.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)
+
+	if err := sts.RecordBundling()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if r, err := sts.Get(hash1); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if r == nil || r.Status != Bundling {
+		t.Fatalf("got %v, want %s", r, Bundling)
+	}
+
+	ctx.Data["txn_hash"] = "0x01"
+	if err := sts.RecordSubmitted()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if r, err := sts.Get(hash1); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if r == nil || r.Status != Submitted || r.TransactionHash != "0x01" {
+		t.Fatalf("got %v, want %s with txn hash 0x01", r, Submitted)
+	}
+	if r, err := sts.Get(hash2); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if r == nil || r.Status != Dropped || r.Reason != "AA21 didn't pay prefund" {
+		t.Fatalf("got %v, want %s with reason", r, Dropped)
+	}
+}
+
+// TestGetUnknown verifies that nil is returned for an op without a status.
+func TestGetUnknown(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+
+	op := testutils.MockValidInitUserOp()
+	if r, err := New(db).Get(op.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID)); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if r != nil {
+		t.Fatalf("got %v, want nil", r)
+	}
+}
+
+// TestExpiredTransition verifies that ops dropped for exceeding their TTL are set to expired.
+func TestExpiredTransition(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	sts := New(db)
+
+	op := testutils.MockValidInitUserOp()
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{op},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		big.NewInt(1),
+		big.NewInt(1),
+		big.NewInt(1),
+	)
+	ctx.MarkOpIndexForRemoval(0, expire.Reason)
+
+	if err := sts.RecordBundling()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if r, err := sts.Get(op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if r == nil || r.Status != Expired || !IsTerminal(r.Status) {
+		t.Fatalf("got %v, want %s", r, Expired)
+	}
+}
+
+// TestReplacedTransition verifies that a pending op with the same sender and nonce as a newly accepted op is
+// set to replaced with the hash of the new op.
+func TestReplacedTransition(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	sts := New(db)
+	mem, err := mempool.New(db)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	op1 := testutils.MockValidInitUserOp()
+	op2 := testutils.MockValidInitUserOp()
+	op2.MaxFeePerGas = big.NewInt(0).Add(op1.MaxFeePerGas, common.Big1)
+	hash1 := op1.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID)
+	hash2 := op2.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID)
+	for _, op := range []*userop.UserOperation{op1, op2} {
+		ctx, err := modules.NewUserOpHandlerContext(
+			op,
+			testutils.ValidAddress1,
+			testutils.ChainID,
+			mem,
+			stake.GetStakeFuncNoop(),
+		)
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		if err := sts.RecordPending()(ctx); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		if err := mem.AddOp(testutils.ValidAddress1, op); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+
+	if r, err := sts.Get(hash1); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if r == nil || r.Status != Replaced || r.Reason != hash2.String() {
+		t.Fatalf("got %v, want %s by %s", r, Replaced, hash2)
+	}
+	if r, err := sts.Get(hash2); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if r == nil || r.Status != Pending || IsTerminal(r.Status) {
+		t.Fatalf("got %v, want %s", r, Pending)
+	}
+}
+
+// TestPrune verifies that only terminal records last updated before the cutoff are deleted.
+func TestPrune(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	sts := New(db)
+
+	included := common.HexToHash("0x01")
+	pending := common.HexToHash("0x02")
+	if err := sts.Set(included, &Record{Status: Included}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := sts.Set(pending, &Record{Status: Pending}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if n, err := sts.Prune(time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if n != 0 {
+		t.Fatalf("got %d, want 0", n)
+	}
+
+	if n, err := sts.Prune(time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if n != 1 {
+		t.Fatalf("got %d, want 1", n)
+	}
+	if r, err := sts.Get(included); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if r != nil {
+		t.Fatalf("got %v, want nil", r)
+	}
+	if r, err := sts.Get(pending); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if r == nil || r.Status != Pending {
+		t.Fatalf("got %v, want %s", r, Pending)
+	}
+}
@@ -0,0 +1,64 @@
+package origin
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Header is the HTTP header a front-end can set to identify the dapp submitting a UserOperation.
+const Header = "X-Dapp-Id"
+
+// WithHeader returns a gin middleware that copies the dapp id from the request header into the options param
+// of a single eth_sendUserOperation request. A dappId already set in the options param takes precedence.
+func WithHeader() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		dappId := g.GetHeader(Header)
+		if dappId == "" {
+			g.Next()
+			return
+		}
+
+		body, err := io.ReadAll(g.Request.Body)
+		if err != nil {
+			_ = g.Error(err)
+			g.Abort()
+			return
+		}
+		g.Request.Body = io.NopCloser(bytes.NewReader(withDappId(body, dappId)))
+		g.Next()
+	}
+}
+
+// withDappId returns the request body with the dapp id added to the eth_sendUserOperation options param. The
+// body is returned unchanged if it is not a single eth_sendUserOperation request.
+func withDappId(body []byte, dappId string) []byte {
+	var req map[string]any
+	if err := json.Unmarshal(body, &req); err != nil || req["method"] != "eth_sendUserOperation" {
+		return body
+	}
+	params, ok := req["params"].([]any)
+	if !ok || len(params) < 2 || len(params) > 3 {
+		return body
+	}
+
+	if len(params) == 2 {
+		params = append(params, map[string]any{})
+	}
+	opts, ok := params[2].(map[string]any)
+	if !ok {
+		return body
+	}
+	if _, ok := opts["dappId"]; !ok {
+		opts["dappId"] = dappId
+	}
+	req["params"] = params
+
+	out, err := json.Marshal(req)
+	if err != nil {
+		return body
+	}
+	return out
+}
@@ -0,0 +1,53 @@
+package origin
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func getDappIdParam(t *testing.T, body []byte) any {
+	var req map[string]any
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Fatal(err)
+	}
+	params := req["params"].([]any)
+	if len(params) != 3 {
+		t.Fatalf("got %d params, want 3", len(params))
+	}
+	return params[2].(map[string]any)["dappId"]
+}
+
+func TestWithDappIdAddsOptions(t *testing.T) {
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_sendUserOperation","params":[{},"0x"]}`)
+	if got := getDappIdParam(t, withDappId(body, "my-dapp")); got != "my-dapp" {
+		t.Fatalf("got %v, want my-dapp", got)
+	}
+}
+
+func TestWithDappIdKeepsExistingParam(t *testing.T) {
+	body := []byte(
+		`{"jsonrpc":"2.0","id":1,"method":"eth_sendUserOperation","params":[{},"0x",{"dappId":"param"}]}`,
+	)
+	if got := getDappIdParam(t, withDappId(body, "header")); got != "param" {
+		t.Fatalf("got %v, want param", got)
+	}
+}
+
+func TestWithDappIdIgnoresOtherMethods(t *testing.T) {
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}`)
+	if got := withDappId(body, "my-dapp"); string(got) != string(body) {
+		t.Fatalf("got %s, want unchanged body", got)
+	}
+}
+
+func TestValidateDappId(t *testing.T) {
+	if err := ValidateDappId("my.dapp_v2-beta"); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := ValidateDappId(""); err == nil {
+		t.Fatal("got nil, want err for empty id")
+	}
+	if err := ValidateDappId("bad id"); err == nil {
+		t.Fatal("got nil, want err for id with a space")
+	}
+}
@@ -0,0 +1,139 @@
+// Package origin tags UserOperations with the dapp that submitted them so that bundler operators can
+// attribute traffic, bill per dapp, and debug integration-specific issues.
+package origin
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/dbutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var (
+	// KeyPrefix is the prefix for all keys stored in the DB by the origin package.
+	KeyPrefix = "origin"
+
+	// RecordTTL is how long the dapp id of a UserOperation is kept after submission.
+	RecordTTL = 7 * 24 * time.Hour
+
+	dappIdPrefix = dbutils.JoinValues(KeyPrefix, "dappId")
+	dappIdRegex  = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+)
+
+func getDappIdKey(userOpHash common.Hash) []byte {
+	return []byte(dbutils.JoinValues(dappIdPrefix, userOpHash.String()))
+}
+
+// ValidateDappId returns an error if the dapp id is not 1 to 64 characters of letters, digits, ".", "_", or
+// "-".
+func ValidateDappId(dappId string) error {
+	if !dappIdRegex.MatchString(dappId) {
+		return errors.New("dappId: must be 1-64 characters of [A-Za-z0-9._-]")
+	}
+	return nil
+}
+
+// Tracker stores the dapp id of each tagged UserOperation and records per dapp metrics and events.
+type Tracker struct {
+	db       *badger.DB
+	logger   logr.Logger
+	received metric.Int64Counter
+	included metric.Int64Counter
+}
+
+// New returns a Tracker that uses the global meter provider for per dapp metrics.
+func New(db *badger.DB, l logr.Logger) (*Tracker, error) {
+	meter := otel.GetMeterProvider().Meter("origin")
+	received, err := meter.Int64Counter("bundler_dapp_ops_received")
+	if err != nil {
+		return nil, err
+	}
+	included, err := meter.Int64Counter("bundler_dapp_ops_included")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tracker{
+		db:       db,
+		logger:   l.WithName("origin"),
+		received: received,
+		included: included,
+	}, nil
+}
+
+// Record stores the dapp id for a UserOperation that has been accepted into the mempool.
+func (t *Tracker) Record(userOpHash common.Hash, dappId string) error {
+	if err := ValidateDappId(dappId); err != nil {
+		return err
+	}
+
+	err := t.db.Update(func(txn *badger.Txn) error {
+		e := badger.NewEntry(getDappIdKey(userOpHash), []byte(dappId)).WithTTL(RecordTTL)
+		return txn.SetEntry(e)
+	})
+	if err != nil {
+		return err
+	}
+
+	t.received.Add(context.Background(), 1, metric.WithAttributes(attribute.String("dapp_id", dappId)))
+	t.logger.
+		WithValues("userop_hash", userOpHash.String()).
+		WithValues("dapp_id", dappId).
+		Info("dapp op received")
+	return nil
+}
+
+// Get returns the dapp id for a UserOperation or an empty string if it was not tagged.
+func (t *Tracker) Get(userOpHash common.Hash) (string, error) {
+	dappId := ""
+	err := t.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(getDappIdKey(userOpHash))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			dappId = string(val)
+			return nil
+		})
+	})
+
+	return dappId, err
+}
+
+// IncOpsIncluded returns a BatchHandler used by the Bundler to record metrics and events for tagged ops in a
+// batch that has been sent. This module should be used after the relayer.
+func (t *Tracker) IncOpsIncluded() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		for _, op := range ctx.Batch {
+			hash := op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)
+			dappId, err := t.Get(hash)
+			if err != nil {
+				return err
+			} else if dappId == "" {
+				continue
+			}
+
+			t.included.Add(context.Background(), 1, metric.WithAttributes(attribute.String("dapp_id", dappId)))
+			l := t.logger.
+				WithValues("userop_hash", hash.String()).
+				WithValues("dapp_id", dappId)
+			if txn, ok := ctx.Data["txn_hash"].(string); ok {
+				l = l.WithValues("txn_hash", txn)
+			}
+			l.Info("dapp op included")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,106 @@
+// Package passkey provides an allowlist of P256/WebAuthn verifier contracts that are commonly called by
+// passkey based smart accounts. Verifying a P256 signature in the EVM executes a large number of steps, so
+// skipping the internals of a known verifier during validation tracing significantly speeds up simulation.
+package passkey
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Verifier is a known verifier contract. VerificationGas is the precomputed gas required for a single call
+// to the verifier and is used in place of the per step OOG checks that are skipped during tracing.
+type Verifier struct {
+	Address         common.Address `json:"address"`
+	CodeHash        common.Hash    `json:"codeHash"`
+	VerificationGas uint64         `json:"verificationGas"`
+}
+
+// ParseVerifiers decodes a list of verifiers in the form "address:codeHash:verificationGas".
+func ParseVerifiers(vals []string) ([]*Verifier, error) {
+	verifiers := []*Verifier{}
+	for _, val := range vals {
+		parts := strings.Split(val, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("passkey: verifier %s must be in the form address:codeHash:verificationGas", val)
+		}
+		if !common.IsHexAddress(parts[0]) {
+			return nil, fmt.Errorf("passkey: verifier %s has an invalid address", val)
+		}
+		gas, err := strconv.ParseUint(parts[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("passkey: verifier %s has an invalid verificationGas", val)
+		}
+
+		verifiers = append(verifiers, &Verifier{
+			Address:         common.HexToAddress(parts[0]),
+			CodeHash:        common.HexToHash(parts[1]),
+			VerificationGas: gas,
+		})
+	}
+	return verifiers, nil
+}
+
+// GetCodeFunc returns the deployed bytecode at an address.
+type GetCodeFunc = func(addr common.Address) ([]byte, error)
+
+// GetCodeWithEthClient returns an implementation of GetCodeFunc that relies on an eth client to fetch the
+// code at the latest block.
+func GetCodeWithEthClient(eth *ethclient.Client) GetCodeFunc {
+	return func(addr common.Address) ([]byte, error) {
+		return eth.CodeAt(context.Background(), addr, nil)
+	}
+}
+
+// Allowlist is a set of verifiers whose deployed bytecode has been checked against the expected code hash.
+type Allowlist struct {
+	verifiers map[common.Address]*Verifier
+}
+
+// NewAllowlist returns an Allowlist after verifying that the code deployed at each verifier address matches
+// its code hash. An error is returned if any verifier does not match.
+func NewAllowlist(gc GetCodeFunc, verifiers ...*Verifier) (*Allowlist, error) {
+	a := &Allowlist{verifiers: make(map[common.Address]*Verifier)}
+	for _, v := range verifiers {
+		code, err := gc(v.Address)
+		if err != nil {
+			return nil, err
+		}
+		if len(code) == 0 {
+			return nil, fmt.Errorf("passkey: verifier %s has no deployed code", v.Address)
+		}
+		if h := crypto.Keccak256Hash(code); h != v.CodeHash {
+			return nil, fmt.Errorf("passkey: verifier %s has code hash %s, want %s", v.Address, h, v.CodeHash)
+		}
+
+		a.verifiers[v.Address] = v
+	}
+	return a, nil
+}
+
+// Get returns the verifier at the given address if it is allowlisted.
+func (a *Allowlist) Get(addr common.Address) (*Verifier, bool) {
+	if a == nil {
+		return nil, false
+	}
+	v, ok := a.verifiers[addr]
+	return v, ok
+}
+
+// Addresses returns the addresses of all allowlisted verifiers.
+func (a *Allowlist) Addresses() []common.Address {
+	addrs := []common.Address{}
+	if a == nil {
+		return addrs
+	}
+	for addr := range a.verifiers {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
@@ -0,0 +1,53 @@
+package passkey
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	verifierAddr = common.HexToAddress("0xc2b78104907F722DABAc4C69f826a522B2754De4")
+	verifierCode = []byte{0x60, 0x00, 0x60, 0x00, 0xf3}
+)
+
+func getCodeFunc(code []byte) GetCodeFunc {
+	return func(addr common.Address) ([]byte, error) {
+		return code, nil
+	}
+}
+
+func TestParseVerifiers(t *testing.T) {
+	hash := crypto.Keccak256Hash(verifierCode)
+	vs, err := ParseVerifiers([]string{verifierAddr.Hex() + ":" + hash.Hex() + ":300000"})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if len(vs) != 1 || vs[0].Address != verifierAddr || vs[0].CodeHash != hash || vs[0].VerificationGas != 300000 {
+		t.Fatalf("got %+v, want parsed verifier", vs[0])
+	}
+
+	if _, err := ParseVerifiers([]string{verifierAddr.Hex() + ":300000"}); err == nil {
+		t.Fatal("got nil, want err for missing code hash")
+	}
+}
+
+func TestNewAllowlistVerifiesCodeHash(t *testing.T) {
+	v := &Verifier{Address: verifierAddr, CodeHash: crypto.Keccak256Hash(verifierCode), VerificationGas: 300000}
+
+	a, err := NewAllowlist(getCodeFunc(verifierCode), v)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if got, ok := a.Get(verifierAddr); !ok || got != v {
+		t.Fatalf("got %v, want allowlisted verifier", got)
+	}
+
+	if _, err := NewAllowlist(getCodeFunc([]byte{0x00}), v); err == nil {
+		t.Fatal("got nil, want err for mismatched code hash")
+	}
+	if _, err := NewAllowlist(getCodeFunc([]byte{}), v); err == nil {
+		t.Fatal("got nil, want err for no deployed code")
+	}
+}
@@ -0,0 +1,69 @@
+package paymaster
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// MethodGetPaymasterStubData is the ERC-7677 method for fetching paymaster fields to use during gas
+	// estimation.
+	MethodGetPaymasterStubData = "pm_getPaymasterStubData"
+
+	// MethodGetPaymasterData is the ERC-7677 method for fetching the final paymaster fields to sign over.
+	MethodGetPaymasterData = "pm_getPaymasterData"
+)
+
+var (
+	// ErrServiceNotConfigured is returned when the paymaster namespace is called without a paymaster service.
+	ErrServiceNotConfigured = errors.New("paymaster: service not configured")
+)
+
+// GetPaymasterDataFunc provides a general interface for calling an ERC-7677 paymaster method. The result is
+// returned as is so that both EntryPoint v0.6 and v0.7 fields are supported.
+type GetPaymasterDataFunc = func(
+	method string,
+	op map[string]any,
+	ep common.Address,
+	chainID *big.Int,
+	context map[string]any,
+) (map[string]any, error)
+
+func GetPaymasterDataFuncNoop() GetPaymasterDataFunc {
+	return func(
+		method string,
+		op map[string]any,
+		ep common.Address,
+		chainID *big.Int,
+		context map[string]any,
+	) (map[string]any, error) {
+		return nil, ErrServiceNotConfigured
+	}
+}
+
+// GetPaymasterDataWithRpcClient returns a GetPaymasterDataFunc that proxies ERC-7677 methods to a paymaster
+// service.
+func GetPaymasterDataWithRpcClient(rpc *rpc.Client, timeout time.Duration) GetPaymasterDataFunc {
+	return func(
+		method string,
+		op map[string]any,
+		ep common.Address,
+		chainID *big.Int,
+		pmCtx map[string]any,
+	) (map[string]any, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		var res map[string]any
+		if err := rpc.CallContext(ctx, &res, method, op, ep, hexutil.EncodeBig(chainID), pmCtx); err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+}
@@ -0,0 +1,61 @@
+package paymaster
+
+import (
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type testService struct{}
+
+func (s *testService) GetPaymasterStubData(
+	op map[string]any,
+	ep common.Address,
+	chainID string,
+	ctx map[string]any,
+) (map[string]any, error) {
+	return map[string]any{"paymasterAndData": "0x01", "chainId": chainID, "policy": ctx["policyId"]}, nil
+}
+
+// TestGetPaymasterDataWithRpcClient verifies that ERC-7677 calls are proxied with the params in spec order.
+func TestGetPaymasterDataWithRpcClient(t *testing.T) {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("pm", &testService{}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	hs := httptest.NewServer(srv)
+	defer hs.Close()
+
+	c, err := rpc.Dial(hs.URL)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	defer c.Close()
+
+	fn := GetPaymasterDataWithRpcClient(c, time.Second)
+	res, err := fn(
+		MethodGetPaymasterStubData,
+		map[string]any{"sender": "0x0000000000000000000000000000000000000001"},
+		common.HexToAddress("0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789"),
+		big.NewInt(1),
+		map[string]any{"policyId": "abc"},
+	)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if res["paymasterAndData"] != "0x01" || res["chainId"] != "0x1" || res["policy"] != "abc" {
+		t.Fatalf("got %v, want proxied result", res)
+	}
+}
+
+// TestGetPaymasterDataFuncNoop verifies that the pm namespace errors if a service is not configured.
+func TestGetPaymasterDataFuncNoop(t *testing.T) {
+	_, err := GetPaymasterDataFuncNoop()(MethodGetPaymasterData, nil, common.Address{}, big.NewInt(1), nil)
+	if err != ErrServiceNotConfigured {
+		t.Fatalf("got %v, want %v", err, ErrServiceNotConfigured)
+	}
+}
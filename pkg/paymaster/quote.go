@@ -0,0 +1,56 @@
+// Package paymaster provides helpers for quoting fees charged by ERC-20 paymasters and for proxying ERC-7677
+// paymaster service methods.
+package paymaster
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+var (
+	// TokenOracleABI is the interface a paymaster must implement to quote its exchange rate. This matches
+	// IOracle from the reference ERC-20 paymaster implementations.
+	TokenOracleABI = `[{"inputs":[{"internalType":"uint256","name":"ethOutput","type":"uint256"}],` +
+		`"name":"getTokenValueOfEth","outputs":[{"internalType":"uint256","name":"tokenInput",` +
+		`"type":"uint256"}],"stateMutability":"view","type":"function"}]`
+	tokenOracle, _ = abi.JSON(strings.NewReader(TokenOracleABI))
+)
+
+// TokenQuote is the amount of ERC-20 tokens a paymaster will charge to sponsor a UserOperation at current
+// gas prices.
+type TokenQuote struct {
+	Paymaster   common.Address `json:"paymaster"`
+	GasPrice    *big.Int       `json:"gasPrice"`
+	MaxGas      *big.Int       `json:"maxGas"`
+	MaxCost     *big.Int       `json:"maxCost"`
+	TokenAmount *big.Int       `json:"tokenAmount"`
+}
+
+// GetTokenValueOfEthFunc provides a general interface for converting an amount of wei to the amount of
+// ERC-20 tokens a paymaster will charge for it.
+type GetTokenValueOfEthFunc = func(paymaster common.Address, ethOutput *big.Int) (*big.Int, error)
+
+func GetTokenValueOfEthFuncNoop() GetTokenValueOfEthFunc {
+	return func(paymaster common.Address, ethOutput *big.Int) (*big.Int, error) {
+		return big.NewInt(0), nil
+	}
+}
+
+// GetTokenValueOfEthWithEthClient returns a GetTokenValueOfEthFunc that relies on an eth client to call the
+// paymaster's quoted exchange rate.
+func GetTokenValueOfEthWithEthClient(eth *ethclient.Client) GetTokenValueOfEthFunc {
+	return func(paymaster common.Address, ethOutput *big.Int) (*big.Int, error) {
+		c := bind.NewBoundContract(paymaster, tokenOracle, eth, eth, eth)
+
+		var out []any
+		if err := c.Call(nil, &out, "getTokenValueOfEth", ethOutput); err != nil {
+			return nil, err
+		}
+		return abi.ConvertType(out[0], new(big.Int)).(*big.Int), nil
+	}
+}
@@ -0,0 +1,23 @@
+package paymaster
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// TestTokenOracleABIPacksQuoteCall verifies that the oracle ABI encodes calls to getTokenValueOfEth with the
+// expected selector.
+func TestTokenOracleABIPacksQuoteCall(t *testing.T) {
+	data, err := tokenOracle.Pack("getTokenValueOfEth", big.NewInt(1))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	// bytes4(keccak256("getTokenValueOfEth(uint256)"))
+	want := hexutil.Encode(tokenOracle.Methods["getTokenValueOfEth"].ID)
+	if got := hexutil.Encode(data[:4]); got != want || len(data) != 36 {
+		t.Fatalf("got %s with length %d, want %s with length 36", got, len(data), want)
+	}
+}
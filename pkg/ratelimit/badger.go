@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/stackup-wallet/stackup-bundler/internal/dbutils"
+)
+
+var (
+	// KeyPrefix is the prefix for all keys stored in the DB by the ratelimit package.
+	KeyPrefix = "ratelimit"
+
+	// maxConflictRetries is the number of times a Take is retried when concurrent requests update the same
+	// bucket.
+	maxConflictRetries = 5
+)
+
+func getBucketKey(key string) []byte {
+	return []byte(dbutils.JoinValues(KeyPrefix, key))
+}
+
+// BadgerStore persists token buckets in the DB so that limits carry over restarts. Buckets expire once they
+// would have refilled.
+type BadgerStore struct {
+	db *badger.DB
+}
+
+// NewBadgerStore returns a BadgerStore for the given DB.
+func NewBadgerStore(db *badger.DB) *BadgerStore {
+	return &BadgerStore{db}
+}
+
+func (s *BadgerStore) take(key string, lim *Limit, n int, now time.Time) (bool, error) {
+	ok := false
+	err := s.db.Update(func(txn *badger.Txn) error {
+		dbKey := getBucketKey(key)
+		b := &bucket{Tokens: float64(lim.Burst), At: now.UnixNano()}
+		item, err := txn.Get(dbKey)
+		if err != nil && err != badger.ErrKeyNotFound {
+			return err
+		} else if err == nil {
+			if err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, b)
+			}); err != nil {
+				return err
+			}
+		}
+
+		if ok = b.take(lim, n, now); !ok {
+			return nil
+		}
+		val, err := json.Marshal(b)
+		if err != nil {
+			return err
+		}
+		return txn.SetEntry(badger.NewEntry(dbKey, val).WithTTL(refillTime(lim) + time.Minute))
+	})
+	return ok, err
+}
+
+// Take implements the Store interface.
+func (s *BadgerStore) Take(key string, lim *Limit, n int, now time.Time) (bool, error) {
+	for i := 0; ; i++ {
+		ok, err := s.take(key, lim, n, now)
+		if err == badger.ErrConflict && i < maxConflictRetries {
+			continue
+		}
+		return ok, err
+	}
+}
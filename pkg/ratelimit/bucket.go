@@ -0,0 +1,131 @@
+// Package ratelimit implements token bucket rate limiting of JSON-RPC calls per client and method. Clients
+// are identified by their API key if one was used or their IP address otherwise.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMethod is the method name used to set a limit for all methods without their own limit.
+const DefaultMethod = "*"
+
+// Limit is a token bucket that refills at Rate tokens per second up to a max of Burst tokens. Each call takes
+// one token.
+type Limit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// Limits maps a method name to its Limit. Methods without a Limit use the DefaultMethod Limit if set and are
+// otherwise unlimited.
+type Limits map[string]*Limit
+
+// Get returns the Limit for a method or nil if it is unlimited.
+func (l Limits) Get(method string) *Limit {
+	if lim, ok := l[method]; ok {
+		return lim
+	}
+	return l[DefaultMethod]
+}
+
+// ParseLimits decodes a list of limits in the form "method:rate:burst". Use DefaultMethod as the method to
+// set a limit for all other methods.
+func ParseLimits(vals []string) (Limits, error) {
+	limits := Limits{}
+	for _, val := range vals {
+		parts := strings.Split(strings.TrimSpace(val), ":")
+		if len(parts) != 3 || parts[0] == "" {
+			return nil, fmt.Errorf("ratelimit: limit %s must be in the form method:rate:burst", val)
+		}
+		rate, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || rate <= 0 || math.IsInf(rate, 0) {
+			return nil, fmt.Errorf("ratelimit: limit %s has an invalid rate", val)
+		}
+		burst, err := strconv.Atoi(parts[2])
+		if err != nil || burst <= 0 {
+			return nil, fmt.Errorf("ratelimit: limit %s has an invalid burst", val)
+		}
+
+		limits[parts[0]] = &Limit{Rate: rate, Burst: burst}
+	}
+	return limits, nil
+}
+
+// bucket is the state of a single token bucket.
+type bucket struct {
+	Tokens float64 `json:"tokens"`
+	At     int64   `json:"at"`
+}
+
+// take refills the bucket up to now and removes n tokens. False is returned if there are not enough tokens,
+// in which case the bucket is left unchanged.
+func (b *bucket) take(lim *Limit, n int, now time.Time) bool {
+	elapsed := now.Sub(time.Unix(0, b.At)).Seconds()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	tokens := math.Min(float64(lim.Burst), b.Tokens+elapsed*lim.Rate)
+	if tokens < float64(n) {
+		return false
+	}
+
+	b.Tokens = tokens - float64(n)
+	b.At = now.UnixNano()
+	return true
+}
+
+// refillTime returns how long it takes for an empty bucket to be full again.
+func refillTime(lim *Limit) time.Duration {
+	return time.Duration(float64(lim.Burst) / lim.Rate * float64(time.Second))
+}
+
+// Store persists token buckets by key.
+type Store interface {
+	// Take removes n tokens from the bucket at key and returns false if there are not enough tokens.
+	Take(key string, lim *Limit, n int, now time.Time) (bool, error)
+}
+
+// MemoryStore keeps token buckets in memory. Buckets are lost on restart.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+// sweep removes idle buckets once per minute to bound memory usage. A bucket idle for longer than its refill
+// time is full and equivalent to a new one.
+func (s *MemoryStore) sweep(lim *Limit, now time.Time) {
+	if now.Sub(s.swept) < time.Minute {
+		return
+	}
+	s.swept = now
+	for key, b := range s.buckets {
+		if now.Sub(time.Unix(0, b.At)) > refillTime(lim)+time.Minute {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// Take implements the Store interface.
+func (s *MemoryStore) Take(key string, lim *Limit, n int, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(lim, now)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{Tokens: float64(lim.Burst), At: now.UnixNano()}
+		s.buckets[key] = b
+	}
+	return b.take(lim, n, now), nil
+}
@@ -0,0 +1,121 @@
+package ratelimit
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/dbutils"
+	"github.com/stackup-wallet/stackup-bundler/internal/ginutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/clock"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+)
+
+// parseRequests returns the number of tokens needed for each method in a single or batch request, in order
+// of first appearance, along with the id of the first call. Each call takes one token except for
+// eth_sendUserOperation with an array of UserOperations, which takes one token per op.
+func parseRequests(body []byte) (methods []string, counts map[string]int, id any) {
+	counts = make(map[string]int)
+	for i, r := range jsonrpc.ParseRequests(body) {
+		if i == 0 {
+			id = r.Id
+		}
+		if _, ok := counts[r.Method]; !ok {
+			methods = append(methods, r.Method)
+		}
+
+		n := 1
+		if r.Method == jsonrpc.SendUserOperationMethod {
+			if ops := len(r.UserOps()); ops > 1 {
+				n = ops
+			}
+		}
+		counts[r.Method] += n
+	}
+	return methods, counts, id
+}
+
+// Limiter applies per method token bucket limits to each client of the RPC server.
+type Limiter struct {
+	store     Store
+	ipLimits  Limits
+	keyLimits Limits
+	clock     clock.Clock
+}
+
+// New returns a Limiter that keeps buckets in the given Store. Clients with an API key are limited by
+// keyLimits and all other clients are limited per IP by ipLimits. If keyLimits is empty, ipLimits also apply
+// to API keys.
+func New(store Store, ipLimits Limits, keyLimits Limits) *Limiter {
+	if len(keyLimits) == 0 {
+		keyLimits = ipLimits
+	}
+	return &Limiter{store, ipLimits, keyLimits, clock.Real()}
+}
+
+// SetClock sets the Clock used to refill buckets.
+//
+// The default value is clock.Real().
+func (r *Limiter) SetClock(c clock.Clock) {
+	r.clock = c
+}
+
+// Middleware returns a gin middleware that rejects requests from a client that has run out of tokens for any
+// method called, where each call in a batch and each op in an eth_sendUserOperation array takes one token.
+// Requests from a delegated relayer are skipped since relayers are limited by their own quota. It must run
+// after the apikey and delegate middleware so that clients can be identified.
+func (r *Limiter) Middleware(l logr.Logger) gin.HandlerFunc {
+	l = l.WithName("ratelimit")
+
+	return func(g *gin.Context) {
+		if _, ok := ginutils.GetRelayer(g); ok {
+			g.Next()
+			return
+		}
+
+		body, err := io.ReadAll(g.Request.Body)
+		if err != nil {
+			_ = g.Error(err)
+			g.Abort()
+			return
+		}
+		g.Request.Body = io.NopCloser(bytes.NewReader(body))
+		methods, counts, id := parseRequests(body)
+
+		client := dbutils.JoinValues("ip", g.ClientIP())
+		limits := r.ipLimits
+		if name, ok := ginutils.GetApiKey(g); ok {
+			client = dbutils.JoinValues("key", name)
+			limits = r.keyLimits
+		}
+
+		now := r.clock.Now()
+		for _, m := range methods {
+			lim := limits.Get(m)
+			if lim == nil {
+				continue
+			}
+
+			ok, err := r.store.Take(dbutils.JoinValues(client, m), lim, counts[m], now)
+			if err != nil {
+				_ = g.Error(err)
+				g.Abort()
+				return
+			} else if !ok {
+				l.Info("rate limit exceeded", "client", client, "method", m)
+				jsonrpc.AbortWithError(
+					g,
+					errors.BANNED_OR_THROTTLED_ENTITY,
+					fmt.Sprintf("ratelimit: too many requests for %s", m),
+					id,
+				)
+				return
+			}
+		}
+
+		g.Next()
+	}
+}
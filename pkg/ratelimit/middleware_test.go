@@ -0,0 +1,207 @@
+package ratelimit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/ginutils"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/clock"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+)
+
+var (
+	sendBody    = []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_sendUserOperation","params":[{},"0x"]}`)
+	chainIdBody = []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_chainId"}`)
+	batchBody   = []byte(`[{"jsonrpc":"2.0","id":1,"method":"eth_chainId"},{"jsonrpc":"2.0","id":2,"method":"eth_chainId"}]`)
+)
+
+func newRouter(rl *Limiter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if err := r.SetTrustedProxies([]string{"192.0.2.1"}); err != nil {
+		panic(err)
+	}
+	r.POST("/", func(g *gin.Context) {
+		if key := g.GetHeader("X-Api-Key"); key != "" {
+			g.Set(ginutils.ApiKeyContextKey, key)
+		}
+		g.Next()
+	}, rl.Middleware(logr.Discard()), func(g *gin.Context) {
+		g.JSON(http.StatusOK, gin.H{"result": true})
+	})
+	return r
+}
+
+func doRequest(t *testing.T, r *gin.Engine, ip string, key string, body []byte) map[string]any {
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("X-Forwarded-For", ip)
+	if key != "" {
+		req.Header.Set("X-Api-Key", key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var res map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func isThrottled(res map[string]any) bool {
+	e, ok := res["error"].(map[string]any)
+	return ok && int(e["code"].(float64)) == errors.BANNED_OR_THROTTLED_ENTITY
+}
+
+// TestMiddlewareLimitsPerMethod verifies that each method uses its own bucket, that methods without a limit
+// fall back to the default, and that each call in a batch takes a token.
+func TestMiddlewareLimitsPerMethod(t *testing.T) {
+	c := clock.NewMock(time.Unix(1000, 0))
+	rl := New(NewMemoryStore(), Limits{
+		"eth_sendUserOperation": {Rate: 1, Burst: 1},
+		DefaultMethod:           {Rate: 1, Burst: 3},
+	}, nil)
+	rl.SetClock(c)
+	r := newRouter(rl)
+
+	if res := doRequest(t, r, "1.1.1.1", "", sendBody); isThrottled(res) {
+		t.Fatalf("got %v, want result", res)
+	}
+	if res := doRequest(t, r, "1.1.1.1", "", sendBody); !isThrottled(res) {
+		t.Fatalf("got %v, want throttled", res)
+	}
+	if res := doRequest(t, r, "1.1.1.1", "", batchBody); isThrottled(res) {
+		t.Fatalf("got %v, want result", res)
+	}
+	if res := doRequest(t, r, "1.1.1.1", "", batchBody); !isThrottled(res) {
+		t.Fatalf("got %v, want throttled", res)
+	}
+	if res := doRequest(t, r, "1.1.1.1", "", chainIdBody); isThrottled(res) {
+		t.Fatalf("got %v, want result", res)
+	}
+
+	c.Add(time.Second)
+	if res := doRequest(t, r, "1.1.1.1", "", sendBody); isThrottled(res) {
+		t.Fatalf("got %v, want refilled result", res)
+	}
+}
+
+// TestMiddlewareLimitsPerClient verifies that IPs and API keys have separate buckets and that API keys use
+// their own limits.
+func TestMiddlewareLimitsPerClient(t *testing.T) {
+	c := clock.NewMock(time.Unix(1000, 0))
+	rl := New(
+		NewMemoryStore(),
+		Limits{DefaultMethod: {Rate: 1, Burst: 1}},
+		Limits{DefaultMethod: {Rate: 1, Burst: 2}},
+	)
+	rl.SetClock(c)
+	r := newRouter(rl)
+
+	if res := doRequest(t, r, "1.1.1.1", "", sendBody); isThrottled(res) {
+		t.Fatalf("got %v, want result", res)
+	}
+	if res := doRequest(t, r, "2.2.2.2", "", sendBody); isThrottled(res) {
+		t.Fatalf("got %v, want result", res)
+	}
+	for i := 0; i < 2; i++ {
+		if res := doRequest(t, r, "1.1.1.1", "alice", sendBody); isThrottled(res) {
+			t.Fatalf("call %d: got %v, want result", i, res)
+		}
+	}
+	if res := doRequest(t, r, "2.2.2.2", "alice", sendBody); !isThrottled(res) {
+		t.Fatalf("got %v, want throttled", res)
+	}
+}
+
+// TestMiddlewareChargesPerOp verifies that each op in an eth_sendUserOperation array takes a token.
+func TestMiddlewareChargesPerOp(t *testing.T) {
+	c := clock.NewMock(time.Unix(1000, 0))
+	rl := New(NewMemoryStore(), Limits{"eth_sendUserOperation": {Rate: 1, Burst: 3}}, nil)
+	rl.SetClock(c)
+	r := newRouter(rl)
+
+	arrayBody := []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_sendUserOperation","params":[[{},{},{},{}],"0x"]}`)
+	if res := doRequest(t, r, "1.1.1.1", "", arrayBody); !isThrottled(res) {
+		t.Fatalf("got %v, want throttled", res)
+	}
+	caseBody := []byte(`{"jsonrpc":"2.0","id":1,"Method":"eth_chainId","method":"Eth_sendUserOperation","params":[[{},{},{}],"0x"]}`)
+	if res := doRequest(t, r, "1.1.1.1", "", caseBody); isThrottled(res) {
+		t.Fatalf("got %v, want result", res)
+	}
+	if res := doRequest(t, r, "1.1.1.1", "", sendBody); !isThrottled(res) {
+		t.Fatalf("got %v, want throttled", res)
+	}
+}
+
+// TestMiddlewareIgnoresUntrustedForwardedFor verifies that X-Forwarded-For is only used to identify the client
+// if the request comes from a trusted proxy.
+func TestMiddlewareIgnoresUntrustedForwardedFor(t *testing.T) {
+	rl := New(NewMemoryStore(), Limits{DefaultMethod: {Rate: 1, Burst: 1}}, nil)
+	rl.SetClock(clock.NewMock(time.Unix(1000, 0)))
+	r := newRouter(rl)
+
+	for i, ip := range []string{"1.1.1.1", "2.2.2.2"} {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(chainIdBody))
+		req.RemoteAddr = "198.51.100.1:1234"
+		req.Header.Set("X-Forwarded-For", ip)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var res map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		if throttled := isThrottled(res); throttled != (i == 1) {
+			t.Fatalf("call %d: got throttled %v, want %v", i, throttled, i == 1)
+		}
+	}
+}
+
+// TestBadgerStorePersistsBuckets verifies that buckets in the DB are shared across stores and refill over
+// time.
+func TestBadgerStorePersistsBuckets(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	lim := &Limit{Rate: 0.5, Burst: 2}
+	now := time.Unix(1000, 0)
+
+	for i := 0; i < 2; i++ {
+		if ok, err := NewBadgerStore(db).Take("client", lim, 1, now); err != nil || !ok {
+			t.Fatalf("call %d: got %v %v, want true nil", i, ok, err)
+		}
+	}
+	if ok, err := NewBadgerStore(db).Take("client", lim, 1, now); err != nil || ok {
+		t.Fatalf("got %v %v, want false nil", ok, err)
+	}
+	if ok, err := NewBadgerStore(db).Take("client", lim, 1, now.Add(2*time.Second)); err != nil || !ok {
+		t.Fatalf("got %v %v, want true nil", ok, err)
+	}
+}
+
+// TestParseLimits verifies that limits from config are decoded and malformed entries are rejected.
+func TestParseLimits(t *testing.T) {
+	limits, err := ParseLimits([]string{"eth_sendUserOperation:0.5:5", "*:10:50"})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if l := limits.Get("eth_sendUserOperation"); l.Rate != 0.5 || l.Burst != 5 {
+		t.Fatalf("got %v, want 0.5 and 5", l)
+	}
+	if l := limits.Get("eth_chainId"); l.Rate != 10 || l.Burst != 50 {
+		t.Fatalf("got %v, want 10 and 50", l)
+	}
+
+	for _, val := range []string{"eth_chainId:1", "eth_chainId:0:1", "eth_chainId:1:0", ":1:1"} {
+		if _, err := ParseLimits([]string{val}); err == nil {
+			t.Fatalf("%s: got nil, want err", val)
+		}
+	}
+}
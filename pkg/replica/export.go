@@ -0,0 +1,53 @@
+// Package replica implements a stream of bundler data to read replicas in other regions. Replicas can answer
+// queries locally while all writes (e.g. eth_sendUserOperation) are routed to the primary. Only durable data,
+// such as entity reputation, is replicated. The mempool is never streamed.
+package replica
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// VersionTrailer is the HTTP trailer set by the primary with the max version included in an export. A
+	// replica should pass this value as the since query param in the next request.
+	VersionTrailer = "X-Replica-Version"
+)
+
+// Export returns a gin handler that streams an incremental backup of all keys matching the given prefixes.
+// The since query param can be set to only include entries written after a previous export.
+func Export(db *badger.DB, prefixes ...string) gin.HandlerFunc {
+	return func(g *gin.Context) {
+		since, err := strconv.ParseUint(g.DefaultQuery("since", "0"), 10, 64)
+		if err != nil {
+			g.Status(http.StatusBadRequest)
+			return
+		}
+
+		stream := db.NewStream()
+		stream.LogPrefix = "replica.Export"
+		stream.SinceTs = since
+		stream.ChooseKey = func(item *badger.Item) bool {
+			for _, p := range prefixes {
+				if bytes.HasPrefix(item.Key(), []byte(p)) {
+					return true
+				}
+			}
+			return false
+		}
+
+		g.Header("Content-Type", "application/octet-stream")
+		g.Header("Trailer", VersionTrailer)
+		g.Status(http.StatusOK)
+		version, err := stream.Backup(g.Writer, since)
+		if err != nil {
+			_ = g.Error(err)
+			return
+		}
+		g.Writer.Header().Set(VersionTrailer, strconv.FormatUint(version, 10))
+	}
+}
@@ -0,0 +1,62 @@
+package replica
+
+import (
+	"bytes"
+	"io"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+)
+
+// WriteMethods are the RPC methods that must be routed to the primary.
+var WriteMethods = []string{
+	"eth_sendUserOperation",
+	"qng_crossSend",
+	"debug_bundler_clearState",
+	"debug_bundler_sendBundleNow",
+	"debug_bundler_setBundlingMode",
+	"debug_bundler_setReputation",
+}
+
+// hasWriteMethod returns true if the body is a single request with a write method or a batch request that
+// contains at least one.
+func hasWriteMethod(body []byte, writes map[string]bool) bool {
+	for _, req := range jsonrpc.ParseRequests(body) {
+		if writes[req.Method] {
+			return true
+		}
+	}
+	return false
+}
+
+// ForwardWrites returns a gin middleware that proxies any request with an RPC method in WriteMethods to the
+// primary at the same path. A batch request with any write method is proxied as a whole. All other requests
+// are passed to the next handler to be served locally.
+func ForwardWrites(primary *url.URL) gin.HandlerFunc {
+	writes := map[string]bool{}
+	for _, m := range WriteMethods {
+		writes[m] = true
+	}
+	proxy := httputil.NewSingleHostReverseProxy(primary)
+
+	return func(g *gin.Context) {
+		body, err := io.ReadAll(g.Request.Body)
+		if err != nil {
+			_ = g.Error(err)
+			g.Abort()
+			return
+		}
+		g.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		if !hasWriteMethod(body, writes) {
+			g.Next()
+			return
+		}
+
+		g.Request.Host = primary.Host
+		proxy.ServeHTTP(g.Writer, g.Request)
+		g.Abort()
+	}
+}
@@ -0,0 +1,97 @@
+package replica
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestReplica(t *testing.T) *gin.Engine {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("primary" + r.URL.Path))
+	}))
+	t.Cleanup(primary.Close)
+
+	u, err := url.Parse(primary.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/rpc", ForwardWrites(u), func(g *gin.Context) {
+		g.String(http.StatusOK, "local")
+	})
+	return r
+}
+
+// doRequest serves the replica over a real HTTP server since the reverse proxy requires a response writer
+// that supports CloseNotify.
+func doRequest(t *testing.T, r *gin.Engine, body string) string {
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	res, err := http.Post(
+		srv.URL+"/rpc",
+		"application/json",
+		strings.NewReader(body),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	out, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func doRpcRequest(t *testing.T, r *gin.Engine, method string) string {
+	return doRequest(t, r, `{"jsonrpc":"2.0","id":1,"method":"`+method+`","params":[]}`)
+}
+
+// TestForwardWritesToPrimary verifies that write methods are proxied to the primary at the same path.
+func TestForwardWritesToPrimary(t *testing.T) {
+	r := newTestReplica(t)
+	if body := doRpcRequest(t, r, "eth_sendUserOperation"); body != "primary/rpc" {
+		t.Fatalf("got %s, want primary/rpc", body)
+	}
+}
+
+// TestForwardWritesServesReadsLocally verifies that read methods are handled by the replica.
+func TestForwardWritesServesReadsLocally(t *testing.T) {
+	r := newTestReplica(t)
+	if body := doRpcRequest(t, r, "eth_getUserOperationReceipt"); body != "local" {
+		t.Fatalf("got %s, want local", body)
+	}
+}
+
+// TestForwardWritesBatchWithWrite verifies that a batch containing a write method is proxied to the primary.
+func TestForwardWritesBatchWithWrite(t *testing.T) {
+	r := newTestReplica(t)
+	body := doRequest(t, r, `[{"method":"eth_chainId"},{"method":"eth_sendUserOperation"}]`)
+	if body != "primary/rpc" {
+		t.Fatalf("got %s, want primary/rpc", body)
+	}
+}
+
+// TestForwardWritesCaseVariantWrite verifies that a write method sent with a case variant name or alongside a
+// case variant key is still proxied to the primary.
+func TestForwardWritesCaseVariantWrite(t *testing.T) {
+	r := newTestReplica(t)
+	for _, body := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"eth_sendUserOperation","Method":"eth_chainId","params":[]}`,
+		`{"jsonrpc":"2.0","id":1,"method":"Eth_sendUserOperation","params":[]}`,
+	} {
+		if out := doRequest(t, r, body); out != "primary/rpc" {
+			t.Fatalf("%s: got %s, want primary/rpc", body, out)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package replica
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/go-logr/logr"
+)
+
+var (
+	// DefaultSyncInterval is the default duration between each sync from the primary.
+	DefaultSyncInterval = 5 * time.Second
+
+	maxPendingWrites = 256
+)
+
+// Importer periodically syncs data from a primary's export endpoint into a local DB.
+type Importer struct {
+	db       *badger.DB
+	url      string
+	interval time.Duration
+	client   *http.Client
+	logger   logr.Logger
+	version  uint64
+}
+
+// NewImporter returns an Importer that will sync from the export endpoint at the given URL.
+func NewImporter(db *badger.DB, url string, l logr.Logger) *Importer {
+	return &Importer{
+		db:       db,
+		url:      url,
+		interval: DefaultSyncInterval,
+		client:   &http.Client{Timeout: time.Minute},
+		logger:   l.WithName("replica_importer"),
+	}
+}
+
+// SetSyncInterval sets the duration between each sync from the primary.
+func (i *Importer) SetSyncInterval(interval time.Duration) {
+	i.interval = interval
+}
+
+// Sync loads all entries written to the primary since the last successful sync.
+func (i *Importer) Sync(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s?since=%d", i.url, i.version), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("replica: unexpected export status %d", resp.StatusCode)
+	}
+
+	if err := i.db.Load(resp.Body, maxPendingWrites); err != nil {
+		return err
+	}
+
+	// Trailers are only available after the body has been fully read.
+	_, _ = io.Copy(io.Discard, resp.Body)
+	version, err := strconv.ParseUint(resp.Trailer.Get(VersionTrailer), 10, 64)
+	if err != nil {
+		return fmt.Errorf("replica: bad export version: %w", err)
+	}
+	if version > i.version {
+		i.version = version
+	}
+	return nil
+}
+
+// Run starts a goroutine that syncs from the primary at every interval.
+func (i *Importer) Run() {
+	go func(i *Importer) {
+		ticker := time.NewTicker(i.interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			l := i.logger.WithValues("since", i.version)
+			if err := i.Sync(context.Background()); err != nil {
+				l.Error(err, "replica sync error")
+			} else {
+				l.V(1).Info("replica sync ok", "version", i.version)
+			}
+		}
+	}(i)
+}
@@ -0,0 +1,78 @@
+package replica
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+)
+
+// ReadMethods are the RPC methods served by a read-only endpoint. These only depend on the upstream node
+// and not on local bundler state.
+var ReadMethods = []string{
+	"eth_chainId",
+	"eth_supportedEntryPoints",
+	"eth_estimateUserOperationGas",
+	"eth_getUserOperationReceipt",
+	"eth_getUserOperationByHash",
+	"eth_getUserOperationNonce",
+	"bundler_estimateSponsoredUserOperationGas",
+	"bundler_getErc20FeeQuote",
+	"bundler_getInfo",
+	"bundler_getUserOperationHashPreimage",
+}
+
+func readOnlyError(id any, method string, primary string) gin.H {
+	data := gin.H{"method": method}
+	if primary != "" {
+		data["primaryUrl"] = primary
+	}
+	return jsonrpc.ErrorResponse(
+		errors.READ_ONLY,
+		"read-only endpoint: "+method+" must be sent to the primary bundler",
+		data,
+		id,
+	)
+}
+
+// findDisallowed returns the first request in a single or batch body with a method not in ReadMethods.
+func findDisallowed(body []byte, reads map[string]bool) (*jsonrpc.Request, bool) {
+	for _, req := range jsonrpc.ParseRequests(body) {
+		if !reads[req.Method] {
+			return req, true
+		}
+	}
+	return nil, false
+}
+
+// ReadOnly returns a gin middleware that rejects any request with an RPC method not in ReadMethods. The error
+// includes the primary URL, if given, as a hint for clients to redirect writes. A batch with any disallowed
+// method is rejected as a whole.
+func ReadOnly(primary string) gin.HandlerFunc {
+	reads := map[string]bool{}
+	for _, m := range ReadMethods {
+		reads[m] = true
+	}
+
+	return func(g *gin.Context) {
+		body, err := io.ReadAll(g.Request.Body)
+		if err != nil {
+			_ = g.Error(err)
+			g.Abort()
+			return
+		}
+		g.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		req, ok := findDisallowed(body, reads)
+		if !ok {
+			g.Next()
+			return
+		}
+
+		g.JSON(http.StatusOK, readOnlyError(req.Id, req.Method, primary))
+		g.Abort()
+	}
+}
@@ -0,0 +1,83 @@
+package replica
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+)
+
+type readOnlyResponse struct {
+	Error *struct {
+		Code int            `json:"code"`
+		Data map[string]any `json:"data"`
+	} `json:"error"`
+}
+
+func doReadOnlyRequest(t *testing.T, body string) (string, *readOnlyResponse) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/rpc", ReadOnly("https://primary.example"), func(g *gin.Context) {
+		g.String(http.StatusOK, "local")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body)))
+	if w.Body.String() == "local" {
+		return "local", nil
+	}
+
+	var res readOnlyResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	return "", &res
+}
+
+// TestReadOnlyServesReads verifies that read methods are handled locally.
+func TestReadOnlyServesReads(t *testing.T) {
+	body, _ := doReadOnlyRequest(t, `{"jsonrpc":"2.0","id":1,"method":"eth_getUserOperationReceipt","params":[]}`)
+	if body != "local" {
+		t.Fatalf("got %s, want local", body)
+	}
+}
+
+// TestReadOnlyRejectsWrites verifies that write methods are rejected with a redirect hint.
+func TestReadOnlyRejectsWrites(t *testing.T) {
+	_, res := doReadOnlyRequest(t, `{"jsonrpc":"2.0","id":1,"method":"eth_sendUserOperation","params":[]}`)
+	if res == nil || res.Error == nil {
+		t.Fatal("got nil, want error")
+	}
+	if res.Error.Code != errors.READ_ONLY {
+		t.Fatalf("got code %d, want %d", res.Error.Code, errors.READ_ONLY)
+	}
+	if res.Error.Data["primaryUrl"] != "https://primary.example" {
+		t.Fatalf("got primaryUrl %v, want https://primary.example", res.Error.Data["primaryUrl"])
+	}
+}
+
+// TestReadOnlyRejectsBatchWithWrite verifies that a batch containing a write method is rejected.
+func TestReadOnlyRejectsBatchWithWrite(t *testing.T) {
+	_, res := doReadOnlyRequest(t, `[{"method":"eth_chainId"},{"method":"eth_sendUserOperation"}]`)
+	if res == nil || res.Error == nil {
+		t.Fatal("got nil, want error")
+	}
+}
+
+// TestReadOnlyRejectsCaseVariantWrites verifies that a write method can't be passed through with a duplicate
+// or case variant key that the JSON-RPC controller ignores.
+func TestReadOnlyRejectsCaseVariantWrites(t *testing.T) {
+	for _, body := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"eth_sendUserOperation","Method":"eth_chainId","params":[]}`,
+		`{"jsonrpc":"2.0","id":1,"method":"eth_chainId","method":"eth_sendUserOperation","params":[]}`,
+		`{"jsonrpc":"2.0","id":1,"method":"Eth_sendUserOperation","params":[]}`,
+	} {
+		if _, res := doReadOnlyRequest(t, body); res == nil || res.Error == nil {
+			t.Fatalf("%s: got nil, want error", body)
+		}
+	}
+}
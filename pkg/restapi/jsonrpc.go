@@ -0,0 +1,192 @@
+package restapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+)
+
+// The JSON-RPC middleware (API keys, rate limits, size limits, etc.) reads the methods and params from a
+// JSON-RPC request body and the errors from a JSON-RPC response body. REST routes are run through the same
+// middleware by presenting each request as the equivalent JSON-RPC call and each response as the equivalent
+// JSON-RPC response. The client only ever sees the REST request and response.
+
+const (
+	restBodyKey   = "restapi.body"
+	restOutputKey = "restapi.output"
+)
+
+// toRPCFunc returns the JSON-RPC request body for an incoming REST request.
+type toRPCFunc = func(g *gin.Context, body []byte) []byte
+
+type rpcRequest struct {
+	JsonRpc string `json:"jsonrpc"`
+	Id      int    `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+type rpcResponse struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Id      int             `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// output is a response written by a REST handler.
+type output struct {
+	status int
+	body   []byte
+}
+
+// bufferedWriter holds the response body and status instead of writing them to the client. Headers are still
+// set on the underlying writer.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferedWriter(w gin.ResponseWriter) *bufferedWriter {
+	return &bufferedWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.body.Len() > 0
+}
+
+func sendToRPC(g *gin.Context, body []byte) []byte {
+	var req SendRequest
+	_ = json.Unmarshal(body, &req)
+	params := []any{req.UserOp, req.EntryPoint}
+	if req.Options != nil {
+		params = append(params, req.Options)
+	}
+
+	data, _ := json.Marshal(&rpcRequest{
+		JsonRpc: "2.0",
+		Id:      1,
+		Method:  jsonrpc.SendUserOperationMethod,
+		Params:  params,
+	})
+	return data
+}
+
+func getToRPC(g *gin.Context, body []byte) []byte {
+	hash := g.Param("hash")
+	data, _ := json.Marshal([]*rpcRequest{
+		{JsonRpc: "2.0", Id: 1, Method: "bundler_getUserOperationStatus", Params: []any{hash}},
+		{JsonRpc: "2.0", Id: 2, Method: "eth_getUserOperationReceipt", Params: []any{hash}},
+	})
+	return data
+}
+
+// toRPCResponse returns the JSON-RPC response body that is equivalent to a REST response.
+func toRPCResponse(o *output) []byte {
+	res := &rpcResponse{JsonRpc: "2.0", Id: 1}
+	var e Error
+	if o.status >= http.StatusBadRequest && json.Unmarshal(o.body, &e) == nil && e.Code != 0 {
+		res.Error = &rpcError{Code: e.Code, Message: e.Message, Data: e.Data}
+	} else {
+		res.Result = o.body
+	}
+
+	data, _ := json.Marshal(res)
+	return data
+}
+
+// fromRPCError writes a JSON-RPC error set by middleware as a REST error. The status set by the middleware
+// is kept if it is not 200.
+func fromRPCError(g *gin.Context, w *bufferedWriter) {
+	var res rpcResponse
+	if err := json.Unmarshal(w.body.Bytes(), &res); err != nil || res.Error == nil {
+		g.Data(w.status, w.Header().Get("Content-Type"), w.body.Bytes())
+		return
+	}
+
+	status := w.status
+	if status == http.StatusOK {
+		status = toHTTPStatus(res.Error.Code)
+	}
+	g.JSON(status, &Error{Code: res.Error.Code, Message: res.Error.Message, Data: res.Error.Data})
+}
+
+// asJSONRPC returns a handler that must run first on a REST route. It replaces the request body with the
+// JSON-RPC body from toRPC for the middleware that follows. Once all handlers have run, it writes the REST
+// response to the client or, if the middleware aborted the request, the middleware's error as a REST error.
+func asJSONRPC(toRPC toRPCFunc) gin.HandlerFunc {
+	return func(g *gin.Context) {
+		body, err := io.ReadAll(g.Request.Body)
+		if err != nil {
+			g.AbortWithStatusJSON(http.StatusBadRequest, &Error{Message: err.Error()})
+			return
+		}
+		g.Set(restBodyKey, body)
+		g.Request.Body = io.NopCloser(bytes.NewReader(toRPC(g, body)))
+
+		client := g.Writer
+		w := newBufferedWriter(client)
+		g.Writer = w
+		g.Next()
+		g.Writer = client
+
+		if v, ok := g.Get(restOutputKey); ok {
+			o := v.(*output)
+			g.Data(o.status, "application/json; charset=utf-8", o.body)
+			return
+		}
+		fromRPCError(g, w)
+	}
+}
+
+// fromJSONRPC returns a handler that must run last on a REST route. It restores the REST request body and
+// runs the REST handler. The REST response is kept for asJSONRPC and the equivalent JSON-RPC response is
+// written for the middleware.
+func fromJSONRPC(h gin.HandlerFunc) gin.HandlerFunc {
+	return func(g *gin.Context) {
+		body, _ := g.Get(restBodyKey)
+		g.Request.Body = io.NopCloser(bytes.NewReader(body.([]byte)))
+
+		rpc := g.Writer
+		w := newBufferedWriter(rpc)
+		g.Writer = w
+		h(g)
+		g.Writer = rpc
+
+		o := &output{status: w.status, body: w.body.Bytes()}
+		g.Set(restOutputKey, o)
+		_, _ = rpc.Write(toRPCResponse(o))
+	}
+}
@@ -0,0 +1,162 @@
+// Package restapi implements a REST facade over the Client for teams that find JSON-RPC awkward behind API
+// gateways. Resources are documented with an OpenAPI spec served at /v1/openapi.json.
+package restapi
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/client"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/filter"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/opstatus"
+	"github.com/stackup-wallet/stackup-bundler/pkg/origin"
+)
+
+//go:embed openapi.json
+var openapiSpec []byte
+
+// SendRequest is the body of POST /v1/userops.
+type SendRequest struct {
+	UserOp     map[string]any `json:"userOp"`
+	EntryPoint string         `json:"entryPoint"`
+	Options    map[string]any `json:"options,omitempty"`
+}
+
+// SendResponse is the body returned by POST /v1/userops.
+type SendResponse struct {
+	UserOpHash string `json:"userOpHash"`
+}
+
+// UserOpResource is the body returned by GET /v1/userops/{hash}.
+type UserOpResource struct {
+	UserOpHash string                       `json:"userOpHash"`
+	Status     *opstatus.Record             `json:"status,omitempty"`
+	Receipt    *filter.UserOperationReceipt `json:"receipt,omitempty"`
+}
+
+// Error is the body returned for all failed requests. Code and Data are the same as the JSON-RPC error, if any.
+type Error struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// Server implements the REST API using the same Client as the JSON-RPC API.
+type Server struct {
+	client *client.Client
+}
+
+// New returns a Server for the given Client.
+func New(c *client.Client) *Server {
+	return &Server{client: c}
+}
+
+// route returns the handlers for a REST route that runs the given JSON-RPC middleware before h.
+func route(toRPC toRPCFunc, middleware []gin.HandlerFunc, h gin.HandlerFunc) []gin.HandlerFunc {
+	handlers := []gin.HandlerFunc{asJSONRPC(toRPC)}
+	handlers = append(handlers, middleware...)
+	return append(handlers, fromJSONRPC(h))
+}
+
+// Register adds the REST routes to a router. The middleware is the same as for the JSON-RPC routes, e.g. for
+// authentication and rate limits, and sees each request as the equivalent JSON-RPC call. POST /v1/userops is
+// eth_sendUserOperation and GET /v1/userops/{hash} is a batch of bundler_getUserOperationStatus and
+// eth_getUserOperationReceipt.
+func (s *Server) Register(r gin.IRoutes, middleware ...gin.HandlerFunc) {
+	r.GET("/v1/openapi.json", s.OpenAPI())
+	r.POST("/v1/userops", route(sendToRPC, middleware, s.SendUserOperation())...)
+	r.GET("/v1/userops/:hash", route(getToRPC, middleware, s.GetUserOperation())...)
+}
+
+// toHTTPStatus maps a JSON-RPC error code to the closest HTTP status.
+func toHTTPStatus(code int) int {
+	switch code {
+	case errors.INVALID_FIELDS:
+		return http.StatusBadRequest
+	case errors.BANNED_OR_THROTTLED_ENTITY:
+		return http.StatusTooManyRequests
+	case errors.SERVICE_UNAVAILABLE:
+		return http.StatusServiceUnavailable
+	case errors.UNAUTHORIZED_RELAYER, errors.UNAUTHORIZED_API_KEY:
+		return http.StatusForbidden
+	case errors.READ_ONLY:
+		return http.StatusMethodNotAllowed
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
+
+func abortWithError(g *gin.Context, err error) {
+	rpcErr, ok := err.(*errors.RPCError)
+	if !ok {
+		g.AbortWithStatusJSON(http.StatusBadRequest, &Error{Code: errors.INVALID_FIELDS, Message: err.Error()})
+		return
+	}
+	g.AbortWithStatusJSON(
+		toHTTPStatus(rpcErr.Code()),
+		&Error{Code: rpcErr.Code(), Message: rpcErr.Error(), Data: rpcErr.Data()},
+	)
+}
+
+// OpenAPI returns a handler that responds with the OpenAPI spec for the REST API.
+func (s *Server) OpenAPI() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		g.Data(http.StatusOK, "application/json", openapiSpec)
+	}
+}
+
+// SendUserOperation returns a handler that routes POST /v1/userops to *Client.SendUserOperation. A dapp id
+// set in the origin header is added to the options unless it is already set.
+func (s *Server) SendUserOperation() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		var req SendRequest
+		if err := g.ShouldBindJSON(&req); err != nil {
+			abortWithError(g, errors.NewRPCError(errors.INVALID_FIELDS, err.Error(), nil))
+			return
+		}
+		if req.UserOp == nil {
+			abortWithError(g, errors.NewRPCError(errors.INVALID_FIELDS, "userOp is required", nil))
+			return
+		}
+		if dappId := g.GetHeader(origin.Header); dappId != "" {
+			if req.Options == nil {
+				req.Options = map[string]any{}
+			}
+			if _, ok := req.Options["dappId"]; !ok {
+				req.Options["dappId"] = dappId
+			}
+		}
+
+		hash, err := s.client.SendUserOperation(req.UserOp, req.EntryPoint, req.Options)
+		if err != nil {
+			abortWithError(g, err)
+			return
+		}
+		g.JSON(http.StatusAccepted, &SendResponse{UserOpHash: hash})
+	}
+}
+
+// GetUserOperation returns a handler that responds with the status and receipt of a UserOperation. It
+// responds with 404 if the op is unknown and has no receipt.
+func (s *Server) GetUserOperation() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		hash := g.Param("hash")
+		sts, err := s.client.GetUserOperationStatus(hash)
+		if err != nil {
+			abortWithError(g, err)
+			return
+		}
+		rct, err := s.client.GetUserOperationReceipt(hash)
+		if err != nil {
+			abortWithError(g, err)
+			return
+		}
+		if sts == nil && rct == nil {
+			g.AbortWithStatusJSON(http.StatusNotFound, &Error{Message: "userOp not found"})
+			return
+		}
+		g.JSON(http.StatusOK, &UserOpResource{UserOpHash: hash, Status: sts, Receipt: rct})
+	}
+}
@@ -0,0 +1,215 @@
+package restapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/client"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/filter"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/opstatus"
+)
+
+var testHash = common.HexToHash("0x01").String()
+
+func newTestClient(t *testing.T) *client.Client {
+	db := testutils.DBMock()
+	t.Cleanup(func() { db.Close() })
+	mem, err := mempool.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client.New(mem, gas.NewDefaultOverhead(), testutils.ChainID, []common.Address{testutils.ValidAddress1}, 1)
+}
+
+func serve(c *client.Client, method string, path string, body string) *httptest.ResponseRecorder {
+	return serveWith(c, nil, method, path, body)
+}
+
+func serveWith(
+	c *client.Client,
+	middleware []gin.HandlerFunc,
+	method string,
+	path string,
+	body string,
+) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	New(c).Register(r, middleware...)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return w
+}
+
+// TestGetUserOperation verifies that the status and receipt from the Client are returned as a single resource.
+func TestGetUserOperation(t *testing.T) {
+	c := newTestClient(t)
+	c.SetGetUserOpStatusFunc(func(hash common.Hash) (*opstatus.Record, error) {
+		return &opstatus.Record{Status: opstatus.Included, TransactionHash: "0x02"}, nil
+	})
+	c.SetGetUserOpReceiptFunc(func(hash string, ep common.Address, blkRange uint64) (*filter.UserOperationReceipt, error) {
+		return &filter.UserOperationReceipt{UserOpHash: common.HexToHash(hash), EntryPoint: ep, Success: true}, nil
+	})
+
+	w := serve(c, http.MethodGet, "/v1/userops/"+testHash, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	var res UserOpResource
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if res.UserOpHash != testHash || res.Status == nil || res.Status.Status != opstatus.Included {
+		t.Fatalf("got %v, want included status", res)
+	}
+	if res.Receipt == nil || !res.Receipt.Success {
+		t.Fatalf("got %v, want successful receipt", res.Receipt)
+	}
+}
+
+// TestGetUserOperationNotFound verifies that 404 is returned for an op without a status or receipt.
+func TestGetUserOperationNotFound(t *testing.T) {
+	w := serve(newTestClient(t), http.MethodGet, "/v1/userops/"+testHash, "")
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+// TestGetUserOperationInvalidHash verifies that 400 is returned for a malformed userOpHash.
+func TestGetUserOperationInvalidHash(t *testing.T) {
+	w := serve(newTestClient(t), http.MethodGet, "/v1/userops/0x01", "")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+// TestSendUserOperationMissingOp verifies that a request without a userOp is rejected with a JSON-RPC error
+// code in the body.
+func TestSendUserOperationMissingOp(t *testing.T) {
+	w := serve(newTestClient(t), http.MethodPost, "/v1/userops", `{"entryPoint":"0x01"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	var res Error
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if res.Code != errors.INVALID_FIELDS {
+		t.Fatalf("got code %d, want %d", res.Code, errors.INVALID_FIELDS)
+	}
+}
+
+// TestOpenAPI verifies that the embedded spec is valid JSON and documents every route.
+func TestOpenAPI(t *testing.T) {
+	w := serve(newTestClient(t), http.MethodGet, "/v1/openapi.json", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	var spec struct {
+		Paths map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	for _, p := range []string{"/v1/userops", "/v1/userops/{hash}"} {
+		if _, ok := spec.Paths[p]; !ok {
+			t.Fatalf("got %v, want path %s", spec.Paths, p)
+		}
+	}
+}
+
+// TestToHTTPStatus verifies that JSON-RPC error codes are mapped to HTTP statuses.
+func TestToHTTPStatus(t *testing.T) {
+	cases := map[int]int{
+		errors.INVALID_FIELDS:             http.StatusBadRequest,
+		errors.BANNED_OR_THROTTLED_ENTITY: http.StatusTooManyRequests,
+		errors.SERVICE_UNAVAILABLE:        http.StatusServiceUnavailable,
+		errors.REJECTED_BY_POLICY:         http.StatusUnprocessableEntity,
+	}
+	for code, want := range cases {
+		if got := toHTTPStatus(code); got != want {
+			t.Fatalf("got %d for %d, want %d", got, code, want)
+		}
+	}
+}
+
+// TestMiddlewareSeesJSONRPC verifies that middleware reads each REST request as the equivalent JSON-RPC call.
+func TestMiddlewareSeesJSONRPC(t *testing.T) {
+	var methods []string
+	record := func(g *gin.Context) {
+		body, _ := io.ReadAll(g.Request.Body)
+		g.Request.Body = io.NopCloser(bytes.NewReader(body))
+		for _, r := range jsonrpc.ParseRequests(body) {
+			methods = append(methods, r.Method)
+		}
+	}
+
+	serveWith(newTestClient(t), []gin.HandlerFunc{record}, http.MethodPost, "/v1/userops", `{"userOp":{}}`)
+	serveWith(newTestClient(t), []gin.HandlerFunc{record}, http.MethodGet, "/v1/userops/"+testHash, "")
+	want := []string{"eth_sendUserOperation", "bundler_getUserOperationStatus", "eth_getUserOperationReceipt"}
+	if strings.Join(methods, ",") != strings.Join(want, ",") {
+		t.Fatalf("got %v, want %v", methods, want)
+	}
+}
+
+// TestMiddlewareErrorAsREST verifies that a JSON-RPC error from middleware is returned as a REST error and that
+// the REST handler is not run.
+func TestMiddlewareErrorAsREST(t *testing.T) {
+	deny := func(g *gin.Context) {
+		jsonrpc.AbortWithError(g, errors.UNAUTHORIZED_API_KEY, "apikey: missing api key", nil)
+	}
+
+	body := `{"entryPoint":"0x01"}`
+	w := serveWith(newTestClient(t), []gin.HandlerFunc{deny}, http.MethodPost, "/v1/userops", body)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusForbidden)
+	}
+	var res Error
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if res.Code != errors.UNAUTHORIZED_API_KEY {
+		t.Fatalf("got code %d, want %d", res.Code, errors.UNAUTHORIZED_API_KEY)
+	}
+}
+
+// TestMiddlewareSeesJSONRPCResponse verifies that middleware reads a REST error as a JSON-RPC error while the
+// client still receives the REST response.
+func TestMiddlewareSeesJSONRPCResponse(t *testing.T) {
+	var got map[string]any
+	record := func(g *gin.Context) {
+		w := newBufferedWriter(g.Writer)
+		client := g.Writer
+		g.Writer = w
+		g.Next()
+		g.Writer = client
+		_ = json.Unmarshal(w.body.Bytes(), &got)
+		_, _ = client.Write(w.body.Bytes())
+	}
+
+	body := `{"entryPoint":"0x01"}`
+	w := serveWith(newTestClient(t), []gin.HandlerFunc{record}, http.MethodPost, "/v1/userops", body)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	rpcErr, ok := got["error"].(map[string]any)
+	if !ok || rpcErr["code"] != float64(errors.INVALID_FIELDS) {
+		t.Fatalf("got %v, want JSON-RPC error %d", got, errors.INVALID_FIELDS)
+	}
+	var res Error
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || res.Code != errors.INVALID_FIELDS {
+		t.Fatalf("got %v %v, want REST error %d", res, err, errors.INVALID_FIELDS)
+	}
+}
@@ -0,0 +1,125 @@
+package sigban
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/dbutils"
+	"github.com/stackup-wallet/stackup-bundler/internal/ginutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+)
+
+type rpcResponse struct {
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// parseSenders returns the sender of every eth_sendUserOperation call in a single or batch request along
+// with the id of the first call.
+func parseSenders(body []byte) (senders []common.Address, id any) {
+	for _, r := range jsonrpc.ParseRequests(body) {
+		if r.Method != jsonrpc.SendUserOperationMethod {
+			continue
+		}
+
+		for _, op := range r.UserOps() {
+			for _, sender := range jsonrpc.UserOpFieldValues(op, "sender") {
+				if !common.IsHexAddress(sender) {
+					continue
+				}
+				if len(senders) == 0 {
+					id = r.Id
+				}
+				senders = append(senders, common.HexToAddress(sender))
+			}
+		}
+	}
+	return senders, id
+}
+
+// isSignatureFailure returns true if the JSON-RPC response is an error caused by an invalid signature.
+func isSignatureFailure(body []byte) bool {
+	var res rpcResponse
+	if err := json.Unmarshal(body, &res); err != nil || res.Error == nil {
+		return false
+	}
+
+	return res.Error.Code == errors.INVALID_SIGNATURE || strings.Contains(res.Error.Message, "AA24")
+}
+
+// Middleware returns a gin middleware that rejects eth_sendUserOperation requests from banned senders or IPs
+// and records a failure for the sender and IP of any request that is rejected due to an invalid signature. If
+// the request was submitted by an authenticated relayer, the relayer is used in place of the IP.
+// Requests are allowed through if the ban status cannot be read from the DB.
+func (t *Tracker) Middleware(l logr.Logger) gin.HandlerFunc {
+	l = l.WithName("sigban")
+
+	return func(g *gin.Context) {
+		body, err := io.ReadAll(g.Request.Body)
+		if err != nil {
+			_ = g.Error(err)
+			g.Abort()
+			return
+		}
+		g.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		senders, id := parseSenders(body)
+		if len(senders) == 0 {
+			g.Next()
+			return
+		}
+		// Requests from an authenticated relayer are tracked by relayer instead of IP.
+		ids := []string{dbutils.JoinValues("ip", g.ClientIP())}
+		if relayer, ok := ginutils.GetRelayer(g); ok {
+			ids = []string{dbutils.JoinValues("relayer", relayer)}
+		}
+		for _, sender := range senders {
+			ids = append(ids, dbutils.JoinValues("sender", sender.String()))
+		}
+
+		if banned, err := t.IsBanned(ids...); err != nil {
+			l.Error(err, "sigban error")
+		} else if banned {
+			jsonrpc.AbortWithError(
+				g,
+				errors.BANNED_OR_THROTTLED_ENTITY,
+				"temporarily banned due to repeated invalid signatures",
+				id,
+			)
+			return
+		}
+
+		rec := &bodyRecorder{ResponseWriter: g.Writer, body: &bytes.Buffer{}}
+		g.Writer = rec
+		g.Next()
+
+		if !isSignatureFailure(rec.body.Bytes()) {
+			return
+		}
+		for _, id := range ids {
+			if banned, err := t.RecordFailure(id); err != nil {
+				l.Error(err, "sigban error")
+			} else if banned {
+				l.Info("sigban banned", "id", id)
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Package sigban tracks senders and IPs that repeatedly submit UserOperations with invalid signatures and
+// temporarily blocks them before any simulation resources are spent on their requests.
+package sigban
+
+import (
+	"strconv"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/stackup-wallet/stackup-bundler/internal/dbutils"
+)
+
+var (
+	// KeyPrefix is the prefix for all keys stored in the DB by the sigban package.
+	KeyPrefix = "sigban"
+
+	DefaultThreshold   = 5
+	DefaultWindow      = 10 * time.Minute
+	DefaultBanDuration = time.Hour
+
+	failuresPrefix = dbutils.JoinValues(KeyPrefix, "failures")
+	bannedPrefix   = dbutils.JoinValues(KeyPrefix, "banned")
+)
+
+func getFailuresKey(id string) []byte {
+	return []byte(dbutils.JoinValues(failuresPrefix, id))
+}
+
+func getBannedKey(id string) []byte {
+	return []byte(dbutils.JoinValues(bannedPrefix, id))
+}
+
+// Tracker counts invalid signature failures per identifier (e.g. a sender or IP address). An identifier that
+// reaches the threshold within the window is banned for the ban duration. Both failure counts and bans are
+// persisted to the DB so that they survive a restart.
+type Tracker struct {
+	db          *badger.DB
+	threshold   int
+	window      time.Duration
+	banDuration time.Duration
+}
+
+// New returns a Tracker with default settings.
+func New(db *badger.DB) *Tracker {
+	return &Tracker{
+		db:          db,
+		threshold:   DefaultThreshold,
+		window:      DefaultWindow,
+		banDuration: DefaultBanDuration,
+	}
+}
+
+// SetThreshold sets the number of failures required to ban an identifier.
+func (t *Tracker) SetThreshold(n int) {
+	t.threshold = n
+}
+
+// SetWindow sets the duration after the last failure before the failure count of an identifier is reset.
+func (t *Tracker) SetWindow(d time.Duration) {
+	t.window = d
+}
+
+// SetBanDuration sets the duration an identifier will remain banned for.
+func (t *Tracker) SetBanDuration(d time.Duration) {
+	t.banDuration = d
+}
+
+// IsBanned returns true if any of the given identifiers are currently banned.
+func (t *Tracker) IsBanned(ids ...string) (bool, error) {
+	banned := false
+	err := t.db.View(func(txn *badger.Txn) error {
+		for _, id := range ids {
+			_, err := txn.Get(getBannedKey(id))
+			if err == badger.ErrKeyNotFound {
+				continue
+			} else if err != nil {
+				return err
+			}
+
+			banned = true
+			return nil
+		}
+		return nil
+	})
+
+	return banned, err
+}
+
+// RecordFailure increments the failure count of an identifier and returns true if it resulted in a ban.
+func (t *Tracker) RecordFailure(id string) (bool, error) {
+	banned := false
+	err := t.db.Update(func(txn *badger.Txn) error {
+		count := 0
+		item, err := txn.Get(getFailuresKey(id))
+		if err != nil && err != badger.ErrKeyNotFound {
+			return err
+		} else if err == nil {
+			err = item.Value(func(val []byte) error {
+				count, err = strconv.Atoi(string(val))
+				return err
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		count++
+		if count < t.threshold {
+			e := badger.NewEntry(getFailuresKey(id), []byte(strconv.Itoa(count))).WithTTL(t.window)
+			return txn.SetEntry(e)
+		}
+
+		banned = true
+		if err := txn.Delete(getFailuresKey(id)); err != nil {
+			return err
+		}
+		e := badger.NewEntry(getBannedKey(id), []byte(strconv.Itoa(count))).WithTTL(t.banDuration)
+		return txn.SetEntry(e)
+	})
+
+	return banned, err
+}
@@ -0,0 +1,132 @@
+package sigban
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+)
+
+// TestRecordFailureBansAtThreshold verifies that an identifier is banned once it reaches the failure
+// threshold and that other identifiers are not affected.
+func TestRecordFailureBansAtThreshold(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	tr := New(db)
+	tr.SetThreshold(2)
+
+	if banned, err := tr.RecordFailure("a"); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if banned {
+		t.Fatal("got banned after first failure, want not banned")
+	}
+	if banned, err := tr.RecordFailure("a"); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if !banned {
+		t.Fatal("got not banned at threshold, want banned")
+	}
+
+	if banned, err := tr.IsBanned("b", "a"); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if !banned {
+		t.Fatal("got a not banned, want banned")
+	}
+	if banned, err := tr.IsBanned("b"); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if banned {
+		t.Fatal("got b banned, want not banned")
+	}
+}
+
+// TestParseSenders verifies that senders are parsed from single, batch, and array eth_sendUserOperation
+// requests while ignoring other methods.
+func TestParseSenders(t *testing.T) {
+	sender := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	single := []byte(`{"jsonrpc":"2.0","id":7,"method":"eth_sendUserOperation","params":[{"sender":"` +
+		sender.String() + `"},"0x"]}`)
+	if senders, id := parseSenders(single); len(senders) != 1 || senders[0] != sender || id != float64(7) {
+		t.Fatalf("got %v and id %v, want [%s] and id 7", senders, id, sender)
+	}
+
+	batch := []byte(`[{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]},` +
+		`{"jsonrpc":"2.0","id":2,"method":"eth_sendUserOperation","params":[{"sender":"` +
+		sender.String() + `"},"0x"]}]`)
+	if senders, id := parseSenders(batch); len(senders) != 1 || senders[0] != sender || id != float64(2) {
+		t.Fatalf("got %v and id %v, want [%s] and id 2", senders, id, sender)
+	}
+
+	sender2 := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	array := []byte(`{"jsonrpc":"2.0","id":3,"method":"eth_sendUserOperation","params":[[{"sender":"` +
+		sender.String() + `"},{"sender":"` + sender2.String() + `"}],"0x"]}`)
+	if senders, id := parseSenders(array); len(senders) != 2 || senders[1] != sender2 || id != float64(3) {
+		t.Fatalf("got %v and id %v, want [%s %s] and id 3", senders, id, sender, sender2)
+	}
+
+	variant := []byte(`{"jsonrpc":"2.0","id":4,"method":"Eth_sendUserOperation","params":[{"Sender":"` +
+		sender.String() + `"},"0x"]}`)
+	if senders, _ := parseSenders(variant); len(senders) != 1 || senders[0] != sender {
+		t.Fatalf("got %v, want [%s]", senders, sender)
+	}
+
+	other := []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}`)
+	if senders, _ := parseSenders(other); len(senders) != 0 {
+		t.Fatalf("got %v, want none", senders)
+	}
+}
+
+// TestIsSignatureFailure verifies that only invalid signature errors are counted as failures.
+func TestIsSignatureFailure(t *testing.T) {
+	if !isSignatureFailure([]byte(`{"error":{"code":-32507,"message":"Invalid UserOp signature"}}`)) {
+		t.Fatal("got false for INVALID_SIGNATURE, want true")
+	}
+	if !isSignatureFailure([]byte(`{"error":{"code":-32500,"message":"AA24 signature error"}}`)) {
+		t.Fatal("got false for AA24, want true")
+	}
+	if isSignatureFailure([]byte(`{"error":{"code":-32500,"message":"AA21 didn't pay prefund"}}`)) {
+		t.Fatal("got true for AA21, want false")
+	}
+	if isSignatureFailure([]byte(`{"result":"0x"}`)) {
+		t.Fatal("got true for result, want false")
+	}
+}
+
+// TestMiddlewareIgnoresUntrustedForwardedFor verifies that a client can't avoid an IP ban by setting its own
+// X-Forwarded-For header when the request does not come from a trusted proxy.
+func TestMiddlewareIgnoresUntrustedForwardedFor(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	tr := New(db)
+	tr.SetThreshold(2)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if err := r.SetTrustedProxies(nil); err != nil {
+		t.Fatal(err)
+	}
+	r.POST("/", tr.Middleware(logr.Discard()), func(g *gin.Context) {
+		g.JSON(http.StatusOK, gin.H{"error": gin.H{"code": errors.INVALID_SIGNATURE, "message": "invalid"}})
+	})
+
+	var res *httptest.ResponseRecorder
+	for i := 1; i <= 3; i++ {
+		body := []byte(fmt.Sprintf(
+			`{"jsonrpc":"2.0","id":1,"method":"eth_sendUserOperation","params":[{"sender":"%s"},"0x"]}`,
+			common.BigToAddress(big.NewInt(int64(i))),
+		))
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("10.0.0.%d", i))
+		res = httptest.NewRecorder()
+		r.ServeHTTP(res, req)
+	}
+	if !bytes.Contains(res.Body.Bytes(), []byte("temporarily banned")) {
+		t.Fatalf("got %s, want banned", res.Body.String())
+	}
+}
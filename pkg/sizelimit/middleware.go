@@ -0,0 +1,115 @@
+// Package sizelimit rejects oversized RPC requests and UserOperations before they reach validation so that
+// they don't consume simulation resources.
+package sizelimit
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+)
+
+// Limits are the max sizes in bytes enforced by the Middleware. A value of 0 disables the limit.
+type Limits struct {
+	MaxBodySize     int64
+	MaxCallDataSize int
+	MaxInitCodeSize int
+}
+
+type rpcUserOp struct {
+	CallData string
+	InitCode string
+}
+
+// hexLen returns the number of bytes in a 0x prefixed hex string without decoding it.
+func hexLen(s string) int {
+	return len(strings.TrimPrefix(s, "0x")) / 2
+}
+
+// longest returns the longest string in vals.
+func longest(vals []string) string {
+	l := ""
+	for _, v := range vals {
+		if len(v) > len(l) {
+			l = v
+		}
+	}
+	return l
+}
+
+// parseUserOps returns every UserOperation passed as the first param of a call in a single or batch request
+// along with the id of the call it belongs to.
+func parseUserOps(body []byte) (ops []rpcUserOp, ids []any) {
+	for _, r := range jsonrpc.ParseRequests(body) {
+		for _, op := range r.UserOps() {
+			ops = append(ops, rpcUserOp{
+				CallData: longest(jsonrpc.UserOpFieldValues(op, "callData")),
+				InitCode: longest(jsonrpc.UserOpFieldValues(op, "initCode")),
+			})
+			ids = append(ids, r.Id)
+		}
+	}
+	return ops, ids
+}
+
+// check returns an error message if the op exceeds any of the limits.
+func (lim Limits) check(op rpcUserOp) string {
+	if n := hexLen(op.CallData); lim.MaxCallDataSize > 0 && n > lim.MaxCallDataSize {
+		return fmt.Sprintf("sizelimit: callData is %d bytes, max is %d", n, lim.MaxCallDataSize)
+	}
+	if n := hexLen(op.InitCode); lim.MaxInitCodeSize > 0 && n > lim.MaxInitCodeSize {
+		return fmt.Sprintf("sizelimit: initCode is %d bytes, max is %d", n, lim.MaxInitCodeSize)
+	}
+	return ""
+}
+
+// Middleware returns a gin middleware that rejects requests with a body larger than MaxBodySize and any
+// request with a UserOperation param that exceeds MaxCallDataSize or MaxInitCodeSize. It should run before any
+// other middleware that reads the body.
+func Middleware(lim Limits, l logr.Logger) gin.HandlerFunc {
+	l = l.WithName("sizelimit")
+
+	return func(g *gin.Context) {
+		r := io.Reader(g.Request.Body)
+		if lim.MaxBodySize > 0 {
+			r = io.LimitReader(r, lim.MaxBodySize+1)
+		}
+		body, err := io.ReadAll(r)
+		if err != nil {
+			_ = g.Error(err)
+			g.Abort()
+			return
+		}
+		if lim.MaxBodySize > 0 && int64(len(body)) > lim.MaxBodySize {
+			l.Info("request body too large", "max", lim.MaxBodySize)
+			jsonrpc.AbortWithStatusError(
+				g,
+				http.StatusRequestEntityTooLarge,
+				errors.INVALID_FIELDS,
+				fmt.Sprintf("sizelimit: request body exceeds %d bytes", lim.MaxBodySize),
+				nil,
+			)
+			return
+		}
+		g.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		if lim.MaxCallDataSize > 0 || lim.MaxInitCodeSize > 0 {
+			ops, ids := parseUserOps(body)
+			for i, op := range ops {
+				if msg := lim.check(op); msg != "" {
+					l.Info("userOp too large", "reason", msg)
+					jsonrpc.AbortWithError(g, errors.INVALID_FIELDS, msg, ids[i])
+					return
+				}
+			}
+		}
+
+		g.Next()
+	}
+}
@@ -0,0 +1,106 @@
+package sizelimit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+)
+
+func sendBody(callData string, initCode string) []byte {
+	return []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_sendUserOperation","params":[{"callData":"` +
+		callData + `","initCode":"` + initCode + `"},"0x"]}`)
+}
+
+func newRouter(lim Limits) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/", Middleware(lim, logr.Discard()), func(g *gin.Context) {
+		g.JSON(http.StatusOK, gin.H{"result": true})
+	})
+	return r
+}
+
+func doRequest(t *testing.T, r *gin.Engine, body []byte) (int, map[string]any) {
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var res map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	return w.Code, res
+}
+
+func assertRejected(t *testing.T, res map[string]any) {
+	e, ok := res["error"].(map[string]any)
+	if !ok {
+		t.Fatalf("got %v, want error", res)
+	}
+	if int(e["code"].(float64)) != errors.INVALID_FIELDS {
+		t.Fatalf("got code %v, want %d", e["code"], errors.INVALID_FIELDS)
+	}
+}
+
+// TestBodySizeLimit verifies that requests larger than MaxBodySize are rejected with 413.
+func TestBodySizeLimit(t *testing.T) {
+	body := sendBody("0x", "0x")
+	r := newRouter(Limits{MaxBodySize: int64(len(body) - 1)})
+
+	code, res := doRequest(t, r, body)
+	if code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("got status %d, want %d", code, http.StatusRequestEntityTooLarge)
+	}
+	assertRejected(t, res)
+
+	r = newRouter(Limits{MaxBodySize: int64(len(body))})
+	if _, res := doRequest(t, r, body); res["result"] != true {
+		t.Fatalf("got %v, want result", res)
+	}
+}
+
+// TestCallDataAndInitCodeLimits verifies that ops with oversized callData or initCode are rejected and ops
+// within the limits are allowed.
+func TestCallDataAndInitCodeLimits(t *testing.T) {
+	r := newRouter(Limits{MaxCallDataSize: 4, MaxInitCodeSize: 2})
+	big := "0x" + strings.Repeat("ab", 5)
+
+	_, res := doRequest(t, r, sendBody(big, "0x"))
+	assertRejected(t, res)
+	_, res = doRequest(t, r, sendBody("0x", big))
+	assertRejected(t, res)
+	if _, res := doRequest(t, r, sendBody("0xabababab", "0xabab")); res["result"] != true {
+		t.Fatalf("got %v, want result", res)
+	}
+}
+
+// TestBatchRequestLimits verifies that any oversized op in a batch request rejects the request with the id of
+// the offending call.
+func TestBatchRequestLimits(t *testing.T) {
+	r := newRouter(Limits{MaxCallDataSize: 1})
+	body := []byte(`[{"jsonrpc":"2.0","id":1,"method":"eth_chainId"},` +
+		`{"jsonrpc":"2.0","id":2,"method":"eth_sendUserOperation","params":[{"callData":"0xabab"},"0x"]}]`)
+
+	_, res := doRequest(t, r, body)
+	assertRejected(t, res)
+	if res["id"] != float64(2) {
+		t.Fatalf("got id %v, want 2", res["id"])
+	}
+}
+
+// TestCaseVariantFieldLimits verifies that limits apply to fields set with a key that only differs in case,
+// since those are also decoded into the UserOperation.
+func TestCaseVariantFieldLimits(t *testing.T) {
+	r := newRouter(Limits{MaxCallDataSize: 1})
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"Eth_sendUserOperation","params":[{"CallData":"0xabab"},"0x"]}`)
+
+	_, res := doRequest(t, r, body)
+	assertRejected(t, res)
+}
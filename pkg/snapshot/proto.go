@@ -0,0 +1,122 @@
+package snapshot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/grpcapi/bundlerv1"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+func toProto(op *userop.UserOperation) (*bundlerv1.UserOperation, error) {
+	m, err := op.ToMap()
+	if err != nil {
+		return nil, err
+	}
+	str := func(key string) string {
+		s, _ := m[key].(string)
+		return s
+	}
+	return &bundlerv1.UserOperation{
+		Sender:               str("sender"),
+		Nonce:                str("nonce"),
+		InitCode:             str("initCode"),
+		CallData:             str("callData"),
+		CallGasLimit:         str("callGasLimit"),
+		VerificationGasLimit: str("verificationGasLimit"),
+		PreVerificationGas:   str("preVerificationGas"),
+		MaxFeePerGas:         str("maxFeePerGas"),
+		MaxPriorityFeePerGas: str("maxPriorityFeePerGas"),
+		PaymasterAndData:     str("paymasterAndData"),
+		Signature:            str("signature"),
+	}, nil
+}
+
+func fromProto(op *bundlerv1.UserOperation) (*userop.UserOperation, error) {
+	return userop.New(map[string]any{
+		"sender":               op.GetSender(),
+		"nonce":                op.GetNonce(),
+		"initCode":             op.GetInitCode(),
+		"callData":             op.GetCallData(),
+		"callGasLimit":         op.GetCallGasLimit(),
+		"verificationGasLimit": op.GetVerificationGasLimit(),
+		"preVerificationGas":   op.GetPreVerificationGas(),
+		"maxFeePerGas":         op.GetMaxFeePerGas(),
+		"maxPriorityFeePerGas": op.GetMaxPriorityFeePerGas(),
+		"paymasterAndData":     op.GetPaymasterAndData(),
+		"signature":            op.GetSignature(),
+	})
+}
+
+// encodeProto writes each UserOperation as a SendUserOperationRequest prefixed by its varint encoded length.
+// This reuses the message types of the gRPC API so that snapshots can be read with any protobuf library.
+func encodeProto(w io.Writer, s *Snapshot) error {
+	bw := bufio.NewWriter(w)
+	for _, p := range s.Pools {
+		for _, op := range p.UserOps {
+			pop, err := toProto(op)
+			if err != nil {
+				return err
+			}
+			b, err := proto.Marshal(&bundlerv1.SendUserOperationRequest{UserOp: pop, EntryPoint: p.EntryPoint.Hex()})
+			if err != nil {
+				return err
+			}
+			if _, err := bw.Write(protowire.AppendVarint(nil, uint64(len(b)))); err != nil {
+				return err
+			}
+			if _, err := bw.Write(b); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// decodeProto reads a stream written by encodeProto. Ops are grouped into a Pool per EntryPoint in the order
+// they appear.
+func decodeProto(r io.Reader) (*Snapshot, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Snapshot{Version: Version, CreatedAt: time.Now().Unix(), Pools: []*Pool{}}
+	pools := make(map[common.Address]*Pool)
+	for i := 0; len(data) > 0; i++ {
+		size, n := protowire.ConsumeVarint(data)
+		if n < 0 || uint64(len(data)-n) < size {
+			return nil, fmt.Errorf("snapshot: record %d is truncated", i)
+		}
+		data = data[n:]
+
+		var req bundlerv1.SendUserOperationRequest
+		if err := proto.Unmarshal(data[:size], &req); err != nil {
+			return nil, fmt.Errorf("snapshot: record %d: %w", i, err)
+		}
+		data = data[size:]
+
+		if !common.IsHexAddress(req.GetEntryPoint()) {
+			return nil, fmt.Errorf("snapshot: record %d has an invalid entryPoint", i)
+		}
+		op, err := fromProto(req.GetUserOp())
+		if err != nil {
+			return nil, fmt.Errorf("snapshot: record %d: %w", i, err)
+		}
+
+		ep := common.HexToAddress(req.GetEntryPoint())
+		p, ok := pools[ep]
+		if !ok {
+			p = &Pool{EntryPoint: ep, UserOps: []*userop.UserOperation{}}
+			pools[ep] = p
+			s.Pools = append(s.Pools, p)
+		}
+		p.UserOps = append(p.UserOps, op)
+	}
+	return s, nil
+}
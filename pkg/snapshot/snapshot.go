@@ -0,0 +1,142 @@
+// Package snapshot exports the entire mempool to a portable file and loads it back. Snapshots are independent
+// of the mempool's storage backend and can be used to migrate between backends, carry pending ops across an
+// upgrade, or recover from the loss of a data directory.
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+const (
+	// Version is the current version of the JSON snapshot format.
+	Version = 1
+
+	// FormatJSON encodes a Snapshot as a single JSON document.
+	FormatJSON = "json"
+
+	// FormatProto encodes a Snapshot as a stream of length delimited bundler.v1.SendUserOperationRequest
+	// messages.
+	FormatProto = "proto"
+)
+
+// Pool is the list of pending UserOperations for a single EntryPoint in the order they were added.
+type Pool struct {
+	EntryPoint common.Address          `json:"entryPoint"`
+	UserOps    []*userop.UserOperation `json:"userOps"`
+}
+
+// UnmarshalJSON decodes a Pool using the same UserOperation parsing rules as the JSON-RPC API.
+func (p *Pool) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		EntryPoint common.Address   `json:"entryPoint"`
+		UserOps    []map[string]any `json:"userOps"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	p.EntryPoint = raw.EntryPoint
+	p.UserOps = []*userop.UserOperation{}
+	for i, item := range raw.UserOps {
+		op, err := userop.New(item)
+		if err != nil {
+			return fmt.Errorf("snapshot: entryPoint %s op %d: %w", raw.EntryPoint, i, err)
+		}
+		p.UserOps = append(p.UserOps, op)
+	}
+	return nil
+}
+
+// Snapshot is a portable copy of the mempool.
+type Snapshot struct {
+	Version   int     `json:"version"`
+	CreatedAt int64   `json:"createdAt"`
+	Pools     []*Pool `json:"pools"`
+}
+
+// Len returns the total number of UserOperations in the Snapshot.
+func (s *Snapshot) Len() int {
+	n := 0
+	for _, p := range s.Pools {
+		n += len(p.UserOps)
+	}
+	return n
+}
+
+// Export returns a Snapshot of the pending UserOperations for each EntryPoint.
+func Export(mem *mempool.Mempool, entryPoints []common.Address) (*Snapshot, error) {
+	s := &Snapshot{Version: Version, CreatedAt: time.Now().Unix(), Pools: []*Pool{}}
+	for _, ep := range entryPoints {
+		ops, err := mem.Dump(ep)
+		if err != nil {
+			return nil, err
+		}
+		s.Pools = append(s.Pools, &Pool{EntryPoint: ep, UserOps: ops})
+	}
+	return s, nil
+}
+
+// Import adds every UserOperation in the Snapshot to the mempool in its original order and returns the number
+// of ops added. Client checks are not run again, so ops that have become invalid since the Snapshot was taken
+// are only dropped once they fail the Bundler's batch checks. Ops for an EntryPoint not in the given list are
+// rejected.
+func Import(mem *mempool.Mempool, entryPoints []common.Address, s *Snapshot) (int, error) {
+	supported := make(map[common.Address]bool)
+	for _, ep := range entryPoints {
+		supported[ep] = true
+	}
+	for _, p := range s.Pools {
+		if !supported[p.EntryPoint] {
+			return 0, fmt.Errorf("snapshot: entryPoint %s is not supported", p.EntryPoint)
+		}
+	}
+
+	n := 0
+	for _, p := range s.Pools {
+		for _, op := range p.UserOps {
+			if err := mem.AddOp(p.EntryPoint, op); err != nil {
+				return n, err
+			}
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Encode writes the Snapshot to w in the given format.
+func Encode(w io.Writer, s *Snapshot, format string) error {
+	switch format {
+	case FormatJSON:
+		return json.NewEncoder(w).Encode(s)
+	case FormatProto:
+		return encodeProto(w, s)
+	default:
+		return fmt.Errorf("snapshot: unsupported format %s", format)
+	}
+}
+
+// Decode reads a Snapshot from r in the given format.
+func Decode(r io.Reader, format string) (*Snapshot, error) {
+	switch format {
+	case FormatJSON:
+		var s Snapshot
+		if err := json.NewDecoder(r).Decode(&s); err != nil {
+			return nil, err
+		}
+		if s.Version != Version {
+			return nil, fmt.Errorf("snapshot: unsupported version %d", s.Version)
+		}
+		return &s, nil
+	case FormatProto:
+		return decodeProto(r)
+	default:
+		return nil, fmt.Errorf("snapshot: unsupported format %s", format)
+	}
+}
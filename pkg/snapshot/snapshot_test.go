@@ -0,0 +1,85 @@
+package snapshot
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+)
+
+func newMempoolWithOps(t *testing.T, n int) (*mempool.Mempool, []common.Address) {
+	db := testutils.DBMock()
+	t.Cleanup(func() { db.Close() })
+	mem, err := mempool.New(db)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	eps := []common.Address{testutils.ValidAddress1, testutils.ValidAddress2}
+	for i := 0; i < n; i++ {
+		op := testutils.MockValidInitUserOp()
+		op.Nonce = big.NewInt(int64(i))
+		if err := mem.AddOp(eps[i%2], op); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+	return mem, eps
+}
+
+// TestRoundTrip verifies that a Snapshot in either format can be loaded into an empty mempool with the same
+// ops in the same order.
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []string{FormatJSON, FormatProto} {
+		src, eps := newMempoolWithOps(t, 5)
+		s, err := Export(src, eps)
+		if err != nil {
+			t.Fatalf("%s: got %v, want nil", format, err)
+		}
+		var buf bytes.Buffer
+		if err := Encode(&buf, s, format); err != nil {
+			t.Fatalf("%s: got %v, want nil", format, err)
+		}
+		decoded, err := Decode(&buf, format)
+		if err != nil {
+			t.Fatalf("%s: got %v, want nil", format, err)
+		}
+
+		dst, _ := newMempoolWithOps(t, 0)
+		if n, err := Import(dst, eps, decoded); err != nil || n != 5 {
+			t.Fatalf("%s: got %d %v, want 5 nil", format, n, err)
+		}
+		for _, ep := range eps {
+			want, _ := src.Dump(ep)
+			got, _ := dst.Dump(ep)
+			if len(got) != len(want) {
+				t.Fatalf("%s: got length %d, want %d", format, len(got), len(want))
+			}
+			for i := range want {
+				if !testutils.IsOpsEqual(want[i], got[i]) {
+					t.Fatalf("%s: ops not equal: %s", format, testutils.GetOpsDiff(want[i], got[i]))
+				}
+			}
+		}
+	}
+}
+
+// TestImportUnsupportedEntryPoint verifies that a Snapshot with an unsupported EntryPoint is rejected before
+// any ops are added.
+func TestImportUnsupportedEntryPoint(t *testing.T) {
+	src, eps := newMempoolWithOps(t, 2)
+	s, err := Export(src, eps)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	dst, _ := newMempoolWithOps(t, 0)
+	if _, err := Import(dst, eps[:1], s); err == nil {
+		t.Fatal("got nil, want err")
+	}
+	if ops, _ := dst.Dump(eps[0]); len(ops) != 0 {
+		t.Fatalf("got length %d, want 0", len(ops))
+	}
+}
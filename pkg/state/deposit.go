@@ -0,0 +1,65 @@
+package state
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// depositsSlot is the storage slot of the deposits mapping in the EntryPoint's StakeManager.
+	depositsSlot = common.Big0
+
+	maxUint112 = big.NewInt(0).Sub(big.NewInt(0).Lsh(common.Big1, 112), common.Big1)
+)
+
+// DepositSlot returns the EntryPoint storage slot holding the packed deposit, staked, and stake values for a
+// given account.
+func DepositSlot(acc common.Address) common.Hash {
+	return crypto.Keccak256Hash(
+		common.LeftPadBytes(acc.Bytes(), 32),
+		common.LeftPadBytes(depositsSlot.Bytes(), 32),
+	)
+}
+
+// WithMaxDepositOverride takes a set and appends a storage override on the EntryPoint for the given account to
+// have a deposit equal to max uint112. The existing stake values must be passed in so that they are preserved
+// in the packed storage slot. A slot already overridden in the set will not be replaced.
+func WithMaxDepositOverride(
+	ep common.Address,
+	acc common.Address,
+	staked bool,
+	stake *big.Int,
+	os OverrideSet,
+) OverrideSet {
+	if os == nil {
+		os = OverrideSet{}
+	}
+
+	// Solidity packs struct members starting from the lowest order bytes of a slot:
+	// uint112 deposit | bool staked | uint112 stake
+	val := big.NewInt(0).Set(maxUint112)
+	if staked {
+		val.Or(val, big.NewInt(0).Lsh(common.Big1, 112))
+	}
+	if stake != nil {
+		val.Or(val, big.NewInt(0).Lsh(stake, 120))
+	}
+
+	slot := DepositSlot(acc)
+	oa := os[ep]
+	storage := oa.StateDiff
+	if oa.State != nil {
+		storage = oa.State
+	} else if storage == nil {
+		storage = &map[common.Hash]common.Hash{}
+		oa.StateDiff = storage
+	}
+	if _, ok := (*storage)[slot]; !ok {
+		(*storage)[slot] = common.BigToHash(val)
+	}
+
+	os[ep] = oa
+	return os
+}
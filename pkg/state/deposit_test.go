@@ -0,0 +1,48 @@
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestWithMaxDepositOverridePreservesStake(t *testing.T) {
+	ep := common.HexToAddress("0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789")
+	acc := common.HexToAddress("0x7357b8a705328FC283dF72D7Ac546895B596DC12")
+	stake := big.NewInt(1000)
+
+	os := WithMaxDepositOverride(ep, acc, true, stake, nil)
+	oa, ok := os[ep]
+	if !ok || oa.StateDiff == nil {
+		t.Fatal("OverrideSet does not contain EntryPoint stateDiff")
+	}
+
+	val := (*oa.StateDiff)[DepositSlot(acc)].Big()
+	deposit := big.NewInt(0).And(val, maxUint112)
+	staked := big.NewInt(0).Rsh(val, 112).Uint64() & 0xff
+	gotStake := big.NewInt(0).Rsh(val, 120)
+	if deposit.Cmp(maxUint112) != 0 {
+		t.Fatalf("got deposit %s, want %s", deposit, maxUint112)
+	} else if staked != 1 {
+		t.Fatalf("got staked %d, want 1", staked)
+	} else if gotStake.Cmp(stake) != 0 {
+		t.Fatalf("got stake %s, want %s", gotStake, stake)
+	}
+}
+
+func TestWithMaxDepositOverrideKeepsExistingSlot(t *testing.T) {
+	ep := common.HexToAddress("0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789")
+	acc := common.HexToAddress("0x7357b8a705328FC283dF72D7Ac546895B596DC12")
+	existing := common.HexToHash("0x01")
+	os := OverrideSet{
+		ep: OverrideAccount{State: &map[common.Hash]common.Hash{DepositSlot(acc): existing}},
+	}
+
+	os = WithMaxDepositOverride(ep, acc, false, nil, os)
+	if os[ep].StateDiff != nil {
+		t.Fatal("got stateDiff, want only state")
+	} else if (*os[ep].State)[DepositSlot(acc)] != existing {
+		t.Fatalf("got %s, want %s", (*os[ep].State)[DepositSlot(acc)], existing)
+	}
+}
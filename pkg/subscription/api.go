@@ -0,0 +1,159 @@
+package subscription
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/stakegrace"
+)
+
+// Filter limits UserOperation notifications to the given hashes or senders. An empty filter matches all
+// UserOperations.
+type Filter struct {
+	UserOpHashes []common.Hash    `json:"userOpHashes"`
+	Senders      []common.Address `json:"senders"`
+}
+
+func (f *Filter) matches(ev *UserOpEvent) bool {
+	if f == nil || (len(f.UserOpHashes) == 0 && len(f.Senders) == 0) {
+		return true
+	}
+	for _, h := range f.UserOpHashes {
+		if h == ev.UserOpHash {
+			return true
+		}
+	}
+	for _, s := range f.Senders {
+		if s == ev.Sender {
+			return true
+		}
+	}
+	return false
+}
+
+// API exposes subscriptions in the eth namespace. Each method is called through eth_subscribe with the
+// method name as the first param, e.g. ["userOperationIncluded", {"senders": ["0x..."]}].
+type API struct {
+	m *Manager
+}
+
+func (a *API) notify(ctx context.Context, event string, match func(any) bool) (*rpc.Subscription, error) {
+	n, ok := rpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+
+	sub := n.CreateSubscription()
+	id, ch := a.m.subscribe(event)
+	go func() {
+		defer a.m.unsubscribe(id)
+		for {
+			select {
+			case data := <-ch:
+				if match(data) {
+					if err := n.Notify(sub.ID, data); err != nil {
+						return
+					}
+				}
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+func (a *API) notifyUserOps(ctx context.Context, event string, f *Filter) (*rpc.Subscription, error) {
+	return a.notify(ctx, event, func(data any) bool {
+		return f.matches(data.(*UserOpEvent))
+	})
+}
+
+// UserOperationPending subscribes to UserOperations accepted into the mempool.
+func (a *API) UserOperationPending(ctx context.Context, f *Filter) (*rpc.Subscription, error) {
+	return a.notifyUserOps(ctx, EventPending, f)
+}
+
+// UserOperationIncluded subscribes to UserOperations sent in a bundle.
+func (a *API) UserOperationIncluded(ctx context.Context, f *Filter) (*rpc.Subscription, error) {
+	return a.notifyUserOps(ctx, EventIncluded, f)
+}
+
+// UserOperationDropped subscribes to UserOperations removed from the mempool without being sent.
+func (a *API) UserOperationDropped(ctx context.Context, f *Filter) (*rpc.Subscription, error) {
+	return a.notifyUserOps(ctx, EventDropped, f)
+}
+
+// NewBundles subscribes to bundles sent by the Bundler.
+func (a *API) NewBundles(ctx context.Context) (*rpc.Subscription, error) {
+	return a.notify(ctx, EventBundle, func(data any) bool { return true })
+}
+
+// PaymasterFilter limits paymaster notifications to the given addresses. An empty filter matches all
+// paymasters.
+type PaymasterFilter struct {
+	Paymasters []common.Address `json:"paymasters"`
+}
+
+func (f *PaymasterFilter) matches(ev *stakegrace.Event) bool {
+	if f == nil || len(f.Paymasters) == 0 {
+		return true
+	}
+	for _, pm := range f.Paymasters {
+		if pm == ev.Paymaster {
+			return true
+		}
+	}
+	return false
+}
+
+// PaymasterStakeLow subscribes to paymasters whose stake has dropped below the minimum while they have pending
+// UserOperations. New ops from the paymaster are rejected until the stake is topped up and pending ops are
+// dropped after the grace period.
+func (a *API) PaymasterStakeLow(ctx context.Context, f *PaymasterFilter) (*rpc.Subscription, error) {
+	return a.notify(ctx, EventPaymasterStakeLow, func(data any) bool {
+		return f.matches(data.(*stakegrace.Event))
+	})
+}
+
+// allowOrigin returns true if the origin matches one of the allowed origins. An allowed origin may contain a
+// single * wildcard, e.g. https://*.example.com, and a * origin on its own allows all origins.
+func allowOrigin(origins []string, origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, o := range origins {
+		o = strings.ToLower(o)
+		if o == "*" || o == origin {
+			return true
+		}
+		if prefix, suffix, ok := strings.Cut(o, "*"); ok &&
+			len(origin) >= len(prefix)+len(suffix) &&
+			strings.HasPrefix(origin, prefix) &&
+			strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler returns an http.Handler that serves eth_subscribe and eth_unsubscribe over WebSocket. Connections
+// with an Origin header are rejected unless it matches one of the allowed origins. Connections without an
+// Origin header are from non-browser clients and are always accepted.
+func (m *Manager) Handler(origins []string) (http.Handler, error) {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", &API{m: m}); err != nil {
+		return nil, err
+	}
+
+	// Origins are checked here since the WebSocket handler does not support wildcard subdomains.
+	ws := srv.WebsocketHandler([]string{"*"})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" && !allowOrigin(origins, origin) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		ws.ServeHTTP(w, r)
+	}), nil
+}
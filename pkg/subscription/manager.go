@@ -0,0 +1,158 @@
+// Package subscription implements a WebSocket JSON-RPC transport that allows clients to subscribe to
+// UserOperation and bundle events with eth_subscribe instead of polling eth_getUserOperationReceipt.
+package subscription
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/stakegrace"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+const (
+	// EventPending is published when a UserOperation has been accepted into the mempool.
+	EventPending = "userOperationPending"
+
+	// EventIncluded is published when a UserOperation has been sent in a bundle.
+	EventIncluded = "userOperationIncluded"
+
+	// EventDropped is published when a UserOperation has been removed from the mempool without being sent.
+	EventDropped = "userOperationDropped"
+
+	// EventBundle is published when a bundle has been sent.
+	EventBundle = "newBundles"
+
+	// EventPaymasterStakeLow is published when a paymaster with pending ops drops below the minimum stake.
+	EventPaymasterStakeLow = "paymasterStakeLow"
+)
+
+var (
+	// BufferSize is the number of events that can be queued for each subscriber. Events are dropped for a
+	// subscriber that is not keeping up so that the Client and Bundler are never blocked.
+	BufferSize = 256
+)
+
+// UserOpEvent is the payload of a UserOperation notification.
+type UserOpEvent struct {
+	UserOpHash      common.Hash    `json:"userOpHash"`
+	EntryPoint      common.Address `json:"entryPoint"`
+	Sender          common.Address `json:"sender"`
+	Nonce           *hexutil.Big   `json:"nonce"`
+	TransactionHash string         `json:"transactionHash,omitempty"`
+	Reason          string         `json:"reason,omitempty"`
+}
+
+// BundleEvent is the payload of a bundle notification.
+type BundleEvent struct {
+	EntryPoint      common.Address `json:"entryPoint"`
+	TransactionHash string         `json:"transactionHash"`
+	UserOpHashes    []common.Hash  `json:"userOpHashes"`
+}
+
+type subscriber struct {
+	event string
+	ch    chan any
+}
+
+// Manager fans out events from the Client and Bundler pipelines to all subscribers.
+type Manager struct {
+	mu   sync.Mutex
+	next int
+	subs map[int]*subscriber
+}
+
+// New returns a Manager with no subscribers.
+func New() *Manager {
+	return &Manager{subs: make(map[int]*subscriber)}
+}
+
+func (m *Manager) subscribe(event string) (int, <-chan any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := m.next
+	m.next++
+	m.subs[id] = &subscriber{event: event, ch: make(chan any, BufferSize)}
+	return id, m.subs[id].ch
+}
+
+func (m *Manager) unsubscribe(id int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.subs, id)
+}
+
+func (m *Manager) publish(event string, data any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, s := range m.subs {
+		if s.event != event {
+			continue
+		}
+		select {
+		case s.ch <- data:
+		default:
+		}
+	}
+}
+
+func newUserOpEvent(op *userop.UserOperation, hash common.Hash, ep common.Address) *UserOpEvent {
+	return &UserOpEvent{
+		UserOpHash: hash,
+		EntryPoint: ep,
+		Sender:     op.Sender,
+		Nonce:      (*hexutil.Big)(op.Nonce),
+	}
+}
+
+// PublishPending returns a UserOpHandler that publishes an event for each UserOperation accepted by the
+// Client. This module should be used last.
+func (m *Manager) PublishPending() modules.UserOpHandlerFunc {
+	return func(ctx *modules.UserOpHandlerCtx) error {
+		hash := ctx.UserOp.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)
+		m.publish(EventPending, newUserOpEvent(ctx.UserOp, hash, ctx.EntryPoint))
+		return nil
+	}
+}
+
+// PublishBatch returns a BatchHandler that publishes events for a bundle that has been sent, each op in it,
+// and each op that was dropped during the run. This module should be used after the relayer.
+func (m *Manager) PublishBatch() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		txn, _ := ctx.Data["txn_hash"].(string)
+		if len(ctx.Batch) > 0 && txn != "" {
+			hashes := []common.Hash{}
+			for _, op := range ctx.Batch {
+				hash := op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)
+				hashes = append(hashes, hash)
+
+				ev := newUserOpEvent(op, hash, ctx.EntryPoint)
+				ev.TransactionHash = txn
+				m.publish(EventIncluded, ev)
+			}
+			m.publish(EventBundle, &BundleEvent{
+				EntryPoint:      ctx.EntryPoint,
+				TransactionHash: txn,
+				UserOpHashes:    hashes,
+			})
+		}
+
+		for _, item := range ctx.PendingRemoval {
+			ev := newUserOpEvent(item.Op, item.Op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID), ctx.EntryPoint)
+			ev.Reason = item.Reason
+			m.publish(EventDropped, ev)
+		}
+		return nil
+	}
+}
+
+// PublishPaymasterStakeLow publishes an event for a paymaster that has entered a stake grace period. This can
+// be used as a stakegrace.NotifyFunc.
+func (m *Manager) PublishPaymasterStakeLow(ev *stakegrace.Event) {
+	m.publish(EventPaymasterStakeLow, ev)
+}
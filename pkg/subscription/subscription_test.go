@@ -0,0 +1,193 @@
+package subscription
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func newTestClient(t *testing.T, m *Manager) *rpc.Client {
+	h, err := m.Handler([]string{"*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	c, err := rpc.DialWebsocket(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+func receive[T any](t *testing.T, ch chan T) T {
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for notification")
+	}
+	var zero T
+	return zero
+}
+
+// TestSubscribePending verifies that a subscriber is notified of UserOperations accepted by the Client.
+func TestSubscribePending(t *testing.T) {
+	m := New()
+	c := newTestClient(t, m)
+	ch := make(chan *UserOpEvent, 1)
+	sub, err := c.EthSubscribe(context.Background(), ch, EventPending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	op := testutils.MockValidInitUserOp()
+	ctx := &modules.UserOpHandlerCtx{UserOp: op, EntryPoint: testutils.ValidAddress1, ChainID: testutils.ChainID}
+	if err := m.PublishPending()(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	ev := receive(t, ch)
+	if want := op.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID); ev.UserOpHash != want {
+		t.Fatalf("got %s, want %s", ev.UserOpHash, want)
+	}
+}
+
+// TestSubscribeIncludedWithFilter verifies that a subscriber is only notified of included UserOperations that
+// match its filter.
+func TestSubscribeIncludedWithFilter(t *testing.T) {
+	m := New()
+	c := newTestClient(t, m)
+	ch := make(chan *UserOpEvent, 2)
+	sub, err := c.EthSubscribe(
+		context.Background(),
+		ch,
+		EventIncluded,
+		&Filter{Senders: []common.Address{testutils.ValidAddress2}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	op1 := testutils.MockValidInitUserOp()
+	op2 := testutils.MockValidInitUserOp()
+	op2.Sender = testutils.ValidAddress2
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{op1, op2},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+	ctx.Data["txn_hash"] = common.HexToHash("0x01").String()
+	if err := m.PublishBatch()(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	ev := receive(t, ch)
+	if ev.Sender != testutils.ValidAddress2 {
+		t.Fatalf("got sender %s, want %s", ev.Sender, testutils.ValidAddress2)
+	}
+	if ev.TransactionHash != ctx.Data["txn_hash"] {
+		t.Fatalf("got txn %s, want %s", ev.TransactionHash, ctx.Data["txn_hash"])
+	}
+	select {
+	case ev := <-ch:
+		t.Fatalf("got unexpected notification for %s", ev.Sender)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestSubscribeDropped verifies that a subscriber is notified of UserOperations removed during a bundler run.
+func TestSubscribeDropped(t *testing.T) {
+	m := New()
+	c := newTestClient(t, m)
+	ch := make(chan *UserOpEvent, 1)
+	sub, err := c.EthSubscribe(context.Background(), ch, EventDropped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	op := testutils.MockValidInitUserOp()
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{op},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+	ctx.MarkOpIndexForRemoval(0, "expired")
+	if err := m.PublishBatch()(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	ev := receive(t, ch)
+	if ev.Reason != "expired" {
+		t.Fatalf("got reason %s, want expired", ev.Reason)
+	}
+}
+
+// TestAllowOrigin verifies that origins are matched exactly or by a wildcard.
+func TestAllowOrigin(t *testing.T) {
+	origins := []string{"https://app.example.com", "https://*.test.com"}
+	cases := map[string]bool{
+		"https://app.example.com": true,
+		"https://APP.example.com": true,
+		"https://a.test.com":      true,
+		"https://evil.com":        false,
+		"http://app.example.com":  false,
+		"https://test.com":        false,
+	}
+	for origin, want := range cases {
+		if got := allowOrigin(origins, origin); got != want {
+			t.Fatalf("got %v for %s, want %v", got, origin, want)
+		}
+	}
+	if !allowOrigin([]string{"*"}, "https://evil.com") {
+		t.Fatal("got false for * origin, want true")
+	}
+}
+
+// TestHandlerChecksOrigin verifies that WebSocket connections from an origin that is not allowed are
+// rejected.
+func TestHandlerChecksOrigin(t *testing.T) {
+	h, err := New().Handler([]string{"https://app.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	for origin, ok := range map[string]bool{"https://app.example.com": true, "https://evil.com": false, "": true} {
+		header := http.Header{}
+		if origin != "" {
+			header.Set("Origin", origin)
+		}
+		c, err := rpc.DialOptions(context.Background(), url, rpc.WithHeaders(header))
+		if ok && err != nil {
+			t.Fatalf("got %v for origin %q, want nil", err, origin)
+		} else if !ok && err == nil {
+			t.Fatalf("got nil for origin %q, want error", origin)
+		}
+		if c != nil {
+			c.Close()
+		}
+	}
+}
@@ -14,6 +14,8 @@ var tracer = {
     "bb47ee3e183a558b1a2ff0874b079f3fc5478b7454eacf2bfc5af2ff5878f972",
   stopCollecting: false,
   topLevelCallCounter: 0,
+  // addresses of allowlisted verifier contracts to skip, set by WithSkippedAddresses.
+  skipAddresses: {},
 
   fault: function fault(log, _db) {
     this.debug.push(
@@ -73,6 +75,9 @@ var tracer = {
     if (this.stopCollecting) {
       return;
     }
+    if (this.skipAddresses[toHex(log.contract.getAddress())]) {
+      return;
+    }
     var opcode = log.op.toString();
 
     var stackSize = log.stack.length();
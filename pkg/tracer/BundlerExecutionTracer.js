@@ -3,6 +3,7 @@ var tracer = {
   validationOOG: false,
   executionOOG: false,
   executionGasLimit: 0,
+  validationRefund: 0,
 
   _depth: 0,
   _executionGasStack: [],
@@ -51,6 +52,7 @@ var tracer = {
       validationOOG: this.validationOOG,
       executionOOG: this.executionOOG,
       executionGasLimit: this.executionGasLimit,
+      validationRefund: this.validationRefund,
       userOperationEvent: this.userOperationEvent,
       output: toHex(ctx.output),
       error: ctx.error,
@@ -111,6 +113,9 @@ var tracer = {
     )
       this._setUserOperationEvent(opcode, log);
 
+    // The refund counter is cumulative so the last value seen during validation is the total refund.
+    if (this._isValidation()) this.validationRefund = log.getRefund();
+
     if (log.getGas() < log.getCost() && this._isValidation())
       this.validationOOG = true;
 
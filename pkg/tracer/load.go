@@ -4,9 +4,12 @@ package tracer
 
 import (
 	"embed"
+	"fmt"
 	"io/fs"
 	"regexp"
 	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
 )
 
 //go:embed *BundlerCollectorTracer.js
@@ -17,6 +20,8 @@ var (
 	whiteSpaceRegex = regexp.MustCompile(`\B\s+|\s+\B`)
 	constInitStr    = "var tracer ="
 	endLineChar     = ";"
+
+	skipAddressesInitStr = "skipAddresses:{}"
 )
 
 // parse takes the raw tracer from file and removes all non-essential code.
@@ -90,3 +95,14 @@ func NewTracers() (*Tracers, error) {
 		BundlerExecutionTracer: et,
 	}, nil
 }
+
+// WithSkippedAddresses returns a copy of the BundlerCollectorTracer that does not collect opcodes, storage
+// access, or contract sizes for steps executed by the given addresses. This is only safe for contracts that
+// have been verified to be side-effect free.
+func WithSkippedAddresses(bct string, addrs []common.Address) string {
+	skip := []string{}
+	for _, addr := range addrs {
+		skip = append(skip, fmt.Sprintf("%q:true", strings.ToLower(addr.Hex())))
+	}
+	return strings.Replace(bct, skipAddressesInitStr, "skipAddresses:{"+strings.Join(skip, ",")+"}", 1)
+}
@@ -0,0 +1,18 @@
+package tracer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestWithSkippedAddresses(t *testing.T) {
+	addr := common.HexToAddress("0xc2b78104907F722DABAc4C69f826a522B2754De4")
+	bct := WithSkippedAddresses(Loaded.BundlerCollectorTracer, []common.Address{addr})
+
+	want := `skipAddresses:{"0xc2b78104907f722dabac4c69f826a522b2754de4":true}`
+	if !strings.Contains(bct, want) {
+		t.Fatalf("got %s, want tracer containing %s", bct, want)
+	}
+}
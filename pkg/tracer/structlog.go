@@ -0,0 +1,463 @@
+package tracer
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// StructLogProfile can be used in place of a tracer to validate UserOperations against the built-in
+// structLogger. Opcode and storage rules are then checked by parsing the struct logs in Go. This works on
+// nodes without JS or native tracer support but is much slower since every step, including its stack and
+// memory, is returned over RPC.
+const StructLogProfile = "structLogger"
+
+// StructLog is a single EVM step returned by the built-in structLogger.
+type StructLog struct {
+	Pc      uint64   `json:"pc"`
+	Op      string   `json:"op"`
+	Gas     uint64   `json:"gas"`
+	GasCost uint64   `json:"gasCost"`
+	Depth   int      `json:"depth"`
+	Error   string   `json:"error,omitempty"`
+	Stack   []string `json:"stack"`
+	Memory  []string `json:"memory"`
+}
+
+// StructLogResult is the return value from performing an EVM trace with the built-in structLogger.
+type StructLogResult struct {
+	Gas         uint64      `json:"gas"`
+	Failed      bool        `json:"failed"`
+	ReturnValue string      `json:"returnValue"`
+	StructLogs  []StructLog `json:"structLogs"`
+}
+
+// CodeSizeFunc returns the size of the code deployed at an address.
+type CodeSizeFunc func(addr common.Address) (int, error)
+
+var (
+	stopCollectingTopic, _ = big.NewInt(0).SetString(
+		"bb47ee3e183a558b1a2ff0874b079f3fc5478b7454eacf2bfc5af2ff5878f972",
+		16,
+	)
+	unimportantOpcodeRegex = regexp.MustCompile(
+		`^(DUP\d+|PUSH\d+|SWAP\d+|POP|ADD|SUB|MUL|DIV|EQ|LTE?|S?GTE?|SLT|SH[LR]|AND|OR|NOT|ISZERO)$`,
+	)
+	accessOpcodeRegex     = regexp.MustCompile(`^(EXT.*|CALL|CALLCODE|DELEGATECALL|STATICCALL)$`)
+	safeExtCodeSizeRegex  = regexp.MustCompile(`^(\w+),EXTCODESIZE,ISZERO$`)
+	maxReturnDataLen      = 1999
+	maxKeccakInputLen     = 512
+	minKeccakInputLen     = 20
+	maxAllowedPrecompiled = int64(10)
+)
+
+type structLogOp struct {
+	opcode    string
+	stackTop3 []*big.Int
+}
+
+// structLogFrame is a call frame entered during the trace.
+type structLogFrame struct {
+	addr     common.Address
+	isCreate bool
+	pending  bool
+	callIdx  int
+	startGas uint64
+	lastGas  uint64
+	out      []byte
+}
+
+// structLogParser rebuilds the output of BundlerCollectorTracer.js from struct logs. Values that the JS
+// tracer reads from state during the trace are recovered from later steps where possible and from the
+// CodeSizeFunc otherwise.
+type structLogParser struct {
+	logs     []StructLog
+	skip     map[common.Address]bool
+	codeSize CodeSizeFunc
+	sizes    map[common.Address]int
+
+	out              *BundlerCollectorReturn
+	frames           []*structLogFrame
+	lastOp           string
+	lastThreeOpcodes []structLogOp
+	stopCollecting   bool
+}
+
+func parseWord(s string) *big.Int {
+	v, ok := big.NewInt(0).SetString(strings.TrimPrefix(s, "0x"), 16)
+	if !ok {
+		return big.NewInt(0)
+	}
+	return v
+}
+
+// peek returns the nth item from the top of the stack.
+func peek(log *StructLog, n int) *big.Int {
+	if n >= len(log.Stack) {
+		return big.NewInt(0)
+	}
+	return parseWord(log.Stack[len(log.Stack)-1-n])
+}
+
+// memorySlice returns size bytes of memory from offset, padded with zeros beyond the current memory size.
+func memorySlice(log *StructLog, offset *big.Int, size int) []byte {
+	out := make([]byte, size)
+	memSize := int64(len(log.Memory) * 32)
+	if !offset.IsInt64() || offset.Int64() >= memSize {
+		return out
+	}
+
+	start := offset.Int64()
+	for i := 0; i < size && start+int64(i) < memSize; i++ {
+		pos := start + int64(i)
+		word := strings.TrimPrefix(log.Memory[pos/32], "0x")
+		b, err := hex.DecodeString(word[(pos%32)*2 : (pos%32)*2+2])
+		if err == nil {
+			out[i] = b[0]
+		}
+	}
+	return out
+}
+
+func boundedLen(v *big.Int, max int) int {
+	if !v.IsInt64() || v.Int64() > int64(max) {
+		return max
+	}
+	return int(v.Int64())
+}
+
+func (p *structLogParser) getCodeSize(addr common.Address) (int, error) {
+	if size, ok := p.sizes[addr]; ok {
+		return size, nil
+	}
+	size, err := p.codeSize(addr)
+	if err != nil {
+		return 0, err
+	}
+	p.sizes[addr] = size
+	return size, nil
+}
+
+func (p *structLogParser) current() *structLogFrame {
+	return p.frames[len(p.frames)-1]
+}
+
+// level returns the info for the latest call from the EntryPoint or nil if there is none yet.
+func (p *structLogParser) level() *CallFromEntryPointInfo {
+	if n := len(p.out.CallsFromEntryPoint); n > 0 {
+		return &p.out.CallsFromEntryPoint[n-1]
+	}
+	return nil
+}
+
+// enter pushes a new frame for a call or create made at step i.
+func (p *structLogParser) enter(i int) {
+	log := &p.logs[i]
+	caller := p.current()
+	f := &structLogFrame{addr: caller.addr, callIdx: -1}
+	call := CallInfo{Type: log.Op, From: caller.addr, Value: "0"}
+
+	switch log.Op {
+	case "CALL", "CALLCODE":
+		to := common.BigToAddress(peek(log, 1))
+		call.To = to
+		call.Value = peek(log, 2).String()
+		call.Method = hexutil.Encode(memorySlice(log, peek(log, 3), boundedLen(peek(log, 4), 4)))
+		if log.Op == "CALL" {
+			f.addr = to
+		}
+	case "DELEGATECALL", "STATICCALL":
+		to := common.BigToAddress(peek(log, 1))
+		call.To = to
+		call.Method = hexutil.Encode(memorySlice(log, peek(log, 2), boundedLen(peek(log, 3), 4)))
+		if log.Op == "STATICCALL" {
+			f.addr = to
+		}
+	case "CREATE":
+		call.Value = peek(log, 0).String()
+		f.isCreate = true
+		f.pending = true
+		f.addr = common.Address{}
+	case "CREATE2":
+		call.Value = peek(log, 0).String()
+		initCode := memorySlice(log, peek(log, 1), boundedLen(peek(log, 2), 1<<24))
+		salt := common.BigToHash(peek(log, 3))
+		f.isCreate = true
+		f.addr = crypto.CreateAddress2(caller.addr, salt, crypto.Keccak256(initCode))
+		call.To = f.addr
+	}
+
+	if i+1 < len(p.logs) && p.logs[i+1].Depth == log.Depth+1 {
+		f.startGas = p.logs[i+1].Gas
+	} else if g := peek(log, 0); g.IsUint64() && !f.isCreate {
+		f.startGas = g.Uint64()
+		if max := log.Gas - log.Gas/64; f.startGas > max {
+			f.startGas = max
+		}
+	}
+	f.lastGas = f.startGas
+	call.Gas = float64(f.startGas)
+
+	if !p.stopCollecting {
+		f.callIdx = len(p.out.Calls)
+		p.out.Calls = append(p.out.Calls, call)
+	}
+	p.frames = append(p.frames, f)
+}
+
+// exit pops the current frame. The step at i is the first step back in the calling frame, where the top of
+// the stack holds the success flag of a call or the address of a created contract.
+func (p *structLogParser) exit(i int) {
+	f := p.current()
+	p.frames = p.frames[:len(p.frames)-1]
+	res := big.NewInt(0)
+	if i < len(p.logs) {
+		res = peek(&p.logs[i], 0)
+	}
+
+	if f.isCreate && res.Sign() != 0 {
+		created := common.BigToAddress(res)
+		p.sizes[created] = len(f.out)
+		if f.pending {
+			p.resolveCreated(f, created)
+		}
+	}
+	if f.callIdx < 0 || p.stopCollecting {
+		return
+	}
+
+	exitType := "RETURN"
+	if res.Sign() == 0 {
+		exitType = "REVERT"
+	}
+	data := f.out
+	if len(data) > maxReturnDataLen {
+		data = data[:maxReturnDataLen]
+	}
+	p.out.Calls = append(p.out.Calls, CallInfo{
+		Type:    exitType,
+		GasUsed: float64(f.startGas - f.lastGas),
+		Data:    hexutil.Encode(data),
+	})
+}
+
+// resolveCreated replaces the placeholder address of a contract made with CREATE once its address is known.
+func (p *structLogParser) resolveCreated(f *structLogFrame, created common.Address) {
+	if f.callIdx >= 0 {
+		p.out.Calls[f.callIdx].To = created
+		for j := f.callIdx + 1; j < len(p.out.Calls); j++ {
+			if p.out.Calls[j].From == f.addr && p.out.Calls[j].Type != "RETURN" && p.out.Calls[j].Type != "REVERT" {
+				p.out.Calls[j].From = created
+			}
+		}
+	}
+	if level := p.level(); level != nil {
+		if access, ok := level.Access[f.addr]; ok {
+			delete(level.Access, f.addr)
+			level.Access[created] = access
+		}
+	}
+}
+
+// step is a port of BundlerCollectorTracer.step.
+func (p *structLogParser) step(i int) error {
+	log := &p.logs[i]
+	f := p.current()
+	if log.Op == "RETURN" || log.Op == "REVERT" {
+		f.out = memorySlice(log, peek(log, 0), boundedLen(peek(log, 1), 1<<24))
+	}
+	if log.Gas >= log.GasCost {
+		f.lastGas = log.Gas - log.GasCost
+	}
+
+	if p.stopCollecting || p.skip[f.addr] {
+		return nil
+	}
+	opcode := log.Op
+	level := p.level()
+
+	stackTop3 := []*big.Int{}
+	for j := 0; j < 3 && j < len(log.Stack); j++ {
+		stackTop3 = append(stackTop3, peek(log, j))
+	}
+	p.lastThreeOpcodes = append(p.lastThreeOpcodes, structLogOp{opcode, stackTop3})
+	if len(p.lastThreeOpcodes) > 3 {
+		p.lastThreeOpcodes = p.lastThreeOpcodes[1:]
+	}
+	if level != nil && (log.Gas < log.GasCost || (opcode == "SSTORE" && log.Gas < 2300)) {
+		level.OOG = true
+	}
+
+	if opcode == "REVERT" || opcode == "RETURN" {
+		if log.Depth == 1 {
+			data := f.out
+			if len(data) > maxReturnDataLen {
+				data = data[:maxReturnDataLen]
+			}
+			p.out.Calls = append(p.out.Calls, CallInfo{Type: opcode, Data: hexutil.Encode(data)})
+		}
+		p.lastThreeOpcodes = []structLogOp{}
+	}
+
+	if log.Depth == 1 {
+		if opcode == "CALL" || opcode == "STATICCALL" {
+			ofs := peek(log, 3)
+			if opcode == "STATICCALL" {
+				ofs = peek(log, 2)
+			}
+			p.out.CallsFromEntryPoint = append(p.out.CallsFromEntryPoint, CallFromEntryPointInfo{
+				TopLevelMethodSig:     memorySlice(log, ofs, 4),
+				TopLevelTargetAddress: common.BigToAddress(peek(log, 1)),
+				Opcodes:               Counts{},
+				Access:                AccessMap{},
+				ContractSize:          ContractSizeMap{},
+				ExtCodeAccessInfo:     ExtCodeAccessInfoMap{},
+			})
+		} else if opcode == "LOG1" && peek(log, 2).Cmp(stopCollectingTopic) == 0 {
+			p.stopCollecting = true
+		}
+		p.lastOp = ""
+		return nil
+	}
+	if level == nil {
+		return nil
+	}
+
+	if len(p.lastThreeOpcodes) >= 2 {
+		lastOpInfo := p.lastThreeOpcodes[len(p.lastThreeOpcodes)-2]
+		if strings.HasPrefix(lastOpInfo.opcode, "EXT") && len(lastOpInfo.stackTop3) > 0 {
+			addr := common.BigToAddress(lastOpInfo.stackTop3[0])
+			ops := []string{}
+			for _, o := range p.lastThreeOpcodes {
+				ops = append(ops, o.opcode)
+			}
+			if !safeExtCodeSizeRegex.MatchString(strings.Join(ops, ",")) {
+				level.ExtCodeAccessInfo[addr] = opcode
+			}
+		}
+	}
+
+	if accessOpcodeRegex.MatchString(opcode) {
+		idx := 1
+		if strings.HasPrefix(opcode, "EXT") {
+			idx = 0
+		}
+		addr := common.BigToAddress(peek(log, idx))
+		addrInt := addr.Big()
+		isAllowedPrecompiled := addrInt.Sign() > 0 && addrInt.Cmp(big.NewInt(maxAllowedPrecompiled)) < 0
+		if _, ok := level.ContractSize[addr]; !ok && !isAllowedPrecompiled {
+			size, err := p.getCodeSize(addr)
+			if err != nil {
+				return err
+			}
+			level.ContractSize[addr] = ContractSizeInfo{ContractSize: float64(size), Opcode: opcode}
+		}
+	}
+
+	if p.lastOp == "GAS" && !strings.Contains(opcode, "CALL") {
+		level.Opcodes["GAS"]++
+	}
+	if opcode != "GAS" && !unimportantOpcodeRegex.MatchString(opcode) {
+		level.Opcodes[opcode]++
+	}
+	p.lastOp = opcode
+
+	if opcode == "SLOAD" || opcode == "SSTORE" {
+		slotHex := common.BigToHash(peek(log, 0)).Hex()
+		access, ok := level.Access[f.addr]
+		if !ok {
+			access = AccessInfo{Reads: HexMap{}, Writes: Counts{}}
+			level.Access[f.addr] = access
+		}
+		if opcode == "SLOAD" {
+			_, read := access.Reads[slotHex]
+			_, written := access.Writes[slotHex]
+			if !read && !written {
+				// The loaded value is on top of the stack in the next step of the same frame.
+				val := big.NewInt(0)
+				if i+1 < len(p.logs) && p.logs[i+1].Depth == log.Depth {
+					val = peek(&p.logs[i+1], 0)
+				}
+				access.Reads[slotHex] = common.BigToHash(val).Hex()
+			}
+		} else {
+			access.Writes[slotHex]++
+		}
+	}
+
+	if opcode == "KECCAK256" {
+		size := peek(log, 1)
+		if size.IsInt64() && size.Int64() > int64(minKeccakInputLen) && size.Int64() < int64(maxKeccakInputLen) {
+			p.out.Keccak = append(p.out.Keccak, hexutil.Encode(memorySlice(log, peek(log, 0), int(size.Int64()))))
+		}
+	} else if strings.HasPrefix(opcode, "LOG") {
+		count := int(opcode[3] - '0')
+		topics := []string{}
+		for j := 0; j < count; j++ {
+			topics = append(topics, "0x"+peek(log, 2+j).Text(16))
+		}
+		data := memorySlice(log, peek(log, 0), boundedLen(peek(log, 1), 1<<24))
+		p.out.Logs = append(p.out.Logs, LogInfo{Topics: topics, Data: hexutil.Encode(data)})
+	}
+	return nil
+}
+
+// ParseStructLogs rebuilds the result of tracing a call to the EntryPoint with BundlerCollectorTracer.js
+// from the output of the built-in structLogger. The trace must be made with memory enabled. Steps inside
+// contracts at the skipped addresses are ignored, similar to WithSkippedAddresses. The code size of
+// contracts that exist before the trace is read with codeSize.
+func ParseStructLogs(
+	res *StructLogResult,
+	entryPoint common.Address,
+	skip []common.Address,
+	codeSize CodeSizeFunc,
+) (*BundlerCollectorReturn, error) {
+	p := &structLogParser{
+		logs:     res.StructLogs,
+		skip:     make(map[common.Address]bool),
+		codeSize: codeSize,
+		sizes:    make(map[common.Address]int),
+		out: &BundlerCollectorReturn{
+			CallsFromEntryPoint: []CallFromEntryPointInfo{},
+			Keccak:              []string{},
+			Calls:               []CallInfo{},
+			Logs:                []LogInfo{},
+			Debug:               []any{},
+		},
+		frames: []*structLogFrame{{addr: entryPoint, callIdx: -1}},
+	}
+	for _, addr := range skip {
+		p.skip[addr] = true
+	}
+
+	for i := range p.logs {
+		for len(p.frames) > p.logs[i].Depth && len(p.frames) > 1 {
+			p.exit(i)
+		}
+		if err := p.step(i); err != nil {
+			return nil, err
+		}
+		if p.logs[i].Error != "" {
+			p.out.Debug = append(p.out.Debug, fmt.Sprintf("fault depth=%d err=%s", p.logs[i].Depth, p.logs[i].Error))
+		}
+
+		switch p.logs[i].Op {
+		case "CALL", "CALLCODE", "DELEGATECALL", "STATICCALL", "CREATE", "CREATE2":
+			p.enter(i)
+			if i+1 >= len(p.logs) || p.logs[i+1].Depth <= p.logs[i].Depth {
+				// The call did not execute any code, such as a call to an EOA or precompile.
+				p.exit(i + 1)
+			}
+		}
+	}
+	for len(p.frames) > 1 {
+		p.exit(len(p.logs))
+	}
+	return p.out, nil
+}
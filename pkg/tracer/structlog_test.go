@@ -0,0 +1,105 @@
+package tracer
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	slEntryPoint = common.HexToAddress("0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789")
+	slAccount    = common.HexToAddress("0xc2b78104907F722DABAc4C69f826a522B2754De4")
+	slTarget     = common.HexToAddress("0x1306b01bC3e4AD202612D3843387e94737673F53")
+)
+
+func word(hex string) string {
+	for len(hex) < 64 {
+		hex += "0"
+	}
+	return hex
+}
+
+func structLogsFixture() *StructLogResult {
+	callMem := []string{word("3a871cdd")}
+	staticMem := []string{word("12345678")}
+	retMem := []string{"000000000000000000000000000000000000000000000000000000000000002a"}
+	return &StructLogResult{StructLogs: []StructLog{
+		{Op: "CALL", Gas: 100000, Depth: 1, Memory: callMem, Stack: []string{
+			"0x0", "0x0", "0x4", "0x0", "0x0", slAccount.Hex(), "0x1000",
+		}},
+		{Op: "TIMESTAMP", Gas: 4000, GasCost: 2, Depth: 2},
+		{Op: "SLOAD", Gas: 3998, GasCost: 2100, Depth: 2, Stack: []string{"0x5"}},
+		{Op: "PUSH1", Gas: 1898, GasCost: 3, Depth: 2, Stack: []string{"0x7"}},
+		{Op: "EXTCODESIZE", Gas: 1895, GasCost: 100, Depth: 2, Stack: []string{slTarget.Hex()}},
+		{Op: "POP", Gas: 1795, GasCost: 2, Depth: 2, Stack: []string{"0x64"}},
+		{Op: "STATICCALL", Gas: 1793, GasCost: 100, Depth: 2, Memory: staticMem, Stack: []string{
+			"0x0", "0x0", "0x4", "0x0", slTarget.Hex(), "0x100",
+		}},
+		{Op: "PUSH1", Gas: 1693, GasCost: 3, Depth: 2, Stack: []string{"0x1"}},
+		{Op: "RETURN", Gas: 1690, GasCost: 0, Depth: 2, Memory: retMem, Stack: []string{"0x20", "0x0"}},
+		{Op: "POP", Gas: 90000, GasCost: 2, Depth: 1, Stack: []string{"0x1"}},
+		{Op: "LOG1", Gas: 89998, GasCost: 750, Depth: 1, Stack: []string{
+			"0x" + stopCollectingTopic.Text(16), "0x0", "0x0",
+		}},
+		{Op: "CALL", Gas: 89248, Depth: 1, Memory: callMem, Stack: []string{
+			"0x0", "0x0", "0x4", "0x0", "0x0", slTarget.Hex(), "0x1000",
+		}},
+	}}
+}
+
+// TestParseStructLogs verifies that struct logs are parsed into the same opcode, storage, and call info
+// collected by BundlerCollectorTracer.js and that collection stops after the BeforeExecution event.
+func TestParseStructLogs(t *testing.T) {
+	codeSize := func(addr common.Address) (int, error) {
+		if addr == slTarget {
+			return 100, nil
+		}
+		return 0, nil
+	}
+	res, err := ParseStructLogs(structLogsFixture(), slEntryPoint, nil, codeSize)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if len(res.CallsFromEntryPoint) != 1 {
+		t.Fatalf("got %d calls from entrypoint, want 1", len(res.CallsFromEntryPoint))
+	}
+	c := res.CallsFromEntryPoint[0]
+	if c.TopLevelTargetAddress != slAccount || c.TopLevelMethodSig.String() != "0x3a871cdd" {
+		t.Fatalf("got %s %s, want account validateUserOp", c.TopLevelTargetAddress, c.TopLevelMethodSig)
+	}
+	for _, op := range []string{"TIMESTAMP", "SLOAD", "EXTCODESIZE", "STATICCALL", "RETURN"} {
+		if c.Opcodes[op] != 1 {
+			t.Fatalf("got %v, want 1 %s", c.Opcodes, op)
+		}
+	}
+	if _, ok := c.Opcodes["PUSH1"]; ok {
+		t.Fatalf("got %v, want PUSH1 ignored", c.Opcodes)
+	}
+
+	slot := common.BigToHash(big.NewInt(5)).Hex()
+	if v := c.Access[slAccount].Reads[slot]; v != common.BigToHash(big.NewInt(7)).Hex() {
+		t.Fatalf("got %s, want loaded value 7", v)
+	}
+	if v := c.ExtCodeAccessInfo[slTarget]; v != "POP" {
+		t.Fatalf("got %s, want EXTCODESIZE without ISZERO", v)
+	}
+	if v := c.ContractSize[slTarget]; v.ContractSize != 100 || v.Opcode != "EXTCODESIZE" {
+		t.Fatalf("got %+v, want size 100 from EXTCODESIZE", v)
+	}
+
+	if len(res.Calls) != 4 {
+		t.Fatalf("got %d calls, want 4", len(res.Calls))
+	}
+	if call := res.Calls[1]; call.Type != "STATICCALL" || call.From != slAccount || call.To != slTarget ||
+		call.Method != "0x12345678" {
+		t.Fatalf("got %+v, want staticcall from account to target", call)
+	}
+	if call := res.Calls[2]; call.Type != "RETURN" {
+		t.Fatalf("got %+v, want target return", call)
+	}
+	if call := res.Calls[3]; call.Type != "RETURN" || call.Data != common.BigToHash(big.NewInt(42)).Hex() {
+		t.Fatalf("got %+v, want account return with data", call)
+	}
+}
@@ -77,6 +77,7 @@ type BundlerExecutionReturn struct {
 	ValidationOOG      bool     `json:"validationOOG"`
 	ExecutionOOG       bool     `json:"executionOOG"`
 	ExecutionGasLimit  float64  `json:"executionGasLimit"`
+	ValidationRefund   float64  `json:"validationRefund"`
 	UserOperationEvent *LogInfo `json:"userOperationEvent,omitempty"`
 	Output             string   `json:"output"`
 	Error              string   `json:"error"`
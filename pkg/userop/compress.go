@@ -0,0 +1,173 @@
+package userop
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const compressionVersion = byte(1)
+
+// ErrBadCompressedOp is returned when a compressed UserOperation cannot be decoded.
+var ErrBadCompressedOp = errors.New("userop: bad compressed op")
+
+// Dictionary is an ordered list of byte prefixes shared by all parties exchanging compressed UserOperations.
+// Prefixes are typically factory addresses with their create account selector or paymaster addresses. Any
+// initCode or paymasterAndData that starts with a prefix is encoded as a reference to it followed by the
+// remaining bytes.
+type Dictionary struct {
+	prefixes [][]byte
+}
+
+// NewDictionary returns a Dictionary with the given prefixes. The order of prefixes must be the same on both
+// ends.
+func NewDictionary(prefixes ...[]byte) *Dictionary {
+	return &Dictionary{prefixes: prefixes}
+}
+
+// ID returns a short identifier of the Dictionary. Parties can compare IDs to detect a mismatch before
+// exchanging compressed UserOperations.
+func (d *Dictionary) ID() string {
+	buf := []byte{}
+	for _, p := range d.prefixes {
+		buf = binary.AppendUvarint(buf, uint64(len(p)))
+		buf = append(buf, p...)
+	}
+	return common.Bytes2Hex(crypto.Keccak256(buf)[:4])
+}
+
+func (d *Dictionary) match(b []byte) int {
+	best := -1
+	for i, p := range d.prefixes {
+		if len(p) > 0 && bytes.HasPrefix(b, p) && (best == -1 || len(p) > len(d.prefixes[best])) {
+			best = i
+		}
+	}
+	return best
+}
+
+func appendBytes(buf []byte, b []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+func appendBig(buf []byte, n *big.Int) []byte {
+	return appendBytes(buf, n.Bytes())
+}
+
+func (d *Dictionary) appendPrefixed(buf []byte, b []byte) []byte {
+	i := d.match(b)
+	if i == -1 {
+		buf = binary.AppendUvarint(buf, 0)
+		return appendBytes(buf, b)
+	}
+
+	buf = binary.AppendUvarint(buf, uint64(i+1))
+	return appendBytes(buf, b[len(d.prefixes[i]):])
+}
+
+// Compress returns a compact binary encoding of the UserOperation. Integers are stored without padding and
+// initCode and paymasterAndData are encoded against the Dictionary.
+func (d *Dictionary) Compress(op *UserOperation) []byte {
+	buf := []byte{compressionVersion}
+	buf = append(buf, op.Sender.Bytes()...)
+	buf = appendBig(buf, op.Nonce)
+	buf = d.appendPrefixed(buf, op.InitCode)
+	buf = appendBytes(buf, op.CallData)
+	buf = appendBig(buf, op.CallGasLimit)
+	buf = appendBig(buf, op.VerificationGasLimit)
+	buf = appendBig(buf, op.PreVerificationGas)
+	buf = appendBig(buf, op.MaxFeePerGas)
+	buf = appendBig(buf, op.MaxPriorityFeePerGas)
+	buf = d.appendPrefixed(buf, op.PaymasterAndData)
+	buf = appendBytes(buf, op.Signature)
+	return buf
+}
+
+type decoder struct {
+	r *bytes.Reader
+}
+
+func (dec *decoder) bytes() ([]byte, error) {
+	n, err := binary.ReadUvarint(dec.r)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(dec.r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(dec.r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (dec *decoder) big() (*big.Int, error) {
+	b, err := dec.bytes()
+	if err != nil {
+		return nil, err
+	}
+	return big.NewInt(0).SetBytes(b), nil
+}
+
+func (dec *decoder) prefixed(d *Dictionary) ([]byte, error) {
+	i, err := binary.ReadUvarint(dec.r)
+	if err != nil {
+		return nil, err
+	}
+	rest, err := dec.bytes()
+	if err != nil {
+		return nil, err
+	}
+	if i == 0 {
+		return rest, nil
+	}
+	if i > uint64(len(d.prefixes)) {
+		return nil, fmt.Errorf("unknown dictionary entry %d", i)
+	}
+
+	return append(append([]byte{}, d.prefixes[i-1]...), rest...), nil
+}
+
+// Decompress decodes a UserOperation that was encoded with Compress using the same Dictionary.
+func (d *Dictionary) Decompress(data []byte) (*UserOperation, error) {
+	dec := &decoder{r: bytes.NewReader(data)}
+	if v, err := dec.r.ReadByte(); err != nil || v != compressionVersion {
+		return nil, fmt.Errorf("%w: unsupported version", ErrBadCompressedOp)
+	}
+	sender := make([]byte, common.AddressLength)
+	if _, err := io.ReadFull(dec.r, sender); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBadCompressedOp, err)
+	}
+
+	op := &UserOperation{Sender: common.BytesToAddress(sender)}
+	var err error
+	for _, step := range []func() error{
+		func() error { op.Nonce, err = dec.big(); return err },
+		func() error { op.InitCode, err = dec.prefixed(d); return err },
+		func() error { op.CallData, err = dec.bytes(); return err },
+		func() error { op.CallGasLimit, err = dec.big(); return err },
+		func() error { op.VerificationGasLimit, err = dec.big(); return err },
+		func() error { op.PreVerificationGas, err = dec.big(); return err },
+		func() error { op.MaxFeePerGas, err = dec.big(); return err },
+		func() error { op.MaxPriorityFeePerGas, err = dec.big(); return err },
+		func() error { op.PaymasterAndData, err = dec.prefixed(d); return err },
+		func() error { op.Signature, err = dec.bytes(); return err },
+	} {
+		if err := step(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrBadCompressedOp, err)
+		}
+	}
+	if dec.r.Len() != 0 {
+		return nil, fmt.Errorf("%w: trailing bytes", ErrBadCompressedOp)
+	}
+	return op, nil
+}
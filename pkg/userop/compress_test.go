@@ -0,0 +1,68 @@
+package userop_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// TestCompressRoundTrip verifies that a compressed UserOperation is decompressed without any changes.
+func TestCompressRoundTrip(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	op.PaymasterAndData = append(testutils.ValidAddress2.Bytes(), 0xde, 0xad)
+	dict := userop.NewDictionary(op.InitCode[:24], testutils.ValidAddress2.Bytes())
+
+	out, err := dict.Decompress(dict.Compress(op))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if !testutils.IsOpsEqual(out, op) {
+		t.Fatalf("ops not equal: %s", testutils.GetOpsDiff(out, op))
+	}
+}
+
+// TestCompressUsesDictionary verifies that matching a dictionary prefix reduces the encoded size.
+func TestCompressUsesDictionary(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	without := userop.NewDictionary().Compress(op)
+	with := userop.NewDictionary(op.InitCode[:24]).Compress(op)
+
+	if len(with) >= len(without) {
+		t.Fatalf("got %d bytes, want less than %d", len(with), len(without))
+	}
+}
+
+// TestDecompressWithMismatchedDictionary verifies that an unknown dictionary reference returns an error.
+func TestDecompressWithMismatchedDictionary(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	data := userop.NewDictionary(op.InitCode[:24]).Compress(op)
+
+	if _, err := userop.NewDictionary().Decompress(data); !errors.Is(err, userop.ErrBadCompressedOp) {
+		t.Fatalf("got %v, want ErrBadCompressedOp", err)
+	}
+}
+
+// TestDecompressTruncated verifies that truncated data returns an error.
+func TestDecompressTruncated(t *testing.T) {
+	dict := userop.NewDictionary()
+	data := dict.Compress(testutils.MockValidInitUserOp())
+
+	if _, err := dict.Decompress(data[:len(data)-1]); !errors.Is(err, userop.ErrBadCompressedOp) {
+		t.Fatalf("got %v, want ErrBadCompressedOp", err)
+	}
+}
+
+// TestDictionaryID verifies that dictionaries with different prefixes have different IDs.
+func TestDictionaryID(t *testing.T) {
+	a := userop.NewDictionary(testutils.ValidAddress1.Bytes())
+	b := userop.NewDictionary(testutils.ValidAddress2.Bytes())
+
+	if a.ID() == b.ID() {
+		t.Fatalf("got equal IDs %s, want different", a.ID())
+	}
+	if a.ID() != userop.NewDictionary(testutils.ValidAddress1.Bytes()).ID() {
+		t.Fatal("got different IDs for the same prefixes")
+	}
+}
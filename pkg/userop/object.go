@@ -2,6 +2,7 @@
 package userop
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"math/big"
@@ -37,6 +38,9 @@ var (
 
 	// UserOpArr is the ABI type for an array of UserOperations.
 	UserOpArr, _ = abi.NewType("tuple[]", "ops", UserOpPrimitives)
+
+	nonceSequenceBits = uint(64)
+	nonceSequenceMask = big.NewInt(0).Sub(big.NewInt(0).Lsh(common.Big1, nonceSequenceBits), common.Big1)
 )
 
 // UserOperation represents an EIP-4337 style transaction for a smart contract account.
@@ -83,6 +87,18 @@ func (op *UserOperation) GetFactoryData() []byte {
 	return op.InitCode[common.AddressLength:]
 }
 
+// GetNonceKey returns the key portion of a 2D nonce. This is the upper 192 bits of the nonce value and allows a
+// sender to have multiple parallel nonce sequences.
+func (op *UserOperation) GetNonceKey() *big.Int {
+	return big.NewInt(0).Rsh(op.Nonce, nonceSequenceBits)
+}
+
+// GetNonceSequence returns the sequence portion of a 2D nonce. This is the lower 64 bits of the nonce value
+// and must be incremented in order within each nonce key.
+func (op *UserOperation) GetNonceSequence() *big.Int {
+	return big.NewInt(0).And(op.Nonce, nonceSequenceMask)
+}
+
 // GetMaxGasAvailable returns the max amount of gas that can be consumed by this UserOperation.
 func (op *UserOperation) GetMaxGasAvailable() *big.Int {
 	// TODO: Multiplier logic might change in v0.7
@@ -185,6 +201,12 @@ func (op *UserOperation) PackForSignature() []byte {
 	return packed
 }
 
+// IsSameIntent returns true if both UserOperations are equal in every field except the signature. Ops with the
+// same intent will also have the same userOpHash.
+func (op *UserOperation) IsSameIntent(other *UserOperation) bool {
+	return bytes.Equal(op.PackForSignature(), other.PackForSignature())
+}
+
 // GetUserOpHash returns the hash of the userOp + entryPoint address + chainID.
 func (op *UserOperation) GetUserOpHash(entryPoint common.Address, chainID *big.Int) common.Hash {
 	return crypto.Keccak256Hash(
@@ -4,6 +4,7 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
 )
 
@@ -48,3 +49,19 @@ func TestUserOperationGetGasPriceNilBF(t *testing.T) {
 		t.Fatalf("got %d, want %d", op.GetDynamicGasPrice(nil).Int64(), op.MaxPriorityFeePerGas)
 	}
 }
+
+// TestUserOperationIsSameIntent verifies that (*UserOperation).IsSameIntent ignores the signature field but
+// not any other field.
+func TestUserOperationIsSameIntent(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	other := testutils.MockValidInitUserOp()
+	other.Signature = []byte{1, 2, 3}
+	if !op.IsSameIntent(other) {
+		t.Fatal("got false, want true")
+	}
+
+	other.Nonce = big.NewInt(0).Add(op.Nonce, common.Big1)
+	if op.IsSameIntent(other) {
+		t.Fatal("got true, want false")
+	}
+}
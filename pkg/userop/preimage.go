@@ -0,0 +1,91 @@
+package userop
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// EntryPointV06 is the EntryPoint version that userOpHashes are derived for. It is the only version
+// supported by the bundler.
+const EntryPointV06 = "v0.6"
+
+// PreimageField is a single ABI encoded value of a packed UserOperation. Dynamic fields are replaced by the
+// keccak256 hash of their bytes before packing. For those, Preimage holds the original bytes.
+type PreimageField struct {
+	Name     string        `json:"name"`
+	Type     string        `json:"type"`
+	Value    string        `json:"value"`
+	Preimage hexutil.Bytes `json:"preimage,omitempty"`
+}
+
+// Preimage is every intermediate value used to derive a userOpHash so that a signature mismatch can be traced
+// to the exact field that differs. The userOpHash is computed as:
+//
+//	userOpHash = keccak256(abi.encode(keccak256(packedUserOp), entryPoint, chainId))
+type Preimage struct {
+	EntryPointVersion string           `json:"entryPointVersion"`
+	EntryPoint        common.Address   `json:"entryPoint"`
+	ChainID           *hexutil.Big     `json:"chainId"`
+	Fields            []*PreimageField `json:"fields"`
+	PackedUserOp      hexutil.Bytes    `json:"packedUserOp"`
+	PackedUserOpHash  common.Hash      `json:"packedUserOpHash"`
+	Encoded           hexutil.Bytes    `json:"encoded"`
+	UserOpHash        common.Hash      `json:"userOpHash"`
+}
+
+// GetUserOpHashPreimage returns the Preimage of the userOpHash for the given EntryPoint version. An error is
+// returned for any version other than EntryPointV06.
+func (op *UserOperation) GetUserOpHashPreimage(
+	entryPoint common.Address,
+	chainID *big.Int,
+	version string,
+) (*Preimage, error) {
+	if version != EntryPointV06 {
+		return nil, fmt.Errorf("entryPointVersion: %s not supported, must be %s", version, EntryPointV06)
+	}
+
+	hashed := func(name string, b []byte) *PreimageField {
+		return &PreimageField{
+			Name:     name,
+			Type:     bytes32.String(),
+			Value:    crypto.Keccak256Hash(b).Hex(),
+			Preimage: b,
+		}
+	}
+	num := func(name string, n *big.Int) *PreimageField {
+		return &PreimageField{Name: name, Type: uint256.String(), Value: hexutil.EncodeBig(n)}
+	}
+	packed := op.PackForSignature()
+	encoded, _ := abi.Arguments{{Type: bytes32}, {Type: address}, {Type: uint256}}.Pack(
+		crypto.Keccak256Hash(packed),
+		entryPoint,
+		chainID,
+	)
+
+	return &Preimage{
+		EntryPointVersion: version,
+		EntryPoint:        entryPoint,
+		ChainID:           (*hexutil.Big)(chainID),
+		Fields: []*PreimageField{
+			{Name: "sender", Type: address.String(), Value: op.Sender.Hex()},
+			num("nonce", op.Nonce),
+			hashed("hashInitCode", op.InitCode),
+			hashed("hashCallData", op.CallData),
+			num("callGasLimit", op.CallGasLimit),
+			num("verificationGasLimit", op.VerificationGasLimit),
+			num("preVerificationGas", op.PreVerificationGas),
+			num("maxFeePerGas", op.MaxFeePerGas),
+			num("maxPriorityFeePerGas", op.MaxPriorityFeePerGas),
+			hashed("hashPaymasterAndData", op.PaymasterAndData),
+		},
+		PackedUserOp:     packed,
+		PackedUserOpHash: crypto.Keccak256Hash(packed),
+		Encoded:          encoded,
+		UserOpHash:       crypto.Keccak256Hash(encoded),
+	}, nil
+}
@@ -0,0 +1,40 @@
+package userop_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// TestGetUserOpHashPreimage verifies that the preimage of a v0.6 userOpHash hashes to the same value as
+// (*UserOperation).GetUserOpHash.
+func TestGetUserOpHashPreimage(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	ep := testutils.ValidAddress1
+
+	p, err := op.GetUserOpHashPreimage(ep, testutils.ChainID, userop.EntryPointV06)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if want := op.GetUserOpHash(ep, testutils.ChainID); p.UserOpHash != want {
+		t.Fatalf("got %s, want %s", p.UserOpHash, want)
+	}
+	if !bytes.Equal(p.PackedUserOp, op.PackForSignature()) {
+		t.Fatalf("got %x, want %x", p.PackedUserOp, op.PackForSignature())
+	}
+	if len(p.Fields) != 10 || !bytes.Equal(p.Fields[2].Preimage, op.InitCode) {
+		t.Fatalf("got %v, want 10 fields with initCode preimage", p.Fields)
+	}
+}
+
+// TestGetUserOpHashPreimageUnsupportedVersion verifies that an error is returned for an unsupported EntryPoint
+// version.
+func TestGetUserOpHashPreimageUnsupportedVersion(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+
+	if _, err := op.GetUserOpHashPreimage(testutils.ValidAddress1, testutils.ChainID, "v0.7"); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
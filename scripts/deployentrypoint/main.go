@@ -0,0 +1,261 @@
+// Use this for deterministically deploying the EntryPoint and optional test contracts to a local or QNG devnet.
+// Contracts are deployed with CREATE2 through the deterministic deployment proxy so that repeated runs against
+// the same chain resolve to the same addresses. The supported EntryPoint in .env is updated on success.
+//
+// Compiled artifacts are read from disk (e.g. from the @account-abstraction/contracts package) and can be in
+// either the Hardhat ("bytecode": "0x...") or Foundry ("bytecode": {"object": "0x..."}) format.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/viper"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+)
+
+var (
+	// DeterministicDeployer is the canonical address of the deterministic deployment proxy.
+	DeterministicDeployer = common.HexToAddress("0x4e59b44847b379578588920ca78fbf26c0b4956c")
+
+	// deployerRuntimeCode is the runtime code of the deterministic deployment proxy. It is used to deploy a copy
+	// of the proxy from the bundler's EOA on chains where the canonical one does not exist.
+	deployerRuntimeCode = hexutil.MustDecode(
+		"0x7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe03601600081602082378035828234f5801515" +
+			"6039578182fd5b8082525050506014600cf3",
+	)
+
+	waitTimeout = 2 * time.Minute
+)
+
+type artifact struct {
+	Bytecode json.RawMessage `json:"bytecode"`
+}
+
+func readBytecode(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var a artifact
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, err
+	}
+	var hex string
+	if err := json.Unmarshal(a.Bytecode, &hex); err != nil {
+		var obj struct {
+			Object string `json:"object"`
+		}
+		if err := json.Unmarshal(a.Bytecode, &obj); err != nil {
+			return nil, fmt.Errorf("%s: bytecode not found", path)
+		}
+		hex = obj.Object
+	}
+	return hexutil.Decode(hex)
+}
+
+// withArgs appends ABI encoded address arguments to the creation code of a contract.
+func withArgs(code []byte, args ...common.Address) []byte {
+	out := append([]byte{}, code...)
+	for _, arg := range args {
+		out = append(out, common.LeftPadBytes(arg.Bytes(), 32)...)
+	}
+	return out
+}
+
+// proxyInitCode returns the creation code for a copy of the deterministic deployment proxy. It returns the
+// runtime code appended to it.
+func proxyInitCode() []byte {
+	return append(
+		[]byte{0x60, byte(len(deployerRuntimeCode)), 0x80, 0x60, 0x0b, 0x60, 0x00, 0x39, 0x60, 0x00, 0xf3},
+		deployerRuntimeCode...,
+	)
+}
+
+type deployer struct {
+	eth     *ethclient.Client
+	eoa     *signer.EOA
+	chainID *big.Int
+	proxy   common.Address
+}
+
+func (d *deployer) send(to *common.Address, data []byte) (*types.Receipt, error) {
+	ctx := context.Background()
+	nonce, err := d.eth.PendingNonceAt(ctx, d.eoa.Address)
+	if err != nil {
+		return nil, err
+	}
+	gp, err := d.eth.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tx := &types.LegacyTx{Nonce: nonce, GasPrice: gp, To: to, Data: data}
+	gas, err := d.eth.EstimateGas(ctx, ethereum.CallMsg{
+		From:     d.eoa.Address,
+		To:       to,
+		GasPrice: gp,
+		Data:     data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	tx.Gas = gas
+
+	signed, err := types.SignNewTx(d.eoa.PrivateKey, types.LatestSignerForChainID(d.chainID), tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.eth.SendTransaction(ctx, signed); err != nil {
+		return nil, err
+	}
+
+	wctx, cancel := context.WithTimeout(ctx, waitTimeout)
+	defer cancel()
+	receipt, err := bind.WaitMined(wctx, d.eth, signed)
+	if err != nil {
+		return nil, err
+	} else if receipt.Status == types.ReceiptStatusFailed {
+		return nil, fmt.Errorf("transaction %s failed", signed.Hash())
+	}
+	return receipt, nil
+}
+
+func (d *deployer) hasCode(addr common.Address) (bool, error) {
+	code, err := d.eth.CodeAt(context.Background(), addr, nil)
+	if err != nil {
+		return false, err
+	}
+	return len(code) > 0, nil
+}
+
+// initProxy uses the canonical deterministic deployment proxy if it exists. Otherwise a copy of it is deployed
+// from the EOA.
+func (d *deployer) initProxy() error {
+	ok, err := d.hasCode(DeterministicDeployer)
+	if err != nil {
+		return err
+	} else if ok {
+		d.proxy = DeterministicDeployer
+		return nil
+	}
+
+	receipt, err := d.send(nil, proxyInitCode())
+	if err != nil {
+		return err
+	}
+	d.proxy = receipt.ContractAddress
+	fmt.Printf("Canonical deployment proxy not found, deployed a copy at %s\n", d.proxy)
+	return nil
+}
+
+// deploy creates a contract with CREATE2 through the deployment proxy. It is a no-op if the contract already
+// exists at the expected address.
+func (d *deployer) deploy(name string, initCode []byte, salt common.Hash) (common.Address, error) {
+	addr := crypto.CreateAddress2(d.proxy, salt, crypto.Keccak256(initCode))
+	if ok, err := d.hasCode(addr); err != nil {
+		return addr, err
+	} else if ok {
+		fmt.Printf("%s already deployed at %s\n", name, addr)
+		return addr, nil
+	}
+
+	if _, err := d.send(&d.proxy, append(salt.Bytes(), initCode...)); err != nil {
+		return addr, fmt.Errorf("%s: %w", name, err)
+	}
+	if ok, err := d.hasCode(addr); err != nil {
+		return addr, err
+	} else if !ok {
+		return addr, fmt.Errorf("%s: no code at %s after deployment", name, addr)
+	}
+	fmt.Printf("%s deployed at %s\n", name, addr)
+	return addr, nil
+}
+
+func main() {
+	epPath := flag.String("entrypoint", "", "Required. Path to the EntryPoint artifact.")
+	factoryPath := flag.String("factory", "", "Path to a SimpleAccountFactory artifact. Constructed with the EntryPoint.")
+	paymasterPath := flag.String(
+		"paymaster",
+		"",
+		"Path to a VerifyingPaymaster artifact. Constructed with the EntryPoint and the bundler EOA as signer.",
+	)
+	saltHex := flag.String("salt", "0x0", "CREATE2 salt used for all contracts.")
+	flag.Parse()
+	if *epPath == "" {
+		log.Fatal("fatal flag error: -entrypoint is required")
+	}
+	salt := common.HexToHash(*saltHex)
+
+	viper.SetConfigName(".env")
+	viper.SetConfigType("env")
+	viper.AddConfigPath(".")
+	if err := viper.ReadInConfig(); err != nil {
+		panic(fmt.Errorf("fatal error config file: %w", err))
+	}
+
+	eoa, err := signer.New(viper.GetString("erc4337_bundler_private_key"))
+	if err != nil {
+		panic(fmt.Errorf("fatal signer error: %w", err))
+	}
+	eth, err := ethclient.Dial(viper.GetString("erc4337_bundler_eth_client_url"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	chainID, err := eth.ChainID(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	d := &deployer{eth: eth, eoa: eoa, chainID: chainID}
+	if err := d.initProxy(); err != nil {
+		log.Fatal(err)
+	}
+
+	code, err := readBytecode(*epPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ep, err := d.deploy("EntryPoint", code, salt)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *factoryPath != "" {
+		code, err := readBytecode(*factoryPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if _, err := d.deploy("SimpleAccountFactory", withArgs(code, ep), salt); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *paymasterPath != "" {
+		code, err := readBytecode(*paymasterPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if _, err := d.deploy("VerifyingPaymaster", withArgs(code, ep, eoa.Address), salt); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	viper.Set("ERC4337_BUNDLER_SUPPORTED_ENTRY_POINTS", ep.String())
+	if err := viper.WriteConfigAs(".env"); err != nil {
+		panic(fmt.Errorf("fatal error config file: %w", err))
+	}
+	fmt.Printf("Updated ERC4337_BUNDLER_SUPPORTED_ENTRY_POINTS in .env to %s\n", ep)
+}
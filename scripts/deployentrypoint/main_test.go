@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+)
+
+func writeArtifact(t *testing.T, data string) string {
+	path := filepath.Join(t.TempDir(), "artifact.json")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestReadBytecode verifies that bytecode is read from both Hardhat and Foundry artifacts.
+func TestReadBytecode(t *testing.T) {
+	for _, data := range []string{
+		`{"bytecode": "0x6080"}`,
+		`{"bytecode": {"object": "0x6080"}}`,
+	} {
+		code, err := readBytecode(writeArtifact(t, data))
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		if !bytes.Equal(code, []byte{0x60, 0x80}) {
+			t.Fatalf("got %x, want 6080", code)
+		}
+	}
+
+	if _, err := readBytecode(writeArtifact(t, `{"abi": []}`)); err == nil {
+		t.Fatal("got nil, want error")
+	}
+}
+
+// TestWithArgs verifies that address arguments are ABI encoded after the creation code.
+func TestWithArgs(t *testing.T) {
+	a := common.HexToAddress("0x01")
+	b := common.HexToAddress("0x02")
+	code := withArgs([]byte{0x60, 0x80}, a, b)
+	if len(code) != 2+64 || code[2+31] != 0x01 || code[2+63] != 0x02 {
+		t.Fatalf("got %x, want code with 2 padded addresses", code)
+	}
+}
+
+// TestProxyInitCode verifies that the creation code for the deployment proxy copies and returns exactly the
+// runtime code appended to it.
+func TestProxyInitCode(t *testing.T) {
+	code := proxyInitCode()
+	// PUSH1 size DUP1 PUSH1 offset PUSH1 0 CODECOPY PUSH1 0 RETURN
+	size, offset := int(code[1]), int(code[4])
+	if offset != len(code)-len(deployerRuntimeCode) {
+		t.Fatalf("got offset %d, want %d", offset, len(code)-len(deployerRuntimeCode))
+	}
+	if size != len(deployerRuntimeCode) || !bytes.Equal(code[offset:offset+size], deployerRuntimeCode) {
+		t.Fatalf("got %x, want %x", code[offset:], deployerRuntimeCode)
+	}
+}
+
+// TestDeploySkipsExistingContract verifies that a contract is not deployed again if code already exists at its
+// CREATE2 address.
+func TestDeploySkipsExistingContract(t *testing.T) {
+	srv := testutils.RpcMock(testutils.MethodMocks{"eth_getCode": "0x6080"})
+	defer srv.Close()
+	eth, err := ethclient.Dial(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer eth.Close()
+
+	d := &deployer{eth: eth, proxy: DeterministicDeployer}
+	initCode := []byte{0x60, 0x80}
+	salt := common.HexToHash("0x01")
+	addr, err := d.deploy("Test", initCode, salt)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if want := crypto.CreateAddress2(DeterministicDeployer, salt, crypto.Keccak256(initCode)); addr != want {
+		t.Fatalf("got %s, want %s", addr, want)
+	}
+}